@@ -0,0 +1,179 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package cbor implements a CBOR encoding of Cadence values.
+//
+// Values are encoded like the interpreter encodes them for storage,
+// using the same CBOR tags, see the CBORTag* constants of the interpreter package.
+//
+// The interpreter stores containers (arrays, dictionaries, and composites) in separate slabs,
+// so their storage encoding is not self-contained.
+// This encoding encodes containers inline instead:
+// Arrays are encoded as a tagged array of the array type and the elements,
+// and dictionaries are encoded as a tagged array of the dictionary type and the keys and values, alternating.
+// The tags are the ones the interpreter used for arrays and dictionaries
+// before it stored them in separate slabs.
+// Composites are encoded like the interpreter encodes their type information
+// (location, qualified identifier, and kind), followed by an array of the field names, types, and values.
+//
+// Types are encoded like the interpreter encodes static types.
+// Composite types are encoded like the interpreter encodes the type information of composites,
+// as their static types do not include the kind of the composite.
+// Only the composite types of composite values include their fields.
+// Interface, restricted, and function types are not supported.
+//
+// Type values, capabilities, links, and functions are not supported.
+//
+package cbor
+
+import (
+	"fmt"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/cadence/runtime"
+	"github.com/onflow/cadence/runtime/interpreter"
+)
+
+// CBOR tags of the containers, which have no tags in the storage encoding.
+// The interpreter used them before it stored containers in separate slabs,
+// and reserves them, see the placeholders following interpreter.CBORTagVoidValue.
+//
+const (
+	cborTagDictionaryValue = interpreter.CBORTagBase + 1
+	cborTagArrayValue      = interpreter.CBORTagBase + 6
+)
+
+// NOTE: NEVER change, only add/increment; ensure uint64
+const (
+	// encodedContainerValueTypeFieldKey     uint64 = 0
+	// encodedContainerValueElementsFieldKey uint64 = 1
+
+	// !!! *WARNING* !!!
+	//
+	// encodedContainerValueLength MUST be updated when new element is added.
+	// It is used to verify encoded array and dictionary length during decoding.
+	encodedContainerValueLength = 2
+)
+
+// NOTE: NEVER change, only add/increment; ensure uint64
+const (
+	// encodedCompositeValueLocationFieldKey            uint64 = 0
+	// encodedCompositeValueQualifiedIdentifierFieldKey uint64 = 1
+	// encodedCompositeValueKindFieldKey                uint64 = 2
+	// encodedCompositeValueFieldsFieldKey              uint64 = 3
+
+	// !!! *WARNING* !!!
+	//
+	// encodedCompositeValueLength MUST be updated when new element is added.
+	// It is used to verify encoded composite length during decoding.
+	encodedCompositeValueLength = 4
+
+	// encodedCompositeTypeLength is the length of the encoded type information of composites,
+	// i.e. the location, qualified identifier, and kind
+	encodedCompositeTypeLength = 3
+)
+
+// primitiveTypes are the types which are encoded as primitive static types
+//
+var primitiveTypes = []cadence.Type{
+	cadence.AnyType{},
+	cadence.AnyStructType{},
+	cadence.AnyResourceType{},
+	cadence.MetaType{},
+	cadence.VoidType{},
+	cadence.NeverType{},
+	cadence.BoolType{},
+	cadence.StringType{},
+	cadence.CharacterType{},
+	cadence.AddressType{},
+	cadence.NumberType{},
+	cadence.SignedNumberType{},
+	cadence.IntegerType{},
+	cadence.SignedIntegerType{},
+	cadence.FixedPointType{},
+	cadence.SignedFixedPointType{},
+	cadence.IntType{},
+	cadence.Int8Type{},
+	cadence.Int16Type{},
+	cadence.Int32Type{},
+	cadence.Int64Type{},
+	cadence.Int128Type{},
+	cadence.Int256Type{},
+	cadence.UIntType{},
+	cadence.UInt8Type{},
+	cadence.UInt16Type{},
+	cadence.UInt32Type{},
+	cadence.UInt64Type{},
+	cadence.UInt128Type{},
+	cadence.UInt256Type{},
+	cadence.Word8Type{},
+	cadence.Word16Type{},
+	cadence.Word32Type{},
+	cadence.Word64Type{},
+	cadence.Fix64Type{},
+	cadence.UFix64Type{},
+	cadence.BlockType{},
+	cadence.CapabilityPathType{},
+	cadence.StoragePathType{},
+	cadence.PublicPathType{},
+	cadence.PrivatePathType{},
+	cadence.AccountKeyType{},
+	cadence.AuthAccountContractsType{},
+	cadence.AuthAccountKeysType{},
+	cadence.AuthAccountType{},
+	cadence.PublicAccountContractsType{},
+	cadence.PublicAccountKeysType{},
+	cadence.PublicAccountType{},
+	cadence.DeployedContractType{},
+}
+
+var primitiveStaticTypes = map[cadence.Type]interpreter.PrimitiveStaticType{}
+
+var primitiveTypesByStaticType = map[interpreter.PrimitiveStaticType]cadence.Type{}
+
+func init() {
+	for _, primitiveType := range primitiveTypes {
+		staticType := runtime.ImportType(primitiveType).(interpreter.PrimitiveStaticType)
+		primitiveStaticTypes[primitiveType] = staticType
+		primitiveTypesByStaticType[staticType] = primitiveType
+	}
+}
+
+var encMode = interpreter.CBOREncMode
+
+var decMode = interpreter.CBORDecMode
+
+// UnsupportedValueError is returned when a value cannot be encoded.
+//
+type UnsupportedValueError struct {
+	Value interface{}
+}
+
+func (e UnsupportedValueError) Error() string {
+	return fmt.Sprintf("cannot encode value of type %T", e.Value)
+}
+
+// UnsupportedTypeError is returned when a type cannot be encoded.
+//
+type UnsupportedTypeError struct {
+	Type cadence.Type
+}
+
+func (e UnsupportedTypeError) Error() string {
+	return fmt.Sprintf("cannot encode type %s", e.Type.ID())
+}
@@ -0,0 +1,729 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cbor
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/fxamacker/cbor/v2"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/cadence/runtime/interpreter"
+)
+
+// Decode returns a Cadence value decoded from its CBOR-encoded representation.
+//
+// This function returns an error if the bytes are not a valid encoding of a Cadence value.
+//
+func Decode(b []byte) (cadence.Value, error) {
+	dec := decMode.NewByteStreamDecoder(b)
+
+	value, err := decodeValue(dec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode value: %w", err)
+	}
+
+	if dec.NumBytesDecoded() != len(b) {
+		return nil, fmt.Errorf(
+			"failed to decode value: %d trailing bytes",
+			len(b)-dec.NumBytesDecoded(),
+		)
+	}
+
+	return value, nil
+}
+
+func decodeValue(dec *cbor.StreamDecoder) (cadence.Value, error) {
+	t, err := dec.NextType()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t {
+	case cbor.BoolType:
+		v, err := dec.DecodeBool()
+		if err != nil {
+			return nil, err
+		}
+		return cadence.NewBool(v), nil
+
+	case cbor.NilType:
+		err := dec.DecodeNil()
+		if err != nil {
+			return nil, err
+		}
+		return cadence.NewOptional(nil), nil
+
+	case cbor.TagType:
+		// handled below
+
+	default:
+		return nil, fmt.Errorf("invalid value encoding: unsupported CBOR type %s", t)
+	}
+
+	num, err := dec.DecodeTagNumber()
+	if err != nil {
+		return nil, err
+	}
+
+	switch num {
+	case interpreter.CBORTagVoidValue:
+		err := dec.DecodeNil()
+		if err != nil {
+			return nil, err
+		}
+		return cadence.NewVoid(), nil
+
+	case interpreter.CBORTagSomeValue:
+		value, err := decodeValue(dec)
+		if err != nil {
+			return nil, err
+		}
+		return cadence.NewOptional(value), nil
+
+	case interpreter.CBORTagStringValue:
+		v, err := dec.DecodeString()
+		if err != nil {
+			return nil, err
+		}
+		return cadence.NewString(v)
+
+	case interpreter.CBORTagAddressValue:
+		return decodeAddress(dec)
+
+	case interpreter.CBORTagIntValue:
+		v, err := dec.DecodeBigInt()
+		if err != nil {
+			return nil, err
+		}
+		return cadence.NewIntFromBig(v), nil
+
+	case interpreter.CBORTagInt8Value:
+		v, err := decodeInt64(dec, math.MinInt8, math.MaxInt8)
+		return cadence.Int8(v), err
+
+	case interpreter.CBORTagInt16Value:
+		v, err := decodeInt64(dec, math.MinInt16, math.MaxInt16)
+		return cadence.Int16(v), err
+
+	case interpreter.CBORTagInt32Value:
+		v, err := decodeInt64(dec, math.MinInt32, math.MaxInt32)
+		return cadence.Int32(v), err
+
+	case interpreter.CBORTagInt64Value:
+		v, err := decodeInt64(dec, math.MinInt64, math.MaxInt64)
+		return cadence.Int64(v), err
+
+	case interpreter.CBORTagInt128Value:
+		v, err := dec.DecodeBigInt()
+		if err != nil {
+			return nil, err
+		}
+		return cadence.NewInt128FromBig(v)
+
+	case interpreter.CBORTagInt256Value:
+		v, err := dec.DecodeBigInt()
+		if err != nil {
+			return nil, err
+		}
+		return cadence.NewInt256FromBig(v)
+
+	case interpreter.CBORTagUIntValue:
+		v, err := dec.DecodeBigInt()
+		if err != nil {
+			return nil, err
+		}
+		return cadence.NewUIntFromBig(v)
+
+	case interpreter.CBORTagUInt8Value:
+		v, err := decodeUint64(dec, math.MaxUint8)
+		return cadence.UInt8(v), err
+
+	case interpreter.CBORTagUInt16Value:
+		v, err := decodeUint64(dec, math.MaxUint16)
+		return cadence.UInt16(v), err
+
+	case interpreter.CBORTagUInt32Value:
+		v, err := decodeUint64(dec, math.MaxUint32)
+		return cadence.UInt32(v), err
+
+	case interpreter.CBORTagUInt64Value:
+		v, err := decodeUint64(dec, math.MaxUint64)
+		return cadence.UInt64(v), err
+
+	case interpreter.CBORTagUInt128Value:
+		v, err := dec.DecodeBigInt()
+		if err != nil {
+			return nil, err
+		}
+		return cadence.NewUInt128FromBig(v)
+
+	case interpreter.CBORTagUInt256Value:
+		v, err := dec.DecodeBigInt()
+		if err != nil {
+			return nil, err
+		}
+		return cadence.NewUInt256FromBig(v)
+
+	case interpreter.CBORTagWord8Value:
+		v, err := decodeUint64(dec, math.MaxUint8)
+		return cadence.Word8(v), err
+
+	case interpreter.CBORTagWord16Value:
+		v, err := decodeUint64(dec, math.MaxUint16)
+		return cadence.Word16(v), err
+
+	case interpreter.CBORTagWord32Value:
+		v, err := decodeUint64(dec, math.MaxUint32)
+		return cadence.Word32(v), err
+
+	case interpreter.CBORTagWord64Value:
+		v, err := decodeUint64(dec, math.MaxUint64)
+		return cadence.Word64(v), err
+
+	case interpreter.CBORTagFix64Value:
+		v, err := decodeInt64(dec, math.MinInt64, math.MaxInt64)
+		return cadence.Fix64(v), err
+
+	case interpreter.CBORTagUFix64Value:
+		v, err := decodeUint64(dec, math.MaxUint64)
+		return cadence.UFix64(v), err
+
+	case interpreter.CBORTagPathValue:
+		return decodePath(dec)
+
+	case cborTagArrayValue:
+		return decodeArray(dec)
+
+	case cborTagDictionaryValue:
+		return decodeDictionary(dec)
+
+	case interpreter.CBORTagCompositeValue:
+		return decodeComposite(dec)
+
+	default:
+		return nil, fmt.Errorf("invalid value encoding: unsupported tag %d", num)
+	}
+}
+
+func decodeInt64(dec *cbor.StreamDecoder, min, max int64) (int64, error) {
+	v, err := dec.DecodeInt64()
+	if err != nil {
+		return 0, err
+	}
+
+	if v < min || v > max {
+		return 0, fmt.Errorf("invalid integer encoding: %d is out of range", v)
+	}
+
+	return v, nil
+}
+
+func decodeUint64(dec *cbor.StreamDecoder, max uint64) (uint64, error) {
+	v, err := dec.DecodeUint64()
+	if err != nil {
+		return 0, err
+	}
+
+	if v > max {
+		return 0, fmt.Errorf("invalid integer encoding: %d is out of range", v)
+	}
+
+	return v, nil
+}
+
+func decodeAddress(dec *cbor.StreamDecoder) (cadence.Value, error) {
+	addressBytes, err := dec.DecodeBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(addressBytes) > common.AddressLength {
+		return nil, fmt.Errorf(
+			"invalid address length: got %d, expected max %d",
+			len(addressBytes),
+			common.AddressLength,
+		)
+	}
+
+	return cadence.BytesToAddress(addressBytes), nil
+}
+
+func decodePath(dec *cbor.StreamDecoder) (cadence.Value, error) {
+	size, err := dec.DecodeArrayHead()
+	if err != nil {
+		return nil, err
+	}
+
+	if size != 2 {
+		return nil, fmt.Errorf("invalid path encoding: expected 2 elements, got %d", size)
+	}
+
+	domainNumber, err := dec.DecodeUint64()
+	if err != nil {
+		return nil, err
+	}
+
+	domain := common.PathDomainUnknown
+	for _, pathDomain := range common.AllPathDomains {
+		if uint64(pathDomain) == domainNumber {
+			domain = pathDomain
+		}
+	}
+
+	if domain == common.PathDomainUnknown {
+		return nil, fmt.Errorf("invalid path domain: %d", domainNumber)
+	}
+
+	identifier, err := dec.DecodeString()
+	if err != nil {
+		return nil, err
+	}
+
+	return cadence.Path{
+		Domain:     domain.Identifier(),
+		Identifier: identifier,
+	}, nil
+}
+
+// decodeContainer decodes the type and the elements of an array or dictionary
+//
+func decodeContainer(dec *cbor.StreamDecoder) (cadence.Type, []cadence.Value, error) {
+	size, err := dec.DecodeArrayHead()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if size != encodedContainerValueLength {
+		return nil, nil, fmt.Errorf(
+			"invalid container encoding: expected %d elements, got %d",
+			encodedContainerValueLength,
+			size,
+		)
+	}
+
+	// Decode type at array index encodedContainerValueTypeFieldKey
+	containerType, err := decodeType(dec)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Decode elements at array index encodedContainerValueElementsFieldKey
+	elementsSize, err := dec.DecodeArrayHead()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	values := make([]cadence.Value, elementsSize)
+
+	for i := range values {
+		values[i], err = decodeValue(dec)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return containerType, values, nil
+}
+
+func decodeArray(dec *cbor.StreamDecoder) (cadence.Value, error) {
+	typ, values, err := decodeContainer(dec)
+	if err != nil {
+		return nil, err
+	}
+
+	array := cadence.NewArray(values)
+
+	if typ != nil {
+		arrayType, ok := typ.(cadence.ArrayType)
+		if !ok {
+			return nil, fmt.Errorf("invalid array encoding: invalid type %s", typ.ID())
+		}
+		array = array.WithType(arrayType)
+	}
+
+	return array, nil
+}
+
+func decodeDictionary(dec *cbor.StreamDecoder) (cadence.Value, error) {
+	typ, values, err := decodeContainer(dec)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(values)%2 != 0 {
+		return nil, fmt.Errorf("invalid dictionary encoding: odd number of keys and values")
+	}
+
+	pairs := make([]cadence.KeyValuePair, 0, len(values)/2)
+
+	for i := 0; i < len(values); i += 2 {
+		pairs = append(
+			pairs,
+			cadence.KeyValuePair{
+				Key:   values[i],
+				Value: values[i+1],
+			},
+		)
+	}
+
+	dictionary := cadence.NewDictionary(pairs)
+
+	if typ != nil {
+		dictionaryType, ok := typ.(cadence.DictionaryType)
+		if !ok {
+			return nil, fmt.Errorf("invalid dictionary encoding: invalid type %s", typ.ID())
+		}
+		dictionary = dictionary.WithType(dictionaryType)
+	}
+
+	return dictionary, nil
+}
+
+func decodeComposite(dec *cbor.StreamDecoder) (cadence.Value, error) {
+	size, err := dec.DecodeArrayHead()
+	if err != nil {
+		return nil, err
+	}
+
+	if size != encodedCompositeValueLength {
+		return nil, fmt.Errorf(
+			"invalid composite encoding: expected %d elements, got %d",
+			encodedCompositeValueLength,
+			size,
+		)
+	}
+
+	location, qualifiedIdentifier, kind, err := decodeCompositeTypeInfo(dec)
+	if err != nil {
+		return nil, err
+	}
+
+	// Decode fields at array index encodedCompositeValueFieldsFieldKey
+	fieldsSize, err := dec.DecodeArrayHead()
+	if err != nil {
+		return nil, err
+	}
+
+	if fieldsSize%3 != 0 {
+		return nil, fmt.Errorf("invalid composite encoding: fields are not triples of names, types, and values")
+	}
+
+	fieldValues := make([]cadence.Value, 0, fieldsSize/3)
+	fieldTypes := make([]cadence.Field, 0, fieldsSize/3)
+
+	for i := uint64(0); i < fieldsSize; i += 3 {
+		name, err := dec.DecodeString()
+		if err != nil {
+			return nil, err
+		}
+
+		fieldType, err := decodeType(dec)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := decodeValue(dec)
+		if err != nil {
+			return nil, err
+		}
+
+		fieldValues = append(fieldValues, value)
+		fieldTypes = append(
+			fieldTypes,
+			cadence.Field{
+				Identifier: name,
+				Type:       fieldType,
+			},
+		)
+	}
+
+	switch kind {
+	case common.CompositeKindStructure:
+		return cadence.NewStruct(fieldValues).WithType(&cadence.StructType{
+			Location:            location,
+			QualifiedIdentifier: qualifiedIdentifier,
+			Fields:              fieldTypes,
+		}), nil
+
+	case common.CompositeKindResource:
+		return cadence.NewResource(fieldValues).WithType(&cadence.ResourceType{
+			Location:            location,
+			QualifiedIdentifier: qualifiedIdentifier,
+			Fields:              fieldTypes,
+		}), nil
+
+	case common.CompositeKindEvent:
+		return cadence.NewEvent(fieldValues).WithType(&cadence.EventType{
+			Location:            location,
+			QualifiedIdentifier: qualifiedIdentifier,
+			Fields:              fieldTypes,
+		}), nil
+
+	case common.CompositeKindContract:
+		return cadence.NewContract(fieldValues).WithType(&cadence.ContractType{
+			Location:            location,
+			QualifiedIdentifier: qualifiedIdentifier,
+			Fields:              fieldTypes,
+		}), nil
+
+	case common.CompositeKindEnum:
+		return cadence.NewEnum(fieldValues).WithType(&cadence.EnumType{
+			Location:            location,
+			QualifiedIdentifier: qualifiedIdentifier,
+			Fields:              fieldTypes,
+		}), nil
+
+	default:
+		return nil, fmt.Errorf("invalid composite encoding: unsupported kind %d", kind)
+	}
+}
+
+// decodeCompositeTypeInfo decodes the location, qualified identifier, and kind of a composite,
+// like interpreter.decodeCompositeTypeInfo
+//
+func decodeCompositeTypeInfo(dec *cbor.StreamDecoder) (
+	location common.Location,
+	qualifiedIdentifier string,
+	kind common.CompositeKind,
+	err error,
+) {
+	// Decode location at array index encodedCompositeValueLocationFieldKey
+	location, err = interpreter.DecodeLocation(dec)
+	if err != nil {
+		return
+	}
+
+	// Decode qualified identifier at array index encodedCompositeValueQualifiedIdentifierFieldKey
+	qualifiedIdentifier, err = dec.DecodeString()
+	if err != nil {
+		return
+	}
+
+	// Decode kind at array index encodedCompositeValueKindFieldKey
+	kindNumber, err := dec.DecodeUint64()
+	if err != nil {
+		return
+	}
+
+	kind = common.CompositeKind(kindNumber)
+
+	return
+}
+
+// decodeType decodes a type encoded by encodeType
+//
+func decodeType(dec *cbor.StreamDecoder) (cadence.Type, error) {
+	t, err := dec.NextType()
+	if err != nil {
+		return nil, err
+	}
+
+	if t == cbor.NilType {
+		err := dec.DecodeNil()
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	num, err := dec.DecodeTagNumber()
+	if err != nil {
+		return nil, err
+	}
+
+	switch num {
+	case interpreter.CBORTagPrimitiveStaticType:
+		number, err := dec.DecodeUint64()
+		if err != nil {
+			return nil, err
+		}
+
+		primitiveType, ok := primitiveTypesByStaticType[interpreter.PrimitiveStaticType(number)]
+		if !ok {
+			return nil, fmt.Errorf("invalid type encoding: unsupported primitive static type %d", number)
+		}
+
+		return primitiveType, nil
+
+	case interpreter.CBORTagOptionalStaticType:
+		innerType, err := decodeType(dec)
+		if err != nil {
+			return nil, err
+		}
+		return cadence.OptionalType{
+			Type: innerType,
+		}, nil
+
+	case interpreter.CBORTagVariableSizedStaticType:
+		elementType, err := decodeType(dec)
+		if err != nil {
+			return nil, err
+		}
+		return cadence.VariableSizedArrayType{
+			ElementType: elementType,
+		}, nil
+
+	case interpreter.CBORTagConstantSizedStaticType:
+		err := decodePairHead(dec)
+		if err != nil {
+			return nil, err
+		}
+
+		size, err := dec.DecodeUint64()
+		if err != nil {
+			return nil, err
+		}
+
+		elementType, err := decodeType(dec)
+		if err != nil {
+			return nil, err
+		}
+
+		return cadence.ConstantSizedArrayType{
+			Size:        uint(size),
+			ElementType: elementType,
+		}, nil
+
+	case interpreter.CBORTagDictionaryStaticType:
+		err := decodePairHead(dec)
+		if err != nil {
+			return nil, err
+		}
+
+		keyType, err := decodeType(dec)
+		if err != nil {
+			return nil, err
+		}
+
+		elementType, err := decodeType(dec)
+		if err != nil {
+			return nil, err
+		}
+
+		return cadence.DictionaryType{
+			KeyType:     keyType,
+			ElementType: elementType,
+		}, nil
+
+	case interpreter.CBORTagReferenceStaticType:
+		err := decodePairHead(dec)
+		if err != nil {
+			return nil, err
+		}
+
+		authorized, err := dec.DecodeBool()
+		if err != nil {
+			return nil, err
+		}
+
+		referencedType, err := decodeType(dec)
+		if err != nil {
+			return nil, err
+		}
+
+		return cadence.ReferenceType{
+			Authorized: authorized,
+			Type:       referencedType,
+		}, nil
+
+	case interpreter.CBORTagCapabilityStaticType:
+		borrowType, err := decodeType(dec)
+		if err != nil {
+			return nil, err
+		}
+		return cadence.CapabilityType{
+			BorrowType: borrowType,
+		}, nil
+
+	case interpreter.CBORTagCompositeValue:
+		size, err := dec.DecodeArrayHead()
+		if err != nil {
+			return nil, err
+		}
+
+		if size != encodedCompositeTypeLength {
+			return nil, fmt.Errorf(
+				"invalid composite type encoding: expected %d elements, got %d",
+				encodedCompositeTypeLength,
+				size,
+			)
+		}
+
+		location, qualifiedIdentifier, kind, err := decodeCompositeTypeInfo(dec)
+		if err != nil {
+			return nil, err
+		}
+
+		switch kind {
+		case common.CompositeKindStructure:
+			return &cadence.StructType{
+				Location:            location,
+				QualifiedIdentifier: qualifiedIdentifier,
+			}, nil
+
+		case common.CompositeKindResource:
+			return &cadence.ResourceType{
+				Location:            location,
+				QualifiedIdentifier: qualifiedIdentifier,
+			}, nil
+
+		case common.CompositeKindEvent:
+			return &cadence.EventType{
+				Location:            location,
+				QualifiedIdentifier: qualifiedIdentifier,
+			}, nil
+
+		case common.CompositeKindContract:
+			return &cadence.ContractType{
+				Location:            location,
+				QualifiedIdentifier: qualifiedIdentifier,
+			}, nil
+
+		case common.CompositeKindEnum:
+			return &cadence.EnumType{
+				Location:            location,
+				QualifiedIdentifier: qualifiedIdentifier,
+			}, nil
+
+		default:
+			return nil, fmt.Errorf("invalid composite type encoding: unsupported kind %d", kind)
+		}
+
+	default:
+		return nil, fmt.Errorf("invalid type encoding: unsupported tag %d", num)
+	}
+}
+
+// decodePairHead decodes the head of an array of two elements
+//
+func decodePairHead(dec *cbor.StreamDecoder) error {
+	size, err := dec.DecodeArrayHead()
+	if err != nil {
+		return err
+	}
+
+	if size != 2 {
+		return fmt.Errorf("invalid type encoding: expected 2 elements, got %d", size)
+	}
+
+	return nil
+}
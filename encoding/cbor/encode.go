@@ -0,0 +1,504 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cbor
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	"github.com/fxamacker/cbor/v2"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/cadence/runtime/interpreter"
+)
+
+// Encode returns the CBOR-encoded representation of the given value.
+//
+// This function returns an UnsupportedValueError if the value,
+// or a value nested in it, cannot be encoded.
+//
+func Encode(value cadence.Value) ([]byte, error) {
+	var w bytes.Buffer
+
+	enc := encMode.NewStreamEncoder(&w)
+
+	err := encodeValue(enc, value)
+	if err != nil {
+		return nil, err
+	}
+
+	err = enc.Flush()
+	if err != nil {
+		return nil, err
+	}
+
+	return w.Bytes(), nil
+}
+
+// MustEncode returns the CBOR-encoded representation of the given value,
+// or panics if the value cannot be encoded.
+//
+func MustEncode(value cadence.Value) []byte {
+	b, err := Encode(value)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func encodeValue(e *cbor.StreamEncoder, value cadence.Value) error {
+	switch v := value.(type) {
+	case cadence.Void:
+		err := e.EncodeTagHead(interpreter.CBORTagVoidValue)
+		if err != nil {
+			return err
+		}
+		return e.EncodeNil()
+
+	case cadence.Optional:
+		if v.Value == nil {
+			return e.EncodeNil()
+		}
+		err := e.EncodeTagHead(interpreter.CBORTagSomeValue)
+		if err != nil {
+			return err
+		}
+		return encodeValue(e, v.Value)
+
+	case cadence.Bool:
+		return e.EncodeBool(bool(v))
+
+	case cadence.String:
+		err := e.EncodeTagHead(interpreter.CBORTagStringValue)
+		if err != nil {
+			return err
+		}
+		return e.EncodeString(string(v))
+
+	case cadence.Address:
+		err := e.EncodeTagHead(interpreter.CBORTagAddressValue)
+		if err != nil {
+			return err
+		}
+		return e.EncodeBytes(common.Address(v).Bytes())
+
+	case cadence.Int:
+		return encodeBigInt(e, interpreter.CBORTagIntValue, v.Value)
+	case cadence.Int8:
+		return encodeInt64(e, interpreter.CBORTagInt8Value, int64(v))
+	case cadence.Int16:
+		return encodeInt64(e, interpreter.CBORTagInt16Value, int64(v))
+	case cadence.Int32:
+		return encodeInt64(e, interpreter.CBORTagInt32Value, int64(v))
+	case cadence.Int64:
+		return encodeInt64(e, interpreter.CBORTagInt64Value, int64(v))
+	case cadence.Int128:
+		return encodeBigInt(e, interpreter.CBORTagInt128Value, v.Value)
+	case cadence.Int256:
+		return encodeBigInt(e, interpreter.CBORTagInt256Value, v.Value)
+
+	case cadence.UInt:
+		return encodeBigInt(e, interpreter.CBORTagUIntValue, v.Value)
+	case cadence.UInt8:
+		return encodeUint64(e, interpreter.CBORTagUInt8Value, uint64(v))
+	case cadence.UInt16:
+		return encodeUint64(e, interpreter.CBORTagUInt16Value, uint64(v))
+	case cadence.UInt32:
+		return encodeUint64(e, interpreter.CBORTagUInt32Value, uint64(v))
+	case cadence.UInt64:
+		return encodeUint64(e, interpreter.CBORTagUInt64Value, uint64(v))
+	case cadence.UInt128:
+		return encodeBigInt(e, interpreter.CBORTagUInt128Value, v.Value)
+	case cadence.UInt256:
+		return encodeBigInt(e, interpreter.CBORTagUInt256Value, v.Value)
+
+	case cadence.Word8:
+		return encodeUint64(e, interpreter.CBORTagWord8Value, uint64(v))
+	case cadence.Word16:
+		return encodeUint64(e, interpreter.CBORTagWord16Value, uint64(v))
+	case cadence.Word32:
+		return encodeUint64(e, interpreter.CBORTagWord32Value, uint64(v))
+	case cadence.Word64:
+		return encodeUint64(e, interpreter.CBORTagWord64Value, uint64(v))
+
+	case cadence.Fix64:
+		return encodeInt64(e, interpreter.CBORTagFix64Value, int64(v))
+	case cadence.UFix64:
+		return encodeUint64(e, interpreter.CBORTagUFix64Value, uint64(v))
+
+	case cadence.Path:
+		return encodePath(e, v)
+
+	case cadence.Array:
+		return encodeArray(e, v)
+
+	case cadence.Dictionary:
+		return encodeDictionary(e, v)
+
+	// Composites can only be encoded if they have a type,
+	// which provides the type information and the names of the fields
+
+	case cadence.Struct:
+		if v.StructType == nil {
+			break
+		}
+		return encodeComposite(e, v.StructType, common.CompositeKindStructure, v.Fields)
+	case cadence.Resource:
+		if v.ResourceType == nil {
+			break
+		}
+		return encodeComposite(e, v.ResourceType, common.CompositeKindResource, v.Fields)
+	case cadence.Event:
+		if v.EventType == nil {
+			break
+		}
+		return encodeComposite(e, v.EventType, common.CompositeKindEvent, v.Fields)
+	case cadence.Contract:
+		if v.ContractType == nil {
+			break
+		}
+		return encodeComposite(e, v.ContractType, common.CompositeKindContract, v.Fields)
+	case cadence.Enum:
+		if v.EnumType == nil {
+			break
+		}
+		return encodeComposite(e, v.EnumType, common.CompositeKindEnum, v.Fields)
+	}
+
+	return UnsupportedValueError{
+		Value: value,
+	}
+}
+
+func encodeInt64(e *cbor.StreamEncoder, tag uint64, v int64) error {
+	err := e.EncodeTagHead(tag)
+	if err != nil {
+		return err
+	}
+	return e.EncodeInt64(v)
+}
+
+func encodeUint64(e *cbor.StreamEncoder, tag uint64, v uint64) error {
+	err := e.EncodeTagHead(tag)
+	if err != nil {
+		return err
+	}
+	return e.EncodeUint64(v)
+}
+
+func encodeBigInt(e *cbor.StreamEncoder, tag uint64, v *big.Int) error {
+	err := e.EncodeTagHead(tag)
+	if err != nil {
+		return err
+	}
+	return e.EncodeBigInt(v)
+}
+
+// encodePath encodes the path like interpreter.PathValue.Encode
+//
+func encodePath(e *cbor.StreamEncoder, v cadence.Path) error {
+	domain := common.PathDomainFromIdentifier(v.Domain)
+	if domain == common.PathDomainUnknown {
+		return fmt.Errorf("invalid path domain: %s", v.Domain)
+	}
+
+	err := e.EncodeTagHead(interpreter.CBORTagPathValue)
+	if err != nil {
+		return err
+	}
+
+	err = e.EncodeArrayHead(2)
+	if err != nil {
+		return err
+	}
+
+	err = e.EncodeUint(uint(domain))
+	if err != nil {
+		return err
+	}
+
+	return e.EncodeString(v.Identifier)
+}
+
+func encodeArray(e *cbor.StreamEncoder, v cadence.Array) error {
+	err := e.EncodeTagHead(cborTagArrayValue)
+	if err != nil {
+		return err
+	}
+
+	err = e.EncodeArrayHead(encodedContainerValueLength)
+	if err != nil {
+		return err
+	}
+
+	// Encode type at array index encodedContainerValueTypeFieldKey
+	err = encodeType(e, v.ArrayType)
+	if err != nil {
+		return err
+	}
+
+	// Encode elements at array index encodedContainerValueElementsFieldKey
+	err = e.EncodeArrayHead(uint64(len(v.Values)))
+	if err != nil {
+		return err
+	}
+
+	for _, value := range v.Values {
+		err = encodeValue(e, value)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func encodeDictionary(e *cbor.StreamEncoder, v cadence.Dictionary) error {
+	err := e.EncodeTagHead(cborTagDictionaryValue)
+	if err != nil {
+		return err
+	}
+
+	err = e.EncodeArrayHead(encodedContainerValueLength)
+	if err != nil {
+		return err
+	}
+
+	// Encode type at array index encodedContainerValueTypeFieldKey
+	err = encodeType(e, v.DictionaryType)
+	if err != nil {
+		return err
+	}
+
+	// Encode keys and values at array index encodedContainerValueElementsFieldKey
+	err = e.EncodeArrayHead(uint64(len(v.Pairs) * 2))
+	if err != nil {
+		return err
+	}
+
+	for _, pair := range v.Pairs {
+		err = encodeValue(e, pair.Key)
+		if err != nil {
+			return err
+		}
+
+		err = encodeValue(e, pair.Value)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// encodeComposite encodes the composite like interpreter.compositeTypeInfo.Encode,
+// followed by the fields
+//
+func encodeComposite(
+	e *cbor.StreamEncoder,
+	compositeType cadence.CompositeType,
+	kind common.CompositeKind,
+	fields []cadence.Value,
+) error {
+	fieldTypes := compositeType.CompositeFields()
+	if len(fieldTypes) != len(fields) {
+		return fmt.Errorf(
+			"invalid composite value: expected %d fields, got %d",
+			len(fieldTypes),
+			len(fields),
+		)
+	}
+
+	err := encodeCompositeTypeInfo(e, compositeType, kind, encodedCompositeValueLength)
+	if err != nil {
+		return err
+	}
+
+	// Encode fields at array index encodedCompositeValueFieldsFieldKey
+	err = e.EncodeArrayHead(uint64(len(fields) * 3))
+	if err != nil {
+		return err
+	}
+
+	for i, field := range fields {
+		err = e.EncodeString(fieldTypes[i].Identifier)
+		if err != nil {
+			return err
+		}
+
+		err = encodeType(e, fieldTypes[i].Type)
+		if err != nil {
+			return err
+		}
+
+		err = encodeValue(e, field)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// encodeCompositeTypeInfo encodes the tag and the array head of the composite,
+// with the given length, followed by the location, qualified identifier, and kind,
+// like interpreter.compositeTypeInfo.Encode
+//
+func encodeCompositeTypeInfo(
+	e *cbor.StreamEncoder,
+	compositeType cadence.CompositeType,
+	kind common.CompositeKind,
+	length uint64,
+) error {
+	err := e.EncodeTagHead(interpreter.CBORTagCompositeValue)
+	if err != nil {
+		return err
+	}
+
+	err = e.EncodeArrayHead(length)
+	if err != nil {
+		return err
+	}
+
+	// Encode location at array index encodedCompositeValueLocationFieldKey
+	err = interpreter.EncodeLocation(e, compositeType.CompositeTypeLocation())
+	if err != nil {
+		return err
+	}
+
+	// Encode qualified identifier at array index encodedCompositeValueQualifiedIdentifierFieldKey
+	err = e.EncodeString(compositeType.CompositeTypeQualifiedIdentifier())
+	if err != nil {
+		return err
+	}
+
+	// Encode kind at array index encodedCompositeValueKindFieldKey
+	return e.EncodeUint64(uint64(kind))
+}
+
+// encodeType encodes the given type like interpreter.EncodeStaticType,
+// except for composite types, which are encoded like interpreter.compositeTypeInfo.Encode.
+//
+// This function returns an UnsupportedTypeError if the type,
+// or a type nested in it, cannot be encoded.
+//
+func encodeType(e *cbor.StreamEncoder, t cadence.Type) error {
+	switch t := t.(type) {
+	case nil:
+		return e.EncodeNil()
+
+	case cadence.OptionalType:
+		err := e.EncodeTagHead(interpreter.CBORTagOptionalStaticType)
+		if err != nil {
+			return err
+		}
+		return encodeType(e, t.Type)
+
+	case cadence.VariableSizedArrayType:
+		err := e.EncodeTagHead(interpreter.CBORTagVariableSizedStaticType)
+		if err != nil {
+			return err
+		}
+		return encodeType(e, t.ElementType)
+
+	case cadence.ConstantSizedArrayType:
+		err := e.EncodeTagHead(interpreter.CBORTagConstantSizedStaticType)
+		if err != nil {
+			return err
+		}
+		err = e.EncodeArrayHead(2)
+		if err != nil {
+			return err
+		}
+		err = e.EncodeInt64(int64(t.Size))
+		if err != nil {
+			return err
+		}
+		return encodeType(e, t.ElementType)
+
+	case cadence.DictionaryType:
+		err := e.EncodeTagHead(interpreter.CBORTagDictionaryStaticType)
+		if err != nil {
+			return err
+		}
+		err = e.EncodeArrayHead(2)
+		if err != nil {
+			return err
+		}
+		err = encodeType(e, t.KeyType)
+		if err != nil {
+			return err
+		}
+		return encodeType(e, t.ElementType)
+
+	case cadence.ReferenceType:
+		err := e.EncodeTagHead(interpreter.CBORTagReferenceStaticType)
+		if err != nil {
+			return err
+		}
+		err = e.EncodeArrayHead(2)
+		if err != nil {
+			return err
+		}
+		err = e.EncodeBool(t.Authorized)
+		if err != nil {
+			return err
+		}
+		return encodeType(e, t.Type)
+
+	case cadence.CapabilityType:
+		err := e.EncodeTagHead(interpreter.CBORTagCapabilityStaticType)
+		if err != nil {
+			return err
+		}
+		return encodeType(e, t.BorrowType)
+
+	case *cadence.StructType:
+		return encodeCompositeTypeInfo(e, t, common.CompositeKindStructure, encodedCompositeTypeLength)
+	case *cadence.ResourceType:
+		return encodeCompositeTypeInfo(e, t, common.CompositeKindResource, encodedCompositeTypeLength)
+	case *cadence.EventType:
+		return encodeCompositeTypeInfo(e, t, common.CompositeKindEvent, encodedCompositeTypeLength)
+	case *cadence.ContractType:
+		return encodeCompositeTypeInfo(e, t, common.CompositeKindContract, encodedCompositeTypeLength)
+	case *cadence.EnumType:
+		return encodeCompositeTypeInfo(e, t, common.CompositeKindEnum, encodedCompositeTypeLength)
+
+	case cadence.InterfaceType,
+		cadence.RestrictedType,
+		cadence.FunctionType:
+
+		// The static types of interface types do not include their kind,
+		// and function types are not storable
+
+		return UnsupportedTypeError{
+			Type: t,
+		}
+	}
+
+	if primitiveStaticType, ok := primitiveStaticTypes[t]; ok {
+		return primitiveStaticType.Encode(e)
+	}
+
+	return UnsupportedTypeError{
+		Type: t,
+	}
+}
@@ -0,0 +1,407 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cbor_test
+
+import (
+	"bytes"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/onflow/atree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/cadence/encoding/cbor"
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/cadence/runtime/interpreter"
+	"github.com/onflow/cadence/runtime/tests/utils"
+)
+
+func TestEncodeDecode(t *testing.T) {
+
+	t.Parallel()
+
+	location := utils.TestLocation
+
+	structType := &cadence.StructType{
+		Location:            location,
+		QualifiedIdentifier: "Foo",
+		Fields: []cadence.Field{
+			{
+				Identifier: "a",
+				Type:       cadence.IntType{},
+			},
+			{
+				Identifier: "b",
+				Type:       cadence.StringType{},
+			},
+			{
+				Identifier: "c",
+				Type: cadence.OptionalType{
+					Type: cadence.IntType{},
+				},
+			},
+		},
+	}
+
+	resourceType := &cadence.ResourceType{
+		Location:            location,
+		QualifiedIdentifier: "Bar",
+		Fields: []cadence.Field{
+			{
+				Identifier: "uuid",
+				Type:       cadence.UInt64Type{},
+			},
+		},
+	}
+
+	values := map[string]cadence.Value{
+		"Void":        cadence.NewVoid(),
+		"nil":         cadence.NewOptional(nil),
+		"some":        cadence.NewOptional(cadence.NewInt(1)),
+		"Bool":        cadence.NewBool(true),
+		"String":      cadence.String("foo"),
+		"Address":     cadence.BytesToAddress([]byte{0x1, 0x2}),
+		"Int":         cadence.NewIntFromBig(big.NewInt(-42)),
+		"Int8":        cadence.NewInt8(-8),
+		"Int16":       cadence.NewInt16(-16),
+		"Int32":       cadence.NewInt32(-32),
+		"Int64":       cadence.NewInt64(-64),
+		"Int128":      cadence.NewInt128(-128),
+		"Int256":      cadence.NewInt256(-256),
+		"UInt":        cadence.NewUInt(42),
+		"UInt8":       cadence.NewUInt8(8),
+		"UInt16":      cadence.NewUInt16(16),
+		"UInt32":      cadence.NewUInt32(32),
+		"UInt64":      cadence.NewUInt64(64),
+		"UInt128":     cadence.NewUInt128(128),
+		"UInt256":     cadence.NewUInt256(256),
+		"Word8":       cadence.NewWord8(8),
+		"Word16":      cadence.NewWord16(16),
+		"Word32":      cadence.NewWord32(32),
+		"Word64":      cadence.NewWord64(64),
+		"Fix64":       cadence.Fix64(-123456789),
+		"UFix64":      cadence.UFix64(123456789),
+		"Path":        cadence.Path{Domain: "storage", Identifier: "foo"},
+		"Array":       cadence.NewArray([]cadence.Value{cadence.NewInt(1), cadence.String("2")}),
+		"empty array": cadence.NewArray([]cadence.Value{}),
+		"typed array": cadence.NewArray([]cadence.Value{
+			cadence.NewInt8(1),
+		}).WithType(cadence.VariableSizedArrayType{
+			ElementType: cadence.Int8Type{},
+		}),
+		"typed constant-sized array": cadence.NewArray([]cadence.Value{
+			cadence.NewOptional(nil),
+			cadence.NewOptional(cadence.String("foo")),
+		}).WithType(cadence.ConstantSizedArrayType{
+			Size: 2,
+			ElementType: cadence.OptionalType{
+				Type: cadence.StringType{},
+			},
+		}),
+		"typed empty array of structs": cadence.NewArray([]cadence.Value{}).
+			WithType(cadence.VariableSizedArrayType{
+				ElementType: &cadence.StructType{
+					Location:            location,
+					QualifiedIdentifier: "Foo",
+				},
+			}),
+		"Dictionary": cadence.NewDictionary([]cadence.KeyValuePair{
+			{
+				Key:   cadence.String("a"),
+				Value: cadence.NewArray([]cadence.Value{cadence.NewBool(false)}),
+			},
+			{
+				Key:   cadence.String("b"),
+				Value: cadence.NewOptional(nil),
+			},
+		}),
+		"typed Dictionary": cadence.NewDictionary([]cadence.KeyValuePair{
+			{
+				Key:   cadence.NewAddress([8]byte{0x1}),
+				Value: cadence.NewUInt64(1),
+			},
+		}).WithType(cadence.DictionaryType{
+			KeyType:     cadence.AddressType{},
+			ElementType: cadence.UInt64Type{},
+		}),
+		"typed empty Dictionary": cadence.NewDictionary([]cadence.KeyValuePair{}).
+			WithType(cadence.DictionaryType{
+				KeyType: cadence.StringType{},
+				ElementType: cadence.CapabilityType{
+					BorrowType: cadence.ReferenceType{
+						Authorized: true,
+						Type:       cadence.AnyStructType{},
+					},
+				},
+			}),
+		"Struct": cadence.NewStruct([]cadence.Value{
+			cadence.NewInt(1),
+			cadence.String("2"),
+			cadence.NewOptional(cadence.NewInt(3)),
+		}).WithType(structType),
+		"Resource": cadence.NewResource([]cadence.Value{
+			cadence.NewUInt64(42),
+		}).WithType(resourceType),
+	}
+
+	for name, value := range values {
+
+		value := value
+
+		t.Run(name, func(t *testing.T) {
+
+			t.Parallel()
+
+			encoded, err := cbor.Encode(value)
+			require.NoError(t, err)
+
+			decoded, err := cbor.Decode(encoded)
+			require.NoError(t, err)
+
+			assert.Equal(t, value, decoded)
+		})
+	}
+}
+
+func TestEncodeStorageEncoding(t *testing.T) {
+
+	t.Parallel()
+
+	// Values which are not containers are encoded like the interpreter encodes them for storage
+
+	test := func(t *testing.T, value cadence.Value, storable atree.Storable) {
+
+		var w bytes.Buffer
+		enc := atree.NewEncoder(&w, interpreter.CBOREncMode)
+
+		err := storable.Encode(enc)
+		require.NoError(t, err)
+
+		err = enc.CBOR.Flush()
+		require.NoError(t, err)
+
+		encoded, err := cbor.Encode(value)
+		require.NoError(t, err)
+
+		assert.Equal(t, w.Bytes(), encoded)
+	}
+
+	t.Run("Void", func(t *testing.T) {
+		t.Parallel()
+
+		test(t, cadence.NewVoid(), interpreter.VoidValue{})
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		t.Parallel()
+
+		test(t, cadence.NewOptional(nil), interpreter.NilValue{})
+	})
+
+	t.Run("some", func(t *testing.T) {
+		t.Parallel()
+
+		test(t,
+			cadence.NewOptional(cadence.NewBool(true)),
+			interpreter.SomeStorable{
+				Storable: interpreter.BoolValue(true),
+			},
+		)
+	})
+
+	t.Run("String", func(t *testing.T) {
+		t.Parallel()
+
+		test(t, cadence.String("foo"), interpreter.NewStringValue("foo"))
+	})
+
+	t.Run("Address", func(t *testing.T) {
+		t.Parallel()
+
+		test(t,
+			cadence.BytesToAddress([]byte{0x1, 0x2}),
+			interpreter.NewAddressValueFromBytes([]byte{0x1, 0x2}),
+		)
+	})
+
+	t.Run("Int", func(t *testing.T) {
+		t.Parallel()
+
+		test(t, cadence.NewInt(-42), interpreter.NewIntValueFromInt64(-42))
+	})
+
+	t.Run("Int8", func(t *testing.T) {
+		t.Parallel()
+
+		test(t, cadence.NewInt8(-8), interpreter.Int8Value(-8))
+	})
+
+	t.Run("UInt64", func(t *testing.T) {
+		t.Parallel()
+
+		test(t, cadence.NewUInt64(64), interpreter.UInt64Value(64))
+	})
+
+	t.Run("UInt256", func(t *testing.T) {
+		t.Parallel()
+
+		test(t, cadence.NewUInt256(256), interpreter.NewUInt256ValueFromUint64(256))
+	})
+
+	t.Run("Word16", func(t *testing.T) {
+		t.Parallel()
+
+		test(t, cadence.NewWord16(16), interpreter.Word16Value(16))
+	})
+
+	t.Run("UFix64", func(t *testing.T) {
+		t.Parallel()
+
+		test(t, cadence.UFix64(123456789), interpreter.UFix64Value(123456789))
+	})
+
+	t.Run("Path", func(t *testing.T) {
+		t.Parallel()
+
+		test(t,
+			cadence.Path{Domain: "public", Identifier: "foo"},
+			interpreter.PathValue{
+				Domain:     common.PathDomainPublic,
+				Identifier: "foo",
+			},
+		)
+	})
+}
+
+func TestEncodeUnsupportedValue(t *testing.T) {
+
+	t.Parallel()
+
+	_, err := cbor.Encode(
+		cadence.NewArray([]cadence.Value{
+			cadence.NewTypeValue(cadence.IntType{}),
+		}),
+	)
+	require.Error(t, err)
+
+	var unsupportedValueErr cbor.UnsupportedValueError
+	require.ErrorAs(t, err, &unsupportedValueErr)
+}
+
+func TestEncodeUnsupportedType(t *testing.T) {
+
+	t.Parallel()
+
+	_, err := cbor.Encode(
+		cadence.NewArray([]cadence.Value{}).
+			WithType(cadence.VariableSizedArrayType{
+				ElementType: &cadence.StructInterfaceType{
+					Location:            utils.TestLocation,
+					QualifiedIdentifier: "I",
+				},
+			}),
+	)
+	require.Error(t, err)
+
+	var unsupportedTypeErr cbor.UnsupportedTypeError
+	require.ErrorAs(t, err, &unsupportedTypeErr)
+}
+
+func TestEncodeContainerTags(t *testing.T) {
+
+	t.Parallel()
+
+	// Arrays and dictionaries are tagged with the tags
+	// the interpreter used for them before they were stored in separate slabs
+
+	t.Run("array", func(t *testing.T) {
+
+		t.Parallel()
+
+		encoded, err := cbor.Encode(cadence.NewArray([]cadence.Value{}))
+		require.NoError(t, err)
+
+		assert.Equal(t,
+			[]byte{
+				// tag
+				0xd8, interpreter.CBORTagBase + 6,
+				// array, 2 items follow
+				0x82,
+				// nil
+				0xf6,
+				// array, 0 items follow
+				0x80,
+			},
+			encoded,
+		)
+	})
+
+	t.Run("dictionary", func(t *testing.T) {
+
+		t.Parallel()
+
+		encoded, err := cbor.Encode(cadence.NewDictionary([]cadence.KeyValuePair{}))
+		require.NoError(t, err)
+
+		assert.Equal(t,
+			[]byte{
+				// tag
+				0xd8, interpreter.CBORTagBase + 1,
+				// array, 2 items follow
+				0x82,
+				// nil
+				0xf6,
+				// array, 0 items follow
+				0x80,
+			},
+			encoded,
+		)
+	})
+}
+
+func TestDecodeInvalid(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("trailing data", func(t *testing.T) {
+		t.Parallel()
+
+		encoded, err := cbor.Encode(cadence.NewBool(true))
+		require.NoError(t, err)
+
+		_, err = cbor.Decode(append(encoded, 0x0))
+		require.Error(t, err)
+	})
+
+	t.Run("out of range", func(t *testing.T) {
+		t.Parallel()
+
+		encoded, err := cbor.Encode(cadence.NewUInt16(math.MaxUint16))
+		require.NoError(t, err)
+
+		// Change the tag from UInt16 to UInt8
+		encoded[1] = interpreter.CBORTagUInt8Value
+
+		_, err = cbor.Decode(encoded)
+		require.Error(t, err)
+	})
+}
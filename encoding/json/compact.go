@@ -0,0 +1,431 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2020 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package json
+
+import (
+	"encoding/json"
+	"fmt"
+	goRuntime "runtime"
+
+	"github.com/onflow/cadence"
+)
+
+// EncodeCompact returns the compact JSON-encoded representation of the given value,
+// which omits the type information that can be derived from the given schema type.
+//
+// The compact representation of a value of a schema type is:
+//   - for a simple type (e.g. Int, String, Address, or UFix64), the "value" part of the full representation
+//   - for Void, null
+//   - for an optional type, null if the value is nil, and the compact representation of the value otherwise.
+//     If the inner type is an optional type or Void, the value is wrapped in an array, to distinguish it from nil
+//   - for an array type, an array of the compact representations of the elements
+//   - for a dictionary type, an array of the pairs, each an array of the compact representations of the key and value
+//   - for a composite type, an array of the compact representations of the fields, in the order of the fields of the type
+//   - for a path type, the "value" part of the full representation
+//   - for the meta type, the "value" part of the full representation
+//   - for all other types (e.g. AnyStruct, restricted types, or capability types), the full representation
+//
+// The value must conform to the schema type, and the representation can only be decoded
+// with DecodeCompact given the same schema type.
+//
+// This function returns an error if the value does not conform to the schema type,
+// or if the value cannot be represented as JSON.
+func EncodeCompact(value cadence.Value, schema cadence.Type) (b []byte, err error) {
+	err = checkValueDepth(value, DefaultMaxDepth)
+	if err != nil {
+		return nil, err
+	}
+
+	// capture panics that occur during struct preparation
+	defer func() {
+		if r := recover(); r != nil {
+			// don't recover Go errors
+			goErr, ok := r.(goRuntime.Error)
+			if ok {
+				panic(goErr)
+			}
+
+			panicErr, isError := r.(error)
+			if !isError {
+				panic(r)
+			}
+
+			err = fmt.Errorf("failed to encode value: %w", panicErr)
+		}
+	}()
+
+	preparedValue := prepareCompact(value, schema)
+
+	return json.Marshal(preparedValue)
+}
+
+// DecodeCompact returns a Cadence value decoded from its compact JSON-encoded representation,
+// produced by EncodeCompact given the same schema type.
+//
+// The types of the decoded arrays, dictionaries, and composites are the types given in the schema.
+//
+// This function returns an error if the bytes represent JSON that is malformed
+// or does not conform to the schema type.
+func DecodeCompact(b []byte, schema cadence.Type) (value cadence.Value, err error) {
+	var valueJSON interface{}
+
+	err = json.Unmarshal(b, &valueJSON)
+	if err != nil {
+		return nil, fmt.Errorf("json-cdc: failed to decode valid JSON structure: %w", err)
+	}
+
+	// capture panics that occur during decoding
+	defer func() {
+		if r := recover(); r != nil {
+			panicErr, isError := r.(error)
+			if !isError {
+				panic(r)
+			}
+
+			err = fmt.Errorf("failed to decode value: %w", panicErr)
+		}
+	}()
+
+	value = newValueDecoder(DefaultMaxDepth).decodeCompact(valueJSON, schema)
+	return value, nil
+}
+
+// compactSimpleTypeDecoders are the decoders for the "value" part of the full representation
+// of the values of simple types, keyed by the ID of the type.
+var compactSimpleTypeDecoders = map[string]func(valueJSON interface{}) cadence.Value{
+	boolTypeStr:    func(valueJSON interface{}) cadence.Value { return decodeBool(valueJSON) },
+	stringTypeStr:  func(valueJSON interface{}) cadence.Value { return decodeString(valueJSON) },
+	addressTypeStr: func(valueJSON interface{}) cadence.Value { return decodeAddress(valueJSON) },
+	intTypeStr:     func(valueJSON interface{}) cadence.Value { return decodeInt(valueJSON) },
+	int8TypeStr:    func(valueJSON interface{}) cadence.Value { return decodeInt8(valueJSON) },
+	int16TypeStr:   func(valueJSON interface{}) cadence.Value { return decodeInt16(valueJSON) },
+	int32TypeStr:   func(valueJSON interface{}) cadence.Value { return decodeInt32(valueJSON) },
+	int64TypeStr:   func(valueJSON interface{}) cadence.Value { return decodeInt64(valueJSON) },
+	int128TypeStr:  func(valueJSON interface{}) cadence.Value { return decodeInt128(valueJSON) },
+	int256TypeStr:  func(valueJSON interface{}) cadence.Value { return decodeInt256(valueJSON) },
+	uintTypeStr:    func(valueJSON interface{}) cadence.Value { return decodeUInt(valueJSON) },
+	uint8TypeStr:   func(valueJSON interface{}) cadence.Value { return decodeUInt8(valueJSON) },
+	uint16TypeStr:  func(valueJSON interface{}) cadence.Value { return decodeUInt16(valueJSON) },
+	uint32TypeStr:  func(valueJSON interface{}) cadence.Value { return decodeUInt32(valueJSON) },
+	uint64TypeStr:  func(valueJSON interface{}) cadence.Value { return decodeUInt64(valueJSON) },
+	uint128TypeStr: func(valueJSON interface{}) cadence.Value { return decodeUInt128(valueJSON) },
+	uint256TypeStr: func(valueJSON interface{}) cadence.Value { return decodeUInt256(valueJSON) },
+	word8TypeStr:   func(valueJSON interface{}) cadence.Value { return decodeWord8(valueJSON) },
+	word16TypeStr:  func(valueJSON interface{}) cadence.Value { return decodeWord16(valueJSON) },
+	word32TypeStr:  func(valueJSON interface{}) cadence.Value { return decodeWord32(valueJSON) },
+	word64TypeStr:  func(valueJSON interface{}) cadence.Value { return decodeWord64(valueJSON) },
+	fix64TypeStr:   func(valueJSON interface{}) cadence.Value { return decodeFix64(valueJSON) },
+	ufix64TypeStr:  func(valueJSON interface{}) cadence.Value { return decodeUFix64(valueJSON) },
+}
+
+func nonConformingValueError(value cadence.Value, schema cadence.Type) error {
+	return fmt.Errorf(
+		"value %s does not conform to schema type %s",
+		value,
+		schema.ID(),
+	)
+}
+
+// isCompactOptionalWrapped returns true if the compact representation of a non-nil optional
+// with the given inner type must be wrapped, because the representation of the inner value might be null.
+func isCompactOptionalWrapped(innerType cadence.Type) bool {
+	switch innerType.(type) {
+	case cadence.OptionalType, cadence.VoidType:
+		return true
+	}
+	return false
+}
+
+func prepareCompact(value cadence.Value, schema cadence.Type) jsonValue {
+	switch schema := schema.(type) {
+	case cadence.VoidType:
+		if _, ok := value.(cadence.Void); !ok {
+			panic(nonConformingValueError(value, schema))
+		}
+		return nil
+
+	case cadence.OptionalType:
+		optional, ok := value.(cadence.Optional)
+		if !ok {
+			panic(nonConformingValueError(value, schema))
+		}
+
+		if optional.Value == nil {
+			return nil
+		}
+
+		preparedValue := prepareCompact(optional.Value, schema.Type)
+		if isCompactOptionalWrapped(schema.Type) {
+			return []jsonValue{preparedValue}
+		}
+		return preparedValue
+
+	case cadence.VariableSizedArrayType:
+		return prepareCompactArray(value, schema, schema.ElementType)
+
+	case cadence.ConstantSizedArrayType:
+		return prepareCompactArray(value, schema, schema.ElementType)
+
+	case cadence.DictionaryType:
+		dictionary, ok := value.(cadence.Dictionary)
+		if !ok {
+			panic(nonConformingValueError(value, schema))
+		}
+
+		pairs := make([]jsonValue, len(dictionary.Pairs))
+
+		for i, pair := range dictionary.Pairs {
+			pairs[i] = []jsonValue{
+				prepareCompact(pair.Key, schema.KeyType),
+				prepareCompact(pair.Value, schema.ElementType),
+			}
+		}
+
+		return pairs
+
+	case cadence.CompositeType:
+		valueType := value.Type()
+		if valueType == nil || valueType.ID() != schema.ID() {
+			panic(nonConformingValueError(value, schema))
+		}
+
+		fieldValues := compositeFieldValues(value)
+		fieldTypes := nonFunctionFields(schema.CompositeFields())
+
+		if len(fieldValues) != len(fieldTypes) {
+			panic(fmt.Errorf(
+				"%s field count (%d) does not match schema type (%d)",
+				schema.ID(),
+				len(fieldValues),
+				len(fieldTypes),
+			))
+		}
+
+		fields := make([]jsonValue, len(fieldValues))
+
+		for i, fieldValue := range fieldValues {
+			fields[i] = prepareCompact(fieldValue, fieldTypes[i].Type)
+		}
+
+		return fields
+
+	case cadence.PathType,
+		cadence.CapabilityPathType,
+		cadence.StoragePathType,
+		cadence.PublicPathType,
+		cadence.PrivatePathType:
+
+		path, ok := value.(cadence.Path)
+		if !ok {
+			panic(nonConformingValueError(value, schema))
+		}
+
+		return preparePath(path).(jsonValueObject).Value
+
+	case cadence.MetaType:
+		typeValue, ok := value.(cadence.TypeValue)
+		if !ok {
+			panic(nonConformingValueError(value, schema))
+		}
+
+		return prepareTypeValue(typeValue).(jsonValueObject).Value
+	}
+
+	if schema == nil {
+		return Prepare(value)
+	}
+
+	if _, ok := compactSimpleTypeDecoders[schema.ID()]; ok {
+		valueType := value.Type()
+		if valueType == nil || valueType.ID() != schema.ID() {
+			panic(nonConformingValueError(value, schema))
+		}
+
+		return Prepare(value).(jsonValueObject).Value
+	}
+
+	// The type information cannot be derived from the schema type,
+	// so the value is encoded with its full representation
+
+	return Prepare(value)
+}
+
+func prepareCompactArray(value cadence.Value, schema cadence.Type, elementType cadence.Type) jsonValue {
+	array, ok := value.(cadence.Array)
+	if !ok {
+		panic(nonConformingValueError(value, schema))
+	}
+
+	values := make([]jsonValue, len(array.Values))
+
+	for i, element := range array.Values {
+		values[i] = prepareCompact(element, elementType)
+	}
+
+	return values
+}
+
+func compositeFieldValues(value cadence.Value) []cadence.Value {
+	switch value := value.(type) {
+	case cadence.Struct:
+		return value.Fields
+	case cadence.Resource:
+		return value.Fields
+	case cadence.Event:
+		return value.Fields
+	case cadence.Contract:
+		return value.Fields
+	case cadence.Enum:
+		return value.Fields
+	default:
+		panic(fmt.Errorf("unsupported composite value: %T, %v", value, value))
+	}
+}
+
+func nonFunctionFields(fields []cadence.Field) []cadence.Field {
+	result := make([]cadence.Field, 0, len(fields))
+
+	for _, field := range fields {
+		if _, ok := field.Type.(cadence.FunctionType); !ok {
+			result = append(result, field)
+		}
+	}
+
+	return result
+}
+
+func (d valueDecoder) decodeCompact(valueJSON interface{}, schema cadence.Type) cadence.Value {
+	switch schema := schema.(type) {
+	case cadence.VoidType:
+		if valueJSON != nil {
+			panic(ErrInvalidJSONCadence)
+		}
+		return cadence.NewVoid()
+
+	case cadence.OptionalType:
+		if valueJSON == nil {
+			return cadence.NewOptional(nil)
+		}
+
+		if isCompactOptionalWrapped(schema.Type) {
+			wrapped := toSlice(valueJSON)
+			if len(wrapped) != 1 {
+				panic(ErrInvalidJSONCadence)
+			}
+			valueJSON = wrapped[0]
+		}
+
+		return cadence.NewOptional(d.enter().decodeCompact(valueJSON, schema.Type))
+
+	case cadence.VariableSizedArrayType:
+		return cadence.NewArray(d.enter().decodeCompactValues(valueJSON, schema.ElementType)).
+			WithType(schema)
+
+	case cadence.ConstantSizedArrayType:
+		return cadence.NewArray(d.enter().decodeCompactValues(valueJSON, schema.ElementType)).
+			WithType(schema)
+
+	case cadence.DictionaryType:
+		nested := d.enter()
+
+		items := toSlice(valueJSON)
+
+		pairs := make([]cadence.KeyValuePair, len(items))
+
+		for i, item := range items {
+			pair := toSlice(item)
+			if len(pair) != 2 {
+				panic(ErrInvalidJSONCadence)
+			}
+
+			pairs[i] = cadence.KeyValuePair{
+				Key:   nested.decodeCompact(pair[0], schema.KeyType),
+				Value: nested.decodeCompact(pair[1], schema.ElementType),
+			}
+		}
+
+		return cadence.NewDictionary(pairs).WithType(schema)
+
+	case cadence.CompositeType:
+		nested := d.enter()
+
+		fieldsJSON := toSlice(valueJSON)
+		fieldTypes := nonFunctionFields(schema.CompositeFields())
+
+		if len(fieldsJSON) != len(fieldTypes) {
+			panic(ErrInvalidJSONCadence)
+		}
+
+		fields := make([]cadence.Value, len(fieldsJSON))
+
+		for i, fieldJSON := range fieldsJSON {
+			fields[i] = nested.decodeCompact(fieldJSON, fieldTypes[i].Type)
+		}
+
+		switch schema := schema.(type) {
+		case *cadence.StructType:
+			return cadence.NewStruct(fields).WithType(schema)
+		case *cadence.ResourceType:
+			return cadence.NewResource(fields).WithType(schema)
+		case *cadence.EventType:
+			return cadence.NewEvent(fields).WithType(schema)
+		case *cadence.ContractType:
+			return cadence.NewContract(fields).WithType(schema)
+		case *cadence.EnumType:
+			return cadence.NewEnum(fields).WithType(schema)
+		default:
+			panic(fmt.Errorf("unsupported composite type: %T, %v", schema, schema))
+		}
+
+	case cadence.PathType,
+		cadence.CapabilityPathType,
+		cadence.StoragePathType,
+		cadence.PublicPathType,
+		cadence.PrivatePathType:
+
+		return decodePath(valueJSON)
+
+	case cadence.MetaType:
+		return decodeTypeValue(valueJSON)
+	}
+
+	// Values in the full representation are decoded at the same depth
+
+	if schema == nil {
+		return d.decodeJSON(valueJSON)
+	}
+
+	if decode, ok := compactSimpleTypeDecoders[schema.ID()]; ok {
+		return decode(valueJSON)
+	}
+
+	return d.decodeJSON(valueJSON)
+}
+
+func (d valueDecoder) decodeCompactValues(valueJSON interface{}, elementType cadence.Type) []cadence.Value {
+	valuesJSON := toSlice(valueJSON)
+
+	values := make([]cadence.Value, len(valuesJSON))
+
+	for i, elementJSON := range valuesJSON {
+		values[i] = d.decodeCompact(elementJSON, elementType)
+	}
+
+	return values
+}
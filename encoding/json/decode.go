@@ -54,6 +54,29 @@ func Decode(b []byte) (cadence.Value, error) {
 	return v, nil
 }
 
+// DecodeAs returns a Cadence value decoded from its JSON-encoded representation,
+// and validates that the decoded value conforms to the given expected type.
+//
+// Unlike Decode, which infers the type of the value from the JSON itself,
+// this function returns an error if the decoded value does not conform to the expected type.
+// The error wraps a cadence.ValidationError, which reports the path to the non-conforming part of the value,
+// as well as the expected and the actual type.
+// The expected type must be supported by cadence.ValidateAgainstType,
+// e.g. values cannot be validated against interface types.
+func DecodeAs(b []byte, expectedType cadence.Type) (cadence.Value, error) {
+	v, err := Decode(b)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cadence.ValidateAgainstType(v, expectedType)
+	if err != nil {
+		return nil, fmt.Errorf("json-cdc: decoded value does not conform to expected type: %w", err)
+	}
+
+	return v, nil
+}
+
 // NewDecoder initializes a Decoder that will decode JSON-encoded bytes from the
 // given io.Reader.
 func NewDecoder(r io.Reader) *Decoder {
@@ -65,7 +88,14 @@ func NewDecoder(r io.Reader) *Decoder {
 //
 // This function returns an error if the bytes represent JSON that is malformed
 // or does not conform to the JSON Cadence specification.
+//
+// The schema version of the payload must be supported,
+// see DecodeWithOptions to restrict the accepted schema versions.
 func (d *Decoder) Decode() (value cadence.Value, err error) {
+	return d.decode(DecodeOptions{})
+}
+
+func (d *Decoder) decode(opts DecodeOptions) (value cadence.Value, err error) {
 	jsonMap := make(map[string]interface{})
 
 	err = d.dec.Decode(&jsonMap)
@@ -85,7 +115,18 @@ func (d *Decoder) Decode() (value cadence.Value, err error) {
 		}
 	}()
 
-	value = decodeJSON(jsonMap)
+	version := decodeVersion(jsonMap)
+
+	minVersion, maxVersion := opts.versionRange()
+	if version < minVersion || version > maxVersion {
+		return nil, UnsupportedSchemaVersionError{
+			Version:    version,
+			MinVersion: minVersion,
+			MaxVersion: maxVersion,
+		}
+	}
+
+	value = newValueDecoder(effectiveMaxDepth(opts.MaxDepth)).decodeJSON(jsonMap)
 	return value, nil
 }
 
@@ -116,7 +157,7 @@ const (
 
 var ErrInvalidJSONCadence = errors.New("invalid JSON Cadence structure")
 
-func decodeJSON(v interface{}) cadence.Value {
+func (d valueDecoder) decodeJSON(v interface{}) cadence.Value {
 	obj := toObject(v)
 
 	typeStr := obj.GetString(typeKey)
@@ -135,7 +176,7 @@ func decodeJSON(v interface{}) cadence.Value {
 
 	switch typeStr {
 	case optionalTypeStr:
-		return decodeOptional(valueJSON)
+		return d.decodeOptional(valueJSON)
 	case boolTypeStr:
 		return decodeBool(valueJSON)
 	case stringTypeStr:
@@ -183,27 +224,27 @@ func decodeJSON(v interface{}) cadence.Value {
 	case ufix64TypeStr:
 		return decodeUFix64(valueJSON)
 	case arrayTypeStr:
-		return decodeArray(valueJSON)
+		return d.enter().decodeArray(valueJSON)
 	case dictionaryTypeStr:
-		return decodeDictionary(valueJSON)
+		return d.enter().decodeDictionary(valueJSON)
 	case resourceTypeStr:
-		return decodeResource(valueJSON)
+		return d.enter().decodeResource(valueJSON)
 	case structTypeStr:
-		return decodeStruct(valueJSON)
+		return d.enter().decodeStruct(valueJSON)
 	case eventTypeStr:
-		return decodeEvent(valueJSON)
+		return d.enter().decodeEvent(valueJSON)
 	case contractTypeStr:
-		return decodeContract(valueJSON)
+		return d.enter().decodeContract(valueJSON)
 	case linkTypeStr:
-		return decodeLink(valueJSON)
+		return d.decodeLink(valueJSON)
 	case pathTypeStr:
 		return decodePath(valueJSON)
 	case typeTypeStr:
 		return decodeTypeValue(valueJSON)
 	case capabilityTypeStr:
-		return decodeCapability(valueJSON)
+		return d.decodeCapability(valueJSON)
 	case enumTypeStr:
-		return decodeEnum(valueJSON)
+		return d.enter().decodeEnum(valueJSON)
 	}
 
 	panic(ErrInvalidJSONCadence)
@@ -219,12 +260,12 @@ func decodeVoid(m map[string]interface{}) cadence.Void {
 	return cadence.NewVoid()
 }
 
-func decodeOptional(valueJSON interface{}) cadence.Optional {
+func (d valueDecoder) decodeOptional(valueJSON interface{}) cadence.Optional {
 	if valueJSON == nil {
 		return cadence.NewOptional(nil)
 	}
 
-	return cadence.NewOptional(decodeJSON(valueJSON))
+	return cadence.NewOptional(d.enter().decodeJSON(valueJSON))
 }
 
 func decodeBool(valueJSON interface{}) cadence.Bool {
@@ -486,39 +527,39 @@ func decodeUFix64(valueJSON interface{}) cadence.UFix64 {
 	return v
 }
 
-func decodeValues(valueJSON interface{}) []cadence.Value {
+func (d valueDecoder) decodeValues(valueJSON interface{}) []cadence.Value {
 	v := toSlice(valueJSON)
 
 	values := make([]cadence.Value, len(v))
 
 	for i, val := range v {
-		values[i] = decodeJSON(val)
+		values[i] = d.decodeJSON(val)
 	}
 
 	return values
 }
 
-func decodeArray(valueJSON interface{}) cadence.Array {
-	return cadence.NewArray(decodeValues(valueJSON))
+func (d valueDecoder) decodeArray(valueJSON interface{}) cadence.Array {
+	return cadence.NewArray(d.decodeValues(valueJSON))
 }
 
-func decodeDictionary(valueJSON interface{}) cadence.Dictionary {
+func (d valueDecoder) decodeDictionary(valueJSON interface{}) cadence.Dictionary {
 	v := toSlice(valueJSON)
 
 	pairs := make([]cadence.KeyValuePair, len(v))
 
 	for i, val := range v {
-		pairs[i] = decodeKeyValuePair(val)
+		pairs[i] = d.decodeKeyValuePair(val)
 	}
 
 	return cadence.NewDictionary(pairs)
 }
 
-func decodeKeyValuePair(valueJSON interface{}) cadence.KeyValuePair {
+func (d valueDecoder) decodeKeyValuePair(valueJSON interface{}) cadence.KeyValuePair {
 	obj := toObject(valueJSON)
 
-	key := obj.GetValue(keyKey)
-	value := obj.GetValue(valueKey)
+	key := d.decodeJSON(obj.Get(keyKey))
+	value := d.decodeJSON(obj.Get(valueKey))
 
 	return cadence.KeyValuePair{
 		Key:   key,
@@ -533,7 +574,7 @@ type composite struct {
 	fieldTypes          []cadence.Field
 }
 
-func decodeComposite(valueJSON interface{}) composite {
+func (d valueDecoder) decodeComposite(valueJSON interface{}) composite {
 	obj := toObject(valueJSON)
 
 	typeID := obj.GetString(idKey)
@@ -553,7 +594,7 @@ func decodeComposite(valueJSON interface{}) composite {
 	fieldTypes := make([]cadence.Field, len(fields))
 
 	for i, field := range fields {
-		value, fieldType := decodeCompositeField(field)
+		value, fieldType := d.decodeCompositeField(field)
 
 		fieldValues[i] = value
 		fieldTypes[i] = fieldType
@@ -567,11 +608,11 @@ func decodeComposite(valueJSON interface{}) composite {
 	}
 }
 
-func decodeCompositeField(valueJSON interface{}) (cadence.Value, cadence.Field) {
+func (d valueDecoder) decodeCompositeField(valueJSON interface{}) (cadence.Value, cadence.Field) {
 	obj := toObject(valueJSON)
 
 	name := obj.GetString(nameKey)
-	value := obj.GetValue(valueKey)
+	value := d.decodeJSON(obj.Get(valueKey))
 
 	field := cadence.Field{
 		Identifier: name,
@@ -581,8 +622,8 @@ func decodeCompositeField(valueJSON interface{}) (cadence.Value, cadence.Field)
 	return value, field
 }
 
-func decodeStruct(valueJSON interface{}) cadence.Struct {
-	comp := decodeComposite(valueJSON)
+func (d valueDecoder) decodeStruct(valueJSON interface{}) cadence.Struct {
+	comp := d.decodeComposite(valueJSON)
 
 	return cadence.NewStruct(comp.fieldValues).WithType(&cadence.StructType{
 		Location:            comp.location,
@@ -591,8 +632,8 @@ func decodeStruct(valueJSON interface{}) cadence.Struct {
 	})
 }
 
-func decodeResource(valueJSON interface{}) cadence.Resource {
-	comp := decodeComposite(valueJSON)
+func (d valueDecoder) decodeResource(valueJSON interface{}) cadence.Resource {
+	comp := d.decodeComposite(valueJSON)
 
 	return cadence.NewResource(comp.fieldValues).WithType(&cadence.ResourceType{
 		Location:            comp.location,
@@ -601,8 +642,8 @@ func decodeResource(valueJSON interface{}) cadence.Resource {
 	})
 }
 
-func decodeEvent(valueJSON interface{}) cadence.Event {
-	comp := decodeComposite(valueJSON)
+func (d valueDecoder) decodeEvent(valueJSON interface{}) cadence.Event {
+	comp := d.decodeComposite(valueJSON)
 
 	return cadence.NewEvent(comp.fieldValues).WithType(&cadence.EventType{
 		Location:            comp.location,
@@ -611,8 +652,8 @@ func decodeEvent(valueJSON interface{}) cadence.Event {
 	})
 }
 
-func decodeContract(valueJSON interface{}) cadence.Contract {
-	comp := decodeComposite(valueJSON)
+func (d valueDecoder) decodeContract(valueJSON interface{}) cadence.Contract {
+	comp := d.decodeComposite(valueJSON)
 
 	return cadence.NewContract(comp.fieldValues).WithType(&cadence.ContractType{
 		Location:            comp.location,
@@ -621,8 +662,8 @@ func decodeContract(valueJSON interface{}) cadence.Contract {
 	})
 }
 
-func decodeEnum(valueJSON interface{}) cadence.Enum {
-	comp := decodeComposite(valueJSON)
+func (d valueDecoder) decodeEnum(valueJSON interface{}) cadence.Enum {
+	comp := d.decodeComposite(valueJSON)
 
 	return cadence.NewEnum(comp.fieldValues).WithType(&cadence.EnumType{
 		Location:            comp.location,
@@ -631,10 +672,10 @@ func decodeEnum(valueJSON interface{}) cadence.Enum {
 	})
 }
 
-func decodeLink(valueJSON interface{}) cadence.Link {
+func (d valueDecoder) decodeLink(valueJSON interface{}) cadence.Link {
 	obj := toObject(valueJSON)
 
-	targetPath, ok := decodeJSON(obj.Get(targetPathKey)).(cadence.Path)
+	targetPath, ok := d.decodeJSON(obj.Get(targetPathKey)).(cadence.Path)
 	if !ok {
 		// TODO: improve error message
 		panic(ErrInvalidJSONCadence)
@@ -957,10 +998,10 @@ func decodeTypeValue(valueJSON interface{}) cadence.TypeValue {
 	}
 }
 
-func decodeCapability(valueJSON interface{}) cadence.Capability {
+func (d valueDecoder) decodeCapability(valueJSON interface{}) cadence.Capability {
 	obj := toObject(valueJSON)
 
-	path, ok := decodeJSON(obj.Get(pathKey)).(cadence.Path)
+	path, ok := d.decodeJSON(obj.Get(pathKey)).(cadence.Path)
 	if !ok {
 		// TODO: improve error message
 		panic(ErrInvalidJSONCadence)
@@ -1002,11 +1043,6 @@ func (obj jsonObject) GetSlice(key string) []interface{} {
 	return toSlice(v)
 }
 
-func (obj jsonObject) GetValue(key string) cadence.Value {
-	v := obj.Get(key)
-	return decodeJSON(v)
-}
-
 // JSON conversion helpers
 
 func toBool(valueJSON interface{}) bool {
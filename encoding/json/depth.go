@@ -0,0 +1,161 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2020 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package json
+
+import (
+	"fmt"
+
+	"github.com/onflow/cadence"
+)
+
+// DefaultMaxDepth is the default maximum nesting depth of encoded and decoded values.
+//
+// The depth of a value is the number of nested container values,
+// i.e. arrays, dictionaries, composites, and optionals,
+// like the depth of stored values (see interpreter.DefaultMaxValueDepth).
+const DefaultMaxDepth = 1000
+
+// ValueDepthLimitExceededError is returned when a value which is encoded or decoded
+// is nested deeper than the maximum depth.
+type ValueDepthLimitExceededError struct {
+	Limit int
+}
+
+func (e ValueDepthLimitExceededError) Error() string {
+	return fmt.Sprintf(
+		"json-cdc: value depth limit exceeded: values may be nested at most %d levels deep",
+		e.Limit,
+	)
+}
+
+// effectiveMaxDepth returns the given maximum depth,
+// or the default maximum depth if the given maximum depth is zero.
+func effectiveMaxDepth(maxDepth int) int {
+	if maxDepth <= 0 {
+		return DefaultMaxDepth
+	}
+	return maxDepth
+}
+
+// valueDecoder decodes values, and keeps track of the nesting depth of the decoded values,
+// so values which are nested deeper than the maximum depth are rejected while they are decoded,
+// before the decoding exhausts the stack.
+type valueDecoder struct {
+	maxDepth       int
+	remainingDepth int
+}
+
+func newValueDecoder(maxDepth int) valueDecoder {
+	return valueDecoder{
+		maxDepth:       maxDepth,
+		remainingDepth: maxDepth,
+	}
+}
+
+// enter returns the decoder for the values which are nested in a container value,
+// i.e. an array, dictionary, composite, or optional, which is decoded by this decoder.
+// It panics with a ValueDepthLimitExceededError if the container value
+// is nested deeper than the maximum depth.
+func (d valueDecoder) enter() valueDecoder {
+	if d.remainingDepth <= 0 {
+		panic(ValueDepthLimitExceededError{
+			Limit: d.maxDepth,
+		})
+	}
+
+	d.remainingDepth--
+	return d
+}
+
+// checkValueDepth returns a ValueDepthLimitExceededError
+// if the given value is nested deeper than the given maximum depth.
+//
+// The check stops descending at the limit,
+// so it does not exhaust the stack for deeply nested values.
+func checkValueDepth(value cadence.Value, maxDepth int) error {
+	if valueDepthExceeds(value, maxDepth) {
+		return ValueDepthLimitExceededError{
+			Limit: maxDepth,
+		}
+	}
+	return nil
+}
+
+// valueDepthExceeds returns true if the given value is a container value,
+// i.e. an array, dictionary, composite, or optional,
+// which is nested deeper than the remaining depth.
+func valueDepthExceeds(value cadence.Value, remaining int) bool {
+	switch value := value.(type) {
+	case cadence.Optional:
+		if value.Value == nil {
+			return false
+		}
+		return remaining <= 0 ||
+			valueDepthExceeds(value.Value, remaining-1)
+
+	case cadence.Array:
+		return remaining <= 0 ||
+			valuesDepthExceeds(value.Values, remaining-1)
+
+	case cadence.Dictionary:
+		if remaining <= 0 {
+			return true
+		}
+		for _, pair := range value.Pairs {
+			if valueDepthExceeds(pair.Key, remaining-1) ||
+				valueDepthExceeds(pair.Value, remaining-1) {
+
+				return true
+			}
+		}
+		return false
+
+	case cadence.Struct:
+		return remaining <= 0 ||
+			valuesDepthExceeds(value.Fields, remaining-1)
+
+	case cadence.Resource:
+		return remaining <= 0 ||
+			valuesDepthExceeds(value.Fields, remaining-1)
+
+	case cadence.Event:
+		return remaining <= 0 ||
+			valuesDepthExceeds(value.Fields, remaining-1)
+
+	case cadence.Contract:
+		return remaining <= 0 ||
+			valuesDepthExceeds(value.Fields, remaining-1)
+
+	case cadence.Enum:
+		return remaining <= 0 ||
+			valuesDepthExceeds(value.Fields, remaining-1)
+
+	default:
+		return false
+	}
+}
+
+func valuesDepthExceeds(values []cadence.Value, remaining int) bool {
+	for _, value := range values {
+		if valueDepthExceeds(value, remaining) {
+			return true
+		}
+	}
+	return false
+}
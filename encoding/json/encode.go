@@ -34,7 +34,8 @@ import (
 
 // An Encoder converts Cadence values into JSON-encoded bytes.
 type Encoder struct {
-	enc *json.Encoder
+	enc      *json.Encoder
+	maxDepth int
 }
 
 // Encode returns the JSON-encoded representation of the given value.
@@ -72,8 +73,14 @@ func NewEncoder(w io.Writer) *Encoder {
 // encoder's io.Writer.
 //
 // This function returns an error if the given value's type is not supported
-// by this encoder.
+// by this encoder, and a ValueDepthLimitExceededError if the value is nested
+// deeper than the maximum depth.
 func (e *Encoder) Encode(value cadence.Value) (err error) {
+	err = checkValueDepth(value, effectiveMaxDepth(e.maxDepth))
+	if err != nil {
+		return err
+	}
+
 	// capture panics that occur during struct preparation
 	defer func() {
 		if r := recover(); r != nil {
@@ -19,6 +19,7 @@
 package json_test
 
 import (
+	"errors"
 	"fmt"
 	"math"
 	"math/big"
@@ -1850,6 +1851,374 @@ func TestDecodeInvalidType(t *testing.T) {
 	})
 }
 
+func TestDecodeAs(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("conforming", func(t *testing.T) {
+		t.Parallel()
+
+		encodedValue := `
+		{
+			"type":"Array",
+			"value":[
+				{"type":"UInt8","value":"1"},
+				{"type":"UInt8","value":"2"}
+			]
+		}
+	`
+		value, err := json.DecodeAs(
+			[]byte(encodedValue),
+			cadence.VariableSizedArrayType{
+				ElementType: cadence.UInt8Type{},
+			},
+		)
+		require.NoError(t, err)
+
+		assert.Equal(t,
+			cadence.NewArray([]cadence.Value{
+				cadence.NewUInt8(1),
+				cadence.NewUInt8(2),
+			}),
+			value,
+		)
+	})
+
+	t.Run("mismatching element", func(t *testing.T) {
+		t.Parallel()
+
+		encodedValue := `
+		{
+			"type":"Array",
+			"value":[
+				{"type":"UInt8","value":"1"},
+				{"type":"String","value":"2"}
+			]
+		}
+	`
+		_, err := json.DecodeAs(
+			[]byte(encodedValue),
+			cadence.VariableSizedArrayType{
+				ElementType: cadence.UInt8Type{},
+			},
+		)
+		require.Error(t, err)
+
+		var validationErr cadence.ValidationError
+		require.ErrorAs(t, err, &validationErr)
+
+		assert.Equal(t, "$[1]", validationErr.Path)
+		assert.Equal(t, "expected value of type UInt8, got String", validationErr.Message)
+	})
+
+	t.Run("mismatching field", func(t *testing.T) {
+		t.Parallel()
+
+		encodedValue := `
+		{
+			"type":"Resource",
+			"value":{
+				"id":"S.test.Foo",
+				"fields":[
+					{"name":"bar","value":{"type":"Bool","value":true}}
+				]
+			}
+		}
+	`
+		_, err := json.DecodeAs([]byte(encodedValue), fooResourceType)
+		require.Error(t, err)
+
+		var validationErr cadence.ValidationError
+		require.ErrorAs(t, err, &validationErr)
+
+		assert.Equal(t, "$.bar", validationErr.Path)
+		assert.Equal(t, "expected value of type Int, got Bool", validationErr.Message)
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := json.DecodeAs([]byte(`{"type":"Int"`), cadence.IntType{})
+		require.Error(t, err)
+
+		var validationErr cadence.ValidationError
+		require.False(t, errors.As(err, &validationErr))
+	})
+}
+
+func TestSchemaVersion(t *testing.T) {
+
+	t.Parallel()
+
+	value := cadence.NewArray([]cadence.Value{
+		cadence.NewUInt64(1),
+		cadence.String("two"),
+	})
+
+	t.Run("encode, default", func(t *testing.T) {
+		t.Parallel()
+
+		expected, err := json.Encode(value)
+		require.NoError(t, err)
+
+		actual, err := json.EncodeWithOptions(value, json.Options{})
+		require.NoError(t, err)
+
+		assert.Equal(t, expected, actual)
+	})
+
+	t.Run("encode, current", func(t *testing.T) {
+		t.Parallel()
+
+		expected, err := json.Encode(value)
+		require.NoError(t, err)
+
+		actual, err := json.EncodeWithOptions(
+			value,
+			json.Options{
+				Version: json.CurrentSchemaVersion,
+			},
+		)
+		require.NoError(t, err)
+
+		assert.Equal(t, expected, actual)
+	})
+
+	t.Run("encode, unsupported", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := json.EncodeWithOptions(
+			value,
+			json.Options{
+				Version: json.CurrentSchemaVersion + 1,
+			},
+		)
+		require.Error(t, err)
+
+		var versionErr json.UnsupportedSchemaVersionError
+		require.ErrorAs(t, err, &versionErr)
+
+		assert.Equal(t, json.CurrentSchemaVersion+1, versionErr.Version)
+	})
+
+	t.Run("decode, untagged", func(t *testing.T) {
+		t.Parallel()
+
+		encoded, err := json.Encode(value)
+		require.NoError(t, err)
+
+		decoded, err := json.DecodeWithOptions(encoded, json.DecodeOptions{})
+		require.NoError(t, err)
+
+		assert.Equal(t, value, decoded)
+	})
+
+	t.Run("decode, tagged", func(t *testing.T) {
+		t.Parallel()
+
+		decoded, err := json.DecodeWithOptions(
+			[]byte(`{"version":1,"type":"UInt8","value":"1"}`),
+			json.DecodeOptions{
+				MinVersion: 1,
+				MaxVersion: 1,
+			},
+		)
+		require.NoError(t, err)
+
+		assert.Equal(t, cadence.NewUInt8(1), decoded)
+	})
+
+	t.Run("decode, unsupported", func(t *testing.T) {
+		t.Parallel()
+
+		encoded := []byte(`{"version":2,"type":"UInt8","value":"1"}`)
+
+		for _, decode := range []func() (cadence.Value, error){
+			func() (cadence.Value, error) {
+				return json.Decode(encoded)
+			},
+			func() (cadence.Value, error) {
+				return json.DecodeWithOptions(
+					encoded,
+					json.DecodeOptions{
+						MaxVersion: 2,
+					},
+				)
+			},
+		} {
+			_, err := decode()
+			require.Error(t, err)
+
+			var versionErr json.UnsupportedSchemaVersionError
+			require.ErrorAs(t, err, &versionErr)
+
+			assert.Equal(t,
+				json.UnsupportedSchemaVersionError{
+					Version:    2,
+					MinVersion: json.SchemaVersion1,
+					MaxVersion: json.CurrentSchemaVersion,
+				},
+				versionErr,
+			)
+		}
+	})
+
+	t.Run("decode, below minimum", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := json.DecodeWithOptions(
+			[]byte(`{"type":"UInt8","value":"1"}`),
+			json.DecodeOptions{
+				MinVersion: 2,
+			},
+		)
+		require.Error(t, err)
+
+		var versionErr json.UnsupportedSchemaVersionError
+		require.ErrorAs(t, err, &versionErr)
+
+		assert.Equal(t, json.SchemaVersion1, versionErr.Version)
+	})
+
+	t.Run("decode, invalid version", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := json.Decode([]byte(`{"version":"1","type":"UInt8","value":"1"}`))
+		require.Error(t, err)
+
+		require.ErrorIs(t, err, json.ErrInvalidJSONCadence)
+	})
+}
+
+func TestValueDepthLimit(t *testing.T) {
+
+	t.Parallel()
+
+	// nestedArray returns arrays nested the given number of levels deep.
+	// The innermost array contains an integer, which is not nested
+	nestedArray := func(depth int) cadence.Value {
+		var value cadence.Value = cadence.NewInt(1)
+		for i := 0; i < depth; i++ {
+			value = cadence.NewArray([]cadence.Value{value})
+		}
+		return value
+	}
+
+	t.Run("encode, within default limit", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := json.Encode(nestedArray(json.DefaultMaxDepth))
+		require.NoError(t, err)
+	})
+
+	t.Run("encode, exceeds default limit", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := json.Encode(nestedArray(json.DefaultMaxDepth + 1))
+		require.Error(t, err)
+
+		var depthErr json.ValueDepthLimitExceededError
+		require.ErrorAs(t, err, &depthErr)
+
+		assert.Equal(t, json.DefaultMaxDepth, depthErr.Limit)
+	})
+
+	t.Run("encode, exceeds configured limit", func(t *testing.T) {
+		t.Parallel()
+
+		value := cadence.NewOptional(nestedArray(3))
+
+		_, err := json.EncodeWithOptions(value, json.Options{MaxDepth: 4})
+		require.NoError(t, err)
+
+		_, err = json.EncodeWithOptions(value, json.Options{MaxDepth: 3})
+		require.Error(t, err)
+
+		var depthErr json.ValueDepthLimitExceededError
+		require.ErrorAs(t, err, &depthErr)
+
+		assert.Equal(t, 3, depthErr.Limit)
+	})
+
+	t.Run("decode, exceeds configured limit", func(t *testing.T) {
+		t.Parallel()
+
+		value := cadence.NewDictionary([]cadence.KeyValuePair{
+			{
+				Key:   cadence.String("a"),
+				Value: nestedArray(2),
+			},
+		})
+
+		encoded, err := json.Encode(value)
+		require.NoError(t, err)
+
+		decoded, err := json.DecodeWithOptions(encoded, json.DecodeOptions{MaxDepth: 3})
+		require.NoError(t, err)
+
+		assert.Equal(t, value, decoded)
+
+		_, err = json.DecodeWithOptions(encoded, json.DecodeOptions{MaxDepth: 2})
+		require.Error(t, err)
+
+		var depthErr json.ValueDepthLimitExceededError
+		require.ErrorAs(t, err, &depthErr)
+
+		assert.Equal(t, 2, depthErr.Limit)
+	})
+
+	t.Run("decode, exceeds limit before nested values are decoded", func(t *testing.T) {
+		t.Parallel()
+
+		// The innermost value is invalid,
+		// but it is nested deeper than the limit, so it is never decoded
+
+		const encoded = `
+          {
+            "type": "Array",
+            "value": [
+              {
+                "type": "Array",
+                "value": [
+                  {"type": "Invalid", "value": "invalid"}
+                ]
+              }
+            ]
+          }
+        `
+
+		_, err := json.DecodeWithOptions([]byte(encoded), json.DecodeOptions{MaxDepth: 1})
+		require.Error(t, err)
+
+		var depthErr json.ValueDepthLimitExceededError
+		require.ErrorAs(t, err, &depthErr)
+
+		assert.Equal(t, 1, depthErr.Limit)
+	})
+
+	t.Run("non-container values are not nested", func(t *testing.T) {
+		t.Parallel()
+
+		value := cadence.NewArray([]cadence.Value{
+			cadence.NewOptional(nil),
+			cadence.NewInt(1),
+			cadence.Capability{
+				Path:       cadence.Path{Domain: "public", Identifier: "foo"},
+				Address:    cadence.BytesToAddress([]byte{1}),
+				BorrowType: cadence.IntType{},
+			},
+		})
+
+		encoded, err := json.EncodeWithOptions(value, json.Options{MaxDepth: 1})
+		require.NoError(t, err)
+
+		decoded, err := json.DecodeWithOptions(encoded, json.DecodeOptions{MaxDepth: 1})
+		require.NoError(t, err)
+
+		assert.Equal(t, value, decoded)
+	})
+}
+
 func testEncodeAndDecode(t *testing.T, val cadence.Value, expectedJSON string) {
 	actualJSON := testEncode(t, val, expectedJSON)
 	testDecode(t, actualJSON, val)
@@ -1903,3 +2272,233 @@ func TestNonUTF8StringEncoding(t *testing.T) {
 	assert.IsType(t, cadence.String(""), decodedValue)
 	assert.True(t, utf8.ValidString(decodedValue.String()))
 }
+
+func TestCompactEncoding(t *testing.T) {
+
+	t.Parallel()
+
+	structType := &cadence.StructType{
+		Location:            utils.TestLocation,
+		QualifiedIdentifier: "Foo",
+		Fields: []cadence.Field{
+			{
+				Identifier: "bar",
+				Type:       cadence.IntType{},
+			},
+			{
+				Identifier: "baz",
+				Type:       cadence.OptionalType{Type: cadence.StringType{}},
+			},
+			{
+				Identifier: "qux",
+				Type: cadence.FunctionType{
+					ReturnType: cadence.VoidType{},
+				},
+			},
+		},
+	}
+
+	enumType := &cadence.EnumType{
+		Location:            utils.TestLocation,
+		QualifiedIdentifier: "E",
+		RawType:             cadence.UInt8Type{},
+		Fields: []cadence.Field{
+			{
+				Identifier: sema.EnumRawValueFieldName,
+				Type:       cadence.UInt8Type{},
+			},
+		},
+	}
+
+	arrayType := cadence.VariableSizedArrayType{
+		ElementType: structType,
+	}
+
+	dictionaryType := cadence.DictionaryType{
+		KeyType:     cadence.AddressType{},
+		ElementType: cadence.OptionalType{Type: cadence.OptionalType{Type: cadence.UFix64Type{}}},
+	}
+
+	ufix64, err := cadence.NewUFix64("1.5")
+	require.NoError(t, err)
+
+	path, err := cadence.NewPath("storage", "foo")
+	require.NoError(t, err)
+
+	type testCase struct {
+		value    cadence.Value
+		schema   cadence.Type
+		expected string
+	}
+
+	for name, test := range map[string]testCase{
+		"Void": {
+			value:    cadence.NewVoid(),
+			schema:   cadence.VoidType{},
+			expected: `null`,
+		},
+		"Int": {
+			value:    cadence.NewInt(-42),
+			schema:   cadence.IntType{},
+			expected: `"-42"`,
+		},
+		"UInt256": {
+			value:    cadence.NewUInt256(42),
+			schema:   cadence.UInt256Type{},
+			expected: `"42"`,
+		},
+		"UFix64": {
+			value:    ufix64,
+			schema:   cadence.UFix64Type{},
+			expected: `"1.50000000"`,
+		},
+		"Bool": {
+			value:    cadence.NewBool(true),
+			schema:   cadence.BoolType{},
+			expected: `true`,
+		},
+		"String": {
+			value:    cadence.String("foo"),
+			schema:   cadence.StringType{},
+			expected: `"foo"`,
+		},
+		"Optional, nil": {
+			value:    cadence.NewOptional(nil),
+			schema:   cadence.OptionalType{Type: cadence.IntType{}},
+			expected: `null`,
+		},
+		"Optional, non-nil": {
+			value:    cadence.NewOptional(cadence.NewInt(1)),
+			schema:   cadence.OptionalType{Type: cadence.IntType{}},
+			expected: `"1"`,
+		},
+		"nested Optional": {
+			value: cadence.NewOptional(cadence.NewOptional(nil)),
+			schema: cadence.OptionalType{
+				Type: cadence.OptionalType{Type: cadence.IntType{}},
+			},
+			expected: `[null]`,
+		},
+		"Array of structs": {
+			value: cadence.NewArray([]cadence.Value{
+				cadence.NewStruct([]cadence.Value{
+					cadence.NewInt(1),
+					cadence.NewOptional(cadence.String("a")),
+				}).WithType(structType),
+				cadence.NewStruct([]cadence.Value{
+					cadence.NewInt(2),
+					cadence.NewOptional(nil),
+				}).WithType(structType),
+			}).WithType(arrayType),
+			schema:   arrayType,
+			expected: `[["1","a"],["2",null]]`,
+		},
+		"Dictionary": {
+			value: cadence.NewDictionary([]cadence.KeyValuePair{
+				{
+					Key:   cadence.BytesToAddress([]byte{0x1}),
+					Value: cadence.NewOptional(cadence.NewOptional(ufix64)),
+				},
+				{
+					Key:   cadence.BytesToAddress([]byte{0x2}),
+					Value: cadence.NewOptional(nil),
+				},
+			}).WithType(dictionaryType),
+			schema:   dictionaryType,
+			expected: `[["0x0000000000000001",["1.50000000"]],["0x0000000000000002",null]]`,
+		},
+		"Enum": {
+			value: cadence.NewEnum([]cadence.Value{
+				cadence.NewUInt8(1),
+			}).WithType(enumType),
+			schema:   enumType,
+			expected: `["1"]`,
+		},
+		"Path": {
+			value:    path,
+			schema:   cadence.StoragePathType{},
+			expected: `{"domain":"storage","identifier":"foo"}`,
+		},
+		"Type": {
+			value:    cadence.NewTypeValue(cadence.IntType{}),
+			schema:   cadence.MetaType{},
+			expected: `{"staticType":{"kind":"Int"}}`,
+		},
+		"AnyStruct": {
+			value:    cadence.NewInt(1),
+			schema:   cadence.AnyStructType{},
+			expected: `{"type":"Int","value":"1"}`,
+		},
+	} {
+		test := test
+
+		t.Run(name, func(t *testing.T) {
+
+			t.Parallel()
+
+			encoded, err := json.EncodeCompact(test.value, test.schema)
+			require.NoError(t, err)
+
+			assert.JSONEq(t, test.expected, string(encoded))
+
+			decoded, err := json.DecodeCompact(encoded, test.schema)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.value, decoded)
+		})
+	}
+
+	t.Run("smaller than full encoding", func(t *testing.T) {
+
+		t.Parallel()
+
+		value := cadence.NewArray([]cadence.Value{
+			cadence.NewStruct([]cadence.Value{
+				cadence.NewInt(1),
+				cadence.NewOptional(cadence.String("a")),
+			}).WithType(structType),
+		}).WithType(arrayType)
+
+		compact, err := json.EncodeCompact(value, arrayType)
+		require.NoError(t, err)
+
+		full, err := json.Encode(value)
+		require.NoError(t, err)
+
+		assert.Less(t, len(compact), len(full))
+	})
+
+	t.Run("non-conforming value", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := json.EncodeCompact(cadence.NewInt8(1), cadence.IntType{})
+		require.Error(t, err)
+
+		otherStructType := &cadence.StructType{
+			Location:            utils.TestLocation,
+			QualifiedIdentifier: "Bar",
+			Fields:              structType.Fields,
+		}
+
+		_, err = json.EncodeCompact(
+			cadence.NewStruct([]cadence.Value{
+				cadence.NewInt(1),
+				cadence.NewOptional(nil),
+			}).WithType(otherStructType),
+			structType,
+		)
+		require.Error(t, err)
+	})
+
+	t.Run("invalid compact JSON", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := json.DecodeCompact([]byte(`["1"]`), structType)
+		require.Error(t, err)
+
+		_, err = json.DecodeCompact([]byte(`[["0x1"]]`), dictionaryType)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,159 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2020 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package json
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/onflow/cadence"
+)
+
+// SchemaVersion1 is the first version of the JSON-Cadence format.
+// Payloads of this version are not tagged with a version.
+const SchemaVersion1 uint = 1
+
+// CurrentSchemaVersion is the version of the JSON-Cadence format produced by Encode.
+const CurrentSchemaVersion = SchemaVersion1
+
+// versionKey is the key of the top-level object which tags the payload with its schema version.
+// Payloads without a version tag are of version 1.
+const versionKey = "version"
+
+// Options are the options for encoding.
+type Options struct {
+	// Version is the target schema version.
+	// The zero value is the current schema version.
+	Version uint
+	// MaxDepth is the maximum nesting depth of the encoded value.
+	// The zero value is DefaultMaxDepth.
+	MaxDepth int
+}
+
+// DecodeOptions are the options for decoding.
+type DecodeOptions struct {
+	// MinVersion is the minimum schema version which is accepted.
+	// The zero value accepts all supported versions.
+	MinVersion uint
+	// MaxVersion is the maximum schema version which is accepted.
+	// The zero value is the current schema version.
+	MaxVersion uint
+	// MaxDepth is the maximum nesting depth of the decoded value.
+	// The zero value is DefaultMaxDepth.
+	MaxDepth int
+}
+
+// UnsupportedSchemaVersionError is returned when a value is encoded with,
+// or a payload is tagged with, a schema version which is not supported.
+type UnsupportedSchemaVersionError struct {
+	Version    uint
+	MinVersion uint
+	MaxVersion uint
+}
+
+func (e UnsupportedSchemaVersionError) Error() string {
+	return fmt.Sprintf(
+		"json-cdc: unsupported schema version %d: expected version between %d and %d",
+		e.Version,
+		e.MinVersion,
+		e.MaxVersion,
+	)
+}
+
+// versionRange returns the range of versions accepted by the decode options,
+// restricted to the supported versions.
+func (opts DecodeOptions) versionRange() (minVersion, maxVersion uint) {
+	minVersion = opts.MinVersion
+	if minVersion < SchemaVersion1 {
+		minVersion = SchemaVersion1
+	}
+
+	maxVersion = opts.MaxVersion
+	if maxVersion == 0 || maxVersion > CurrentSchemaVersion {
+		maxVersion = CurrentSchemaVersion
+	}
+
+	return
+}
+
+// EncodeWithOptions returns the JSON-encoded representation of the given value,
+// in the schema version given in the options.
+//
+// This function returns an UnsupportedSchemaVersionError if the schema version is not supported,
+// and an error if the Cadence value cannot be represented as JSON.
+//
+// The encoding of the current schema version is the same as the one of Encode.
+func EncodeWithOptions(value cadence.Value, opts Options) ([]byte, error) {
+	version := opts.Version
+	if version == 0 {
+		version = CurrentSchemaVersion
+	}
+
+	if version < SchemaVersion1 || version > CurrentSchemaVersion {
+		return nil, UnsupportedSchemaVersionError{
+			Version:    version,
+			MinVersion: SchemaVersion1,
+			MaxVersion: CurrentSchemaVersion,
+		}
+	}
+
+	// NOTE: version 1 payloads are not tagged
+
+	var w bytes.Buffer
+	enc := NewEncoder(&w)
+	enc.maxDepth = opts.MaxDepth
+
+	err := enc.Encode(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return w.Bytes(), nil
+}
+
+// DecodeWithOptions returns a Cadence value decoded from its JSON-encoded representation,
+// if the schema version of the payload is in the range given in the options.
+//
+// This function returns an UnsupportedSchemaVersionError if the schema version of the payload
+// is not in the range or is not supported, and an error if the bytes represent JSON that is malformed
+// or does not conform to the JSON Cadence specification.
+func DecodeWithOptions(b []byte, opts DecodeOptions) (cadence.Value, error) {
+	r := bytes.NewReader(b)
+	dec := NewDecoder(r)
+
+	return dec.decode(opts)
+}
+
+// decodeVersion removes the version tag from the given top-level object, if any,
+// and returns the schema version of the payload.
+func decodeVersion(jsonMap map[string]interface{}) uint {
+	versionJSON, ok := jsonMap[versionKey]
+	if !ok {
+		return SchemaVersion1
+	}
+
+	delete(jsonMap, versionKey)
+
+	version, ok := versionJSON.(float64)
+	if !ok || version < 0 || version != float64(uint(version)) {
+		panic(fmt.Errorf("%w: invalid schema version: %v", ErrInvalidJSONCadence, versionJSON))
+	}
+
+	return uint(version)
+}
@@ -0,0 +1,162 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2020 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cadence
+
+// ReferencedTypes returns all composite and interface types which are reachable from the given value,
+// in order of first occurrence, without duplicates.
+//
+// The reachable types are the types of composite values (e.g. the collection and the NFTs it contains),
+// and the composite and interface types occurring in the types of values,
+// e.g. in the element type of an array, the borrow type of a capability,
+// the restrictions of a restricted type, or the field types of a composite type.
+//
+func ReferencedTypes(value Value) []Type {
+	collector := referencedTypesCollector{
+		seen: map[string]struct{}{},
+	}
+	collector.collectValue(value)
+	return collector.types
+}
+
+type referencedTypesCollector struct {
+	seen  map[string]struct{}
+	types []Type
+}
+
+func (c *referencedTypesCollector) collectValue(value Value) {
+	switch value := value.(type) {
+	case Optional:
+		c.collectValue(value.Value)
+
+	case Array:
+		if value.ArrayType != nil {
+			c.collectType(value.ArrayType)
+		}
+		c.collectValues(value.Values)
+
+	case Dictionary:
+		c.collectType(value.DictionaryType)
+		for _, pair := range value.Pairs {
+			c.collectValue(pair.Key)
+			c.collectValue(pair.Value)
+		}
+
+	case Struct:
+		c.collectCompositeValue(value.StructType, value.Fields)
+
+	case Resource:
+		c.collectCompositeValue(value.ResourceType, value.Fields)
+
+	case Event:
+		c.collectCompositeValue(value.EventType, value.Fields)
+
+	case Contract:
+		c.collectCompositeValue(value.ContractType, value.Fields)
+
+	case Enum:
+		c.collectCompositeValue(value.EnumType, value.Fields)
+
+	case Capability:
+		c.collectType(value.BorrowType)
+
+	case TypeValue:
+		c.collectType(value.StaticType)
+	}
+}
+
+func (c *referencedTypesCollector) collectValues(values []Value) {
+	for _, value := range values {
+		c.collectValue(value)
+	}
+}
+
+func (c *referencedTypesCollector) collectCompositeValue(compositeType CompositeType, fields []Value) {
+	compositeType = nonNilCompositeType(compositeType)
+	if compositeType != nil {
+		c.collectType(compositeType)
+	}
+	c.collectValues(fields)
+}
+
+func (c *referencedTypesCollector) collectType(t Type) {
+	switch t := t.(type) {
+	case OptionalType:
+		c.collectType(t.Type)
+
+	case VariableSizedArrayType:
+		c.collectType(t.ElementType)
+
+	case ConstantSizedArrayType:
+		c.collectType(t.ElementType)
+
+	case DictionaryType:
+		c.collectType(t.KeyType)
+		c.collectType(t.ElementType)
+
+	case ReferenceType:
+		c.collectType(t.Type)
+
+	case RestrictedType:
+		c.collectType(t.Type)
+		for _, restriction := range t.Restrictions {
+			c.collectType(restriction)
+		}
+
+	case CapabilityType:
+		c.collectType(t.BorrowType)
+
+	case FunctionType:
+		for _, parameter := range t.Parameters {
+			c.collectType(parameter.Type)
+		}
+		c.collectType(t.ReturnType)
+
+	case CompositeType:
+		if nonNilCompositeType(t) == nil {
+			return
+		}
+		if c.add(t) {
+			c.collectFieldTypes(t.CompositeFields())
+		}
+
+	case InterfaceType:
+		if c.add(t) {
+			c.collectFieldTypes(t.InterfaceFields())
+		}
+	}
+}
+
+func (c *referencedTypesCollector) collectFieldTypes(fields []Field) {
+	for _, field := range fields {
+		c.collectType(field.Type)
+	}
+}
+
+// add adds the given type, if it was not added before,
+// and returns true if it was added.
+//
+func (c *referencedTypesCollector) add(t Type) bool {
+	id := t.ID()
+	if _, ok := c.seen[id]; ok {
+		return false
+	}
+	c.seen[id] = struct{}{}
+	c.types = append(c.types, t)
+	return true
+}
@@ -540,6 +540,36 @@ func TestRuntimePublicAccountKeys(t *testing.T) {
 		assert.Equal(t, accountKeyB, storage.returnedKey)
 	})
 
+	t.Run("get key weight", func(t *testing.T) {
+
+		t.Parallel()
+
+		storage := newTestAccountKeyStorage()
+		storage.keys = append(storage.keys, accountKeyA, accountKeyB)
+
+		runtime := newTestInterpreterRuntime()
+		runtimeInterface := getAccountKeyTestRuntimeInterface(storage)
+
+		test := accountKeyTestCase{
+			code: `
+              pub fun main(): UFix64? {
+                  let acc = getAccount(0x02)
+                  return acc.keys.get(keyIndex: 1)?.weight
+              }
+            `,
+			args: []cadence.Value{},
+		}
+
+		value, err := test.executeScript(runtime, runtimeInterface)
+		require.NoError(t, err)
+
+		expectedWeight, err := cadence.NewUFix64("100.0")
+		require.NoError(t, err)
+
+		assert.Equal(t, cadence.NewOptional(expectedWeight), value)
+		assert.Equal(t, accountKeyB, storage.returnedKey)
+	})
+
 	t.Run("get non-existing key", func(t *testing.T) {
 
 		t.Parallel()
@@ -27,6 +27,7 @@ import (
 type FunctionDeclaration struct {
 	Access               Access
 	Identifier           Identifier
+	TypeParameters       []*TypeParameter `json:",omitempty"`
 	ParameterList        *ParameterList
 	ReturnTypeAnnotation *TypeAnnotation
 	FunctionBlock        *FunctionBlock
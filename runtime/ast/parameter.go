@@ -36,3 +36,13 @@ func (p Parameter) EffectiveArgumentLabel() string {
 	}
 	return p.Identifier.Identifier
 }
+
+// TypeParameter is a type parameter of a function declaration,
+// e.g. `T` in `fun identity<T: AnyStruct>(_ value: T): T`.
+//
+// The type bound is optional, i.e. it is nil if no type bound is declared.
+//
+type TypeParameter struct {
+	Identifier Identifier
+	TypeBound  Type
+}
@@ -0,0 +1,242 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2020 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runtime
+
+import (
+	"github.com/onflow/atree"
+
+	"github.com/onflow/cadence"
+)
+
+// BatchTransaction is a transaction which is executed as part of a batch.
+//
+type BatchTransaction struct {
+	Script   Script
+	Location Location
+}
+
+// TransactionResult is the result of a transaction which was executed as part of a batch.
+//
+type TransactionResult struct {
+	Location Location
+	// Events are the events emitted by the transaction, in emission order.
+	// The events of a failed transaction are discarded.
+	Events []cadence.Event
+	// Err is the error of the transaction, if it failed.
+	Err error
+}
+
+// TransactionResults are the results of the transactions of a batch,
+// in the order of the transactions.
+//
+type TransactionResults []TransactionResult
+
+// BatchEvent is an event emitted by a transaction of a batch.
+//
+type BatchEvent struct {
+	// TransactionIndex is the index of the emitting transaction in the batch.
+	TransactionIndex int
+	// EventIndex is the index of the event in the events of the emitting transaction.
+	EventIndex int
+	Event      cadence.Event
+}
+
+// Events returns the events of all transactions of the batch as one stream,
+// ordered by transaction order, and then by emission order within each transaction.
+//
+func (results TransactionResults) Events() []BatchEvent {
+	var events []BatchEvent
+
+	for transactionIndex, result := range results {
+		for eventIndex, event := range result.Events {
+			events = append(
+				events,
+				BatchEvent{
+					TransactionIndex: transactionIndex,
+					EventIndex:       eventIndex,
+					Event:            event,
+				},
+			)
+		}
+	}
+
+	return events
+}
+
+// eventRecordingInterface is a runtime interface which records the events emitted by a transaction of a batch,
+// instead of passing them on to the wrapped runtime interface,
+// so they are only passed on once the transaction was executed successfully (see forwardEvents).
+//
+type eventRecordingInterface struct {
+	Interface
+	events []cadence.Event
+	// indexedFields are the names of the indexed fields of the recorded events,
+	// at the same index as the event
+	indexedFields [][]string
+}
+
+func (i *eventRecordingInterface) EmitEvent(event cadence.Event) error {
+	i.events = append(i.events, event)
+	i.indexedFields = append(i.indexedFields, nil)
+	return nil
+}
+
+// EmitEventWithIndex records the given event, together with the names of its indexed fields.
+//
+func (i *eventRecordingInterface) EmitEventWithIndex(event cadence.Event, indexedFields []string) error {
+	i.events = append(i.events, event)
+	i.indexedFields = append(i.indexedFields, indexedFields)
+	return nil
+}
+
+// EmitEvents records the given events.
+//
+func (i *eventRecordingInterface) EmitEvents(events []cadence.Event) error {
+	i.events = append(i.events, events...)
+	i.indexedFields = append(i.indexedFields, make([][]string, len(events))...)
+	return nil
+}
+
+// EmitEventsWithIndex records the given events, together with the names of their indexed fields.
+//
+func (i *eventRecordingInterface) EmitEventsWithIndex(events []cadence.Event, indexedFields [][]string) error {
+	i.events = append(i.events, events...)
+	i.indexedFields = append(i.indexedFields, indexedFields...)
+	return nil
+}
+
+// forwardEvents passes the recorded events on to the wrapped runtime interface,
+// in one batch if it implements BatchEventEmitter (see emitEventsWithIndex).
+//
+func (i *eventRecordingInterface) forwardEvents() (err error) {
+	if len(i.events) == 0 {
+		return nil
+	}
+
+	wrapPanic(func() {
+		err = emitEventsWithIndex(i.Interface, i.events, i.indexedFields)
+	})
+	return err
+}
+
+// unwrapInterface returns the runtime interface provided by the host,
+// i.e. the interface wrapped by the runtime, if any.
+//
+// Optional runtime interfaces, like Metrics, must be detected
+// on the unwrapped runtime interface.
+//
+func unwrapInterface(runtimeInterface Interface) Interface {
+	for {
+		switch wrappingInterface := runtimeInterface.(type) {
+		case *eventRecordingInterface:
+			runtimeInterface = wrappingInterface.Interface
+		case *eventBufferingInterface:
+			runtimeInterface = wrappingInterface.Interface
+		case *reproCaseRecordingInterface:
+			runtimeInterface = wrappingInterface.Interface
+		case *reproCaseReplayInterface:
+			runtimeInterface = wrappingInterface.Interface
+		default:
+			return runtimeInterface
+		}
+	}
+}
+
+// getStorageCapacityProvider returns the provider of the storage capacity of accounts,
+// i.e. the runtime interface provided by the host, if it implements StorageCapacityProvider.
+//
+// The repro case interfaces record and replay the storage capacity,
+// so they are the provider if they wrap the runtime interface provided by the host.
+//
+func getStorageCapacityProvider(runtimeInterface Interface) (StorageCapacityProvider, bool) {
+	for {
+		switch wrappingInterface := runtimeInterface.(type) {
+		case *eventRecordingInterface:
+			runtimeInterface = wrappingInterface.Interface
+		case *eventBufferingInterface:
+			runtimeInterface = wrappingInterface.Interface
+		case *reproCaseRecordingInterface:
+			return wrappingInterface, true
+		case *reproCaseReplayInterface:
+			return wrappingInterface, true
+		default:
+			provider, ok := runtimeInterface.(StorageCapacityProvider)
+			return provider, ok
+		}
+	}
+}
+
+// unwrapLedger returns the ledger provided by the host,
+// i.e. if the given ledger is a runtime interface, the runtime interface wrapped by the runtime, if any.
+//
+// Optional interfaces of the ledger, like AccountRegisterIterator, must be detected
+// on the unwrapped ledger.
+//
+func unwrapLedger(ledger atree.Ledger) atree.Ledger {
+	if runtimeInterface, ok := ledger.(Interface); ok {
+		return unwrapInterface(runtimeInterface)
+	}
+	return ledger
+}
+
+func (r *interpreterRuntime) ExecuteTransactionBatch(
+	transactions []BatchTransaction,
+	context Context,
+) TransactionResults {
+
+	results := make(TransactionResults, len(transactions))
+
+	// The transactions are executed sequentially,
+	// so each transaction observes the effects of the transactions before it,
+	// and the results are deterministic
+
+	for i, transaction := range transactions {
+
+		recordingInterface := &eventRecordingInterface{
+			Interface: context.Interface,
+		}
+
+		transactionContext := context.WithLocation(transaction.Location)
+		transactionContext.Interface = recordingInterface
+
+		err := r.ExecuteTransaction(transaction.Script, transactionContext)
+
+		// Only pass the events on to the host if the transaction succeeded,
+		// even if the host does not accept them in one batch
+
+		if err == nil {
+			err = recordingInterface.forwardEvents()
+			if err != nil {
+				err = newError(err, transactionContext)
+			}
+		}
+
+		result := TransactionResult{
+			Location: transaction.Location,
+			Err:      err,
+		}
+		if err == nil {
+			result.Events = recordingInterface.events
+		}
+
+		results[i] = result
+	}
+
+	return results
+}
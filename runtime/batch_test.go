@@ -0,0 +1,286 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2020 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runtime
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/cadence/runtime/tests/utils"
+)
+
+func TestRuntimeExecuteTransactionBatch(t *testing.T) {
+
+	t.Parallel()
+
+	runtime := newTestInterpreterRuntime()
+
+	address := common.BytesToAddress([]byte{0x1})
+
+	const contract = `
+      pub contract Test {
+
+          pub event Emitted(index: Int)
+
+          pub fun emitEvents(count: Int) {
+              var index = 0
+              while index < count {
+                  emit Emitted(index: index)
+                  index = index + 1
+              }
+          }
+      }
+    `
+
+	emitTransaction := func(count int, fail bool) []byte {
+		var failure string
+		if fail {
+			failure = `panic("failed")`
+		}
+
+		return []byte(fmt.Sprintf(
+			`
+              import Test from 0x1
+
+              transaction {
+                  prepare(signer: AuthAccount) {
+                      Test.emitEvents(count: %d)
+                      %s
+                  }
+              }
+            `,
+			count,
+			failure,
+		))
+	}
+
+	accountCodes := map[common.LocationID][]byte{}
+	var hostEvents []cadence.Event
+
+	runtimeInterface := &testRuntimeInterface{
+		storage: newTestLedger(nil, nil),
+		getSigningAccounts: func() ([]Address, error) {
+			return []Address{address}, nil
+		},
+		resolveLocation: singleIdentifierLocationResolver(t),
+		getAccountContractCode: func(address Address, name string) (code []byte, err error) {
+			location := common.AddressLocation{
+				Address: address,
+				Name:    name,
+			}
+			return accountCodes[location.ID()], nil
+		},
+		updateAccountContractCode: func(address Address, name string, code []byte) error {
+			location := common.AddressLocation{
+				Address: address,
+				Name:    name,
+			}
+			accountCodes[location.ID()] = code
+			return nil
+		},
+		emitEvent: func(event cadence.Event) error {
+			hostEvents = append(hostEvents, event)
+			return nil
+		},
+	}
+
+	nextTransactionLocation := newTransactionLocationGenerator()
+
+	newBatchTransaction := func(source []byte) BatchTransaction {
+		return BatchTransaction{
+			Script: Script{
+				Source: source,
+			},
+			Location: nextTransactionLocation(),
+		}
+	}
+
+	eventTypeID := "A.0000000000000001.Test.Emitted"
+
+	t.Run("ordered events", func(t *testing.T) {
+
+		transactions := []BatchTransaction{
+			newBatchTransaction(utils.DeploymentTransaction("Test", []byte(contract))),
+			newBatchTransaction(emitTransaction(2, false)),
+			newBatchTransaction(emitTransaction(3, false)),
+		}
+
+		results := runtime.ExecuteTransactionBatch(
+			transactions,
+			Context{
+				Interface: runtimeInterface,
+			},
+		)
+		require.Len(t, results, 3)
+
+		type eventInfo struct {
+			transactionIndex int
+			eventIndex       int
+			typeID           string
+			index            cadence.Value
+		}
+
+		var events []eventInfo
+
+		for transactionIndex, result := range results {
+			require.NoError(t, result.Err)
+			assert.Equal(t, transactions[transactionIndex].Location, result.Location)
+
+			for eventIndex, event := range result.Events {
+				var index cadence.Value
+				if event.EventType.ID() == eventTypeID {
+					index = event.Fields[0]
+				}
+
+				events = append(events, eventInfo{
+					transactionIndex: transactionIndex,
+					eventIndex:       eventIndex,
+					typeID:           event.EventType.ID(),
+					index:            index,
+				})
+			}
+		}
+
+		assert.Equal(t,
+			[]eventInfo{
+				{0, 0, "flow.AccountContractAdded", nil},
+				{1, 0, eventTypeID, cadence.NewInt(0)},
+				{1, 1, eventTypeID, cadence.NewInt(1)},
+				{2, 0, eventTypeID, cadence.NewInt(0)},
+				{2, 1, eventTypeID, cadence.NewInt(1)},
+				{2, 2, eventTypeID, cadence.NewInt(2)},
+			},
+			events,
+		)
+
+		// The combined stream is ordered by transaction order,
+		// and then by emission order
+
+		batchEvents := results.Events()
+		require.Len(t, batchEvents, len(events))
+
+		for i, batchEvent := range batchEvents {
+			assert.Equal(t, events[i].transactionIndex, batchEvent.TransactionIndex)
+			assert.Equal(t, events[i].eventIndex, batchEvent.EventIndex)
+			assert.Equal(t,
+				results[batchEvent.TransactionIndex].Events[batchEvent.EventIndex],
+				batchEvent.Event,
+			)
+		}
+
+		// The events are still emitted to the host
+
+		require.Len(t, hostEvents, len(events))
+		assert.Equal(t, "flow.AccountContractAdded", hostEvents[0].EventType.ID())
+	})
+
+	t.Run("failed transaction", func(t *testing.T) {
+
+		hostEvents = nil
+
+		results := runtime.ExecuteTransactionBatch(
+			[]BatchTransaction{
+				newBatchTransaction(emitTransaction(1, false)),
+				newBatchTransaction(emitTransaction(1, true)),
+				newBatchTransaction(emitTransaction(1, false)),
+			},
+			Context{
+				Interface: runtimeInterface,
+			},
+		)
+		require.Len(t, results, 3)
+
+		require.NoError(t, results[0].Err)
+		require.Error(t, results[1].Err)
+		require.NoError(t, results[2].Err)
+
+		assert.Len(t, results[0].Events, 1)
+		assert.Empty(t, results[1].Events)
+		assert.Len(t, results[2].Events, 1)
+
+		batchEvents := results.Events()
+		require.Len(t, batchEvents, 2)
+
+		assert.Equal(t, 0, batchEvents[0].TransactionIndex)
+		assert.Equal(t, 2, batchEvents[1].TransactionIndex)
+
+		// The events of the failed transaction are not emitted to the host
+
+		require.Len(t, hostEvents, 2)
+		assert.Equal(t, batchEvents[0].Event, hostEvents[0])
+		assert.Equal(t, batchEvents[1].Event, hostEvents[1])
+	})
+}
+
+func TestRuntimeExecuteTransactionBatchComputationMetering(t *testing.T) {
+
+	t.Parallel()
+
+	runtime := newTestInterpreterRuntime()
+
+	address := common.BytesToAddress([]byte{0x1})
+
+	meteredIntensities := map[ComputationKind]uint{}
+
+	runtimeInterface := testComputationMeterRuntimeInterface{
+		testRuntimeInterface: &testRuntimeInterface{
+			storage: newTestLedger(nil, nil),
+			getSigningAccounts: func() ([]Address, error) {
+				return []Address{address}, nil
+			},
+		},
+		meterComputation: func(kind ComputationKind, intensity uint) error {
+			meteredIntensities[kind] += intensity
+			return nil
+		},
+	}
+
+	nextTransactionLocation := newTransactionLocationGenerator()
+
+	results := runtime.ExecuteTransactionBatch(
+		[]BatchTransaction{
+			{
+				Script: Script{
+					Source: []byte(`
+                      transaction {
+                          prepare(signer: AuthAccount) {
+                              signer.save([1, 2, 3], to: /storage/numbers)
+                          }
+                      }
+                    `),
+				},
+				Location: nextTransactionLocation(),
+			},
+		},
+		Context{
+			Interface: runtimeInterface,
+		},
+	)
+	require.Len(t, results, 1)
+	require.NoError(t, results[0].Err)
+
+	// The storage of the transactions in the batch is metered
+
+	require.NotZero(t, meteredIntensities[ComputationKindStorageWrite])
+}
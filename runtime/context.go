@@ -19,16 +19,92 @@
 package runtime
 
 import (
+	"github.com/onflow/cadence"
 	"github.com/onflow/cadence/runtime/ast"
 	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/cadence/runtime/interpreter"
+	"github.com/onflow/cadence/runtime/sema"
 )
 
+// UnknownTypeHandlerFunc is a function which is called when a value is exported,
+// but the value's type cannot be found, e.g. because the declaring contract was removed.
+//
+// The function may return a substitute type (e.g. a placeholder type),
+// which is used to export the value instead.
+// If the function returns an error, the export fails.
+//
+type UnknownTypeHandlerFunc func(typeID string) (cadence.Type, error)
+
 type Context struct {
 	Interface         Interface
 	Location          Location
 	PredeclaredValues []ValueDeclaration
-	codes             map[common.LocationID]string
-	programs          map[common.LocationID]*ast.Program
+	// ContractCodeCache is an optional cache for the programs of contracts,
+	// which is used across executions.
+	ContractCodeCache ContractCodeCache
+	// UnknownTypeHandler is an optional handler for types of stored values
+	// which cannot be found when the values are read.
+	UnknownTypeHandler UnknownTypeHandlerFunc
+	// MaxBorrowChainLength is the maximum number of borrows a single statement may perform.
+	// Zero means the number of borrows is unlimited.
+	MaxBorrowChainLength int
+	// RejectStoredReferences determines if the checker rejects programs
+	// which save values that statically contain references.
+	// Such values are always rejected at run-time.
+	RejectStoredReferences bool
+	// AllowEscapingReferences determines if the checker accepts programs
+	// in which references to local variables escape their function, e.g. are returned.
+	// By default, such programs are rejected.
+	// The check can be disabled, as existing contracts may contain such references.
+	AllowEscapingReferences bool
+	// ValidateStoredCapabilities determines if the capabilities which are stored
+	// are validated when the storage is committed, i.e. if it is checked that their links resolve to a stored value.
+	// All capabilities which are transferred into the storage are validated,
+	// e.g. saved or inserted into a stored container.
+	ValidateStoredCapabilities bool
+	// ReadOnly determines if the execution is read-only,
+	// i.e. if all storage mutations are rejected with a ReadOnlyViolationError.
+	ReadOnly bool
+	// MaxValueDepth is the maximum nesting depth of stored values.
+	// Zero means the interpreter's default limit, interpreter.DefaultMaxValueDepth.
+	MaxValueDepth int
+	// MaxTypeDepth is the maximum nesting depth of type expressions in checked programs.
+	// Zero means the checker's default limit, sema.DefaultMaxTypeDepth.
+	MaxTypeDepth int
+	// StorageKeyPrefix is an optional prefix for the keys of all registers of the storage,
+	// which namespaces the storage, e.g. to let multiple independent runtimes share one ledger.
+	// The prefix must not contain interpreter.StorageKeySeparator. See NewNamespacedStorage.
+	StorageKeyPrefix string
+	// MaxStorageReads is the maximum number of registers the storage may read from the ledger.
+	// Zero means the number of reads is unlimited.
+	MaxStorageReads int
+	// MaxLoopIterations is the maximum number of loop iterations an execution may perform.
+	// Zero means the number of loop iterations is unlimited.
+	MaxLoopIterations uint64
+	// MaxEvents is the maximum number of events an execution may emit.
+	// Zero means the number of events is unlimited.
+	MaxEvents uint64
+	// CacheStorageUsed determines if the amounts of storage used by accounts are cached during the execution.
+	// If enabled, repeated reads of the storage used by an account only query the host environment once,
+	// until the storage of the account is written.
+	CacheStorageUsed bool
+	// ResolveCapabilityChains determines if capabilities read by ReadStored and ReadLinked are resolved.
+	// If enabled, the exported capability includes the chain of paths through which it is linked,
+	// see cadence.Capability.Chain.
+	ResolveCapabilityChains bool
+	// ResourceHistory is an optional diagnostic record of resource moves.
+	// If set, the moves of resources (creation, saves, loads, returns, field assignments, and destruction)
+	// are recorded, and included in the messages of resource errors, e.g. resource loss or invalidation.
+	// Nil disables the recording.
+	ResourceHistory *interpreter.ResourceHistory
+	// OnContractCodeLoaded is an optional function which is called when a contract is loaded
+	// during the execution, e.g. when the contract is imported,
+	// no matter if its code is fetched, or its program is provided by Interface.GetProgram.
+	// It is called once per distinct contract, even if the contract is loaded multiple times.
+	OnContractCodeLoaded func(location common.AddressLocation)
+	codes                map[common.LocationID]string
+	programs             map[common.LocationID]*ast.Program
+	loadedContracts      map[common.LocationID]struct{}
 }
 
 func (c Context) SetCode(location common.Location, code string) {
@@ -53,4 +129,35 @@ func (c *Context) InitializeCodesAndPrograms() {
 	if c.programs == nil {
 		c.programs = map[common.LocationID]*ast.Program{}
 	}
+
+	if c.loadedContracts == nil {
+		c.loadedContracts = map[common.LocationID]struct{}{}
+	}
+}
+
+// maxTypeDepth returns the maximum nesting depth of type expressions,
+// i.e. MaxTypeDepth, or the checker's default limit if it is not set.
+//
+func (c Context) maxTypeDepth() int {
+	if c.MaxTypeDepth > 0 {
+		return c.MaxTypeDepth
+	}
+	return sema.DefaultMaxTypeDepth
+}
+
+// recordContractCodeLoaded calls the OnContractCodeLoaded function, if any,
+// if the code of the contract at the given location was not loaded before in the execution.
+//
+func (c Context) recordContractCodeLoaded(location common.AddressLocation) {
+	if c.OnContractCodeLoaded == nil {
+		return
+	}
+
+	locationID := location.ID()
+	if _, ok := c.loadedContracts[locationID]; ok {
+		return
+	}
+	c.loadedContracts[locationID] = struct{}{}
+
+	c.OnContractCodeLoaded(location)
 }
@@ -0,0 +1,256 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2020 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runtime
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+
+	"golang.org/x/crypto/sha3"
+
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/cadence/runtime/interpreter"
+	"github.com/onflow/cadence/runtime/sema"
+)
+
+// CodeHash is the hash of the code of a contract.
+//
+type CodeHash [32]byte
+
+// ContractCodeHash returns the hash of the given contract code.
+//
+func ContractCodeHash(code []byte) CodeHash {
+	return sha3.Sum256(code)
+}
+
+// CheckerFingerprint is the fingerprint of the configuration of the checker which checked a program,
+// e.g. the predeclared values and the enabled checks.
+//
+type CheckerFingerprint [32]byte
+
+// ContractCodeCache caches parsed and checked programs of contracts across executions.
+//
+// Programs are keyed by location, code hash, and checker fingerprint,
+// so a program is only reused if the code did not change,
+// and if it would be checked the same way.
+// The runtime additionally invalidates the cached programs of a location
+// when the contract at the location is updated or removed.
+//
+// As the checked program of a contract depends on the programs it imports,
+// invalidating a location must also invalidate the programs which import it.
+//
+type ContractCodeCache interface {
+	// GetProgram returns the cached program for the given location, code hash, and checker fingerprint, if any.
+	GetProgram(location common.Location, codeHash CodeHash, fingerprint CheckerFingerprint) *interpreter.Program
+	// SetProgram caches the given program for the given location, code hash, and checker fingerprint.
+	SetProgram(location common.Location, codeHash CodeHash, fingerprint CheckerFingerprint, program *interpreter.Program)
+	// Invalidate removes all cached programs for the given location,
+	// and all cached programs which directly or indirectly import it.
+	Invalidate(location common.Location)
+}
+
+// ContractCodeCacheStats are the hit and miss statistics of a contract code cache.
+//
+type ContractCodeCacheStats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+type contractCodeCacheKey struct {
+	LocationID         common.LocationID
+	CodeHash           CodeHash
+	CheckerFingerprint CheckerFingerprint
+}
+
+type contractCodeCacheEntry struct {
+	location common.Location
+	program  *interpreter.Program
+}
+
+// InMemoryContractCodeCache is a contract code cache which keeps programs in memory.
+// It is safe for concurrent use.
+//
+type InMemoryContractCodeCache struct {
+	mutex    sync.Mutex
+	programs map[contractCodeCacheKey]contractCodeCacheEntry
+	stats    ContractCodeCacheStats
+}
+
+var _ ContractCodeCache = &InMemoryContractCodeCache{}
+
+func NewInMemoryContractCodeCache() *InMemoryContractCodeCache {
+	return &InMemoryContractCodeCache{
+		programs: map[contractCodeCacheKey]contractCodeCacheEntry{},
+	}
+}
+
+func (c *InMemoryContractCodeCache) GetProgram(
+	location common.Location,
+	codeHash CodeHash,
+	fingerprint CheckerFingerprint,
+) *interpreter.Program {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	key := contractCodeCacheKey{
+		LocationID:         location.ID(),
+		CodeHash:           codeHash,
+		CheckerFingerprint: fingerprint,
+	}
+
+	entry, ok := c.programs[key]
+	if !ok {
+		c.stats.Misses++
+		return nil
+	}
+
+	c.stats.Hits++
+
+	return entry.program
+}
+
+func (c *InMemoryContractCodeCache) SetProgram(
+	location common.Location,
+	codeHash CodeHash,
+	fingerprint CheckerFingerprint,
+	program *interpreter.Program,
+) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	key := contractCodeCacheKey{
+		LocationID:         location.ID(),
+		CodeHash:           codeHash,
+		CheckerFingerprint: fingerprint,
+	}
+
+	c.programs[key] = contractCodeCacheEntry{
+		location: location,
+		program:  program,
+	}
+}
+
+func (c *InMemoryContractCodeCache) Invalidate(location common.Location) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	invalidated := []common.Location{location}
+
+	for len(invalidated) > 0 {
+		lastIndex := len(invalidated) - 1
+		location := invalidated[lastIndex]
+		invalidated = invalidated[:lastIndex]
+
+		locationID := location.ID()
+
+		// NOTE: map range is safe, as it only deletes entries,
+		// and the order of deletion does not matter
+		for key, entry := range c.programs { //nolint:maprangecheck
+			if key.LocationID == locationID {
+				delete(c.programs, key)
+				continue
+			}
+
+			if importsLocation(entry.program, location) {
+				delete(c.programs, key)
+				invalidated = append(invalidated, entry.location)
+			}
+		}
+	}
+}
+
+// checkerFingerprint returns the fingerprint of the configuration of the checker
+// which checks the program at the location of the given context,
+// with the given predeclared values, if they are available.
+//
+// The import rewriting of the host environment cannot be fingerprinted,
+// only if imports are rewritten at all.
+// A host environment which changes how imports are rewritten must invalidate the cached programs.
+//
+func checkerFingerprint(context Context, valueDeclarations []sema.ValueDeclaration) CheckerFingerprint {
+	var buffer bytes.Buffer
+
+	_, rewritesImports := unwrapInterface(context.Interface).(ImportRewriter)
+
+	_, _ = fmt.Fprintf(
+		&buffer,
+		"%t %t %d %t",
+		context.RejectStoredReferences,
+		context.AllowEscapingReferences,
+		context.maxTypeDepth(),
+		rewritesImports,
+	)
+
+	for _, declaration := range valueDeclarations {
+		if !declaration.ValueDeclarationAvailable(context.Location) {
+			continue
+		}
+
+		var typeID sema.TypeID
+		if declarationType := declaration.ValueDeclarationType(); declarationType != nil {
+			typeID = declarationType.ID()
+		}
+
+		_, _ = fmt.Fprintf(
+			&buffer,
+			"\x00%s %s %s",
+			declaration.ValueDeclarationName(),
+			declaration.ValueDeclarationKind(),
+			typeID,
+		)
+	}
+
+	return sha3.Sum256(buffer.Bytes())
+}
+
+// importsLocation returns true if the given program imports the given location.
+//
+// Imports of address locations are resolved by the host environment,
+// so an import of any contract of the address is conservatively considered
+// to be an import of all contracts of the address.
+//
+func importsLocation(program *interpreter.Program, location common.Location) bool {
+	for _, declaration := range program.Program.ImportDeclarations() {
+		importedLocation := declaration.Location
+
+		if importedAddressLocation, ok := importedLocation.(common.AddressLocation); ok {
+			addressLocation, ok := location.(common.AddressLocation)
+			if ok && importedAddressLocation.Address == addressLocation.Address {
+				return true
+			}
+			continue
+		}
+
+		if importedLocation.ID() == location.ID() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Stats returns the hit and miss statistics of the cache.
+//
+func (c *InMemoryContractCodeCache) Stats() ContractCodeCacheStats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.stats
+}
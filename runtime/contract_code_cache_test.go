@@ -0,0 +1,417 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2020 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runtime
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/cadence/runtime/interpreter"
+	"github.com/onflow/cadence/runtime/sema"
+	"github.com/onflow/cadence/runtime/tests/utils"
+)
+
+func TestRuntimeContractCodeCache(t *testing.T) {
+
+	t.Parallel()
+
+	runtime := newTestInterpreterRuntime()
+
+	address := common.BytesToAddress([]byte{0x1})
+
+	contract := []byte(`
+      pub contract Test {
+          pub fun answer(): Int {
+              return 42
+          }
+      }
+    `)
+
+	updatedContract := []byte(`
+      pub contract Test {
+          pub fun answer(): Int {
+              return 43
+          }
+      }
+    `)
+
+	script := []byte(`
+      import Test from 0x1
+
+      pub fun main(): Int {
+          return Test.answer()
+      }
+    `)
+
+	accountCodes := map[common.LocationID][]byte{}
+
+	runtimeInterface := &testRuntimeInterface{
+		storage: newTestLedger(nil, nil),
+		getSigningAccounts: func() ([]Address, error) {
+			return []Address{address}, nil
+		},
+		// NOTE: do not keep programs in the host environment,
+		// so the contract code cache is used
+		getProgram: func(_ Location) (*interpreter.Program, error) {
+			return nil, nil
+		},
+		setProgram: func(_ Location, _ *interpreter.Program) error {
+			return nil
+		},
+		resolveLocation: singleIdentifierLocationResolver(t),
+		getAccountContractCode: func(address Address, name string) (code []byte, err error) {
+			location := common.AddressLocation{
+				Address: address,
+				Name:    name,
+			}
+			return accountCodes[location.ID()], nil
+		},
+		updateAccountContractCode: func(address Address, name string, code []byte) error {
+			location := common.AddressLocation{
+				Address: address,
+				Name:    name,
+			}
+			accountCodes[location.ID()] = code
+			return nil
+		},
+		emitEvent: func(event cadence.Event) error {
+			return nil
+		},
+	}
+
+	cache := NewInMemoryContractCodeCache()
+
+	nextTransactionLocation := newTransactionLocationGenerator()
+
+	err := runtime.ExecuteTransaction(
+		Script{
+			Source: utils.DeploymentTransaction("Test", contract),
+		},
+		Context{
+			Interface:         runtimeInterface,
+			Location:          nextTransactionLocation(),
+			ContractCodeCache: cache,
+		},
+	)
+	require.NoError(t, err)
+
+	executeScript := func() cadence.Value {
+		result, err := runtime.ExecuteScript(
+			Script{
+				Source: script,
+			},
+			Context{
+				Interface:         runtimeInterface,
+				Location:          common.ScriptLocation{},
+				ContractCodeCache: cache,
+			},
+		)
+		require.NoError(t, err)
+		return result
+	}
+
+	// The first execution parses and checks the contract once
+	// (the import is resolved by both the checker and the interpreter),
+	// the second execution only reuses the cached program
+
+	require.Equal(t, cadence.NewInt(42), executeScript())
+	require.Equal(t, ContractCodeCacheStats{Hits: 1, Misses: 1}, cache.Stats())
+
+	require.Equal(t, cadence.NewInt(42), executeScript())
+	require.Equal(t, ContractCodeCacheStats{Hits: 3, Misses: 1}, cache.Stats())
+
+	// Updating the contract invalidates the cached program
+
+	err = runtime.ExecuteTransaction(
+		Script{
+			Source: utils.UpdateTransaction("Test", updatedContract),
+		},
+		Context{
+			Interface:         runtimeInterface,
+			Location:          nextTransactionLocation(),
+			ContractCodeCache: cache,
+		},
+	)
+	require.NoError(t, err)
+
+	require.Equal(t, cadence.NewInt(43), executeScript())
+	require.Equal(t, ContractCodeCacheStats{Hits: 4, Misses: 2}, cache.Stats())
+}
+
+func TestRuntimeContractCodeCacheDependencyUpdate(t *testing.T) {
+
+	t.Parallel()
+
+	runtime := newTestInterpreterRuntime()
+
+	address := common.BytesToAddress([]byte{0x1})
+
+	contract := []byte(`
+      pub contract Test {
+          pub fun answer(): Int {
+              return 42
+          }
+      }
+    `)
+
+	updatedContract := []byte(`
+      pub contract Test {
+          pub fun answer(): String {
+              return "43"
+          }
+      }
+    `)
+
+	dependentContract := []byte(`
+      import Test from 0x1
+
+      pub contract Dependent {
+          pub fun answer(): Int {
+              return Test.answer()
+          }
+      }
+    `)
+
+	script := []byte(`
+      import Dependent from 0x1
+
+      pub fun main(): Int {
+          return Dependent.answer()
+      }
+    `)
+
+	accountCodes := map[common.LocationID][]byte{}
+
+	runtimeInterface := &testRuntimeInterface{
+		storage: newTestLedger(nil, nil),
+		getSigningAccounts: func() ([]Address, error) {
+			return []Address{address}, nil
+		},
+		// NOTE: do not keep programs in the host environment,
+		// so the contract code cache is used
+		getProgram: func(_ Location) (*interpreter.Program, error) {
+			return nil, nil
+		},
+		setProgram: func(_ Location, _ *interpreter.Program) error {
+			return nil
+		},
+		resolveLocation: singleIdentifierLocationResolver(t),
+		getAccountContractCode: func(address Address, name string) (code []byte, err error) {
+			location := common.AddressLocation{
+				Address: address,
+				Name:    name,
+			}
+			return accountCodes[location.ID()], nil
+		},
+		updateAccountContractCode: func(address Address, name string, code []byte) error {
+			location := common.AddressLocation{
+				Address: address,
+				Name:    name,
+			}
+			accountCodes[location.ID()] = code
+			return nil
+		},
+		emitEvent: func(event cadence.Event) error {
+			return nil
+		},
+	}
+
+	cache := NewInMemoryContractCodeCache()
+
+	nextTransactionLocation := newTransactionLocationGenerator()
+
+	executeTransaction := func(source []byte) {
+		err := runtime.ExecuteTransaction(
+			Script{
+				Source: source,
+			},
+			Context{
+				Interface:         runtimeInterface,
+				Location:          nextTransactionLocation(),
+				ContractCodeCache: cache,
+			},
+		)
+		require.NoError(t, err)
+	}
+
+	executeScript := func() (cadence.Value, error) {
+		return runtime.ExecuteScript(
+			Script{
+				Source: script,
+			},
+			Context{
+				Interface:         runtimeInterface,
+				Location:          common.ScriptLocation{},
+				ContractCodeCache: cache,
+			},
+		)
+	}
+
+	executeTransaction(utils.DeploymentTransaction("Test", contract))
+	executeTransaction(utils.DeploymentTransaction("Dependent", dependentContract))
+
+	result, err := executeScript()
+	require.NoError(t, err)
+	require.Equal(t, cadence.NewInt(42), result)
+
+	// Updating the imported contract invalidates the cached program of the dependent contract,
+	// so it is checked again against the updated contract, and the check fails
+
+	executeTransaction(utils.UpdateTransaction("Test", updatedContract))
+
+	_, err = executeScript()
+	require.Error(t, err)
+
+	var checkerErr *sema.CheckerError
+	require.ErrorAs(t, err, &checkerErr)
+}
+
+func TestRuntimeContractCodeCacheCheckerFingerprint(t *testing.T) {
+
+	t.Parallel()
+
+	runtime := newTestInterpreterRuntime()
+
+	address := common.BytesToAddress([]byte{0x1})
+
+	// The contract uses a predeclared value
+
+	contract := []byte(`
+      pub contract Test {
+          pub fun answer(): Int {
+              return fortyTwo
+          }
+      }
+    `)
+
+	script := []byte(`
+      import Test from 0x1
+
+      pub fun main(): Int {
+          return Test.answer()
+      }
+    `)
+
+	predeclaredValues := []ValueDeclaration{
+		{
+			Name:       "fortyTwo",
+			Type:       sema.IntType,
+			Kind:       common.DeclarationKindConstant,
+			IsConstant: true,
+			Value:      interpreter.NewIntValueFromInt64(42),
+		},
+	}
+
+	accountCodes := map[common.LocationID][]byte{}
+
+	runtimeInterface := &testRuntimeInterface{
+		storage: newTestLedger(nil, nil),
+		getSigningAccounts: func() ([]Address, error) {
+			return []Address{address}, nil
+		},
+		// NOTE: do not keep programs in the host environment,
+		// so the contract code cache is used
+		getProgram: func(_ Location) (*interpreter.Program, error) {
+			return nil, nil
+		},
+		setProgram: func(_ Location, _ *interpreter.Program) error {
+			return nil
+		},
+		resolveLocation: singleIdentifierLocationResolver(t),
+		getAccountContractCode: func(address Address, name string) (code []byte, err error) {
+			location := common.AddressLocation{
+				Address: address,
+				Name:    name,
+			}
+			return accountCodes[location.ID()], nil
+		},
+		updateAccountContractCode: func(address Address, name string, code []byte) error {
+			location := common.AddressLocation{
+				Address: address,
+				Name:    name,
+			}
+			accountCodes[location.ID()] = code
+			return nil
+		},
+		emitEvent: func(event cadence.Event) error {
+			return nil
+		},
+	}
+
+	cache := NewInMemoryContractCodeCache()
+
+	err := runtime.ExecuteTransaction(
+		Script{
+			Source: utils.DeploymentTransaction("Test", contract),
+		},
+		Context{
+			Interface:         runtimeInterface,
+			Location:          common.TransactionLocation{},
+			ContractCodeCache: cache,
+			PredeclaredValues: predeclaredValues,
+		},
+	)
+	require.NoError(t, err)
+
+	executeScript := func(context Context) (cadence.Value, error) {
+		context.Interface = runtimeInterface
+		context.Location = common.ScriptLocation{}
+		context.ContractCodeCache = cache
+
+		return runtime.ExecuteScript(
+			Script{
+				Source: script,
+			},
+			context,
+		)
+	}
+
+	result, err := executeScript(Context{
+		PredeclaredValues: predeclaredValues,
+	})
+	require.NoError(t, err)
+	require.Equal(t, cadence.NewInt(42), result)
+
+	require.Equal(t, ContractCodeCacheStats{Hits: 1, Misses: 1}, cache.Stats())
+
+	// Checking with other checks enabled does not reuse the cached program
+
+	result, err = executeScript(Context{
+		PredeclaredValues:      predeclaredValues,
+		RejectStoredReferences: true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, cadence.NewInt(42), result)
+
+	require.Equal(t, ContractCodeCacheStats{Hits: 2, Misses: 2}, cache.Stats())
+
+	// Checking without the predeclared value does not reuse the cached program,
+	// so the contract is checked again, and the check fails
+
+	_, err = executeScript(Context{})
+	require.Error(t, err)
+
+	var checkerErr *sema.CheckerError
+	require.ErrorAs(t, err, &checkerErr)
+
+	require.Equal(t, ContractCodeCacheStats{Hits: 2, Misses: 3}, cache.Stats())
+}
@@ -0,0 +1,247 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runtime
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/onflow/cadence/runtime/ast"
+	"github.com/onflow/cadence/runtime/common"
+)
+
+//go:generate go run golang.org/x/tools/cmd/stringer -type=BreakingChangeKind
+
+// BreakingChangeKind is the kind of a change of a contract
+// which is incompatible with the values already stored.
+//
+type BreakingChangeKind uint
+
+const (
+	BreakingChangeKindUnknown BreakingChangeKind = iota
+	// BreakingChangeKindRemovedField is the removal of a field
+	BreakingChangeKindRemovedField
+	// BreakingChangeKindChangedFieldType is the change of the type of a field
+	BreakingChangeKindChangedFieldType
+	// BreakingChangeKindRemovedEnumCase is the removal of an enum case,
+	// or the change of its position, which changes the raw value of the case
+	BreakingChangeKindRemovedEnumCase
+)
+
+// BreakingChange is a change of a contract which is incompatible with the values already stored,
+// e.g. the removal of a field of a composite type.
+//
+type BreakingChange struct {
+	Kind BreakingChangeKind
+	// DeclName is the qualified name of the declaration which declares the changed member,
+	// e.g. `Test.R`
+	DeclName string
+	// MemberName is the name of the changed field or enum case
+	MemberName string
+	// OldRange is the range of the member in the old code
+	OldRange ast.Range
+	// NewRange is the range of the changed member in the new code,
+	// or an empty range if the member was removed
+	NewRange ast.Range
+}
+
+func (c BreakingChange) String() string {
+	switch c.Kind {
+	case BreakingChangeKindRemovedField:
+		return fmt.Sprintf("removed field `%s` of `%s`", c.MemberName, c.DeclName)
+	case BreakingChangeKindChangedFieldType:
+		return fmt.Sprintf("changed type of field `%s` of `%s`", c.MemberName, c.DeclName)
+	case BreakingChangeKindRemovedEnumCase:
+		return fmt.Sprintf("removed enum case `%s` of `%s`", c.MemberName, c.DeclName)
+	default:
+		return fmt.Sprintf("%s `%s` of `%s`", c.Kind, c.MemberName, c.DeclName)
+	}
+}
+
+// UpdateCompatibilityReport reports the changes of a contract update
+// which are incompatible with the values already stored, see CheckContractUpdateCompatibility.
+//
+type UpdateCompatibilityReport struct {
+	BreakingChanges []BreakingChange
+}
+
+// IsCompatible returns true if the update has no breaking changes.
+//
+func (r *UpdateCompatibilityReport) IsCompatible() bool {
+	return len(r.BreakingChanges) == 0
+}
+
+// CheckContractUpdateCompatibility compares the old and the new program of a contract,
+// and reports the changes which are incompatible with the values already stored:
+// Removed fields and changed field types of composite types which can be stored,
+// and removed enum cases.
+//
+// Unlike the validation of contract updates, added fields and other changes are not reported,
+// and the comparison does not stop at the first invalid declaration.
+//
+func CheckContractUpdateCompatibility(
+	location Location,
+	contractName string,
+	oldProgram *ast.Program,
+	newProgram *ast.Program,
+) (
+	*UpdateCompatibilityReport,
+	error,
+) {
+	oldRootDecl, err := getRootDeclaration(oldProgram)
+	if err != nil {
+		return nil, err
+	}
+
+	newRootDecl, err := getRootDeclaration(newProgram)
+	if err != nil {
+		return nil, err
+	}
+
+	// Field types are compared like in the validation of contract updates,
+	// e.g. qualified and unqualified names of nested types are equal
+
+	validator := NewContractUpdateValidator(
+		location,
+		contractName,
+		oldProgram,
+		newProgram,
+	)
+	validator.rootDecl = newRootDecl
+
+	checker := &updateCompatibilityChecker{
+		validator: validator,
+		report:    &UpdateCompatibilityReport{},
+	}
+
+	checker.checkDeclaration(nil, oldRootDecl, newRootDecl)
+
+	return checker.report, nil
+}
+
+type updateCompatibilityChecker struct {
+	validator *ContractUpdateValidator
+	report    *UpdateCompatibilityReport
+}
+
+func (c *updateCompatibilityChecker) reportChange(change BreakingChange) {
+	c.report.BreakingChanges = append(c.report.BreakingChanges, change)
+}
+
+func (c *updateCompatibilityChecker) checkDeclaration(
+	parentNames []string,
+	oldDeclaration ast.Declaration,
+	newDeclaration ast.Declaration,
+) {
+	names := append(
+		parentNames[:len(parentNames):len(parentNames)],
+		oldDeclaration.DeclarationIdentifier().Identifier,
+	)
+	declName := strings.Join(names, ".")
+
+	oldCompositeDecl, ok := oldDeclaration.(*ast.CompositeDeclaration)
+	if ok {
+		newCompositeDecl, ok := newDeclaration.(*ast.CompositeDeclaration)
+		if ok {
+			c.checkComposite(declName, oldCompositeDecl, newCompositeDecl)
+		}
+	}
+
+	// Check the nested composite declarations, in declaration order.
+	// Removed declarations are not reported, as stored values of them cannot be loaded anyways
+
+	newNestedDecls := newDeclaration.DeclarationMembers().CompositesByIdentifier()
+
+	for _, oldNestedDecl := range oldDeclaration.DeclarationMembers().Composites() {
+		newNestedDecl, ok := newNestedDecls[oldNestedDecl.Identifier.Identifier]
+		if !ok {
+			continue
+		}
+
+		c.checkDeclaration(names, oldNestedDecl, newNestedDecl)
+	}
+}
+
+func (c *updateCompatibilityChecker) checkComposite(
+	declName string,
+	oldDecl *ast.CompositeDeclaration,
+	newDecl *ast.CompositeDeclaration,
+) {
+	// Events are never stored
+
+	if oldDecl.CompositeKind == common.CompositeKindEvent {
+		return
+	}
+
+	newFields := newDecl.Members.FieldsByIdentifier()
+
+	for _, oldField := range oldDecl.Members.Fields() {
+		fieldName := oldField.Identifier.Identifier
+
+		newField, ok := newFields[fieldName]
+		if !ok {
+			c.reportChange(BreakingChange{
+				Kind:       BreakingChangeKindRemovedField,
+				DeclName:   declName,
+				MemberName: fieldName,
+				OldRange:   ast.NewRangeFromPositioned(oldField),
+			})
+
+			continue
+		}
+
+		err := oldField.TypeAnnotation.Type.CheckEqual(newField.TypeAnnotation.Type, c.validator)
+		if err != nil {
+			c.reportChange(BreakingChange{
+				Kind:       BreakingChangeKindChangedFieldType,
+				DeclName:   declName,
+				MemberName: fieldName,
+				OldRange:   ast.NewRangeFromPositioned(oldField.TypeAnnotation),
+				NewRange:   ast.NewRangeFromPositioned(newField.TypeAnnotation),
+			})
+		}
+	}
+
+	// Enum cases are stored as their raw value, i.e. their index.
+	// An old enum case is removed if the new enum case at its index has a different name
+
+	newEnumCases := newDecl.Members.EnumCases()
+
+	for index, oldEnumCase := range oldDecl.Members.EnumCases() {
+		caseName := oldEnumCase.Identifier.Identifier
+
+		change := BreakingChange{
+			Kind:       BreakingChangeKindRemovedEnumCase,
+			DeclName:   declName,
+			MemberName: caseName,
+			OldRange:   ast.NewRangeFromPositioned(oldEnumCase),
+		}
+
+		if index >= len(newEnumCases) {
+			c.reportChange(change)
+			continue
+		}
+
+		newEnumCase := newEnumCases[index]
+		if newEnumCase.Identifier.Identifier != caseName {
+			change.NewRange = ast.NewRangeFromPositioned(newEnumCase)
+			c.reportChange(change)
+		}
+	}
+}
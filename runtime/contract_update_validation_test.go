@@ -1980,3 +1980,243 @@ func TestContractUpdateValidationDisabled(t *testing.T) {
 		require.NoError(t, err)
 	})
 }
+
+func TestRuntimeCheckContractUpdate(t *testing.T) {
+
+	t.Parallel()
+
+	runtime := newTestInterpreterRuntime()
+
+	address := common.BytesToAddress([]byte{0x42})
+
+	const oldCode = `
+		pub contract Test {
+
+			pub enum E: UInt8 {
+				pub case a
+				pub case b
+				pub case c
+			}
+
+			pub resource R {
+				pub let a: Int
+				pub let b: String
+				pub let c: @R?
+
+				init() {
+					self.a = 0
+					self.b = ""
+					self.c <- nil
+				}
+
+				destroy() {
+					destroy self.c
+				}
+			}
+
+			pub event Foo(x: Int)
+
+			pub let x: Int
+
+			init() {
+				self.x = 0
+			}
+		}
+	`
+
+	accountCode := map[common.LocationID][]byte{
+		common.AddressLocation{
+			Address: address,
+			Name:    "Test",
+		}.ID(): []byte(oldCode),
+	}
+	runtimeInterface := getMockedRuntimeInterfaceForTxUpdate(t, accountCode, nil)
+
+	t.Run("compatible", func(t *testing.T) {
+
+		t.Parallel()
+
+		const newCode = `
+			pub contract Test {
+
+				pub enum E: UInt8 {
+					pub case a
+					pub case b
+					pub case c
+					pub case d
+				}
+
+				pub resource R {
+					pub let a: Int
+					pub let b: String
+					pub let c: @Test.R?
+
+					init() {
+						self.a = 0
+						self.b = ""
+						self.c <- nil
+					}
+
+					destroy() {
+						destroy self.c
+					}
+				}
+
+				pub event Foo(y: String)
+
+				pub let x: Int
+				pub let y: Int
+
+				init() {
+					self.x = 0
+					self.y = 1
+				}
+			}
+		`
+
+		report, err := runtime.CheckContractUpdate(
+			address,
+			"Test",
+			[]byte(newCode),
+			Context{
+				Interface: runtimeInterface,
+			},
+		)
+		require.NoError(t, err)
+
+		assert.True(t, report.IsCompatible())
+	})
+
+	t.Run("incompatible", func(t *testing.T) {
+
+		t.Parallel()
+
+		const newCode = `
+			pub contract Test {
+
+				pub enum E: UInt8 {
+					pub case a
+					pub case c
+				}
+
+				pub resource R {
+					pub let a: String
+					pub let c: @R?
+
+					init() {
+						self.a = ""
+						self.c <- nil
+					}
+
+					destroy() {
+						destroy self.c
+					}
+				}
+
+				init() {}
+			}
+		`
+
+		report, err := runtime.CheckContractUpdate(
+			address,
+			"Test",
+			[]byte(newCode),
+			Context{
+				Interface: runtimeInterface,
+			},
+		)
+		require.NoError(t, err)
+
+		assert.False(t, report.IsCompatible())
+
+		type change struct {
+			kind       BreakingChangeKind
+			declName   string
+			memberName string
+			oldLine    int
+			newLine    int
+		}
+
+		var changes []change
+		for _, breakingChange := range report.BreakingChanges {
+			changes = append(changes, change{
+				kind:       breakingChange.Kind,
+				declName:   breakingChange.DeclName,
+				memberName: breakingChange.MemberName,
+				oldLine:    breakingChange.OldRange.StartPos.Line,
+				newLine:    breakingChange.NewRange.StartPos.Line,
+			})
+		}
+
+		assert.Equal(t,
+			[]change{
+				{
+					kind:       BreakingChangeKindRemovedField,
+					declName:   "Test",
+					memberName: "x",
+					oldLine:    28,
+				},
+				{
+					kind:       BreakingChangeKindRemovedEnumCase,
+					declName:   "Test.E",
+					memberName: "b",
+					oldLine:    6,
+					newLine:    6,
+				},
+				{
+					kind:       BreakingChangeKindRemovedEnumCase,
+					declName:   "Test.E",
+					memberName: "c",
+					oldLine:    7,
+				},
+				{
+					kind:       BreakingChangeKindChangedFieldType,
+					declName:   "Test.R",
+					memberName: "a",
+					oldLine:    11,
+					newLine:    10,
+				},
+				{
+					kind:       BreakingChangeKindRemovedField,
+					declName:   "Test.R",
+					memberName: "b",
+					oldLine:    12,
+				},
+			},
+			changes,
+		)
+	})
+
+	t.Run("non-existing contract", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := runtime.CheckContractUpdate(
+			address,
+			"Unknown",
+			[]byte(`pub contract Unknown {}`),
+			Context{
+				Interface: runtimeInterface,
+			},
+		)
+		require.Error(t, err)
+	})
+
+	t.Run("invalid new code", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := runtime.CheckContractUpdate(
+			address,
+			"Test",
+			[]byte(`pub contract Test {`),
+			Context{
+				Interface: runtimeInterface,
+			},
+		)
+		require.Error(t, err)
+
+		var parsingCheckingErr *ParsingCheckingError
+		require.ErrorAs(t, err, &parsingCheckingErr)
+	})
+}
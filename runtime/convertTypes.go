@@ -417,10 +417,10 @@ func importInterfaceType(t cadence.InterfaceType) interpreter.InterfaceStaticTyp
 }
 
 func importCompositeType(t cadence.CompositeType) interpreter.CompositeStaticType {
-	return interpreter.CompositeStaticType{
-		Location:            t.CompositeTypeLocation(),
-		QualifiedIdentifier: t.CompositeTypeQualifiedIdentifier(),
-	}
+	return interpreter.NewCompositeStaticType(
+		t.CompositeTypeLocation(),
+		t.CompositeTypeQualifiedIdentifier(),
+	)
 }
 
 func ImportType(t cadence.Type) interpreter.StaticType {
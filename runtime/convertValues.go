@@ -29,13 +29,13 @@ import (
 )
 
 // exportValue converts a runtime value to its native Go representation.
-func exportValue(value exportableValue) (cadence.Value, error) {
-	return exportValueWithInterpreter(value.Value, value.Interpreter(), seenReferences{})
+func exportValue(value exportableValue, unknownTypeHandler UnknownTypeHandlerFunc) (cadence.Value, error) {
+	return exportValueWithInterpreter(value.Value, value.Interpreter(), seenReferences{}, unknownTypeHandler)
 }
 
 // ExportValue converts a runtime value to its native Go representation.
 func ExportValue(value interpreter.Value, inter *interpreter.Interpreter) (cadence.Value, error) {
-	return exportValueWithInterpreter(value, inter, seenReferences{})
+	return exportValueWithInterpreter(value, inter, seenReferences{}, nil)
 }
 
 // NOTE: Do not generalize to map[interpreter.Value],
@@ -48,10 +48,14 @@ type seenReferences map[*interpreter.EphemeralReferenceValue]struct{}
 // it is checked at the start of the recursively called function,
 // and pre-set before a recursive call.
 //
+// The optional unknown type handler is used for composite values
+// whose type cannot be loaded.
+//
 func exportValueWithInterpreter(
 	value interpreter.Value,
 	inter *interpreter.Interpreter,
 	seenReferences seenReferences,
+	unknownTypeHandler UnknownTypeHandlerFunc,
 ) (
 	cadence.Value,
 	error,
@@ -63,13 +67,13 @@ func exportValueWithInterpreter(
 	case interpreter.NilValue:
 		return cadence.NewOptional(nil), nil
 	case *interpreter.SomeValue:
-		return exportSomeValue(v, inter, seenReferences)
+		return exportSomeValue(v, inter, seenReferences, unknownTypeHandler)
 	case interpreter.BoolValue:
 		return cadence.NewBool(bool(v)), nil
 	case *interpreter.StringValue:
 		return cadence.NewString(v.Str)
 	case *interpreter.ArrayValue:
-		return exportArrayValue(v, inter, seenReferences)
+		return exportArrayValue(v, inter, seenReferences, unknownTypeHandler)
 	case interpreter.IntValue:
 		return cadence.NewIntFromBig(v.ToBigInt()), nil
 	case interpreter.Int8Value:
@@ -111,11 +115,11 @@ func exportValueWithInterpreter(
 	case interpreter.UFix64Value:
 		return cadence.UFix64(v), nil
 	case *interpreter.CompositeValue:
-		return exportCompositeValue(v, inter, seenReferences)
+		return exportCompositeValue(v, inter, seenReferences, unknownTypeHandler)
 	case *interpreter.SimpleCompositeValue:
-		return exportSimpleCompositeValue(v, inter, seenReferences)
+		return exportSimpleCompositeValue(v, inter, seenReferences, unknownTypeHandler)
 	case *interpreter.DictionaryValue:
-		return exportDictionaryValue(v, inter, seenReferences)
+		return exportDictionaryValue(v, inter, seenReferences, unknownTypeHandler)
 	case interpreter.AddressValue:
 		return cadence.NewAddress(v), nil
 	case interpreter.LinkValue:
@@ -133,13 +137,13 @@ func exportValueWithInterpreter(
 		}
 		defer delete(seenReferences, v)
 		seenReferences[v] = struct{}{}
-		return exportValueWithInterpreter(v.Value, inter, seenReferences)
+		return exportValueWithInterpreter(v.Value, inter, seenReferences, unknownTypeHandler)
 	case *interpreter.StorageReferenceValue:
 		referencedValue := v.ReferencedValue(inter)
 		if referencedValue == nil {
 			return nil, nil
 		}
-		return exportValueWithInterpreter(*referencedValue, inter, seenReferences)
+		return exportValueWithInterpreter(*referencedValue, inter, seenReferences, unknownTypeHandler)
 	}
 
 	return nil, fmt.Errorf("cannot export value of type %T", value)
@@ -150,6 +154,7 @@ func exportSomeValue(
 	v *interpreter.SomeValue,
 	inter *interpreter.Interpreter,
 	seenReferences seenReferences,
+	unknownTypeHandler UnknownTypeHandlerFunc,
 ) (
 	cadence.Optional,
 	error,
@@ -158,7 +163,7 @@ func exportSomeValue(
 		return cadence.NewOptional(nil), nil
 	}
 
-	value, err := exportValueWithInterpreter(v.Value, inter, seenReferences)
+	value, err := exportValueWithInterpreter(v.Value, inter, seenReferences, unknownTypeHandler)
 	if err != nil {
 		return cadence.Optional{}, err
 	}
@@ -170,6 +175,7 @@ func exportArrayValue(
 	v *interpreter.ArrayValue,
 	inter *interpreter.Interpreter,
 	seenReferences seenReferences,
+	unknownTypeHandler UnknownTypeHandlerFunc,
 ) (
 	cadence.Array,
 	error,
@@ -179,7 +185,7 @@ func exportArrayValue(
 	var err error
 	v.Iterate(func(value interpreter.Value) (resume bool) {
 		var exportedValue cadence.Value
-		exportedValue, err = exportValueWithInterpreter(value, inter, seenReferences)
+		exportedValue, err = exportValueWithInterpreter(value, inter, seenReferences, unknownTypeHandler)
 		if err != nil {
 			return false
 		}
@@ -200,11 +206,23 @@ func exportCompositeValue(
 	v *interpreter.CompositeValue,
 	inter *interpreter.Interpreter,
 	seenReferences seenReferences,
+	unknownTypeHandler UnknownTypeHandlerFunc,
 ) (
 	cadence.Value,
 	error,
 ) {
 
+	// If the type of the value cannot be loaded,
+	// e.g. because the declaring contract was removed,
+	// let the unknown type handler provide a substitute type, if any
+
+	if unknownTypeHandler != nil {
+		_, err := inter.GetCompositeType(v.Location, v.QualifiedIdentifier, v.TypeID())
+		if _, ok := err.(interpreter.TypeLoadingError); ok {
+			return exportUnknownTypeCompositeValue(v, inter, seenReferences, unknownTypeHandler)
+		}
+	}
+
 	dynamicType := v.DynamicType(inter, interpreter.SeenReferences{}).(interpreter.CompositeDynamicType)
 	staticType := dynamicType.StaticType.(*sema.CompositeType)
 	// TODO: consider making the results map "global", by moving it up to exportValueWithInterpreter
@@ -228,7 +246,7 @@ func exportCompositeValue(
 			}
 		}
 
-		exportedFieldValue, err := exportValueWithInterpreter(fieldValue, inter, seenReferences)
+		exportedFieldValue, err := exportValueWithInterpreter(fieldValue, inter, seenReferences, unknownTypeHandler)
 		if err != nil {
 			return nil, err
 		}
@@ -267,10 +285,82 @@ func exportCompositeValue(
 	)
 }
 
+// exportUnknownTypeCompositeValue exports a composite value whose type cannot be loaded,
+// using the substitute type provided by the given unknown type handler.
+//
+func exportUnknownTypeCompositeValue(
+	v *interpreter.CompositeValue,
+	inter *interpreter.Interpreter,
+	seenReferences seenReferences,
+	unknownTypeHandler UnknownTypeHandlerFunc,
+) (
+	cadence.Value,
+	error,
+) {
+	typeID := string(v.TypeID())
+
+	substituteType, err := unknownTypeHandler(typeID)
+	if err != nil {
+		return nil, err
+	}
+
+	compositeType, ok := substituteType.(cadence.CompositeType)
+	if !ok {
+		return nil, fmt.Errorf(
+			"invalid substitute type for unknown type `%s`: expected composite type, got `%s`",
+			typeID,
+			substituteType.ID(),
+		)
+	}
+
+	compositeFields := compositeType.CompositeFields()
+	fields := make([]cadence.Value, len(compositeFields))
+
+	for i, field := range compositeFields {
+		fieldName := field.Identifier
+
+		// TODO: provide proper location range
+		fieldValue := v.GetField(inter, interpreter.ReturnEmptyLocationRange, fieldName)
+		if fieldValue == nil {
+			return nil, fmt.Errorf(
+				"invalid substitute type for unknown type `%s`: missing field `%s`",
+				typeID,
+				fieldName,
+			)
+		}
+
+		exportedFieldValue, err := exportValueWithInterpreter(fieldValue, inter, seenReferences, unknownTypeHandler)
+		if err != nil {
+			return nil, err
+		}
+		fields[i] = exportedFieldValue
+	}
+
+	switch compositeType := compositeType.(type) {
+	case *cadence.StructType:
+		return cadence.NewStruct(fields).WithType(compositeType), nil
+	case *cadence.ResourceType:
+		return cadence.NewResource(fields).WithType(compositeType), nil
+	case *cadence.EventType:
+		return cadence.NewEvent(fields).WithType(compositeType), nil
+	case *cadence.ContractType:
+		return cadence.NewContract(fields).WithType(compositeType), nil
+	case *cadence.EnumType:
+		return cadence.NewEnum(fields).WithType(compositeType), nil
+	}
+
+	return nil, fmt.Errorf(
+		"invalid substitute type for unknown type `%s`: unsupported type `%s`",
+		typeID,
+		substituteType.ID(),
+	)
+}
+
 func exportSimpleCompositeValue(
 	v *interpreter.SimpleCompositeValue,
 	inter *interpreter.Interpreter,
 	seenReferences seenReferences,
+	unknownTypeHandler UnknownTypeHandlerFunc,
 ) (
 	cadence.Value,
 	error,
@@ -302,7 +392,7 @@ func exportSimpleCompositeValue(
 			}
 		}
 
-		exportedFieldValue, err := exportValueWithInterpreter(fieldValue, inter, seenReferences)
+		exportedFieldValue, err := exportValueWithInterpreter(fieldValue, inter, seenReferences, unknownTypeHandler)
 		if err != nil {
 			return nil, err
 		}
@@ -345,6 +435,7 @@ func exportDictionaryValue(
 	v *interpreter.DictionaryValue,
 	inter *interpreter.Interpreter,
 	seenReferences seenReferences,
+	unknownTypeHandler UnknownTypeHandlerFunc,
 ) (
 	cadence.Dictionary,
 	error,
@@ -355,13 +446,13 @@ func exportDictionaryValue(
 	v.Iterate(func(key, value interpreter.Value) (resume bool) {
 
 		var convertedKey cadence.Value
-		convertedKey, err = exportValueWithInterpreter(key, inter, seenReferences)
+		convertedKey, err = exportValueWithInterpreter(key, inter, seenReferences, unknownTypeHandler)
 		if err != nil {
 			return false
 		}
 
 		var convertedValue cadence.Value
-		convertedValue, err = exportValueWithInterpreter(value, inter, seenReferences)
+		convertedValue, err = exportValueWithInterpreter(value, inter, seenReferences, unknownTypeHandler)
 		if err != nil {
 			return false
 		}
@@ -425,7 +516,7 @@ func exportEvent(event exportableEvent, seenReferences seenReferences) (cadence.
 	fields := make([]cadence.Value, len(event.Fields))
 
 	for i, field := range event.Fields {
-		value, err := exportValueWithInterpreter(field.Value, field.Interpreter(), seenReferences)
+		value, err := exportValueWithInterpreter(field.Value, field.Interpreter(), seenReferences, nil)
 		if err != nil {
 			return cadence.Event{}, err
 		}
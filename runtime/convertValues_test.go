@@ -62,7 +62,7 @@ func TestExportValue(t *testing.T) {
 			if tt.valueFactory != nil {
 				value = tt.valueFactory(inter)
 			}
-			actual, err := exportValueWithInterpreter(value, inter, seenReferences{})
+			actual, err := exportValueWithInterpreter(value, inter, seenReferences{}, nil)
 			if tt.expected == nil {
 				require.Error(t, err)
 			} else {
@@ -1080,10 +1080,7 @@ func TestImportRuntimeType(t *testing.T) {
 				Location:            TestLocation,
 				QualifiedIdentifier: "S",
 			},
-			expected: interpreter.CompositeStaticType{
-				Location:            TestLocation,
-				QualifiedIdentifier: "S",
-			},
+			expected: interpreter.NewCompositeStaticType(TestLocation, "S"),
 		},
 		{
 			label: "Resource",
@@ -1091,10 +1088,7 @@ func TestImportRuntimeType(t *testing.T) {
 				Location:            TestLocation,
 				QualifiedIdentifier: "S",
 			},
-			expected: interpreter.CompositeStaticType{
-				Location:            TestLocation,
-				QualifiedIdentifier: "S",
-			},
+			expected: interpreter.NewCompositeStaticType(TestLocation, "S"),
 		},
 		{
 			label: "Contract",
@@ -1102,10 +1096,7 @@ func TestImportRuntimeType(t *testing.T) {
 				Location:            TestLocation,
 				QualifiedIdentifier: "S",
 			},
-			expected: interpreter.CompositeStaticType{
-				Location:            TestLocation,
-				QualifiedIdentifier: "S",
-			},
+			expected: interpreter.NewCompositeStaticType(TestLocation, "S"),
 		},
 		{
 			label: "Event",
@@ -1113,10 +1104,7 @@ func TestImportRuntimeType(t *testing.T) {
 				Location:            TestLocation,
 				QualifiedIdentifier: "S",
 			},
-			expected: interpreter.CompositeStaticType{
-				Location:            TestLocation,
-				QualifiedIdentifier: "S",
-			},
+			expected: interpreter.NewCompositeStaticType(TestLocation, "S"),
 		},
 		{
 			label: "Enum",
@@ -1124,10 +1112,7 @@ func TestImportRuntimeType(t *testing.T) {
 				Location:            TestLocation,
 				QualifiedIdentifier: "S",
 			},
-			expected: interpreter.CompositeStaticType{
-				Location:            TestLocation,
-				QualifiedIdentifier: "S",
-			},
+			expected: interpreter.NewCompositeStaticType(TestLocation, "S"),
 		},
 		{
 			label: "StructInterface",
@@ -1176,10 +1161,7 @@ func TestImportRuntimeType(t *testing.T) {
 					}},
 			},
 			expected: &interpreter.RestrictedStaticType{
-				Type: interpreter.CompositeStaticType{
-					Location:            TestLocation,
-					QualifiedIdentifier: "S",
-				},
+				Type: interpreter.NewCompositeStaticType(TestLocation, "S"),
 				Restrictions: []interpreter.InterfaceStaticType{
 					{
 						Location:            TestLocation,
@@ -1708,7 +1690,7 @@ func TestExportTypeValue(t *testing.T) {
 		value := interpreter.TypeValue{
 			Type: nil,
 		}
-		actual, err := exportValueWithInterpreter(value, nil, seenReferences{})
+		actual, err := exportValueWithInterpreter(value, nil, seenReferences{}, nil)
 		require.NoError(t, err)
 
 		expected := cadence.TypeValue{
@@ -1792,7 +1774,7 @@ func TestExportCapabilityValue(t *testing.T) {
 			BorrowType: interpreter.PrimitiveStaticTypeInt,
 		}
 
-		actual, err := exportValueWithInterpreter(capability, nil, seenReferences{})
+		actual, err := exportValueWithInterpreter(capability, nil, seenReferences{}, nil)
 		require.NoError(t, err)
 
 		expected := cadence.Capability{
@@ -1831,7 +1813,7 @@ func TestExportCapabilityValue(t *testing.T) {
 			BorrowType: interpreter.NewCompositeStaticType(TestLocation, "S"),
 		}
 
-		actual, err := exportValueWithInterpreter(capability, inter, seenReferences{})
+		actual, err := exportValueWithInterpreter(capability, inter, seenReferences{}, nil)
 		require.NoError(t, err)
 
 		expected := cadence.Capability{
@@ -1860,7 +1842,7 @@ func TestExportCapabilityValue(t *testing.T) {
 			},
 		}
 
-		actual, err := exportValueWithInterpreter(capability, nil, seenReferences{})
+		actual, err := exportValueWithInterpreter(capability, nil, seenReferences{}, nil)
 		require.NoError(t, err)
 
 		expected := cadence.Capability{
@@ -1889,7 +1871,7 @@ func TestExportLinkValue(t *testing.T) {
 			Type: interpreter.PrimitiveStaticTypeInt,
 		}
 
-		actual, err := exportValueWithInterpreter(link, nil, seenReferences{})
+		actual, err := exportValueWithInterpreter(link, nil, seenReferences{}, nil)
 		require.NoError(t, err)
 
 		expected := cadence.Link{
@@ -1925,7 +1907,7 @@ func TestExportLinkValue(t *testing.T) {
 			Type: interpreter.NewCompositeStaticType(TestLocation, "S"),
 		}
 
-		actual, err := exportValueWithInterpreter(capability, inter, seenReferences{})
+		actual, err := exportValueWithInterpreter(capability, inter, seenReferences{}, nil)
 		require.NoError(t, err)
 
 		expected := cadence.Link{
@@ -2900,7 +2882,7 @@ func TestRuntimeImportExportArrayValue(t *testing.T) {
 			common.Address{},
 		)
 
-		actual, err := exportValueWithInterpreter(value, inter, seenReferences{})
+		actual, err := exportValueWithInterpreter(value, inter, seenReferences{}, nil)
 		require.NoError(t, err)
 
 		assert.Equal(t,
@@ -2954,7 +2936,7 @@ func TestRuntimeImportExportArrayValue(t *testing.T) {
 			interpreter.NewStringValue("foo"),
 		)
 
-		actual, err := exportValueWithInterpreter(value, nil, seenReferences{})
+		actual, err := exportValueWithInterpreter(value, nil, seenReferences{}, nil)
 		require.NoError(t, err)
 
 		assert.Equal(t,
@@ -3077,7 +3059,7 @@ func TestRuntimeImportExportDictionaryValue(t *testing.T) {
 			},
 		)
 
-		actual, err := exportValueWithInterpreter(value, nil, seenReferences{})
+		actual, err := exportValueWithInterpreter(value, nil, seenReferences{}, nil)
 		require.NoError(t, err)
 
 		assert.Equal(t,
@@ -3132,7 +3114,7 @@ func TestRuntimeImportExportDictionaryValue(t *testing.T) {
 			interpreter.NewStringValue("b"), interpreter.NewIntValueFromInt64(2),
 		)
 
-		actual, err := exportValueWithInterpreter(value, nil, seenReferences{})
+		actual, err := exportValueWithInterpreter(value, nil, seenReferences{}, nil)
 		require.NoError(t, err)
 
 		assert.Equal(t,
@@ -4424,7 +4406,7 @@ func TestRuntimeImportExportComplex(t *testing.T) {
 
 		t.Parallel()
 
-		actual, err := exportValueWithInterpreter(internalCompositeValue, inter, seenReferences{})
+		actual, err := exportValueWithInterpreter(internalCompositeValue, inter, seenReferences{}, nil)
 		require.NoError(t, err)
 
 		assert.Equal(t,
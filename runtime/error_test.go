@@ -129,7 +129,7 @@ func TestRuntimeError(t *testing.T) {
 		require.EqualError(
 			t,
 			err,
-			"Execution failed:\nerror: overflow\n"+
+			"Execution failed:\nerror: overflow: 255 + 1 overflows UInt8\n"+
 				" --> 01:5:16\n"+
 				"  |\n"+
 				"5 |                 a + b\n"+
@@ -271,7 +271,7 @@ func TestRuntimeError(t *testing.T) {
 		require.EqualError(
 			t,
 			err,
-			"Execution failed:\nerror: overflow\n"+
+			"Execution failed:\nerror: overflow: 255 + 1 overflows UInt8\n"+
 				" --> imported:5:16\n"+
 				"  |\n"+
 				"5 |                 a + b\n"+
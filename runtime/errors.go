@@ -20,8 +20,11 @@ package runtime
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
+	"github.com/onflow/atree"
+
 	"github.com/onflow/cadence/runtime/ast"
 	"github.com/onflow/cadence/runtime/common"
 	"github.com/onflow/cadence/runtime/interpreter"
@@ -35,14 +38,17 @@ type Error struct {
 	Location common.Location
 	Codes    map[common.LocationID]string
 	Programs map[common.LocationID]*ast.Program
+	// LimitExceeded is the limit which caused the execution to abort, if any
+	LimitExceeded *LimitExceeded
 }
 
 func newError(err error, context Context) Error {
 	return Error{
-		Err:      err,
-		Location: context.Location,
-		Codes:    context.codes,
-		Programs: context.programs,
+		Err:           err,
+		Location:      context.Location,
+		Codes:         context.codes,
+		Programs:      context.programs,
+		LimitExceeded: limitExceeded(err),
 	}
 }
 
@@ -87,6 +93,185 @@ func (e CallStackLimitExceededError) Error() string {
 	)
 }
 
+// StorageReadLimitExceededError is reported when the storage
+// reads more registers from the ledger than allowed.
+//
+type StorageReadLimitExceededError struct {
+	Count int
+	Limit int
+}
+
+var _ interpreter.ExternalAbortError = StorageReadLimitExceededError{}
+
+func (StorageReadLimitExceededError) IsExternalAbortError() {}
+
+func (e StorageReadLimitExceededError) Error() string {
+	return fmt.Sprintf(
+		"storage read limit exceeded: %d reads, limit %d",
+		e.Count,
+		e.Limit,
+	)
+}
+
+// StorageCapacityUnavailableError is reported when the storage capacity of an account is read,
+// but the environment does not provide it (see StorageCapacityProvider).
+//
+type StorageCapacityUnavailableError struct {
+	Address common.Address
+}
+
+func (e StorageCapacityUnavailableError) Error() string {
+	return fmt.Sprintf(
+		"cannot get storage capacity of account %s: environment does not provide storage capacity",
+		e.Address.ShortHexWithPrefix(),
+	)
+}
+
+// ReadOnlyStorageError is reported when a read-only storage (see NewReadOnlyStorage) is written to.
+//
+type ReadOnlyStorageError struct{}
+
+func (ReadOnlyStorageError) Error() string {
+	return "cannot write to read-only storage"
+}
+
+// InvalidStorageKeyPrefixError is reported when the key prefix of a namespaced storage
+// contains interpreter.StorageKeySeparator (see NewNamespacedStorage).
+//
+type InvalidStorageKeyPrefixError struct {
+	KeyPrefix string
+}
+
+func (e InvalidStorageKeyPrefixError) Error() string {
+	return fmt.Sprintf(
+		"invalid storage key prefix %q: must not contain the storage key separator",
+		e.KeyPrefix,
+	)
+}
+
+// AccountLedgerUnavailableError is reported by a MultiAccountLedger
+// when the ledger of an account is accessed while it is unavailable.
+//
+type AccountLedgerUnavailableError struct {
+	Address common.Address
+}
+
+func (e AccountLedgerUnavailableError) Error() string {
+	return fmt.Sprintf(
+		"ledger of account %s is unavailable",
+		e.Address.ShortHexWithPrefix(),
+	)
+}
+
+// MissingSlabError is reported when a slab referenced by a stored value does not exist,
+// e.g. because the stored data is corrupted.
+//
+// StorageKey is the key of the account storage entry which (transitively) references the slab, if known.
+//
+// The error aborts the execution, like an error of the interpreter.
+//
+type MissingSlabError struct {
+	StorageID  atree.StorageID
+	StorageKey interpreter.StorageKey
+}
+
+var _ interpreter.ExternalAbortError = MissingSlabError{}
+
+func (MissingSlabError) IsExternalAbortError() {}
+
+func (e MissingSlabError) Error() string {
+	if e.StorageKey == (interpreter.StorageKey{}) {
+		return fmt.Sprintf("missing slab %s", e.StorageID)
+	}
+
+	key := strconv.Quote(e.StorageKey.Key)
+	domain, identifier, ok := interpreter.PathForStorageKey(e.StorageKey.Key)
+	if ok {
+		key = fmt.Sprintf("/%s/%s", domain.Identifier(), identifier)
+	}
+
+	return fmt.Sprintf(
+		"missing slab %s of value stored at %s in account %s",
+		e.StorageID,
+		key,
+		e.StorageKey.Address.ShortHexWithPrefix(),
+	)
+}
+
+// DanglingStoredCapabilityError is reported when a capability is stored,
+// but its link does not resolve to a stored value (see Context.ValidateStoredCapabilities).
+//
+type DanglingStoredCapabilityError struct {
+	Address common.Address
+	Path    interpreter.PathValue
+}
+
+func (e DanglingStoredCapabilityError) Error() string {
+	return fmt.Sprintf(
+		"cannot store dangling capability: path %s in account %s does not resolve to a stored value",
+		e.Path,
+		e.Address.ShortHexWithPrefix(),
+	)
+}
+
+// BrokenCapabilityError is reported by Runtime.ReadStoredResolvingCapabilities
+// when a stored capability cannot be borrowed.
+//
+type BrokenCapabilityError struct {
+	Address common.Address
+	Path    interpreter.PathValue
+}
+
+func (e BrokenCapabilityError) Error() string {
+	return fmt.Sprintf(
+		"cannot resolve capability: path %s in account %s cannot be borrowed",
+		e.Path,
+		e.Address.ShortHexWithPrefix(),
+	)
+}
+
+// ViewResolverNotFoundError is reported by Runtime.ResolveViews when the given path
+// does not resolve to a stored value which has a `resolveView` function.
+//
+type ViewResolverNotFoundError struct {
+	Address common.Address
+	Path    interpreter.PathValue
+}
+
+func (e ViewResolverNotFoundError) Error() string {
+	return fmt.Sprintf(
+		"cannot resolve views: path %s in account %s does not resolve to a value with a `%s` function",
+		e.Path,
+		e.Address.ShortHexWithPrefix(),
+		resolveViewFunctionName,
+	)
+}
+
+// ViewResolverTypeMismatchError is reported by Runtime.ResolveViews when the `resolveView` function
+// of the value at the given path does not have the type `fun(Type): AnyStruct?`.
+//
+type ViewResolverTypeMismatchError struct {
+	Address common.Address
+	Path    interpreter.PathValue
+	Type    sema.Type
+}
+
+func (e ViewResolverTypeMismatchError) Error() string {
+	actualType := "unknown"
+	if e.Type != nil {
+		actualType = e.Type.QualifiedString()
+	}
+
+	return fmt.Sprintf(
+		"cannot resolve views: `%s` function of value at path %s in account %s has type `%s`, expected `%s`",
+		resolveViewFunctionName,
+		e.Path,
+		e.Address.ShortHexWithPrefix(),
+		actualType,
+		resolveViewFunctionType.QualifiedString(),
+	)
+}
+
 // InvalidTransactionCountError
 
 type InvalidTransactionCountError struct {
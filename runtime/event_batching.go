@@ -0,0 +1,151 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2020 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runtime
+
+import (
+	"github.com/onflow/cadence"
+)
+
+// eventBufferingInterface is a runtime interface which buffers the emitted events,
+// instead of passing them on to the wrapped runtime interface,
+// until they are flushed in one batch (see BatchEventEmitter).
+//
+type eventBufferingInterface struct {
+	Interface
+	events []cadence.Event
+	// indexedFields are the names of the indexed fields of the buffered events,
+	// at the same index as the event
+	indexedFields [][]string
+}
+
+// newEventBufferingInterface returns a runtime interface which buffers the emitted events,
+// if the runtime interface provided by the host implements BatchEventEmitter.
+// Otherwise, it returns nil.
+//
+func newEventBufferingInterface(runtimeInterface Interface) *eventBufferingInterface {
+	if _, ok := unwrapInterface(runtimeInterface).(BatchEventEmitter); !ok {
+		return nil
+	}
+
+	// NOTE: the given runtime interface might be wrapped by the runtime,
+	// e.g. to record the events of a transaction in a batch,
+	// so the events are flushed through the given runtime interface,
+	// which also implements BatchEventEmitter in that case.
+	// If a wrapper does not forward batches, events are not buffered
+
+	if _, ok := runtimeInterface.(BatchEventEmitter); !ok {
+		return nil
+	}
+
+	return &eventBufferingInterface{
+		Interface: runtimeInterface,
+	}
+}
+
+// emitEventWithIndex passes the given event to the given runtime interface,
+// together with the names of the indexed fields if it implements IndexedEventEmitter.
+//
+func emitEventWithIndex(runtimeInterface Interface, event cadence.Event, indexedFields []string) error {
+	if emitter, ok := runtimeInterface.(IndexedEventEmitter); ok {
+		return emitter.EmitEventWithIndex(event, indexedFields)
+	}
+
+	return runtimeInterface.EmitEvent(event)
+}
+
+// emitEvents passes the given events to the given runtime interface,
+// in one batch if it implements BatchEventEmitter, and one by one otherwise.
+//
+func emitEvents(runtimeInterface Interface, events []cadence.Event) error {
+	if emitter, ok := runtimeInterface.(BatchEventEmitter); ok {
+		return emitter.EmitEvents(events)
+	}
+
+	for _, event := range events {
+		err := runtimeInterface.EmitEvent(event)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// emitEventsWithIndex passes the given events to the given runtime interface,
+// together with the names of their indexed fields, if any.
+//
+// The events are passed in one batch if the runtime interface implements BatchEventEmitter,
+// but the names of the indexed fields are only passed on if it also implements IndexedBatchEventEmitter.
+// Otherwise, the events are passed one by one, together with the names of their indexed fields.
+//
+func emitEventsWithIndex(runtimeInterface Interface, events []cadence.Event, indexedFields [][]string) error {
+	if emitter, ok := runtimeInterface.(IndexedBatchEventEmitter); ok {
+		return emitter.EmitEventsWithIndex(events, indexedFields)
+	}
+
+	if emitter, ok := runtimeInterface.(BatchEventEmitter); ok {
+		return emitter.EmitEvents(events)
+	}
+
+	for i, event := range events {
+		var err error
+		if len(indexedFields[i]) > 0 {
+			err = emitEventWithIndex(runtimeInterface, event, indexedFields[i])
+		} else {
+			err = runtimeInterface.EmitEvent(event)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (i *eventBufferingInterface) EmitEvent(event cadence.Event) error {
+	i.events = append(i.events, event)
+	i.indexedFields = append(i.indexedFields, nil)
+	return nil
+}
+
+// EmitEventWithIndex buffers the given event, together with the names of its indexed fields,
+// so they are passed on when the events are flushed.
+//
+func (i *eventBufferingInterface) EmitEventWithIndex(event cadence.Event, indexedFields []string) error {
+	i.events = append(i.events, event)
+	i.indexedFields = append(i.indexedFields, indexedFields)
+	return nil
+}
+
+// flushEvents passes all buffered events to the wrapped runtime interface in one batch,
+// together with the names of their indexed fields if it implements IndexedBatchEventEmitter.
+//
+func (i *eventBufferingInterface) flushEvents() (err error) {
+	if len(i.events) == 0 {
+		return nil
+	}
+
+	events := i.events
+	indexedFields := i.indexedFields
+	i.events = nil
+	i.indexedFields = nil
+
+	wrapPanic(func() {
+		err = emitEventsWithIndex(i.Interface, events, indexedFields)
+	})
+	return err
+}
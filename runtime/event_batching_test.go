@@ -0,0 +1,324 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2020 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/cadence/runtime/tests/utils"
+)
+
+type testBatchEventEmitterRuntimeInterface struct {
+	*testRuntimeInterface
+	emitEvents func(events []cadence.Event) error
+}
+
+var _ BatchEventEmitter = testBatchEventEmitterRuntimeInterface{}
+
+func (i testBatchEventEmitterRuntimeInterface) EmitEvents(events []cadence.Event) error {
+	return i.emitEvents(events)
+}
+
+func TestRuntimeBatchEventEmitter(t *testing.T) {
+
+	t.Parallel()
+
+	address := common.BytesToAddress([]byte{0x1})
+
+	const contract = `
+      pub contract Test {
+
+          pub event Emitted(index: Int)
+
+          pub fun emitEvents(count: Int) {
+              var index = 0
+              while index < count {
+                  emit Emitted(index: index)
+                  index = index + 1
+              }
+          }
+      }
+    `
+
+	emitTransaction := func(count int, fail bool) []byte {
+		var failure string
+		if fail {
+			failure = `panic("failed")`
+		}
+
+		return []byte(fmt.Sprintf(
+			`
+              import Test from 0x1
+
+              transaction {
+                  prepare(signer: AuthAccount) {
+                      signer.load<Int>(from: /storage/count)
+                      signer.save(%[1]d, to: /storage/count)
+                      Test.emitEvents(count: %[1]d)
+                      %[2]s
+                  }
+              }
+            `,
+			count,
+			failure,
+		))
+	}
+
+	type testCase struct {
+		runtimeInterface Interface
+		eventBatches     *[][]cadence.Event
+		writes           *int
+		writeErr         *error
+		emitEventsErr    *error
+	}
+
+	newTestCase := func(t *testing.T) testCase {
+		var eventBatches [][]cadence.Event
+		var writes int
+		var writeErr error
+		var emitEventsErr error
+		var contractCode []byte
+
+		ledger := newTestLedger(
+			nil,
+			func(_, _, _ []byte) {
+				writes++
+			},
+		)
+		setValue := ledger.setValue
+		ledger.setValue = func(owner, key, value []byte) error {
+			if writeErr != nil {
+				return writeErr
+			}
+			return setValue(owner, key, value)
+		}
+
+		test := testCase{
+			runtimeInterface: testBatchEventEmitterRuntimeInterface{
+				testRuntimeInterface: &testRuntimeInterface{
+					storage: ledger,
+					getSigningAccounts: func() ([]Address, error) {
+						return []Address{address}, nil
+					},
+					resolveLocation: singleIdentifierLocationResolver(t),
+					getAccountContractCode: func(_ Address, _ string) ([]byte, error) {
+						return contractCode, nil
+					},
+					updateAccountContractCode: func(_ Address, _ string, code []byte) error {
+						contractCode = code
+						return nil
+					},
+					emitEvent: func(event cadence.Event) error {
+						require.FailNow(t, "unexpected call of EmitEvent")
+						return nil
+					},
+				},
+				emitEvents: func(events []cadence.Event) error {
+					eventBatches = append(eventBatches, events)
+					return emitEventsErr
+				},
+			},
+			eventBatches:  &eventBatches,
+			writes:        &writes,
+			writeErr:      &writeErr,
+			emitEventsErr: &emitEventsErr,
+		}
+
+		err := newTestInterpreterRuntime().ExecuteTransaction(
+			Script{
+				Source: utils.DeploymentTransaction("Test", []byte(contract)),
+			},
+			Context{
+				Interface: test.runtimeInterface,
+				Location:  common.TransactionLocation{},
+			},
+		)
+		require.NoError(t, err)
+
+		// Only observe the effects of the tested transactions
+
+		eventBatches = nil
+		writes = 0
+
+		return test
+	}
+
+	eventIndices := func(events []cadence.Event) []cadence.Value {
+		indices := make([]cadence.Value, len(events))
+		for i, event := range events {
+			indices[i] = event.Fields[0]
+		}
+		return indices
+	}
+
+	t.Run("events are emitted in one batch, in order", func(t *testing.T) {
+
+		t.Parallel()
+
+		runtime := newTestInterpreterRuntime()
+		test := newTestCase(t)
+
+		err := runtime.ExecuteTransaction(
+			Script{
+				Source: emitTransaction(3, false),
+			},
+			Context{
+				Interface: test.runtimeInterface,
+				Location:  common.TransactionLocation{0x1},
+			},
+		)
+		require.NoError(t, err)
+
+		require.Len(t, *test.eventBatches, 1)
+		require.Equal(t,
+			[]cadence.Value{
+				cadence.NewInt(0),
+				cadence.NewInt(1),
+				cadence.NewInt(2),
+			},
+			eventIndices((*test.eventBatches)[0]),
+		)
+		require.NotZero(t, *test.writes)
+	})
+
+	t.Run("events of failed transaction are not emitted", func(t *testing.T) {
+
+		t.Parallel()
+
+		runtime := newTestInterpreterRuntime()
+		test := newTestCase(t)
+
+		err := runtime.ExecuteTransaction(
+			Script{
+				Source: emitTransaction(3, true),
+			},
+			Context{
+				Interface: test.runtimeInterface,
+				Location:  common.TransactionLocation{0x1},
+			},
+		)
+		require.Error(t, err)
+
+		require.Empty(t, *test.eventBatches)
+		require.Zero(t, *test.writes)
+	})
+
+	t.Run("emission failure fails transaction", func(t *testing.T) {
+
+		t.Parallel()
+
+		emitEventsErr := errors.New("event limit exceeded")
+
+		runtime := newTestInterpreterRuntime()
+		test := newTestCase(t)
+		*test.emitEventsErr = emitEventsErr
+
+		err := runtime.ExecuteTransaction(
+			Script{
+				Source: emitTransaction(3, false),
+			},
+			Context{
+				Interface: test.runtimeInterface,
+				Location:  common.TransactionLocation{0x1},
+			},
+		)
+		require.ErrorIs(t, err, emitEventsErr)
+
+		// The events are emitted after the storage is committed,
+		// so the host must discard the writes of the failed transaction
+
+		require.Len(t, *test.eventBatches, 1)
+		require.NotZero(t, *test.writes)
+	})
+
+	t.Run("events of transaction failing to commit are not emitted", func(t *testing.T) {
+
+		t.Parallel()
+
+		writeErr := errors.New("ledger unavailable")
+
+		runtime := newTestInterpreterRuntime()
+		test := newTestCase(t)
+		*test.writeErr = writeErr
+
+		err := runtime.ExecuteTransaction(
+			Script{
+				Source: emitTransaction(3, false),
+			},
+			Context{
+				Interface: test.runtimeInterface,
+				Location:  common.TransactionLocation{0x1},
+			},
+		)
+		require.ErrorIs(t, err, writeErr)
+
+		require.Empty(t, *test.eventBatches)
+	})
+
+	t.Run("transaction batch", func(t *testing.T) {
+
+		t.Parallel()
+
+		runtime := newTestInterpreterRuntime()
+		test := newTestCase(t)
+
+		results := runtime.ExecuteTransactionBatch(
+			[]BatchTransaction{
+				{
+					Script: Script{
+						Source: emitTransaction(2, false),
+					},
+					Location: common.TransactionLocation{0x1},
+				},
+				{
+					Script: Script{
+						Source: emitTransaction(1, false),
+					},
+					Location: common.TransactionLocation{0x2},
+				},
+			},
+			Context{
+				Interface: test.runtimeInterface,
+			},
+		)
+		require.NoError(t, results[0].Err)
+		require.NoError(t, results[1].Err)
+
+		require.Len(t, *test.eventBatches, 2)
+		require.Equal(t,
+			[]cadence.Value{
+				cadence.NewInt(0),
+				cadence.NewInt(1),
+			},
+			eventIndices(results[0].Events),
+		)
+		require.Equal(t,
+			[]cadence.Value{
+				cadence.NewInt(0),
+			},
+			eventIndices(results[1].Events),
+		)
+	})
+}
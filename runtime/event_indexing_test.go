@@ -0,0 +1,311 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2020 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runtime
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/cadence/runtime/tests/utils"
+)
+
+type testIndexedEventEmitterRuntimeInterface struct {
+	*testRuntimeInterface
+	emitEventWithIndex func(event cadence.Event, indexedFields []string) error
+}
+
+var _ IndexedEventEmitter = testIndexedEventEmitterRuntimeInterface{}
+
+func (i testIndexedEventEmitterRuntimeInterface) EmitEventWithIndex(
+	event cadence.Event,
+	indexedFields []string,
+) error {
+	return i.emitEventWithIndex(event, indexedFields)
+}
+
+type testIndexedBatchEventEmitterRuntimeInterface struct {
+	*testRuntimeInterface
+	emitEventsWithIndex func(events []cadence.Event, indexedFields [][]string) error
+}
+
+var _ BatchEventEmitter = testIndexedBatchEventEmitterRuntimeInterface{}
+var _ IndexedBatchEventEmitter = testIndexedBatchEventEmitterRuntimeInterface{}
+
+func (i testIndexedBatchEventEmitterRuntimeInterface) EmitEvents(events []cadence.Event) error {
+	return i.emitEventsWithIndex(events, make([][]string, len(events)))
+}
+
+func (i testIndexedBatchEventEmitterRuntimeInterface) EmitEventsWithIndex(
+	events []cadence.Event,
+	indexedFields [][]string,
+) error {
+	return i.emitEventsWithIndex(events, indexedFields)
+}
+
+func TestRuntimeIndexedEventEmitter(t *testing.T) {
+
+	t.Parallel()
+
+	address := common.BytesToAddress([]byte{0x1})
+
+	const contract = `
+      pub contract Test {
+
+          /// Emitted when tokens are transferred.
+          ///
+          /// pragma indexed from, to
+          pub event Transfer(from: Address, to: Address, amount: UFix64)
+
+          pub event Other(value: Int)
+
+          pub fun emitEvents() {
+              emit Transfer(from: 0x1, to: 0x2, amount: 1.0)
+              emit Other(value: 42)
+          }
+      }
+    `
+
+	const transaction = `
+      import Test from 0x1
+
+      transaction {
+          prepare(signer: AuthAccount) {
+              Test.emitEvents()
+          }
+      }
+    `
+
+	type emittedEvent struct {
+		typeID        string
+		indexedFields []string
+	}
+
+	newRuntimeInterface := func(t *testing.T, events *[]emittedEvent) *testRuntimeInterface {
+		var contractCode []byte
+
+		return &testRuntimeInterface{
+			storage: newTestLedger(nil, nil),
+			getSigningAccounts: func() ([]Address, error) {
+				return []Address{address}, nil
+			},
+			resolveLocation: singleIdentifierLocationResolver(t),
+			getAccountContractCode: func(_ Address, _ string) ([]byte, error) {
+				return contractCode, nil
+			},
+			updateAccountContractCode: func(_ Address, _ string, code []byte) error {
+				contractCode = code
+				return nil
+			},
+			emitEvent: func(event cadence.Event) error {
+				*events = append(*events, emittedEvent{
+					typeID: event.EventType.ID(),
+				})
+				return nil
+			},
+		}
+	}
+
+	execute := func(t *testing.T, runtimeInterface Interface) {
+		runtime := newTestInterpreterRuntime()
+
+		nextTransactionLocation := newTransactionLocationGenerator()
+
+		for _, source := range [][]byte{
+			utils.DeploymentTransaction("Test", []byte(contract)),
+			[]byte(transaction),
+		} {
+			err := runtime.ExecuteTransaction(
+				Script{
+					Source: source,
+				},
+				Context{
+					Interface: runtimeInterface,
+					Location:  nextTransactionLocation(),
+				},
+			)
+			require.NoError(t, err)
+		}
+	}
+
+	t.Run("indexed event emitter", func(t *testing.T) {
+
+		t.Parallel()
+
+		var events []emittedEvent
+
+		runtimeInterface := testIndexedEventEmitterRuntimeInterface{
+			testRuntimeInterface: newRuntimeInterface(t, &events),
+			emitEventWithIndex: func(event cadence.Event, indexedFields []string) error {
+				events = append(events, emittedEvent{
+					typeID:        event.EventType.ID(),
+					indexedFields: indexedFields,
+				})
+				return nil
+			},
+		}
+
+		execute(t, runtimeInterface)
+
+		require.Equal(t,
+			[]emittedEvent{
+				{
+					typeID: "flow.AccountContractAdded",
+				},
+				{
+					typeID:        "A.0000000000000001.Test.Transfer",
+					indexedFields: []string{"from", "to"},
+				},
+				{
+					typeID: "A.0000000000000001.Test.Other",
+				},
+			},
+			events,
+		)
+	})
+
+	t.Run("indexed batch event emitter", func(t *testing.T) {
+
+		t.Parallel()
+
+		var events []emittedEvent
+
+		runtimeInterface := testIndexedBatchEventEmitterRuntimeInterface{
+			testRuntimeInterface: newRuntimeInterface(t, &events),
+			emitEventsWithIndex: func(batch []cadence.Event, indexedFields [][]string) error {
+				require.Len(t, indexedFields, len(batch))
+				for i, event := range batch {
+					events = append(events, emittedEvent{
+						typeID:        event.EventType.ID(),
+						indexedFields: indexedFields[i],
+					})
+				}
+				return nil
+			},
+		}
+
+		execute(t, runtimeInterface)
+
+		require.Equal(t,
+			[]emittedEvent{
+				{
+					typeID: "flow.AccountContractAdded",
+				},
+				{
+					typeID:        "A.0000000000000001.Test.Transfer",
+					indexedFields: []string{"from", "to"},
+				},
+				{
+					typeID: "A.0000000000000001.Test.Other",
+				},
+			},
+			events,
+		)
+	})
+
+	t.Run("indexed batch event emitter, transaction batch", func(t *testing.T) {
+
+		t.Parallel()
+
+		var events []emittedEvent
+
+		runtimeInterface := testIndexedBatchEventEmitterRuntimeInterface{
+			testRuntimeInterface: newRuntimeInterface(t, &events),
+			emitEventsWithIndex: func(batch []cadence.Event, indexedFields [][]string) error {
+				require.Len(t, indexedFields, len(batch))
+				for i, event := range batch {
+					events = append(events, emittedEvent{
+						typeID:        event.EventType.ID(),
+						indexedFields: indexedFields[i],
+					})
+				}
+				return nil
+			},
+		}
+
+		runtime := newTestInterpreterRuntime()
+
+		nextTransactionLocation := newTransactionLocationGenerator()
+
+		results := runtime.ExecuteTransactionBatch(
+			[]BatchTransaction{
+				{
+					Script: Script{
+						Source: utils.DeploymentTransaction("Test", []byte(contract)),
+					},
+					Location: nextTransactionLocation(),
+				},
+				{
+					Script: Script{
+						Source: []byte(transaction),
+					},
+					Location: nextTransactionLocation(),
+				},
+			},
+			Context{
+				Interface: runtimeInterface,
+			},
+		)
+		for _, result := range results {
+			require.NoError(t, result.Err)
+		}
+
+		require.Equal(t,
+			[]emittedEvent{
+				{
+					typeID: "flow.AccountContractAdded",
+				},
+				{
+					typeID:        "A.0000000000000001.Test.Transfer",
+					indexedFields: []string{"from", "to"},
+				},
+				{
+					typeID: "A.0000000000000001.Test.Other",
+				},
+			},
+			events,
+		)
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+
+		t.Parallel()
+
+		var events []emittedEvent
+
+		execute(t, newRuntimeInterface(t, &events))
+
+		require.Equal(t,
+			[]emittedEvent{
+				{
+					typeID: "flow.AccountContractAdded",
+				},
+				{
+					typeID: "A.0000000000000001.Test.Transfer",
+				},
+				{
+					typeID: "A.0000000000000001.Test.Other",
+				},
+			},
+			events,
+		)
+	})
+}
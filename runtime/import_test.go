@@ -19,15 +19,18 @@
 package runtime
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/onflow/cadence"
 	"github.com/onflow/cadence/runtime/common"
 	"github.com/onflow/cadence/runtime/sema"
 	"github.com/onflow/cadence/runtime/tests/checker"
+	"github.com/onflow/cadence/runtime/tests/utils"
 )
 
 func TestRuntimeCyclicImport(t *testing.T) {
@@ -114,6 +117,262 @@ func TestRuntimeCyclicImport(t *testing.T) {
 	require.IsType(t, &sema.CyclicImportsError{}, errs[0])
 }
 
+func TestRuntimeImportRewriting(t *testing.T) {
+
+	t.Parallel()
+
+	runtime := newTestInterpreterRuntime()
+
+	mainnetAddress := common.BytesToAddress([]byte{0x1})
+	testingAddress := common.BytesToAddress([]byte{0x2})
+
+	script := []byte(`
+      import Test from 0x1
+
+      pub fun main(): Int {
+          return Test.answer()
+      }
+    `)
+
+	contract := []byte(`
+      pub contract Test {
+          pub fun answer(): Int {
+              return 42
+          }
+      }
+    `)
+
+	ledger := newTestLedger(nil, nil)
+	accountCodes := map[common.LocationID][]byte{}
+
+	newRuntimeInterface := func(rewriteImport func(location Location) (Location, error)) *testRuntimeInterface {
+		return &testRuntimeInterface{
+			storage:         ledger,
+			resolveLocation: singleIdentifierLocationResolver(t),
+			rewriteImport:   rewriteImport,
+			getSigningAccounts: func() ([]Address, error) {
+				return []Address{testingAddress}, nil
+			},
+			getAccountContractCode: func(address Address, name string) ([]byte, error) {
+				location := common.AddressLocation{
+					Address: address,
+					Name:    name,
+				}
+				return accountCodes[location.ID()], nil
+			},
+			updateAccountContractCode: func(address Address, name string, code []byte) error {
+				location := common.AddressLocation{
+					Address: address,
+					Name:    name,
+				}
+				accountCodes[location.ID()] = code
+				return nil
+			},
+			emitEvent: func(_ cadence.Event) error {
+				return nil
+			},
+		}
+	}
+
+	// Deploy the contract to the testing address only
+
+	err := runtime.ExecuteTransaction(
+		Script{
+			Source: utils.DeploymentTransaction("Test", contract),
+		},
+		Context{
+			Interface: newRuntimeInterface(nil),
+			Location:  newTransactionLocationGenerator()(),
+		},
+	)
+	require.NoError(t, err)
+
+	executeScript := func(runtimeInterface Interface) (cadence.Value, error) {
+		return runtime.ExecuteScript(
+			Script{
+				Source: script,
+			},
+			Context{
+				Interface: runtimeInterface,
+				Location:  common.ScriptLocation{},
+			},
+		)
+	}
+
+	t.Run("rewritten", func(t *testing.T) {
+
+		var rewrittenLocations []Location
+
+		runtimeInterface := newRuntimeInterface(
+			func(location Location) (Location, error) {
+				rewrittenLocations = append(rewrittenLocations, location)
+
+				addressLocation, ok := location.(common.AddressLocation)
+				if ok && addressLocation.Address == mainnetAddress {
+					addressLocation.Address = testingAddress
+					return addressLocation, nil
+				}
+				return location, nil
+			},
+		)
+
+		value, err := executeScript(runtimeInterface)
+		require.NoError(t, err)
+
+		require.Equal(t, cadence.NewInt(42), value)
+
+		require.Equal(t,
+			[]Location{
+				common.AddressLocation{Address: mainnetAddress},
+			},
+			rewrittenLocations,
+		)
+	})
+
+	t.Run("not rewritten", func(t *testing.T) {
+
+		runtimeInterface := newRuntimeInterface(nil)
+
+		_, err := executeScript(runtimeInterface)
+		require.Error(t, err)
+	})
+
+	t.Run("error", func(t *testing.T) {
+
+		rewriteErr := errors.New("cannot rewrite import")
+
+		runtimeInterface := newRuntimeInterface(
+			func(_ Location) (Location, error) {
+				return nil, rewriteErr
+			},
+		)
+
+		_, err := executeScript(runtimeInterface)
+		require.Error(t, err)
+
+		require.Contains(t, err.Error(), rewriteErr.Error())
+	})
+}
+
+func TestRuntimeImportRewritingContractChain(t *testing.T) {
+
+	t.Parallel()
+
+	runtime := newTestInterpreterRuntime()
+
+	// The contracts import each other from the original address,
+	// but are deployed to a sandbox address
+
+	originalAddress, err := common.HexToAddress("0xaad3e26e406987c2")
+	require.NoError(t, err)
+
+	sandboxAddress := common.BytesToAddress([]byte{0x2})
+
+	accountCodes := map[common.LocationID][]byte{}
+
+	var rewrittenLocations []Location
+
+	runtimeInterface := &testRuntimeInterface{
+		storage:         newTestLedger(nil, nil),
+		resolveLocation: singleIdentifierLocationResolver(t),
+		rewriteImport: func(location Location) (Location, error) {
+			addressLocation, ok := location.(common.AddressLocation)
+			if !ok || addressLocation.Address != originalAddress {
+				return location, nil
+			}
+
+			rewrittenLocations = append(rewrittenLocations, location)
+
+			addressLocation.Address = sandboxAddress
+			return addressLocation, nil
+		},
+		getSigningAccounts: func() ([]Address, error) {
+			return []Address{sandboxAddress}, nil
+		},
+		getAccountContractCode: func(address Address, name string) ([]byte, error) {
+			location := common.AddressLocation{
+				Address: address,
+				Name:    name,
+			}
+			return accountCodes[location.ID()], nil
+		},
+		updateAccountContractCode: func(address Address, name string, code []byte) error {
+			location := common.AddressLocation{
+				Address: address,
+				Name:    name,
+			}
+			accountCodes[location.ID()] = code
+			return nil
+		},
+		emitEvent: func(_ cadence.Event) error {
+			return nil
+		},
+	}
+
+	nextTransactionLocation := newTransactionLocationGenerator()
+
+	for _, deployTx := range [][]byte{
+		utils.DeploymentTransaction("FungibleToken", []byte(realFungibleTokenContractInterface)),
+		utils.DeploymentTransaction("DapperUtilityCoin", []byte(realDapperUtilityCoinContract)),
+	} {
+		err := runtime.ExecuteTransaction(
+			Script{
+				Source: deployTx,
+			},
+			Context{
+				Interface: runtimeInterface,
+				Location:  nextTransactionLocation(),
+			},
+		)
+		require.NoError(t, err)
+	}
+
+	// The import of FungibleToken in DapperUtilityCoin was rewritten
+
+	require.Equal(t,
+		[]Location{
+			common.AddressLocation{Address: originalAddress},
+		},
+		rewrittenLocations,
+	)
+
+	rewrittenLocations = nil
+
+	value, err := runtime.ExecuteScript(
+		Script{
+			Source: []byte(`
+              import FungibleToken from 0xaad3e26e406987c2
+              import DapperUtilityCoin from 0xaad3e26e406987c2
+
+              pub fun main(): UFix64 {
+                  let vault <- DapperUtilityCoin.createEmptyVault() as! @FungibleToken.Vault
+                  let balance = vault.balance
+                  destroy vault
+                  return balance
+              }
+            `),
+		},
+		Context{
+			Interface: runtimeInterface,
+			Location:  common.ScriptLocation{},
+		},
+	)
+	require.NoError(t, err)
+
+	require.Equal(t, cadence.UFix64(0), value)
+
+	// The imports of the script and the import of FungibleToken in DapperUtilityCoin were rewritten
+
+	require.Equal(t,
+		[]Location{
+			common.AddressLocation{Address: originalAddress},
+			common.AddressLocation{Address: originalAddress},
+			common.AddressLocation{Address: originalAddress},
+		},
+		rewrittenLocations,
+	)
+}
+
 func TestRuntimeExport(t *testing.T) {
 
 	t.Parallel()
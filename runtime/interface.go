@@ -115,8 +115,6 @@ type Interface interface {
 	GetAccountAvailableBalance(address common.Address) (value uint64, err error)
 	// GetStorageUsed gets storage used in bytes by the address at the moment of the function call.
 	GetStorageUsed(address Address) (value uint64, err error)
-	// GetStorageCapacity gets storage capacity in bytes on the address.
-	GetStorageCapacity(address Address) (value uint64, err error)
 	// ImplementationDebugLog logs implementation log statements on a debug-level
 	ImplementationDebugLog(message string) error
 	// ValidatePublicKey verifies the validity of a public key.
@@ -133,6 +131,129 @@ type Interface interface {
 	AggregateBLSPublicKeys(keys []*PublicKey) (*PublicKey, error)
 }
 
+// ResourceDestructionListener is an optional interface of the runtime interface.
+// If the runtime interface implements it, it is notified when a resource is destroyed.
+//
+// The listener is notified for every destroyed resource, including the resources which are
+// destroyed implicitly, e.g. the elements of a destroyed array or dictionary.
+//
+type ResourceDestructionListener interface {
+	OnResourceDestroyed(typeID string, uuid uint64)
+}
+
+// ImportRewriter is an optional interface of the runtime interface.
+// If the runtime interface implements it, the location of each import is rewritten
+// before it is resolved using ResolveLocation,
+// e.g. to redirect imports of contracts to other addresses for local testing.
+//
+type ImportRewriter interface {
+	RewriteImport(location common.Location) (common.Location, error)
+}
+
+// AccessedAccountsListener is an optional interface of the runtime interface.
+// If the runtime interface implements it, it is notified about the accounts
+// whose storage was read or written by a transaction, after the transaction was executed successfully.
+//
+// The addresses are distinct and sorted.
+//
+type AccessedAccountsListener interface {
+	OnAccountsAccessed(addresses []Address)
+}
+
+// EventCountListener is an optional interface of the runtime interface.
+// If the runtime interface implements it, it is notified about the number of events
+// emitted by a transaction, after the transaction was executed successfully.
+//
+// Like for Context.MaxEvents, only the events emitted by the program are counted,
+// not the events emitted by the runtime, e.g. flow.AccountContractAdded.
+//
+type EventCountListener interface {
+	OnEventsEmitted(count uint64)
+}
+
+// StorageCapacityProvider is an optional interface of the runtime interface.
+// If the runtime interface implements it, programs can read the storage capacity of accounts,
+// i.e. the `storageCapacity` field of `AuthAccount` and `PublicAccount`.
+//
+// If the runtime interface does not implement it,
+// reading the field aborts the execution with a StorageCapacityUnavailableError.
+//
+type StorageCapacityProvider interface {
+	// GetStorageCapacity gets storage capacity in bytes on the address.
+	GetStorageCapacity(address Address) (value uint64, err error)
+}
+
+// BatchEventEmitter is an optional interface of the runtime interface.
+// If the runtime interface implements it, the events emitted by a transaction
+// are not passed to EmitEvent one by one, but are buffered,
+// and are passed to EmitEvents in one batch once the transaction was executed successfully,
+// after the storage was committed.
+//
+// The events are delivered in the order in which they were emitted.
+//
+// The events of a transaction which fails, including when committing the storage fails,
+// are never delivered. If EmitEvents returns an error, the transaction fails,
+// so none of the events of the batch should be considered emitted,
+// and the host must discard the writes of the transaction.
+//
+type BatchEventEmitter interface {
+	EmitEvents(events []cadence.Event) error
+}
+
+// IndexedEventEmitter is an optional interface of the runtime interface.
+// If the runtime interface implements it, events which have indexed fields
+// are passed to EmitEventWithIndex instead of EmitEvent, together with the names of the indexed fields,
+// e.g. so event-indexing backends can build indices for them.
+//
+// Fields of an event are marked as indexed using `pragma indexed` declarations
+// in the docstring of the event declaration.
+// Events without indexed fields are still passed to EmitEvent.
+//
+// If the runtime interface also implements BatchEventEmitter, the events are buffered,
+// and are passed to EmitEventsWithIndex if it implements IndexedBatchEventEmitter.
+// Otherwise, the events are passed to EmitEvents, without the names of the indexed fields.
+//
+type IndexedEventEmitter interface {
+	EmitEventWithIndex(event cadence.Event, indexedFields []string) error
+}
+
+// IndexedBatchEventEmitter is an optional interface of the runtime interface.
+// If the runtime interface implements it in addition to BatchEventEmitter,
+// the buffered events of a transaction are passed to EmitEventsWithIndex instead of EmitEvents,
+// together with the names of the indexed fields of each event (see IndexedEventEmitter).
+//
+// The names of the indexed fields of an event are at the same index as the event.
+// They are nil for events without indexed fields.
+//
+type IndexedBatchEventEmitter interface {
+	EmitEventsWithIndex(events []cadence.Event, indexedFields [][]string) error
+}
+
+// ComputationKind is the kind of computation which is metered.
+//
+type ComputationKind uint
+
+const (
+	ComputationKindUnknown ComputationKind = iota
+	// ComputationKindStorageRead is the computation of reading a register from storage.
+	// The intensity is the number of bytes read.
+	ComputationKindStorageRead
+	// ComputationKindStorageWrite is the computation of writing a register to storage.
+	// The intensity is the number of bytes written.
+	ComputationKindStorageWrite
+)
+
+// ComputationMeter is an optional interface of the runtime interface.
+// If the runtime interface implements it, the storage meters the computation
+// of reading and writing registers, e.g. slabs and account storage values,
+// so storage I/O can be priced independently from the interpreter steps.
+//
+// If the function returns an error, the execution is aborted.
+//
+type ComputationMeter interface {
+	MeterComputation(kind ComputationKind, intensity uint) error
+}
+
 type Metrics interface {
 	ProgramParsed(location common.Location, duration time.Duration)
 	ProgramChecked(location common.Location, duration time.Duration)
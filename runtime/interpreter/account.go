@@ -101,6 +101,12 @@ func NewAuthAccountValue(
 		sema.AuthAccountSaveField: func(inter *Interpreter, _ func() LocationRange) Value {
 			return inter.authAccountSaveFunction(address)
 		},
+		sema.AuthAccountEnsureSavedField: func(inter *Interpreter, _ func() LocationRange) Value {
+			return inter.authAccountEnsureSavedFunction(address)
+		},
+		sema.AuthAccountSaveOrReplaceField: func(inter *Interpreter, _ func() LocationRange) Value {
+			return inter.authAccountSaveOrReplaceFunction(address)
+		},
 		sema.AuthAccountBorrowField: func(inter *Interpreter, _ func() LocationRange) Value {
 			return inter.authAccountBorrowFunction(address)
 		},
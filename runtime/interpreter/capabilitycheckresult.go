@@ -0,0 +1,53 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2020 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package interpreter
+
+import (
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/cadence/runtime/sema"
+)
+
+// NewCapabilityCheckResultValue constructs a CapabilityCheckResult value.
+//
+func NewCapabilityCheckResultValue(
+	interpreter *Interpreter,
+	isLinked BoolValue,
+	isBorrowable BoolValue,
+) *CompositeValue {
+
+	fields := []CompositeField{
+		{
+			Name:  sema.CapabilityCheckResultIsLinkedField,
+			Value: isLinked,
+		},
+		{
+			Name:  sema.CapabilityCheckResultIsBorrowableField,
+			Value: isBorrowable,
+		},
+	}
+
+	return NewCompositeValue(
+		interpreter,
+		sema.CapabilityCheckResultType.Location,
+		sema.CapabilityCheckResultType.QualifiedIdentifier(),
+		sema.CapabilityCheckResultType.Kind,
+		fields,
+		common.Address{},
+	)
+}
@@ -242,7 +242,9 @@ func (d Decoder) decodeString(v string) *StringValue {
 	return NewStringValue(v)
 }
 
-func decodeLocation(dec *cbor.StreamDecoder) (common.Location, error) {
+// DecodeLocation decodes a location which was encoded using EncodeLocation.
+//
+func DecodeLocation(dec *cbor.StreamDecoder) (common.Location, error) {
 	// Location can be CBOR nil.
 	err := dec.DecodeNil()
 	if err == nil {
@@ -1118,7 +1120,7 @@ func decodeCompositeStaticType(dec *cbor.StreamDecoder) (StaticType, error) {
 	}
 
 	// Decode location at array index encodedCompositeStaticTypeLocationFieldKey
-	location, err := decodeLocation(dec)
+	location, err := DecodeLocation(dec)
 	if err != nil {
 		return nil, fmt.Errorf("invalid composite static type location encoding: %w", err)
 	}
@@ -1165,7 +1167,7 @@ func decodeInterfaceStaticType(dec *cbor.StreamDecoder) (InterfaceStaticType, er
 	}
 
 	// Decode location at array index encodedInterfaceStaticTypeLocationFieldKey
-	location, err := decodeLocation(dec)
+	location, err := DecodeLocation(dec)
 	if err != nil {
 		return InterfaceStaticType{}, fmt.Errorf(
 			"invalid interface static type location encoding: %w",
@@ -1487,7 +1489,7 @@ func decodeCompositeTypeInfo(dec *cbor.StreamDecoder) (atree.TypeInfo, error) {
 		)
 	}
 
-	location, err := decodeLocation(dec)
+	location, err := DecodeLocation(dec)
 	if err != nil {
 		return nil, err
 	}
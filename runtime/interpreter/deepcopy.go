@@ -0,0 +1,86 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package interpreter
+
+import (
+	"github.com/onflow/atree"
+
+	"github.com/onflow/cadence/runtime/common"
+)
+
+// DeepCopy returns a deep copy of the given value, owned by the given address,
+// e.g. to duplicate a stored value under another account in a migration.
+//
+// The copy has the semantics of a transfer of the value to the new owner:
+// All nested values are copied into new slabs with fresh storage IDs,
+// which are allocated in the storage of the new owner.
+//
+// Unlike a transfer, the given value is left unchanged,
+// even if it is a resource. Resources are duplicated as-is,
+// e.g. the copy of a resource has the same UUID as the original.
+//
+func DeepCopy(inter *Interpreter, value Value, newOwner common.Address) (result Value, err error) {
+
+	// Recover internal panics and return them as an error.
+	// For example, the storage might fail to allocate slabs
+
+	defer inter.RecoverErrors(func(internalErr error) {
+		err = internalErr
+	})
+
+	return deepCopy(inter, value, atree.Address(newOwner)), nil
+}
+
+func deepCopy(inter *Interpreter, value Value, address atree.Address) Value {
+
+	// The root slab of an inner value is only removed by the transfer
+	// if the storable of the containing optional is given,
+	// so copy the inner value separately
+
+	if someValue, ok := value.(*SomeValue); ok {
+		innerValue := deepCopy(inter, someValue.Value, address)
+		return NewSomeValueNonCopying(innerValue)
+	}
+
+	// Transferring a resource moves it, i.e. the transferred resource is the same value.
+	// So first clone the value, and then transfer the clone to the new owner.
+	//
+	// The clone is removed from storage by the transfer,
+	// so only the slabs of the copy remain
+
+	clone := value.Clone(inter)
+
+	var storable atree.Storable
+	switch clone := clone.(type) {
+	case *ArrayValue:
+		storable = atree.StorageIDStorable(clone.StorageID())
+	case *DictionaryValue:
+		storable = atree.StorageIDStorable(clone.StorageID())
+	case *CompositeValue:
+		storable = atree.StorageIDStorable(clone.StorageID())
+	}
+
+	return clone.Transfer(
+		inter,
+		ReturnEmptyLocationRange,
+		address,
+		true,
+		storable,
+	)
+}
@@ -0,0 +1,125 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2021 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package interpreter_test
+
+import (
+	"testing"
+
+	"github.com/onflow/atree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/cadence/runtime/common"
+	. "github.com/onflow/cadence/runtime/interpreter"
+	"github.com/onflow/cadence/runtime/tests/utils"
+)
+
+func TestDeepCopy(t *testing.T) {
+
+	t.Parallel()
+
+	oldOwner := common.Address{0x1}
+	newOwner := common.Address{0x2}
+
+	storage := NewInMemoryStorage()
+
+	inter, err := NewInterpreter(
+		nil,
+		utils.TestLocation,
+		WithStorage(storage),
+	)
+	require.NoError(t, err)
+
+	countSlabs := func(address common.Address) int {
+		count := 0
+		for id := range storage.Slabs {
+			if id.Address == atree.Address(address) {
+				count++
+			}
+		}
+		return count
+	}
+
+	dictionaryStaticType := DictionaryStaticType{
+		KeyType:   PrimitiveStaticTypeString,
+		ValueType: PrimitiveStaticTypeInt256,
+	}
+
+	dictValue := NewDictionaryValue(
+		inter,
+		dictionaryStaticType,
+		NewStringValue("a"),
+		NewInt256ValueFromInt64(1),
+	)
+
+	arrayValue := NewArrayValue(
+		inter,
+		VariableSizedStaticType{
+			Type: dictionaryStaticType,
+		},
+		common.Address{},
+		dictValue,
+	)
+
+	resourceValue := NewCompositeValue(
+		inter,
+		utils.TestLocation,
+		"Test",
+		common.CompositeKindResource,
+		nil,
+		oldOwner,
+	)
+
+	resourceValue.SetMember(
+		inter,
+		ReturnEmptyLocationRange,
+		"value",
+		NewSomeValueNonCopying(arrayValue),
+	)
+
+	oldOwnerSlabCount := countSlabs(oldOwner)
+	require.NotZero(t, oldOwnerSlabCount)
+	require.Zero(t, countSlabs(newOwner))
+
+	copied, err := DeepCopy(inter, resourceValue, newOwner)
+	require.NoError(t, err)
+
+	require.IsType(t, &CompositeValue{}, copied)
+	copiedResource := copied.(*CompositeValue)
+
+	// The copy is a separate value, owned by the new owner,
+	// stored in as many slabs as the original
+
+	assert.NotSame(t, resourceValue, copiedResource)
+	assert.Equal(t, newOwner, copiedResource.GetOwner())
+	assert.NotEqual(t, resourceValue.StorageID(), copiedResource.StorageID())
+	assert.Equal(t, oldOwnerSlabCount, countSlabs(newOwner))
+
+	copiedArray := copiedResource.GetField(inter, ReturnEmptyLocationRange, "value").(*SomeValue).Value.(*ArrayValue)
+	assert.Equal(t, newOwner, copiedArray.GetOwner())
+
+	// The original is unchanged, and no temporary slabs remain
+
+	assert.Equal(t, oldOwner, resourceValue.GetOwner())
+	assert.Equal(t, oldOwnerSlabCount, countSlabs(oldOwner))
+
+	originalArray := resourceValue.GetField(inter, ReturnEmptyLocationRange, "value").(*SomeValue).Value.(*ArrayValue)
+	assert.Equal(t, oldOwner, originalArray.GetOwner())
+	assert.Equal(t, 1, originalArray.Count())
+}
@@ -735,7 +735,9 @@ const (
 	encodedAddressLocationLength = 2
 )
 
-func encodeLocation(e *cbor.StreamEncoder, l common.Location) error {
+// EncodeLocation encodes the given location, or CBOR nil if the location is nil.
+//
+func EncodeLocation(e *cbor.StreamEncoder, l common.Location) error {
 	if l == nil {
 		return e.EncodeNil()
 	}
@@ -1011,7 +1013,7 @@ func (t CompositeStaticType) Encode(e *cbor.StreamEncoder) error {
 	}
 
 	// Encode location at array index encodedCompositeStaticTypeLocationFieldKey
-	err = encodeLocation(e, t.Location)
+	err = EncodeLocation(e, t.Location)
 	if err != nil {
 		return err
 	}
@@ -1053,7 +1055,7 @@ func (t InterfaceStaticType) Encode(e *cbor.StreamEncoder) error {
 	}
 
 	// Encode location at array index encodedInterfaceStaticTypeLocationFieldKey
-	err = encodeLocation(e, t.Location)
+	err = EncodeLocation(e, t.Location)
 	if err != nil {
 		return err
 	}
@@ -1294,7 +1296,7 @@ func (c compositeTypeInfo) Encode(e *cbor.StreamEncoder) error {
 		return err
 	}
 
-	err = encodeLocation(e, c.location)
+	err = EncodeLocation(e, c.location)
 	if err != nil {
 		return err
 	}
@@ -91,6 +91,15 @@ func (e ExternalError) Error() string {
 	return fmt.Sprint(e.Recovered)
 }
 
+// ExternalAbortError is implemented by errors which external code, e.g. the ledger of the storage,
+// returns to abort the execution, e.g. because a limit is exceeded.
+// Unlike other external errors, they are recovered and reported like errors of the interpreter.
+//
+type ExternalAbortError interface {
+	error
+	IsExternalAbortError()
+}
+
 // NotDeclaredError
 
 type NotDeclaredError struct {
@@ -183,20 +192,106 @@ func (e DereferenceError) Error() string {
 	return "dereference failed"
 }
 
-// OverflowError
-
-type OverflowError struct{}
+// OverflowError is reported when the result of an arithmetic operation
+// or a conversion exceeds the maximum value of the type.
+//
+// Operation is unknown and RightValue is nil for conversions,
+// and RightValue is nil for unary operations.
+//
+type OverflowError struct {
+	Operation  ast.Operation
+	LeftValue  Value
+	RightValue Value
+	Type       StaticType
+}
 
 func (e OverflowError) Error() string {
-	return "overflow"
+	return arithmeticErrorMessage("overflow", "overflows", e.Operation, e.LeftValue, e.RightValue, e.Type)
 }
 
-// UnderflowError
-
-type UnderflowError struct{}
+// UnderflowError is reported when the result of an arithmetic operation
+// or a conversion is below the minimum value of the type.
+//
+// The fields are set in the same way as for OverflowError.
+//
+type UnderflowError struct {
+	Operation  ast.Operation
+	LeftValue  Value
+	RightValue Value
+	Type       StaticType
+}
 
 func (e UnderflowError) Error() string {
-	return "underflow"
+	return arithmeticErrorMessage("underflow", "underflows", e.Operation, e.LeftValue, e.RightValue, e.Type)
+}
+
+// newArithmeticOverflowError returns an OverflowError for the given arithmetic operation.
+// The right value is nil for unary operations.
+//
+func newArithmeticOverflowError(operation ast.Operation, left, right NumberValue) OverflowError {
+	return OverflowError{
+		Operation:  operation,
+		LeftValue:  left,
+		RightValue: right,
+		Type:       left.StaticType(),
+	}
+}
+
+// newArithmeticUnderflowError returns an UnderflowError for the given arithmetic operation.
+// The right value is nil for unary operations.
+//
+func newArithmeticUnderflowError(operation ast.Operation, left, right NumberValue) UnderflowError {
+	return UnderflowError{
+		Operation:  operation,
+		LeftValue:  left,
+		RightValue: right,
+		Type:       left.StaticType(),
+	}
+}
+
+// newConversionOverflowError returns an OverflowError
+// for the conversion of the given value to the given type.
+//
+func newConversionOverflowError(value Value, targetType StaticType) OverflowError {
+	return OverflowError{
+		LeftValue: value,
+		Type:      targetType,
+	}
+}
+
+// newConversionUnderflowError returns an UnderflowError
+// for the conversion of the given value to the given type.
+//
+func newConversionUnderflowError(value Value, targetType StaticType) UnderflowError {
+	return UnderflowError{
+		LeftValue: value,
+		Type:      targetType,
+	}
+}
+
+func arithmeticErrorMessage(
+	kind string,
+	verb string,
+	operation ast.Operation,
+	left Value,
+	right Value,
+	staticType StaticType,
+) string {
+	if left == nil || staticType == nil {
+		return kind
+	}
+
+	var expression string
+	switch {
+	case right != nil:
+		expression = fmt.Sprintf("%s %s %s", left, operation.Symbol(), right)
+	case operation == ast.OperationNegate:
+		expression = fmt.Sprintf("-(%s)", left)
+	default:
+		expression = left.String()
+	}
+
+	return fmt.Sprintf("%s: %s %s %s", kind, expression, verb, staticType)
 }
 
 // UnderflowError
@@ -211,20 +306,26 @@ func (e DivisionByZeroError) Error() string {
 
 type InvalidatedResourceError struct {
 	LocationRange
+	// History is the recorded move history of the resource, if any
+	History []ResourceMove
 }
 
 func (e InvalidatedResourceError) Error() string {
-	return "resource is invalidated and cannot be used anymore"
+	return "resource is invalidated and cannot be used anymore" +
+		formatResourceHistory(e.History)
 }
 
 // ForceAssignmentToNonNilResourceError
 //
 type ForceAssignmentToNonNilResourceError struct {
 	LocationRange
+	// History is the recorded move history of the existing resource, if any
+	History []ResourceMove
 }
 
 func (e ForceAssignmentToNonNilResourceError) Error() string {
-	return "force assignment to non-nil resource-typed value"
+	return "force assignment to non-nil resource-typed value" +
+		formatResourceHistory(e.History)
 }
 
 // ForceNilError
@@ -292,19 +393,71 @@ func (e InvalidPathDomainError) SecondaryError() string {
 	)
 }
 
+// InvalidHexStringError
+//
+type InvalidHexStringError struct {
+	Err error
+	LocationRange
+}
+
+func (e InvalidHexStringError) Error() string {
+	return fmt.Sprintf("invalid hex string: %s", e.Err)
+}
+
+func (e InvalidHexStringError) Unwrap() error {
+	return e.Err
+}
+
+// ReadOnlyViolationError is reported when a read-only execution
+// attempts to mutate storage, e.g. save a value or write a field of a stored value.
+//
+type ReadOnlyViolationError struct {
+	Operation string
+	LocationRange
+}
+
+func (e ReadOnlyViolationError) Error() string {
+	return fmt.Sprintf(
+		"cannot perform %s: storage is read-only",
+		e.Operation,
+	)
+}
+
 // OverwriteError
 //
 type OverwriteError struct {
 	Address AddressValue
 	Path    PathValue
 	LocationRange
+	// History is the recorded move history of the resource being saved, if any
+	History []ResourceMove
 }
 
 func (e OverwriteError) Error() string {
 	return fmt.Sprintf(
-		"failed to save object: path %s in account %s already stores an object",
+		"failed to save object: path %s in account %s already stores an object%s",
+		e.Path,
+		e.Address,
+		formatResourceHistory(e.History),
+	)
+}
+
+// ResourceOverwriteError
+//
+type ResourceOverwriteError struct {
+	Address AddressValue
+	Path    PathValue
+	LocationRange
+	// History is the recorded move history of the stored resource, if any
+	History []ResourceMove
+}
+
+func (e ResourceOverwriteError) Error() string {
+	return fmt.Sprintf(
+		"failed to replace object: path %s in account %s stores a resource, which must be loaded first%s",
 		e.Path,
 		e.Address,
+		formatResourceHistory(e.History),
 	)
 }
 
@@ -333,6 +486,65 @@ func (e CyclicLinkError) Error() string {
 	)
 }
 
+// BorrowChainLengthExceededError
+//
+type BorrowChainLengthExceededError struct {
+	Limit int
+	LocationRange
+}
+
+func (e BorrowChainLengthExceededError) Error() string {
+	return fmt.Sprintf(
+		"borrow chain length limit exceeded: a single statement may perform at most %d borrows",
+		e.Limit,
+	)
+}
+
+// ValueDepthLimitExceededError is reported when a value which is stored
+// is nested deeper than the maximum value depth.
+//
+type ValueDepthLimitExceededError struct {
+	Limit int
+	LocationRange
+}
+
+func (e ValueDepthLimitExceededError) Error() string {
+	return fmt.Sprintf(
+		"value depth limit exceeded: values may be nested at most %d levels deep",
+		e.Limit,
+	)
+}
+
+// LoopIterationLimitError is reported when an execution
+// performs more loop iterations than the maximum number of loop iterations.
+//
+type LoopIterationLimitError struct {
+	Limit uint64
+	LocationRange
+}
+
+func (e LoopIterationLimitError) Error() string {
+	return fmt.Sprintf(
+		"loop iteration limit exceeded: an execution may perform at most %d loop iterations",
+		e.Limit,
+	)
+}
+
+// EventLimitExceededError is reported when an execution
+// emits more events than the maximum number of events.
+//
+type EventLimitExceededError struct {
+	Limit uint64
+	LocationRange
+}
+
+func (e EventLimitExceededError) Error() string {
+	return fmt.Sprintf(
+		"event limit exceeded: an execution may emit at most %d events",
+		e.Limit,
+	)
+}
+
 // ArrayIndexOutOfBoundsError
 //
 type ArrayIndexOutOfBoundsError struct {
@@ -395,6 +607,23 @@ func (e TypeLoadingError) Error() string {
 	return fmt.Sprintf("failed to load type: %s", e.TypeID)
 }
 
+// CapabilityBorrowTypeNotFoundError is reported when a capability is used,
+// e.g. borrowed, but its borrow type refers to a type which does not exist (anymore),
+// for example because the declaring contract was removed.
+//
+type CapabilityBorrowTypeNotFoundError struct {
+	Location            common.Location
+	QualifiedIdentifier string
+	LocationRange
+}
+
+func (e CapabilityBorrowTypeNotFoundError) Error() string {
+	return fmt.Sprintf(
+		"capability borrow type not found: `%s`",
+		e.Location.TypeID(e.QualifiedIdentifier),
+	)
+}
+
 // EncodingUnsupportedValueError
 //
 type EncodingUnsupportedValueError struct {
@@ -498,10 +727,22 @@ func (e ContainerMutationError) Error() string {
 	)
 }
 
+// EphemeralResourceReferenceError is reported when a reference is taken to a resource
+// which is produced by the referenced expression, e.g. returned from a function call.
+//
+type EphemeralResourceReferenceError struct {
+	LocationRange
+}
+
+func (e EphemeralResourceReferenceError) Error() string {
+	return "cannot create reference to ephemeral resource: the resource is not stored and would be lost"
+}
+
 // NonStorableValueError
 //
 type NonStorableValueError struct {
 	Value Value
+	LocationRange
 }
 
 func (e NonStorableValueError) Error() string {
@@ -24,6 +24,7 @@ import (
 	"fmt"
 	"math"
 	goRuntime "runtime"
+	"strings"
 	"time"
 
 	"github.com/onflow/atree"
@@ -88,6 +89,23 @@ type OnEventEmittedFunc func(
 	eventType *sema.CompositeType,
 ) error
 
+// OnResourceDestroyedFunc is a function that is triggered when a resource is destroyed.
+//
+type OnResourceDestroyedFunc func(
+	inter *Interpreter,
+	typeID common.TypeID,
+	uuid uint64,
+)
+
+// OnCapabilityStoredFunc is a function that is triggered when a capability is transferred
+// into the storage of an account, e.g. when it is saved or inserted into a stored container.
+//
+type OnCapabilityStoredFunc func(
+	inter *Interpreter,
+	getLocationRange func() LocationRange,
+	capability *CapabilityValue,
+)
+
 // OnStatementFunc is a function that is triggered when a statement is about to be executed.
 //
 type OnStatementFunc func(
@@ -285,6 +303,8 @@ type Interpreter struct {
 	Transactions                   []*HostFunctionValue
 	Storage                        Storage
 	onEventEmitted                 OnEventEmittedFunc
+	onResourceDestroyed            OnResourceDestroyedFunc
+	onCapabilityStored             OnCapabilityStoredFunc
 	onStatement                    OnStatementFunc
 	onLoopIteration                OnLoopIterationFunc
 	onFunctionInvocation           OnFunctionInvocationFunc
@@ -307,7 +327,25 @@ type Interpreter struct {
 	atreeValueValidationEnabled    bool
 	atreeStorageValidationEnabled  bool
 	tracingEnabled                 bool
-}
+	maxBorrowChainLength           int
+	borrowCount                    int
+	readOnly                       bool
+	maxValueDepth                  int
+	storedValueDepth               int
+	maxLoopIterations              uint64
+	loopIterations                 *uint64
+	resourceHistory                *ResourceHistory
+	maxEvents                      uint64
+	eventCount                     *uint64
+	storageUsedCache               *StorageUsedCache
+}
+
+// DefaultMaxValueDepth is the default maximum nesting depth of stored values.
+//
+// The depth of a value is the number of nested container values,
+// i.e. arrays, dictionaries, composites, and optionals.
+//
+const DefaultMaxValueDepth = 1000
 
 type Option func(*Interpreter) error
 
@@ -321,6 +359,26 @@ func WithOnEventEmittedHandler(handler OnEventEmittedFunc) Option {
 	}
 }
 
+// WithOnResourceDestroyedHandler returns an interpreter option which sets
+// the given function as the resource destruction handler.
+//
+func WithOnResourceDestroyedHandler(handler OnResourceDestroyedFunc) Option {
+	return func(interpreter *Interpreter) error {
+		interpreter.SetOnResourceDestroyedHandler(handler)
+		return nil
+	}
+}
+
+// WithOnCapabilityStoredHandler returns an interpreter option which sets
+// the given function as the capability storage handler.
+//
+func WithOnCapabilityStoredHandler(handler OnCapabilityStoredFunc) Option {
+	return func(interpreter *Interpreter) error {
+		interpreter.SetOnCapabilityStoredHandler(handler)
+		return nil
+	}
+}
+
 // WithOnStatementHandler returns an interpreter option which sets
 // the given function as the statement handler.
 //
@@ -550,6 +608,102 @@ func WithTracingEnabled(enabled bool) Option {
 	}
 }
 
+// WithMaxBorrowChainLength returns an interpreter option which sets
+// the maximum number of borrows a single statement may perform.
+// A limit of zero means the number of borrows is unlimited.
+//
+func WithMaxBorrowChainLength(limit int) Option {
+	return func(interpreter *Interpreter) error {
+		interpreter.SetMaxBorrowChainLength(limit)
+		return nil
+	}
+}
+
+// WithReadOnly returns an interpreter option which sets
+// if the interpreter is read-only, i.e. if all storage mutations are rejected.
+//
+func WithReadOnly(readOnly bool) Option {
+	return func(interpreter *Interpreter) error {
+		interpreter.SetReadOnly(readOnly)
+		return nil
+	}
+}
+
+// WithMaxValueDepth returns an interpreter option which sets
+// the maximum nesting depth of stored values.
+// A limit of zero means the nesting depth is unlimited.
+//
+func WithMaxValueDepth(limit int) Option {
+	return func(interpreter *Interpreter) error {
+		interpreter.SetMaxValueDepth(limit)
+		return nil
+	}
+}
+
+// WithMaxLoopIterations returns an interpreter option which sets
+// the maximum number of loop iterations an execution may perform.
+// A limit of zero means the number of loop iterations is unlimited.
+//
+func WithMaxLoopIterations(limit uint64) Option {
+	return func(interpreter *Interpreter) error {
+		interpreter.SetMaxLoopIterations(limit)
+		return nil
+	}
+}
+
+// WithResourceHistory returns an interpreter option which sets
+// the history in which the moves of resources are recorded.
+// If the history is nil, no moves are recorded.
+//
+func WithResourceHistory(history *ResourceHistory) Option {
+	return func(interpreter *Interpreter) error {
+		interpreter.resourceHistory = history
+		return nil
+	}
+}
+
+// WithMaxEvents returns an interpreter option which sets
+// the maximum number of events an execution may emit.
+// A limit of zero means the number of events is unlimited.
+//
+func WithMaxEvents(limit uint64) Option {
+	return func(interpreter *Interpreter) error {
+		interpreter.SetMaxEvents(limit)
+		return nil
+	}
+}
+
+// WithStorageUsedCache returns an interpreter option which sets
+// the cache of the amounts of storage used by accounts.
+// If the cache is nil, the amounts are not cached.
+//
+func WithStorageUsedCache(cache *StorageUsedCache) Option {
+	return func(interpreter *Interpreter) error {
+		interpreter.storageUsedCache = cache
+		return nil
+	}
+}
+
+// withEventCount returns an interpreter option which sets
+// the counter of emitted events, which is shared with sub-interpreters.
+//
+func withEventCount(eventCount *uint64) Option {
+	return func(interpreter *Interpreter) error {
+		interpreter.eventCount = eventCount
+		return nil
+	}
+}
+
+// withLoopIterations returns an interpreter option which sets
+// the counter of loop iterations, which is shared with sub-interpreters.
+//
+func withLoopIterations(loopIterations *uint64) Option {
+	return func(interpreter *Interpreter) error {
+		interpreter.loopIterations = loopIterations
+		return nil
+	}
+}
+
 // withTypeCodes returns an interpreter option which sets the type codes.
 //
 func withTypeCodes(typeCodes TypeCodes) Option {
@@ -588,6 +742,9 @@ func NewInterpreter(program *Program, location common.Location, options ...Optio
 			InterfaceCodes:       map[sema.TypeID]WrapperCode{},
 			TypeRequirementCodes: map[sema.TypeID]WrapperCode{},
 		}),
+		WithMaxValueDepth(DefaultMaxValueDepth),
+		withLoopIterations(new(uint64)),
+		withEventCount(new(uint64)),
 	}
 
 	for _, option := range defaultOptions {
@@ -613,6 +770,19 @@ func (interpreter *Interpreter) SetOnEventEmittedHandler(function OnEventEmitted
 	interpreter.onEventEmitted = function
 }
 
+// SetOnResourceDestroyedHandler sets the function that is triggered when a resource is destroyed.
+//
+func (interpreter *Interpreter) SetOnResourceDestroyedHandler(function OnResourceDestroyedFunc) {
+	interpreter.onResourceDestroyed = function
+}
+
+// SetOnCapabilityStoredHandler sets the function that is triggered
+// when a capability is transferred into the storage of an account.
+//
+func (interpreter *Interpreter) SetOnCapabilityStoredHandler(function OnCapabilityStoredFunc) {
+	interpreter.onCapabilityStored = function
+}
+
 // SetOnStatementHandler sets the function that is triggered when a statement is about to be executed.
 //
 func (interpreter *Interpreter) SetOnStatementHandler(function OnStatementFunc) {
@@ -745,6 +915,50 @@ func (interpreter *Interpreter) SetTracingEnabled(enabled bool) {
 	interpreter.tracingEnabled = enabled
 }
 
+// SetMaxBorrowChainLength sets the maximum number of borrows a single statement may perform.
+//
+func (interpreter *Interpreter) SetMaxBorrowChainLength(limit int) {
+	interpreter.maxBorrowChainLength = limit
+}
+
+// SetReadOnly sets if the interpreter is read-only, i.e. if all storage mutations are rejected.
+//
+func (interpreter *Interpreter) SetReadOnly(readOnly bool) {
+	interpreter.readOnly = readOnly
+}
+
+// SetMaxValueDepth sets the maximum nesting depth of stored values.
+//
+func (interpreter *Interpreter) SetMaxValueDepth(limit int) {
+	interpreter.maxValueDepth = limit
+}
+
+// SetMaxLoopIterations sets the maximum number of loop iterations an execution may perform.
+//
+func (interpreter *Interpreter) SetMaxLoopIterations(limit uint64) {
+	interpreter.maxLoopIterations = limit
+}
+
+// SetMaxEvents sets the maximum number of events an execution may emit.
+//
+func (interpreter *Interpreter) SetMaxEvents(limit uint64) {
+	interpreter.maxEvents = limit
+}
+
+// EventCount returns the number of events emitted so far in the execution,
+// across all interpreters of the execution.
+//
+func (interpreter *Interpreter) EventCount() uint64 {
+	return *interpreter.eventCount
+}
+
+// StorageUsedCache returns the cache of the amounts of storage used by accounts,
+// or nil if the amounts are not cached.
+//
+func (interpreter *Interpreter) StorageUsedCache() *StorageUsedCache {
+	return interpreter.storageUsedCache
+}
+
 // setTypeCodes sets the type codes.
 //
 func (interpreter *Interpreter) setTypeCodes(typeCodes TypeCodes) {
@@ -965,9 +1179,18 @@ func (interpreter *Interpreter) RecoverErrors(onError func(error)) {
 	if r := recover(); r != nil {
 		var err error
 		switch r := r.(type) {
-		case goRuntime.Error, ExternalError:
-			// Don't recover Go's or external panics
+		case goRuntime.Error:
+			// Don't recover Go's panics
 			panic(r)
+		case ExternalError:
+			// Don't recover external panics,
+			// unless they are errors which abort the execution
+			recoveredErr, ok := r.Recovered.(error)
+			var abortErr ExternalAbortError
+			if !ok || !goErrors.As(recoveredErr, &abortErr) {
+				panic(r)
+			}
+			err = recoveredErr
 		case error:
 			err = r
 		default:
@@ -1298,6 +1521,7 @@ func (interpreter *Interpreter) visitAssignment(
 			getLocationRange := locationRangeGetter(interpreter.Location, position)
 			panic(ForceAssignmentToNonNilResourceError{
 				LocationRange: getLocationRange(),
+				History:       interpreter.resourceMoves(target),
 			})
 		}
 	}
@@ -1580,6 +1804,13 @@ func (interpreter *Interpreter) declareNonEnumCompositeValue(
 				value.Functions = functions
 				value.Destructor = destructorFunction
 
+				interpreter.recordResourceMove(
+					value,
+					ResourceMoveKindCreate,
+					"",
+					invocation.GetLocationRange,
+				)
+
 				invocation.Self = value
 
 				if declaration.CompositeKind == common.CompositeKindContract {
@@ -2455,6 +2686,8 @@ func (interpreter *Interpreter) NewSubInterpreter(
 		WithStorage(interpreter.Storage),
 		WithPredeclaredValues(interpreter.PredeclaredValues),
 		WithOnEventEmittedHandler(interpreter.onEventEmitted),
+		WithOnResourceDestroyedHandler(interpreter.onResourceDestroyed),
+		WithOnCapabilityStoredHandler(interpreter.onCapabilityStored),
 		WithOnStatementHandler(interpreter.onStatement),
 		WithOnLoopIterationHandler(interpreter.onLoopIteration),
 		WithOnFunctionInvocationHandler(interpreter.onFunctionInvocation),
@@ -2466,6 +2699,15 @@ func (interpreter *Interpreter) NewSubInterpreter(
 		WithAllInterpreters(interpreter.allInterpreters),
 		WithAtreeValueValidationEnabled(interpreter.atreeValueValidationEnabled),
 		WithAtreeStorageValidationEnabled(interpreter.atreeStorageValidationEnabled),
+		WithMaxBorrowChainLength(interpreter.maxBorrowChainLength),
+		WithReadOnly(interpreter.readOnly),
+		WithMaxValueDepth(interpreter.maxValueDepth),
+		WithMaxLoopIterations(interpreter.maxLoopIterations),
+		withLoopIterations(interpreter.loopIterations),
+		WithMaxEvents(interpreter.maxEvents),
+		withEventCount(interpreter.eventCount),
+		WithResourceHistory(interpreter.resourceHistory),
+		WithStorageUsedCache(interpreter.storageUsedCache),
 		withTypeCodes(interpreter.typeCodes),
 		WithPublicAccountHandlerFunc(interpreter.publicAccountHandler),
 		WithPublicKeyValidationHandler(interpreter.PublicKeyValidationHandler),
@@ -2489,7 +2731,16 @@ func (interpreter *Interpreter) storedValueExists(storageAddress common.Address,
 }
 
 func (interpreter *Interpreter) ReadStored(storageAddress common.Address, key string) OptionalValue {
-	return interpreter.Storage.ReadValue(interpreter, storageAddress, key)
+	value := interpreter.Storage.ReadValue(interpreter, storageAddress, key)
+
+	// The stored value is the root of the stored value,
+	// it is only wrapped in an optional to indicate that it exists
+
+	if someValue, ok := value.(*SomeValue); ok {
+		setStoredValueDepth(someValue.Value, 1)
+	}
+
+	return value
 }
 
 func (interpreter *Interpreter) writeStored(storageAddress common.Address, key string, value OptionalValue) {
@@ -3133,6 +3384,17 @@ func (interpreter *Interpreter) IsSubType(subType DynamicType, superType sema.Ty
 		return true
 	}
 
+	// The type arguments of generic functions are not available at run-time.
+	// The checker ensured the type arguments are consistent, so only check the type bound
+
+	if genericSuperType, ok := superType.(*sema.GenericType); ok {
+		typeBound := genericSuperType.TypeParameter.TypeBound
+		if typeBound == nil {
+			return true
+		}
+		return interpreter.IsSubType(subType, typeBound)
+	}
+
 	switch typedSubType := subType.(type) {
 	case MetaTypeDynamicType:
 		switch superType {
@@ -3360,19 +3622,209 @@ func (interpreter *Interpreter) IsSubType(subType DynamicType, superType sema.Ty
 	return false
 }
 
-// PathToStorageKey returns the storage identifier with the proper prefix
-// for the given path.
+// StorageKeySeparator separates the domain and the identifier of a path in a storage key,
+// and the key prefix of a namespaced storage from the keys.
 //
 // \x1F = Information Separator One
 //
+const StorageKeySeparator = "\x1F"
+
+// PathToStorageKey returns the storage identifier with the proper prefix
+// for the given path.
+//
 func PathToStorageKey(path PathValue) string {
-	return fmt.Sprintf("%s\x1F%s", path.Domain.Identifier(), path.Identifier)
+	return StorageKeyForPath(path.Domain, path.Identifier)
+}
+
+// StorageKeyForPath returns the storage key for the path
+// with the given domain and identifier.
+//
+func StorageKeyForPath(domain common.PathDomain, identifier string) string {
+	return domain.Identifier() + StorageKeySeparator + identifier
+}
+
+// PathForStorageKey returns the domain and the identifier of the path
+// for the given storage key, i.e. it is the inverse of StorageKeyForPath.
+//
+// It returns false if the key is not the storage key of a path,
+// e.g. if it is the key of a contract or of a slab.
+//
+func PathForStorageKey(key string) (domain common.PathDomain, identifier string, ok bool) {
+	parts := strings.SplitN(key, StorageKeySeparator, 2)
+	if len(parts) != 2 {
+		return common.PathDomainUnknown, "", false
+	}
+
+	domain = common.PathDomainFromIdentifier(parts[0])
+	if domain == common.PathDomainUnknown {
+		return common.PathDomainUnknown, "", false
+	}
+
+	return domain, parts[1], true
+}
+
+// checkStorable is called when a value is transferred to the given address,
+// i.e. when it is converted to a storable, e.g. as an element of a container which is transferred.
+//
+// If the value is transferred into an account,
+// it panics with a NonStorableValueError if the value is not storable.
+// Values nested in the value are checked when they are transferred,
+// so the value is not traversed.
+//
+func (interpreter *Interpreter) checkStorable(
+	value Value,
+	address atree.Address,
+	getLocationRange func() LocationRange,
+) {
+	if value == nil || address == (atree.Address{}) {
+		return
+	}
+
+	storable, err := value.Storable(interpreter.Storage, address, math.MaxUint64)
+	if err != nil {
+		panic(ExternalError{err})
+	}
+
+	if _, ok := storable.(NonStorable); ok {
+		panic(NonStorableValueError{
+			Value:         value,
+			LocationRange: getLocationRange(),
+		})
+	}
+}
+
+// enterStoredValue is called when a container value is transferred to the given address,
+// i.e. when it and the values it contains are converted to storables,
+// and returns a function which must be called when the transfer is finished.
+//
+// If the value is transferred into an account,
+// it panics with a ValueDepthLimitExceededError
+// if the value is nested deeper than the maximum value depth.
+// The transfer stops descending at the limit,
+// so deeply nested values do not exhaust the stack when they are stored.
+//
+func (interpreter *Interpreter) enterStoredValue(address atree.Address, getLocationRange func() LocationRange) func() {
+	if interpreter.maxValueDepth <= 0 || address == (atree.Address{}) {
+		return noopExitStoredValue
+	}
+
+	if interpreter.storedValueDepth >= interpreter.maxValueDepth {
+		panic(ValueDepthLimitExceededError{
+			Limit:         interpreter.maxValueDepth,
+			LocationRange: getLocationRange(),
+		})
+	}
+
+	interpreter.storedValueDepth++
+
+	return interpreter.exitStoredValue
+}
+
+func (interpreter *Interpreter) exitStoredValue() {
+	interpreter.storedValueDepth--
+}
+
+func noopExitStoredValue() {}
+
+// transferredValueDepth returns the depth of the container value which is transferred to the given address,
+// i.e. the depth of the container in the stored value it is part of,
+// or zero if the value is not transferred into an account.
+//
+func (interpreter *Interpreter) transferredValueDepth(address atree.Address) int {
+	if interpreter.maxValueDepth <= 0 || address == (atree.Address{}) {
+		return 0
+	}
+
+	return interpreter.storedValueDepth
+}
+
+// enterStoredContainer is called when a value is transferred into a container at the given depth,
+// e.g. when it is inserted into an array or dictionary, or assigned to a field of a composite,
+// and returns a function which must be called when the transfer is finished.
+//
+// If the container is stored, the depth of the transferred value is counted
+// from the root of the stored value the container is part of, instead of from the transferred value,
+// so the value depth limit also applies to stored values which are mutated in-place, e.g. through references.
+//
+func (interpreter *Interpreter) enterStoredContainer(depth int) func() {
+	if depth == 0 {
+		return noopExitStoredValue
+	}
+
+	previousDepth := interpreter.storedValueDepth
+	interpreter.storedValueDepth = depth
+
+	return func() {
+		interpreter.storedValueDepth = previousDepth
+	}
+}
+
+// setStoredValueDepth sets the depth of the given value, which is read from the storage,
+// i.e. the depth of the value in the stored value it is part of.
+//
+// The depth of a container value is known if it was transferred into an account,
+// or if it was read from the storage, i.e. it is the root of a stored value,
+// or it was read from a container with a known depth.
+//
+func setStoredValueDepth(value Value, depth int) {
+	switch value := value.(type) {
+	case *ArrayValue:
+		value.storedDepth = depth
+	case *DictionaryValue:
+		value.storedDepth = depth
+	case *CompositeValue:
+		value.storedDepth = depth
+	case *SomeValue:
+		setStoredValueDepth(value.Value, depth+1)
+	}
+}
+
+// setNestedStoredValueDepth sets the depth of the given value,
+// which is read from a container at the given depth.
+//
+func setNestedStoredValueDepth(value Value, containerDepth int) {
+	if containerDepth == 0 {
+		return
+	}
+
+	setStoredValueDepth(value, containerDepth+1)
+}
+
+// transferToStorage transfers the given value, which is saved by a host function,
+// to the account storage of the given address.
+//
+// Non-storable values, e.g. references or functions, are rejected during the transfer,
+// so the error can be reported at the location of the call,
+// instead of only when the storage is committed.
+//
+func (interpreter *Interpreter) transferToStorage(
+	value Value,
+	address common.Address,
+	getLocationRange func() LocationRange,
+) Value {
+	atreeAddress := atree.Address(address)
+
+	interpreter.checkStorable(value, atreeAddress, getLocationRange)
+
+	return value.Transfer(
+		interpreter,
+		getLocationRange,
+		atreeAddress,
+		true,
+		nil,
+	)
 }
 
 func (interpreter *Interpreter) authAccountSaveFunction(addressValue AddressValue) *HostFunctionValue {
 	return NewHostFunctionValue(
 		func(invocation Invocation) Value {
 
+			interpreter.checkStorageMutation(
+				atree.Address(addressValue),
+				sema.AuthAccountSaveField,
+				invocation.GetLocationRange,
+			)
+
 			value := invocation.Arguments[0]
 			path := invocation.Arguments[1].(PathValue)
 
@@ -3389,17 +3841,12 @@ func (interpreter *Interpreter) authAccountSaveFunction(addressValue AddressValu
 						Address:       addressValue,
 						Path:          path,
 						LocationRange: getLocationRange(),
+						History:       interpreter.resourceMoves(value),
 					},
 				)
 			}
 
-			value = value.Transfer(
-				interpreter,
-				getLocationRange,
-				atree.Address(address),
-				true,
-				nil,
-			)
+			value = interpreter.transferToStorage(value, address, getLocationRange)
 
 			// Write new value
 
@@ -3409,52 +3856,140 @@ func (interpreter *Interpreter) authAccountSaveFunction(addressValue AddressValu
 				NewSomeValueNonCopying(value),
 			)
 
+			interpreter.recordResourceMove(
+				value,
+				ResourceMoveKindSave,
+				path.String(),
+				getLocationRange,
+			)
+
 			return VoidValue{}
 		},
 		sema.AuthAccountTypeSaveFunctionType,
 	)
 }
 
-func (interpreter *Interpreter) authAccountTypeFunction(addressValue AddressValue) *HostFunctionValue {
+func (interpreter *Interpreter) authAccountEnsureSavedFunction(addressValue AddressValue) *HostFunctionValue {
 	return NewHostFunctionValue(
 		func(invocation Invocation) Value {
 
-			address := addressValue.ToAddress()
+			interpreter.checkStorageMutation(
+				atree.Address(addressValue),
+				sema.AuthAccountEnsureSavedField,
+				invocation.GetLocationRange,
+			)
 
-			path := invocation.Arguments[0].(PathValue)
+			value := invocation.Arguments[0]
+			path := invocation.Arguments[1].(PathValue)
+
+			address := addressValue.ToAddress()
 			key := PathToStorageKey(path)
 
-			value := interpreter.ReadStored(address, key)
+			getLocationRange := invocation.GetLocationRange
 
-			switch value := value.(type) {
-			case NilValue:
-				return value
+			// If there is already a value stored, it must be equal to the new value.
+			// Resources cannot be compared, so for resources the path must be empty.
 
-			case *SomeValue:
-				return NewSomeValueNonCopying(
-					TypeValue{
-						Type: value.Value.StaticType(),
+			if storedValue, ok := interpreter.ReadStored(address, key).(*SomeValue); ok {
+
+				if !value.IsResourceKinded(interpreter) {
+					equatableValue, ok := value.(EquatableValue)
+					if ok && equatableValue.Equal(interpreter, getLocationRange, storedValue.Value) {
+						return BoolValue(false)
+					}
+				}
+
+				panic(
+					OverwriteError{
+						Address:       addressValue,
+						Path:          path,
+						LocationRange: getLocationRange(),
+						History:       interpreter.resourceMoves(value),
 					},
 				)
-			default:
-				panic(errors.NewUnreachableError())
 			}
-		},
-
-		sema.AuthAccountTypeTypeFunctionType,
-	)
-}
 
-func (interpreter *Interpreter) authAccountLoadFunction(addressValue AddressValue) *HostFunctionValue {
-	return interpreter.authAccountReadFunction(addressValue, true)
-}
+			value = interpreter.transferToStorage(value, address, getLocationRange)
 
-func (interpreter *Interpreter) authAccountCopyFunction(addressValue AddressValue) *HostFunctionValue {
-	return interpreter.authAccountReadFunction(addressValue, false)
-}
+			// Write new value
 
-func (interpreter *Interpreter) authAccountReadFunction(addressValue AddressValue, clear bool) *HostFunctionValue {
+			interpreter.writeStored(
+				address,
+				key,
+				NewSomeValueNonCopying(value),
+			)
+
+			interpreter.recordResourceMove(
+				value,
+				ResourceMoveKindSave,
+				path.String(),
+				getLocationRange,
+			)
+
+			return BoolValue(true)
+		},
+		sema.AuthAccountTypeEnsureSavedFunctionType,
+	)
+}
+
+func (interpreter *Interpreter) authAccountSaveOrReplaceFunction(addressValue AddressValue) *HostFunctionValue {
+	return NewHostFunctionValue(
+		func(invocation Invocation) Value {
+
+			interpreter.checkStorageMutation(
+				atree.Address(addressValue),
+				sema.AuthAccountSaveOrReplaceField,
+				invocation.GetLocationRange,
+			)
+
+			value := invocation.Arguments[0]
+			path := invocation.Arguments[1].(PathValue)
+
+			address := addressValue.ToAddress()
+			key := PathToStorageKey(path)
+
+			getLocationRange := invocation.GetLocationRange
+
+			// Only replace a non-resource value.
+			// A stored resource must be loaded first, to prevent its loss
+
+			if storedValue, ok := interpreter.ReadStored(address, key).(*SomeValue); ok &&
+				storedValue.Value.IsResourceKinded(interpreter) {
+
+				panic(
+					ResourceOverwriteError{
+						Address:       addressValue,
+						Path:          path,
+						LocationRange: getLocationRange(),
+						History:       interpreter.resourceMoves(storedValue.Value),
+					},
+				)
+			}
+
+			value = interpreter.transferToStorage(value, address, getLocationRange)
+
+			// Write new value, replacing the existing value, if any
+
+			interpreter.writeStored(
+				address,
+				key,
+				NewSomeValueNonCopying(value),
+			)
+
+			interpreter.recordResourceMove(
+				value,
+				ResourceMoveKindSave,
+				path.String(),
+				getLocationRange,
+			)
+
+			return VoidValue{}
+		},
+		sema.AuthAccountTypeSaveOrReplaceFunctionType,
+	)
+}
 
+func (interpreter *Interpreter) authAccountTypeFunction(addressValue AddressValue) *HostFunctionValue {
 	return NewHostFunctionValue(
 		func(invocation Invocation) Value {
 
@@ -3465,6 +4000,53 @@ func (interpreter *Interpreter) authAccountReadFunction(addressValue AddressValu
 
 			value := interpreter.ReadStored(address, key)
 
+			switch value := value.(type) {
+			case NilValue:
+				return value
+
+			case *SomeValue:
+				return NewSomeValueNonCopying(
+					TypeValue{
+						Type: value.Value.StaticType(),
+					},
+				)
+			default:
+				panic(errors.NewUnreachableError())
+			}
+		},
+
+		sema.AuthAccountTypeTypeFunctionType,
+	)
+}
+
+func (interpreter *Interpreter) authAccountLoadFunction(addressValue AddressValue) *HostFunctionValue {
+	return interpreter.authAccountReadFunction(addressValue, true)
+}
+
+func (interpreter *Interpreter) authAccountCopyFunction(addressValue AddressValue) *HostFunctionValue {
+	return interpreter.authAccountReadFunction(addressValue, false)
+}
+
+func (interpreter *Interpreter) authAccountReadFunction(addressValue AddressValue, clear bool) *HostFunctionValue {
+
+	return NewHostFunctionValue(
+		func(invocation Invocation) Value {
+
+			if clear {
+				interpreter.checkStorageMutation(
+					atree.Address(addressValue),
+					sema.AuthAccountLoadField,
+					invocation.GetLocationRange,
+				)
+			}
+
+			address := addressValue.ToAddress()
+
+			path := invocation.Arguments[0].(PathValue)
+			key := PathToStorageKey(path)
+
+			value := interpreter.ReadStored(address, key)
+
 			switch value := value.(type) {
 			case NilValue:
 				return value
@@ -3504,6 +4086,13 @@ func (interpreter *Interpreter) authAccountReadFunction(addressValue AddressValu
 				// but only if the type check succeeded.
 				if clear {
 					interpreter.writeStored(address, key, NilValue{})
+
+					interpreter.recordResourceMove(
+						transferredValue,
+						ResourceMoveKindLoad,
+						path.String(),
+						getLocationRange,
+					)
 				}
 
 				return transferredValue
@@ -3536,6 +4125,8 @@ func (interpreter *Interpreter) authAccountBorrowFunction(addressValue AddressVa
 
 			referenceType := ty.(*sema.ReferenceType)
 
+			invocation.Interpreter.recordBorrow(invocation.GetLocationRange)
+
 			reference := &StorageReferenceValue{
 				Authorized:           referenceType.Authorized,
 				TargetStorageAddress: address,
@@ -3561,6 +4152,12 @@ func (interpreter *Interpreter) authAccountLinkFunction(addressValue AddressValu
 	return NewHostFunctionValue(
 		func(invocation Invocation) Value {
 
+			interpreter.checkStorageMutation(
+				atree.Address(addressValue),
+				sema.AuthAccountLinkField,
+				invocation.GetLocationRange,
+			)
+
 			address := addressValue.ToAddress()
 
 			typeParameterPair := invocation.TypeParameterTypes.Oldest()
@@ -3646,6 +4243,12 @@ func (interpreter *Interpreter) authAccountUnlinkFunction(addressValue AddressVa
 	return NewHostFunctionValue(
 		func(invocation Invocation) Value {
 
+			interpreter.checkStorageMutation(
+				atree.Address(addressValue),
+				sema.AuthAccountUnlinkField,
+				invocation.GetLocationRange,
+			)
+
 			address := addressValue.ToAddress()
 
 			capabilityPath := invocation.Arguments[0].(PathValue)
@@ -3665,6 +4268,24 @@ func (interpreter *Interpreter) authAccountUnlinkFunction(addressValue AddressVa
 	)
 }
 
+// recordBorrow records that the current statement performs a borrow,
+// and reports an error if the statement exceeds the maximum number of borrows.
+//
+func (interpreter *Interpreter) recordBorrow(getLocationRange func() LocationRange) {
+	if interpreter.maxBorrowChainLength <= 0 {
+		return
+	}
+
+	interpreter.borrowCount++
+
+	if interpreter.borrowCount > interpreter.maxBorrowChainLength {
+		panic(BorrowChainLengthExceededError{
+			Limit:         interpreter.maxBorrowChainLength,
+			LocationRange: getLocationRange(),
+		})
+	}
+}
+
 func (interpreter *Interpreter) capabilityBorrowFunction(
 	addressValue AddressValue,
 	pathValue PathValue,
@@ -3674,54 +4295,124 @@ func (interpreter *Interpreter) capabilityBorrowFunction(
 	return NewHostFunctionValue(
 		func(invocation Invocation) Value {
 
-			if borrowType == nil {
-				typeParameterPair := invocation.TypeParameterTypes.Oldest()
-				if typeParameterPair != nil {
-					ty := typeParameterPair.Value
-					borrowType = ty.(*sema.ReferenceType)
-				}
+			borrowType := capabilityCheckBorrowType(borrowType, invocation)
+
+			interpreter.recordBorrow(invocation.GetLocationRange)
+
+			return interpreter.borrowCapability(
+				addressValue,
+				pathValue,
+				borrowType,
+				invocation.GetLocationRange,
+			)
+		},
+		sema.CapabilityTypeBorrowFunctionType(borrowType),
+	)
+}
+
+func (interpreter *Interpreter) capabilityBorrowOrFunction(
+	addressValue AddressValue,
+	pathValue PathValue,
+	borrowType *sema.ReferenceType,
+) *HostFunctionValue {
+
+	return NewHostFunctionValue(
+		func(invocation Invocation) Value {
+
+			borrowType := capabilityCheckBorrowType(borrowType, invocation)
+
+			// Borrowing the capability or the fallback capability is one borrow
+
+			interpreter.recordBorrow(invocation.GetLocationRange)
+
+			reference := interpreter.borrowCapability(
+				addressValue,
+				pathValue,
+				borrowType,
+				invocation.GetLocationRange,
+			)
+			if _, ok := reference.(NilValue); !ok {
+				return reference
 			}
 
-			if borrowType == nil {
+			// The capability cannot be borrowed, try the fallback capability
+
+			fallback, ok := invocation.Arguments[0].(*CapabilityValue)
+			if !ok {
 				panic(errors.NewUnreachableError())
 			}
 
-			address := addressValue.ToAddress()
+			// If the fallback capability is typed, it must be borrowed using its own borrow type,
+			// like its borrow function does, so it cannot be borrowed as a type it was not issued for.
+			// The resulting reference must be a subtype of the wanted reference type
 
-			targetStorageKey, authorized, err :=
-				interpreter.GetCapabilityFinalTargetStorageKey(
-					address,
-					pathValue,
-					borrowType,
+			fallbackBorrowType := borrowType
+			if fallback.BorrowType != nil {
+				fallbackBorrowType = interpreter.mustConvertCapabilityBorrowType(
+					fallback.BorrowType,
 					invocation.GetLocationRange,
 				)
-			if err != nil {
-				panic(err)
-			}
 
-			if targetStorageKey == "" {
-				return NilValue{}
+				if !sema.IsSubType(fallbackBorrowType, borrowType) {
+					return NilValue{}
+				}
 			}
 
-			reference := &StorageReferenceValue{
-				Authorized:           authorized,
-				TargetStorageAddress: address,
-				TargetKey:            targetStorageKey,
-				BorrowedType:         borrowType.Type,
-			}
+			return interpreter.borrowCapability(
+				fallback.Address,
+				fallback.Path,
+				fallbackBorrowType,
+				invocation.GetLocationRange,
+			)
+		},
+		sema.CapabilityTypeBorrowOrFunctionType(borrowType),
+	)
+}
 
-			// Attempt to dereference,
-			// which reads the stored value
-			// and performs a dynamic type check
+// borrowCapability returns a reference to the object targeted by the capability
+// with the given address and path, wrapped in an optional,
+// or nil if the capability cannot be borrowed using the given type.
+//
+func (interpreter *Interpreter) borrowCapability(
+	addressValue AddressValue,
+	pathValue PathValue,
+	borrowType *sema.ReferenceType,
+	getLocationRange func() LocationRange,
+) OptionalValue {
 
-			if reference.ReferencedValue(interpreter) == nil {
-				return NilValue{}
-			}
+	address := addressValue.ToAddress()
 
-			return NewSomeValueNonCopying(reference)
-		},
-		sema.CapabilityTypeBorrowFunctionType(borrowType),
-	)
+	targetStorageKey, authorized, err :=
+		interpreter.GetCapabilityFinalTargetStorageKey(
+			address,
+			pathValue,
+			borrowType,
+			getLocationRange,
+		)
+	if err != nil {
+		panic(err)
+	}
+
+	if targetStorageKey == "" {
+		return NilValue{}
+	}
+
+	reference := &StorageReferenceValue{
+		Authorized:           authorized,
+		TargetStorageAddress: address,
+		TargetKey:            targetStorageKey,
+		BorrowedType:         borrowType.Type,
+	}
+
+	// Attempt to dereference,
+	// which reads the stored value
+	// and performs a dynamic type check
+
+	if reference.ReferencedValue(interpreter) == nil {
+		return NilValue{}
+	}
+
+	return NewSomeValueNonCopying(reference)
 }
 
 func (interpreter *Interpreter) capabilityCheckFunction(
@@ -3733,55 +4424,162 @@ func (interpreter *Interpreter) capabilityCheckFunction(
 	return NewHostFunctionValue(
 		func(invocation Invocation) Value {
 
-			if borrowType == nil {
+			borrowType := capabilityCheckBorrowType(borrowType, invocation)
 
-				typeParameterPair := invocation.TypeParameterTypes.Oldest()
-				if typeParameterPair != nil {
-					ty := typeParameterPair.Value
-					borrowType = ty.(*sema.ReferenceType)
-				}
-			}
+			return BoolValue(
+				interpreter.IsCapabilityBorrowable(
+					addressValue,
+					pathValue,
+					borrowType,
+					invocation.GetLocationRange,
+				),
+			)
+		},
+		sema.CapabilityTypeCheckFunctionType(borrowType),
+	)
+}
 
-			if borrowType == nil {
-				panic(errors.NewUnreachableError())
-			}
+func (interpreter *Interpreter) capabilityCheckStatusFunction(
+	addressValue AddressValue,
+	pathValue PathValue,
+	borrowType *sema.ReferenceType,
+) *HostFunctionValue {
+
+	return NewHostFunctionValue(
+		func(invocation Invocation) Value {
+
+			borrowType := capabilityCheckBorrowType(borrowType, invocation)
 
 			address := addressValue.ToAddress()
 
-			targetStorageKey, authorized, err :=
-				interpreter.GetCapabilityFinalTargetStorageKey(
-					address,
+			isLinked := interpreter.storedValueExists(address, PathToStorageKey(pathValue))
+
+			isBorrowable := isLinked &&
+				interpreter.IsCapabilityBorrowable(
+					addressValue,
 					pathValue,
 					borrowType,
 					invocation.GetLocationRange,
 				)
-			if err != nil {
-				panic(err)
-			}
 
-			if targetStorageKey == "" {
-				return BoolValue(false)
-			}
+			return NewCapabilityCheckResultValue(
+				invocation.Interpreter,
+				BoolValue(isLinked),
+				BoolValue(isBorrowable),
+			)
+		},
+		sema.CapabilityTypeCheckStatusFunctionType(borrowType),
+	)
+}
 
-			reference := &StorageReferenceValue{
-				Authorized:           authorized,
-				TargetStorageAddress: address,
-				TargetKey:            targetStorageKey,
-				BorrowedType:         borrowType.Type,
-			}
+// capabilityCheckBorrowType returns the type a capability is checked with:
+// Either the borrow type of the capability, if it is typed,
+// or the type argument of the invocation.
+//
+func capabilityCheckBorrowType(borrowType *sema.ReferenceType, invocation Invocation) *sema.ReferenceType {
+	if borrowType == nil {
 
-			// Attempt to dereference,
-			// which reads the stored value
-			// and performs a dynamic type check
+		typeParameterPair := invocation.TypeParameterTypes.Oldest()
+		if typeParameterPair != nil {
+			ty := typeParameterPair.Value
+			borrowType = ty.(*sema.ReferenceType)
+		}
+	}
 
-			if reference.ReferencedValue(interpreter) == nil {
-				return BoolValue(false)
-			}
+	if borrowType == nil {
+		panic(errors.NewUnreachableError())
+	}
 
-			return BoolValue(true)
+	return borrowType
+}
+
+// IsCapabilityBorrowable returns true if the capability with the given address and path
+// currently targets an object that satisfies the given type, i.e. could be borrowed using the given type.
+//
+func (interpreter *Interpreter) IsCapabilityBorrowable(
+	addressValue AddressValue,
+	pathValue PathValue,
+	borrowType *sema.ReferenceType,
+	getLocationRange func() LocationRange,
+) bool {
+
+	address := addressValue.ToAddress()
+
+	targetStorageKey, authorized, err :=
+		interpreter.GetCapabilityFinalTargetStorageKey(
+			address,
+			pathValue,
+			borrowType,
+			getLocationRange,
+		)
+	if err != nil {
+		panic(err)
+	}
+
+	if targetStorageKey == "" {
+		return false
+	}
+
+	reference := &StorageReferenceValue{
+		Authorized:           authorized,
+		TargetStorageAddress: address,
+		TargetKey:            targetStorageKey,
+		BorrowedType:         borrowType.Type,
+	}
+
+	// Attempt to dereference,
+	// which reads the stored value
+	// and performs a dynamic type check
+
+	return reference.ReferencedValue(interpreter) != nil
+}
+
+// mustConvertCapabilityBorrowType converts the given static borrow type of a capability
+// to a sema type.
+//
+// If the borrow type refers to a type which cannot be found,
+// for example because the declaring contract was removed,
+// a CapabilityBorrowTypeNotFoundError is reported.
+//
+func (interpreter *Interpreter) mustConvertCapabilityBorrowType(
+	staticType StaticType,
+	getLocationRange func() LocationRange,
+) *sema.ReferenceType {
+
+	wrapTypeLoadingError := func(err error, location common.Location, qualifiedIdentifier string) error {
+		if _, ok := err.(TypeLoadingError); !ok {
+			return err
+		}
+
+		return CapabilityBorrowTypeNotFoundError{
+			Location:            location,
+			QualifiedIdentifier: qualifiedIdentifier,
+			LocationRange:       getLocationRange(),
+		}
+	}
+
+	semaType, err := ConvertStaticToSemaType(
+		staticType,
+		func(location common.Location, qualifiedIdentifier string) (*sema.InterfaceType, error) {
+			ty, err := interpreter.getInterfaceType(location, qualifiedIdentifier)
+			if err != nil {
+				return nil, wrapTypeLoadingError(err, location, qualifiedIdentifier)
+			}
+			return ty, nil
+		},
+		func(location common.Location, qualifiedIdentifier string, typeID common.TypeID) (*sema.CompositeType, error) {
+			ty, err := interpreter.GetCompositeType(location, qualifiedIdentifier, typeID)
+			if err != nil && location != nil {
+				return nil, wrapTypeLoadingError(err, location, qualifiedIdentifier)
+			}
+			return ty, err
 		},
-		sema.CapabilityTypeCheckFunctionType(borrowType),
 	)
+	if err != nil {
+		panic(err)
+	}
+
+	return semaType.(*sema.ReferenceType)
 }
 
 func (interpreter *Interpreter) GetCapabilityFinalTargetStorageKey(
@@ -3793,19 +4591,67 @@ func (interpreter *Interpreter) GetCapabilityFinalTargetStorageKey(
 	finalStorageKey string,
 	authorized bool,
 	err error,
+) {
+	finalStorageKey, authorized, _, err = interpreter.getCapabilityFinalTarget(
+		address,
+		path,
+		wantedBorrowType,
+		getLocationRange,
+	)
+	return
+}
+
+// GetCapabilityLinkChain returns the chain of paths through which the given path is linked,
+// starting with the given path, and ending with the path of the final target.
+//
+// If the link chain cannot be resolved for the wanted borrow type,
+// e.g. because a link is missing or does not allow the borrow type, the chain is nil.
+//
+func (interpreter *Interpreter) GetCapabilityLinkChain(
+	address common.Address,
+	path PathValue,
+	wantedBorrowType *sema.ReferenceType,
+	getLocationRange func() LocationRange,
+) (
+	[]PathValue,
+	error,
+) {
+	finalStorageKey, _, paths, err := interpreter.getCapabilityFinalTarget(
+		address,
+		path,
+		wantedBorrowType,
+		getLocationRange,
+	)
+	if err != nil || finalStorageKey == "" {
+		return nil, err
+	}
+
+	return paths, nil
+}
+
+func (interpreter *Interpreter) getCapabilityFinalTarget(
+	address common.Address,
+	path PathValue,
+	wantedBorrowType *sema.ReferenceType,
+	getLocationRange func() LocationRange,
+) (
+	finalStorageKey string,
+	authorized bool,
+	paths []PathValue,
+	err error,
 ) {
 	key := PathToStorageKey(path)
 
 	wantedReferenceType := wantedBorrowType
 
 	seenKeys := map[string]struct{}{}
-	paths := []PathValue{path}
+	paths = []PathValue{path}
 
 	for {
 		// Detect cyclic links
 
 		if _, ok := seenKeys[key]; ok {
-			return "", false, CyclicLinkError{
+			return "", false, nil, CyclicLinkError{
 				Address:       address,
 				Paths:         paths,
 				LocationRange: getLocationRange(),
@@ -3818,7 +4664,7 @@ func (interpreter *Interpreter) GetCapabilityFinalTargetStorageKey(
 
 		switch value := value.(type) {
 		case NilValue:
-			return "", false, nil
+			return "", false, nil, nil
 
 		case *SomeValue:
 
@@ -3827,7 +4673,7 @@ func (interpreter *Interpreter) GetCapabilityFinalTargetStorageKey(
 				allowedType := interpreter.MustConvertStaticToSemaType(link.Type)
 
 				if !sema.IsSubType(allowedType, wantedBorrowType) {
-					return "", false, nil
+					return "", false, nil, nil
 				}
 
 				targetPath := link.TargetPath
@@ -3835,7 +4681,7 @@ func (interpreter *Interpreter) GetCapabilityFinalTargetStorageKey(
 				key = PathToStorageKey(targetPath)
 
 			} else {
-				return key, wantedReferenceType.Authorized, nil
+				return key, wantedReferenceType.Authorized, paths, nil
 			}
 
 		default:
@@ -3967,6 +4813,26 @@ func (interpreter *Interpreter) getInterfaceType(location common.Location, quali
 	return ty, nil
 }
 
+// checkLoopIterationLimit counts the loop iteration,
+// and aborts the execution if the maximum number of loop iterations is exceeded.
+//
+// Loop iterations are counted across all interpreters of an execution.
+//
+func (interpreter *Interpreter) checkLoopIterationLimit(statement ast.Statement) {
+	if interpreter.maxLoopIterations == 0 {
+		return
+	}
+
+	*interpreter.loopIterations++
+
+	if *interpreter.loopIterations > interpreter.maxLoopIterations {
+		panic(LoopIterationLimitError{
+			Limit:         interpreter.maxLoopIterations,
+			LocationRange: locationRangeGetter(interpreter.Location, statement)(),
+		})
+	}
+}
+
 func (interpreter *Interpreter) reportLoopIteration(pos ast.HasPosition) {
 	if interpreter.onLoopIteration == nil {
 		return
@@ -4075,6 +4941,26 @@ func (interpreter *Interpreter) ExpectType(
 	}
 }
 
+// checkStorageMutation panics with a ReadOnlyViolationError
+// if the interpreter is read-only and the given operation mutates the storage of the given account.
+//
+// Values which are not stored in an account, i.e. temporary values, may always be mutated.
+//
+func (interpreter *Interpreter) checkStorageMutation(
+	address atree.Address,
+	operation string,
+	getLocationRange func() LocationRange,
+) {
+	if !interpreter.readOnly || address == atree.AddressUndefined {
+		return
+	}
+
+	panic(ReadOnlyViolationError{
+		Operation:     operation,
+		LocationRange: getLocationRange(),
+	})
+}
+
 func (interpreter *Interpreter) checkContainerMutation(
 	elementType StaticType,
 	element Value,
@@ -4100,6 +4986,7 @@ func (interpreter *Interpreter) checkResourceNotDestroyed(value Value, getLocati
 
 	panic(InvalidatedResourceError{
 		LocationRange: getLocationRange(),
+		History:       interpreter.resourceMoves(value),
 	})
 }
 
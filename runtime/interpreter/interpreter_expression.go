@@ -149,6 +149,13 @@ func (interpreter *Interpreter) memberExpressionGetterSetter(memberExpression *a
 		},
 		set: func(value Value) {
 			interpreter.setMember(target, getLocationRange, identifier, value)
+
+			interpreter.recordResourceMove(
+				value,
+				ResourceMoveKindFieldAssignment,
+				identifier,
+				getLocationRange,
+			)
 		},
 	}
 }
@@ -734,6 +741,41 @@ func (interpreter *Interpreter) VisitReferenceExpression(referenceExpression *as
 
 	result := interpreter.evalExpression(referenceExpression.Expression)
 
+	// A reference to a resource which is produced by the referenced expression,
+	// e.g. returned from a function call, is rejected by the checker
+	// (see sema.EphemeralResourceReferenceError), as the resource would be lost
+
+	if sema.IsEphemeralValueExpression(referenceExpression.Expression) &&
+		result.IsResourceKinded(interpreter) {
+
+		panic(EphemeralResourceReferenceError{
+			LocationRange: locationRangeGetter(interpreter.Location, referenceExpression.Expression)(),
+		})
+	}
+
+	// If the referenced expression is optional-chained,
+	// the result is nil if the chain short-circuited,
+	// or an optional reference to the value otherwise
+
+	if _, ok := interpreter.Program.Elaboration.OptionalReferenceExpressions[referenceExpression]; ok {
+		switch result := result.(type) {
+		case NilValue:
+			return result
+
+		case *SomeValue:
+			return NewSomeValueNonCopying(
+				&EphemeralReferenceValue{
+					Authorized:   borrowType.Authorized,
+					Value:        result.Value,
+					BorrowedType: borrowType.Type,
+				},
+			)
+
+		default:
+			panic(errors.NewUnreachableError())
+		}
+	}
+
 	return &EphemeralReferenceValue{
 		Authorized:   borrowType.Authorized,
 		Value:        result,
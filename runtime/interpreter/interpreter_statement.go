@@ -36,6 +36,18 @@ func (interpreter *Interpreter) evalStatement(statement ast.Statement) interface
 
 	interpreter.statement = statement
 
+	// Borrows are limited per statement.
+	// Statements which are executed as part of the statement,
+	// e.g. in the body of an invoked function, are limited separately
+
+	if interpreter.maxBorrowChainLength > 0 {
+		borrowCount := interpreter.borrowCount
+		interpreter.borrowCount = 0
+		defer func() {
+			interpreter.borrowCount = borrowCount
+		}()
+	}
+
 	if interpreter.onStatement != nil {
 		interpreter.onStatement(interpreter, statement)
 	}
@@ -71,6 +83,8 @@ func (interpreter *Interpreter) VisitReturnStatement(statement *ast.ReturnStatem
 
 		// NOTE: copy on return
 		value = interpreter.transferAndConvert(value, valueType, returnType, getLocationRange)
+
+		interpreter.recordResourceMove(value, ResourceMoveKindReturn, "", getLocationRange)
 	}
 
 	return functionReturn{value}
@@ -244,6 +258,7 @@ func (interpreter *Interpreter) VisitWhileStatement(statement *ast.WhileStatemen
 			return nil
 		}
 
+		interpreter.checkLoopIterationLimit(statement)
 		interpreter.reportLoopIteration(statement)
 
 		result := statement.Block.Accept(interpreter)
@@ -307,6 +322,7 @@ func (interpreter *Interpreter) VisitForStatement(statement *ast.ForStatement) a
 			return nil
 		}
 
+		interpreter.checkLoopIterationLimit(statement)
 		interpreter.reportLoopIteration(statement)
 
 		// atree.Array iterator returns low-level atree.Value,
@@ -347,6 +363,17 @@ func (interpreter *Interpreter) VisitEmitStatement(statement *ast.EmitStatement)
 		})
 	}
 
+	// Events are counted across all interpreters of an execution
+
+	*interpreter.eventCount++
+
+	if interpreter.maxEvents > 0 && *interpreter.eventCount > interpreter.maxEvents {
+		panic(EventLimitExceededError{
+			Limit:         interpreter.maxEvents,
+			LocationRange: getLocationRange(),
+		})
+	}
+
 	err := interpreter.onEventEmitted(interpreter, getLocationRange, event, eventType)
 	if err != nil {
 		panic(err)
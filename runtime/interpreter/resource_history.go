@@ -0,0 +1,188 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2020 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package interpreter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/onflow/cadence/runtime/sema"
+)
+
+//go:generate go run golang.org/x/tools/cmd/stringer -type=ResourceMoveKind -trimprefix=ResourceMoveKind
+
+// ResourceMoveKind is the kind of a recorded resource move.
+//
+type ResourceMoveKind uint
+
+const (
+	ResourceMoveKindUnknown ResourceMoveKind = iota
+	// ResourceMoveKindCreate is the creation of the resource
+	ResourceMoveKindCreate
+	// ResourceMoveKindSave is the move of the resource into account storage
+	ResourceMoveKindSave
+	// ResourceMoveKindLoad is the move of the resource out of account storage
+	ResourceMoveKindLoad
+	// ResourceMoveKindReturn is the move of the resource out of a function
+	ResourceMoveKindReturn
+	// ResourceMoveKindFieldAssignment is the move of the resource into a field of a composite
+	ResourceMoveKindFieldAssignment
+	// ResourceMoveKindDestroy is the destruction of the resource
+	ResourceMoveKindDestroy
+)
+
+// ResourceMove is a recorded move of a resource.
+//
+type ResourceMove struct {
+	Kind ResourceMoveKind
+	// Detail describes the move further,
+	// e.g. the path for saves and loads, or the field name for field assignments
+	Detail string
+	LocationRange
+}
+
+func (m ResourceMove) String() string {
+	var builder strings.Builder
+
+	builder.WriteString(m.Kind.String())
+
+	if m.Detail != "" {
+		builder.WriteString(" ")
+		builder.WriteString(m.Detail)
+	}
+
+	if m.Location != nil {
+		_, _ = fmt.Fprintf(
+			&builder,
+			" at %s:%d:%d",
+			m.Location,
+			m.StartPos.Line,
+			m.StartPos.Column,
+		)
+	}
+
+	return builder.String()
+}
+
+// ResourceHistory records the moves of resources, keyed by the UUID of the resource.
+//
+// Only composite resources have a UUID, so only their moves are recorded,
+// e.g. moves of arrays or dictionaries of resources are not recorded.
+//
+type ResourceHistory struct {
+	moves map[uint64][]ResourceMove
+}
+
+func NewResourceHistory() *ResourceHistory {
+	return &ResourceHistory{
+		moves: map[uint64][]ResourceMove{},
+	}
+}
+
+// Moves returns the recorded moves of the resource with the given UUID,
+// in the order in which they occurred.
+//
+func (h *ResourceHistory) Moves(uuid uint64) []ResourceMove {
+	return h.moves[uuid]
+}
+
+func (h *ResourceHistory) record(uuid uint64, move ResourceMove) {
+	h.moves[uuid] = append(h.moves[uuid], move)
+}
+
+// resourceUUID returns the UUID of the given value,
+// if it is a composite resource which has a UUID.
+//
+func resourceUUID(interpreter *Interpreter, value Value) (uint64, bool) {
+	if someValue, ok := value.(*SomeValue); ok {
+		value = someValue.Value
+	}
+
+	compositeValue, ok := value.(*CompositeValue)
+	if !ok || !compositeValue.IsResourceKinded(interpreter) {
+		return 0, false
+	}
+
+	uuidValue, ok := compositeValue.GetField(interpreter, ReturnEmptyLocationRange, sema.ResourceUUIDFieldName).(UInt64Value)
+	if !ok {
+		return 0, false
+	}
+
+	return uint64(uuidValue), true
+}
+
+// recordResourceMove records the move of the given value,
+// if resource moves are recorded and the value is a composite resource.
+//
+func (interpreter *Interpreter) recordResourceMove(
+	value Value,
+	kind ResourceMoveKind,
+	detail string,
+	getLocationRange func() LocationRange,
+) {
+	if interpreter.resourceHistory == nil {
+		return
+	}
+
+	uuid, ok := resourceUUID(interpreter, value)
+	if !ok {
+		return
+	}
+
+	interpreter.resourceHistory.record(
+		uuid,
+		ResourceMove{
+			Kind:          kind,
+			Detail:        detail,
+			LocationRange: getLocationRange(),
+		},
+	)
+}
+
+// resourceMoves returns the recorded moves of the given value,
+// if resource moves are recorded and the value is a composite resource.
+//
+func (interpreter *Interpreter) resourceMoves(value Value) []ResourceMove {
+	if interpreter.resourceHistory == nil {
+		return nil
+	}
+
+	uuid, ok := resourceUUID(interpreter, value)
+	if !ok {
+		return nil
+	}
+
+	return interpreter.resourceHistory.Moves(uuid)
+}
+
+// formatResourceHistory returns a description of the given resource moves
+// which can be appended to an error message, or an empty string if there are no moves.
+//
+func formatResourceHistory(history []ResourceMove) string {
+	if len(history) == 0 {
+		return ""
+	}
+
+	moves := make([]string, len(history))
+	for i, move := range history {
+		moves[i] = move.String()
+	}
+
+	return fmt.Sprintf("; resource history: %s", strings.Join(moves, ", "))
+}
@@ -292,3 +292,49 @@ func maybeLargeImmutableStorable(
 
 	return atree.StorageIDStorable(storageID), nil
 }
+
+// EstimateSlabCount returns the number of slabs the given value would occupy
+// if it was stored, e.g. written to account storage.
+//
+// The value is copied into a separate, temporary in-memory storage,
+// so the storage of the given interpreter is not modified.
+//
+func EstimateSlabCount(inter *Interpreter, value Value) (count int, err error) {
+
+	// recover internal panics and return them as an error
+	defer inter.RecoverErrors(func(internalErr error) {
+		err = internalErr
+	})
+
+	storage := NewInMemoryStorage()
+
+	estimationInterpreter, err := NewInterpreter(
+		nil,
+		inter.Location,
+		WithStorage(storage),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	// Copy the value into the temporary storage,
+	// as if it was stored in an account.
+	// The address is irrelevant, it only needs to be an account address,
+	// so the copied value is not temporary.
+	//
+	// NOTE: The value must be deep-copied, as transferring a resource moves it
+
+	address := atree.Address{0x1}
+
+	copiedValue := deepCopy(estimationInterpreter, value, address)
+
+	// Like when writing to account storage,
+	// the value is stored in the account storage register, if possible
+
+	_, err = copiedValue.Storable(storage, address, math.MaxUint64)
+	if err != nil {
+		return 0, err
+	}
+
+	return storage.Count(), nil
+}
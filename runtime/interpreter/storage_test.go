@@ -477,3 +477,170 @@ func TestStorageOverwriteAndRemove(t *testing.T) {
 
 	assert.Len(t, storage.Slabs, 0)
 }
+
+func TestEstimateSlabCount(t *testing.T) {
+
+	t.Parallel()
+
+	newInterpreter := func(t *testing.T) (InMemoryStorage, *Interpreter) {
+		storage := NewInMemoryStorage()
+
+		inter, err := NewInterpreter(
+			nil,
+			common.AddressLocation{},
+			WithStorage(storage),
+		)
+		require.NoError(t, err)
+
+		return storage, inter
+	}
+
+	newArray := func(inter *Interpreter, elementCount int) *ArrayValue {
+		elements := make([]Value, elementCount)
+		for i := range elements {
+			elements[i] = NewIntValueFromInt64(int64(i))
+		}
+
+		return NewArrayValue(
+			inter,
+			VariableSizedStaticType{
+				Type: PrimitiveStaticTypeInt,
+			},
+			common.Address{},
+			elements...,
+		)
+	}
+
+	t.Run("simple value", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, inter := newInterpreter(t)
+
+		count, err := EstimateSlabCount(inter, NewIntValueFromInt64(42))
+		require.NoError(t, err)
+		require.Equal(t, 0, count)
+	})
+
+	t.Run("small array", func(t *testing.T) {
+
+		t.Parallel()
+
+		storage, inter := newInterpreter(t)
+
+		array := newArray(inter, 3)
+
+		slabCountBefore := storage.Count()
+
+		count, err := EstimateSlabCount(inter, array)
+		require.NoError(t, err)
+		require.Equal(t, 1, count)
+
+		// The storage of the interpreter is not modified
+
+		require.Equal(t, slabCountBefore, storage.Count())
+	})
+
+	t.Run("large array", func(t *testing.T) {
+
+		t.Parallel()
+
+		storage, inter := newInterpreter(t)
+
+		array := newArray(inter, 1000)
+
+		slabCountBefore := storage.Count()
+
+		count, err := EstimateSlabCount(inter, array)
+		require.NoError(t, err)
+
+		// The array is split across multiple slabs
+
+		require.Greater(t, count, 1)
+
+		require.Equal(t, slabCountBefore, storage.Count())
+
+		// Storing the array results in the estimated number of slabs
+
+		storedStorage, storedInter := newInterpreter(t)
+
+		_ = array.Transfer(storedInter, ReturnEmptyLocationRange, atree.Address(testOwner), false, nil)
+
+		require.Equal(t, count, storedStorage.Count())
+	})
+
+	t.Run("resource", func(t *testing.T) {
+
+		t.Parallel()
+
+		storage, inter := newInterpreter(t)
+
+		resource := NewCompositeValue(
+			inter,
+			TestLocation,
+			"Test",
+			common.CompositeKindResource,
+			nil,
+			common.Address{},
+		)
+
+		resource.SetMember(
+			inter,
+			ReturnEmptyLocationRange,
+			"value",
+			newArray(inter, 1000),
+		)
+
+		storageID := resource.StorageID()
+		slabCountBefore := storage.Count()
+
+		count, err := EstimateSlabCount(inter, resource)
+		require.NoError(t, err)
+		require.Greater(t, count, 1)
+
+		// The resource is not moved
+
+		require.Equal(t, storageID, resource.StorageID())
+		require.Equal(t, slabCountBefore, storage.Count())
+
+		array := resource.GetField(inter, ReturnEmptyLocationRange, "value").(*ArrayValue)
+		require.Equal(t, 1000, array.Count())
+	})
+}
+
+func TestStorageKeyForPath(t *testing.T) {
+
+	t.Parallel()
+
+	key := StorageKeyForPath(common.PathDomainStorage, "one")
+	assert.Equal(t, "storage\x1fone", key)
+
+	assert.Equal(t,
+		key,
+		PathToStorageKey(PathValue{
+			Domain:     common.PathDomainStorage,
+			Identifier: "one",
+		}),
+	)
+
+	for _, domain := range common.AllPathDomains {
+
+		key := StorageKeyForPath(domain, "foo")
+
+		actualDomain, identifier, ok := PathForStorageKey(key)
+		require.True(t, ok)
+
+		assert.Equal(t, domain, actualDomain)
+		assert.Equal(t, "foo", identifier)
+	}
+
+	for _, key := range []string{
+		"contract\x1fFoo",
+		"unknown\x1ffoo",
+		"storage",
+		"$\x00\x00\x00\x00\x00\x00\x00\x01",
+	} {
+		_, _, ok := PathForStorageKey(key)
+		assert.False(t, ok, key)
+	}
+}
@@ -0,0 +1,59 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2020 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package interpreter
+
+import (
+	"github.com/onflow/cadence/runtime/common"
+)
+
+// StorageUsedCache caches the amounts of storage used by accounts during an execution,
+// so repeated reads of the storage used by an account
+// only query the host environment once.
+//
+// The cached amount of an account must be invalidated
+// when the storage of the account is written.
+//
+type StorageUsedCache struct {
+	amounts map[common.Address]uint64
+}
+
+func NewStorageUsedCache() *StorageUsedCache {
+	return &StorageUsedCache{
+		amounts: map[common.Address]uint64{},
+	}
+}
+
+// Get returns the cached amount of storage used by the given account, if any.
+//
+func (c *StorageUsedCache) Get(address common.Address) (amount uint64, ok bool) {
+	amount, ok = c.amounts[address]
+	return
+}
+
+// Set caches the amount of storage used by the given account.
+//
+func (c *StorageUsedCache) Set(address common.Address, amount uint64) {
+	c.amounts[address] = amount
+}
+
+// Invalidate removes the cached amount of storage used by the given account, if any.
+//
+func (c *StorageUsedCache) Invalidate(address common.Address) {
+	delete(c.amounts, address)
+}
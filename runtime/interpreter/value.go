@@ -24,6 +24,7 @@ import (
 	"fmt"
 	"math"
 	"math/big"
+	"sort"
 	"strings"
 	"time"
 
@@ -31,6 +32,7 @@ import (
 	"github.com/rivo/uniseg"
 	"golang.org/x/text/unicode/norm"
 
+	"github.com/onflow/cadence/runtime/ast"
 	"github.com/onflow/cadence/runtime/common"
 	"github.com/onflow/cadence/runtime/errors"
 	"github.com/onflow/cadence/runtime/format"
@@ -259,6 +261,16 @@ func (v TypeValue) GetMember(interpreter *Interpreter, _ func() LocationRange, n
 			},
 			sema.MetaTypeIsSubtypeFunctionType,
 		)
+	case "isStorable":
+		staticType := v.Type
+
+		// if the type is unknown, it is not known to be storable
+		if staticType == nil {
+			return BoolValue(false)
+		}
+
+		semaType := interpreter.MustConvertStaticToSemaType(staticType)
+		return BoolValue(semaType.IsStorable(map[*sema.Member]bool{}))
 	}
 
 	return nil
@@ -758,7 +770,10 @@ func (v *StringValue) GetMember(interpreter *Interpreter, _ func() LocationRange
 	case "decodeHex":
 		return NewHostFunctionValue(
 			func(invocation Invocation) Value {
-				return v.DecodeHex(invocation.Interpreter)
+				return v.DecodeHex(
+					invocation.Interpreter,
+					invocation.GetLocationRange,
+				)
 			},
 			sema.StringTypeDecodeHexFunctionType,
 		)
@@ -852,10 +867,13 @@ var ByteArrayStaticType = ConvertSemaArrayTypeToStaticArrayType(sema.ByteArrayTy
 
 // DecodeHex hex-decodes this string and returns an array of UInt8 values
 //
-func (v *StringValue) DecodeHex(interpreter *Interpreter) *ArrayValue {
+func (v *StringValue) DecodeHex(interpreter *Interpreter, getLocationRange func() LocationRange) *ArrayValue {
 	bs, err := hex.DecodeString(v.Str)
 	if err != nil {
-		panic(err)
+		panic(InvalidHexStringError{
+			Err:           err,
+			LocationRange: getLocationRange(),
+		})
 	}
 
 	i := 0
@@ -896,6 +914,9 @@ type ArrayValue struct {
 	array            *atree.Array
 	isDestroyed      bool
 	isResourceKinded *bool
+	// storedDepth is the depth of the array in the stored value it is part of,
+	// or zero if the array is not stored, or its depth is unknown (see setStoredValueDepth)
+	storedDepth int
 }
 
 func NewArrayValue(
@@ -1120,7 +1141,9 @@ func (v *ArrayValue) Get(interpreter *Interpreter, getLocationRange func() Locat
 		panic(ExternalError{err})
 	}
 
-	return StoredValue(storable, interpreter.Storage)
+	value := StoredValue(storable, interpreter.Storage)
+	setNestedStoredValueDepth(value, v.storedDepth)
+	return value
 }
 
 func (v *ArrayValue) SetKey(interpreter *Interpreter, getLocationRange func() LocationRange, key Value, value Value) {
@@ -1130,8 +1153,12 @@ func (v *ArrayValue) SetKey(interpreter *Interpreter, getLocationRange func() Lo
 
 func (v *ArrayValue) Set(interpreter *Interpreter, getLocationRange func() LocationRange, index int, element Value) {
 
+	interpreter.checkStorageMutation(v.array.Address(), "array element write", getLocationRange)
+
 	interpreter.checkContainerMutation(v.Type.ElementType(), element, getLocationRange)
 
+	defer interpreter.enterStoredContainer(v.storedDepth)()
+
 	element = element.Transfer(
 		interpreter,
 		getLocationRange,
@@ -1173,8 +1200,12 @@ func (v *ArrayValue) RecursiveString(seenReferences SeenReferences) string {
 
 func (v *ArrayValue) Append(interpreter *Interpreter, getLocationRange func() LocationRange, element Value) {
 
+	interpreter.checkStorageMutation(v.array.Address(), "array append", getLocationRange)
+
 	interpreter.checkContainerMutation(v.Type.ElementType(), element, getLocationRange)
 
+	defer interpreter.enterStoredContainer(v.storedDepth)()
+
 	element = element.Transfer(
 		interpreter,
 		getLocationRange,
@@ -1203,8 +1234,12 @@ func (v *ArrayValue) InsertKey(interpreter *Interpreter, getLocationRange func()
 
 func (v *ArrayValue) Insert(interpreter *Interpreter, getLocationRange func() LocationRange, index int, element Value) {
 
+	interpreter.checkStorageMutation(v.array.Address(), "array insertion", getLocationRange)
+
 	interpreter.checkContainerMutation(v.Type.ElementType(), element, getLocationRange)
 
+	defer interpreter.enterStoredContainer(v.storedDepth)()
+
 	element = element.Transfer(
 		interpreter,
 		getLocationRange,
@@ -1228,6 +1263,8 @@ func (v *ArrayValue) RemoveKey(interpreter *Interpreter, getLocationRange func()
 }
 
 func (v *ArrayValue) Remove(interpreter *Interpreter, getLocationRange func() LocationRange, index int) Value {
+
+	interpreter.checkStorageMutation(v.array.Address(), "array removal", getLocationRange)
 	storable, err := v.array.Remove(uint64(index))
 	if err != nil {
 		v.handleIndexOutOfBoundsError(err, index, getLocationRange)
@@ -1255,6 +1292,47 @@ func (v *ArrayValue) RemoveLast(interpreter *Interpreter, getLocationRange func(
 	return v.Remove(interpreter, getLocationRange, v.Count()-1)
 }
 
+// SortedByUUID moves all resources out of this array, which must be an array of composite resources,
+// and returns them in a new array, ordered by their UUIDs in ascending order.
+//
+func (v *ArrayValue) SortedByUUID(interpreter *Interpreter, getLocationRange func() LocationRange) *ArrayValue {
+
+	type element struct {
+		value *CompositeValue
+		uuid  UInt64Value
+	}
+
+	count := v.Count()
+
+	elements := make([]element, count)
+
+	for i := count - 1; i >= 0; i-- {
+		value := v.RemoveLast(interpreter, getLocationRange).(*CompositeValue)
+		uuid := value.GetField(interpreter, getLocationRange, sema.ResourceUUIDFieldName).(UInt64Value)
+
+		elements[i] = element{
+			value: value,
+			uuid:  uuid,
+		}
+	}
+
+	sort.SliceStable(elements, func(i, j int) bool {
+		return elements[i].uuid < elements[j].uuid
+	})
+
+	values := make([]Value, count)
+	for i, element := range elements {
+		values[i] = element.value
+	}
+
+	return NewArrayValue(
+		interpreter,
+		v.Type,
+		common.Address{},
+		values...,
+	)
+}
+
 func (v *ArrayValue) Contains(interpreter *Interpreter, getLocationRange func() LocationRange, needleValue Value) BoolValue {
 
 	needleEquatable := needleValue.(EquatableValue)
@@ -1273,11 +1351,30 @@ func (v *ArrayValue) Contains(interpreter *Interpreter, getLocationRange func()
 	return BoolValue(result)
 }
 
+// ToHexString hex-encodes the bytes of this array, which must be an array of UInt8 values
+//
+func (v *ArrayValue) ToHexString() *StringValue {
+	bytes, err := ByteArrayValueToByteSlice(v)
+	if err != nil {
+		panic(err)
+	}
+
+	return NewStringValue(hex.EncodeToString(bytes))
+}
+
 func (v *ArrayValue) GetMember(inter *Interpreter, _ func() LocationRange, name string) Value {
 	switch name {
 	case "length":
 		return NewIntValueFromInt64(int64(v.Count()))
 
+	case "toHexString":
+		return NewHostFunctionValue(
+			func(invocation Invocation) Value {
+				return v.ToHexString()
+			},
+			sema.ArrayToHexStringFunctionType,
+		)
+
 	case "append":
 		return NewHostFunctionValue(
 			func(invocation Invocation) Value {
@@ -1383,6 +1480,19 @@ func (v *ArrayValue) GetMember(inter *Interpreter, _ func() LocationRange, name
 			),
 		)
 
+	case "sortedByUUID":
+		return NewHostFunctionValue(
+			func(invocation Invocation) Value {
+				return v.SortedByUUID(
+					invocation.Interpreter,
+					invocation.GetLocationRange,
+				)
+			},
+			sema.ArraySortedByUUIDFunctionType(
+				v.SemaType(inter),
+			),
+		)
+
 	case "contains":
 		return NewHostFunctionValue(
 			func(invocation Invocation) Value {
@@ -1505,11 +1615,15 @@ func (v *ArrayValue) Transfer(
 	}
 
 	array := v.array
+	storedDepth := v.storedDepth
 
 	needsStoreTo := v.NeedsStoreTo(address)
 	isResourceKinded := v.IsResourceKinded(interpreter)
 
 	if needsStoreTo || !isResourceKinded {
+		defer interpreter.enterStoredValue(address, getLocationRange)()
+
+		storedDepth = interpreter.transferredValueDepth(address)
 
 		iterator, err := v.array.Iterator()
 		if err != nil {
@@ -1532,6 +1646,8 @@ func (v *ArrayValue) Transfer(
 				element := MustConvertStoredValue(value).
 					Transfer(interpreter, getLocationRange, address, remove, nil)
 
+				interpreter.checkStorable(element, address, getLocationRange)
+
 				return element, nil
 			},
 		)
@@ -1554,6 +1670,7 @@ func (v *ArrayValue) Transfer(
 
 	if isResourceKinded {
 		v.array = array
+		v.storedDepth = storedDepth
 		return v
 	} else {
 		return &ArrayValue{
@@ -1562,6 +1679,7 @@ func (v *ArrayValue) Transfer(
 			isResourceKinded: v.isResourceKinded,
 			array:            array,
 			isDestroyed:      v.isDestroyed,
+			storedDepth:      storedDepth,
 		}
 	}
 }
@@ -1825,7 +1943,10 @@ func (IntValue) StaticType() StaticType {
 
 func (v IntValue) ToInt() int {
 	if !v.BigInt.IsInt64() {
-		panic(OverflowError{})
+		if v.BigInt.Sign() < 0 {
+			panic(newConversionUnderflowError(v, PrimitiveStaticTypeInt64))
+		}
+		panic(newConversionOverflowError(v, PrimitiveStaticTypeInt64))
 	}
 	return int(v.BigInt.Int64())
 }
@@ -1967,10 +2088,10 @@ func (v IntValue) BitwiseLeftShift(other IntegerValue) IntegerValue {
 	o := other.(IntValue)
 	res := new(big.Int)
 	if o.BigInt.Sign() < 0 {
-		panic(UnderflowError{})
+		panic(newArithmeticUnderflowError(ast.OperationBitwiseLeftShift, v, o))
 	}
 	if !o.BigInt.IsUint64() {
-		panic(OverflowError{})
+		panic(newArithmeticOverflowError(ast.OperationBitwiseLeftShift, v, o))
 	}
 	res.Lsh(v.BigInt, uint(o.BigInt.Uint64()))
 	return IntValue{res}
@@ -1980,10 +2101,10 @@ func (v IntValue) BitwiseRightShift(other IntegerValue) IntegerValue {
 	o := other.(IntValue)
 	res := new(big.Int)
 	if o.BigInt.Sign() < 0 {
-		panic(UnderflowError{})
+		panic(newArithmeticUnderflowError(ast.OperationBitwiseRightShift, v, o))
 	}
 	if !o.BigInt.IsUint64() {
-		panic(OverflowError{})
+		panic(newArithmeticOverflowError(ast.OperationBitwiseRightShift, v, o))
 	}
 	res.Rsh(v.BigInt, uint(o.BigInt.Uint64()))
 	return IntValue{res}
@@ -2109,7 +2230,7 @@ func (v Int8Value) ToInt() int {
 func (v Int8Value) Negate() NumberValue {
 	// INT32-C
 	if v == math.MinInt8 {
-		panic(OverflowError{})
+		panic(newArithmeticOverflowError(ast.OperationNegate, v, nil))
 	}
 	return -v
 }
@@ -2118,9 +2239,9 @@ func (v Int8Value) Plus(other NumberValue) NumberValue {
 	o := other.(Int8Value)
 	// INT32-C
 	if (o > 0) && (v > (math.MaxInt8 - o)) {
-		panic(OverflowError{})
+		panic(newArithmeticOverflowError(ast.OperationPlus, v, o))
 	} else if (o < 0) && (v < (math.MinInt8 - o)) {
-		panic(UnderflowError{})
+		panic(newArithmeticUnderflowError(ast.OperationPlus, v, o))
 	}
 	return v + o
 }
@@ -2140,9 +2261,9 @@ func (v Int8Value) Minus(other NumberValue) NumberValue {
 	o := other.(Int8Value)
 	// INT32-C
 	if (o > 0) && (v < (math.MinInt8 + o)) {
-		panic(OverflowError{})
+		panic(newArithmeticUnderflowError(ast.OperationMinus, v, o))
 	} else if (o < 0) && (v > (math.MaxInt8 + o)) {
-		panic(UnderflowError{})
+		panic(newArithmeticOverflowError(ast.OperationMinus, v, o))
 	}
 	return v - o
 }
@@ -2174,24 +2295,24 @@ func (v Int8Value) Mul(other NumberValue) NumberValue {
 		if o > 0 {
 			// positive * positive = positive. overflow?
 			if v > (math.MaxInt8 / o) {
-				panic(OverflowError{})
+				panic(newArithmeticOverflowError(ast.OperationMul, v, o))
 			}
 		} else {
 			// positive * negative = negative. underflow?
 			if o < (math.MinInt8 / v) {
-				panic(UnderflowError{})
+				panic(newArithmeticUnderflowError(ast.OperationMul, v, o))
 			}
 		}
 	} else {
 		if o > 0 {
 			// negative * positive = negative. underflow?
 			if v < (math.MinInt8 / o) {
-				panic(UnderflowError{})
+				panic(newArithmeticUnderflowError(ast.OperationMul, v, o))
 			}
 		} else {
 			// negative * negative = positive. overflow?
 			if (v != 0) && (o < (math.MaxInt8 / v)) {
-				panic(OverflowError{})
+				panic(newArithmeticOverflowError(ast.OperationMul, v, o))
 			}
 		}
 	}
@@ -2236,7 +2357,7 @@ func (v Int8Value) Div(other NumberValue) NumberValue {
 	if o == 0 {
 		panic(DivisionByZeroError{})
 	} else if (v == math.MinInt8) && (o == -1) {
-		panic(OverflowError{})
+		panic(newArithmeticOverflowError(ast.OperationDiv, v, o))
 	}
 	return v / o
 }
@@ -2290,18 +2411,18 @@ func ConvertInt8(value Value) Int8Value {
 	case BigNumberValue:
 		v := value.ToBigInt()
 		if v.Cmp(sema.Int8TypeMaxInt) > 0 {
-			panic(OverflowError{})
+			panic(newConversionOverflowError(value, PrimitiveStaticTypeInt8))
 		} else if v.Cmp(sema.Int8TypeMinInt) < 0 {
-			panic(UnderflowError{})
+			panic(newConversionUnderflowError(value, PrimitiveStaticTypeInt8))
 		}
 		res = int8(v.Int64())
 
 	case NumberValue:
 		v := value.ToInt()
 		if v > math.MaxInt8 {
-			panic(OverflowError{})
+			panic(newConversionOverflowError(value, PrimitiveStaticTypeInt8))
 		} else if v < math.MinInt8 {
-			panic(UnderflowError{})
+			panic(newConversionUnderflowError(value, PrimitiveStaticTypeInt8))
 		}
 		res = int8(v)
 
@@ -2457,7 +2578,7 @@ func (v Int16Value) ToInt() int {
 func (v Int16Value) Negate() NumberValue {
 	// INT32-C
 	if v == math.MinInt16 {
-		panic(OverflowError{})
+		panic(newArithmeticOverflowError(ast.OperationNegate, v, nil))
 	}
 	return -v
 }
@@ -2466,9 +2587,9 @@ func (v Int16Value) Plus(other NumberValue) NumberValue {
 	o := other.(Int16Value)
 	// INT32-C
 	if (o > 0) && (v > (math.MaxInt16 - o)) {
-		panic(OverflowError{})
+		panic(newArithmeticOverflowError(ast.OperationPlus, v, o))
 	} else if (o < 0) && (v < (math.MinInt16 - o)) {
-		panic(UnderflowError{})
+		panic(newArithmeticUnderflowError(ast.OperationPlus, v, o))
 	}
 	return v + o
 }
@@ -2488,9 +2609,9 @@ func (v Int16Value) Minus(other NumberValue) NumberValue {
 	o := other.(Int16Value)
 	// INT32-C
 	if (o > 0) && (v < (math.MinInt16 + o)) {
-		panic(OverflowError{})
+		panic(newArithmeticUnderflowError(ast.OperationMinus, v, o))
 	} else if (o < 0) && (v > (math.MaxInt16 + o)) {
-		panic(UnderflowError{})
+		panic(newArithmeticOverflowError(ast.OperationMinus, v, o))
 	}
 	return v - o
 }
@@ -2522,24 +2643,24 @@ func (v Int16Value) Mul(other NumberValue) NumberValue {
 		if o > 0 {
 			// positive * positive = positive. overflow?
 			if v > (math.MaxInt16 / o) {
-				panic(OverflowError{})
+				panic(newArithmeticOverflowError(ast.OperationMul, v, o))
 			}
 		} else {
 			// positive * negative = negative. underflow?
 			if o < (math.MinInt16 / v) {
-				panic(UnderflowError{})
+				panic(newArithmeticUnderflowError(ast.OperationMul, v, o))
 			}
 		}
 	} else {
 		if o > 0 {
 			// negative * positive = negative. underflow?
 			if v < (math.MinInt16 / o) {
-				panic(UnderflowError{})
+				panic(newArithmeticUnderflowError(ast.OperationMul, v, o))
 			}
 		} else {
 			// negative * negative = positive. overflow?
 			if (v != 0) && (o < (math.MaxInt16 / v)) {
-				panic(OverflowError{})
+				panic(newArithmeticOverflowError(ast.OperationMul, v, o))
 			}
 		}
 	}
@@ -2584,7 +2705,7 @@ func (v Int16Value) Div(other NumberValue) NumberValue {
 	if o == 0 {
 		panic(DivisionByZeroError{})
 	} else if (v == math.MinInt16) && (o == -1) {
-		panic(OverflowError{})
+		panic(newArithmeticOverflowError(ast.OperationDiv, v, o))
 	}
 	return v / o
 }
@@ -2638,18 +2759,18 @@ func ConvertInt16(value Value) Int16Value {
 	case BigNumberValue:
 		v := value.ToBigInt()
 		if v.Cmp(sema.Int16TypeMaxInt) > 0 {
-			panic(OverflowError{})
+			panic(newConversionOverflowError(value, PrimitiveStaticTypeInt16))
 		} else if v.Cmp(sema.Int16TypeMinInt) < 0 {
-			panic(UnderflowError{})
+			panic(newConversionUnderflowError(value, PrimitiveStaticTypeInt16))
 		}
 		res = int16(v.Int64())
 
 	case NumberValue:
 		v := value.ToInt()
 		if v > math.MaxInt16 {
-			panic(OverflowError{})
+			panic(newConversionOverflowError(value, PrimitiveStaticTypeInt16))
 		} else if v < math.MinInt16 {
-			panic(UnderflowError{})
+			panic(newConversionUnderflowError(value, PrimitiveStaticTypeInt16))
 		}
 		res = int16(v)
 
@@ -2807,7 +2928,7 @@ func (v Int32Value) ToInt() int {
 func (v Int32Value) Negate() NumberValue {
 	// INT32-C
 	if v == math.MinInt32 {
-		panic(OverflowError{})
+		panic(newArithmeticOverflowError(ast.OperationNegate, v, nil))
 	}
 	return -v
 }
@@ -2816,9 +2937,9 @@ func (v Int32Value) Plus(other NumberValue) NumberValue {
 	o := other.(Int32Value)
 	// INT32-C
 	if (o > 0) && (v > (math.MaxInt32 - o)) {
-		panic(OverflowError{})
+		panic(newArithmeticOverflowError(ast.OperationPlus, v, o))
 	} else if (o < 0) && (v < (math.MinInt32 - o)) {
-		panic(UnderflowError{})
+		panic(newArithmeticUnderflowError(ast.OperationPlus, v, o))
 	}
 	return v + o
 }
@@ -2838,9 +2959,9 @@ func (v Int32Value) Minus(other NumberValue) NumberValue {
 	o := other.(Int32Value)
 	// INT32-C
 	if (o > 0) && (v < (math.MinInt32 + o)) {
-		panic(OverflowError{})
+		panic(newArithmeticUnderflowError(ast.OperationMinus, v, o))
 	} else if (o < 0) && (v > (math.MaxInt32 + o)) {
-		panic(UnderflowError{})
+		panic(newArithmeticOverflowError(ast.OperationMinus, v, o))
 	}
 	return v - o
 }
@@ -2872,24 +2993,24 @@ func (v Int32Value) Mul(other NumberValue) NumberValue {
 		if o > 0 {
 			// positive * positive = positive. overflow?
 			if v > (math.MaxInt32 / o) {
-				panic(OverflowError{})
+				panic(newArithmeticOverflowError(ast.OperationMul, v, o))
 			}
 		} else {
 			// positive * negative = negative. underflow?
 			if o < (math.MinInt32 / v) {
-				panic(UnderflowError{})
+				panic(newArithmeticUnderflowError(ast.OperationMul, v, o))
 			}
 		}
 	} else {
 		if o > 0 {
 			// negative * positive = negative. underflow?
 			if v < (math.MinInt32 / o) {
-				panic(UnderflowError{})
+				panic(newArithmeticUnderflowError(ast.OperationMul, v, o))
 			}
 		} else {
 			// negative * negative = positive. overflow?
 			if (v != 0) && (o < (math.MaxInt32 / v)) {
-				panic(OverflowError{})
+				panic(newArithmeticOverflowError(ast.OperationMul, v, o))
 			}
 		}
 	}
@@ -2934,7 +3055,7 @@ func (v Int32Value) Div(other NumberValue) NumberValue {
 	if o == 0 {
 		panic(DivisionByZeroError{})
 	} else if (v == math.MinInt32) && (o == -1) {
-		panic(OverflowError{})
+		panic(newArithmeticOverflowError(ast.OperationDiv, v, o))
 	}
 	return v / o
 }
@@ -2988,18 +3109,18 @@ func ConvertInt32(value Value) Int32Value {
 	case BigNumberValue:
 		v := value.ToBigInt()
 		if v.Cmp(sema.Int32TypeMaxInt) > 0 {
-			panic(OverflowError{})
+			panic(newConversionOverflowError(value, PrimitiveStaticTypeInt32))
 		} else if v.Cmp(sema.Int32TypeMinInt) < 0 {
-			panic(UnderflowError{})
+			panic(newConversionUnderflowError(value, PrimitiveStaticTypeInt32))
 		}
 		res = int32(v.Int64())
 
 	case NumberValue:
 		v := value.ToInt()
 		if v > math.MaxInt32 {
-			panic(OverflowError{})
+			panic(newConversionOverflowError(value, PrimitiveStaticTypeInt32))
 		} else if v < math.MinInt32 {
-			panic(UnderflowError{})
+			panic(newConversionUnderflowError(value, PrimitiveStaticTypeInt32))
 		}
 		res = int32(v)
 
@@ -3157,24 +3278,24 @@ func (v Int64Value) ToInt() int {
 func (v Int64Value) Negate() NumberValue {
 	// INT32-C
 	if v == math.MinInt64 {
-		panic(OverflowError{})
+		panic(newArithmeticOverflowError(ast.OperationNegate, v, nil))
 	}
 	return -v
 }
 
-func safeAddInt64(a, b int64) int64 {
+func safeAddInt64(left, right NumberValue, a, b int64) int64 {
 	// INT32-C
 	if (b > 0) && (a > (math.MaxInt64 - b)) {
-		panic(OverflowError{})
+		panic(newArithmeticOverflowError(ast.OperationPlus, left, right))
 	} else if (b < 0) && (a < (math.MinInt64 - b)) {
-		panic(UnderflowError{})
+		panic(newArithmeticUnderflowError(ast.OperationPlus, left, right))
 	}
 	return a + b
 }
 
 func (v Int64Value) Plus(other NumberValue) NumberValue {
 	o := other.(Int64Value)
-	return Int64Value(safeAddInt64(int64(v), int64(o)))
+	return Int64Value(safeAddInt64(v, o, int64(v), int64(o)))
 }
 
 func (v Int64Value) SaturatingPlus(other NumberValue) NumberValue {
@@ -3192,9 +3313,9 @@ func (v Int64Value) Minus(other NumberValue) NumberValue {
 	o := other.(Int64Value)
 	// INT32-C
 	if (o > 0) && (v < (math.MinInt64 + o)) {
-		panic(OverflowError{})
+		panic(newArithmeticUnderflowError(ast.OperationMinus, v, o))
 	} else if (o < 0) && (v > (math.MaxInt64 + o)) {
-		panic(UnderflowError{})
+		panic(newArithmeticOverflowError(ast.OperationMinus, v, o))
 	}
 	return v - o
 }
@@ -3226,24 +3347,24 @@ func (v Int64Value) Mul(other NumberValue) NumberValue {
 		if o > 0 {
 			// positive * positive = positive. overflow?
 			if v > (math.MaxInt64 / o) {
-				panic(OverflowError{})
+				panic(newArithmeticOverflowError(ast.OperationMul, v, o))
 			}
 		} else {
 			// positive * negative = negative. underflow?
 			if o < (math.MinInt64 / v) {
-				panic(UnderflowError{})
+				panic(newArithmeticUnderflowError(ast.OperationMul, v, o))
 			}
 		}
 	} else {
 		if o > 0 {
 			// negative * positive = negative. underflow?
 			if v < (math.MinInt64 / o) {
-				panic(UnderflowError{})
+				panic(newArithmeticUnderflowError(ast.OperationMul, v, o))
 			}
 		} else {
 			// negative * negative = positive. overflow?
 			if (v != 0) && (o < (math.MaxInt64 / v)) {
-				panic(OverflowError{})
+				panic(newArithmeticOverflowError(ast.OperationMul, v, o))
 			}
 		}
 	}
@@ -3288,7 +3409,7 @@ func (v Int64Value) Div(other NumberValue) NumberValue {
 	if o == 0 {
 		panic(DivisionByZeroError{})
 	} else if (v == math.MinInt64) && (o == -1) {
-		panic(OverflowError{})
+		panic(newArithmeticOverflowError(ast.OperationDiv, v, o))
 	}
 	return v / o
 }
@@ -3342,9 +3463,9 @@ func ConvertInt64(value Value) Int64Value {
 	case BigNumberValue:
 		v := value.ToBigInt()
 		if v.Cmp(sema.Int64TypeMaxInt) > 0 {
-			panic(OverflowError{})
+			panic(newConversionOverflowError(value, PrimitiveStaticTypeInt64))
 		} else if v.Cmp(sema.Int64TypeMinInt) < 0 {
-			panic(UnderflowError{})
+			panic(newConversionUnderflowError(value, PrimitiveStaticTypeInt64))
 		}
 		res = v.Int64()
 
@@ -3503,7 +3624,10 @@ func (Int128Value) StaticType() StaticType {
 
 func (v Int128Value) ToInt() int {
 	if !v.BigInt.IsInt64() {
-		panic(OverflowError{})
+		if v.BigInt.Sign() < 0 {
+			panic(newConversionUnderflowError(v, PrimitiveStaticTypeInt64))
+		}
+		panic(newConversionOverflowError(v, PrimitiveStaticTypeInt64))
 	}
 	return int(v.BigInt.Int64())
 }
@@ -3526,7 +3650,7 @@ func (v Int128Value) Negate() NumberValue {
 	//       ...
 	//   }
 	if v.BigInt.Cmp(sema.Int128TypeMinIntBig) == 0 {
-		panic(OverflowError{})
+		panic(newArithmeticOverflowError(ast.OperationNegate, v, nil))
 	}
 	return Int128Value{new(big.Int).Neg(v.BigInt)}
 }
@@ -3548,9 +3672,9 @@ func (v Int128Value) Plus(other NumberValue) NumberValue {
 	res := new(big.Int)
 	res.Add(v.BigInt, o.BigInt)
 	if res.Cmp(sema.Int128TypeMinIntBig) < 0 {
-		panic(UnderflowError{})
+		panic(newArithmeticUnderflowError(ast.OperationPlus, v, o))
 	} else if res.Cmp(sema.Int128TypeMaxIntBig) > 0 {
-		panic(OverflowError{})
+		panic(newArithmeticOverflowError(ast.OperationPlus, v, o))
 	}
 	return Int128Value{res}
 }
@@ -3596,9 +3720,9 @@ func (v Int128Value) Minus(other NumberValue) NumberValue {
 	res := new(big.Int)
 	res.Sub(v.BigInt, o.BigInt)
 	if res.Cmp(sema.Int128TypeMinIntBig) < 0 {
-		panic(UnderflowError{})
+		panic(newArithmeticUnderflowError(ast.OperationMinus, v, o))
 	} else if res.Cmp(sema.Int128TypeMaxIntBig) > 0 {
-		panic(OverflowError{})
+		panic(newArithmeticOverflowError(ast.OperationMinus, v, o))
 	}
 	return Int128Value{res}
 }
@@ -3643,9 +3767,9 @@ func (v Int128Value) Mul(other NumberValue) NumberValue {
 	res := new(big.Int)
 	res.Mul(v.BigInt, o.BigInt)
 	if res.Cmp(sema.Int128TypeMinIntBig) < 0 {
-		panic(UnderflowError{})
+		panic(newArithmeticUnderflowError(ast.OperationMul, v, o))
 	} else if res.Cmp(sema.Int128TypeMaxIntBig) > 0 {
-		panic(OverflowError{})
+		panic(newArithmeticOverflowError(ast.OperationMul, v, o))
 	}
 	return Int128Value{res}
 }
@@ -3676,7 +3800,7 @@ func (v Int128Value) Div(other NumberValue) NumberValue {
 	}
 	res.SetInt64(-1)
 	if (v.BigInt.Cmp(sema.Int128TypeMinIntBig) == 0) && (o.BigInt.Cmp(res) == 0) {
-		panic(OverflowError{})
+		panic(newArithmeticOverflowError(ast.OperationDiv, v, o))
 	}
 	res.Div(v.BigInt, o.BigInt)
 	return Int128Value{res}
@@ -3754,9 +3878,9 @@ func ConvertInt128(value Value) Int128Value {
 	}
 
 	if v.Cmp(sema.Int128TypeMaxIntBig) > 0 {
-		panic(OverflowError{})
+		panic(newConversionOverflowError(value, PrimitiveStaticTypeInt128))
 	} else if v.Cmp(sema.Int128TypeMinIntBig) < 0 {
-		panic(UnderflowError{})
+		panic(newConversionUnderflowError(value, PrimitiveStaticTypeInt128))
 	}
 
 	return NewInt128ValueFromBigInt(v)
@@ -3787,10 +3911,10 @@ func (v Int128Value) BitwiseLeftShift(other IntegerValue) IntegerValue {
 	o := other.(Int128Value)
 	res := new(big.Int)
 	if o.BigInt.Sign() < 0 {
-		panic(UnderflowError{})
+		panic(newArithmeticUnderflowError(ast.OperationBitwiseLeftShift, v, o))
 	}
 	if !o.BigInt.IsUint64() {
-		panic(OverflowError{})
+		panic(newArithmeticOverflowError(ast.OperationBitwiseLeftShift, v, o))
 	}
 	res.Lsh(v.BigInt, uint(o.BigInt.Uint64()))
 	return Int128Value{res}
@@ -3800,10 +3924,10 @@ func (v Int128Value) BitwiseRightShift(other IntegerValue) IntegerValue {
 	o := other.(Int128Value)
 	res := new(big.Int)
 	if o.BigInt.Sign() < 0 {
-		panic(UnderflowError{})
+		panic(newArithmeticUnderflowError(ast.OperationBitwiseRightShift, v, o))
 	}
 	if !o.BigInt.IsUint64() {
-		panic(OverflowError{})
+		panic(newArithmeticOverflowError(ast.OperationBitwiseRightShift, v, o))
 	}
 	res.Rsh(v.BigInt, uint(o.BigInt.Uint64()))
 	return Int128Value{res}
@@ -3927,7 +4051,10 @@ func (Int256Value) StaticType() StaticType {
 
 func (v Int256Value) ToInt() int {
 	if !v.BigInt.IsInt64() {
-		panic(OverflowError{})
+		if v.BigInt.Sign() < 0 {
+			panic(newConversionUnderflowError(v, PrimitiveStaticTypeInt64))
+		}
+		panic(newConversionOverflowError(v, PrimitiveStaticTypeInt64))
 	}
 	return int(v.BigInt.Int64())
 }
@@ -3950,7 +4077,7 @@ func (v Int256Value) Negate() NumberValue {
 	//       ...
 	//   }
 	if v.BigInt.Cmp(sema.Int256TypeMinIntBig) == 0 {
-		panic(OverflowError{})
+		panic(newArithmeticOverflowError(ast.OperationNegate, v, nil))
 	}
 	return Int256Value{BigInt: new(big.Int).Neg(v.BigInt)}
 }
@@ -3972,9 +4099,9 @@ func (v Int256Value) Plus(other NumberValue) NumberValue {
 	res := new(big.Int)
 	res.Add(v.BigInt, o.BigInt)
 	if res.Cmp(sema.Int256TypeMinIntBig) < 0 {
-		panic(UnderflowError{})
+		panic(newArithmeticUnderflowError(ast.OperationPlus, v, o))
 	} else if res.Cmp(sema.Int256TypeMaxIntBig) > 0 {
-		panic(OverflowError{})
+		panic(newArithmeticOverflowError(ast.OperationPlus, v, o))
 	}
 	return Int256Value{res}
 }
@@ -4020,9 +4147,9 @@ func (v Int256Value) Minus(other NumberValue) NumberValue {
 	res := new(big.Int)
 	res.Sub(v.BigInt, o.BigInt)
 	if res.Cmp(sema.Int256TypeMinIntBig) < 0 {
-		panic(UnderflowError{})
+		panic(newArithmeticUnderflowError(ast.OperationMinus, v, o))
 	} else if res.Cmp(sema.Int256TypeMaxIntBig) > 0 {
-		panic(OverflowError{})
+		panic(newArithmeticOverflowError(ast.OperationMinus, v, o))
 	}
 	return Int256Value{res}
 }
@@ -4067,9 +4194,9 @@ func (v Int256Value) Mul(other NumberValue) NumberValue {
 	res := new(big.Int)
 	res.Mul(v.BigInt, o.BigInt)
 	if res.Cmp(sema.Int256TypeMinIntBig) < 0 {
-		panic(UnderflowError{})
+		panic(newArithmeticUnderflowError(ast.OperationMul, v, o))
 	} else if res.Cmp(sema.Int256TypeMaxIntBig) > 0 {
-		panic(OverflowError{})
+		panic(newArithmeticOverflowError(ast.OperationMul, v, o))
 	}
 	return Int256Value{res}
 }
@@ -4100,7 +4227,7 @@ func (v Int256Value) Div(other NumberValue) NumberValue {
 	}
 	res.SetInt64(-1)
 	if (v.BigInt.Cmp(sema.Int256TypeMinIntBig) == 0) && (o.BigInt.Cmp(res) == 0) {
-		panic(OverflowError{})
+		panic(newArithmeticOverflowError(ast.OperationDiv, v, o))
 	}
 	res.Div(v.BigInt, o.BigInt)
 	return Int256Value{res}
@@ -4178,9 +4305,9 @@ func ConvertInt256(value Value) Int256Value {
 	}
 
 	if v.Cmp(sema.Int256TypeMaxIntBig) > 0 {
-		panic(OverflowError{})
+		panic(newConversionOverflowError(value, PrimitiveStaticTypeInt256))
 	} else if v.Cmp(sema.Int256TypeMinIntBig) < 0 {
-		panic(UnderflowError{})
+		panic(newConversionUnderflowError(value, PrimitiveStaticTypeInt256))
 	}
 
 	return NewInt256ValueFromBigInt(v)
@@ -4211,10 +4338,10 @@ func (v Int256Value) BitwiseLeftShift(other IntegerValue) IntegerValue {
 	o := other.(Int256Value)
 	res := new(big.Int)
 	if o.BigInt.Sign() < 0 {
-		panic(UnderflowError{})
+		panic(newArithmeticUnderflowError(ast.OperationBitwiseLeftShift, v, o))
 	}
 	if !o.BigInt.IsUint64() {
-		panic(OverflowError{})
+		panic(newArithmeticOverflowError(ast.OperationBitwiseLeftShift, v, o))
 	}
 	res.Lsh(v.BigInt, uint(o.BigInt.Uint64()))
 	return Int256Value{res}
@@ -4224,10 +4351,10 @@ func (v Int256Value) BitwiseRightShift(other IntegerValue) IntegerValue {
 	o := other.(Int256Value)
 	res := new(big.Int)
 	if o.BigInt.Sign() < 0 {
-		panic(UnderflowError{})
+		panic(newArithmeticUnderflowError(ast.OperationBitwiseRightShift, v, o))
 	}
 	if !o.BigInt.IsUint64() {
-		panic(OverflowError{})
+		panic(newArithmeticOverflowError(ast.OperationBitwiseRightShift, v, o))
 	}
 	res.Rsh(v.BigInt, uint(o.BigInt.Uint64()))
 	return Int256Value{res}
@@ -4326,14 +4453,14 @@ func ConvertUInt(value Value) UIntValue {
 	case BigNumberValue:
 		v := value.ToBigInt()
 		if v.Sign() < 0 {
-			panic(UnderflowError{})
+			panic(newConversionUnderflowError(value, PrimitiveStaticTypeUInt))
 		}
 		return NewUIntValueFromBigInt(value.ToBigInt())
 
 	case NumberValue:
 		v := value.ToInt()
 		if v < 0 {
-			panic(UnderflowError{})
+			panic(newConversionUnderflowError(value, PrimitiveStaticTypeUInt))
 		}
 		return NewUIntValueFromUint64(uint64(v))
 
@@ -4371,8 +4498,8 @@ func (UIntValue) StaticType() StaticType {
 }
 
 func (v UIntValue) ToInt() int {
-	if v.BigInt.IsInt64() {
-		panic(OverflowError{})
+	if !v.BigInt.IsInt64() {
+		panic(newConversionOverflowError(v, PrimitiveStaticTypeInt64))
 	}
 	return int(v.BigInt.Int64())
 }
@@ -4410,7 +4537,7 @@ func (v UIntValue) Minus(other NumberValue) NumberValue {
 	res.Sub(v.BigInt, o.BigInt)
 	// INT30-C
 	if res.Sign() < 0 {
-		panic(UnderflowError{})
+		panic(newArithmeticUnderflowError(ast.OperationMinus, v, o))
 	}
 	return UIntValue{res}
 }
@@ -4525,10 +4652,10 @@ func (v UIntValue) BitwiseLeftShift(other IntegerValue) IntegerValue {
 	o := other.(UIntValue)
 	res := new(big.Int)
 	if o.BigInt.Sign() < 0 {
-		panic(UnderflowError{})
+		panic(newArithmeticUnderflowError(ast.OperationBitwiseLeftShift, v, o))
 	}
 	if !o.BigInt.IsUint64() {
-		panic(OverflowError{})
+		panic(newArithmeticOverflowError(ast.OperationBitwiseLeftShift, v, o))
 	}
 	res.Lsh(v.BigInt, uint(o.BigInt.Uint64()))
 	return UIntValue{res}
@@ -4538,10 +4665,10 @@ func (v UIntValue) BitwiseRightShift(other IntegerValue) IntegerValue {
 	o := other.(UIntValue)
 	res := new(big.Int)
 	if o.BigInt.Sign() < 0 {
-		panic(UnderflowError{})
+		panic(newArithmeticUnderflowError(ast.OperationBitwiseRightShift, v, o))
 	}
 	if !o.BigInt.IsUint64() {
-		panic(OverflowError{})
+		panic(newArithmeticOverflowError(ast.OperationBitwiseRightShift, v, o))
 	}
 	res.Rsh(v.BigInt, uint(o.BigInt.Uint64()))
 	return UIntValue{res}
@@ -4673,7 +4800,7 @@ func (v UInt8Value) Plus(other NumberValue) NumberValue {
 	sum := v + other.(UInt8Value)
 	// INT30-C
 	if sum < v {
-		panic(OverflowError{})
+		panic(newArithmeticOverflowError(ast.OperationPlus, v, other))
 	}
 	return sum
 }
@@ -4691,7 +4818,7 @@ func (v UInt8Value) Minus(other NumberValue) NumberValue {
 	diff := v - other.(UInt8Value)
 	// INT30-C
 	if diff > v {
-		panic(UnderflowError{})
+		panic(newArithmeticUnderflowError(ast.OperationMinus, v, other))
 	}
 	return diff
 }
@@ -4717,7 +4844,7 @@ func (v UInt8Value) Mul(other NumberValue) NumberValue {
 	o := other.(UInt8Value)
 	// INT30-C
 	if (v > 0) && (o > 0) && (v > (math.MaxUint8 / o)) {
-		panic(OverflowError{})
+		panic(newArithmeticOverflowError(ast.OperationMul, v, o))
 	}
 	return v * o
 }
@@ -4780,18 +4907,18 @@ func ConvertUInt8(value Value) UInt8Value {
 	case BigNumberValue:
 		v := value.ToBigInt()
 		if v.Cmp(sema.UInt8TypeMaxInt) > 0 {
-			panic(OverflowError{})
+			panic(newConversionOverflowError(value, PrimitiveStaticTypeUInt8))
 		} else if v.Sign() < 0 {
-			panic(UnderflowError{})
+			panic(newConversionUnderflowError(value, PrimitiveStaticTypeUInt8))
 		}
 		res = uint8(v.Int64())
 
 	case NumberValue:
 		v := value.ToInt()
 		if v > math.MaxUint8 {
-			panic(OverflowError{})
+			panic(newConversionOverflowError(value, PrimitiveStaticTypeUInt8))
 		} else if v < 0 {
-			panic(UnderflowError{})
+			panic(newConversionUnderflowError(value, PrimitiveStaticTypeUInt8))
 		}
 		res = uint8(v)
 
@@ -4951,7 +5078,7 @@ func (v UInt16Value) Plus(other NumberValue) NumberValue {
 	sum := v + other.(UInt16Value)
 	// INT30-C
 	if sum < v {
-		panic(OverflowError{})
+		panic(newArithmeticOverflowError(ast.OperationPlus, v, other))
 	}
 	return sum
 }
@@ -4969,7 +5096,7 @@ func (v UInt16Value) Minus(other NumberValue) NumberValue {
 	diff := v - other.(UInt16Value)
 	// INT30-C
 	if diff > v {
-		panic(UnderflowError{})
+		panic(newArithmeticUnderflowError(ast.OperationMinus, v, other))
 	}
 	return diff
 }
@@ -4995,7 +5122,7 @@ func (v UInt16Value) Mul(other NumberValue) NumberValue {
 	o := other.(UInt16Value)
 	// INT30-C
 	if (v > 0) && (o > 0) && (v > (math.MaxUint16 / o)) {
-		panic(OverflowError{})
+		panic(newArithmeticOverflowError(ast.OperationMul, v, o))
 	}
 	return v * o
 }
@@ -5058,18 +5185,18 @@ func ConvertUInt16(value Value) UInt16Value {
 	case BigNumberValue:
 		v := value.ToBigInt()
 		if v.Cmp(sema.UInt16TypeMaxInt) > 0 {
-			panic(OverflowError{})
+			panic(newConversionOverflowError(value, PrimitiveStaticTypeUInt16))
 		} else if v.Sign() < 0 {
-			panic(UnderflowError{})
+			panic(newConversionUnderflowError(value, PrimitiveStaticTypeUInt16))
 		}
 		res = uint16(v.Int64())
 
 	case NumberValue:
 		v := value.ToInt()
 		if v > math.MaxUint16 {
-			panic(OverflowError{})
+			panic(newConversionOverflowError(value, PrimitiveStaticTypeUInt16))
 		} else if v < 0 {
-			panic(UnderflowError{})
+			panic(newConversionUnderflowError(value, PrimitiveStaticTypeUInt16))
 		}
 		res = uint16(v)
 
@@ -5236,7 +5363,7 @@ func (v UInt32Value) Plus(other NumberValue) NumberValue {
 	sum := v + other.(UInt32Value)
 	// INT30-C
 	if sum < v {
-		panic(OverflowError{})
+		panic(newArithmeticOverflowError(ast.OperationPlus, v, other))
 	}
 	return sum
 }
@@ -5254,7 +5381,7 @@ func (v UInt32Value) Minus(other NumberValue) NumberValue {
 	diff := v - other.(UInt32Value)
 	// INT30-C
 	if diff > v {
-		panic(UnderflowError{})
+		panic(newArithmeticUnderflowError(ast.OperationMinus, v, other))
 	}
 	return diff
 }
@@ -5279,7 +5406,7 @@ func (v UInt32Value) Mod(other NumberValue) NumberValue {
 func (v UInt32Value) Mul(other NumberValue) NumberValue {
 	o := other.(UInt32Value)
 	if (v > 0) && (o > 0) && (v > (math.MaxUint32 / o)) {
-		panic(OverflowError{})
+		panic(newArithmeticOverflowError(ast.OperationMul, v, o))
 	}
 	return v * o
 }
@@ -5342,18 +5469,18 @@ func ConvertUInt32(value Value) UInt32Value {
 	case BigNumberValue:
 		v := value.ToBigInt()
 		if v.Cmp(sema.UInt32TypeMaxInt) > 0 {
-			panic(OverflowError{})
+			panic(newConversionOverflowError(value, PrimitiveStaticTypeUInt32))
 		} else if v.Sign() < 0 {
-			panic(UnderflowError{})
+			panic(newConversionUnderflowError(value, PrimitiveStaticTypeUInt32))
 		}
 		res = uint32(v.Int64())
 
 	case NumberValue:
 		v := value.ToInt()
 		if v > math.MaxUint32 {
-			panic(OverflowError{})
+			panic(newConversionOverflowError(value, PrimitiveStaticTypeUInt32))
 		} else if v < 0 {
-			panic(UnderflowError{})
+			panic(newConversionUnderflowError(value, PrimitiveStaticTypeUInt32))
 		}
 		res = uint32(v)
 
@@ -5517,7 +5644,7 @@ func (v UInt64Value) RecursiveString(_ SeenReferences) string {
 
 func (v UInt64Value) ToInt() int {
 	if v > math.MaxInt64 {
-		panic(OverflowError{})
+		panic(newConversionOverflowError(v, PrimitiveStaticTypeInt64))
 	}
 	return int(v)
 }
@@ -5537,18 +5664,18 @@ func (v UInt64Value) Negate() NumberValue {
 	panic(errors.NewUnreachableError())
 }
 
-func safeAddUint64(a, b uint64) uint64 {
+func safeAddUint64(left, right NumberValue, a, b uint64) uint64 {
 	sum := a + b
 	// INT30-C
 	if sum < a {
-		panic(OverflowError{})
+		panic(newArithmeticOverflowError(ast.OperationPlus, left, right))
 	}
 	return sum
 }
 
 func (v UInt64Value) Plus(other NumberValue) NumberValue {
 	o := other.(UInt64Value)
-	return UInt64Value(safeAddUint64(uint64(v), uint64(o)))
+	return UInt64Value(safeAddUint64(v, o, uint64(v), uint64(o)))
 }
 
 func (v UInt64Value) SaturatingPlus(other NumberValue) NumberValue {
@@ -5564,7 +5691,7 @@ func (v UInt64Value) Minus(other NumberValue) NumberValue {
 	diff := v - other.(UInt64Value)
 	// INT30-C
 	if diff > v {
-		panic(UnderflowError{})
+		panic(newArithmeticUnderflowError(ast.OperationMinus, v, other))
 	}
 	return diff
 }
@@ -5589,7 +5716,7 @@ func (v UInt64Value) Mod(other NumberValue) NumberValue {
 func (v UInt64Value) Mul(other NumberValue) NumberValue {
 	o := other.(UInt64Value)
 	if (v > 0) && (o > 0) && (v > (math.MaxUint64 / o)) {
-		panic(OverflowError{})
+		panic(newArithmeticOverflowError(ast.OperationMul, v, o))
 	}
 	return v * o
 }
@@ -5652,16 +5779,16 @@ func ConvertUInt64(value Value) UInt64Value {
 	case BigNumberValue:
 		v := value.ToBigInt()
 		if v.Cmp(sema.UInt64TypeMaxInt) > 0 {
-			panic(OverflowError{})
+			panic(newConversionOverflowError(value, PrimitiveStaticTypeUInt64))
 		} else if v.Sign() < 0 {
-			panic(UnderflowError{})
+			panic(newConversionUnderflowError(value, PrimitiveStaticTypeUInt64))
 		}
 		res = uint64(v.Int64())
 
 	case NumberValue:
 		v := value.ToInt()
 		if v < 0 {
-			panic(UnderflowError{})
+			panic(newConversionUnderflowError(value, PrimitiveStaticTypeUInt64))
 		}
 		res = uint64(v)
 
@@ -5820,7 +5947,7 @@ func (UInt128Value) StaticType() StaticType {
 
 func (v UInt128Value) ToInt() int {
 	if !v.BigInt.IsInt64() {
-		panic(OverflowError{})
+		panic(newConversionOverflowError(v, PrimitiveStaticTypeInt64))
 	}
 	return int(v.BigInt.Int64())
 }
@@ -5855,7 +5982,7 @@ func (v UInt128Value) Plus(other NumberValue) NumberValue {
 	//  }
 	//
 	if sum.Cmp(sema.UInt128TypeMaxIntBig) > 0 {
-		panic(OverflowError{})
+		panic(newArithmeticOverflowError(ast.OperationPlus, v, other))
 	}
 	return UInt128Value{sum}
 }
@@ -5893,7 +6020,7 @@ func (v UInt128Value) Minus(other NumberValue) NumberValue {
 	//   }
 	//
 	if diff.Cmp(sema.UInt128TypeMinIntBig) < 0 {
-		panic(UnderflowError{})
+		panic(newArithmeticUnderflowError(ast.OperationMinus, v, other))
 	}
 	return UInt128Value{diff}
 }
@@ -5932,7 +6059,7 @@ func (v UInt128Value) Mul(other NumberValue) NumberValue {
 	res := new(big.Int)
 	res.Mul(v.BigInt, o.BigInt)
 	if res.Cmp(sema.UInt128TypeMaxIntBig) > 0 {
-		panic(OverflowError{})
+		panic(newArithmeticOverflowError(ast.OperationMul, v, o))
 	}
 	return UInt128Value{res}
 }
@@ -6013,9 +6140,9 @@ func ConvertUInt128(value Value) UInt128Value {
 	}
 
 	if v.Cmp(sema.UInt128TypeMaxIntBig) > 0 {
-		panic(OverflowError{})
+		panic(newConversionOverflowError(value, PrimitiveStaticTypeUInt128))
 	} else if v.Sign() < 0 {
-		panic(UnderflowError{})
+		panic(newConversionUnderflowError(value, PrimitiveStaticTypeUInt128))
 	}
 
 	return NewUInt128ValueFromBigInt(v)
@@ -6046,10 +6173,10 @@ func (v UInt128Value) BitwiseLeftShift(other IntegerValue) IntegerValue {
 	o := other.(UInt128Value)
 	res := new(big.Int)
 	if o.BigInt.Sign() < 0 {
-		panic(UnderflowError{})
+		panic(newArithmeticUnderflowError(ast.OperationBitwiseLeftShift, v, o))
 	}
 	if !o.BigInt.IsUint64() {
-		panic(OverflowError{})
+		panic(newArithmeticOverflowError(ast.OperationBitwiseLeftShift, v, o))
 	}
 	res.Lsh(v.BigInt, uint(o.BigInt.Uint64()))
 	return UInt128Value{res}
@@ -6059,10 +6186,10 @@ func (v UInt128Value) BitwiseRightShift(other IntegerValue) IntegerValue {
 	o := other.(UInt128Value)
 	res := new(big.Int)
 	if o.BigInt.Sign() < 0 {
-		panic(UnderflowError{})
+		panic(newArithmeticUnderflowError(ast.OperationBitwiseRightShift, v, o))
 	}
 	if !o.BigInt.IsUint64() {
-		panic(OverflowError{})
+		panic(newArithmeticOverflowError(ast.OperationBitwiseRightShift, v, o))
 	}
 	res.Rsh(v.BigInt, uint(o.BigInt.Uint64()))
 	return UInt128Value{res}
@@ -6190,7 +6317,7 @@ func (UInt256Value) StaticType() StaticType {
 
 func (v UInt256Value) ToInt() int {
 	if !v.BigInt.IsInt64() {
-		panic(OverflowError{})
+		panic(newConversionOverflowError(v, PrimitiveStaticTypeInt64))
 	}
 
 	return int(v.BigInt.Int64())
@@ -6226,7 +6353,7 @@ func (v UInt256Value) Plus(other NumberValue) NumberValue {
 	//  }
 	//
 	if sum.Cmp(sema.UInt256TypeMaxIntBig) > 0 {
-		panic(OverflowError{})
+		panic(newArithmeticOverflowError(ast.OperationPlus, v, other))
 	}
 	return UInt256Value{sum}
 }
@@ -6264,7 +6391,7 @@ func (v UInt256Value) Minus(other NumberValue) NumberValue {
 	//   }
 	//
 	if diff.Cmp(sema.UInt256TypeMinIntBig) < 0 {
-		panic(UnderflowError{})
+		panic(newArithmeticUnderflowError(ast.OperationMinus, v, other))
 	}
 	return UInt256Value{diff}
 }
@@ -6303,7 +6430,7 @@ func (v UInt256Value) Mul(other NumberValue) NumberValue {
 	res := new(big.Int)
 	res.Mul(v.BigInt, o.BigInt)
 	if res.Cmp(sema.UInt256TypeMaxIntBig) > 0 {
-		panic(OverflowError{})
+		panic(newArithmeticOverflowError(ast.OperationMul, v, o))
 	}
 	return UInt256Value{res}
 }
@@ -6384,9 +6511,9 @@ func ConvertUInt256(value Value) UInt256Value {
 	}
 
 	if v.Cmp(sema.UInt256TypeMaxIntBig) > 0 {
-		panic(OverflowError{})
+		panic(newConversionOverflowError(value, PrimitiveStaticTypeUInt256))
 	} else if v.Sign() < 0 {
-		panic(UnderflowError{})
+		panic(newConversionUnderflowError(value, PrimitiveStaticTypeUInt256))
 	}
 
 	return NewUInt256ValueFromBigInt(v)
@@ -6417,10 +6544,10 @@ func (v UInt256Value) BitwiseLeftShift(other IntegerValue) IntegerValue {
 	o := other.(UInt256Value)
 	res := new(big.Int)
 	if o.BigInt.Sign() < 0 {
-		panic(UnderflowError{})
+		panic(newArithmeticUnderflowError(ast.OperationBitwiseLeftShift, v, o))
 	}
 	if !o.BigInt.IsUint64() {
-		panic(OverflowError{})
+		panic(newArithmeticOverflowError(ast.OperationBitwiseLeftShift, v, o))
 	}
 	res.Lsh(v.BigInt, uint(o.BigInt.Uint64()))
 	return UInt256Value{res}
@@ -6430,10 +6557,10 @@ func (v UInt256Value) BitwiseRightShift(other IntegerValue) IntegerValue {
 	o := other.(UInt256Value)
 	res := new(big.Int)
 	if o.BigInt.Sign() < 0 {
-		panic(UnderflowError{})
+		panic(newArithmeticUnderflowError(ast.OperationBitwiseRightShift, v, o))
 	}
 	if !o.BigInt.IsUint64() {
-		panic(OverflowError{})
+		panic(newArithmeticOverflowError(ast.OperationBitwiseRightShift, v, o))
 	}
 	res.Rsh(v.BigInt, uint(o.BigInt.Uint64()))
 	return UInt256Value{res}
@@ -7252,7 +7379,7 @@ func (v Word64Value) RecursiveString(_ SeenReferences) string {
 
 func (v Word64Value) ToInt() int {
 	if v > math.MaxInt64 {
-		panic(OverflowError{})
+		panic(newConversionOverflowError(v, PrimitiveStaticTypeInt64))
 	}
 	return int(v)
 }
@@ -7463,11 +7590,11 @@ const Fix64MaxValue = math.MaxInt64
 func NewFix64ValueWithInteger(integer int64) Fix64Value {
 
 	if integer < sema.Fix64TypeMinInt {
-		panic(UnderflowError{})
+		panic(newConversionUnderflowError(Int64Value(integer), PrimitiveStaticTypeFix64))
 	}
 
 	if integer > sema.Fix64TypeMaxInt {
-		panic(OverflowError{})
+		panic(newConversionOverflowError(Int64Value(integer), PrimitiveStaticTypeFix64))
 	}
 
 	return Fix64Value(integer * sema.Fix64Factor)
@@ -7515,14 +7642,14 @@ func (v Fix64Value) ToInt() int {
 func (v Fix64Value) Negate() NumberValue {
 	// INT32-C
 	if v == math.MinInt64 {
-		panic(OverflowError{})
+		panic(newArithmeticOverflowError(ast.OperationNegate, v, nil))
 	}
 	return -v
 }
 
 func (v Fix64Value) Plus(other NumberValue) NumberValue {
 	o := other.(Fix64Value)
-	return Fix64Value(safeAddInt64(int64(v), int64(o)))
+	return Fix64Value(safeAddInt64(v, o, int64(v), int64(o)))
 }
 
 func (v Fix64Value) SaturatingPlus(other NumberValue) NumberValue {
@@ -7540,9 +7667,9 @@ func (v Fix64Value) Minus(other NumberValue) NumberValue {
 	o := other.(Fix64Value)
 	// INT32-C
 	if (o > 0) && (v < (math.MinInt64 + o)) {
-		panic(OverflowError{})
+		panic(newArithmeticUnderflowError(ast.OperationMinus, v, o))
 	} else if (o < 0) && (v > (math.MaxInt64 + o)) {
-		panic(UnderflowError{})
+		panic(newArithmeticOverflowError(ast.OperationMinus, v, o))
 	}
 	return v - o
 }
@@ -7571,9 +7698,9 @@ func (v Fix64Value) Mul(other NumberValue) NumberValue {
 	result.Div(result, sema.Fix64FactorBig)
 
 	if result.Cmp(minInt64Big) < 0 {
-		panic(UnderflowError{})
+		panic(newArithmeticUnderflowError(ast.OperationMul, v, o))
 	} else if result.Cmp(maxInt64Big) > 0 {
-		panic(OverflowError{})
+		panic(newArithmeticOverflowError(ast.OperationMul, v, o))
 	}
 
 	return Fix64Value(result.Int64())
@@ -7607,9 +7734,9 @@ func (v Fix64Value) Div(other NumberValue) NumberValue {
 	result.Div(result, b)
 
 	if result.Cmp(minInt64Big) < 0 {
-		panic(UnderflowError{})
+		panic(newArithmeticUnderflowError(ast.OperationDiv, v, o))
 	} else if result.Cmp(maxInt64Big) > 0 {
-		panic(OverflowError{})
+		panic(newArithmeticOverflowError(ast.OperationDiv, v, o))
 	}
 
 	return Fix64Value(result.Int64())
@@ -7678,7 +7805,7 @@ func ConvertFix64(value Value) Fix64Value {
 
 	case UFix64Value:
 		if value > Fix64MaxValue {
-			panic(OverflowError{})
+			panic(newConversionOverflowError(value, PrimitiveStaticTypeFix64))
 		}
 		return Fix64Value(value)
 
@@ -7690,7 +7817,7 @@ func ConvertFix64(value Value) Fix64Value {
 		// allows us to call `v.Int64()` safely.
 
 		if !v.IsInt64() {
-			panic(OverflowError{})
+			panic(newConversionOverflowError(value, PrimitiveStaticTypeFix64))
 		}
 
 		// Now check that the integer value fits the range of Fix64
@@ -7793,7 +7920,7 @@ const UFix64MaxValue = math.MaxUint64
 
 func NewUFix64ValueWithInteger(integer uint64) UFix64Value {
 	if integer > sema.UFix64TypeMaxInt {
-		panic(OverflowError{})
+		panic(newConversionOverflowError(UInt64Value(integer), PrimitiveStaticTypeUFix64))
 	}
 
 	return UFix64Value(integer * sema.Fix64Factor)
@@ -7844,7 +7971,7 @@ func (v UFix64Value) Negate() NumberValue {
 
 func (v UFix64Value) Plus(other NumberValue) NumberValue {
 	o := other.(UFix64Value)
-	return UFix64Value(safeAddUint64(uint64(v), uint64(o)))
+	return UFix64Value(safeAddUint64(v, o, uint64(v), uint64(o)))
 }
 
 func (v UFix64Value) SaturatingPlus(other NumberValue) NumberValue {
@@ -7861,7 +7988,7 @@ func (v UFix64Value) Minus(other NumberValue) NumberValue {
 	diff := v - other.(UFix64Value)
 	// INT30-C
 	if diff > v {
-		panic(UnderflowError{})
+		panic(newArithmeticUnderflowError(ast.OperationMinus, v, other))
 	}
 	return diff
 }
@@ -7885,7 +8012,7 @@ func (v UFix64Value) Mul(other NumberValue) NumberValue {
 	result.Div(result, sema.Fix64FactorBig)
 
 	if !result.IsUint64() {
-		panic(OverflowError{})
+		panic(newArithmeticOverflowError(ast.OperationMul, v, o))
 	}
 
 	return UFix64Value(result.Uint64())
@@ -7968,7 +8095,7 @@ func ConvertUFix64(value Value) UFix64Value {
 
 	case Fix64Value:
 		if value < 0 {
-			panic(UnderflowError{})
+			panic(newConversionUnderflowError(value, PrimitiveStaticTypeUFix64))
 		}
 		return UFix64Value(value)
 
@@ -7976,7 +8103,7 @@ func ConvertUFix64(value Value) UFix64Value {
 		v := value.ToBigInt()
 
 		if v.Sign() < 0 {
-			panic(UnderflowError{})
+			panic(newConversionUnderflowError(value, PrimitiveStaticTypeUFix64))
 		}
 
 		// First, check if the value is at least in the uint64 range.
@@ -7984,7 +8111,7 @@ func ConvertUFix64(value Value) UFix64Value {
 		// allows us to call `v.UInt64()` safely.
 
 		if !v.IsUint64() {
-			panic(OverflowError{})
+			panic(newConversionOverflowError(value, PrimitiveStaticTypeUFix64))
 		}
 
 		// Now check that the integer value fits the range of UFix64
@@ -7993,7 +8120,7 @@ func ConvertUFix64(value Value) UFix64Value {
 	case NumberValue:
 		v := value.ToInt()
 		if v < 0 {
-			panic(UnderflowError{})
+			panic(newConversionUnderflowError(value, PrimitiveStaticTypeUFix64))
 		}
 		// Check that the integer value fits the range of UFix64
 		return NewUFix64ValueWithInteger(uint64(v))
@@ -8099,6 +8226,9 @@ type CompositeValue struct {
 	typeID              common.TypeID
 	staticType          StaticType
 	dynamicType         DynamicType
+	// storedDepth is the depth of the composite in the stored value it is part of,
+	// or zero if the composite is not stored, or its depth is unknown (see setStoredValueDepth)
+	storedDepth int
 }
 
 type ComputedField func(*Interpreter, func() LocationRange) Value
@@ -8236,6 +8366,19 @@ func (v *CompositeValue) Destroy(interpreter *Interpreter, getLocationRange func
 		destructor.invoke(invocation)
 	}
 
+	if v.Kind == common.CompositeKindResource &&
+		interpreter.onResourceDestroyed != nil {
+
+		var uuid uint64
+		if uuidValue, ok := v.GetField(interpreter, getLocationRange, sema.ResourceUUIDFieldName).(UInt64Value); ok {
+			uuid = uint64(uuidValue)
+		}
+
+		interpreter.onResourceDestroyed(interpreter, v.TypeID(), uuid)
+	}
+
+	interpreter.recordResourceMove(v, ResourceMoveKindDestroy, "", getLocationRange)
+
 	v.isDestroyed = true
 }
 
@@ -8258,7 +8401,9 @@ func (v *CompositeValue) GetMember(interpreter *Interpreter, getLocationRange fu
 		}
 	}
 	if storable != nil {
-		return StoredValue(storable, interpreter.Storage)
+		value := StoredValue(storable, interpreter.Storage)
+		setNestedStoredValueDepth(value, v.storedDepth)
+		return value
 	}
 
 	if v.NestedVariables != nil {
@@ -8351,6 +8496,8 @@ func (v *CompositeValue) RemoveMember(
 	name string,
 ) Value {
 
+	interpreter.checkStorageMutation(v.StorageID().Address, "field removal", getLocationRange)
+
 	// No need to clean up storable for passed-in key value,
 	// as atree never calls Storable()
 	existingKeyStorable, existingValueStorable, err := v.dictionary.Remove(
@@ -8392,6 +8539,10 @@ func (v *CompositeValue) SetMember(
 ) {
 	address := v.StorageID().Address
 
+	interpreter.checkStorageMutation(address, "field write", getLocationRange)
+
+	defer interpreter.enterStoredContainer(v.storedDepth)()
+
 	value = value.Transfer(
 		interpreter,
 		getLocationRange,
@@ -8484,7 +8635,9 @@ func (v *CompositeValue) GetField(_ *Interpreter, _ func() LocationRange, name s
 		panic(ExternalError{err})
 	}
 
-	return StoredValue(storable, v.dictionary.Storage)
+	value := StoredValue(storable, v.dictionary.Storage)
+	setNestedStoredValueDepth(value, v.storedDepth)
+	return value
 }
 
 func (v *CompositeValue) Equal(interpreter *Interpreter, getLocationRange func() LocationRange, other Value) bool {
@@ -8671,11 +8824,16 @@ func (v *CompositeValue) Transfer(
 ) Value {
 
 	dictionary := v.dictionary
+	storedDepth := v.storedDepth
 
 	needsStoreTo := v.NeedsStoreTo(address)
 	isResourceKinded := v.IsResourceKinded(interpreter)
 
 	if needsStoreTo || !isResourceKinded {
+		defer interpreter.enterStoredValue(address, getLocationRange)()
+
+		storedDepth = interpreter.transferredValueDepth(address)
+
 		iterator, err := v.dictionary.Iterator()
 		if err != nil {
 			panic(ExternalError{err})
@@ -8705,6 +8863,8 @@ func (v *CompositeValue) Transfer(
 				value := MustConvertStoredValue(atreeValue).
 					Transfer(interpreter, getLocationRange, address, remove, nil)
 
+				interpreter.checkStorable(value, address, getLocationRange)
+
 				return atreeKey, value, nil
 			},
 		)
@@ -8728,6 +8888,7 @@ func (v *CompositeValue) Transfer(
 
 	if isResourceKinded {
 		v.dictionary = dictionary
+		v.storedDepth = storedDepth
 		return v
 	} else {
 		return &CompositeValue{
@@ -8745,6 +8906,7 @@ func (v *CompositeValue) Transfer(
 			typeID:              v.typeID,
 			staticType:          v.staticType,
 			dynamicType:         v.dynamicType,
+			storedDepth:         storedDepth,
 		}
 	}
 }
@@ -8774,10 +8936,12 @@ func (v *CompositeValue) Clone(interpreter *Interpreter) Value {
 				return nil, nil, nil
 			}
 
-			key := MustConvertStoredValue(atreeKey).Clone(interpreter)
+			// NOTE: key is stringAtreeValue
+			// and does not need to be converted or copied
+
 			value := MustConvertStoredValue(atreeValue).Clone(interpreter)
 
-			return key, value, nil
+			return atreeKey, value, nil
 		},
 	)
 	if err != nil {
@@ -8917,6 +9081,9 @@ type DictionaryValue struct {
 	isResourceKinded *bool
 	dictionary       *atree.OrderedMap
 	isDestroyed      bool
+	// storedDepth is the depth of the dictionary in the stored value it is part of,
+	// or zero if the dictionary is not stored, or its depth is unknown (see setStoredValueDepth)
+	storedDepth int
 }
 
 func NewDictionaryValue(
@@ -9099,6 +9266,7 @@ func (v *DictionaryValue) Get(
 
 	storage := v.dictionary.Storage
 	value := StoredValue(storable, storage)
+	setNestedStoredValueDepth(value, v.storedDepth)
 	return value, true
 }
 
@@ -9266,6 +9434,31 @@ func (v *DictionaryValue) GetMember(
 			),
 		)
 
+	case "merging":
+		return NewHostFunctionValue(
+			func(invocation Invocation) Value {
+				other, ok := invocation.Arguments[0].(*DictionaryValue)
+				if !ok {
+					panic(errors.NewUnreachableError())
+				}
+
+				resolve, ok := invocation.Arguments[1].(FunctionValue)
+				if !ok {
+					panic(errors.NewUnreachableError())
+				}
+
+				return v.Merging(
+					invocation.Interpreter,
+					invocation.GetLocationRange,
+					other,
+					resolve,
+				)
+			},
+			sema.DictionaryMergingFunctionType(
+				v.SemaType(interpreter),
+			),
+		)
+
 	case "containsKey":
 		return NewHostFunctionValue(
 			func(invocation Invocation) Value {
@@ -9313,6 +9506,8 @@ func (v *DictionaryValue) Remove(
 	keyValue Value,
 ) OptionalValue {
 
+	interpreter.checkStorageMutation(v.dictionary.Address(), "dictionary removal", getLocationRange)
+
 	valueComparator := newValueComparator(interpreter, getLocationRange)
 	hashInputProvider := newHashInputProvider(interpreter, getLocationRange)
 
@@ -9367,11 +9562,15 @@ func (v *DictionaryValue) Insert(
 	keyValue, value Value,
 ) OptionalValue {
 
+	interpreter.checkStorageMutation(v.dictionary.Address(), "dictionary insertion", getLocationRange)
+
 	interpreter.checkContainerMutation(v.Type.KeyType, keyValue, getLocationRange)
 	interpreter.checkContainerMutation(v.Type.ValueType, value, getLocationRange)
 
 	address := v.dictionary.Address()
 
+	defer interpreter.enterStoredContainer(v.storedDepth)()
+
 	keyValue = keyValue.Transfer(
 		interpreter,
 		getLocationRange,
@@ -9420,6 +9619,76 @@ func (v *DictionaryValue) Insert(
 	return NewSomeValueNonCopying(existingValue)
 }
 
+// Merging returns a new dictionary which contains the entries of this dictionary
+// and the entries of the other dictionary.
+//
+// If both dictionaries contain the same key, the resolve function is called
+// with the existing value and the value of the other dictionary,
+// and its result is used as the value for the key.
+//
+func (v *DictionaryValue) Merging(
+	interpreter *Interpreter,
+	getLocationRange func() LocationRange,
+	other *DictionaryValue,
+	resolve FunctionValue,
+) *DictionaryValue {
+
+	result := NewDictionaryValue(interpreter, v.Type)
+
+	// NOTE: Insert transfers and removes the given values,
+	// so the entries of the receiver must be copied first,
+	// otherwise nested containers of the receiver would be removed
+
+	v.Iterate(func(key, value Value) (resume bool) {
+		result.Insert(
+			interpreter,
+			getLocationRange,
+			key.Transfer(interpreter, getLocationRange, atree.Address{}, false, nil),
+			value.Transfer(interpreter, getLocationRange, atree.Address{}, false, nil),
+		)
+		return true
+	})
+
+	// Gather the entries of the other dictionary first,
+	// as the resolve function might have side effects
+
+	var otherEntries []DictionaryEntryValues
+
+	other.Iterate(func(key, value Value) (resume bool) {
+		otherEntries = append(
+			otherEntries,
+			DictionaryEntryValues{
+				Key:   key.Transfer(interpreter, getLocationRange, atree.Address{}, false, nil),
+				Value: value.Transfer(interpreter, getLocationRange, atree.Address{}, false, nil),
+			},
+		)
+		return true
+	})
+
+	valueType := v.SemaType(interpreter).ValueType
+
+	for _, entry := range otherEntries {
+		value := entry.Value
+
+		existingValue, ok := result.Get(interpreter, getLocationRange, entry.Key)
+		if ok {
+			value = resolve.invoke(Invocation{
+				Arguments: []Value{
+					existingValue.Transfer(interpreter, getLocationRange, atree.Address{}, false, nil),
+					value,
+				},
+				ArgumentTypes:    []sema.Type{valueType, valueType},
+				GetLocationRange: getLocationRange,
+				Interpreter:      interpreter,
+			})
+		}
+
+		result.Insert(interpreter, getLocationRange, entry.Key, value)
+	}
+
+	return result
+}
+
 type DictionaryEntryValues struct {
 	Key   Value
 	Value Value
@@ -9555,11 +9824,15 @@ func (v *DictionaryValue) Transfer(
 	}
 
 	dictionary := v.dictionary
+	storedDepth := v.storedDepth
 
 	needsStoreTo := v.NeedsStoreTo(address)
 	isResourceKinded := v.IsResourceKinded(interpreter)
 
 	if needsStoreTo || !isResourceKinded {
+		defer interpreter.enterStoredValue(address, getLocationRange)()
+
+		storedDepth = interpreter.transferredValueDepth(address)
 
 		valueComparator := newValueComparator(interpreter, getLocationRange)
 		hashInputProvider := newHashInputProvider(interpreter, getLocationRange)
@@ -9593,6 +9866,8 @@ func (v *DictionaryValue) Transfer(
 				value := MustConvertStoredValue(atreeValue).
 					Transfer(interpreter, getLocationRange, address, remove, nil)
 
+				interpreter.checkStorable(value, address, getLocationRange)
+
 				return key, value, nil
 			},
 		)
@@ -9616,6 +9891,7 @@ func (v *DictionaryValue) Transfer(
 
 	if isResourceKinded {
 		v.dictionary = dictionary
+		v.storedDepth = storedDepth
 		return v
 	} else {
 		return &DictionaryValue{
@@ -9624,6 +9900,7 @@ func (v *DictionaryValue) Transfer(
 			isResourceKinded: v.isResourceKinded,
 			dictionary:       dictionary,
 			isDestroyed:      v.isDestroyed,
+			storedDepth:      storedDepth,
 		}
 	}
 }
@@ -9794,10 +10071,30 @@ var nilValueMapFunction = NewHostFunctionValue(
 	},
 )
 
+var nilValueOrElseFunction = NewHostFunctionValue(
+	func(invocation Invocation) Value {
+		computeFunction := invocation.Arguments[0].(FunctionValue)
+
+		computeInvocation := Invocation{
+			GetLocationRange: invocation.GetLocationRange,
+			Interpreter:      invocation.Interpreter,
+		}
+
+		return computeFunction.invoke(computeInvocation)
+	},
+	&sema.FunctionType{
+		ReturnTypeAnnotation: sema.NewTypeAnnotation(
+			sema.NeverType,
+		),
+	},
+)
+
 func (v NilValue) GetMember(_ *Interpreter, _ func() LocationRange, name string) Value {
 	switch name {
 	case "map":
 		return nilValueMapFunction
+	case "orElse":
+		return nilValueOrElseFunction
 	}
 
 	return nil
@@ -9967,6 +10264,15 @@ func (v *SomeValue) GetMember(inter *Interpreter, _ func() LocationRange, name s
 			},
 			sema.OptionalTypeMapFunctionType(inter.MustConvertStaticToSemaType(v.Value.StaticType())),
 		)
+
+	case "orElse":
+		return NewHostFunctionValue(
+			func(_ Invocation) Value {
+				// The function is only called if the optional is nil
+				return v.Value
+			},
+			sema.OptionalTypeOrElseFunctionType(inter.MustConvertStaticToSemaType(v.Value.StaticType())),
+		)
 	}
 
 	return nil
@@ -10059,9 +10365,12 @@ func (v *SomeValue) Transfer(
 	isResourceKinded := v.IsResourceKinded(interpreter)
 
 	if needsStoreTo || !isResourceKinded {
+		defer interpreter.enterStoredValue(address, getLocationRange)()
 
 		innerValue = v.Value.Transfer(interpreter, getLocationRange, address, remove, nil)
 
+		interpreter.checkStorable(innerValue, address, getLocationRange)
+
 		if remove {
 			interpreter.RemoveReferencedSlab(v.valueStorable)
 			interpreter.RemoveReferencedSlab(storable)
@@ -11229,7 +11538,7 @@ func (v *CapabilityValue) Walk(walkChild func(Value)) {
 func (v *CapabilityValue) DynamicType(interpreter *Interpreter, _ SeenReferences) DynamicType {
 	var borrowType *sema.ReferenceType
 	if v.BorrowType != nil {
-		borrowType = interpreter.MustConvertStaticToSemaType(v.BorrowType).(*sema.ReferenceType)
+		borrowType = interpreter.mustConvertCapabilityBorrowType(v.BorrowType, ReturnEmptyLocationRange)
 	}
 
 	return CapabilityDynamicType{
@@ -11260,22 +11569,36 @@ func (v *CapabilityValue) RecursiveString(seenReferences SeenReferences) string
 	)
 }
 
-func (v *CapabilityValue) GetMember(interpreter *Interpreter, _ func() LocationRange, name string) Value {
+func (v *CapabilityValue) GetMember(interpreter *Interpreter, getLocationRange func() LocationRange, name string) Value {
 	switch name {
 	case "borrow":
 		var borrowType *sema.ReferenceType
 		if v.BorrowType != nil {
-			borrowType = interpreter.MustConvertStaticToSemaType(v.BorrowType).(*sema.ReferenceType)
+			borrowType = interpreter.mustConvertCapabilityBorrowType(v.BorrowType, getLocationRange)
 		}
 		return interpreter.capabilityBorrowFunction(v.Address, v.Path, borrowType)
 
+	case "borrowOr":
+		var borrowType *sema.ReferenceType
+		if v.BorrowType != nil {
+			borrowType = interpreter.mustConvertCapabilityBorrowType(v.BorrowType, getLocationRange)
+		}
+		return interpreter.capabilityBorrowOrFunction(v.Address, v.Path, borrowType)
+
 	case "check":
 		var borrowType *sema.ReferenceType
 		if v.BorrowType != nil {
-			borrowType = interpreter.MustConvertStaticToSemaType(v.BorrowType).(*sema.ReferenceType)
+			borrowType = interpreter.mustConvertCapabilityBorrowType(v.BorrowType, getLocationRange)
 		}
 		return interpreter.capabilityCheckFunction(v.Address, v.Path, borrowType)
 
+	case "checkStatus":
+		var borrowType *sema.ReferenceType
+		if v.BorrowType != nil {
+			borrowType = interpreter.mustConvertCapabilityBorrowType(v.BorrowType, getLocationRange)
+		}
+		return interpreter.capabilityCheckStatusFunction(v.Address, v.Path, borrowType)
+
 	case "address":
 		return v.Address
 	}
@@ -11350,11 +11673,15 @@ func (*CapabilityValue) IsResourceKinded(_ *Interpreter) bool {
 
 func (v *CapabilityValue) Transfer(
 	interpreter *Interpreter,
-	_ func() LocationRange,
-	_ atree.Address,
+	getLocationRange func() LocationRange,
+	address atree.Address,
 	remove bool,
 	storable atree.Storable,
 ) Value {
+	if address != (atree.Address{}) && interpreter.onCapabilityStored != nil {
+		interpreter.onCapabilityStored(interpreter, getLocationRange, v)
+	}
+
 	if remove {
 		v.DeepRemove(interpreter)
 		interpreter.RemoveReferencedSlab(storable)
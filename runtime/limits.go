@@ -0,0 +1,166 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2020 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runtime
+
+import (
+	goErrors "errors"
+
+	"github.com/onflow/cadence/runtime/interpreter"
+)
+
+//go:generate go run golang.org/x/tools/cmd/stringer -type=LimitKind
+
+// LimitKind is the kind of a limit of an execution.
+//
+type LimitKind uint
+
+const (
+	LimitKindUnknown LimitKind = iota
+	LimitKindComputation
+	LimitKindCallStackDepth
+	LimitKindBorrowChainLength
+	LimitKindValueDepth
+	LimitKindStorageReads
+	LimitKindLoopIterations
+	LimitKindEvents
+)
+
+// TODO: make runtime interface function
+const callStackDepthLimit = 2000
+
+// Limits are the limits of an execution,
+// aggregated from the context and the runtime interface.
+// A limit of zero means the execution is not limited.
+//
+type Limits struct {
+	// Computation is the computation limit, provided by the runtime interface.
+	Computation uint64
+	// CallStackDepth is the maximum depth of the call stack.
+	// It is only enforced if the computation is limited.
+	CallStackDepth uint64
+	// BorrowChainLength is the maximum number of borrows a single statement may perform.
+	BorrowChainLength int
+	// ValueDepth is the maximum nesting depth of stored values.
+	ValueDepth int
+	// StorageReads is the maximum number of registers the storage may read.
+	StorageReads int
+	// LoopIterations is the maximum number of loop iterations.
+	LoopIterations uint64
+	// Events is the maximum number of emitted events.
+	Events uint64
+}
+
+// Limits returns the limits of executions with this context.
+//
+func (c Context) Limits() Limits {
+	var computationLimit uint64
+	wrapPanic(func() {
+		computationLimit = c.Interface.GetComputationLimit()
+	})
+
+	var callStackDepth uint64
+	if computationLimit > 0 {
+		callStackDepth = callStackDepthLimit
+	}
+
+	valueDepth := c.MaxValueDepth
+	if valueDepth <= 0 {
+		valueDepth = interpreter.DefaultMaxValueDepth
+	}
+
+	return Limits{
+		Computation:       computationLimit,
+		CallStackDepth:    callStackDepth,
+		BorrowChainLength: c.MaxBorrowChainLength,
+		ValueDepth:        valueDepth,
+		StorageReads:      c.MaxStorageReads,
+		LoopIterations:    c.MaxLoopIterations,
+		Events:            c.MaxEvents,
+	}
+}
+
+// LimitExceeded describes the limit which caused an execution to abort.
+//
+type LimitExceeded struct {
+	Kind  LimitKind
+	Limit uint64
+}
+
+// limitExceeded returns the limit which caused the given error, if any.
+//
+func limitExceeded(err error) *LimitExceeded {
+
+	var computationErr ComputationLimitExceededError
+	if goErrors.As(err, &computationErr) {
+		return &LimitExceeded{
+			Kind:  LimitKindComputation,
+			Limit: computationErr.Limit,
+		}
+	}
+
+	var callStackErr CallStackLimitExceededError
+	if goErrors.As(err, &callStackErr) {
+		return &LimitExceeded{
+			Kind:  LimitKindCallStackDepth,
+			Limit: callStackErr.Limit,
+		}
+	}
+
+	var borrowChainErr interpreter.BorrowChainLengthExceededError
+	if goErrors.As(err, &borrowChainErr) {
+		return &LimitExceeded{
+			Kind:  LimitKindBorrowChainLength,
+			Limit: uint64(borrowChainErr.Limit),
+		}
+	}
+
+	var valueDepthErr interpreter.ValueDepthLimitExceededError
+	if goErrors.As(err, &valueDepthErr) {
+		return &LimitExceeded{
+			Kind:  LimitKindValueDepth,
+			Limit: uint64(valueDepthErr.Limit),
+		}
+	}
+
+	var storageReadErr StorageReadLimitExceededError
+	if goErrors.As(err, &storageReadErr) {
+		return &LimitExceeded{
+			Kind:  LimitKindStorageReads,
+			Limit: uint64(storageReadErr.Limit),
+		}
+	}
+
+	var loopIterationErr interpreter.LoopIterationLimitError
+	if goErrors.As(err, &loopIterationErr) {
+		return &LimitExceeded{
+			Kind:  LimitKindLoopIterations,
+			Limit: loopIterationErr.Limit,
+		}
+	}
+
+	var eventLimitErr interpreter.EventLimitExceededError
+	if goErrors.As(err, &eventLimitErr) {
+		return &LimitExceeded{
+			Kind:  LimitKindEvents,
+			Limit: eventLimitErr.Limit,
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,172 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2020 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runtime
+
+import (
+	"encoding/binary"
+	"sort"
+
+	"github.com/onflow/atree"
+
+	"github.com/onflow/cadence/runtime/common"
+)
+
+// MultiAccountLedger is an in-memory ledger which keeps the registers of each account separately,
+// e.g. for testing transactions which are signed by, or access, multiple accounts.
+//
+// The ledger of an account can be made unavailable,
+// in which case the operations on the registers of the account fail
+// with an AccountLedgerUnavailableError, while the ledgers of all other accounts are unaffected.
+//
+// The ledger does not roll back writes: If a transaction fails while its storage is committed,
+// the registers written before the failure remain written (see Storage.Commit).
+//
+type MultiAccountLedger struct {
+	accounts map[common.Address]*accountLedger
+}
+
+type accountLedger struct {
+	registers         map[string][]byte
+	storageIndex      uint64
+	readsUnavailable  bool
+	writesUnavailable bool
+}
+
+var _ atree.Ledger = &MultiAccountLedger{}
+var _ AccountRegisterIterator = &MultiAccountLedger{}
+
+// NewMultiAccountLedger returns a new ledger without any registers.
+//
+func NewMultiAccountLedger() *MultiAccountLedger {
+	return &MultiAccountLedger{
+		accounts: map[common.Address]*accountLedger{},
+	}
+}
+
+func (l *MultiAccountLedger) account(owner []byte) *accountLedger {
+	address := common.BytesToAddress(owner)
+	account, ok := l.accounts[address]
+	if !ok {
+		account = &accountLedger{
+			registers: map[string][]byte{},
+		}
+		l.accounts[address] = account
+	}
+	return account
+}
+
+// SetUnavailable configures if the ledger of the given account is unavailable,
+// i.e. if reading and writing the registers of the account fails.
+//
+func (l *MultiAccountLedger) SetUnavailable(address common.Address, unavailable bool) {
+	account := l.account(address[:])
+	account.readsUnavailable = unavailable
+	account.writesUnavailable = unavailable
+}
+
+// SetWritesUnavailable configures if writes to the ledger of the given account are unavailable,
+// i.e. if writing registers and allocating storage indices for the account fails,
+// while reading the registers of the account still succeeds.
+//
+func (l *MultiAccountLedger) SetWritesUnavailable(address common.Address, unavailable bool) {
+	l.account(address[:]).writesUnavailable = unavailable
+}
+
+func (l *MultiAccountLedger) GetValue(owner, key []byte) ([]byte, error) {
+	account := l.account(owner)
+	if account.readsUnavailable {
+		return nil, AccountLedgerUnavailableError{
+			Address: common.BytesToAddress(owner),
+		}
+	}
+	return account.registers[string(key)], nil
+}
+
+func (l *MultiAccountLedger) SetValue(owner, key, value []byte) error {
+	account := l.account(owner)
+	if account.writesUnavailable {
+		return AccountLedgerUnavailableError{
+			Address: common.BytesToAddress(owner),
+		}
+	}
+	if len(value) == 0 {
+		delete(account.registers, string(key))
+	} else {
+		account.registers[string(key)] = value
+	}
+	return nil
+}
+
+func (l *MultiAccountLedger) ValueExists(owner, key []byte) (bool, error) {
+	value, err := l.GetValue(owner, key)
+	if err != nil {
+		return false, err
+	}
+	return len(value) > 0, nil
+}
+
+func (l *MultiAccountLedger) AllocateStorageIndex(owner []byte) (atree.StorageIndex, error) {
+	account := l.account(owner)
+	if account.writesUnavailable {
+		return atree.StorageIndex{}, AccountLedgerUnavailableError{
+			Address: common.BytesToAddress(owner),
+		}
+	}
+	account.storageIndex++
+	var result atree.StorageIndex
+	binary.BigEndian.PutUint64(result[:], account.storageIndex)
+	return result, nil
+}
+
+// ForEachAccountRegister calls the given function for each register of the given account,
+// in lexicographic order of the keys.
+//
+func (l *MultiAccountLedger) ForEachAccountRegister(owner []byte, f func(key, value []byte) error) error {
+	account := l.account(owner)
+	if account.readsUnavailable {
+		return AccountLedgerUnavailableError{
+			Address: common.BytesToAddress(owner),
+		}
+	}
+
+	keys := make([]string, 0, len(account.registers))
+	for key := range account.registers { //nolint:maprangecheck
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		err := f([]byte(key), account.registers[key])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Registers returns a copy of the registers of the given account.
+//
+func (l *MultiAccountLedger) Registers(address common.Address) map[string][]byte {
+	account := l.account(address[:])
+	registers := make(map[string][]byte, len(account.registers))
+	for key, value := range account.registers { //nolint:maprangecheck
+		registers[key] = value
+	}
+	return registers
+}
@@ -0,0 +1,285 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2020 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runtime
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/cadence/runtime/interpreter"
+)
+
+func newMultiAccountTestRuntimeInterface(
+	ledger *MultiAccountLedger,
+	signers *[]Address,
+) *testRuntimeInterface {
+	return &testRuntimeInterface{
+		storage: testLedger{
+			getValue:             ledger.GetValue,
+			setValue:             ledger.SetValue,
+			valueExists:          ledger.ValueExists,
+			allocateStorageIndex: ledger.AllocateStorageIndex,
+		},
+		getSigningAccounts: func() ([]Address, error) {
+			return *signers, nil
+		},
+	}
+}
+
+func TestRuntimeMultiAccountLedger(t *testing.T) {
+
+	t.Parallel()
+
+	address1 := common.BytesToAddress([]byte{0x1})
+	address2 := common.BytesToAddress([]byte{0x2})
+
+	t.Run("registers are partitioned by account", func(t *testing.T) {
+
+		t.Parallel()
+
+		ledger := NewMultiAccountLedger()
+
+		err := ledger.SetValue(address1[:], []byte("key"), []byte{1})
+		require.NoError(t, err)
+
+		value, err := ledger.GetValue(address1[:], []byte("key"))
+		require.NoError(t, err)
+		require.Equal(t, []byte{1}, value)
+
+		exists, err := ledger.ValueExists(address2[:], []byte("key"))
+		require.NoError(t, err)
+		require.False(t, exists)
+
+		index1, err := ledger.AllocateStorageIndex(address1[:])
+		require.NoError(t, err)
+
+		index2, err := ledger.AllocateStorageIndex(address2[:])
+		require.NoError(t, err)
+
+		require.Equal(t, index1, index2)
+	})
+
+	t.Run("unavailable account", func(t *testing.T) {
+
+		t.Parallel()
+
+		ledger := NewMultiAccountLedger()
+
+		err := ledger.SetValue(address2[:], []byte("key"), []byte{1})
+		require.NoError(t, err)
+
+		ledger.SetUnavailable(address2, true)
+
+		_, err = ledger.GetValue(address2[:], []byte("key"))
+		require.ErrorAs(t, err, &AccountLedgerUnavailableError{})
+
+		err = ledger.SetValue(address2[:], []byte("key"), []byte{2})
+		require.ErrorAs(t, err, &AccountLedgerUnavailableError{})
+
+		// The ledgers of other accounts are still available
+
+		err = ledger.SetValue(address1[:], []byte("key"), []byte{1})
+		require.NoError(t, err)
+
+		ledger.SetUnavailable(address2, false)
+
+		value, err := ledger.GetValue(address2[:], []byte("key"))
+		require.NoError(t, err)
+		require.Equal(t, []byte{1}, value)
+	})
+
+	t.Run("transfer to account with unavailable writes, failing during execution", func(t *testing.T) {
+
+		t.Parallel()
+
+		runtime := newTestInterpreterRuntime()
+
+		ledger := NewMultiAccountLedger()
+
+		var signers []Address
+
+		runtimeInterface := newMultiAccountTestRuntimeInterface(ledger, &signers)
+
+		nextTransactionLocation := newTransactionLocationGenerator()
+
+		// Store
+
+		signers = []Address{address1}
+
+		err := runtime.ExecuteTransaction(
+			Script{
+				Source: []byte(`
+                  transaction {
+                      prepare(signer: AuthAccount) {
+                          signer.save([1], to: /storage/test)
+                      }
+                  }
+                `),
+			},
+			Context{
+				Interface: runtimeInterface,
+				Location:  nextTransactionLocation(),
+			},
+		)
+		require.NoError(t, err)
+
+		registers1 := ledger.Registers(address1)
+		require.NotEmpty(t, registers1)
+
+		// Transfer, while the ledger of the second signer is unavailable for writes.
+		// Saving the array allocates a storage index for its slab,
+		// so the execution fails before anything is written
+
+		ledger.SetWritesUnavailable(address2, true)
+
+		signers = []Address{address1, address2}
+
+		// Failures of the ledger are failures of the host environment,
+		// so they are not reported as a transaction error, but abort the execution
+
+		var recovered interface{}
+
+		func() {
+			defer func() {
+				recovered = recover()
+			}()
+
+			_ = runtime.ExecuteTransaction(
+				Script{
+					Source: []byte(`
+                      transaction {
+                          prepare(signer1: AuthAccount, signer2: AuthAccount) {
+                              let value = signer1.load<[Int]>(from: /storage/test)!
+                              signer2.save(value, to: /storage/test)
+                          }
+                      }
+                    `),
+				},
+				Context{
+					Interface: runtimeInterface,
+					Location:  nextTransactionLocation(),
+				},
+			)
+		}()
+
+		require.IsType(t, interpreter.ExternalError{}, recovered)
+
+		recoveredErr, ok := recovered.(interpreter.ExternalError).Recovered.(error)
+		require.True(t, ok)
+		require.ErrorAs(t, recoveredErr, &AccountLedgerUnavailableError{})
+
+		// The state of the first signer is unchanged
+
+		require.Equal(t, registers1, ledger.Registers(address1))
+		require.Empty(t, ledger.Registers(address2))
+
+		value, err := runtime.ReadStored(
+			address1,
+			cadence.Path{
+				Domain:     "storage",
+				Identifier: "test",
+			},
+			Context{
+				Interface: runtimeInterface,
+				Location:  nextTransactionLocation(),
+			},
+		)
+		require.NoError(t, err)
+		require.Equal(t,
+			cadence.NewOptional(cadence.NewArray([]cadence.Value{cadence.NewInt(1)})),
+			value,
+		)
+	})
+	t.Run("transfer to account with unavailable writes, failing during commit", func(t *testing.T) {
+
+		t.Parallel()
+
+		runtime := newTestInterpreterRuntime()
+
+		ledger := NewMultiAccountLedger()
+
+		var signers []Address
+
+		runtimeInterface := newMultiAccountTestRuntimeInterface(ledger, &signers)
+
+		nextTransactionLocation := newTransactionLocationGenerator()
+
+		// Store
+
+		signers = []Address{address1}
+
+		err := runtime.ExecuteTransaction(
+			Script{
+				Source: []byte(`
+                  transaction {
+                      prepare(signer: AuthAccount) {
+                          signer.save(1, to: /storage/test)
+                      }
+                  }
+                `),
+			},
+			Context{
+				Interface: runtimeInterface,
+				Location:  nextTransactionLocation(),
+			},
+		)
+		require.NoError(t, err)
+
+		storageKey := interpreter.StorageKeyForPath(common.PathDomainStorage, "test")
+
+		require.Contains(t, ledger.Registers(address1), storageKey)
+
+		// Transfer, while the ledger of the second signer is unavailable for writes.
+		// The integer is stored inline, so the execution only fails when the storage is committed
+
+		ledger.SetWritesUnavailable(address2, true)
+
+		signers = []Address{address1, address2}
+
+		err = runtime.ExecuteTransaction(
+			Script{
+				Source: []byte(`
+                  transaction {
+                      prepare(signer1: AuthAccount, signer2: AuthAccount) {
+                          let value = signer1.load<Int>(from: /storage/test)!
+                          signer2.save(value, to: /storage/test)
+                      }
+                  }
+                `),
+			},
+			Context{
+				Interface: runtimeInterface,
+				Location:  nextTransactionLocation(),
+			},
+		)
+		require.Error(t, err)
+		require.ErrorAs(t, err, &AccountLedgerUnavailableError{})
+
+		require.Empty(t, ledger.Registers(address2))
+
+		// The commit is not atomic: The registers are written in order of the storage keys,
+		// so the removal from the first signer's storage was already written.
+		// The host must discard all writes of the failed transaction
+
+		require.NotContains(t, ledger.Registers(address1), storageKey)
+	})
+}
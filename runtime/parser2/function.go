@@ -165,6 +165,89 @@ func parseParameter(p *parser) *ast.Parameter {
 	}
 }
 
+func parseTypeParameterList(p *parser) (typeParameters []*ast.TypeParameter) {
+
+	// Skip the opening angle bracket
+	p.next()
+
+	expectTypeParameter := true
+
+	atEnd := false
+	for !atEnd {
+		p.skipSpaceAndComments(true)
+		switch p.current.Type {
+		case lexer.TokenIdentifier:
+			if !expectTypeParameter {
+				panic("expected comma, got start of type parameter")
+			}
+			typeParameter := parseTypeParameter(p)
+			typeParameters = append(typeParameters, typeParameter)
+			expectTypeParameter = false
+
+		case lexer.TokenComma:
+			if expectTypeParameter {
+				panic(fmt.Errorf(
+					"expected type parameter or end of type parameter list, got %s",
+					p.current.Type,
+				))
+			}
+			// Skip the comma
+			p.next()
+			expectTypeParameter = true
+
+		case lexer.TokenGreater:
+			// Skip the closing angle bracket
+			p.next()
+			atEnd = true
+
+		case lexer.TokenEOF:
+			panic(fmt.Errorf(
+				"missing %s at end of type parameter list",
+				lexer.TokenGreater,
+			))
+
+		default:
+			if expectTypeParameter {
+				panic(fmt.Errorf(
+					"expected type parameter or end of type parameter list, got %s",
+					p.current.Type,
+				))
+			} else {
+				panic(fmt.Errorf(
+					"expected comma or end of type parameter list, got %s",
+					p.current.Type,
+				))
+			}
+		}
+	}
+
+	return typeParameters
+}
+
+func parseTypeParameter(p *parser) *ast.TypeParameter {
+
+	identifier := tokenToIdentifier(p.current)
+
+	// Skip the identifier
+	p.next()
+
+	var typeBound ast.Type
+
+	p.skipSpaceAndComments(true)
+	if p.current.Is(lexer.TokenColon) {
+		// Skip the colon
+		p.next()
+		p.skipSpaceAndComments(true)
+
+		typeBound = parseType(p, lowestBindingPower)
+	}
+
+	return &ast.TypeParameter{
+		Identifier: identifier,
+		TypeBound:  typeBound,
+	}
+}
+
 func parseFunctionDeclaration(
 	p *parser,
 	functionBlockIsOptional bool,
@@ -194,12 +277,20 @@ func parseFunctionDeclaration(
 	// Skip the identifier
 	p.next()
 
+	var typeParameters []*ast.TypeParameter
+
+	p.skipSpaceAndComments(true)
+	if p.current.Is(lexer.TokenLess) {
+		typeParameters = parseTypeParameterList(p)
+	}
+
 	parameterList, returnTypeAnnotation, functionBlock :=
 		parseFunctionParameterListAndRest(p, functionBlockIsOptional)
 
 	return &ast.FunctionDeclaration{
 		Access:               access,
 		Identifier:           identifier,
+		TypeParameters:       typeParameters,
 		ParameterList:        parameterList,
 		ReturnTypeAnnotation: returnTypeAnnotation,
 		FunctionBlock:        functionBlock,
@@ -162,12 +162,20 @@ func parseFunctionDeclarationOrFunctionExpressionStatement(p *parser) ast.Statem
 
 		p.next()
 
+		var typeParameters []*ast.TypeParameter
+
+		p.skipSpaceAndComments(true)
+		if p.current.Is(lexer.TokenLess) {
+			typeParameters = parseTypeParameterList(p)
+		}
+
 		parameterList, returnTypeAnnotation, functionBlock :=
 			parseFunctionParameterListAndRest(p, false)
 
 		return &ast.FunctionDeclaration{
 			Access:               ast.AccessNotSpecified,
 			Identifier:           identifier,
+			TypeParameters:       typeParameters,
 			ParameterList:        parameterList,
 			ReturnTypeAnnotation: returnTypeAnnotation,
 			FunctionBlock:        functionBlock,
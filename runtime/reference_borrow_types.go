@@ -0,0 +1,49 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2020 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runtime
+
+import (
+	"github.com/onflow/cadence"
+	"github.com/onflow/cadence/runtime/ast"
+	"github.com/onflow/cadence/runtime/interpreter"
+	"github.com/onflow/cadence/runtime/sema"
+)
+
+// ReferenceBorrowTypes returns the borrow types of all reference expressions
+// of the given checked program, keyed by the start position of the reference expressions.
+//
+// The borrow type of a reference expression is the reference type the checker inferred,
+// e.g. `&Int` for `&x as &Int`.
+//
+func ReferenceBorrowTypes(program *interpreter.Program) map[ast.Position]cadence.Type {
+	borrowTypes := program.Elaboration.ReferenceExpressionBorrowTypes
+
+	results := map[sema.TypeID]cadence.Type{}
+
+	result := make(map[ast.Position]cadence.Type, len(borrowTypes))
+
+	// NOTE: ranging over maps is safe (deterministic),
+	// as the result is a map, keyed by position
+
+	for referenceExpression, borrowType := range borrowTypes { //nolint:maprangecheck
+		result[referenceExpression.StartPosition()] = ExportType(borrowType, results)
+	}
+
+	return result
+}
@@ -0,0 +1,70 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2020 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runtime
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/cadence/runtime/ast"
+	"github.com/onflow/cadence/runtime/common"
+)
+
+func TestRuntimeReferenceBorrowTypes(t *testing.T) {
+
+	t.Parallel()
+
+	runtime := newTestInterpreterRuntime()
+
+	runtimeInterface := &testRuntimeInterface{
+		storage: newTestLedger(nil, nil),
+	}
+
+	program, err := runtime.ParseAndCheckProgram(
+		[]byte(`
+pub fun main() {
+    let x = 1
+    let ref1 = &x as &Int
+    let ref2 = &[x] as auth &[Int]
+}
+`),
+		Context{
+			Interface: runtimeInterface,
+			Location:  common.ScriptLocation{},
+		},
+	)
+	require.NoError(t, err)
+
+	require.Equal(t,
+		map[ast.Position]cadence.Type{
+			{Offset: 47, Line: 4, Column: 15}: cadence.ReferenceType{
+				Type: cadence.IntType{},
+			},
+			{Offset: 73, Line: 5, Column: 15}: cadence.ReferenceType{
+				Authorized: true,
+				Type: cadence.VariableSizedArrayType{
+					ElementType: cadence.IntType{},
+				},
+			},
+		},
+		ReferenceBorrowTypes(program),
+	)
+}
@@ -0,0 +1,828 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2020 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runtime
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/onflow/atree"
+
+	"github.com/onflow/cadence"
+	jsoncdc "github.com/onflow/cadence/encoding/json"
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/cadence/runtime/interpreter"
+)
+
+const reproCaseVersion = 2
+
+// reproCase is the portable representation of a failing transaction,
+// which allows replaying the transaction without access to the original environment.
+//
+// It contains the transaction and its arguments, the signing accounts,
+// the registers which were read by the transaction (its read set),
+// the storage indices which were allocated, the code of the imported programs,
+// and the answers of the runtime interface to the queries of the transaction.
+//
+type reproCase struct {
+	_              struct{} `cbor:",toarray"`
+	Version        uint64
+	Source         []byte
+	Arguments      [][]byte
+	Signers        [][]byte
+	Registers      []reproCaseRegister
+	StorageIndices []reproCaseStorageIndices
+	Codes          []reproCaseCode
+	Answers        reproCaseAnswers
+}
+
+// reproCaseAnswers are the answers of the runtime interface to the queries of a transaction,
+// e.g. the generated UUIDs, or the storage used by accounts,
+// in the order in which the transaction queried them.
+//
+// The transaction is replayed deterministically,
+// so it queries the runtime interface in the same order when it is replayed.
+//
+type reproCaseAnswers struct {
+	_                 struct{} `cbor:",toarray"`
+	UUIDs             []uint64
+	BlockHeights      []uint64
+	Blocks            []reproCaseBlock
+	RandomNumbers     []uint64
+	StorageUsed       []uint64
+	StorageCapacities []uint64
+	Balances          []uint64
+	AvailableBalances []uint64
+	AccountKeys       []reproCaseAccountKey
+	DecodedArguments  [][]byte
+}
+
+type reproCaseBlock struct {
+	_         struct{} `cbor:",toarray"`
+	Exists    bool
+	Height    uint64
+	View      uint64
+	Hash      []byte
+	Timestamp int64
+}
+
+type reproCaseAccountKey struct {
+	_         struct{} `cbor:",toarray"`
+	Exists    bool
+	KeyIndex  int
+	PublicKey []byte
+	SignAlgo  SignatureAlgorithm
+	IsValid   bool
+	Validated bool
+	HashAlgo  HashAlgorithm
+	Weight    int
+	IsRevoked bool
+}
+
+func newReproCaseBlock(block Block, exists bool) reproCaseBlock {
+	return reproCaseBlock{
+		Exists:    exists,
+		Height:    block.Height,
+		View:      block.View,
+		Hash:      block.Hash[:],
+		Timestamp: block.Timestamp,
+	}
+}
+
+func (b reproCaseBlock) block() (Block, bool) {
+	block := Block{
+		Height:    b.Height,
+		View:      b.View,
+		Timestamp: b.Timestamp,
+	}
+	copy(block.Hash[:], b.Hash)
+	return block, b.Exists
+}
+
+func newReproCaseAccountKey(accountKey *AccountKey) reproCaseAccountKey {
+	if accountKey == nil {
+		return reproCaseAccountKey{}
+	}
+
+	result := reproCaseAccountKey{
+		Exists:    true,
+		KeyIndex:  accountKey.KeyIndex,
+		HashAlgo:  accountKey.HashAlgo,
+		Weight:    accountKey.Weight,
+		IsRevoked: accountKey.IsRevoked,
+	}
+
+	if accountKey.PublicKey != nil {
+		result.PublicKey = accountKey.PublicKey.PublicKey
+		result.SignAlgo = accountKey.PublicKey.SignAlgo
+		result.IsValid = accountKey.PublicKey.IsValid
+		result.Validated = accountKey.PublicKey.Validated
+	}
+
+	return result
+}
+
+func (k reproCaseAccountKey) accountKey() *AccountKey {
+	if !k.Exists {
+		return nil
+	}
+
+	return &AccountKey{
+		KeyIndex: k.KeyIndex,
+		PublicKey: &PublicKey{
+			PublicKey: k.PublicKey,
+			SignAlgo:  k.SignAlgo,
+			IsValid:   k.IsValid,
+			Validated: k.Validated,
+		},
+		HashAlgo:  k.HashAlgo,
+		Weight:    k.Weight,
+		IsRevoked: k.IsRevoked,
+	}
+}
+
+// nextReproCaseAnswer removes the next answer from the given answers and returns it,
+// or returns false if all answers were used.
+//
+func nextReproCaseAnswer(answers *[]uint64) (uint64, bool) {
+	if len(*answers) == 0 {
+		return 0, false
+	}
+
+	answer := (*answers)[0]
+	*answers = (*answers)[1:]
+	return answer, true
+}
+
+type reproCaseRegister struct {
+	_     struct{} `cbor:",toarray"`
+	Owner []byte
+	Key   []byte
+	Value []byte
+}
+
+type reproCaseStorageIndices struct {
+	_       struct{} `cbor:",toarray"`
+	Owner   []byte
+	Indices [][]byte
+}
+
+type reproCaseCode struct {
+	_          struct{} `cbor:",toarray"`
+	LocationID string
+	Code       []byte
+}
+
+type registerKey struct {
+	owner string
+	key   string
+}
+
+// reproCaseRecordingInterface is a runtime interface which records the information
+// needed to replay a transaction, i.e. the read set, the storage index allocations,
+// the signing accounts, the code of imported programs,
+// and the answers of the wrapped runtime interface to the queries of the transaction.
+//
+// Writes are kept in memory and are not passed on to the wrapped runtime interface,
+// so the ledger is not modified. Events are not passed on either.
+//
+type reproCaseRecordingInterface struct {
+	Interface
+	reads          map[registerKey][]byte
+	writes         map[registerKey][]byte
+	storageIndices map[string][][]byte
+	signers        []Address
+	codes          map[common.LocationID][]byte
+	answers        reproCaseAnswers
+}
+
+func newReproCaseRecordingInterface(runtimeInterface Interface) *reproCaseRecordingInterface {
+	return &reproCaseRecordingInterface{
+		Interface:      runtimeInterface,
+		reads:          map[registerKey][]byte{},
+		writes:         map[registerKey][]byte{},
+		storageIndices: map[string][][]byte{},
+		codes:          map[common.LocationID][]byte{},
+	}
+}
+
+func (i *reproCaseRecordingInterface) GetValue(owner, key []byte) ([]byte, error) {
+	registerKey := registerKey{
+		owner: string(owner),
+		key:   string(key),
+	}
+
+	if value, ok := i.writes[registerKey]; ok {
+		return value, nil
+	}
+
+	if value, ok := i.reads[registerKey]; ok {
+		return value, nil
+	}
+
+	value, err := i.Interface.GetValue(owner, key)
+	if err != nil {
+		return nil, err
+	}
+
+	i.reads[registerKey] = value
+
+	return value, nil
+}
+
+func (i *reproCaseRecordingInterface) SetValue(owner, key, value []byte) error {
+	registerKey := registerKey{
+		owner: string(owner),
+		key:   string(key),
+	}
+	i.writes[registerKey] = value
+	return nil
+}
+
+func (i *reproCaseRecordingInterface) ValueExists(owner, key []byte) (bool, error) {
+	value, err := i.GetValue(owner, key)
+	if err != nil {
+		return false, err
+	}
+	return len(value) > 0, nil
+}
+
+func (i *reproCaseRecordingInterface) AllocateStorageIndex(owner []byte) (atree.StorageIndex, error) {
+	storageIndex, err := i.Interface.AllocateStorageIndex(owner)
+	if err != nil {
+		return atree.StorageIndex{}, err
+	}
+
+	i.storageIndices[string(owner)] = append(
+		i.storageIndices[string(owner)],
+		storageIndex[:],
+	)
+
+	return storageIndex, nil
+}
+
+func (i *reproCaseRecordingInterface) GetSigningAccounts() ([]Address, error) {
+	signers, err := i.Interface.GetSigningAccounts()
+	if err != nil {
+		return nil, err
+	}
+
+	i.signers = signers
+
+	return signers, nil
+}
+
+func (i *reproCaseRecordingInterface) GetCode(location Location) ([]byte, error) {
+	code, err := i.Interface.GetCode(location)
+	if err != nil {
+		return nil, err
+	}
+
+	i.codes[location.ID()] = code
+
+	return code, nil
+}
+
+func (i *reproCaseRecordingInterface) GetAccountContractCode(address Address, name string) ([]byte, error) {
+	code, err := i.Interface.GetAccountContractCode(address, name)
+	if err != nil {
+		return nil, err
+	}
+
+	location := common.AddressLocation{
+		Address: address,
+		Name:    name,
+	}
+	i.codes[location.ID()] = code
+
+	return code, nil
+}
+
+// GetProgram never returns a cached program,
+// so the code of all imported programs is loaded, and recorded.
+//
+func (*reproCaseRecordingInterface) GetProgram(_ Location) (*interpreter.Program, error) {
+	return nil, nil
+}
+
+func (*reproCaseRecordingInterface) SetProgram(_ Location, _ *interpreter.Program) error {
+	return nil
+}
+
+// EmitEvent discards the given event,
+// as the transaction is only executed to record it, i.e. it must have no side effects.
+//
+func (*reproCaseRecordingInterface) EmitEvent(_ cadence.Event) error {
+	return nil
+}
+
+// EmitEvents discards the given events, see EmitEvent.
+//
+func (*reproCaseRecordingInterface) EmitEvents(_ []cadence.Event) error {
+	return nil
+}
+
+// EmitEventWithIndex discards the given event, see EmitEvent.
+//
+func (*reproCaseRecordingInterface) EmitEventWithIndex(_ cadence.Event, _ []string) error {
+	return nil
+}
+
+// EmitEventsWithIndex discards the given events, see EmitEvent.
+//
+func (*reproCaseRecordingInterface) EmitEventsWithIndex(_ []cadence.Event, _ [][]string) error {
+	return nil
+}
+
+func (i *reproCaseRecordingInterface) GenerateUUID() (uint64, error) {
+	uuid, err := i.Interface.GenerateUUID()
+	if err != nil {
+		return 0, err
+	}
+
+	i.answers.UUIDs = append(i.answers.UUIDs, uuid)
+
+	return uuid, nil
+}
+
+func (i *reproCaseRecordingInterface) GetCurrentBlockHeight() (uint64, error) {
+	height, err := i.Interface.GetCurrentBlockHeight()
+	if err != nil {
+		return 0, err
+	}
+
+	i.answers.BlockHeights = append(i.answers.BlockHeights, height)
+
+	return height, nil
+}
+
+func (i *reproCaseRecordingInterface) GetBlockAtHeight(height uint64) (Block, bool, error) {
+	block, exists, err := i.Interface.GetBlockAtHeight(height)
+	if err != nil {
+		return Block{}, false, err
+	}
+
+	i.answers.Blocks = append(i.answers.Blocks, newReproCaseBlock(block, exists))
+
+	return block, exists, nil
+}
+
+func (i *reproCaseRecordingInterface) UnsafeRandom() (uint64, error) {
+	random, err := i.Interface.UnsafeRandom()
+	if err != nil {
+		return 0, err
+	}
+
+	i.answers.RandomNumbers = append(i.answers.RandomNumbers, random)
+
+	return random, nil
+}
+
+func (i *reproCaseRecordingInterface) GetStorageUsed(address Address) (uint64, error) {
+	used, err := i.Interface.GetStorageUsed(address)
+	if err != nil {
+		return 0, err
+	}
+
+	i.answers.StorageUsed = append(i.answers.StorageUsed, used)
+
+	return used, nil
+}
+
+// GetStorageCapacity records the storage capacity provided by the wrapped runtime interface.
+// It returns a StorageCapacityUnavailableError if the wrapped runtime interface
+// does not implement StorageCapacityProvider.
+//
+func (i *reproCaseRecordingInterface) GetStorageCapacity(address Address) (uint64, error) {
+	provider, ok := getStorageCapacityProvider(i.Interface)
+	if !ok {
+		return 0, StorageCapacityUnavailableError{
+			Address: address,
+		}
+	}
+
+	capacity, err := provider.GetStorageCapacity(address)
+	if err != nil {
+		return 0, err
+	}
+
+	i.answers.StorageCapacities = append(i.answers.StorageCapacities, capacity)
+
+	return capacity, nil
+}
+
+func (i *reproCaseRecordingInterface) GetAccountBalance(address common.Address) (uint64, error) {
+	balance, err := i.Interface.GetAccountBalance(address)
+	if err != nil {
+		return 0, err
+	}
+
+	i.answers.Balances = append(i.answers.Balances, balance)
+
+	return balance, nil
+}
+
+func (i *reproCaseRecordingInterface) GetAccountAvailableBalance(address common.Address) (uint64, error) {
+	balance, err := i.Interface.GetAccountAvailableBalance(address)
+	if err != nil {
+		return 0, err
+	}
+
+	i.answers.AvailableBalances = append(i.answers.AvailableBalances, balance)
+
+	return balance, nil
+}
+
+func (i *reproCaseRecordingInterface) GetAccountKey(address Address, index int) (*AccountKey, error) {
+	accountKey, err := i.Interface.GetAccountKey(address, index)
+	if err != nil {
+		return nil, err
+	}
+
+	i.answers.AccountKeys = append(i.answers.AccountKeys, newReproCaseAccountKey(accountKey))
+
+	return accountKey, nil
+}
+
+// DecodeArgument records the argument decoded by the wrapped runtime interface, encoded as JSON-Cadence.
+//
+func (i *reproCaseRecordingInterface) DecodeArgument(argument []byte, argumentType cadence.Type) (cadence.Value, error) {
+	value, err := i.Interface.DecodeArgument(argument, argumentType)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded, err := jsoncdc.Encode(value)
+	if err != nil {
+		return nil, err
+	}
+
+	i.answers.DecodedArguments = append(i.answers.DecodedArguments, encoded)
+
+	return value, nil
+}
+
+func (i *reproCaseRecordingInterface) reproCase(script Script) reproCase {
+
+	// Sort all recorded information, so the repro case is deterministic
+
+	registers := make([]reproCaseRegister, 0, len(i.reads))
+	for registerKey, value := range i.reads { //nolint:maprangecheck
+		registers = append(
+			registers,
+			reproCaseRegister{
+				Owner: []byte(registerKey.owner),
+				Key:   []byte(registerKey.key),
+				Value: value,
+			},
+		)
+	}
+
+	sort.Slice(registers, func(a, b int) bool {
+		ownerComparison := bytes.Compare(registers[a].Owner, registers[b].Owner)
+		if ownerComparison != 0 {
+			return ownerComparison < 0
+		}
+		return bytes.Compare(registers[a].Key, registers[b].Key) < 0
+	})
+
+	storageIndices := make([]reproCaseStorageIndices, 0, len(i.storageIndices))
+	for owner, indices := range i.storageIndices { //nolint:maprangecheck
+		storageIndices = append(
+			storageIndices,
+			reproCaseStorageIndices{
+				Owner:   []byte(owner),
+				Indices: indices,
+			},
+		)
+	}
+
+	sort.Slice(storageIndices, func(a, b int) bool {
+		return bytes.Compare(storageIndices[a].Owner, storageIndices[b].Owner) < 0
+	})
+
+	codes := make([]reproCaseCode, 0, len(i.codes))
+	for locationID, code := range i.codes { //nolint:maprangecheck
+		codes = append(
+			codes,
+			reproCaseCode{
+				LocationID: string(locationID),
+				Code:       code,
+			},
+		)
+	}
+
+	sort.Slice(codes, func(a, b int) bool {
+		return codes[a].LocationID < codes[b].LocationID
+	})
+
+	signers := make([][]byte, len(i.signers))
+	for index, signer := range i.signers {
+		signers[index] = signer.Bytes()
+	}
+
+	return reproCase{
+		Version:        reproCaseVersion,
+		Source:         script.Source,
+		Arguments:      script.Arguments,
+		Signers:        signers,
+		Registers:      registers,
+		StorageIndices: storageIndices,
+		Codes:          codes,
+		Answers:        i.answers,
+	}
+}
+
+// reproCaseReplayInterface is a runtime interface which replays a transaction
+// from a repro case, i.e. it provides the recorded registers, storage indices,
+// signing accounts, code of imported programs, and answers to the queries of the transaction.
+//
+// Once all recorded answers to a query are used, the query is answered by the wrapped runtime interface.
+// All other functionality is provided by the wrapped runtime interface.
+//
+type reproCaseReplayInterface struct {
+	Interface
+	registers          map[registerKey][]byte
+	storageIndices     map[string][][]byte
+	lastStorageIndices map[string]atree.StorageIndex
+	signers            []Address
+	codes              map[common.LocationID][]byte
+	answers            reproCaseAnswers
+}
+
+func newReproCaseReplayInterface(runtimeInterface Interface, reproCase reproCase) *reproCaseReplayInterface {
+
+	registers := make(map[registerKey][]byte, len(reproCase.Registers))
+	for _, register := range reproCase.Registers {
+		registerKey := registerKey{
+			owner: string(register.Owner),
+			key:   string(register.Key),
+		}
+		registers[registerKey] = register.Value
+	}
+
+	storageIndices := make(map[string][][]byte, len(reproCase.StorageIndices))
+	for _, indices := range reproCase.StorageIndices {
+		storageIndices[string(indices.Owner)] = indices.Indices
+	}
+
+	signers := make([]Address, len(reproCase.Signers))
+	for index, signer := range reproCase.Signers {
+		signers[index] = common.BytesToAddress(signer)
+	}
+
+	codes := make(map[common.LocationID][]byte, len(reproCase.Codes))
+	for _, code := range reproCase.Codes {
+		codes[common.LocationID(code.LocationID)] = code.Code
+	}
+
+	return &reproCaseReplayInterface{
+		Interface:          runtimeInterface,
+		registers:          registers,
+		storageIndices:     storageIndices,
+		lastStorageIndices: map[string]atree.StorageIndex{},
+		signers:            signers,
+		codes:              codes,
+		answers:            reproCase.Answers,
+	}
+}
+
+func (i *reproCaseReplayInterface) GetValue(owner, key []byte) ([]byte, error) {
+	registerKey := registerKey{
+		owner: string(owner),
+		key:   string(key),
+	}
+	return i.registers[registerKey], nil
+}
+
+func (i *reproCaseReplayInterface) SetValue(owner, key, value []byte) error {
+	registerKey := registerKey{
+		owner: string(owner),
+		key:   string(key),
+	}
+	i.registers[registerKey] = value
+	return nil
+}
+
+func (i *reproCaseReplayInterface) ValueExists(owner, key []byte) (bool, error) {
+	value, err := i.GetValue(owner, key)
+	if err != nil {
+		return false, err
+	}
+	return len(value) > 0, nil
+}
+
+// AllocateStorageIndex returns the recorded storage indices of the given account in order.
+// Once all recorded storage indices are used, the following storage indices are allocated.
+//
+func (i *reproCaseReplayInterface) AllocateStorageIndex(owner []byte) (atree.StorageIndex, error) {
+	var storageIndex atree.StorageIndex
+
+	indices := i.storageIndices[string(owner)]
+	if len(indices) > 0 {
+		copy(storageIndex[:], indices[0])
+		i.storageIndices[string(owner)] = indices[1:]
+	} else {
+		storageIndex = i.lastStorageIndices[string(owner)].Next()
+	}
+
+	i.lastStorageIndices[string(owner)] = storageIndex
+
+	return storageIndex, nil
+}
+
+func (i *reproCaseReplayInterface) GetSigningAccounts() ([]Address, error) {
+	return i.signers, nil
+}
+
+func (i *reproCaseReplayInterface) GetCode(location Location) ([]byte, error) {
+	return i.codes[location.ID()], nil
+}
+
+func (i *reproCaseReplayInterface) GetAccountContractCode(address Address, name string) ([]byte, error) {
+	location := common.AddressLocation{
+		Address: address,
+		Name:    name,
+	}
+	return i.codes[location.ID()], nil
+}
+
+func (*reproCaseReplayInterface) GetProgram(_ Location) (*interpreter.Program, error) {
+	return nil, nil
+}
+
+func (*reproCaseReplayInterface) SetProgram(_ Location, _ *interpreter.Program) error {
+	return nil
+}
+
+func (i *reproCaseReplayInterface) GenerateUUID() (uint64, error) {
+	if uuid, ok := nextReproCaseAnswer(&i.answers.UUIDs); ok {
+		return uuid, nil
+	}
+	return i.Interface.GenerateUUID()
+}
+
+func (i *reproCaseReplayInterface) GetCurrentBlockHeight() (uint64, error) {
+	if height, ok := nextReproCaseAnswer(&i.answers.BlockHeights); ok {
+		return height, nil
+	}
+	return i.Interface.GetCurrentBlockHeight()
+}
+
+func (i *reproCaseReplayInterface) GetBlockAtHeight(height uint64) (Block, bool, error) {
+	if len(i.answers.Blocks) > 0 {
+		block, exists := i.answers.Blocks[0].block()
+		i.answers.Blocks = i.answers.Blocks[1:]
+		return block, exists, nil
+	}
+	return i.Interface.GetBlockAtHeight(height)
+}
+
+func (i *reproCaseReplayInterface) UnsafeRandom() (uint64, error) {
+	if random, ok := nextReproCaseAnswer(&i.answers.RandomNumbers); ok {
+		return random, nil
+	}
+	return i.Interface.UnsafeRandom()
+}
+
+func (i *reproCaseReplayInterface) GetStorageUsed(address Address) (uint64, error) {
+	if used, ok := nextReproCaseAnswer(&i.answers.StorageUsed); ok {
+		return used, nil
+	}
+	return i.Interface.GetStorageUsed(address)
+}
+
+// GetStorageCapacity returns a StorageCapacityUnavailableError once all recorded answers are used,
+// if the wrapped runtime interface does not implement StorageCapacityProvider.
+//
+func (i *reproCaseReplayInterface) GetStorageCapacity(address Address) (uint64, error) {
+	if capacity, ok := nextReproCaseAnswer(&i.answers.StorageCapacities); ok {
+		return capacity, nil
+	}
+
+	provider, ok := getStorageCapacityProvider(i.Interface)
+	if !ok {
+		return 0, StorageCapacityUnavailableError{
+			Address: address,
+		}
+	}
+
+	return provider.GetStorageCapacity(address)
+}
+
+func (i *reproCaseReplayInterface) GetAccountBalance(address common.Address) (uint64, error) {
+	if balance, ok := nextReproCaseAnswer(&i.answers.Balances); ok {
+		return balance, nil
+	}
+	return i.Interface.GetAccountBalance(address)
+}
+
+func (i *reproCaseReplayInterface) GetAccountAvailableBalance(address common.Address) (uint64, error) {
+	if balance, ok := nextReproCaseAnswer(&i.answers.AvailableBalances); ok {
+		return balance, nil
+	}
+	return i.Interface.GetAccountAvailableBalance(address)
+}
+
+func (i *reproCaseReplayInterface) GetAccountKey(address Address, index int) (*AccountKey, error) {
+	if len(i.answers.AccountKeys) > 0 {
+		accountKey := i.answers.AccountKeys[0].accountKey()
+		i.answers.AccountKeys = i.answers.AccountKeys[1:]
+		return accountKey, nil
+	}
+	return i.Interface.GetAccountKey(address, index)
+}
+
+func (i *reproCaseReplayInterface) DecodeArgument(argument []byte, argumentType cadence.Type) (cadence.Value, error) {
+	if len(i.answers.DecodedArguments) > 0 {
+		encoded := i.answers.DecodedArguments[0]
+		i.answers.DecodedArguments = i.answers.DecodedArguments[1:]
+		return jsoncdc.Decode(encoded)
+	}
+	return i.Interface.DecodeArgument(argument, argumentType)
+}
+
+// EmitEvents passes the given events on to the wrapped runtime interface,
+// in one batch if it implements BatchEventEmitter.
+//
+func (i *reproCaseReplayInterface) EmitEvents(events []cadence.Event) error {
+	return emitEvents(i.Interface, events)
+}
+
+// EmitEventWithIndex passes the given event on to the wrapped runtime interface,
+// together with the names of the indexed fields if it implements IndexedEventEmitter.
+//
+func (i *reproCaseReplayInterface) EmitEventWithIndex(event cadence.Event, indexedFields []string) error {
+	return emitEventWithIndex(i.Interface, event, indexedFields)
+}
+
+// EmitEventsWithIndex passes the given events on to the wrapped runtime interface,
+// together with the names of their indexed fields if it implements IndexedBatchEventEmitter.
+//
+func (i *reproCaseReplayInterface) EmitEventsWithIndex(events []cadence.Event, indexedFields [][]string) error {
+	return emitEventsWithIndex(i.Interface, events, indexedFields)
+}
+
+func (r *interpreterRuntime) ExportReproCase(script Script, context Context) ([]byte, error) {
+
+	recordingInterface := newReproCaseRecordingInterface(context.Interface)
+
+	context.Interface = recordingInterface
+
+	// Load all imported programs from the runtime interface, so their code is recorded
+
+	context.ContractCodeCache = nil
+
+	err := r.ExecuteTransaction(script, context)
+	if err == nil {
+		return nil, fmt.Errorf("cannot export repro case: transaction did not fail")
+	}
+
+	return interpreter.CBOREncMode.Marshal(recordingInterface.reproCase(script))
+}
+
+func (r *interpreterRuntime) ReplayReproCase(data []byte, context Context) error {
+
+	var reproCase reproCase
+	err := interpreter.CBORDecMode.Unmarshal(data, &reproCase)
+	if err != nil {
+		return fmt.Errorf("cannot replay repro case: %w", err)
+	}
+
+	if reproCase.Version != reproCaseVersion {
+		return fmt.Errorf(
+			"cannot replay repro case: unsupported version: expected %d, got %d",
+			reproCaseVersion,
+			reproCase.Version,
+		)
+	}
+
+	context.Interface = newReproCaseReplayInterface(context.Interface, reproCase)
+
+	context.ContractCodeCache = nil
+
+	return r.ExecuteTransaction(
+		Script{
+			Source:    reproCase.Source,
+			Arguments: reproCase.Arguments,
+		},
+		context,
+	)
+}
@@ -0,0 +1,398 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2020 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runtime
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/cadence"
+	jsoncdc "github.com/onflow/cadence/encoding/json"
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/cadence/runtime/tests/utils"
+)
+
+func TestRuntimeReproCase(t *testing.T) {
+
+	t.Parallel()
+
+	address := common.BytesToAddress([]byte{0x1})
+
+	const contract = `
+      pub contract Test {
+
+          pub resource Vault {
+              pub var balance: Int
+
+              init(balance: Int) {
+                  self.balance = balance
+              }
+
+              pub fun withdraw(amount: Int) {
+                  pre {
+                      amount <= self.balance: "insufficient balance"
+                  }
+                  self.balance = self.balance - amount
+              }
+          }
+
+          pub event VaultCreated(balance: Int)
+
+          pub fun createVault(balance: Int): @Vault {
+              emit VaultCreated(balance: balance)
+              return <- create Vault(balance: balance)
+          }
+      }
+    `
+
+	const setupTransaction = `
+      import Test from 0x1
+
+      transaction {
+          prepare(signer: AuthAccount) {
+              signer.save(<-Test.createVault(balance: 10), to: /storage/vault)
+          }
+      }
+    `
+
+	const withdrawTransaction = `
+      import Test from 0x1
+
+      transaction(amount: Int) {
+          prepare(signer: AuthAccount) {
+              let vault = signer.borrow<&Test.Vault>(from: /storage/vault)!
+              vault.withdraw(amount: amount)
+          }
+      }
+    `
+
+	newRuntimeInterface := func(ledger testLedger) *testRuntimeInterface {
+		var contractCode []byte
+
+		return &testRuntimeInterface{
+			storage: ledger,
+			getSigningAccounts: func() ([]Address, error) {
+				return []Address{address}, nil
+			},
+			resolveLocation: singleIdentifierLocationResolver(t),
+			getAccountContractCode: func(_ Address, _ string) ([]byte, error) {
+				return contractCode, nil
+			},
+			updateAccountContractCode: func(_ Address, _ string, code []byte) error {
+				contractCode = code
+				return nil
+			},
+			emitEvent: func(event cadence.Event) error {
+				return nil
+			},
+			decodeArgument: func(b []byte, t cadence.Type) (cadence.Value, error) {
+				return jsoncdc.Decode(b)
+			},
+		}
+	}
+
+	runtime := newTestInterpreterRuntime()
+
+	ledger := newTestLedger(nil, nil)
+	runtimeInterface := newRuntimeInterface(ledger)
+
+	nextTransactionLocation := newTransactionLocationGenerator()
+
+	for _, source := range [][]byte{
+		utils.DeploymentTransaction("Test", []byte(contract)),
+		[]byte(setupTransaction),
+	} {
+		err := runtime.ExecuteTransaction(
+			Script{
+				Source: source,
+			},
+			Context{
+				Interface: runtimeInterface,
+				Location:  nextTransactionLocation(),
+			},
+		)
+		require.NoError(t, err)
+	}
+
+	// The error messages of the original execution and the replay may differ in the code excerpt,
+	// as the original execution might use cached programs, so compare the underlying errors
+
+	requireSameError := func(t *testing.T, expected, actual error) {
+		require.IsType(t, Error{}, expected)
+		require.IsType(t, Error{}, actual)
+
+		require.Equal(t, expected.(Error).Err, actual.(Error).Err)
+	}
+
+	withdrawScript := func(amount int) Script {
+		return Script{
+			Source: []byte(withdrawTransaction),
+			Arguments: [][]byte{
+				jsoncdc.MustEncode(cadence.NewInt(amount)),
+			},
+		}
+	}
+
+	t.Run("failing transaction", func(t *testing.T) {
+
+		location := nextTransactionLocation()
+
+		expectedErr := runtime.ExecuteTransaction(
+			withdrawScript(100),
+			Context{
+				Interface: runtimeInterface,
+				Location:  location,
+			},
+		)
+		require.Error(t, expectedErr)
+		require.Contains(t, expectedErr.Error(), "insufficient balance")
+
+		data, err := runtime.ExportReproCase(
+			withdrawScript(100),
+			Context{
+				Interface: runtimeInterface,
+				Location:  location,
+			},
+		)
+		require.NoError(t, err)
+
+		// Replay in a fresh environment,
+		// without any registers or contract code
+
+		replayLedger := newTestLedger(nil, nil)
+		replayInterface := newRuntimeInterface(replayLedger)
+		replayInterface.getSigningAccounts = nil
+
+		replayErr := runtime.ReplayReproCase(
+			data,
+			Context{
+				Interface: replayInterface,
+				Location:  location,
+			},
+		)
+		requireSameError(t, expectedErr, replayErr)
+
+		// Replaying again results in the same error
+
+		replayErr = runtime.ReplayReproCase(
+			data,
+			Context{
+				Interface: replayInterface,
+				Location:  location,
+			},
+		)
+		requireSameError(t, expectedErr, replayErr)
+	})
+
+	t.Run("successful transaction", func(t *testing.T) {
+
+		storedValues := map[string]string{}
+		for key, value := range ledger.storedValues {
+			storedValues[key] = string(value)
+		}
+
+		_, err := runtime.ExportReproCase(
+			withdrawScript(1),
+			Context{
+				Interface: runtimeInterface,
+				Location:  nextTransactionLocation(),
+			},
+		)
+		require.Error(t, err)
+
+		// The ledger is not modified
+
+		require.Len(t, ledger.storedValues, len(storedValues))
+		for key, value := range ledger.storedValues {
+			require.Equal(t, storedValues[key], string(value))
+		}
+	})
+
+	t.Run("batch event emitter", func(t *testing.T) {
+
+		location := nextTransactionLocation()
+
+		batchInterface := testBatchEventEmitterRuntimeInterface{
+			testRuntimeInterface: runtimeInterface,
+			emitEvents: func(events []cadence.Event) error {
+				return nil
+			},
+		}
+
+		data, err := runtime.ExportReproCase(
+			withdrawScript(100),
+			Context{
+				Interface: batchInterface,
+				Location:  location,
+			},
+		)
+		require.NoError(t, err)
+
+		replayInterface := testBatchEventEmitterRuntimeInterface{
+			testRuntimeInterface: newRuntimeInterface(newTestLedger(nil, nil)),
+			emitEvents: func(events []cadence.Event) error {
+				return nil
+			},
+		}
+		replayInterface.getSigningAccounts = nil
+
+		replayErr := runtime.ReplayReproCase(
+			data,
+			Context{
+				Interface: replayInterface,
+				Location:  location,
+			},
+		)
+		require.Error(t, replayErr)
+		require.Contains(t, replayErr.Error(), "insufficient balance")
+	})
+
+	t.Run("answers of the runtime interface", func(t *testing.T) {
+
+		// The transaction fails with an error message which contains
+		// the answers of the runtime interface to its queries
+
+		const answersTransaction = `
+          import Test from 0x1
+
+          transaction(amount: Int) {
+              prepare(signer: AuthAccount) {
+                  let vault <- Test.createVault(balance: amount)
+                  let uuid = vault.uuid
+                  destroy vault
+
+                  panic(
+                      uuid.toString()
+                          .concat(" ").concat(unsafeRandom().toString())
+                          .concat(" ").concat(signer.storageUsed.toString())
+                          .concat(" ").concat(signer.storageCapacity.toString())
+                          .concat(" ").concat(signer.balance.toString())
+                          .concat(" ").concat(signer.keys.get(keyIndex: 0)!.weight.toString())
+                          .concat(" ").concat(amount.toString())
+                  )
+              }
+          }
+        `
+
+		script := Script{
+			Source: []byte(answersTransaction),
+			Arguments: [][]byte{
+				jsoncdc.MustEncode(cadence.NewInt(1)),
+			},
+		}
+
+		setAnswers := func(runtimeInterface *testRuntimeInterface, answer uint64) {
+			runtimeInterface.generateUUID = func() (uint64, error) {
+				return answer, nil
+			}
+			runtimeInterface.unsafeRandom = func() (uint64, error) {
+				return answer, nil
+			}
+			runtimeInterface.getStorageUsed = func(_ Address) (uint64, error) {
+				return answer, nil
+			}
+			runtimeInterface.getStorageCapacity = func(_ Address) (uint64, error) {
+				return answer, nil
+			}
+			runtimeInterface.getAccountBalance = func(_ Address) (uint64, error) {
+				return answer, nil
+			}
+			runtimeInterface.getAccountKey = func(_ Address, index int) (*AccountKey, error) {
+				return &AccountKey{
+					KeyIndex: index,
+					PublicKey: &PublicKey{
+						PublicKey: []byte{1, 2, 3},
+						SignAlgo:  SignatureAlgorithmECDSA_P256,
+					},
+					HashAlgo: HashAlgorithmSHA3_256,
+					Weight:   int(answer),
+				}, nil
+			}
+			runtimeInterface.decodeArgument = func(_ []byte, _ cadence.Type) (cadence.Value, error) {
+				return cadence.NewInt(int(answer)), nil
+			}
+		}
+
+		location := nextTransactionLocation()
+
+		recordingInterface := *runtimeInterface
+		setAnswers(&recordingInterface, 42)
+
+		var emittedEvents int
+		recordingInterface.emitEvent = func(_ cadence.Event) error {
+			emittedEvents++
+			return nil
+		}
+
+		expectedErr := runtime.ExecuteTransaction(
+			script,
+			Context{
+				Interface: &recordingInterface,
+				Location:  location,
+			},
+		)
+		require.Error(t, expectedErr)
+		require.Contains(t, expectedErr.Error(), "42 42 42 42 0.00000042 42.00000000 42")
+
+		// Events are emitted when the transaction is executed,
+		// but not when the repro case is exported
+
+		require.NotZero(t, emittedEvents)
+		emittedEvents = 0
+
+		data, err := runtime.ExportReproCase(
+			script,
+			Context{
+				Interface: &recordingInterface,
+				Location:  location,
+			},
+		)
+		require.NoError(t, err)
+
+		require.Zero(t, emittedEvents)
+
+		// Replay with a runtime interface which answers differently
+
+		replayInterface := newRuntimeInterface(newTestLedger(nil, nil))
+		replayInterface.getSigningAccounts = nil
+		setAnswers(replayInterface, 7)
+
+		replayErr := runtime.ReplayReproCase(
+			data,
+			Context{
+				Interface: replayInterface,
+				Location:  location,
+			},
+		)
+		requireSameError(t, expectedErr, replayErr)
+	})
+
+	t.Run("invalid data", func(t *testing.T) {
+
+		err := runtime.ReplayReproCase(
+			[]byte{0x1},
+			Context{
+				Interface: runtimeInterface,
+				Location:  nextTransactionLocation(),
+			},
+		)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,221 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2020 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runtime
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/cadence/runtime/interpreter"
+	"github.com/onflow/cadence/runtime/tests/utils"
+)
+
+func TestRuntimeResourceHistory(t *testing.T) {
+
+	t.Parallel()
+
+	address := common.BytesToAddress([]byte{0x1})
+
+	const contract = `
+      pub contract Test {
+
+          pub resource R {}
+
+          pub resource Holder {
+              pub let r: @R
+
+              init(r: @R) {
+                  self.r <- r
+              }
+
+              destroy() {
+                  destroy self.r
+              }
+          }
+
+          pub fun createR(): @R {
+              return <- create R()
+          }
+
+          pub fun createHolder(r: @R): @Holder {
+              return <- create Holder(r: <-r)
+          }
+      }
+    `
+
+	newRuntimeInterface := func(t *testing.T) *testRuntimeInterface {
+		var contractCode []byte
+		var uuid uint64
+
+		return &testRuntimeInterface{
+			storage: newTestLedger(nil, nil),
+			getSigningAccounts: func() ([]Address, error) {
+				return []Address{address}, nil
+			},
+			resolveLocation: singleIdentifierLocationResolver(t),
+			getAccountContractCode: func(_ Address, _ string) ([]byte, error) {
+				return contractCode, nil
+			},
+			updateAccountContractCode: func(_ Address, _ string, code []byte) error {
+				contractCode = code
+				return nil
+			},
+			emitEvent: func(event cadence.Event) error {
+				return nil
+			},
+			generateUUID: func() (uint64, error) {
+				uuid++
+				return uuid, nil
+			},
+		}
+	}
+
+	execute := func(t *testing.T, transaction string, history *interpreter.ResourceHistory) error {
+		runtime := newTestInterpreterRuntime()
+		runtimeInterface := newRuntimeInterface(t)
+		nextTransactionLocation := newTransactionLocationGenerator()
+
+		err := runtime.ExecuteTransaction(
+			Script{
+				Source: utils.DeploymentTransaction("Test", []byte(contract)),
+			},
+			Context{
+				Interface: runtimeInterface,
+				Location:  nextTransactionLocation(),
+			},
+		)
+		require.NoError(t, err)
+
+		return runtime.ExecuteTransaction(
+			Script{
+				Source: []byte(transaction),
+			},
+			Context{
+				Interface:       runtimeInterface,
+				Location:        nextTransactionLocation(),
+				ResourceHistory: history,
+			},
+		)
+	}
+
+	t.Run("moves", func(t *testing.T) {
+
+		t.Parallel()
+
+		const transaction = `
+          import Test from 0x1
+
+          transaction {
+              prepare(signer: AuthAccount) {
+                  let r <- Test.createR()
+                  signer.save(<-r, to: /storage/r)
+                  let loaded <- signer.load<@Test.R>(from: /storage/r)!
+                  let holder <- Test.createHolder(r: <-loaded)
+                  destroy holder
+              }
+          }
+        `
+
+		history := interpreter.NewResourceHistory()
+
+		err := execute(t, transaction, history)
+		require.NoError(t, err)
+
+		// The first UUID is generated for R, the second for the holder
+
+		var kinds []interpreter.ResourceMoveKind
+		var details []string
+		for _, move := range history.Moves(1) {
+			kinds = append(kinds, move.Kind)
+			details = append(details, move.Detail)
+		}
+
+		assert.Equal(t,
+			[]interpreter.ResourceMoveKind{
+				interpreter.ResourceMoveKindCreate,
+				interpreter.ResourceMoveKindReturn,
+				interpreter.ResourceMoveKindSave,
+				interpreter.ResourceMoveKindLoad,
+				interpreter.ResourceMoveKindFieldAssignment,
+				interpreter.ResourceMoveKindDestroy,
+			},
+			kinds,
+		)
+
+		assert.Equal(t,
+			[]string{"", "", "/storage/r", "/storage/r", "r", ""},
+			details,
+		)
+	})
+
+	t.Run("error", func(t *testing.T) {
+
+		t.Parallel()
+
+		const transaction = `
+          import Test from 0x1
+
+          transaction {
+              prepare(signer: AuthAccount) {
+                  signer.save(<-Test.createR(), to: /storage/r)
+                  signer.saveOrReplace(<-Test.createR(), to: /storage/r)
+              }
+          }
+        `
+
+		err := execute(t, transaction, interpreter.NewResourceHistory())
+		require.Error(t, err)
+
+		var overwriteErr interpreter.ResourceOverwriteError
+		require.ErrorAs(t, err, &overwriteErr)
+
+		require.Len(t, overwriteErr.History, 3)
+		assert.Equal(t, interpreter.ResourceMoveKindSave, overwriteErr.History[2].Kind)
+		assert.Contains(t, err.Error(), "; resource history: Create at 0000000000000001.Test:19:31, Return at 0000000000000001.Test:19:21, Save /storage/r at")
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+
+		t.Parallel()
+
+		const transaction = `
+          import Test from 0x1
+
+          transaction {
+              prepare(signer: AuthAccount) {
+                  signer.save(<-Test.createR(), to: /storage/r)
+                  signer.saveOrReplace(<-Test.createR(), to: /storage/r)
+              }
+          }
+        `
+
+		err := execute(t, transaction, nil)
+		require.Error(t, err)
+
+		var overwriteErr interpreter.ResourceOverwriteError
+		require.ErrorAs(t, err, &overwriteErr)
+
+		assert.Empty(t, overwriteErr.History)
+		assert.NotContains(t, err.Error(), "resource history")
+	})
+}
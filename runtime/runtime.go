@@ -23,8 +23,10 @@ import (
 	"fmt"
 	"math"
 	goRuntime "runtime"
+	"sort"
 	"time"
 
+	"github.com/onflow/atree"
 	opentracing "github.com/opentracing/opentracing-go"
 	"golang.org/x/crypto/sha3"
 
@@ -59,6 +61,29 @@ type Runtime interface {
 	// or if the execution fails.
 	ExecuteTransaction(Script, Context) error
 
+	// ExportReproCase executes the given failing transaction, and exports a self-contained repro case,
+	// which can be replayed without access to the original environment using ReplayReproCase.
+	//
+	// The repro case contains the transaction and its arguments, the signing accounts,
+	// the registers read by the transaction, the allocated storage indices,
+	// the code of the imported programs, and the answers of the runtime interface
+	// to the queries of the transaction, i.e. the generated UUIDs, the block height and blocks,
+	// the random numbers, the storage used and capacity, the balances, the account keys,
+	// and the decoded arguments.
+	//
+	// Writes are not passed on to the runtime interface, i.e. the ledger is not modified,
+	// and events are not emitted.
+	// This function returns an error if the transaction does not fail.
+	ExportReproCase(script Script, context Context) ([]byte, error)
+
+	// ReplayReproCase replays the transaction of a repro case, which was exported using ExportReproCase,
+	// and returns the error of the transaction.
+	//
+	// The registers, storage indices, signing accounts, code, and answers to queries
+	// are provided by the repro case. All other functionality (e.g. signature verification)
+	// is provided by the runtime interface.
+	ReplayReproCase(data []byte, context Context) error
+
 	// InvokeContractFunction invokes a contract function with the given arguments.
 	//
 	// This function returns an error if the execution fails.
@@ -72,11 +97,56 @@ type Runtime interface {
 		context Context,
 	) (cadence.Value, error)
 
+	// ParseProgram parses the given code without checking or executing the program.
+	// Imports are not resolved.
+	//
+	// This function returns an error if the program contains any syntax errors.
+	ParseProgram(source []byte, context Context) (*ast.Program, error)
+
 	// ParseAndCheckProgram parses and checks the given code without executing the program.
 	//
 	// This function returns an error if the program contains any syntax or semantic errors.
 	ParseAndCheckProgram(source []byte, context Context) (*interpreter.Program, error)
 
+	// GetProgramDependencies parses and checks the given code without executing the program,
+	// and returns the locations of all programs which are imported by the program,
+	// directly or transitively.
+	//
+	// This function returns an error if the program contains any syntax or semantic errors.
+	GetProgramDependencies(source []byte, context Context) ([]common.Location, error)
+
+	// TransactionSignature parses and checks the given transaction without executing it,
+	// and returns its signature, i.e. its parameters and its number of authorizers.
+	//
+	// This function returns an error if the program contains any syntax or semantic errors,
+	// or if it does not declare exactly one transaction.
+	TransactionSignature(source []byte, context Context) (TransactionSignature, error)
+
+	// CheckTransactionArguments parses and checks the given transaction without executing it,
+	// and validates that the arguments of the script match the parameters of the transaction,
+	// i.e. that the number of arguments is correct, and that each argument can be decoded
+	// and is a subtype of the corresponding parameter type.
+	//
+	// Neither the prepare nor the execute block of the transaction is run.
+	//
+	CheckTransactionArguments(script Script, context Context) error
+
+	// CheckContractUpdate parses the existing code of the contract with the given name in the given account,
+	// and the given new code, and reports the changes which are incompatible with the values already stored,
+	// e.g. removed fields, changed field types, and removed enum cases.
+	//
+	// Neither program is checked, and the contract is not updated.
+	//
+	// This function returns an error if the contract does not exist,
+	// or if the old or the new program contains any syntax errors.
+	//
+	CheckContractUpdate(
+		address common.Address,
+		name string,
+		newCode []byte,
+		context Context,
+	) (*UpdateCompatibilityReport, error)
+
 	// SetCoverageReport activates reporting coverage in the given report.
 	// Passing nil disables coverage reporting (default).
 	//
@@ -94,11 +164,146 @@ type Runtime interface {
 
 	// ReadStored reads the value stored at the given path
 	//
+	// If Context.ResolveCapabilityChains is enabled and the stored value is a capability,
+	// the returned capability includes the chain of paths through which it is linked.
+	//
 	ReadStored(address common.Address, path cadence.Path, context Context) (cadence.Value, error)
 
+	// WriteStored imports the given value and saves it to the given storage path,
+	// and commits the storage.
+	//
+	// The value must be storable, and the path must not already store a value.
+	// Imported resources get a fresh UUID.
+	//
+	WriteStored(address common.Address, path cadence.Path, value cadence.Value, context Context) error
+
+	// ReencodeStored re-encodes the value stored at the given path in the current encoding format.
+	//
+	// If the stored encoding differs from the current encoding, the value is written back,
+	// the storage is committed, and true is returned.
+	// If the stored encoding is already current, or no value is stored, nothing is written.
+	//
+	ReencodeStored(address common.Address, path cadence.Path, context Context) (changed bool, err error)
+
+	// ReadStoredResolvingCapabilities reads the value stored at the given path.
+	//
+	// If the stored value is a capability, the capability is borrowed,
+	// and the value stored at its target is returned instead.
+	// If the capability cannot be borrowed, a BrokenCapabilityError is returned.
+	//
+	ReadStoredResolvingCapabilities(address common.Address, path cadence.Path, context Context) (cadence.Value, error)
+
 	// ReadLinked dereferences the path and returns the value stored at the target
 	//
+	// If Context.ResolveCapabilityChains is enabled and the target value is a capability,
+	// the returned capability includes the chain of paths through which it is linked.
+	//
 	ReadLinked(address common.Address, path cadence.Path, context Context) (cadence.Value, error)
+
+	// ResolveViews resolves the given view types of the value stored at the given path,
+	// by calling the value's `resolveView` function for each view type.
+	//
+	// Public and private paths are dereferenced.
+	// The views are returned keyed by the type ID of the view type.
+	// Views which the value does not resolve, i.e. for which `resolveView` returns nil,
+	// are not included.
+	//
+	// Changes to storage made by `resolveView` are not committed.
+	//
+	ResolveViews(
+		address common.Address,
+		path cadence.Path,
+		viewTypes []cadence.Type,
+		context Context,
+	) (
+		map[string]cadence.Value,
+		error,
+	)
+
+	// StoredCapabilityTypes returns the borrow type IDs of all capabilities stored in the given account,
+	// keyed by the path they are stored at.
+	// The type ID is empty for untyped capabilities.
+	//
+	// The borrow types of some capabilities might not be resolvable,
+	// e.g. because the contract which declares the type was removed.
+	// The paths of these capabilities are returned as unresolved, in lexicographic order.
+	//
+	// The ledger of the runtime interface must implement AccountRegisterIterator.
+	//
+	StoredCapabilityTypes(
+		address common.Address,
+		context Context,
+	) (
+		types map[cadence.Path]string,
+		unresolved []cadence.Path,
+		err error,
+	)
+
+	// IterateStorageDomain calls the given function for each value stored in the given domain
+	// of the given account, in lexicographic order of the path identifiers.
+	// Iteration stops early if the function returns false.
+	//
+	// The function is passed the path and the static type of the stored value.
+	// Stored values are not fully decoded, i.e. only the type information is loaded.
+	// For links, the type is the borrow type of the link.
+	//
+	// Values whose type cannot be loaded, e.g. because the contract which declares the type was removed,
+	// are skipped.
+	//
+	// The ledger of the runtime interface must implement AccountRegisterIterator.
+	//
+	IterateStorageDomain(
+		address common.Address,
+		domain common.PathDomain,
+		fn func(path cadence.Path, typ cadence.Type) bool,
+		context Context,
+	) error
+
+	// ValidateAccountStorage validates the storage of the given account,
+	// without modifying it: It checks that all slabs reachable from the stored values exist and can be decoded,
+	// and that no slabs are orphaned, i.e. not reachable from any stored value.
+	//
+	// All problems found are reported, see ValidateAccountStorage.
+	//
+	// The ledger of the runtime interface must implement AccountRegisterIterator.
+	//
+	ValidateAccountStorage(address common.Address, context Context) (*StorageValidationReport, error)
+
+	// CheckCapability checks the link stored at the given public or private path:
+	// If a link exists, if the final target of the link stores a value,
+	// and if that value can be borrowed as the type declared by the link.
+	//
+	CheckCapability(address common.Address, path cadence.Path, context Context) (CapabilityStatus, error)
+
+	// GetLinkTarget returns the target path of the link stored at the given public or private path,
+	// without following or borrowing the link.
+	//
+	// If no link is stored at the given path, false is returned.
+	//
+	GetLinkTarget(address common.Address, path cadence.Path, context Context) (target cadence.Path, linked bool, err error)
+
+	// AccountStorageInfo returns the storage used, the storage capacity,
+	// and the storage available of the given account, read from one consistent state.
+	//
+	// The runtime interface must implement StorageCapacityProvider.
+	//
+	AccountStorageInfo(address common.Address, context Context) (StorageInfo, error)
+
+	// GetAccountContractNames returns the names of all contracts deployed to the given account,
+	// sorted in lexicographic order.
+	//
+	// Only contracts which have code are returned.
+	//
+	GetAccountContractNames(address common.Address, context Context) ([]string, error)
+
+	// ExecuteTransactionBatch executes the given transactions sequentially, in the given order.
+	// The location of the context is ignored, each transaction is executed at its own location.
+	//
+	// A failing transaction does not abort the batch.
+	// Each result carries the events emitted by its transaction, in emission order,
+	// and the error of the transaction, if it failed.
+	//
+	ExecuteTransactionBatch(transactions []BatchTransaction, context Context) TransactionResults
 }
 
 var typeDeclarations = append(
@@ -137,7 +342,7 @@ func reportMetric(
 	runtimeInterface Interface,
 	report func(Metrics, time.Duration),
 ) {
-	metrics, ok := runtimeInterface.(Metrics)
+	metrics, ok := unwrapInterface(runtimeInterface).(Metrics)
 	if !ok {
 		f()
 		return
@@ -150,6 +355,34 @@ func reportMetric(
 	report(metrics, elapsed)
 }
 
+// resolveLocation resolves the given import location using the runtime interface.
+//
+// If the runtime interface implements ImportRewriter,
+// the location is rewritten before it is resolved.
+//
+func resolveLocation(
+	runtimeInterface Interface,
+	identifiers []Identifier,
+	location Location,
+) (
+	resolvedLocations []ResolvedLocation,
+	err error,
+) {
+	if rewriter, ok := unwrapInterface(runtimeInterface).(ImportRewriter); ok {
+		wrapPanic(func() {
+			location, err = rewriter.RewriteImport(location)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	wrapPanic(func() {
+		resolvedLocations, err = runtimeInterface.ResolveLocation(identifiers, location)
+	})
+	return
+}
+
 // interpreterRuntime is a interpreter-based version of the Flow runtime.
 type interpreterRuntime struct {
 	coverageReport                  *CoverageReport
@@ -215,7 +448,10 @@ func (r *interpreterRuntime) SetTracingEnabled(enabled bool) {
 func (r *interpreterRuntime) ExecuteScript(script Script, context Context) (cadence.Value, error) {
 	context.InitializeCodesAndPrograms()
 
-	storage := r.newStorage(context.Interface)
+	storage, err := r.newStorage(context)
+	if err != nil {
+		return nil, newError(err, context)
+	}
 
 	var checkerOptions []sema.Option
 	var interpreterOptions []interpreter.Option
@@ -287,7 +523,7 @@ func (r *interpreterRuntime) ExecuteScript(script Script, context Context) (cade
 
 	// Export before committing storage
 
-	result, err := exportValue(value)
+	result, err := exportValue(value, context.UnknownTypeHandler)
 	if err != nil {
 		return nil, newError(err, context)
 	}
@@ -297,7 +533,7 @@ func (r *interpreterRuntime) ExecuteScript(script Script, context Context) (cade
 	// Even though this function is `ExecuteScript`, that doesn't imply the changes
 	// to storage will be actually persisted
 
-	err = r.commitStorage(storage, inter)
+	err = r.commitStorage(storage, inter, context)
 	if err != nil {
 		return nil, newError(err, context)
 	}
@@ -305,7 +541,11 @@ func (r *interpreterRuntime) ExecuteScript(script Script, context Context) (cade
 	return result, nil
 }
 
-func (r *interpreterRuntime) commitStorage(storage *Storage, inter *interpreter.Interpreter) error {
+func (r *interpreterRuntime) commitStorage(
+	storage *Storage,
+	inter *interpreter.Interpreter,
+	context Context,
+) error {
 	const commitContractUpdates = true
 	err := storage.Commit(inter, commitContractUpdates)
 	if err != nil {
@@ -452,7 +692,10 @@ func (r *interpreterRuntime) InvokeContractFunction(
 ) (cadence.Value, error) {
 	context.InitializeCodesAndPrograms()
 
-	storage := r.newStorage(context.Interface)
+	storage, err := r.newStorage(context)
+	if err != nil {
+		return nil, newError(err, context)
+	}
 
 	var interpreterOptions []interpreter.Option
 	var checkerOptions []sema.Option
@@ -529,7 +772,7 @@ func (r *interpreterRuntime) InvokeContractFunction(
 	}
 
 	// Write back all stored values, which were actually just cached, back into storage
-	err = r.commitStorage(storage, inter)
+	err = r.commitStorage(storage, inter, context)
 	if err != nil {
 		return nil, newError(err, context)
 	}
@@ -579,7 +822,25 @@ func (r *interpreterRuntime) convertArgument(
 func (r *interpreterRuntime) ExecuteTransaction(script Script, context Context) error {
 	context.InitializeCodesAndPrograms()
 
-	storage := r.newStorage(context.Interface)
+	storage, err := r.newStorage(context)
+	if err != nil {
+		return newError(err, context)
+	}
+
+	// Only track the accessed accounts if the runtime interface is interested,
+	// so there is no overhead otherwise
+
+	accessedAccountsListener, reportAccessedAccounts := unwrapInterface(context.Interface).(AccessedAccountsListener)
+	if reportAccessedAccounts {
+		storage.TrackAccessedAccounts()
+	}
+
+	// Buffer the emitted events if the runtime interface accepts them in one batch
+
+	eventBuffer := newEventBufferingInterface(context.Interface)
+	if eventBuffer != nil {
+		context.Interface = eventBuffer
+	}
 
 	var interpreterOptions []interpreter.Option
 	var checkerOptions []sema.Option
@@ -684,11 +945,34 @@ func (r *interpreterRuntime) ExecuteTransaction(script Script, context Context)
 	}
 
 	// Write back all stored values, which were actually just cached, back into storage
-	err = r.commitStorage(storage, inter)
+	err = r.commitStorage(storage, inter, context)
 	if err != nil {
 		return newError(err, context)
 	}
 
+	// Only emit the buffered events once the storage was committed successfully
+
+	if eventBuffer != nil {
+		err = eventBuffer.flushEvents()
+		if err != nil {
+			return newError(err, context)
+		}
+	}
+
+	if reportAccessedAccounts {
+		accessedAccounts := storage.AccessedAccounts()
+		wrapPanic(func() {
+			accessedAccountsListener.OnAccountsAccessed(accessedAccounts)
+		})
+	}
+
+	if eventCountListener, ok := unwrapInterface(context.Interface).(EventCountListener); ok {
+		eventCount := inter.EventCount()
+		wrapPanic(func() {
+			eventCountListener.OnEventsEmitted(eventCount)
+		})
+	}
+
 	return nil
 }
 
@@ -862,13 +1146,116 @@ func hasValidStaticType(value interpreter.Value) bool {
 	}
 }
 
+// ParseProgram parses the given code, without checking it.
+//
+func (r *interpreterRuntime) ParseProgram(code []byte, context Context) (program *ast.Program, err error) {
+	context.InitializeCodesAndPrograms()
+
+	// Make the code available for error messages
+
+	context.SetCode(context.Location, string(code))
+
+	reportMetric(
+		func() {
+			program, err = parser2.ParseProgram(string(code))
+		},
+		context.Interface,
+		func(metrics Metrics, duration time.Duration) {
+			metrics.ProgramParsed(context.Location, duration)
+		},
+	)
+	if err != nil {
+		return nil, newError(
+			&ParsingCheckingError{
+				Err:      err,
+				Location: context.Location,
+			},
+			context,
+		)
+	}
+
+	return program, nil
+}
+
+// CheckContractUpdate parses the existing and the given new code of a contract,
+// and reports the changes which are incompatible with the values already stored.
+//
+func (r *interpreterRuntime) CheckContractUpdate(
+	address common.Address,
+	name string,
+	newCode []byte,
+	context Context,
+) (
+	*UpdateCompatibilityReport,
+	error,
+) {
+	context.InitializeCodesAndPrograms()
+
+	location := common.AddressLocation{
+		Address: address,
+		Name:    name,
+	}
+	context.Location = location
+
+	oldCode, err := r.getCode(context)
+	if err != nil {
+		return nil, newError(err, context)
+	}
+	if len(oldCode) == 0 {
+		return nil, newError(
+			fmt.Errorf(
+				"cannot check update of non-existing contract with name %q in account %s",
+				name,
+				address.ShortHexWithPrefix(),
+			),
+			context,
+		)
+	}
+
+	// Make the new code available for error messages
+
+	context.SetCode(location, string(newCode))
+
+	oldProgram, err := parser2.ParseProgram(string(oldCode))
+	if err != nil {
+		return nil, newError(
+			&ParsingCheckingError{
+				Err:      err,
+				Location: location,
+			},
+			context,
+		)
+	}
+
+	newProgram, err := parser2.ParseProgram(string(newCode))
+	if err != nil {
+		return nil, newError(
+			&ParsingCheckingError{
+				Err:      err,
+				Location: location,
+			},
+			context,
+		)
+	}
+
+	report, err := CheckContractUpdateCompatibility(location, name, oldProgram, newProgram)
+	if err != nil {
+		return nil, newError(err, context)
+	}
+
+	return report, nil
+}
+
 // ParseAndCheckProgram parses the given code and checks it.
 // Returns a program that can be interpreted (AST + elaboration).
 //
 func (r *interpreterRuntime) ParseAndCheckProgram(code []byte, context Context) (*interpreter.Program, error) {
 	context.InitializeCodesAndPrograms()
 
-	storage := r.newStorage(context.Interface)
+	storage, err := r.newStorage(context)
+	if err != nil {
+		return nil, newError(err, context)
+	}
 
 	var interpreterOptions []interpreter.Option
 	var checkerOptions []sema.Option
@@ -896,94 +1283,341 @@ func (r *interpreterRuntime) ParseAndCheckProgram(code []byte, context Context)
 	return program, nil
 }
 
-func (r *interpreterRuntime) parseAndCheckProgram(
-	code []byte,
-	context Context,
-	functions stdlib.StandardLibraryFunctions,
-	values stdlib.StandardLibraryValues,
-	checkerOptions []sema.Option,
-	storeProgram bool,
-	checkedImports importResolutionResults,
-) (
-	program *interpreter.Program,
-	err error,
-) {
-	wrapError := func(err error) error {
-		return &ParsingCheckingError{
-			Err:      err,
-			Location: context.Location,
-		}
-	}
-
-	if storeProgram {
-		context.SetCode(context.Location, string(code))
-	}
-
-	// Parse
+// TransactionSignature is the signature of a transaction,
+// i.e. the information which is needed to construct it.
+//
+type TransactionSignature struct {
+	// Parameters are the parameters of the transaction, i.e. the arguments it requires
+	Parameters []cadence.Parameter
+	// AuthorizerCount is the number of parameters of the prepare block,
+	// i.e. the number of accounts which must authorize the transaction
+	AuthorizerCount int
+	// HasExecute is true if the transaction declares an execute block
+	HasExecute bool
+}
 
-	var parse *ast.Program
-	reportMetric(
-		func() {
-			parse, err = parser2.ParseProgram(string(code))
-		},
-		context.Interface,
-		func(metrics Metrics, duration time.Duration) {
-			metrics.ProgramParsed(context.Location, duration)
-		},
-	)
+func (r *interpreterRuntime) TransactionSignature(code []byte, context Context) (TransactionSignature, error) {
+	program, err := r.ParseAndCheckProgram(code, context)
 	if err != nil {
-		return nil, wrapError(err)
+		return TransactionSignature{}, err
 	}
 
-	if storeProgram {
-		context.SetProgram(context.Location, parse)
+	transactionTypes := program.Elaboration.TransactionTypes
+	transactionCount := len(transactionTypes)
+	if transactionCount != 1 {
+		err = InvalidTransactionCountError{
+			Count: transactionCount,
+		}
+		return TransactionSignature{}, newError(err, context)
 	}
 
-	// Check
+	transactionType := transactionTypes[0]
 
-	elaboration, err := r.check(parse, context, functions, values, checkerOptions, checkedImports)
-	if err != nil {
-		return nil, wrapError(err)
+	results := map[sema.TypeID]cadence.Type{}
+
+	parameters := make([]cadence.Parameter, len(transactionType.Parameters))
+	for i, parameter := range transactionType.Parameters {
+		parameters[i] = cadence.Parameter{
+			Label:      parameter.Label,
+			Identifier: parameter.Identifier,
+			Type:       ExportType(parameter.TypeAnnotation.Type, results),
+		}
 	}
 
-	// Return
+	transactionDeclaration := program.Program.TransactionDeclarations()[0]
 
-	program = &interpreter.Program{
-		Program:     parse,
-		Elaboration: elaboration,
-	}
+	return TransactionSignature{
+		Parameters:      parameters,
+		AuthorizerCount: len(transactionType.PrepareParameters),
+		HasExecute:      transactionDeclaration.Execute != nil,
+	}, nil
+}
 
-	if storeProgram {
-		wrapPanic(func() {
-			err = context.Interface.SetProgram(context.Location, program)
-		})
-		if err != nil {
-			return nil, err
-		}
+func (r *interpreterRuntime) CheckTransactionArguments(script Script, context Context) error {
+	context.InitializeCodesAndPrograms()
+
+	storage, err := r.newStorage(context)
+	if err != nil {
+		return newError(err, context)
 	}
 
-	return program, nil
-}
+	var interpreterOptions []interpreter.Option
+	var checkerOptions []sema.Option
 
-func (r *interpreterRuntime) check(
-	program *ast.Program,
-	startContext Context,
+	functions := r.standardLibraryFunctions(
+		context,
+		storage,
+		interpreterOptions,
+		checkerOptions,
+	)
+
+	program, err := r.parseAndCheckProgram(
+		script.Source,
+		context,
+		functions,
+		stdlib.BuiltinValues(),
+		checkerOptions,
+		true,
+		importResolutionResults{},
+	)
+	if err != nil {
+		return newError(err, context)
+	}
+
+	transactionTypes := program.Elaboration.TransactionTypes
+	transactionCount := len(transactionTypes)
+	if transactionCount != 1 {
+		err = InvalidTransactionCountError{
+			Count: transactionCount,
+		}
+		return newError(err, context)
+	}
+
+	transactionType := transactionTypes[0]
+
+	// The arguments are only validated, the transaction is not invoked,
+	// and the storage is not committed
+
+	_, _, err = r.interpret(
+		program,
+		context,
+		storage,
+		functions,
+		stdlib.BuiltinValues(),
+		interpreterOptions,
+		checkerOptions,
+		func(inter *interpreter.Interpreter) (_ interpreter.Value, err error) {
+
+			// Recover internal panics and return them as an error.
+			// For example, the argument validation might attempt to
+			// load contract code for non-existing types
+
+			defer inter.RecoverErrors(func(internalErr error) {
+				err = internalErr
+			})
+
+			_, err = validateArgumentParams(
+				inter,
+				context.Interface,
+				script.Arguments,
+				transactionType.Parameters,
+			)
+			if err != nil {
+				return nil, err
+			}
+
+			return interpreter.VoidValue{}, nil
+		},
+	)
+	if err != nil {
+		return newError(err, context)
+	}
+
+	return nil
+}
+
+// GetProgramDependencies parses the given code, checks it,
+// and returns the deduplicated locations of all directly and transitively imported programs.
+//
+func (r *interpreterRuntime) GetProgramDependencies(code []byte, context Context) ([]common.Location, error) {
+	context.InitializeCodesAndPrograms()
+
+	storage, err := r.newStorage(context)
+	if err != nil {
+		return nil, newError(err, context)
+	}
+
+	var interpreterOptions []interpreter.Option
+	var checkerOptions []sema.Option
+
+	functions := r.standardLibraryFunctions(
+		context,
+		storage,
+		interpreterOptions,
+		checkerOptions,
+	)
+
+	values := stdlib.BuiltinValues()
+
+	program, err := r.parseAndCheckProgram(
+		code,
+		context,
+		functions,
+		values,
+		checkerOptions,
+		true,
+		importResolutionResults{},
+	)
+	if err != nil {
+		return nil, newError(err, context)
+	}
+
+	var dependencies []common.Location
+	seenDependencies := map[common.LocationID]struct{}{}
+
+	var collectDependencies func(program *ast.Program) error
+	collectDependencies = func(program *ast.Program) error {
+
+		for _, declaration := range program.ImportDeclarations() {
+
+			resolvedLocations, err := resolveLocation(
+				context.Interface,
+				declaration.Identifiers,
+				declaration.Location,
+			)
+			if err != nil {
+				return err
+			}
+
+			for _, resolvedLocation := range resolvedLocations {
+				importedLocation := resolvedLocation.Location
+
+				locationID := importedLocation.ID()
+				if _, ok := seenDependencies[locationID]; ok {
+					continue
+				}
+				seenDependencies[locationID] = struct{}{}
+
+				dependencies = append(dependencies, importedLocation)
+
+				// The standard library's crypto contract is built-in and has no dependencies
+				if importedLocation == stdlib.CryptoChecker.Location {
+					continue
+				}
+
+				// The imported program was already checked when checking the program,
+				// so it is available
+
+				importedProgram, err := r.getProgram(
+					context.WithLocation(importedLocation),
+					functions,
+					values,
+					checkerOptions,
+					importResolutionResults{},
+				)
+				if err != nil {
+					return err
+				}
+
+				err = collectDependencies(importedProgram.Program)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	}
+
+	err = collectDependencies(program.Program)
+	if err != nil {
+		return nil, newError(err, context)
+	}
+
+	return dependencies, nil
+}
+
+func (r *interpreterRuntime) parseAndCheckProgram(
+	code []byte,
+	context Context,
 	functions stdlib.StandardLibraryFunctions,
 	values stdlib.StandardLibraryValues,
 	checkerOptions []sema.Option,
+	storeProgram bool,
 	checkedImports importResolutionResults,
 ) (
-	elaboration *sema.Elaboration,
+	program *interpreter.Program,
 	err error,
 ) {
+	wrapError := func(err error) error {
+		return &ParsingCheckingError{
+			Err:      err,
+			Location: context.Location,
+		}
+	}
+
+	if storeProgram {
+		context.SetCode(context.Location, string(code))
+	}
+
+	// Parse
+
+	var parse *ast.Program
+	reportMetric(
+		func() {
+			parse, err = parser2.ParseProgram(string(code))
+		},
+		context.Interface,
+		func(metrics Metrics, duration time.Duration) {
+			metrics.ProgramParsed(context.Location, duration)
+		},
+	)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+
+	if storeProgram {
+		context.SetProgram(context.Location, parse)
+	}
+
+	// Check
+
+	elaboration, err := r.check(parse, context, functions, values, checkerOptions, checkedImports)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+
+	// Return
+
+	program = &interpreter.Program{
+		Program:     parse,
+		Elaboration: elaboration,
+	}
+
+	if storeProgram {
+		wrapPanic(func() {
+			err = context.Interface.SetProgram(context.Location, program)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return program, nil
+}
 
+// semaValueDeclarations returns the values which are predeclared for the checker,
+// i.e. the given standard library functions and values, and the predeclared values of the context.
+//
+func semaValueDeclarations(
+	context Context,
+	functions stdlib.StandardLibraryFunctions,
+	values stdlib.StandardLibraryValues,
+) []sema.ValueDeclaration {
 	valueDeclarations := functions.ToSemaValueDeclarations()
 	valueDeclarations = append(valueDeclarations, values.ToSemaValueDeclarations()...)
 
-	for _, predeclaredValue := range startContext.PredeclaredValues {
+	for _, predeclaredValue := range context.PredeclaredValues {
 		valueDeclarations = append(valueDeclarations, predeclaredValue)
 	}
 
+	return valueDeclarations
+}
+
+func (r *interpreterRuntime) check(
+	program *ast.Program,
+	startContext Context,
+	functions stdlib.StandardLibraryFunctions,
+	values stdlib.StandardLibraryValues,
+	checkerOptions []sema.Option,
+	checkedImports importResolutionResults,
+) (
+	elaboration *sema.Elaboration,
+	err error,
+) {
+
+	valueDeclarations := semaValueDeclarations(startContext, functions, values)
+
 	checker, err := sema.NewChecker(
 		program,
 		startContext.Location,
@@ -993,11 +1627,8 @@ func (r *interpreterRuntime) check(
 				sema.WithPredeclaredTypes(typeDeclarations),
 				sema.WithValidTopLevelDeclarationsHandler(validTopLevelDeclarations),
 				sema.WithLocationHandler(
-					func(identifiers []Identifier, location Location) (res []ResolvedLocation, err error) {
-						wrapPanic(func() {
-							res, err = startContext.Interface.ResolveLocation(identifiers, location)
-						})
-						return
+					func(identifiers []Identifier, location Location) ([]ResolvedLocation, error) {
+						return resolveLocation(startContext.Interface, identifiers, location)
 					},
 				),
 				sema.WithImportHandler(
@@ -1035,6 +1666,9 @@ func (r *interpreterRuntime) check(
 						}, nil
 					},
 				),
+				sema.WithRejectStoredReferences(startContext.RejectStoredReferences),
+				sema.WithReferenceEscapeChecksEnabled(!startContext.AllowEscapingReferences),
+				sema.WithMaxTypeDepth(startContext.maxTypeDepth()),
 				sema.WithCheckHandler(func(location common.Location, check func()) {
 					reportMetric(
 						check,
@@ -1095,6 +1729,12 @@ func (r *interpreterRuntime) newInterpreter(
 	defaultOptions := []interpreter.Option{
 		interpreter.WithStorage(storage),
 		interpreter.WithPredeclaredValues(preDeclaredValues),
+		interpreter.WithMaxBorrowChainLength(context.MaxBorrowChainLength),
+		interpreter.WithMaxLoopIterations(context.MaxLoopIterations),
+		interpreter.WithMaxEvents(context.MaxEvents),
+		interpreter.WithResourceHistory(context.ResourceHistory),
+		interpreter.WithStorageUsedCache(storage.StorageUsedCache()),
+		interpreter.WithReadOnly(context.ReadOnly),
 		interpreter.WithOnEventEmittedHandler(
 			func(
 				inter *interpreter.Interpreter,
@@ -1133,7 +1773,6 @@ func (r *interpreterRuntime) newInterpreter(
 					compositeType,
 					constructorGenerator,
 					invocationRange,
-					storage,
 				)
 			},
 		),
@@ -1240,6 +1879,33 @@ func (r *interpreterRuntime) newInterpreter(
 		interpreter.WithAtreeStorageValidationEnabled(false),
 	}
 
+	// Only notify the runtime interface about destroyed resources if it is interested,
+	// so there is no overhead otherwise
+
+	if listener, ok := unwrapInterface(context.Interface).(ResourceDestructionListener); ok {
+		defaultOptions = append(defaultOptions,
+			interpreter.WithOnResourceDestroyedHandler(
+				func(_ *interpreter.Interpreter, typeID common.TypeID, uuid uint64) {
+					wrapPanic(func() {
+						listener.OnResourceDestroyed(string(typeID), uuid)
+					})
+				},
+			),
+		)
+	}
+
+	if context.ValidateStoredCapabilities {
+		defaultOptions = append(defaultOptions,
+			interpreter.WithOnCapabilityStoredHandler(storage.recordStoredCapability),
+		)
+	}
+
+	if context.MaxValueDepth > 0 {
+		defaultOptions = append(defaultOptions,
+			interpreter.WithMaxValueDepth(context.MaxValueDepth),
+		)
+	}
+
 	defaultOptions = append(defaultOptions,
 		r.meteringInterpreterOptions(context.Interface)...,
 	)
@@ -1321,22 +1987,58 @@ func (r *interpreterRuntime) getProgram(
 			return nil, err
 		}
 
-		program, err = r.parseAndCheckProgram(
-			code,
-			context,
-			functions,
-			values,
-			checkerOptions,
-			true,
-			checkedImports,
-		)
-		if err != nil {
-			return nil, err
+		cache := context.ContractCodeCache
+
+		var codeHash CodeHash
+		var fingerprint CheckerFingerprint
+		if cache != nil {
+			codeHash = ContractCodeHash(code)
+			fingerprint = checkerFingerprint(
+				context,
+				semaValueDeclarations(context, functions, values),
+			)
+			program = cache.GetProgram(context.Location, codeHash, fingerprint)
+		}
+
+		if program != nil {
+			// The program was checked in a previous execution,
+			// but it must still be made available in this one
+
+			context.SetCode(context.Location, string(code))
+
+			wrapPanic(func() {
+				err = context.Interface.SetProgram(context.Location, program)
+			})
+			if err != nil {
+				return nil, err
+			}
+
+		} else {
+			program, err = r.parseAndCheckProgram(
+				code,
+				context,
+				functions,
+				values,
+				checkerOptions,
+				true,
+				checkedImports,
+			)
+			if err != nil {
+				return nil, err
+			}
+
+			if cache != nil {
+				cache.SetProgram(context.Location, codeHash, fingerprint, program)
+			}
 		}
 	}
 
 	context.SetProgram(context.Location, program.Program)
 
+	if addressLocation, ok := context.Location.(common.AddressLocation); ok {
+		context.recordContractCodeLoaded(addressLocation)
+	}
+
 	return program, nil
 }
 
@@ -1423,8 +2125,6 @@ func (r *interpreterRuntime) meteringInterpreterOptions(runtimeInterface Interfa
 	}
 
 	callStackDepth := 0
-	// TODO: make runtime interface function
-	const callStackDepthLimit = 2000
 
 	checkCallStackDepth := func() {
 
@@ -1485,12 +2185,13 @@ func (r *interpreterRuntime) standardLibraryFunctions(
 	checkerOptions []sema.Option,
 ) stdlib.StandardLibraryFunctions {
 	builtins := stdlib.FlowBuiltInFunctions(stdlib.FlowBuiltinImpls{
-		CreateAccount:   r.newCreateAccountFunction(context, storage, interpreterOptions, checkerOptions),
-		GetAccount:      r.newGetAccountFunction(context.Interface, storage),
-		Log:             r.newLogFunction(context.Interface),
-		GetCurrentBlock: r.newGetCurrentBlockFunction(context.Interface),
-		GetBlock:        r.newGetBlockFunction(context.Interface),
-		UnsafeRandom:    r.newUnsafeRandomFunction(context.Interface),
+		CreateAccount:          r.newCreateAccountFunction(context, storage, interpreterOptions, checkerOptions),
+		GetAccount:             r.newGetAccountFunction(context.Interface, storage),
+		Log:                    r.newLogFunction(context.Interface),
+		GetCurrentBlock:        r.newGetCurrentBlockFunction(context.Interface),
+		GetBlock:               r.newGetBlockFunction(context.Interface),
+		UnsafeRandom:           r.newUnsafeRandomFunction(context.Interface),
+		GetPendingStorageDelta: r.newGetPendingStorageDeltaFunction(storage),
 	})
 
 	switch context.Location.(type) {
@@ -1556,9 +2257,25 @@ func (r *interpreterRuntime) emitEvent(
 	if err != nil {
 		return err
 	}
-	wrapPanic(func() {
-		err = runtimeInterface.EmitEvent(exportedEvent)
-	})
+
+	// NOTE: the runtime interface is not unwrapped, so events are passed through
+	// the runtime interfaces wrapped by the runtime, e.g. to record the events of a transaction in a batch.
+	// The wrapping runtime interfaces pass the names of the indexed fields on to the host,
+	// and the buffering runtime interface buffers them until the events are flushed
+	// (see IndexedBatchEventEmitter)
+
+	if len(eventType.IndexedFields) > 0 {
+		if emitter, ok := runtimeInterface.(IndexedEventEmitter); ok {
+			wrapPanic(func() {
+				err = emitter.EmitEventWithIndex(exportedEvent, eventType.IndexedFields)
+			})
+			return err
+		}
+	}
+
+	wrapPanic(func() {
+		err = runtimeInterface.EmitEvent(exportedEvent)
+	})
 	return err
 }
 
@@ -1704,6 +2421,16 @@ func storageUsedGetFunction(
 	address := addressValue.ToAddress()
 	return func(inter *interpreter.Interpreter) interpreter.UInt64Value {
 
+		// If the amount is cached, the account's storage was not written since it was queried,
+		// so neither a commit nor a query of the host environment is necessary
+
+		cache := inter.StorageUsedCache()
+		if cache != nil {
+			if used, ok := cache.Get(address); ok {
+				return interpreter.UInt64Value(used)
+			}
+		}
+
 		// NOTE: flush the cached values, so the host environment
 		// can properly calculate the amount of storage used by the account
 		const commitContractUpdates = false
@@ -1712,24 +2439,49 @@ func storageUsedGetFunction(
 			panic(err)
 		}
 
-		var capacity uint64
+		var used uint64
 		wrapPanic(func() {
-			capacity, err = runtimeInterface.GetStorageUsed(address)
+			used, err = runtimeInterface.GetStorageUsed(address)
 		})
 		if err != nil {
 			panic(err)
 		}
-		return interpreter.UInt64Value(capacity)
+
+		if cache != nil {
+			cache.Set(address, used)
+		}
+
+		return interpreter.UInt64Value(used)
+	}
+}
+
+// invalidateStorageUsed invalidates the cached amount of storage used by the given account, if any.
+// The host environment stores e.g. the keys of an account in the account's storage,
+// so the amount changes when the host environment changes them.
+//
+func invalidateStorageUsed(inter *interpreter.Interpreter, address common.Address) {
+	cache := inter.StorageUsedCache()
+	if cache == nil {
+		return
 	}
+
+	cache.Invalidate(address)
 }
 
 func storageCapacityGetFunction(addressValue interpreter.AddressValue, runtimeInterface Interface) func() interpreter.UInt64Value {
 	address := addressValue.ToAddress()
 	return func() interpreter.UInt64Value {
+		provider, ok := getStorageCapacityProvider(runtimeInterface)
+		if !ok {
+			panic(StorageCapacityUnavailableError{
+				Address: address,
+			})
+		}
+
 		var capacity uint64
 		var err error
 		wrapPanic(func() {
-			capacity, err = runtimeInterface.GetStorageCapacity(address)
+			capacity, err = provider.GetStorageCapacity(address)
 		})
 		if err != nil {
 			panic(err)
@@ -1760,6 +2512,8 @@ func (r *interpreterRuntime) newAddPublicKeyFunction(
 
 			inter := invocation.Interpreter
 
+			invalidateStorageUsed(inter, addressValue.ToAddress())
+
 			r.emitAccountEvent(
 				stdlib.AccountKeyAddedEventType,
 				runtimeInterface,
@@ -1794,6 +2548,8 @@ func (r *interpreterRuntime) newRemovePublicKeyFunction(
 
 			inter := invocation.Interpreter
 
+			invalidateStorageUsed(inter, addressValue.ToAddress())
+
 			publicKeyValue := interpreter.ByteSliceToByteArrayValue(
 				inter,
 				publicKey,
@@ -1844,7 +2600,6 @@ func (r *interpreterRuntime) loadContract(
 	compositeType *sema.CompositeType,
 	constructorGenerator func(common.Address) *interpreter.HostFunctionValue,
 	invocationRange ast.Range,
-	storage *Storage,
 ) *interpreter.CompositeValue {
 
 	switch compositeType.Location {
@@ -1866,8 +2621,7 @@ func (r *interpreterRuntime) loadContract(
 		switch location := compositeType.Location.(type) {
 
 		case common.AddressLocation:
-			storedValue = storage.ReadValue(
-				inter,
+			storedValue = inter.ReadStored(
 				location.Address,
 				formatContractKey(location.Name),
 			)
@@ -1992,7 +2746,6 @@ func (r *interpreterRuntime) instantiateContract(
 					compositeType,
 					constructorGenerator,
 					invocationRange,
-					storage,
 				)
 			},
 		),
@@ -2182,6 +2935,12 @@ func (r *interpreterRuntime) newUnsafeRandomFunction(runtimeInterface Interface)
 	}
 }
 
+func (r *interpreterRuntime) newGetPendingStorageDeltaFunction(storage *Storage) interpreter.HostFunction {
+	return func(invocation interpreter.Invocation) interpreter.Value {
+		return interpreter.Int64Value(storage.PendingStorageDelta())
+	}
+}
+
 func (r *interpreterRuntime) newAuthAccountContracts(
 	addressValue interpreter.AddressValue,
 	context Context,
@@ -2213,7 +2972,7 @@ func (r *interpreterRuntime) newAuthAccountContracts(
 		),
 		r.newAuthAccountContractsRemoveFunction(
 			addressValue,
-			context.Interface,
+			context,
 			storage,
 		),
 		r.newAccountContractsGetNamesFunction(
@@ -2259,6 +3018,18 @@ func (r *interpreterRuntime) newAuthAccountContractsChangeFunction(
 	return interpreter.NewHostFunctionValue(
 		func(invocation interpreter.Invocation) interpreter.Value {
 
+			if startContext.ReadOnly {
+				operation := sema.AuthAccountContractsTypeAddFunctionName
+				if isUpdate {
+					operation = sema.AuthAccountContractsTypeUpdateExperimentalFunctionName
+				}
+
+				panic(interpreter.ReadOnlyViolationError{
+					Operation:     operation,
+					LocationRange: invocation.GetLocationRange(),
+				})
+			}
+
 			const requiredArgumentCount = 2
 
 			nameValue := invocation.Arguments[0].(*interpreter.StringValue)
@@ -2608,6 +3379,12 @@ func (r *interpreterRuntime) updateAccountContractCode(
 		return err
 	}
 
+	storage.invalidateStorageUsed(address)
+
+	if context.ContractCodeCache != nil {
+		context.ContractCodeCache.Invalidate(context.Location)
+	}
+
 	if createContract {
 		// NOTE: the contract recording delays the write
 		// until the end of the execution of the program
@@ -2665,12 +3442,21 @@ func (r *interpreterRuntime) newAccountContractsGetFunction(
 
 func (r *interpreterRuntime) newAuthAccountContractsRemoveFunction(
 	addressValue interpreter.AddressValue,
-	runtimeInterface Interface,
+	context Context,
 	storage *Storage,
 ) *interpreter.HostFunctionValue {
+	runtimeInterface := context.Interface
+
 	return interpreter.NewHostFunctionValue(
 		func(invocation interpreter.Invocation) interpreter.Value {
 
+			if context.ReadOnly {
+				panic(interpreter.ReadOnlyViolationError{
+					Operation:     sema.AuthAccountContractsTypeRemoveFunctionName,
+					LocationRange: invocation.GetLocationRange(),
+				})
+			}
+
 			inter := invocation.Interpreter
 			nameValue := invocation.Arguments[0].(*interpreter.StringValue)
 
@@ -2719,6 +3505,17 @@ func (r *interpreterRuntime) newAuthAccountContractsRemoveFunction(
 					panic(err)
 				}
 
+				storage.invalidateStorageUsed(address)
+
+				if context.ContractCodeCache != nil {
+					context.ContractCodeCache.Invalidate(
+						common.AddressLocation{
+							Address: address,
+							Name:    nameArgument,
+						},
+					)
+				}
+
 				// NOTE: the contract recording function delays the write
 				// until the end of the execution of the program
 
@@ -2809,7 +3606,10 @@ func (r *interpreterRuntime) executeNonProgram(interpret interpretFunc, context
 
 	var program *interpreter.Program
 
-	storage := r.newStorage(context.Interface)
+	storage, err := r.newStorage(context)
+	if err != nil {
+		return nil, newError(err, context)
+	}
 
 	var functions stdlib.StandardLibraryFunctions
 	var values stdlib.StandardLibraryValues
@@ -2830,11 +3630,11 @@ func (r *interpreterRuntime) executeNonProgram(interpret interpretFunc, context
 		return nil, newError(err, context)
 	}
 
-	return exportValue(value)
+	return exportValue(value, context.UnknownTypeHandler)
 }
 
 func (r *interpreterRuntime) ReadStored(address common.Address, path cadence.Path, context Context) (cadence.Value, error) {
-	return r.executeNonProgram(
+	return r.readWithCapabilityChain(
 		func(inter *interpreter.Interpreter) (interpreter.Value, error) {
 			key := interpreter.PathToStorageKey(importPathValue(path))
 			value := inter.ReadStored(address, key)
@@ -2844,155 +3644,1266 @@ func (r *interpreterRuntime) ReadStored(address common.Address, path cadence.Pat
 	)
 }
 
-func (r *interpreterRuntime) ReadLinked(address common.Address, path cadence.Path, context Context) (cadence.Value, error) {
+func (r *interpreterRuntime) ReadStoredResolvingCapabilities(
+	address common.Address,
+	path cadence.Path,
+	context Context,
+) (
+	cadence.Value,
+	error,
+) {
 	return r.executeNonProgram(
-		func(inter *interpreter.Interpreter) (interpreter.Value, error) {
-			key, _, err := inter.GetCapabilityFinalTargetStorageKey(
-				address,
-				importPathValue(path),
-				&sema.ReferenceType{
-					Type: sema.AnyType,
-				},
-				interpreter.ReturnEmptyLocationRange,
-			)
-			if err != nil {
-				return nil, err
-			}
+		func(inter *interpreter.Interpreter) (result interpreter.Value, err error) {
+
+			// Recover internal panics and return them as an error.
+			// For example, the borrow type of the capability might not be loadable
+
+			defer inter.RecoverErrors(func(internalErr error) {
+				err = internalErr
+			})
+
+			key := interpreter.PathToStorageKey(importPathValue(path))
 			value := inter.ReadStored(address, key)
-			return value, nil
+
+			someValue, ok := value.(*interpreter.SomeValue)
+			if !ok {
+				return value, nil
+			}
+
+			capability, ok := someValue.Value.(*interpreter.CapabilityValue)
+			if !ok {
+				return value, nil
+			}
+
+			return resolveCapability(inter, capability)
 		},
 		context,
 	)
 }
 
-var BlockIDStaticType = interpreter.ConstantSizedStaticType{
-	Type: interpreter.PrimitiveStaticTypeUInt8,
-	Size: 32,
-}
+// resolveCapability returns the value stored at the target of the given capability,
+// or a BrokenCapabilityError if the capability cannot be borrowed.
+//
+func resolveCapability(
+	inter *interpreter.Interpreter,
+	capability *interpreter.CapabilityValue,
+) (
+	interpreter.Value,
+	error,
+) {
+	capabilityAddress := capability.Address.ToAddress()
 
-func NewBlockValue(inter *interpreter.Interpreter, block Block) interpreter.Value {
+	brokenCapabilityError := BrokenCapabilityError{
+		Address: capabilityAddress,
+		Path:    capability.Path,
+	}
 
-	// height
-	heightValue := interpreter.UInt64Value(block.Height)
+	borrowType, ok := capabilityBorrowType(inter, capability)
+	if !ok {
+		return nil, brokenCapabilityError
+	}
 
-	// view
-	viewValue := interpreter.UInt64Value(block.View)
+	key, _, err := inter.GetCapabilityFinalTargetStorageKey(
+		capabilityAddress,
+		capability.Path,
+		borrowType,
+		interpreter.ReturnEmptyLocationRange,
+	)
+	if err != nil {
+		return nil, err
+	}
 
-	// ID
-	var values = make([]interpreter.Value, sema.BlockIDSize)
-	for i, b := range block.Hash {
-		values[i] = interpreter.UInt8Value(b)
+	if key == "" {
+		return nil, brokenCapabilityError
 	}
 
-	idValue := interpreter.NewArrayValue(
-		inter,
-		BlockIDStaticType,
-		common.Address{},
-		values...,
-	)
+	// Like a borrow, ensure the target value has the borrow type
 
-	// timestamp
-	// TODO: verify
-	timestampValue := interpreter.NewUFix64ValueWithInteger(uint64(time.Unix(0, block.Timestamp).Unix()))
+	target, ok := inter.ReadStored(capabilityAddress, key).(*interpreter.SomeValue)
+	if !ok {
+		return nil, brokenCapabilityError
+	}
 
-	return interpreter.NewBlockValue(
-		heightValue,
-		viewValue,
-		idValue,
-		timestampValue,
-	)
+	dynamicType := target.Value.DynamicType(inter, interpreter.SeenReferences{})
+	if !inter.IsSubType(dynamicType, borrowType.Type) {
+		return nil, brokenCapabilityError
+	}
+
+	return target, nil
 }
 
-func (r *interpreterRuntime) newAccountKeysAddFunction(
-	addressValue interpreter.AddressValue,
-	runtimeInterface Interface,
-) *interpreter.HostFunctionValue {
-	return interpreter.NewHostFunctionValue(
-		func(invocation interpreter.Invocation) interpreter.Value {
-			publicKeyValue := invocation.Arguments[0].(*interpreter.CompositeValue)
+// capabilityBorrowType returns the type the given capability can be borrowed as.
+// Untyped capabilities can be borrowed as any type.
+//
+func capabilityBorrowType(
+	inter *interpreter.Interpreter,
+	capability *interpreter.CapabilityValue,
+) (
+	*sema.ReferenceType,
+	bool,
+) {
+	if capability.BorrowType == nil {
+		return &sema.ReferenceType{
+			Type: sema.AnyType,
+		}, true
+	}
 
-			inter := invocation.Interpreter
-			getLocationRange := invocation.GetLocationRange
+	borrowType, ok := inter.MustConvertStaticToSemaType(capability.BorrowType).(*sema.ReferenceType)
+	return borrowType, ok
+}
 
-			publicKey, err := NewPublicKeyFromValue(inter, getLocationRange, publicKeyValue)
-			if err != nil {
-				panic(err)
-			}
+// readWithCapabilityChain reads a value using the given function.
+//
+// If the context requests the resolution of capability chains,
+// and the read value is a capability, or an optional capability,
+// the chain of paths through which the capability is linked is included in the exported capability.
+//
+func (r *interpreterRuntime) readWithCapabilityChain(
+	read func(inter *interpreter.Interpreter) (interpreter.Value, error),
+	context Context,
+) (
+	cadence.Value,
+	error,
+) {
+	var chain cadence.CapabilityChain
 
-			hashAlgo := NewHashAlgorithmFromValue(inter, getLocationRange, invocation.Arguments[1])
-			address := addressValue.ToAddress()
-			weight := invocation.Arguments[2].(interpreter.UFix64Value).ToInt()
+	value, err := r.executeNonProgram(
+		func(inter *interpreter.Interpreter) (result interpreter.Value, err error) {
 
-			var accountKey *AccountKey
-			wrapPanic(func() {
-				accountKey, err = runtimeInterface.AddAccountKey(address, publicKey, hashAlgo, weight)
+			// Recover internal panics and return them as an error.
+			// For example, the borrow type of the capability might not be loadable
+
+			defer inter.RecoverErrors(func(internalErr error) {
+				err = internalErr
 			})
-			if err != nil {
-				panic(err)
+
+			value, err := read(inter)
+			if err != nil || !context.ResolveCapabilityChains {
+				return value, err
 			}
 
-			r.emitAccountEvent(
-				stdlib.AccountKeyAddedEventType,
-				runtimeInterface,
-				[]exportableValue{
-					newExportableValue(addressValue, inter),
-					newExportableValue(publicKeyValue, inter),
-				},
-			)
+			chain, err = capabilityChain(inter, value)
+			if err != nil {
+				return nil, err
+			}
 
-			return NewAccountKeyValue(
-				inter,
-				getLocationRange,
-				accountKey,
-				inter.PublicKeyValidationHandler,
-			)
+			return value, nil
 		},
-		sema.AuthAccountKeysTypeAddFunctionType,
+		context,
 	)
-}
+	if err != nil {
+		return nil, err
+	}
 
-func (r *interpreterRuntime) newAccountKeysGetFunction(
-	addressValue interpreter.AddressValue,
-	runtimeInterface Interface,
-) *interpreter.HostFunctionValue {
-	return interpreter.NewHostFunctionValue(
-		func(invocation interpreter.Invocation) interpreter.Value {
-			index := invocation.Arguments[0].(interpreter.IntValue).ToInt()
-			address := addressValue.ToAddress()
+	if chain.IsEmpty() {
+		return value, nil
+	}
 
-			var err error
-			var accountKey *AccountKey
-			wrapPanic(func() {
-				accountKey, err = runtimeInterface.GetAccountKey(address, index)
-			})
+	switch value := value.(type) {
+	case cadence.Capability:
+		value.Chain = chain
+		return value, nil
+
+	case cadence.Optional:
+		if capability, ok := value.Value.(cadence.Capability); ok {
+			capability.Chain = chain
+			value.Value = capability
+		}
+		return value, nil
 
-			if err != nil {
-				panic(err)
-			}
+	default:
+		return value, nil
+	}
+}
 
-			// Here it is expected the host function to return a nil key, if a key is not found at the given index.
-			// This is done because, if the host function returns an error when a key is not found, then
-			// currently there's no way to distinguish between a 'key not found error' vs other internal errors.
-			if accountKey == nil {
-				return interpreter.NilValue{}
-			}
+// capabilityChain returns the chain of paths through which the given capability,
+// or optional capability, is linked.
+//
+// The chain is empty if the value is not a capability,
+// or if the capability cannot be borrowed.
+//
+func capabilityChain(
+	inter *interpreter.Interpreter,
+	value interpreter.Value,
+) (
+	cadence.CapabilityChain,
+	error,
+) {
+	if someValue, ok := value.(*interpreter.SomeValue); ok {
+		value = someValue.Value
+	}
 
-			inter := invocation.Interpreter
+	capability, ok := value.(*interpreter.CapabilityValue)
+	if !ok {
+		return cadence.CapabilityChain{}, nil
+	}
 
-			return interpreter.NewSomeValueNonCopying(
-				NewAccountKeyValue(
-					inter,
-					invocation.GetLocationRange,
-					accountKey,
-					inter.PublicKeyValidationHandler,
-				),
-			)
-		},
-		sema.AccountKeysTypeGetFunctionType,
+	borrowType, ok := capabilityBorrowType(inter, capability)
+	if !ok {
+		return cadence.CapabilityChain{}, nil
+	}
+
+	paths, err := inter.GetCapabilityLinkChain(
+		capability.Address.ToAddress(),
+		capability.Path,
+		borrowType,
+		interpreter.ReturnEmptyLocationRange,
 	)
+	if err != nil || paths == nil {
+		return cadence.CapabilityChain{}, err
+	}
+
+	chain := make([]cadence.Path, len(paths))
+	for i, path := range paths {
+		chain[i] = exportPathValue(path)
+	}
+
+	return cadence.NewCapabilityChain(chain), nil
 }
 
-func (r *interpreterRuntime) newAccountKeysRevokeFunction(
+func (r *interpreterRuntime) WriteStored(
+	address common.Address,
+	path cadence.Path,
+	value cadence.Value,
+	context Context,
+) error {
+	context.InitializeCodesAndPrograms()
+
+	storage, err := r.newStorage(context)
+	if err != nil {
+		return newError(err, context)
+	}
+
+	var program *interpreter.Program
+	var functions stdlib.StandardLibraryFunctions
+	var values stdlib.StandardLibraryValues
+	var interpreterOptions []interpreter.Option
+	var checkerOptions []sema.Option
+
+	_, inter, err := r.interpret(
+		program,
+		context,
+		storage,
+		functions,
+		values,
+		interpreterOptions,
+		checkerOptions,
+		func(inter *interpreter.Interpreter) (result interpreter.Value, err error) {
+
+			// Recover internal panics and return them as an error.
+			// For example, the import might attempt to load contract code
+			// for non-existing types
+
+			defer inter.RecoverErrors(func(internalErr error) {
+				err = internalErr
+			})
+
+			err = writeStored(inter, storage, address, path, value, context)
+			if err != nil {
+				return nil, err
+			}
+
+			return interpreter.VoidValue{}, nil
+		},
+	)
+	if err != nil {
+		return newError(err, context)
+	}
+
+	err = r.commitStorage(storage, inter, context)
+	if err != nil {
+		return newError(err, context)
+	}
+
+	return nil
+}
+
+func writeStored(
+	inter *interpreter.Interpreter,
+	storage *Storage,
+	address common.Address,
+	path cadence.Path,
+	value cadence.Value,
+	context Context,
+) error {
+	pathValue := importPathValue(path)
+
+	if pathValue.Domain != common.PathDomainStorage {
+		return interpreter.InvalidPathDomainError{
+			ActualDomain:    pathValue.Domain,
+			ExpectedDomains: []common.PathDomain{common.PathDomainStorage},
+		}
+	}
+
+	key := interpreter.PathToStorageKey(pathValue)
+
+	if storage.ValueExists(inter, address, key) {
+		return interpreter.OverwriteError{
+			Address: interpreter.NewAddressValue(address),
+			Path:    pathValue,
+		}
+	}
+
+	importedValue, err := importValue(inter, value, nil)
+	if err != nil {
+		return err
+	}
+
+	semaType := inter.MustConvertStaticToSemaType(importedValue.StaticType())
+	if !semaType.IsStorable(map[*sema.Member]bool{}) {
+		return interpreter.NonStorableValueError{
+			Value: importedValue,
+		}
+	}
+
+	// Imported resources are new resources, so they must get a fresh UUID
+
+	var resources []*interpreter.CompositeValue
+
+	interpreter.InspectValue(
+		importedValue,
+		func(value interpreter.Value) bool {
+			if composite, ok := value.(*interpreter.CompositeValue); ok &&
+				composite.Kind == common.CompositeKindResource {
+
+				resources = append(resources, composite)
+			}
+			return true
+		},
+	)
+
+	for _, resource := range resources {
+		var uuid uint64
+		wrapPanic(func() {
+			uuid, err = context.Interface.GenerateUUID()
+		})
+		if err != nil {
+			return err
+		}
+
+		resource.SetMember(
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			sema.ResourceUUIDFieldName,
+			interpreter.UInt64Value(uuid),
+		)
+	}
+
+	importedValue = importedValue.Transfer(
+		inter,
+		interpreter.ReturnEmptyLocationRange,
+		atree.Address(address),
+		true,
+		nil,
+	)
+
+	storage.WriteValue(
+		inter,
+		address,
+		key,
+		interpreter.NewSomeValueNonCopying(importedValue),
+	)
+
+	return nil
+}
+
+func (r *interpreterRuntime) ReadLinked(address common.Address, path cadence.Path, context Context) (cadence.Value, error) {
+	return r.readWithCapabilityChain(
+		func(inter *interpreter.Interpreter) (interpreter.Value, error) {
+			key, _, err := inter.GetCapabilityFinalTargetStorageKey(
+				address,
+				importPathValue(path),
+				&sema.ReferenceType{
+					Type: sema.AnyType,
+				},
+				interpreter.ReturnEmptyLocationRange,
+			)
+			if err != nil {
+				return nil, err
+			}
+			value := inter.ReadStored(address, key)
+			return value, nil
+		},
+		context,
+	)
+}
+
+// resolveViewFunctionName is the name of the conventional function
+// which resolves a view of a value, e.g. `fun resolveView(_ view: Type): AnyStruct?`
+//
+const resolveViewFunctionName = "resolveView"
+
+// resolveViewFunctionType is the type the `resolveView` function must have
+//
+var resolveViewFunctionType = &sema.FunctionType{
+	Parameters: []*sema.Parameter{
+		{
+			Label:          sema.ArgumentLabelNotRequired,
+			Identifier:     "view",
+			TypeAnnotation: sema.NewTypeAnnotation(sema.MetaType),
+		},
+	},
+	ReturnTypeAnnotation: sema.NewTypeAnnotation(
+		&sema.OptionalType{
+			Type: sema.AnyStructType,
+		},
+	),
+}
+
+// isResolveViewFunctionType returns true if the given function type is
+// the type of a `resolveView` function, i.e. if it can be invoked with a type,
+// and it returns an optional struct
+//
+func isResolveViewFunctionType(functionType *sema.FunctionType) bool {
+	if _, ok := functionType.ReturnTypeAnnotation.Type.(*sema.OptionalType); !ok {
+		return false
+	}
+
+	return sema.IsSubType(functionType, resolveViewFunctionType)
+}
+
+func (r *interpreterRuntime) ResolveViews(
+	address common.Address,
+	path cadence.Path,
+	viewTypes []cadence.Type,
+	context Context,
+) (
+	map[string]cadence.Value,
+	error,
+) {
+	context.InitializeCodesAndPrograms()
+
+	storage, err := r.newStorage(context)
+	if err != nil {
+		return nil, newError(err, context)
+	}
+
+	var interpreterOptions []interpreter.Option
+	var checkerOptions []sema.Option
+
+	functions := r.standardLibraryFunctions(
+		context,
+		storage,
+		interpreterOptions,
+		checkerOptions,
+	)
+
+	pathValue := importPathValue(path)
+
+	views := make(map[string]cadence.Value, len(viewTypes))
+
+	_, _, err = r.interpret(
+		nil,
+		context,
+		storage,
+		functions,
+		stdlib.BuiltinValues(),
+		interpreterOptions,
+		checkerOptions,
+		func(inter *interpreter.Interpreter) (_ interpreter.Value, err error) {
+
+			// Recover internal panics and return them as an error.
+			// For example, the resolution of a view might fail
+
+			defer inter.RecoverErrors(func(internalErr error) {
+				err = internalErr
+			})
+
+			getLocationRange := func() interpreter.LocationRange {
+				return interpreter.LocationRange{
+					Location: context.Location,
+				}
+			}
+
+			key, _, err := inter.GetCapabilityFinalTargetStorageKey(
+				address,
+				pathValue,
+				&sema.ReferenceType{
+					Type: sema.AnyType,
+				},
+				getLocationRange,
+			)
+			if err != nil {
+				return nil, err
+			}
+
+			var resolver interpreter.MemberAccessibleValue
+			var resolveView interpreter.FunctionValue
+
+			if key != "" {
+				if someValue, ok := inter.ReadStored(address, key).(*interpreter.SomeValue); ok {
+					resolver, ok = someValue.Value.(interpreter.MemberAccessibleValue)
+					if ok {
+						member := resolver.GetMember(inter, getLocationRange, resolveViewFunctionName)
+						resolveView, _ = member.(interpreter.FunctionValue)
+					}
+				}
+			}
+
+			if resolveView == nil {
+				return nil, ViewResolverNotFoundError{
+					Address: address,
+					Path:    pathValue,
+				}
+			}
+
+			// The function is invoked with a type, and its result must be an optional,
+			// so check the function's type before invoking it
+
+			functionStaticType, ok := resolveView.StaticType().(interpreter.FunctionStaticType)
+			if !ok || !isResolveViewFunctionType(functionStaticType.Type) {
+				var functionType sema.Type
+				if ok {
+					functionType = functionStaticType.Type
+				}
+
+				return nil, ViewResolverTypeMismatchError{
+					Address: address,
+					Path:    pathValue,
+					Type:    functionType,
+				}
+			}
+
+			for _, viewType := range viewTypes {
+
+				viewTypeValue, err := importTypeValue(inter, viewType)
+				if err != nil {
+					return nil, err
+				}
+
+				invocation := interpreter.Invocation{
+					Self: resolver,
+					Arguments: []interpreter.Value{
+						viewTypeValue,
+					},
+					ArgumentTypes: []sema.Type{
+						sema.MetaType,
+					},
+					GetLocationRange: getLocationRange,
+					Interpreter:      inter,
+				}
+
+				result, err := inter.InvokeFunction(resolveView, invocation)
+				if err != nil {
+					return nil, err
+				}
+
+				someView, ok := result.(*interpreter.SomeValue)
+				if !ok {
+					continue
+				}
+
+				view, err := ExportValue(someView.Value, inter)
+				if err != nil {
+					return nil, err
+				}
+
+				views[viewType.ID()] = view
+			}
+
+			return interpreter.VoidValue{}, nil
+		},
+	)
+	if err != nil {
+		return nil, newError(err, context)
+	}
+
+	return views, nil
+}
+
+func (r *interpreterRuntime) StoredCapabilityTypes(
+	address common.Address,
+	context Context,
+) (
+	types map[cadence.Path]string,
+	unresolved []cadence.Path,
+	err error,
+) {
+	iterator, ok, err := accountRegisterIterator(context)
+	if err != nil {
+		return nil, nil, newError(err, context)
+	}
+	if !ok {
+		return nil, nil, newError(
+			fmt.Errorf(
+				"cannot get stored capability types: ledger does not support iterating over account registers",
+			),
+			context,
+		)
+	}
+
+	// Gather the paths of all stored values
+
+	var paths []interpreter.PathValue
+
+	wrapPanic(func() {
+		err = iterator.ForEachAccountRegister(
+			address[:],
+			func(key []byte, value []byte) error {
+				// Empty registers do not exist
+				if len(value) == 0 {
+					return nil
+				}
+
+				domain, identifier, ok := interpreter.PathForStorageKey(string(key))
+				if !ok {
+					return nil
+				}
+
+				paths = append(
+					paths,
+					interpreter.PathValue{
+						Domain:     domain,
+						Identifier: identifier,
+					},
+				)
+				return nil
+			},
+		)
+	})
+	if err != nil {
+		return nil, nil, newError(err, context)
+	}
+
+	sort.Slice(paths, func(i, j int) bool {
+		return interpreter.PathToStorageKey(paths[i]) < interpreter.PathToStorageKey(paths[j])
+	})
+
+	types = map[cadence.Path]string{}
+
+	_, err = r.executeNonProgram(
+		func(inter *interpreter.Interpreter) (interpreter.Value, error) {
+			for _, path := range paths {
+				key := interpreter.PathToStorageKey(path)
+
+				someValue, ok := inter.ReadStored(address, key).(*interpreter.SomeValue)
+				if !ok {
+					continue
+				}
+
+				capability, ok := someValue.Value.(*interpreter.CapabilityValue)
+				if !ok {
+					continue
+				}
+
+				borrowTypeID, ok := capabilityBorrowTypeID(inter, capability)
+				if !ok {
+					unresolved = append(unresolved, exportPathValue(path))
+					continue
+				}
+
+				types[exportPathValue(path)] = borrowTypeID
+			}
+
+			return interpreter.VoidValue{}, nil
+		},
+		context,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return types, unresolved, nil
+}
+
+// capabilityBorrowTypeID returns the ID of the borrow type of the given capability,
+// or an empty ID if the capability is untyped.
+//
+// It returns false if the borrow type cannot be loaded,
+// e.g. because the contract which declares the type was removed.
+//
+func capabilityBorrowTypeID(
+	inter *interpreter.Interpreter,
+	capability *interpreter.CapabilityValue,
+) (
+	string,
+	bool,
+) {
+	if capability.BorrowType == nil {
+		return "", true
+	}
+
+	borrowType, ok := convertStaticToSemaType(inter, capability.BorrowType)
+	if !ok {
+		return "", false
+	}
+
+	return string(borrowType.ID()), true
+}
+
+// convertStaticToSemaType converts the given static type to a sema type.
+//
+// It returns false if the type cannot be loaded,
+// e.g. because the contract which declares the type was removed.
+//
+func convertStaticToSemaType(
+	inter *interpreter.Interpreter,
+	staticType interpreter.StaticType,
+) (
+	semaType sema.Type,
+	ok bool,
+) {
+	defer inter.RecoverErrors(func(_ error) {
+		semaType = nil
+		ok = false
+	})
+
+	return inter.MustConvertStaticToSemaType(staticType), true
+}
+
+// CapabilityStatus is the result of checking a link.
+//
+type CapabilityStatus struct {
+	// IsLinked is true if a link is stored at the path
+	IsLinked bool
+	// TargetExists is true if a value is stored at the final target of the link
+	TargetExists bool
+	// IsBorrowable is true if the target value can be borrowed as the type declared by the link
+	IsBorrowable bool
+}
+
+func (r *interpreterRuntime) IterateStorageDomain(
+	address common.Address,
+	domain common.PathDomain,
+	fn func(path cadence.Path, typ cadence.Type) bool,
+	context Context,
+) error {
+
+	if domain != common.PathDomainStorage &&
+		domain != common.PathDomainPublic &&
+		domain != common.PathDomainPrivate {
+
+		return newError(
+			interpreter.InvalidPathDomainError{
+				ActualDomain: domain,
+				ExpectedDomains: []common.PathDomain{
+					common.PathDomainStorage,
+					common.PathDomainPublic,
+					common.PathDomainPrivate,
+				},
+			},
+			context,
+		)
+	}
+
+	iterator, ok, err := accountRegisterIterator(context)
+	if err != nil {
+		return newError(err, context)
+	}
+	if !ok {
+		return newError(
+			fmt.Errorf(
+				"cannot iterate storage domain: ledger does not support iterating over account registers",
+			),
+			context,
+		)
+	}
+
+	// Gather the identifiers of all values stored in the domain
+
+	var identifiers []string
+
+	wrapPanic(func() {
+		err = iterator.ForEachAccountRegister(
+			address[:],
+			func(key []byte, value []byte) error {
+				// Empty registers do not exist
+				if len(value) == 0 {
+					return nil
+				}
+
+				keyDomain, identifier, ok := interpreter.PathForStorageKey(string(key))
+				if !ok || keyDomain != domain {
+					return nil
+				}
+
+				identifiers = append(identifiers, identifier)
+				return nil
+			},
+		)
+	})
+	if err != nil {
+		return newError(err, context)
+	}
+
+	sort.Strings(identifiers)
+
+	_, err = r.executeNonProgram(
+		func(inter *interpreter.Interpreter) (interpreter.Value, error) {
+
+			exportedTypes := map[sema.TypeID]cadence.Type{}
+
+			for _, identifier := range identifiers {
+				path := interpreter.PathValue{
+					Domain:     domain,
+					Identifier: identifier,
+				}
+
+				someValue, ok := inter.ReadStored(address, interpreter.PathToStorageKey(path)).(*interpreter.SomeValue)
+				if !ok {
+					continue
+				}
+
+				// NOTE: Getting the static type of the stored value
+				// does not load the elements or fields of the value
+
+				var staticType interpreter.StaticType
+				if link, ok := someValue.Value.(interpreter.LinkValue); ok {
+					staticType = link.Type
+				} else {
+					staticType = someValue.Value.StaticType()
+				}
+
+				var typ cadence.Type
+				if staticType != nil {
+					semaType, ok := convertStaticToSemaType(inter, staticType)
+					if !ok {
+						continue
+					}
+					typ = ExportType(semaType, exportedTypes)
+				}
+
+				if !fn(exportPathValue(path), typ) {
+					break
+				}
+			}
+
+			return interpreter.VoidValue{}, nil
+		},
+		context,
+	)
+	return err
+}
+
+func (r *interpreterRuntime) ValidateAccountStorage(
+	address common.Address,
+	context Context,
+) (
+	report *StorageValidationReport,
+	err error,
+) {
+	iterator, ok, err := accountRegisterIterator(context)
+	if err != nil {
+		return nil, newError(err, context)
+	}
+	if !ok {
+		return nil, fmt.Errorf(
+			"cannot validate account storage: ledger does not support iterating over account registers",
+		)
+	}
+
+	wrapPanic(func() {
+		report, err = ValidateAccountStorage(iterator, address)
+	})
+	if err != nil {
+		return nil, newError(err, context)
+	}
+
+	return report, nil
+}
+
+func (r *interpreterRuntime) CheckCapability(
+	address common.Address,
+	path cadence.Path,
+	context Context,
+) (
+	status CapabilityStatus,
+	err error,
+) {
+	pathValue := importPathValue(path)
+
+	if pathValue.Domain != common.PathDomainPublic &&
+		pathValue.Domain != common.PathDomainPrivate {
+
+		return CapabilityStatus{}, newError(
+			interpreter.InvalidPathDomainError{
+				ActualDomain: pathValue.Domain,
+				ExpectedDomains: []common.PathDomain{
+					common.PathDomainPublic,
+					common.PathDomainPrivate,
+				},
+			},
+			context,
+		)
+	}
+
+	_, err = r.executeNonProgram(
+		func(inter *interpreter.Interpreter) (_ interpreter.Value, err error) {
+
+			// Recover internal panics and return them as an error.
+			// For example, the borrow type of the link might not be loadable anymore
+
+			defer inter.RecoverErrors(func(internalErr error) {
+				err = internalErr
+			})
+
+			status = checkLink(inter, address, pathValue)
+
+			return interpreter.VoidValue{}, nil
+		},
+		context,
+	)
+	if err != nil {
+		return CapabilityStatus{}, err
+	}
+
+	return status, nil
+}
+
+func (r *interpreterRuntime) GetLinkTarget(
+	address common.Address,
+	path cadence.Path,
+	context Context,
+) (
+	target cadence.Path,
+	linked bool,
+	err error,
+) {
+	pathValue := importPathValue(path)
+
+	if pathValue.Domain != common.PathDomainPublic &&
+		pathValue.Domain != common.PathDomainPrivate {
+
+		return cadence.Path{}, false, newError(
+			interpreter.InvalidPathDomainError{
+				ActualDomain: pathValue.Domain,
+				ExpectedDomains: []common.PathDomain{
+					common.PathDomainPublic,
+					common.PathDomainPrivate,
+				},
+			},
+			context,
+		)
+	}
+
+	_, err = r.executeNonProgram(
+		func(inter *interpreter.Interpreter) (interpreter.Value, error) {
+
+			someValue, ok := inter.ReadStored(address, interpreter.PathToStorageKey(pathValue)).(*interpreter.SomeValue)
+			if !ok {
+				return interpreter.VoidValue{}, nil
+			}
+
+			link, ok := someValue.Value.(interpreter.LinkValue)
+			if !ok {
+				return interpreter.VoidValue{}, nil
+			}
+
+			target = exportPathValue(link.TargetPath)
+			linked = true
+
+			return interpreter.VoidValue{}, nil
+		},
+		context,
+	)
+	if err != nil {
+		return cadence.Path{}, false, err
+	}
+
+	return target, linked, nil
+}
+
+func (r *interpreterRuntime) ReencodeStored(
+	address common.Address,
+	path cadence.Path,
+	context Context,
+) (
+	changed bool,
+	err error,
+) {
+	context.InitializeCodesAndPrograms()
+
+	storage, err := r.newStorage(context)
+	if err != nil {
+		return false, newError(err, context)
+	}
+
+	var program *interpreter.Program
+	var functions stdlib.StandardLibraryFunctions
+	var values stdlib.StandardLibraryValues
+	var interpreterOptions []interpreter.Option
+	var checkerOptions []sema.Option
+
+	_, inter, err := r.interpret(
+		program,
+		context,
+		storage,
+		functions,
+		values,
+		interpreterOptions,
+		checkerOptions,
+		func(inter *interpreter.Interpreter) (_ interpreter.Value, err error) {
+
+			// Recover internal panics and return them as an error.
+			// For example, the stored data might not be decodable
+
+			defer inter.RecoverErrors(func(internalErr error) {
+				err = internalErr
+			})
+
+			storageKey := interpreter.StorageKey{
+				Address: address,
+				Key:     interpreter.PathToStorageKey(importPathValue(path)),
+			}
+
+			changed, err = storage.Reencode(storageKey)
+			if err != nil {
+				return nil, err
+			}
+
+			return interpreter.VoidValue{}, nil
+		},
+	)
+	if err != nil {
+		return false, newError(err, context)
+	}
+
+	if !changed {
+		return false, nil
+	}
+
+	err = r.commitStorage(storage, inter, context)
+	if err != nil {
+		return false, newError(err, context)
+	}
+
+	return true, nil
+}
+
+// StorageInfo is the storage information of an account, in bytes.
+//
+type StorageInfo struct {
+	Used     uint64
+	Capacity uint64
+	// Available is the storage capacity which is not used,
+	// i.e. it is zero if the account uses more storage than its capacity
+	Available uint64
+}
+
+func (r *interpreterRuntime) AccountStorageInfo(
+	address common.Address,
+	context Context,
+) (
+	StorageInfo,
+	error,
+) {
+	capacityProvider, ok := getStorageCapacityProvider(context.Interface)
+	if !ok {
+		return StorageInfo{}, newError(
+			StorageCapacityUnavailableError{
+				Address: address,
+			},
+			context,
+		)
+	}
+
+	// Both the storage used and the storage capacity are read
+	// without executing any program in between,
+	// and without committing any pending changes to storage,
+	// so they are consistent with each other
+
+	var info StorageInfo
+
+	var err error
+	wrapPanic(func() {
+		info.Used, err = context.Interface.GetStorageUsed(address)
+		if err != nil {
+			return
+		}
+
+		info.Capacity, err = capacityProvider.GetStorageCapacity(address)
+	})
+	if err != nil {
+		return StorageInfo{}, newError(err, context)
+	}
+
+	if info.Capacity > info.Used {
+		info.Available = info.Capacity - info.Used
+	}
+
+	return info, nil
+}
+
+func (r *interpreterRuntime) GetAccountContractNames(
+	address common.Address,
+	context Context,
+) (
+	[]string,
+	error,
+) {
+	var names []string
+
+	var err error
+	wrapPanic(func() {
+		var deployedNames []string
+		deployedNames, err = context.Interface.GetAccountContractNames(address)
+		if err != nil {
+			return
+		}
+
+		// Only include contracts which actually have code,
+		// and include each contract only once
+
+		seen := make(map[string]struct{}, len(deployedNames))
+
+		for _, name := range deployedNames {
+			if _, ok := seen[name]; ok {
+				continue
+			}
+			seen[name] = struct{}{}
+
+			var code []byte
+			code, err = context.Interface.GetAccountContractCode(address, name)
+			if err != nil {
+				return
+			}
+
+			if len(code) == 0 {
+				continue
+			}
+
+			names = append(names, name)
+		}
+	})
+	if err != nil {
+		return nil, newError(err, context)
+	}
+
+	sort.Strings(names)
+
+	return names, nil
+}
+
+var BlockIDStaticType = interpreter.ConstantSizedStaticType{
+	Type: interpreter.PrimitiveStaticTypeUInt8,
+	Size: 32,
+}
+
+func NewBlockValue(inter *interpreter.Interpreter, block Block) interpreter.Value {
+
+	// height
+	heightValue := interpreter.UInt64Value(block.Height)
+
+	// view
+	viewValue := interpreter.UInt64Value(block.View)
+
+	// ID
+	var values = make([]interpreter.Value, sema.BlockIDSize)
+	for i, b := range block.Hash {
+		values[i] = interpreter.UInt8Value(b)
+	}
+
+	idValue := interpreter.NewArrayValue(
+		inter,
+		BlockIDStaticType,
+		common.Address{},
+		values...,
+	)
+
+	// timestamp
+	// TODO: verify
+	timestampValue := interpreter.NewUFix64ValueWithInteger(uint64(time.Unix(0, block.Timestamp).Unix()))
+
+	return interpreter.NewBlockValue(
+		heightValue,
+		viewValue,
+		idValue,
+		timestampValue,
+	)
+}
+
+func (r *interpreterRuntime) newAccountKeysAddFunction(
+	addressValue interpreter.AddressValue,
+	runtimeInterface Interface,
+) *interpreter.HostFunctionValue {
+	return interpreter.NewHostFunctionValue(
+		func(invocation interpreter.Invocation) interpreter.Value {
+			publicKeyValue := invocation.Arguments[0].(*interpreter.CompositeValue)
+
+			inter := invocation.Interpreter
+			getLocationRange := invocation.GetLocationRange
+
+			publicKey, err := NewPublicKeyFromValue(inter, getLocationRange, publicKeyValue)
+			if err != nil {
+				panic(err)
+			}
+
+			hashAlgo := NewHashAlgorithmFromValue(inter, getLocationRange, invocation.Arguments[1])
+			address := addressValue.ToAddress()
+			weight := invocation.Arguments[2].(interpreter.UFix64Value).ToInt()
+
+			var accountKey *AccountKey
+			wrapPanic(func() {
+				accountKey, err = runtimeInterface.AddAccountKey(address, publicKey, hashAlgo, weight)
+			})
+			if err != nil {
+				panic(err)
+			}
+
+			invalidateStorageUsed(inter, address)
+
+			r.emitAccountEvent(
+				stdlib.AccountKeyAddedEventType,
+				runtimeInterface,
+				[]exportableValue{
+					newExportableValue(addressValue, inter),
+					newExportableValue(publicKeyValue, inter),
+				},
+			)
+
+			return NewAccountKeyValue(
+				inter,
+				getLocationRange,
+				accountKey,
+				inter.PublicKeyValidationHandler,
+			)
+		},
+		sema.AuthAccountKeysTypeAddFunctionType,
+	)
+}
+
+func (r *interpreterRuntime) newAccountKeysGetFunction(
+	addressValue interpreter.AddressValue,
+	runtimeInterface Interface,
+) *interpreter.HostFunctionValue {
+	return interpreter.NewHostFunctionValue(
+		func(invocation interpreter.Invocation) interpreter.Value {
+			index := invocation.Arguments[0].(interpreter.IntValue).ToInt()
+			address := addressValue.ToAddress()
+
+			var err error
+			var accountKey *AccountKey
+			wrapPanic(func() {
+				accountKey, err = runtimeInterface.GetAccountKey(address, index)
+			})
+
+			if err != nil {
+				panic(err)
+			}
+
+			// Here it is expected the host function to return a nil key, if a key is not found at the given index.
+			// This is done because, if the host function returns an error when a key is not found, then
+			// currently there's no way to distinguish between a 'key not found error' vs other internal errors.
+			if accountKey == nil {
+				return interpreter.NilValue{}
+			}
+
+			inter := invocation.Interpreter
+
+			return interpreter.NewSomeValueNonCopying(
+				NewAccountKeyValue(
+					inter,
+					invocation.GetLocationRange,
+					accountKey,
+					inter.PublicKeyValidationHandler,
+				),
+			)
+		},
+		sema.AccountKeysTypeGetFunctionType,
+	)
+}
+
+func (r *interpreterRuntime) newAccountKeysRevokeFunction(
 	addressValue interpreter.AddressValue,
 	runtimeInterface Interface,
 ) *interpreter.HostFunctionValue {
@@ -3011,6 +4922,8 @@ func (r *interpreterRuntime) newAccountKeysRevokeFunction(
 				panic(err)
 			}
 
+			invalidateStorageUsed(invocation.Interpreter, address)
+
 			// Here it is expected the host function to return a nil key, if a key is not found at the given index.
 			// This is done because, if the host function returns an error when a key is not found, then
 			// currently there's no way to distinguish between a 'key not found error' vs other internal errors.
@@ -3072,13 +4985,35 @@ func (r *interpreterRuntime) newPublicAccountContracts(
 	)
 }
 
-func (r *interpreterRuntime) newStorage(runtimeInterface Interface) *Storage {
-	return NewStorage(
+func (r *interpreterRuntime) newStorage(context Context) (*Storage, error) {
+	err := validateStorageKeyPrefix(context.StorageKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	runtimeInterface := context.Interface
+
+	reportMetric := func(f func(), report func(metrics Metrics, duration time.Duration)) {
+		reportMetric(f, runtimeInterface, report)
+	}
+
+	// A read-only execution must never write to the ledger,
+	// even if a storage mutation is not rejected by the interpreter
+
+	storage := newStorage(
 		runtimeInterface,
-		func(f func(), report func(metrics Metrics, duration time.Duration)) {
-			reportMetric(f, runtimeInterface, report)
-		},
+		reportMetric,
+		context.ReadOnly,
+		context.StorageKeyPrefix,
 	)
+
+	storage.SetMaxReads(context.MaxStorageReads)
+
+	if context.CacheStorageUsed {
+		storage.CacheStorageUsed()
+	}
+
+	return storage, nil
 }
 
 func NewPublicKeyFromValue(
@@ -41,6 +41,7 @@ import (
 	"github.com/onflow/cadence/runtime/ast"
 	"github.com/onflow/cadence/runtime/common"
 	"github.com/onflow/cadence/runtime/interpreter"
+	"github.com/onflow/cadence/runtime/parser2"
 	"github.com/onflow/cadence/runtime/sema"
 	"github.com/onflow/cadence/runtime/stdlib"
 	"github.com/onflow/cadence/runtime/tests/checker"
@@ -53,6 +54,7 @@ type testLedger struct {
 	getValue             func(owner, key []byte) (value []byte, err error)
 	setValue             func(owner, key, value []byte) (err error)
 	allocateStorageIndex func(owner []byte) (atree.StorageIndex, error)
+	advanceStorageIndex  func(owner []byte, index atree.StorageIndex) error
 }
 
 var _ atree.Ledger = testLedger{}
@@ -73,6 +75,24 @@ func (s testLedger) AllocateStorageIndex(owner []byte) (atree.StorageIndex, erro
 	return s.allocateStorageIndex(owner)
 }
 
+func (s testLedger) AdvanceStorageIndex(owner []byte, index atree.StorageIndex) error {
+	return s.advanceStorageIndex(owner, index)
+}
+
+func (s testLedger) ForEachAccountRegister(owner []byte, f func(key, value []byte) error) error {
+	prefix := string(owner) + "|"
+	for storageKey, value := range s.storedValues {
+		if !strings.HasPrefix(storageKey, prefix) {
+			continue
+		}
+		err := f([]byte(storageKey[len(prefix):]), value)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (s testLedger) Dump() {
 	for key, data := range s.storedValues {
 		fmt.Printf("%s:\n", strconv.Quote(key))
@@ -120,6 +140,13 @@ func newTestLedger(
 			binary.BigEndian.PutUint64(result[:], index)
 			return
 		},
+		advanceStorageIndex: func(owner []byte, index atree.StorageIndex) error {
+			advancedIndex := binary.BigEndian.Uint64(index[:])
+			if advancedIndex > storageIndices[string(owner)] {
+				storageIndices[string(owner)] = advancedIndex
+			}
+			return nil
+		},
 	}
 
 	return storage
@@ -133,6 +160,7 @@ func newTestInterpreterRuntime(options ...Option) Runtime {
 
 type testRuntimeInterface struct {
 	resolveLocation           func(identifiers []Identifier, location Location) ([]ResolvedLocation, error)
+	rewriteImport             func(location Location) (Location, error)
 	getCode                   func(_ Location) ([]byte, error)
 	getProgram                func(Location) (*interpreter.Program, error)
 	setProgram                func(Location, *interpreter.Program) error
@@ -197,6 +225,13 @@ func (i *testRuntimeInterface) ResolveLocation(identifiers []Identifier, locatio
 	return i.resolveLocation(identifiers, location)
 }
 
+func (i *testRuntimeInterface) RewriteImport(location Location) (Location, error) {
+	if i.rewriteImport == nil {
+		return location, nil
+	}
+	return i.rewriteImport(location)
+}
+
 func (i *testRuntimeInterface) GetCode(location Location) ([]byte, error) {
 	if i.getCode == nil {
 		return nil, nil
@@ -243,6 +278,14 @@ func (i *testRuntimeInterface) AllocateStorageIndex(owner []byte) (atree.Storage
 	return i.storage.allocateStorageIndex(owner)
 }
 
+func (i *testRuntimeInterface) AdvanceStorageIndex(owner []byte, index atree.StorageIndex) error {
+	return i.storage.advanceStorageIndex(owner, index)
+}
+
+func (i *testRuntimeInterface) ForEachAccountRegister(owner []byte, f func(key, value []byte) error) error {
+	return i.storage.ForEachAccountRegister(owner, f)
+}
+
 func (i *testRuntimeInterface) CreateAccount(payer Address) (address Address, err error) {
 	return i.createAccount(payer)
 }
@@ -2415,6 +2458,60 @@ func TestRuntimeParseAndCheckProgram(t *testing.T) {
 	})
 }
 
+func TestRuntimeParseProgram(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("valid program", func(t *testing.T) {
+
+		t.Parallel()
+
+		runtime := newTestInterpreterRuntime()
+
+		// The program is not checked, so the import and the type mismatch are not reported
+
+		script := []byte(`
+          import Missing from 0x1
+
+          pub let a: Int = "b"
+        `)
+
+		program, err := runtime.ParseProgram(
+			script,
+			Context{
+				Interface: &testRuntimeInterface{},
+				Location:  common.ScriptLocation{},
+			},
+		)
+		require.NoError(t, err)
+
+		assert.Len(t, program.ImportDeclarations(), 1)
+		assert.Len(t, program.VariableDeclarations(), 1)
+	})
+
+	t.Run("invalid syntax", func(t *testing.T) {
+
+		t.Parallel()
+
+		runtime := newTestInterpreterRuntime()
+
+		_, err := runtime.ParseProgram(
+			[]byte("invalid syntax"),
+			Context{
+				Interface: &testRuntimeInterface{},
+				Location:  common.ScriptLocation{},
+			},
+		)
+		require.Error(t, err)
+
+		var parsingCheckingErr *ParsingCheckingError
+		require.ErrorAs(t, err, &parsingCheckingErr)
+
+		var parserErr parser2.Error
+		require.ErrorAs(t, err, &parserErr)
+	})
+}
+
 func TestRuntimeScriptReturnTypeNotReturnableError(t *testing.T) {
 
 	t.Parallel()
@@ -2476,8 +2573,8 @@ func TestRuntimeScriptReturnTypeNotReturnableError(t *testing.T) {
 		test(t,
 			`
               pub fun main(): &Address {
-                  let a: Address = 0x1
-                  return &a as &Address
+                  let a: [Address] = [0x1]
+                  return &a[0] as &Address
               }
             `,
 			cadence.Address{0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x1},
@@ -5257,6 +5354,65 @@ func TestRuntimeComputationLimit(t *testing.T) {
 	}
 }
 
+func TestRuntimeLimitExceeded(t *testing.T) {
+
+	t.Parallel()
+
+	const computationLimit = 5
+
+	runtime := newTestInterpreterRuntime()
+
+	runtimeInterface := &testRuntimeInterface{
+		storage: newTestLedger(nil, nil),
+		getSigningAccounts: func() ([]Address, error) {
+			return nil, nil
+		},
+		computationLimit: computationLimit,
+	}
+
+	context := Context{
+		Interface:            runtimeInterface,
+		Location:             newTransactionLocationGenerator()(),
+		MaxBorrowChainLength: 10,
+	}
+
+	assert.Equal(t,
+		Limits{
+			Computation:       computationLimit,
+			CallStackDepth:    callStackDepthLimit,
+			BorrowChainLength: 10,
+			ValueDepth:        interpreter.DefaultMaxValueDepth,
+		},
+		context.Limits(),
+	)
+
+	err := runtime.ExecuteTransaction(
+		Script{
+			Source: []byte(`
+              transaction {
+                  prepare() {
+                      while true {}
+                  }
+              }
+            `),
+		},
+		context,
+	)
+	require.Error(t, err)
+
+	var runtimeErr Error
+	require.ErrorAs(t, err, &runtimeErr)
+
+	assert.Equal(t,
+		&LimitExceeded{
+			Kind:  LimitKindComputation,
+			Limit: computationLimit,
+		},
+		runtimeErr.LimitExceeded,
+	)
+	assert.Equal(t, "LimitKindComputation", runtimeErr.LimitExceeded.Kind.String())
+}
+
 func TestRuntimeMetrics(t *testing.T) {
 
 	t.Parallel()
@@ -6856,3 +7012,2257 @@ func TestRuntimeStackOverflow(t *testing.T) {
 	var callStackLimitExceededErr CallStackLimitExceededError
 	require.ErrorAs(t, err, &callStackLimitExceededErr)
 }
+
+func TestRuntimeEventDeclaringLocation(t *testing.T) {
+
+	t.Parallel()
+
+	runtime := newTestInterpreterRuntime()
+
+	address := common.BytesToAddress([]byte{0xaa, 0xd3, 0xe2, 0x6e, 0x40, 0x69, 0x87, 0xc2})
+
+	accountCodes := map[common.LocationID][]byte{}
+	var events []cadence.Event
+
+	runtimeInterface := &testRuntimeInterface{
+		storage: newTestLedger(nil, nil),
+		getSigningAccounts: func() ([]Address, error) {
+			return []Address{address}, nil
+		},
+		resolveLocation: singleIdentifierLocationResolver(t),
+		updateAccountContractCode: func(address Address, name string, code []byte) error {
+			location := common.AddressLocation{
+				Address: address,
+				Name:    name,
+			}
+			accountCodes[location.ID()] = code
+			return nil
+		},
+		getAccountContractCode: func(address Address, name string) (code []byte, err error) {
+			location := common.AddressLocation{
+				Address: address,
+				Name:    name,
+			}
+			code = accountCodes[location.ID()]
+			return code, nil
+		},
+		emitEvent: func(event cadence.Event) error {
+			events = append(events, event)
+			return nil
+		},
+	}
+
+	nextTransactionLocation := newTransactionLocationGenerator()
+
+	for _, deployTx := range [][]byte{
+		utils.DeploymentTransaction("FungibleToken", []byte(realFungibleTokenContractInterface)),
+		utils.DeploymentTransaction("DapperUtilityCoin", []byte(realDapperUtilityCoinContract)),
+	} {
+		err := runtime.ExecuteTransaction(
+			Script{
+				Source: deployTx,
+			},
+			Context{
+				Interface: runtimeInterface,
+				Location:  nextTransactionLocation(),
+			},
+		)
+		require.NoError(t, err)
+	}
+
+	var tokensInitializedEvent *cadence.Event
+	for i, event := range events {
+		if event.EventType.QualifiedIdentifier == "DapperUtilityCoin.TokensInitialized" {
+			tokensInitializedEvent = &events[i]
+			break
+		}
+	}
+	require.NotNil(t, tokensInitializedEvent)
+
+	location, err := tokensInitializedEvent.DeclaringLocation()
+	require.NoError(t, err)
+
+	require.Equal(t,
+		common.AddressLocation{
+			Address: address,
+			Name:    "DapperUtilityCoin",
+		},
+		location,
+	)
+}
+
+func TestRuntimeMaxBorrowChainLength(t *testing.T) {
+
+	t.Parallel()
+
+	runtime := newTestInterpreterRuntime()
+
+	setupTx := []byte(`
+      transaction {
+        prepare(signer: AuthAccount) {
+          signer.save([1, 2, 3], to: /storage/numbers)
+          signer.link<&[Int]>(/public/numbers, target: /storage/numbers)
+        }
+      }
+    `)
+
+	// The second statement performs three borrows in a single expression
+
+	borrowTx := []byte(`
+      transaction {
+        prepare(signer: AuthAccount) {
+          let length = signer.borrow<&[Int]>(from: /storage/numbers)!.length
+          let total = signer.borrow<&[Int]>(from: /storage/numbers)!.length
+              + signer.getCapability(/public/numbers).borrow<&[Int]>()!.length
+              + signer.borrow<&[Int]>(from: /storage/numbers)!.length
+        }
+      }
+    `)
+
+	runtimeInterface := &testRuntimeInterface{
+		storage: newTestLedger(nil, nil),
+		getSigningAccounts: func() ([]Address, error) {
+			return []Address{{0x1}}, nil
+		},
+	}
+
+	nextTransactionLocation := newTransactionLocationGenerator()
+
+	err := runtime.ExecuteTransaction(
+		Script{
+			Source: setupTx,
+		},
+		Context{
+			Interface: runtimeInterface,
+			Location:  nextTransactionLocation(),
+		},
+	)
+	require.NoError(t, err)
+
+	executeBorrowTransaction := func(maxBorrowChainLength int) error {
+		return runtime.ExecuteTransaction(
+			Script{
+				Source: borrowTx,
+			},
+			Context{
+				Interface:            runtimeInterface,
+				Location:             nextTransactionLocation(),
+				MaxBorrowChainLength: maxBorrowChainLength,
+			},
+		)
+	}
+
+	t.Run("unlimited", func(t *testing.T) {
+		err := executeBorrowTransaction(0)
+		require.NoError(t, err)
+	})
+
+	t.Run("within limit", func(t *testing.T) {
+		err := executeBorrowTransaction(3)
+		require.NoError(t, err)
+	})
+
+	t.Run("exceeding limit", func(t *testing.T) {
+		err := executeBorrowTransaction(2)
+		require.Error(t, err)
+
+		var borrowChainLengthExceededErr interpreter.BorrowChainLengthExceededError
+		require.ErrorAs(t, err, &borrowChainLengthExceededErr)
+		require.Equal(t, 2, borrowChainLengthExceededErr.Limit)
+	})
+
+	t.Run("fallback capability", func(t *testing.T) {
+
+		// Borrowing a capability or its fallback capability is one borrow
+
+		err := runtime.ExecuteTransaction(
+			Script{
+				Source: []byte(`
+                  transaction {
+                    prepare(signer: AuthAccount) {
+                      let length = signer.getCapability(/public/missing)
+                          .borrowOr<&[Int]>(signer.getCapability(/public/numbers))!.length
+                    }
+                  }
+                `),
+			},
+			Context{
+				Interface:            runtimeInterface,
+				Location:             nextTransactionLocation(),
+				MaxBorrowChainLength: 1,
+			},
+		)
+		require.NoError(t, err)
+	})
+}
+
+func TestRuntimeMaxTypeDepth(t *testing.T) {
+
+	t.Parallel()
+
+	runtime := newTestInterpreterRuntime()
+
+	nestedArrayType := func(depth int) string {
+		return strings.Repeat("[", depth) + "Int" + strings.Repeat("]", depth)
+	}
+
+	executeScript := func(depth int, maxTypeDepth int) error {
+		_, err := runtime.ExecuteScript(
+			Script{
+				Source: []byte(fmt.Sprintf(
+					`
+                      pub fun main() {
+                          let xs: %s = []
+                      }
+                    `,
+					nestedArrayType(depth),
+				)),
+			},
+			Context{
+				Interface:    &testRuntimeInterface{},
+				Location:     common.ScriptLocation{},
+				MaxTypeDepth: maxTypeDepth,
+			},
+		)
+		return err
+	}
+
+	t.Run("within limit", func(t *testing.T) {
+
+		t.Parallel()
+
+		// Two arrays and the element type
+
+		err := executeScript(2, 3)
+		require.NoError(t, err)
+	})
+
+	t.Run("exceeding limit", func(t *testing.T) {
+
+		t.Parallel()
+
+		err := executeScript(3, 3)
+		require.Error(t, err)
+
+		var checkerErr *sema.CheckerError
+		require.ErrorAs(t, err, &checkerErr)
+
+		errs := checker.ExpectCheckerErrors(t, checkerErr, 1)
+
+		require.IsType(t, &sema.TypeDepthLimitError{}, errs[0])
+		assert.Equal(t, 3, errs[0].(*sema.TypeDepthLimitError).Limit)
+	})
+
+	t.Run("default limit", func(t *testing.T) {
+
+		t.Parallel()
+
+		err := executeScript(sema.DefaultMaxTypeDepth, 0)
+		require.Error(t, err)
+
+		var checkerErr *sema.CheckerError
+		require.ErrorAs(t, err, &checkerErr)
+
+		errs := checker.ExpectCheckerErrors(t, checkerErr, 1)
+
+		require.IsType(t, &sema.TypeDepthLimitError{}, errs[0])
+		assert.Equal(t, sema.DefaultMaxTypeDepth, errs[0].(*sema.TypeDepthLimitError).Limit)
+	})
+}
+
+type testResourceDestructionRuntimeInterface struct {
+	*testRuntimeInterface
+	onResourceDestroyed func(typeID string, uuid uint64)
+}
+
+var _ ResourceDestructionListener = testResourceDestructionRuntimeInterface{}
+
+func (i testResourceDestructionRuntimeInterface) OnResourceDestroyed(typeID string, uuid uint64) {
+	i.onResourceDestroyed(typeID, uuid)
+}
+
+func TestRuntimeResourceDestructionListener(t *testing.T) {
+
+	t.Parallel()
+
+	runtime := newTestInterpreterRuntime()
+
+	address := common.BytesToAddress([]byte{0x1})
+
+	contract := []byte(`
+      pub contract Test {
+
+          pub resource Inner {}
+
+          pub resource Outer {
+              pub let inner: @Inner
+              pub let inners: @{String: [Inner]}
+
+              init() {
+                  self.inner <- create Inner()
+                  self.inners <- {"a": <-[<-create Inner()]}
+              }
+
+              destroy() {
+                  destroy self.inner
+                  destroy self.inners
+              }
+          }
+
+          pub fun createOuter(): @Outer {
+              return <- create Outer()
+          }
+      }
+    `)
+
+	tx := []byte(`
+      import Test from 0x1
+
+      transaction {
+          prepare(signer: AuthAccount) {
+              let outer <- Test.createOuter()
+              destroy outer
+          }
+      }
+    `)
+
+	accountCodes := map[common.LocationID][]byte{}
+
+	type destroyedResource struct {
+		typeID string
+		uuid   uint64
+	}
+
+	var destroyedResources []destroyedResource
+
+	var uuid uint64
+
+	runtimeInterface := testResourceDestructionRuntimeInterface{
+		testRuntimeInterface: &testRuntimeInterface{
+			storage: newTestLedger(nil, nil),
+			getSigningAccounts: func() ([]Address, error) {
+				return []Address{address}, nil
+			},
+			resolveLocation: singleIdentifierLocationResolver(t),
+			getAccountContractCode: func(address Address, name string) (code []byte, err error) {
+				location := common.AddressLocation{
+					Address: address,
+					Name:    name,
+				}
+				return accountCodes[location.ID()], nil
+			},
+			updateAccountContractCode: func(address Address, name string, code []byte) error {
+				location := common.AddressLocation{
+					Address: address,
+					Name:    name,
+				}
+				accountCodes[location.ID()] = code
+				return nil
+			},
+			emitEvent: func(event cadence.Event) error {
+				return nil
+			},
+			generateUUID: func() (uint64, error) {
+				uuid++
+				return uuid, nil
+			},
+		},
+		onResourceDestroyed: func(typeID string, uuid uint64) {
+			destroyedResources = append(destroyedResources, destroyedResource{
+				typeID: typeID,
+				uuid:   uuid,
+			})
+		},
+	}
+
+	nextTransactionLocation := newTransactionLocationGenerator()
+
+	for _, source := range [][]byte{
+		utils.DeploymentTransaction("Test", contract),
+		tx,
+	} {
+		err := runtime.ExecuteTransaction(
+			Script{
+				Source: source,
+			},
+			Context{
+				Interface: runtimeInterface,
+				Location:  nextTransactionLocation(),
+			},
+		)
+		require.NoError(t, err)
+	}
+
+	// The inner resources are destroyed by the destructor of the outer resource,
+	// so they are reported first.
+	// The resources nested in the dictionary are destroyed implicitly, and reported too
+
+	require.Equal(t,
+		[]destroyedResource{
+			{
+				typeID: "A.0000000000000001.Test.Inner",
+				uuid:   2,
+			},
+			{
+				typeID: "A.0000000000000001.Test.Inner",
+				uuid:   3,
+			},
+			{
+				typeID: "A.0000000000000001.Test.Outer",
+				uuid:   1,
+			},
+		},
+		destroyedResources,
+	)
+}
+
+func TestRuntimeGetProgramDependencies(t *testing.T) {
+
+	t.Parallel()
+
+	runtime := newTestInterpreterRuntime()
+
+	address := common.BytesToAddress([]byte{0x1})
+
+	contracts := map[string][]byte{
+		"A": []byte(`
+          import B from 0x1
+
+          pub contract A {}
+        `),
+		"B": []byte(`
+          import C from 0x1
+
+          pub contract B {}
+        `),
+		"C": []byte(`
+          pub contract C {}
+        `),
+	}
+
+	runtimeInterface := &testRuntimeInterface{
+		resolveLocation: singleIdentifierLocationResolver(t),
+		getAccountContractCode: func(_ Address, name string) ([]byte, error) {
+			return contracts[name], nil
+		},
+	}
+
+	newLocation := func(name string) common.Location {
+		return common.AddressLocation{
+			Address: address,
+			Name:    name,
+		}
+	}
+
+	t.Run("transitive", func(t *testing.T) {
+
+		script := []byte(`
+          import A from 0x1
+          import C from 0x1
+
+          pub fun main() {}
+        `)
+
+		dependencies, err := runtime.GetProgramDependencies(
+			script,
+			Context{
+				Interface: runtimeInterface,
+				Location:  common.ScriptLocation{},
+			},
+		)
+		require.NoError(t, err)
+
+		require.Equal(t,
+			[]common.Location{
+				newLocation("A"),
+				newLocation("B"),
+				newLocation("C"),
+			},
+			dependencies,
+		)
+	})
+
+	t.Run("checking error", func(t *testing.T) {
+
+		script := []byte(`
+          import A from 0x1
+
+          pub fun main(): Int {
+              return "not an integer"
+          }
+        `)
+
+		_, err := runtime.GetProgramDependencies(
+			script,
+			Context{
+				Interface: runtimeInterface,
+				Location:  common.ScriptLocation{},
+			},
+		)
+		require.Error(t, err)
+
+		var checkerErr *sema.CheckerError
+		require.ErrorAs(t, err, &checkerErr)
+	})
+}
+
+func TestRuntimeTransactionSignature(t *testing.T) {
+
+	t.Parallel()
+
+	runtime := newTestInterpreterRuntime()
+
+	accountCodes := map[common.LocationID]string{
+		"A.1d7e57aa55817448.NonFungibleToken": realNonFungibleTokenInterface,
+		"A.0b2a3299cc857e29.TopShot":          realTopShotContract,
+	}
+
+	runtimeInterface := &testRuntimeInterface{
+		resolveLocation: singleIdentifierLocationResolver(t),
+		getAccountContractCode: func(address Address, name string) (code []byte, err error) {
+			location := common.AddressLocation{
+				Address: address,
+				Name:    name,
+			}
+			return []byte(accountCodes[location.ID()]), nil
+		},
+	}
+
+	transactionSignature := func(code string) (TransactionSignature, error) {
+		return runtime.TransactionSignature(
+			[]byte(code),
+			Context{
+				Interface: runtimeInterface,
+				Location:  common.TransactionLocation{},
+			},
+		)
+	}
+
+	t.Run("TopShot transfer", func(t *testing.T) {
+
+		t.Parallel()
+
+		signature, err := transactionSignature(`
+          import NonFungibleToken from 0x1d7e57aa55817448
+          import TopShot from 0x0b2a3299cc857e29
+
+          transaction(momentIDs: [UInt64]) {
+              let transferTokens: @NonFungibleToken.Collection
+
+              prepare(acct: AuthAccount) {
+                  let ref = acct.borrow<&TopShot.Collection>(from: /storage/MomentCollection)!
+                  self.transferTokens <- ref.batchWithdraw(ids: momentIDs)
+              }
+
+              execute {
+                  let recipient = getAccount(0x42)
+
+                  let receiverRef = recipient.getCapability(/public/MomentCollection)
+                      .borrow<&{TopShot.MomentCollectionPublic}>()!
+
+                  receiverRef.batchDeposit(tokens: <-self.transferTokens)
+              }
+          }
+        `)
+		require.NoError(t, err)
+
+		assert.Equal(t,
+			TransactionSignature{
+				Parameters: []cadence.Parameter{
+					{
+						Identifier: "momentIDs",
+						Type: cadence.VariableSizedArrayType{
+							ElementType: cadence.UInt64Type{},
+						},
+					},
+				},
+				AuthorizerCount: 1,
+				HasExecute:      true,
+			},
+			signature,
+		)
+	})
+
+	t.Run("no parameters, no execute", func(t *testing.T) {
+
+		t.Parallel()
+
+		signature, err := transactionSignature(`
+          transaction {
+              prepare(first: AuthAccount, second: AuthAccount) {}
+          }
+        `)
+		require.NoError(t, err)
+
+		assert.Equal(t,
+			TransactionSignature{
+				Parameters:      []cadence.Parameter{},
+				AuthorizerCount: 2,
+				HasExecute:      false,
+			},
+			signature,
+		)
+	})
+
+	t.Run("no transaction", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := transactionSignature(`
+          pub fun main() {}
+        `)
+		require.Error(t, err)
+
+		require.ErrorAs(t, err, &InvalidTransactionCountError{})
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := transactionSignature(`
+          transaction(x: Int) {
+              prepare(signer: AuthAccount) {
+                  let y: String = x
+              }
+          }
+        `)
+		require.Error(t, err)
+
+		var checkerErr *sema.CheckerError
+		require.ErrorAs(t, err, &checkerErr)
+	})
+}
+
+func TestRuntimeCheckTransactionArguments(t *testing.T) {
+
+	t.Parallel()
+
+	runtime := newTestInterpreterRuntime()
+
+	var loggedMessages []string
+
+	runtimeInterface := &testRuntimeInterface{
+		storage: newTestLedger(nil, nil),
+		decodeArgument: func(b []byte, t cadence.Type) (cadence.Value, error) {
+			return jsoncdc.Decode(b)
+		},
+		log: func(message string) {
+			loggedMessages = append(loggedMessages, message)
+		},
+	}
+
+	const transaction = `
+      transaction(amount: UFix64, recipients: [Address]) {
+          prepare(signer: AuthAccount) {
+              log("prepare")
+          }
+
+          execute {
+              log("execute")
+          }
+      }
+    `
+
+	checkArguments := func(arguments ...cadence.Value) error {
+		encodedArguments := make([][]byte, len(arguments))
+		for i, argument := range arguments {
+			encodedArguments[i] = jsoncdc.MustEncode(argument)
+		}
+
+		return runtime.CheckTransactionArguments(
+			Script{
+				Source:    []byte(transaction),
+				Arguments: encodedArguments,
+			},
+			Context{
+				Interface: runtimeInterface,
+				Location:  common.TransactionLocation{},
+			},
+		)
+	}
+
+	t.Run("valid", func(t *testing.T) {
+
+		err := checkArguments(
+			cadence.UFix64(1_00000000),
+			cadence.NewArray([]cadence.Value{
+				cadence.NewAddress([8]byte{0, 0, 0, 0, 0, 0, 0, 1}),
+			}),
+		)
+		require.NoError(t, err)
+
+		// Neither the prepare nor the execute block was run
+
+		require.Empty(t, loggedMessages)
+	})
+
+	t.Run("invalid count", func(t *testing.T) {
+
+		err := checkArguments(cadence.UFix64(1_00000000))
+		require.Error(t, err)
+
+		var countErr InvalidEntryPointParameterCountError
+		require.ErrorAs(t, err, &countErr)
+
+		require.Equal(t,
+			InvalidEntryPointParameterCountError{
+				Expected: 2,
+				Actual:   1,
+			},
+			countErr,
+		)
+	})
+
+	t.Run("invalid type", func(t *testing.T) {
+
+		err := checkArguments(
+			cadence.UFix64(1_00000000),
+			cadence.NewArray([]cadence.Value{
+				cadence.NewInt(1),
+			}),
+		)
+		require.Error(t, err)
+
+		var argumentErr *InvalidEntryPointArgumentError
+		require.ErrorAs(t, err, &argumentErr)
+
+		require.Equal(t, 1, argumentErr.Index)
+	})
+
+	t.Run("not a transaction", func(t *testing.T) {
+
+		err := runtime.CheckTransactionArguments(
+			Script{
+				Source: []byte(`pub fun main() {}`),
+			},
+			Context{
+				Interface: runtimeInterface,
+				Location:  common.TransactionLocation{},
+			},
+		)
+		require.Error(t, err)
+
+		var countErr InvalidTransactionCountError
+		require.ErrorAs(t, err, &countErr)
+	})
+}
+
+func TestRuntimeAuthAccountEnsureSaved(t *testing.T) {
+
+	t.Parallel()
+
+	runtime := newTestInterpreterRuntime()
+
+	address := common.BytesToAddress([]byte{0x1})
+
+	contract := []byte(`
+      pub contract Test {
+
+          pub struct S {
+              pub let values: [Int]
+
+              init(values: [Int]) {
+                  self.values = values
+              }
+          }
+
+          pub resource R {}
+
+          pub fun createR(): @R {
+              return <- create R()
+          }
+      }
+    `)
+
+	accountCodes := map[common.LocationID][]byte{}
+
+	var loggedMessages []string
+
+	runtimeInterface := &testRuntimeInterface{
+		storage: newTestLedger(nil, nil),
+		getSigningAccounts: func() ([]Address, error) {
+			return []Address{address}, nil
+		},
+		resolveLocation: singleIdentifierLocationResolver(t),
+		getAccountContractCode: func(address Address, name string) (code []byte, err error) {
+			location := common.AddressLocation{
+				Address: address,
+				Name:    name,
+			}
+			return accountCodes[location.ID()], nil
+		},
+		updateAccountContractCode: func(address Address, name string, code []byte) error {
+			location := common.AddressLocation{
+				Address: address,
+				Name:    name,
+			}
+			accountCodes[location.ID()] = code
+			return nil
+		},
+		emitEvent: func(event cadence.Event) error {
+			return nil
+		},
+		log: func(message string) {
+			loggedMessages = append(loggedMessages, message)
+		},
+	}
+
+	nextTransactionLocation := newTransactionLocationGenerator()
+
+	executeTransaction := func(source []byte) error {
+		loggedMessages = nil
+
+		return runtime.ExecuteTransaction(
+			Script{
+				Source: source,
+			},
+			Context{
+				Interface: runtimeInterface,
+				Location:  nextTransactionLocation(),
+			},
+		)
+	}
+
+	err := executeTransaction(utils.DeploymentTransaction("Test", contract))
+	require.NoError(t, err)
+
+	structTx := func(values string) []byte {
+		return []byte(fmt.Sprintf(
+			`
+              import Test from 0x1
+
+              transaction {
+                  prepare(signer: AuthAccount) {
+                      log(signer.ensureSaved(Test.S(values: %s), to: /storage/s))
+                  }
+              }
+            `,
+			values,
+		))
+	}
+
+	resourceTx := []byte(`
+      import Test from 0x1
+
+      transaction {
+          prepare(signer: AuthAccount) {
+              log(signer.ensureSaved(<-Test.createR(), to: /storage/r))
+          }
+      }
+    `)
+
+	t.Run("empty", func(t *testing.T) {
+		err := executeTransaction(structTx("[1, 2]"))
+		require.NoError(t, err)
+		require.Equal(t, []string{"true"}, loggedMessages)
+	})
+
+	t.Run("equal existing", func(t *testing.T) {
+		err := executeTransaction(structTx("[1, 2]"))
+		require.NoError(t, err)
+		require.Equal(t, []string{"false"}, loggedMessages)
+	})
+
+	t.Run("differing existing", func(t *testing.T) {
+		err := executeTransaction(structTx("[1, 3]"))
+		require.Error(t, err)
+
+		var overwriteErr interpreter.OverwriteError
+		require.ErrorAs(t, err, &overwriteErr)
+	})
+
+	t.Run("resource, empty", func(t *testing.T) {
+		err := executeTransaction(resourceTx)
+		require.NoError(t, err)
+		require.Equal(t, []string{"true"}, loggedMessages)
+	})
+
+	t.Run("resource, existing", func(t *testing.T) {
+		err := executeTransaction(resourceTx)
+		require.Error(t, err)
+
+		var overwriteErr interpreter.OverwriteError
+		require.ErrorAs(t, err, &overwriteErr)
+	})
+}
+
+func TestRuntimeAuthAccountSaveOrReplace(t *testing.T) {
+
+	t.Parallel()
+
+	runtime := newTestInterpreterRuntime()
+
+	address := common.BytesToAddress([]byte{0x1})
+
+	contract := []byte(`
+      pub contract Test {
+
+          pub resource R {}
+
+          pub fun createR(): @R {
+              return <- create R()
+          }
+      }
+    `)
+
+	accountCodes := map[common.LocationID][]byte{}
+
+	var loggedMessages []string
+
+	runtimeInterface := &testRuntimeInterface{
+		storage: newTestLedger(nil, nil),
+		getSigningAccounts: func() ([]Address, error) {
+			return []Address{address}, nil
+		},
+		resolveLocation: singleIdentifierLocationResolver(t),
+		getAccountContractCode: func(address Address, name string) (code []byte, err error) {
+			location := common.AddressLocation{
+				Address: address,
+				Name:    name,
+			}
+			return accountCodes[location.ID()], nil
+		},
+		updateAccountContractCode: func(address Address, name string, code []byte) error {
+			location := common.AddressLocation{
+				Address: address,
+				Name:    name,
+			}
+			accountCodes[location.ID()] = code
+			return nil
+		},
+		emitEvent: func(event cadence.Event) error {
+			return nil
+		},
+		log: func(message string) {
+			loggedMessages = append(loggedMessages, message)
+		},
+	}
+
+	nextTransactionLocation := newTransactionLocationGenerator()
+
+	executeTransaction := func(source string) error {
+		loggedMessages = nil
+
+		return runtime.ExecuteTransaction(
+			Script{
+				Source: []byte(source),
+			},
+			Context{
+				Interface: runtimeInterface,
+				Location:  nextTransactionLocation(),
+			},
+		)
+	}
+
+	err := executeTransaction(string(utils.DeploymentTransaction("Test", contract)))
+	require.NoError(t, err)
+
+	t.Run("non-resource", func(t *testing.T) {
+
+		const tx = `
+          transaction {
+              prepare(signer: AuthAccount) {
+                  signer.saveOrReplace(%s, to: /storage/s)
+                  log(signer.copy<[Int]>(from: /storage/s))
+              }
+          }
+        `
+
+		err := executeTransaction(fmt.Sprintf(tx, "[1, 2]"))
+		require.NoError(t, err)
+		require.Equal(t, []string{"[1, 2]"}, loggedMessages)
+
+		err = executeTransaction(fmt.Sprintf(tx, "[3]"))
+		require.NoError(t, err)
+		require.Equal(t, []string{"[3]"}, loggedMessages)
+	})
+
+	t.Run("resource", func(t *testing.T) {
+
+		const saveTx = `
+          import Test from 0x1
+
+          transaction {
+              prepare(signer: AuthAccount) {
+                  signer.saveOrReplace(<-Test.createR(), to: /storage/r)
+              }
+          }
+        `
+
+		err := executeTransaction(saveTx)
+		require.NoError(t, err)
+
+		// Replacing a stored resource is not allowed,
+		// as it would be lost
+
+		err = executeTransaction(saveTx)
+		require.Error(t, err)
+
+		var overwriteErr interpreter.ResourceOverwriteError
+		require.ErrorAs(t, err, &overwriteErr)
+
+		// Replacing is allowed after the stored resource was loaded
+
+		err = executeTransaction(`
+          import Test from 0x1
+
+          transaction {
+              prepare(signer: AuthAccount) {
+                  let r <- signer.load<@Test.R>(from: /storage/r)!
+                  signer.saveOrReplace(<-Test.createR(), to: /storage/r)
+                  log(signer.borrow<&Test.R>(from: /storage/r) != nil)
+                  destroy r
+              }
+          }
+        `)
+		require.NoError(t, err)
+		require.Equal(t, []string{"true"}, loggedMessages)
+	})
+}
+
+func TestRuntimeStorageCapacity(t *testing.T) {
+
+	t.Parallel()
+
+	runtime := newTestInterpreterRuntime()
+
+	script := []byte(`
+      pub fun main(): UInt64 {
+          return getAccount(0x1).storageCapacity
+      }
+    `)
+
+	t.Run("provided", func(t *testing.T) {
+
+		t.Parallel()
+
+		runtimeInterface := &testRuntimeInterface{
+			storage: newTestLedger(nil, nil),
+			getStorageCapacity: func(address Address) (uint64, error) {
+				assert.Equal(t, common.BytesToAddress([]byte{0x1}), address)
+				return 1245, nil
+			},
+		}
+
+		result, err := runtime.ExecuteScript(
+			Script{
+				Source: script,
+			},
+			Context{
+				Interface: runtimeInterface,
+				Location:  common.ScriptLocation{},
+			},
+		)
+		require.NoError(t, err)
+
+		assert.Equal(t, cadence.UInt64(1245), result)
+	})
+
+	t.Run("not provided", func(t *testing.T) {
+
+		t.Parallel()
+
+		// Only expose the methods of the runtime interface,
+		// i.e. hide the optional storage capacity provider
+
+		runtimeInterface := struct {
+			Interface
+		}{
+			Interface: &testRuntimeInterface{
+				storage: newTestLedger(nil, nil),
+			},
+		}
+
+		_, err := runtime.ExecuteScript(
+			Script{
+				Source: script,
+			},
+			Context{
+				Interface: runtimeInterface,
+				Location:  common.ScriptLocation{},
+			},
+		)
+		require.Error(t, err)
+
+		var unavailableErr StorageCapacityUnavailableError
+		require.ErrorAs(t, err, &unavailableErr)
+
+		assert.Contains(t, err.Error(), "environment does not provide storage capacity")
+	})
+}
+
+func TestRuntimeAccountStorageInfo(t *testing.T) {
+
+	t.Parallel()
+
+	runtime := newTestInterpreterRuntime()
+
+	address := common.BytesToAddress([]byte{0x1})
+
+	newRuntimeInterface := func(used, capacity uint64) *testRuntimeInterface {
+		return &testRuntimeInterface{
+			storage: newTestLedger(nil, nil),
+			getStorageUsed: func(a Address) (uint64, error) {
+				assert.Equal(t, address, a)
+				return used, nil
+			},
+			getStorageCapacity: func(a Address) (uint64, error) {
+				assert.Equal(t, address, a)
+				return capacity, nil
+			},
+		}
+	}
+
+	t.Run("within capacity", func(t *testing.T) {
+
+		t.Parallel()
+
+		info, err := runtime.AccountStorageInfo(
+			address,
+			Context{
+				Interface: newRuntimeInterface(120, 1245),
+			},
+		)
+		require.NoError(t, err)
+
+		assert.Equal(t,
+			StorageInfo{
+				Used:      120,
+				Capacity:  1245,
+				Available: 1125,
+			},
+			info,
+		)
+		assert.Equal(t, info.Capacity, info.Used+info.Available)
+	})
+
+	t.Run("exceeding capacity", func(t *testing.T) {
+
+		t.Parallel()
+
+		info, err := runtime.AccountStorageInfo(
+			address,
+			Context{
+				Interface: newRuntimeInterface(200, 100),
+			},
+		)
+		require.NoError(t, err)
+
+		assert.Equal(t,
+			StorageInfo{
+				Used:      200,
+				Capacity:  100,
+				Available: 0,
+			},
+			info,
+		)
+	})
+
+	t.Run("used fails", func(t *testing.T) {
+
+		t.Parallel()
+
+		usedErr := errors.New("failed to get storage used")
+
+		runtimeInterface := newRuntimeInterface(0, 100)
+		runtimeInterface.getStorageUsed = func(_ Address) (uint64, error) {
+			return 0, usedErr
+		}
+
+		_, err := runtime.AccountStorageInfo(
+			address,
+			Context{
+				Interface: runtimeInterface,
+			},
+		)
+		require.ErrorIs(t, err, usedErr)
+	})
+
+	t.Run("capacity not provided", func(t *testing.T) {
+
+		t.Parallel()
+
+		// Only expose the methods of the runtime interface,
+		// i.e. hide the optional storage capacity provider
+
+		runtimeInterface := struct {
+			Interface
+		}{
+			Interface: newRuntimeInterface(120, 1245),
+		}
+
+		_, err := runtime.AccountStorageInfo(
+			address,
+			Context{
+				Interface: runtimeInterface,
+			},
+		)
+		require.Error(t, err)
+
+		var unavailableErr StorageCapacityUnavailableError
+		require.ErrorAs(t, err, &unavailableErr)
+	})
+}
+
+func TestRuntimeGetAccountContractNames(t *testing.T) {
+
+	t.Parallel()
+
+	runtime := newTestInterpreterRuntime()
+
+	address := common.BytesToAddress([]byte{0x1})
+
+	accountCodes := map[common.LocationID][]byte{}
+	var accountContractNames []string
+
+	runtimeInterface := &testRuntimeInterface{
+		storage: newTestLedger(nil, nil),
+		getSigningAccounts: func() ([]Address, error) {
+			return []Address{address}, nil
+		},
+		getAccountContractCode: func(address Address, name string) (code []byte, err error) {
+			location := common.AddressLocation{
+				Address: address,
+				Name:    name,
+			}
+			return accountCodes[location.ID()], nil
+		},
+		updateAccountContractCode: func(address Address, name string, code []byte) error {
+			location := common.AddressLocation{
+				Address: address,
+				Name:    name,
+			}
+			accountCodes[location.ID()] = code
+			accountContractNames = append(accountContractNames, name)
+			return nil
+		},
+		removeAccountContractCode: func(address Address, name string) error {
+			location := common.AddressLocation{
+				Address: address,
+				Name:    name,
+			}
+			delete(accountCodes, location.ID())
+			return nil
+		},
+		getAccountContractNames: func(_ Address) ([]string, error) {
+			return accountContractNames, nil
+		},
+		emitEvent: func(event cadence.Event) error {
+			return nil
+		},
+	}
+
+	nextTransactionLocation := newTransactionLocationGenerator()
+
+	executeTransaction := func(source []byte) {
+		err := runtime.ExecuteTransaction(
+			Script{
+				Source: source,
+			},
+			Context{
+				Interface: runtimeInterface,
+				Location:  nextTransactionLocation(),
+			},
+		)
+		require.NoError(t, err)
+	}
+
+	getNames := func() []string {
+		names, err := runtime.GetAccountContractNames(
+			address,
+			Context{
+				Interface: runtimeInterface,
+			},
+		)
+		require.NoError(t, err)
+		return names
+	}
+
+	require.Empty(t, getNames())
+
+	for _, name := range []string{"C", "A", "B"} {
+		executeTransaction(utils.DeploymentTransaction(
+			name,
+			[]byte(fmt.Sprintf("pub contract %s {}", name)),
+		))
+	}
+
+	require.Equal(t, []string{"A", "B", "C"}, getNames())
+
+	executeTransaction([]byte(`
+      transaction {
+          prepare(signer: AuthAccount) {
+              signer.contracts.remove(name: "B")
+          }
+      }
+    `))
+
+	require.Equal(t, []string{"A", "C"}, getNames())
+}
+
+func TestRuntimeWriteStored(t *testing.T) {
+
+	t.Parallel()
+
+	runtime := newTestInterpreterRuntime()
+
+	address := common.BytesToAddress([]byte{0x1})
+
+	contract := []byte(`
+      pub contract Test {
+
+          pub resource Vault {
+              pub let balance: UFix64
+
+              init(balance: UFix64) {
+                  self.balance = balance
+              }
+          }
+
+          pub struct S {
+              pub let f: ((): Void)?
+
+              init() {
+                  self.f = nil
+              }
+          }
+      }
+    `)
+
+	accountCodes := map[common.LocationID][]byte{}
+
+	var loggedMessages []string
+
+	var uuid uint64 = 42
+
+	runtimeInterface := &testRuntimeInterface{
+		storage: newTestLedger(nil, nil),
+		getSigningAccounts: func() ([]Address, error) {
+			return []Address{address}, nil
+		},
+		resolveLocation: singleIdentifierLocationResolver(t),
+		getAccountContractCode: func(address Address, name string) (code []byte, err error) {
+			location := common.AddressLocation{
+				Address: address,
+				Name:    name,
+			}
+			return accountCodes[location.ID()], nil
+		},
+		updateAccountContractCode: func(address Address, name string, code []byte) error {
+			location := common.AddressLocation{
+				Address: address,
+				Name:    name,
+			}
+			accountCodes[location.ID()] = code
+			return nil
+		},
+		emitEvent: func(event cadence.Event) error {
+			return nil
+		},
+		log: func(message string) {
+			loggedMessages = append(loggedMessages, message)
+		},
+		generateUUID: func() (uint64, error) {
+			uuid++
+			return uuid, nil
+		},
+	}
+
+	nextTransactionLocation := newTransactionLocationGenerator()
+
+	err := runtime.ExecuteTransaction(
+		Script{
+			Source: utils.DeploymentTransaction("Test", contract),
+		},
+		Context{
+			Interface: runtimeInterface,
+			Location:  nextTransactionLocation(),
+		},
+	)
+	require.NoError(t, err)
+
+	contractLocation := common.AddressLocation{
+		Address: address,
+		Name:    "Test",
+	}
+
+	vaultType := &cadence.ResourceType{
+		Location:            contractLocation,
+		QualifiedIdentifier: "Test.Vault",
+		Fields: []cadence.Field{
+			{
+				Identifier: "uuid",
+				Type:       cadence.UInt64Type{},
+			},
+			{
+				Identifier: "balance",
+				Type:       cadence.UFix64Type{},
+			},
+		},
+	}
+
+	writeStored := func(path cadence.Path, value cadence.Value) error {
+		return runtime.WriteStored(
+			address,
+			path,
+			value,
+			Context{
+				Interface: runtimeInterface,
+				Location:  nextTransactionLocation(),
+			},
+		)
+	}
+
+	t.Run("resource", func(t *testing.T) {
+
+		path := cadence.Path{
+			Domain:     "storage",
+			Identifier: "vault",
+		}
+
+		vault := cadence.NewResource([]cadence.Value{
+			cadence.NewUInt64(1),
+			cadence.UFix64(10_00000000),
+		}).WithType(vaultType)
+
+		err := writeStored(path, vault)
+		require.NoError(t, err)
+
+		loggedMessages = nil
+
+		err = runtime.ExecuteTransaction(
+			Script{
+				Source: []byte(`
+                  import Test from 0x1
+
+                  transaction {
+                      prepare(signer: AuthAccount) {
+                          let vault = signer.borrow<&Test.Vault>(from: /storage/vault)!
+                          log(vault.balance)
+                          log(vault.uuid)
+                      }
+                  }
+                `),
+			},
+			Context{
+				Interface: runtimeInterface,
+				Location:  nextTransactionLocation(),
+			},
+		)
+		require.NoError(t, err)
+
+		// The resource got a fresh UUID
+
+		require.Equal(t,
+			[]string{"10.00000000", fmt.Sprint(uuid)},
+			loggedMessages,
+		)
+
+		// The stored resource is not overwritten
+
+		err = writeStored(path, vault)
+		require.Error(t, err)
+
+		require.ErrorAs(t, err, &interpreter.OverwriteError{})
+	})
+
+	t.Run("non-storable", func(t *testing.T) {
+
+		structType := &cadence.StructType{
+			Location:            contractLocation,
+			QualifiedIdentifier: "Test.S",
+			Fields: []cadence.Field{
+				{
+					Identifier: "f",
+					Type: cadence.OptionalType{
+						Type: &cadence.FunctionType{
+							ReturnType: cadence.VoidType{},
+						},
+					},
+				},
+			},
+		}
+
+		value := cadence.NewStruct([]cadence.Value{
+			cadence.NewOptional(nil),
+		}).WithType(structType)
+
+		err := writeStored(
+			cadence.Path{
+				Domain:     "storage",
+				Identifier: "s",
+			},
+			value,
+		)
+		require.Error(t, err)
+
+		require.ErrorAs(t, err, &interpreter.NonStorableValueError{})
+	})
+
+	t.Run("public path", func(t *testing.T) {
+
+		err := writeStored(
+			cadence.Path{
+				Domain:     "public",
+				Identifier: "x",
+			},
+			cadence.NewInt(1),
+		)
+		require.Error(t, err)
+
+		require.ErrorAs(t, err, &interpreter.InvalidPathDomainError{})
+	})
+}
+
+func TestRuntimeReadOnly(t *testing.T) {
+
+	t.Parallel()
+
+	runtime := newTestInterpreterRuntime()
+
+	address := common.BytesToAddress([]byte{0x1})
+
+	contract := []byte(`
+      pub contract Test {
+
+          pub var counter: Int
+
+          pub let items: [Int]
+
+          init() {
+              self.counter = 0
+              self.items = []
+          }
+
+          pub fun increment() {
+              self.counter = self.counter + 1
+          }
+
+          pub fun appendItem() {
+              self.items.append(1)
+          }
+
+          pub fun save() {
+              self.account.save(1, to: /storage/one)
+          }
+
+          pub fun remove() {
+              self.account.contracts.remove(name: "Test")
+          }
+      }
+    `)
+
+	accountCodes := map[common.LocationID][]byte{}
+
+	var ledgerWrites int
+
+	runtimeInterface := &testRuntimeInterface{
+		storage: newTestLedger(
+			nil,
+			func(_, _, _ []byte) {
+				ledgerWrites++
+			},
+		),
+		getSigningAccounts: func() ([]Address, error) {
+			return []Address{address}, nil
+		},
+		resolveLocation: singleIdentifierLocationResolver(t),
+		getAccountContractCode: func(address Address, name string) (code []byte, err error) {
+			location := common.AddressLocation{
+				Address: address,
+				Name:    name,
+			}
+			return accountCodes[location.ID()], nil
+		},
+		updateAccountContractCode: func(address Address, name string, code []byte) error {
+			location := common.AddressLocation{
+				Address: address,
+				Name:    name,
+			}
+			accountCodes[location.ID()] = code
+			return nil
+		},
+		emitEvent: func(event cadence.Event) error {
+			return nil
+		},
+	}
+
+	nextTransactionLocation := newTransactionLocationGenerator()
+
+	err := runtime.ExecuteTransaction(
+		Script{
+			Source: utils.DeploymentTransaction("Test", contract),
+		},
+		Context{
+			Interface: runtimeInterface,
+			Location:  nextTransactionLocation(),
+		},
+	)
+	require.NoError(t, err)
+
+	committedLedgerWrites := ledgerWrites
+
+	executeScript := func(code string) (cadence.Value, error) {
+		return runtime.ExecuteScript(
+			Script{
+				Source: []byte(code),
+			},
+			Context{
+				Interface: runtimeInterface,
+				Location:  common.ScriptLocation{},
+				ReadOnly:  true,
+			},
+		)
+	}
+
+	t.Run("read", func(t *testing.T) {
+
+		value, err := executeScript(`
+          import Test from 0x1
+
+          pub fun main(): Int {
+              return Test.counter + Test.items.length
+          }
+        `)
+		require.NoError(t, err)
+
+		assert.Equal(t, cadence.NewInt(0), value)
+	})
+
+	for name, test := range map[string]struct {
+		function  string
+		operation string
+	}{
+		"field write": {
+			function:  "increment",
+			operation: "field write",
+		},
+		"array append": {
+			function:  "appendItem",
+			operation: "array append",
+		},
+		"save": {
+			function:  "save",
+			operation: "save",
+		},
+		"contract removal": {
+			function:  "remove",
+			operation: "remove",
+		},
+	} {
+		test := test
+
+		t.Run(name, func(t *testing.T) {
+
+			_, err := executeScript(fmt.Sprintf(
+				`
+                  import Test from 0x1
+
+                  pub fun main() {
+                      Test.%s()
+                  }
+                `,
+				test.function,
+			))
+			require.Error(t, err)
+
+			var violationErr interpreter.ReadOnlyViolationError
+			require.ErrorAs(t, err, &violationErr)
+
+			assert.Equal(t, test.operation, violationErr.Operation)
+
+			assert.Equal(t, committedLedgerWrites, ledgerWrites)
+		})
+	}
+}
+
+func TestRuntimeValidateStoredCapabilities(t *testing.T) {
+
+	t.Parallel()
+
+	address := common.BytesToAddress([]byte{0x1})
+
+	newRuntimeInterface := func() Interface {
+		return &testRuntimeInterface{
+			storage: newTestLedger(nil, nil),
+			getSigningAccounts: func() ([]Address, error) {
+				return []Address{address}, nil
+			},
+		}
+	}
+
+	executeTransaction := func(
+		runtimeInterface Interface,
+		source string,
+		validateStoredCapabilities bool,
+	) error {
+		runtime := newTestInterpreterRuntime()
+
+		return runtime.ExecuteTransaction(
+			Script{
+				Source: []byte(source),
+			},
+			Context{
+				Interface:                  runtimeInterface,
+				Location:                   utils.TestLocation,
+				ValidateStoredCapabilities: validateStoredCapabilities,
+			},
+		)
+	}
+
+	const storeDanglingCapabilityTx = `
+      transaction {
+          prepare(signer: AuthAccount) {
+              let cap = signer.getCapability<&Int>(/public/missing)
+              signer.save({"cap": cap}, to: /storage/caps)
+          }
+      }
+    `
+
+	t.Run("dangling, disabled", func(t *testing.T) {
+
+		t.Parallel()
+
+		err := executeTransaction(newRuntimeInterface(), storeDanglingCapabilityTx, false)
+		require.NoError(t, err)
+	})
+
+	t.Run("dangling, enabled", func(t *testing.T) {
+
+		t.Parallel()
+
+		err := executeTransaction(newRuntimeInterface(), storeDanglingCapabilityTx, true)
+		require.Error(t, err)
+
+		var danglingErr DanglingStoredCapabilityError
+		require.ErrorAs(t, err, &danglingErr)
+
+		assert.Equal(t, address, danglingErr.Address)
+		assert.Equal(t,
+			interpreter.PathValue{
+				Domain:     common.PathDomainPublic,
+				Identifier: "missing",
+			},
+			danglingErr.Path,
+		)
+	})
+
+	t.Run("dangling, inserted into stored container, enabled", func(t *testing.T) {
+
+		t.Parallel()
+
+		runtimeInterface := newRuntimeInterface()
+
+		err := executeTransaction(
+			runtimeInterface,
+			`
+              transaction {
+                  prepare(signer: AuthAccount) {
+                      let caps: [Capability] = []
+                      signer.save(caps, to: /storage/caps)
+                  }
+              }
+            `,
+			true,
+		)
+		require.NoError(t, err)
+
+		err = executeTransaction(
+			runtimeInterface,
+			`
+              transaction {
+                  prepare(signer: AuthAccount) {
+                      let caps = signer.borrow<&[Capability]>(from: /storage/caps)!
+                      caps.append(signer.getCapability<&Int>(/public/missing))
+                  }
+              }
+            `,
+			true,
+		)
+		require.Error(t, err)
+
+		var danglingErr DanglingStoredCapabilityError
+		require.ErrorAs(t, err, &danglingErr)
+	})
+
+	t.Run("live, enabled", func(t *testing.T) {
+
+		t.Parallel()
+
+		err := executeTransaction(
+			newRuntimeInterface(),
+			`
+              transaction {
+                  prepare(signer: AuthAccount) {
+                      signer.save(1, to: /storage/one)
+                      signer.link<&Int>(/public/one, target: /storage/one)
+
+                      let typedCap = signer.getCapability<&Int>(/public/one)
+                      let untypedCap = signer.getCapability(/public/one)
+                      signer.save([typedCap, untypedCap], to: /storage/caps)
+                  }
+              }
+            `,
+			true,
+		)
+		require.NoError(t, err)
+	})
+
+	t.Run("linked after saved, enabled", func(t *testing.T) {
+
+		t.Parallel()
+
+		err := executeTransaction(
+			newRuntimeInterface(),
+			`
+              transaction {
+                  prepare(signer: AuthAccount) {
+                      let cap = signer.getCapability<&Int>(/public/one)
+                      signer.save(cap, to: /storage/cap)
+
+                      signer.save(1, to: /storage/one)
+                      signer.link<&Int>(/public/one, target: /storage/one)
+                  }
+              }
+            `,
+			true,
+		)
+		require.NoError(t, err)
+	})
+
+	t.Run("unlinked after saved, enabled", func(t *testing.T) {
+
+		t.Parallel()
+
+		err := executeTransaction(
+			newRuntimeInterface(),
+			`
+              transaction {
+                  prepare(signer: AuthAccount) {
+                      signer.save(1, to: /storage/one)
+                      signer.link<&Int>(/public/one, target: /storage/one)
+
+                      let cap = signer.getCapability<&Int>(/public/one)
+                      signer.save(cap, to: /storage/cap)
+
+                      signer.unlink(/public/one)
+                  }
+              }
+            `,
+			true,
+		)
+		require.Error(t, err)
+
+		var danglingErr DanglingStoredCapabilityError
+		require.ErrorAs(t, err, &danglingErr)
+	})
+}
+
+func TestRuntimeEventLimit(t *testing.T) {
+
+	t.Parallel()
+
+	address := common.BytesToAddress([]byte{0x1})
+
+	const contract = `
+      pub contract Test {
+
+          pub event Emitted(index: Int)
+
+          pub fun emitEvents(count: Int) {
+              var index = 0
+              while index < count {
+                  emit Emitted(index: index)
+                  index = index + 1
+              }
+          }
+      }
+    `
+
+	const maxEvents = 3
+
+	test := func(t *testing.T, count int) (int, error) {
+
+		var contractCode []byte
+		var events int
+
+		runtimeInterface := &testRuntimeInterface{
+			storage: newTestLedger(nil, nil),
+			getSigningAccounts: func() ([]Address, error) {
+				return []Address{address}, nil
+			},
+			resolveLocation: singleIdentifierLocationResolver(t),
+			getAccountContractCode: func(_ Address, _ string) ([]byte, error) {
+				return contractCode, nil
+			},
+			updateAccountContractCode: func(_ Address, _ string, code []byte) error {
+				contractCode = code
+				return nil
+			},
+			emitEvent: func(event cadence.Event) error {
+				events++
+				return nil
+			},
+		}
+
+		runtime := newTestInterpreterRuntime()
+
+		nextTransactionLocation := newTransactionLocationGenerator()
+
+		err := runtime.ExecuteTransaction(
+			Script{
+				Source: utils.DeploymentTransaction("Test", []byte(contract)),
+			},
+			Context{
+				Interface: runtimeInterface,
+				Location:  nextTransactionLocation(),
+			},
+		)
+		require.NoError(t, err)
+
+		events = 0
+
+		err = runtime.ExecuteTransaction(
+			Script{
+				Source: []byte(fmt.Sprintf(
+					`
+                      import Test from 0x1
+
+                      transaction {
+                          prepare(signer: AuthAccount) {
+                              Test.emitEvents(count: %d)
+                          }
+                      }
+                    `,
+					count,
+				)),
+			},
+			Context{
+				Interface: runtimeInterface,
+				Location:  nextTransactionLocation(),
+				MaxEvents: maxEvents,
+			},
+		)
+
+		return events, err
+	}
+
+	t.Run("within limit", func(t *testing.T) {
+
+		t.Parallel()
+
+		events, err := test(t, maxEvents)
+		require.NoError(t, err)
+
+		assert.Equal(t, maxEvents, events)
+	})
+
+	t.Run("exceeding limit", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := test(t, maxEvents+1)
+		require.Error(t, err)
+
+		var eventLimitErr interpreter.EventLimitExceededError
+		require.ErrorAs(t, err, &eventLimitErr)
+
+		var runtimeErr Error
+		require.ErrorAs(t, err, &runtimeErr)
+
+		assert.Equal(t,
+			&LimitExceeded{
+				Kind:  LimitKindEvents,
+				Limit: maxEvents,
+			},
+			runtimeErr.LimitExceeded,
+		)
+	})
+}
+
+type testEventCountRuntimeInterface struct {
+	*testRuntimeInterface
+	onEventsEmitted func(count uint64)
+}
+
+var _ EventCountListener = testEventCountRuntimeInterface{}
+
+func (i testEventCountRuntimeInterface) OnEventsEmitted(count uint64) {
+	i.onEventsEmitted(count)
+}
+
+func TestRuntimeEventCount(t *testing.T) {
+
+	t.Parallel()
+
+	address := common.BytesToAddress([]byte{0x1})
+
+	const contract = `
+      pub contract Test {
+
+          pub event Emitted(index: Int)
+
+          pub fun emitEvents(count: Int) {
+              var index = 0
+              while index < count {
+                  emit Emitted(index: index)
+                  index = index + 1
+              }
+          }
+      }
+    `
+
+	var contractCode []byte
+	var eventCount *uint64
+
+	runtimeInterface := testEventCountRuntimeInterface{
+		testRuntimeInterface: &testRuntimeInterface{
+			storage: newTestLedger(nil, nil),
+			getSigningAccounts: func() ([]Address, error) {
+				return []Address{address}, nil
+			},
+			resolveLocation: singleIdentifierLocationResolver(t),
+			getAccountContractCode: func(_ Address, _ string) ([]byte, error) {
+				return contractCode, nil
+			},
+			updateAccountContractCode: func(_ Address, _ string, code []byte) error {
+				contractCode = code
+				return nil
+			},
+			emitEvent: func(event cadence.Event) error {
+				return nil
+			},
+		},
+		onEventsEmitted: func(count uint64) {
+			eventCount = &count
+		},
+	}
+
+	runtime := newTestInterpreterRuntime()
+
+	nextTransactionLocation := newTransactionLocationGenerator()
+
+	err := runtime.ExecuteTransaction(
+		Script{
+			Source: utils.DeploymentTransaction("Test", []byte(contract)),
+		},
+		Context{
+			Interface: runtimeInterface,
+			Location:  nextTransactionLocation(),
+		},
+	)
+	require.NoError(t, err)
+
+	// The AccountContractAdded event is emitted by the runtime, not by the program
+
+	require.NotNil(t, eventCount)
+	assert.Equal(t, uint64(0), *eventCount)
+
+	eventCount = nil
+
+	err = runtime.ExecuteTransaction(
+		Script{
+			Source: []byte(`
+              import Test from 0x1
+
+              transaction {
+                  prepare(signer: AuthAccount) {
+                      Test.emitEvents(count: 3)
+                  }
+              }
+            `),
+		},
+		Context{
+			Interface: runtimeInterface,
+			Location:  nextTransactionLocation(),
+		},
+	)
+	require.NoError(t, err)
+
+	require.NotNil(t, eventCount)
+	assert.Equal(t, uint64(3), *eventCount)
+}
+
+func TestRuntimeOnContractCodeLoaded(t *testing.T) {
+
+	t.Parallel()
+
+	address := common.BytesToAddress([]byte{0x1})
+
+	accountCodes := map[common.LocationID][]byte{}
+
+	runtimeInterface := &testRuntimeInterface{
+		storage: newTestLedger(nil, nil),
+		getSigningAccounts: func() ([]Address, error) {
+			return []Address{address}, nil
+		},
+		resolveLocation: singleIdentifierLocationResolver(t),
+		getAccountContractCode: func(address Address, name string) ([]byte, error) {
+			location := common.AddressLocation{
+				Address: address,
+				Name:    name,
+			}
+			return accountCodes[location.ID()], nil
+		},
+		updateAccountContractCode: func(address Address, name string, code []byte) error {
+			location := common.AddressLocation{
+				Address: address,
+				Name:    name,
+			}
+			accountCodes[location.ID()] = code
+			return nil
+		},
+		emitEvent: func(event cadence.Event) error {
+			return nil
+		},
+	}
+
+	runtime := newTestInterpreterRuntime()
+
+	nextTransactionLocation := newTransactionLocationGenerator()
+
+	for name, contract := range map[string]string{
+		"A": `
+          pub contract A {
+              pub fun answer(): Int {
+                  return 42
+              }
+          }
+        `,
+		"C": `
+          pub contract C {}
+        `,
+	} {
+		err := runtime.ExecuteTransaction(
+			Script{
+				Source: utils.DeploymentTransaction(name, []byte(contract)),
+			},
+			Context{
+				Interface: runtimeInterface,
+				Location:  nextTransactionLocation(),
+			},
+		)
+		require.NoError(t, err)
+	}
+
+	err := runtime.ExecuteTransaction(
+		Script{
+			Source: utils.DeploymentTransaction("B", []byte(`
+              import A from 0x1
+
+              pub contract B {
+                  pub fun answer(): Int {
+                      return A.answer()
+                  }
+              }
+            `)),
+		},
+		Context{
+			Interface: runtimeInterface,
+			Location:  nextTransactionLocation(),
+		},
+	)
+	require.NoError(t, err)
+
+	var loadedContracts []common.AddressLocation
+
+	// A is imported both by the transaction and by B,
+	// but is only reported once. C is never loaded
+
+	err = runtime.ExecuteTransaction(
+		Script{
+			Source: []byte(`
+              import A from 0x1
+              import B from 0x1
+
+              transaction {
+                  prepare(signer: AuthAccount) {
+                      assert(A.answer() == B.answer())
+                  }
+              }
+            `),
+		},
+		Context{
+			Interface: runtimeInterface,
+			Location:  nextTransactionLocation(),
+			OnContractCodeLoaded: func(location common.AddressLocation) {
+				loadedContracts = append(loadedContracts, location)
+			},
+		},
+	)
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t,
+		[]common.AddressLocation{
+			{
+				Address: address,
+				Name:    "A",
+			},
+			{
+				Address: address,
+				Name:    "B",
+			},
+		},
+		loadedContracts,
+	)
+}
+
+func TestRuntimeAllowEscapingReferences(t *testing.T) {
+
+	t.Parallel()
+
+	runtime := newTestInterpreterRuntime()
+
+	script := []byte(`
+      pub fun ref(): &Int {
+          let x = 1
+          return &x as &Int
+      }
+
+      pub fun main() {
+          ref()
+      }
+    `)
+
+	runtimeInterface := &testRuntimeInterface{
+		storage: newTestLedger(nil, nil),
+	}
+
+	executeScript := func(allowEscapingReferences bool) error {
+		_, err := runtime.ExecuteScript(
+			Script{
+				Source: script,
+			},
+			Context{
+				Interface:               runtimeInterface,
+				Location:                common.ScriptLocation{},
+				AllowEscapingReferences: allowEscapingReferences,
+			},
+		)
+		return err
+	}
+
+	t.Run("allowed", func(t *testing.T) {
+
+		t.Parallel()
+
+		err := executeScript(true)
+		require.NoError(t, err)
+	})
+
+	t.Run("rejected by default", func(t *testing.T) {
+
+		t.Parallel()
+
+		err := executeScript(false)
+		require.Error(t, err)
+
+		var checkerErr *sema.CheckerError
+		require.ErrorAs(t, err, &checkerErr)
+
+		errs := checker.ExpectCheckerErrors(t, checkerErr, 1)
+
+		assert.IsType(t, &sema.ReferenceEscapesScopeError{}, errs[0])
+	})
+}
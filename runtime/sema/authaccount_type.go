@@ -31,6 +31,8 @@ const AuthAccountStorageCapacityField = "storageCapacity"
 const AuthAccountAddPublicKeyField = "addPublicKey"
 const AuthAccountRemovePublicKeyField = "removePublicKey"
 const AuthAccountSaveField = "save"
+const AuthAccountEnsureSavedField = "ensureSaved"
+const AuthAccountSaveOrReplaceField = "saveOrReplace"
 const AuthAccountLoadField = "load"
 const AuthAccountTypeField = "type"
 const AuthAccountCopyField = "copy"
@@ -111,6 +113,18 @@ var AuthAccountType = func() *CompositeType {
 			AuthAccountTypeSaveFunctionType,
 			authAccountTypeSaveFunctionDocString,
 		),
+		NewPublicFunctionMember(
+			authAccountType,
+			AuthAccountEnsureSavedField,
+			AuthAccountTypeEnsureSavedFunctionType,
+			authAccountTypeEnsureSavedFunctionDocString,
+		),
+		NewPublicFunctionMember(
+			authAccountType,
+			AuthAccountSaveOrReplaceField,
+			AuthAccountTypeSaveOrReplaceFunctionType,
+			authAccountTypeSaveOrReplaceFunctionDocString,
+		),
 		NewPublicFunctionMember(
 			authAccountType,
 			AuthAccountTypeField,
@@ -256,6 +270,90 @@ If there is already an object stored under the given path, the program aborts.
 The path must be a storage path, i.e., only the domain ` + "`storage`" + ` is allowed
 `
 
+var AuthAccountTypeEnsureSavedFunctionType = func() *FunctionType {
+
+	typeParameter := &TypeParameter{
+		Name:      "T",
+		TypeBound: StorableType,
+	}
+
+	return &FunctionType{
+		TypeParameters: []*TypeParameter{
+			typeParameter,
+		},
+		Parameters: []*Parameter{
+			{
+				Label:      ArgumentLabelNotRequired,
+				Identifier: "value",
+				TypeAnnotation: NewTypeAnnotation(
+					&GenericType{
+						TypeParameter: typeParameter,
+					},
+				),
+			},
+			{
+				Label:          "to",
+				Identifier:     "path",
+				TypeAnnotation: NewTypeAnnotation(StoragePathType),
+			},
+		},
+		ReturnTypeAnnotation: NewTypeAnnotation(BoolType),
+	}
+}()
+
+const authAccountTypeEnsureSavedFunctionDocString = `
+Saves the given object into the account's storage at the given path, if there is no object stored under the given path yet.
+
+Returns true if the object was saved, and false if an equal structure is already stored under the given path,
+which allows the function to be called repeatedly, e.g. in re-runnable setup transactions.
+
+If a different object is stored under the given path, the program aborts.
+Resources cannot be compared, so if the given object is a resource, there must be no object stored under the given path.
+
+The path must be a storage path, i.e., only the domain ` + "`storage`" + ` is allowed
+`
+
+var AuthAccountTypeSaveOrReplaceFunctionType = func() *FunctionType {
+
+	typeParameter := &TypeParameter{
+		Name:      "T",
+		TypeBound: StorableType,
+	}
+
+	return &FunctionType{
+		TypeParameters: []*TypeParameter{
+			typeParameter,
+		},
+		Parameters: []*Parameter{
+			{
+				Label:      ArgumentLabelNotRequired,
+				Identifier: "value",
+				TypeAnnotation: NewTypeAnnotation(
+					&GenericType{
+						TypeParameter: typeParameter,
+					},
+				),
+			},
+			{
+				Label:          "to",
+				Identifier:     "path",
+				TypeAnnotation: NewTypeAnnotation(StoragePathType),
+			},
+		},
+		ReturnTypeAnnotation: NewTypeAnnotation(VoidType),
+	}
+}()
+
+const authAccountTypeSaveOrReplaceFunctionDocString = `
+Saves the given object into the account's storage at the given path, replacing the object already stored under the given path, if any.
+Resources are moved into storage, and structures are copied.
+
+If there is already a resource stored under the given path, the program aborts.
+The resource must be loaded first, so that it is not lost.
+
+The path must be a storage path, i.e., only the domain ` + "`storage`" + ` is allowed
+`
+
 var AuthAccountTypeLoadFunctionType = func() *FunctionType {
 
 	typeParameter := &TypeParameter{
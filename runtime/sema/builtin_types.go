@@ -0,0 +1,103 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2020 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sema
+
+import (
+	"math/big"
+)
+
+// BuiltinTypeInfo describes a builtin type, i.e. a type which is declared in the base type activation.
+//
+type BuiltinTypeInfo struct {
+	Name string
+	Type Type
+	// IsEquatable is true if values of the type can be compared for equality (`==` and `!=`)
+	IsEquatable bool
+	// IsComparable is true if values of the type can be ordered (`<`, `<=`, `>`, and `>=`)
+	IsComparable bool
+	// IsStorable is true if values of the type can be stored
+	IsStorable bool
+	// IsImportable is true if values of the type can be passed as arguments to a program
+	IsImportable bool
+	// MinInt and MaxInt are the bounds of the integer part of number types.
+	// They are nil if the type is not a number type, or if the type is unbounded
+	MinInt *big.Int
+	MaxInt *big.Int
+	// MinFractional and MaxFractional are the bounds of the fractional part of fixed-point types.
+	// They are nil if the type is not a fixed-point type
+	MinFractional *big.Int
+	MaxFractional *big.Int
+	// Scale is the number of decimal places of fixed-point types.
+	// It is zero if the type is not a fixed-point type
+	Scale uint
+}
+
+// BuiltinTypes returns information about all builtin types,
+// in the order they are declared.
+//
+func BuiltinTypes() []BuiltinTypeInfo {
+	var infos []BuiltinTypeInfo
+
+	_ = BaseTypeActivation.ForEach(func(name string, variable *Variable) error {
+
+		// The empty type annotation is an alias for Void
+		if name == "" {
+			return nil
+		}
+
+		infos = append(infos, newBuiltinTypeInfo(name, variable.Type))
+		return nil
+	})
+
+	return infos
+}
+
+func newBuiltinTypeInfo(name string, ty Type) BuiltinTypeInfo {
+	info := BuiltinTypeInfo{
+		Name:         name,
+		Type:         ty,
+		IsEquatable:  ty.IsEquatable(),
+		IsComparable: IsSubType(ty, NumberType),
+		IsStorable:   ty.IsStorable(map[*Member]bool{}),
+		IsImportable: ty.IsImportable(map[*Member]bool{}),
+	}
+
+	if rangedType, ok := ty.(IntegerRangedType); ok {
+		info.MinInt = copyBigInt(rangedType.MinInt())
+		info.MaxInt = copyBigInt(rangedType.MaxInt())
+	}
+
+	if fractionalType, ok := ty.(FractionalRangedType); ok {
+		info.MinFractional = copyBigInt(fractionalType.MinFractional())
+		info.MaxFractional = copyBigInt(fractionalType.MaxFractional())
+		info.Scale = fractionalType.Scale()
+	}
+
+	return info
+}
+
+// copyBigInt returns a copy of the given integer,
+// so the bounds of the types cannot be modified through the returned information.
+//
+func copyBigInt(i *big.Int) *big.Int {
+	if i == nil {
+		return nil
+	}
+	return new(big.Int).Set(i)
+}
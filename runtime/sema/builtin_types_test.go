@@ -0,0 +1,116 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2020 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sema
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuiltinTypes(t *testing.T) {
+
+	t.Parallel()
+
+	infos := map[string]BuiltinTypeInfo{}
+
+	for _, info := range BuiltinTypes() {
+		_, ok := infos[info.Name]
+		require.False(t, ok, "duplicate builtin type %s", info.Name)
+
+		infos[info.Name] = info
+	}
+
+	t.Run("UInt8", func(t *testing.T) {
+
+		t.Parallel()
+
+		info, ok := infos["UInt8"]
+		require.True(t, ok)
+
+		assert.Equal(t, UInt8Type, info.Type)
+		assert.Equal(t, big.NewInt(0), info.MinInt)
+		assert.Equal(t, big.NewInt(255), info.MaxInt)
+		assert.Nil(t, info.MinFractional)
+		assert.Nil(t, info.MaxFractional)
+		assert.Equal(t, uint(0), info.Scale)
+		assert.True(t, info.IsEquatable)
+		assert.True(t, info.IsComparable)
+		assert.True(t, info.IsStorable)
+		assert.True(t, info.IsImportable)
+	})
+
+	t.Run("UFix64", func(t *testing.T) {
+
+		t.Parallel()
+
+		info, ok := infos["UFix64"]
+		require.True(t, ok)
+
+		assert.Equal(t, UFix64Type, info.Type)
+		assert.Equal(t, uint(8), info.Scale)
+		assert.Equal(t, big.NewInt(0), info.MinInt)
+		assert.Equal(t, big.NewInt(184467440737), info.MaxInt)
+		assert.Equal(t, big.NewInt(0), info.MinFractional)
+		assert.Equal(t, big.NewInt(9551615), info.MaxFractional)
+		assert.True(t, info.IsComparable)
+	})
+
+	t.Run("Int", func(t *testing.T) {
+
+		t.Parallel()
+
+		info, ok := infos["Int"]
+		require.True(t, ok)
+
+		assert.Nil(t, info.MinInt)
+		assert.Nil(t, info.MaxInt)
+		assert.True(t, info.IsComparable)
+	})
+
+	t.Run("String", func(t *testing.T) {
+
+		t.Parallel()
+
+		info, ok := infos["String"]
+		require.True(t, ok)
+
+		assert.True(t, info.IsEquatable)
+		assert.False(t, info.IsComparable)
+		assert.Nil(t, info.MinInt)
+		assert.Nil(t, info.MaxInt)
+	})
+
+	t.Run("bounds are copies", func(t *testing.T) {
+
+		t.Parallel()
+
+		for _, info := range BuiltinTypes() {
+			if info.Name != "UInt8" {
+				continue
+			}
+
+			info.MaxInt.SetInt64(1)
+		}
+
+		assert.Equal(t, big.NewInt(255), UInt8Type.MaxInt())
+	})
+}
@@ -0,0 +1,69 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2020 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sema
+
+import (
+	"github.com/onflow/cadence/runtime/common"
+)
+
+const CapabilityCheckResultTypeName = "CapabilityCheckResult"
+const CapabilityCheckResultIsLinkedField = "isLinked"
+const CapabilityCheckResultIsBorrowableField = "isBorrowable"
+
+const capabilityCheckResultIsLinkedFieldDocString = `
+True if there is a link or an object stored under the path of the capability
+`
+
+const capabilityCheckResultIsBorrowableFieldDocString = `
+True if the capability can be borrowed using the checked type.
+
+If the capability is linked, but cannot be borrowed, then the link does not allow the checked type,
+or the target of the link does not exist or does not satisfy the checked type
+`
+
+// CapabilityCheckResultType represents the result of checking a capability,
+// i.e. if the capability is linked, and if it can be borrowed using a certain type.
+//
+var CapabilityCheckResultType = func() *CompositeType {
+
+	capabilityCheckResultType := &CompositeType{
+		Identifier: CapabilityCheckResultTypeName,
+		Kind:       common.CompositeKindStructure,
+		importable: false,
+	}
+
+	var members = []*Member{
+		NewPublicConstantFieldMember(
+			capabilityCheckResultType,
+			CapabilityCheckResultIsLinkedField,
+			BoolType,
+			capabilityCheckResultIsLinkedFieldDocString,
+		),
+		NewPublicConstantFieldMember(
+			capabilityCheckResultType,
+			CapabilityCheckResultIsBorrowableField,
+			BoolType,
+			capabilityCheckResultIsBorrowableFieldDocString,
+		),
+	}
+
+	capabilityCheckResultType.Members = GetMembersAsMap(members)
+	capabilityCheckResultType.Fields = getFieldNames(members)
+	return capabilityCheckResultType
+}()
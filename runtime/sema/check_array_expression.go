@@ -96,6 +96,10 @@ func (checker *Checker) VisitArrayExpression(expression *ast.ArrayExpression) as
 		}
 	}
 
+	// The array is created with the array type at run-time
+
+	checker.checkRuntimeType(resultType, expression)
+
 	checker.Elaboration.ArrayExpressionArrayType[expression] = resultType
 
 	return resultType
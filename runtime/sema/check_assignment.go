@@ -104,6 +104,10 @@ func (checker *Checker) checkAssignment(
 		ResourceInvalidationKindMoveDefinite,
 	)
 
+	if checker.referenceEscapeChecksEnabled {
+		checker.checkAssignedReferenceEscape(target, value)
+	}
+
 	return
 }
 
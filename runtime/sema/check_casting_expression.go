@@ -87,6 +87,10 @@ func (checker *Checker) VisitCastingExpression(expression *ast.CastingExpression
 	switch expression.Operation {
 	case ast.OperationFailableCast, ast.OperationForceCast:
 
+		// The dynamic cast tests the value against the type at run-time
+
+		checker.checkRuntimeType(rightHandType, expression.TypeAnnotation)
+
 		if bothValid {
 
 			if leftHandType.IsResourceType() {
@@ -111,7 +115,37 @@ func (checker *Checker) VisitCastingExpression(expression *ast.CastingExpression
 				}
 			}
 
-			if !FailableCastCanSucceed(leftHandType, rightHandType) {
+			// Force-casting a restricted reference to a wider type always fails at run-time,
+			// if the reference was created with the restricted type,
+			// even if the static type of the value was widened before using a static cast,
+			// e.g. `(ref as AnyStruct) as! &R`, where `ref` was borrowed as `&R{RI}`.
+			//
+			// NOTE: A value of static type `&R{RI}` might also be a reference of type `&R`,
+			// e.g. if it was statically upcast before, so only references which are created directly,
+			// i.e. by a reference expression or a borrow, are considered.
+			//
+			// A failable cast is allowed, so the downcast can be attempted safely:
+			// It results in nil in exactly these cases
+
+			uncastLeftHandExpression, uncastLeftHandType :=
+				checker.uncastValue(leftHandExpression, leftHandType)
+
+			isWidening := isRestrictedReferenceWidening(uncastLeftHandType, rightHandType)
+
+			if isWidening &&
+				expression.Operation == ast.OperationForceCast &&
+				checker.isCreatedReference(uncastLeftHandExpression) {
+
+				checker.report(
+					&InvalidRestrictedReferenceWideningError{
+						ValueType:  uncastLeftHandType,
+						TargetType: rightHandType,
+						Range:      ast.NewRangeFromPositioned(expression),
+					},
+				)
+			} else if isWidening && expression.Operation == ast.OperationFailableCast {
+				// The failable cast is allowed
+			} else if !FailableCastCanSucceed(leftHandType, rightHandType) {
 
 				checker.report(
 					&TypeMismatchError{
@@ -172,6 +206,139 @@ func (checker *Checker) VisitCastingExpression(expression *ast.CastingExpression
 	}
 }
 
+// uncastValue returns the expression and the static type of the value of the given expression,
+// before it was widened by static casts, e.g. `x` and its type in `x as AnyStruct`.
+//
+func (checker *Checker) uncastValue(expression ast.Expression, ty Type) (ast.Expression, Type) {
+	for {
+		castingExpression, ok := expression.(*ast.CastingExpression)
+		if !ok || castingExpression.Operation != ast.OperationCast {
+			return expression, ty
+		}
+
+		valueType := checker.Elaboration.CastingStaticValueTypes[castingExpression]
+		if valueType == nil || valueType.IsInvalidType() {
+			return expression, ty
+		}
+
+		ty = valueType
+		expression = castingExpression.Expression
+	}
+}
+
+// isCreatedReference returns true if the given expression creates a reference directly,
+// see isReferenceCreation, or if it is a constant which was initialized with such an expression,
+// i.e. if the static type of the expression is the type the reference was created with.
+//
+func (checker *Checker) isCreatedReference(expression ast.Expression) bool {
+	if identifierExpression, ok := expression.(*ast.IdentifierExpression); ok {
+		variable := checker.valueActivations.Find(identifierExpression.Identifier.Identifier)
+		if variable == nil {
+			return false
+		}
+
+		_, ok := checker.createdReferenceConstants[variable]
+		return ok
+	}
+
+	return checker.isReferenceCreation(expression)
+}
+
+// isReferenceCreation returns true if the given expression creates a reference directly,
+// i.e. if it is a reference expression, or a (force-unwrapped) invocation
+// of a builtin borrow function, i.e. of a capability or of an account.
+//
+// Other functions named borrow, e.g. of user-defined composites,
+// might return any reference of the result type.
+//
+func (checker *Checker) isReferenceCreation(expression ast.Expression) bool {
+	if forceExpression, ok := expression.(*ast.ForceExpression); ok {
+		expression = forceExpression.Expression
+	}
+
+	switch expression := expression.(type) {
+	case *ast.ReferenceExpression:
+		return true
+
+	case *ast.InvocationExpression:
+		memberExpression, ok := expression.InvokedExpression.(*ast.MemberExpression)
+		if !ok {
+			return false
+		}
+
+		memberInfo, ok := checker.Elaboration.MemberExpressionMemberInfos[memberExpression]
+		if !ok || memberInfo.Member == nil {
+			return false
+		}
+
+		return isBuiltinBorrowFunction(memberInfo.Member)
+
+	default:
+		return false
+	}
+}
+
+// isBuiltinBorrowFunction returns true if the given member is
+// the borrow function of a capability or of an account.
+//
+func isBuiltinBorrowFunction(member *Member) bool {
+	switch member.ContainerType.(type) {
+	case *CapabilityType:
+		return member.Identifier.Identifier == "borrow"
+
+	default:
+		return member.ContainerType == AuthAccountType &&
+			member.Identifier.Identifier == AuthAccountBorrowField
+	}
+}
+
+// recordCreatedReferenceConstant records that the given variable holds a reference
+// which was created with the type of the variable, see isCreatedReference.
+//
+func (checker *Checker) recordCreatedReferenceConstant(
+	variable *Variable,
+	value ast.Expression,
+	valueType Type,
+) {
+	if variable == nil ||
+		!variable.IsConstant ||
+		valueType == nil ||
+		!variable.Type.Equal(valueType) ||
+		!checker.isReferenceCreation(value) {
+
+		return
+	}
+
+	checker.createdReferenceConstants[variable] = struct{}{}
+}
+
+// isRestrictedReferenceWidening returns true if the given value type
+// is a non-authorized reference to a restricted type, e.g. `&R{RI}`,
+// and the given target type is a reference to the unrestricted type, e.g. `&R`.
+//
+// Such a cast fails at run-time if the reference was created with the restricted type,
+// as restricted references cannot be widened.
+//
+func isRestrictedReferenceWidening(valueType, targetType Type) bool {
+	valueReferenceType, ok := valueType.(*ReferenceType)
+	if !ok || valueReferenceType.Authorized {
+		return false
+	}
+
+	restrictedType, ok := valueReferenceType.Type.(*RestrictedType)
+	if !ok {
+		return false
+	}
+
+	targetReferenceType, ok := targetType.(*ReferenceType)
+	if !ok {
+		return false
+	}
+
+	return restrictedType.Type.Equal(targetReferenceType.Type) &&
+		!IsSubType(valueType, targetType)
+}
+
 // FailableCastCanSucceed checks a failable (dynamic) cast, i.e. a cast that might succeed at run-time.
 // It returns true if the cast from subType to superType could potentially succeed at run-time,
 // and returns false if the cast will definitely always fail.
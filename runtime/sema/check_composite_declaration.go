@@ -19,6 +19,9 @@
 package sema
 
 import (
+	"regexp"
+	"strings"
+
 	"github.com/onflow/cadence/runtime/ast"
 	"github.com/onflow/cadence/runtime/common"
 	"github.com/onflow/cadence/runtime/errors"
@@ -519,6 +522,10 @@ func (checker *Checker) declareCompositeMembersAndValue(
 		initializers := declaration.Members.Initializers()
 		compositeType.ConstructorParameters = checker.initializerParameters(initializers)
 
+		if compositeType.Kind == common.CompositeKindEvent {
+			compositeType.IndexedFields = checker.eventIndexedFields(declaration, compositeType.ConstructorParameters)
+		}
+
 		// Declare nested declarations' members
 
 		for _, nestedInterfaceDeclaration := range declaration.Members.Interfaces() {
@@ -1411,17 +1418,21 @@ func (checker *Checker) defaultMembersAndOrigins(
 			)
 		}
 
-		members.Set(
-			identifier,
-			&Member{
-				ContainerType:   containerType,
-				Access:          field.Access,
-				Identifier:      field.Identifier,
-				DeclarationKind: declarationKind,
-				TypeAnnotation:  fieldTypeAnnotation,
-				VariableKind:    field.VariableKind,
-				DocString:       field.DocString,
-			})
+		member := &Member{
+			ContainerType:   containerType,
+			Access:          field.Access,
+			Identifier:      field.Identifier,
+			DeclarationKind: declarationKind,
+			TypeAnnotation:  fieldTypeAnnotation,
+			VariableKind:    field.VariableKind,
+			DocString:       field.DocString,
+		}
+
+		members.Set(identifier, member)
+
+		if containerKind == ContainerKindComposite {
+			checker.recordMemberDeclaration(member, field)
+		}
 
 		if checker.positionInfoEnabled && origins != nil {
 			origins[identifier] =
@@ -1452,7 +1463,24 @@ func (checker *Checker) defaultMembersAndOrigins(
 
 		identifier := function.Identifier.Identifier
 
-		functionType := checker.functionType(function.ParameterList, function.ReturnTypeAnnotation)
+		if containerKind == ContainerKindInterface &&
+			len(function.TypeParameters) > 0 {
+
+			checker.report(
+				&InvalidInterfaceFunctionTypeParametersError{
+					Range: ast.NewRangeFromPositioned(function.Identifier),
+				},
+			)
+		}
+
+		functionType := checker.functionDeclarationType(function)
+
+		// Record the function type of generic functions,
+		// so the function's body is checked using the same type parameters
+
+		if len(functionType.TypeParameters) > 0 {
+			checker.Elaboration.FunctionDeclarationFunctionTypes[function] = functionType
+		}
 
 		argumentLabels := function.ParameterList.EffectiveArgumentLabels()
 
@@ -1475,18 +1503,22 @@ func (checker *Checker) defaultMembersAndOrigins(
 			)
 		}
 
-		members.Set(
-			identifier,
-			&Member{
-				ContainerType:   containerType,
-				Access:          function.Access,
-				Identifier:      function.Identifier,
-				DeclarationKind: declarationKind,
-				TypeAnnotation:  fieldTypeAnnotation,
-				VariableKind:    ast.VariableKindConstant,
-				ArgumentLabels:  argumentLabels,
-				DocString:       function.DocString,
-			})
+		member := &Member{
+			ContainerType:   containerType,
+			Access:          function.Access,
+			Identifier:      function.Identifier,
+			DeclarationKind: declarationKind,
+			TypeAnnotation:  fieldTypeAnnotation,
+			VariableKind:    ast.VariableKindConstant,
+			ArgumentLabels:  argumentLabels,
+			DocString:       function.DocString,
+		}
+
+		members.Set(identifier, member)
+
+		if containerKind == ContainerKindComposite {
+			checker.recordMemberDeclaration(member, function)
+		}
 
 		if checker.positionInfoEnabled && origins != nil {
 			origins[identifier] =
@@ -2090,3 +2122,74 @@ func (checker *Checker) checkResourceUseAfterInvalidation(resource interface{},
 		},
 	)
 }
+
+var pragmaIndexedRegexp = regexp.MustCompile(`^\s+pragma\s+indexed\s+(.*)(?:\n|$)`)
+
+// parseDocstringPragmaIndexed parses the docstring and returns the field names of all pragma indexed declarations.
+//
+// A pragma indexed declaration has the form `pragma indexed <field-list>`,
+// where <field-list> is a comma-separated list of field names, e.g. `pragma indexed from, to`.
+//
+func parseDocstringPragmaIndexed(docString string) []string {
+	var fieldNames []string
+
+	for _, line := range strings.Split(docString, "\n") {
+		match := pragmaIndexedRegexp.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+
+		for _, fieldName := range strings.Split(match[1], ",") {
+			fieldName = strings.TrimSpace(fieldName)
+			if fieldName == "" {
+				continue
+			}
+			fieldNames = append(fieldNames, fieldName)
+		}
+	}
+
+	return fieldNames
+}
+
+// eventIndexedFields returns the names of the fields of the given event declaration
+// which are marked as indexed, using `pragma indexed` declarations in the docstring.
+//
+// Each name must be the identifier of a parameter of the event.
+// Duplicate names are ignored.
+//
+func (checker *Checker) eventIndexedFields(declaration *ast.CompositeDeclaration, parameters []*Parameter) []string {
+
+	var indexedFields []string
+
+	seen := map[string]struct{}{}
+
+	for _, name := range parseDocstringPragmaIndexed(declaration.DocString) {
+
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+
+		isParameter := false
+		for _, parameter := range parameters {
+			if parameter.Identifier == name {
+				isParameter = true
+				break
+			}
+		}
+
+		if !isParameter {
+			checker.report(
+				&InvalidIndexedEventFieldError{
+					Name:  name,
+					Range: ast.NewRangeFromPositioned(declaration.Identifier),
+				},
+			)
+			continue
+		}
+
+		indexedFields = append(indexedFields, name)
+	}
+
+	return indexedFields
+}
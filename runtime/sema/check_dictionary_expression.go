@@ -96,6 +96,10 @@ func (checker *Checker) VisitDictionaryExpression(expression *ast.DictionaryExpr
 		ValueType: valueType,
 	}
 
+	// The dictionary is created with the dictionary type at run-time
+
+	checker.checkRuntimeType(dictionaryType, expression)
+
 	checker.Elaboration.DictionaryExpressionEntryTypes[expression] = entryTypes
 	checker.Elaboration.DictionaryExpressionType[expression] = dictionaryType
 
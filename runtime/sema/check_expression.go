@@ -40,6 +40,10 @@ func (checker *Checker) VisitIdentifierExpression(expression *ast.IdentifierExpr
 
 	checker.checkSelfVariableUseInInitializer(variable, identifier.Pos)
 
+	if checker.referenceEscapeChecksEnabled {
+		checker.recordCapturedReferenceVariable(variable)
+	}
+
 	if checker.inInvocation {
 		checker.Elaboration.IdentifierInInvocationTypes[expression] = valueType
 	}
@@ -138,6 +142,12 @@ func (checker *Checker) checkResourceVariableCapturingInFunction(variable *Varia
 func (checker *Checker) VisitExpressionStatement(statement *ast.ExpressionStatement) ast.Repr {
 	expression := statement.Expression
 
+	previousDiscardedExpression := checker.discardedExpression
+	checker.discardedExpression = expression
+	defer func() {
+		checker.discardedExpression = previousDiscardedExpression
+	}()
+
 	ty := checker.VisitExpression(expression, nil)
 
 	if ty.IsResourceType() {
@@ -242,6 +252,8 @@ func (checker *Checker) visitIndexExpression(
 	targetExpression := indexExpression.TargetExpression
 	targetType := checker.VisitExpression(targetExpression, nil)
 
+	checker.Elaboration.IndexExpressionIndexedTypes[indexExpression] = targetType
+
 	// NOTE: check indexed type first for UX reasons
 
 	// check indexed expression's type is indexable
@@ -285,6 +297,10 @@ func (checker *Checker) visitIndexExpression(
 
 	checker.checkUnusedExpressionResourceLoss(elementType, targetExpression)
 
+	if checker.referencedIndexExpression == indexExpression {
+		checker.checkConstantSizedArrayIndexBounds(indexedType, indexExpression.IndexingExpression)
+	}
+
 	return elementType
 }
 
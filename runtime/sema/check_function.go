@@ -65,7 +65,7 @@ func (checker *Checker) visitFunctionDeclaration(
 
 	functionType := checker.Elaboration.FunctionDeclarationFunctionTypes[declaration]
 	if functionType == nil {
-		functionType = checker.functionType(declaration.ParameterList, declaration.ReturnTypeAnnotation)
+		functionType = checker.functionDeclarationType(declaration)
 
 		if options.declareFunction {
 			checker.declareFunctionDeclaration(declaration, functionType)
@@ -74,6 +74,19 @@ func (checker *Checker) visitFunctionDeclaration(
 
 	checker.Elaboration.FunctionDeclarationFunctionTypes[declaration] = functionType
 
+	// Declare the type parameters of the function (if any),
+	// so that the function's body can refer to them
+
+	if len(functionType.TypeParameters) > 0 {
+		checker.typeActivations.Enter()
+		defer checker.typeActivations.Leave(declaration.EndPosition)
+
+		checker.declareFunctionTypeParameters(
+			declaration.TypeParameters,
+			functionType.TypeParameters,
+		)
+	}
+
 	checker.checkFunction(
 		declaration.ParameterList,
 		declaration.ReturnTypeAnnotation,
@@ -87,6 +100,74 @@ func (checker *Checker) visitFunctionDeclaration(
 	return nil
 }
 
+// functionDeclarationType returns the function type for the given function declaration.
+//
+// The type parameters of the function declaration (if any) are declared
+// while the parameter types and the return type are converted,
+// so they may refer to the type parameters.
+//
+func (checker *Checker) functionDeclarationType(declaration *ast.FunctionDeclaration) *FunctionType {
+
+	if len(declaration.TypeParameters) == 0 {
+		return checker.functionType(declaration.ParameterList, declaration.ReturnTypeAnnotation)
+	}
+
+	checker.typeActivations.Enter()
+	defer checker.typeActivations.Leave(declaration.EndPosition)
+
+	typeParameters := make([]*TypeParameter, len(declaration.TypeParameters))
+
+	for i, typeParameter := range declaration.TypeParameters {
+
+		// Type parameters without an explicit type bound are bound to `AnyStruct`,
+		// as the function's body cannot know if the type argument is a resource or not
+
+		var typeBound Type = AnyStructType
+		if typeParameter.TypeBound != nil {
+			typeBound = checker.ConvertType(typeParameter.TypeBound)
+		}
+
+		typeParameters[i] = &TypeParameter{
+			Name:      typeParameter.Identifier.Identifier,
+			TypeBound: typeBound,
+		}
+
+		_, err := checker.typeActivations.DeclareType(typeDeclaration{
+			identifier:      typeParameter.Identifier,
+			ty:              &GenericType{TypeParameter: typeParameters[i]},
+			declarationKind: common.DeclarationKindTypeParameter,
+			access:          ast.AccessPublic,
+		})
+		checker.report(err)
+	}
+
+	functionType := checker.functionType(declaration.ParameterList, declaration.ReturnTypeAnnotation)
+	functionType.TypeParameters = typeParameters
+	functionType.isDeclaredGeneric = true
+
+	return functionType
+}
+
+// declareFunctionTypeParameters declares the given type parameters as generic types
+// in the current type activation.
+//
+// NOTE: Errors, like redeclarations, were already reported
+// when the function type was determined, see `functionDeclarationType`
+//
+func (checker *Checker) declareFunctionTypeParameters(
+	declaredTypeParameters []*ast.TypeParameter,
+	typeParameters []*TypeParameter,
+) {
+	for i, typeParameter := range typeParameters {
+		_, _ = checker.typeActivations.DeclareType(typeDeclaration{
+			identifier:      declaredTypeParameters[i].Identifier,
+			ty:              &GenericType{TypeParameter: typeParameter},
+			declarationKind: common.DeclarationKindTypeParameter,
+			access:          ast.AccessPublic,
+		})
+	}
+}
+
 func (checker *Checker) declareFunctionDeclaration(
 	declaration *ast.FunctionDeclaration,
 	functionType *FunctionType,
@@ -420,6 +501,10 @@ func (checker *Checker) VisitFunctionExpression(expression *ast.FunctionExpressi
 
 	checker.Elaboration.FunctionExpressionFunctionType[expression] = functionType
 
+	if checker.referenceEscapeChecksEnabled {
+		defer checker.enterFunctionExpression(expression)()
+	}
+
 	checker.checkFunction(
 		expression.ParameterList,
 		expression.ReturnTypeAnnotation,
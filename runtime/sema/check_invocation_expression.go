@@ -39,6 +39,10 @@ func (checker *Checker) VisitInvocationExpression(invocationExpression *ast.Invo
 		return InvalidType
 	}
 
+	if checker.referenceEscapeChecksEnabled {
+		checker.checkInsertedReferenceEscape(invocationExpression)
+	}
+
 	return ty
 }
 
@@ -179,6 +183,14 @@ func (checker *Checker) checkInvocationExpression(invocationExpression *ast.Invo
 
 	checker.checkMemberInvocationResourceInvalidation(invokedExpression)
 
+	if checker.rejectStoredReferences {
+		checker.checkStoredReferences(invocationExpression, argumentTypes)
+	}
+
+	if checker.unborrowedLinkHintsEnabled {
+		checker.recordCapabilityLinkOrRetrieval(invocationExpression)
+	}
+
 	// Update the return info for invocations that do not return (i.e. have a `Never` return type)
 
 	if returnType == NeverType {
@@ -192,6 +204,106 @@ func (checker *Checker) checkInvocationExpression(invocationExpression *ast.Invo
 	return returnType
 }
 
+// checkStoredReferences reports an error if the invocation saves a value to storage,
+// and the value statically contains a reference.
+//
+// The value might be cast to a less specific type, e.g. `AnyStruct`,
+// so the static type of the value before any casts is checked.
+//
+func (checker *Checker) checkStoredReferences(
+	invocationExpression *ast.InvocationExpression,
+	argumentTypes []Type,
+) {
+	memberExpression, ok := invocationExpression.InvokedExpression.(*ast.MemberExpression)
+	if !ok {
+		return
+	}
+
+	memberInfo, ok := checker.Elaboration.MemberExpressionMemberInfos[memberExpression]
+	if !ok || memberInfo.Member == nil {
+		return
+	}
+
+	member := memberInfo.Member
+	if member.ContainerType != AuthAccountType {
+		return
+	}
+
+	switch member.Identifier.Identifier {
+	case AuthAccountSaveField,
+		AuthAccountEnsureSavedField,
+		AuthAccountSaveOrReplaceField:
+
+		break
+	default:
+		return
+	}
+
+	if len(argumentTypes) < 1 || len(invocationExpression.Arguments) < 1 {
+		return
+	}
+
+	valueExpression := invocationExpression.Arguments[0].Expression
+	valueType := argumentTypes[0]
+
+	for {
+		castingExpression, ok := valueExpression.(*ast.CastingExpression)
+		if !ok {
+			break
+		}
+
+		valueExpression = castingExpression.Expression
+		valueType = checker.Elaboration.CastingStaticValueTypes[castingExpression]
+	}
+
+	if valueType == nil || !containsReferenceType(valueType, map[Type]struct{}{}) {
+		return
+	}
+
+	checker.report(
+		&StoredReferenceError{
+			Type:  valueType,
+			Range: ast.NewRangeFromPositioned(valueExpression),
+		},
+	)
+}
+
+// containsReferenceType returns true if the given type is a reference type,
+// or if values of the type may contain a reference, e.g. an array of references,
+// or a composite with a field of a reference type.
+//
+func containsReferenceType(ty Type, seenTypes map[Type]struct{}) bool {
+	if _, ok := seenTypes[ty]; ok {
+		return false
+	}
+	seenTypes[ty] = struct{}{}
+
+	switch ty := ty.(type) {
+	case *ReferenceType:
+		return true
+
+	case *OptionalType:
+		return containsReferenceType(ty.Type, seenTypes)
+
+	case ArrayType:
+		return containsReferenceType(ty.ElementType(false), seenTypes)
+
+	case *DictionaryType:
+		return containsReferenceType(ty.KeyType, seenTypes) ||
+			containsReferenceType(ty.ValueType, seenTypes)
+
+	case *CompositeType:
+		for _, fieldName := range ty.Fields {
+			member, ok := ty.Members.Get(fieldName)
+			if ok && containsReferenceType(member.TypeAnnotation.Type, seenTypes) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
 func (checker *Checker) checkMemberInvocationResourceInvalidation(invokedExpression ast.Expression) {
 	// If the invocation is on a resource, i.e., a member expression where the accessed expression
 	// is an identifier which refers to a resource, then the resource is temporarily "moved into"
@@ -477,6 +589,17 @@ func (checker *Checker) checkInvocation(
 		invocationExpression,
 	)
 
+	// The type arguments of declared generic functions are not needed at run-time,
+	// but those of other generic functions, e.g. `Type<T>()`, might be
+
+	if !functionType.isDeclaredGeneric {
+		checker.checkRuntimeTypeArguments(
+			functionType,
+			typeArguments,
+			invocationExpression,
+		)
+	}
+
 	// Save types in the elaboration
 
 	checker.Elaboration.InvocationExpressionTypeArguments[invocationExpression] = typeArguments
@@ -515,6 +638,33 @@ func (checker *Checker) checkTypeParameterInference(
 	}
 }
 
+// checkRuntimeTypeArguments checks that the type arguments of the invocation
+// of the given generic function type can be used at run-time.
+//
+func (checker *Checker) checkRuntimeTypeArguments(
+	functionType *FunctionType,
+	typeArguments *TypeParameterTypeOrderedMap,
+	invocationExpression *ast.InvocationExpression,
+) {
+	for i, typeParameter := range functionType.TypeParameters {
+
+		ty, ok := typeArguments.Get(typeParameter)
+		if !ok || ty == nil {
+			continue
+		}
+
+		// Report the error for the explicit type argument, if any,
+		// and for the invocation otherwise
+
+		var pos ast.HasPosition = invocationExpression
+		if i < len(invocationExpression.TypeArguments) {
+			pos = invocationExpression.TypeArguments[i]
+		}
+
+		checker.checkRuntimeType(ty, pos)
+	}
+}
+
 func (checker *Checker) checkInvocationRequiredArgument(
 	arguments ast.Arguments,
 	argumentIndex int,
@@ -163,6 +163,7 @@ func (checker *Checker) visitMember(expression *ast.MemberExpression) (accessedT
 		}
 		targetRange := ast.NewRangeFromPositioned(expression.Expression)
 		member = resolver.Resolve(identifier, targetRange, checker.report)
+		checker.recordMemberReference(member)
 	}
 
 	// Get the member from the accessed value based
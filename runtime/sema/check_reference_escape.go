@@ -0,0 +1,433 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2020 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sema
+
+import (
+	"github.com/onflow/cadence/runtime/ast"
+	"github.com/onflow/cadence/runtime/common"
+)
+
+// Reference escape checks
+//
+// A reference to a local variable of a function, e.g. `&x as &T`, must not escape the function,
+// i.e. it must not be returned, or assigned to a target which is not a local variable of the function,
+// e.g. a field: The reference would outlive the referenced value.
+//
+// A reference also escapes if a value which holds it escapes, for example an array or dictionary literal,
+// an optional, the result of an invocation which is passed the reference, e.g. a composite constructor,
+// or a closure which captures a local variable holding the reference.
+// A reference also escapes if it is appended or inserted into an array or dictionary which is not local.
+//
+// The checks are conservative: Only references which are created from a local variable,
+// or local variables which hold such a reference, are considered.
+// References to values in storage or in fields, e.g. `&self.values[0] as &T`, may escape.
+// References to local variables of composite, array, or dictionary types may also escape,
+// as these values are heap-allocated, i.e. they are not scoped to the function.
+
+// enclosingFunctionExpression is a function expression which is currently checked.
+//
+type enclosingFunctionExpression struct {
+	expression           *ast.FunctionExpression
+	valueActivationDepth int
+}
+
+// isFunctionLocalVariable returns true if the given variable is a constant, variable, or parameter,
+// which is declared in the current function.
+//
+func (checker *Checker) isFunctionLocalVariable(variable *Variable) bool {
+	if variable == nil {
+		return false
+	}
+
+	switch variable.DeclarationKind {
+	case common.DeclarationKindConstant,
+		common.DeclarationKindVariable,
+		common.DeclarationKindParameter:
+
+		break
+
+	default:
+		return false
+	}
+
+	if !checker.functionActivations.IsLocal() {
+		return false
+	}
+
+	functionActivation := checker.functionActivations.Current()
+	return variable.ActivationDepth > functionActivation.ValueActivationDepth
+}
+
+// localReferencedVariable returns the local variable of the current function
+// which is referenced by the given expression, if any,
+// i.e. if the expression is a reference expression to a local variable,
+// a local variable which holds such a reference,
+// or an expression which results in a value that may hold such a reference.
+//
+func (checker *Checker) localReferencedVariable(expression ast.Expression) *Variable {
+	switch expression := expression.(type) {
+	case *ast.ReferenceExpression:
+		identifierExpression, ok := expression.Expression.(*ast.IdentifierExpression)
+		if !ok {
+			return nil
+		}
+
+		variable := checker.valueActivations.Find(identifierExpression.Identifier.Identifier)
+		if !checker.isFunctionLocalVariable(variable) ||
+			isHeapAllocatedType(variable.Type) {
+
+			return nil
+		}
+
+		return variable
+
+	case *ast.IdentifierExpression:
+		variable := checker.valueActivations.Find(expression.Identifier.Identifier)
+		if variable == nil {
+			return nil
+		}
+
+		return checker.functionLocalVariable(checker.localReferenceVariables[variable])
+
+	case *ast.FunctionExpression:
+		return checker.functionLocalVariable(checker.referenceCapturingFunctions[expression])
+
+	case *ast.CastingExpression:
+		return checker.localReferencedVariable(expression.Expression)
+
+	case *ast.ForceExpression:
+		return checker.localReferencedVariable(expression.Expression)
+
+	case *ast.ConditionalExpression:
+		return checker.firstLocalReferencedVariable(expression.Then, expression.Else)
+
+	case *ast.BinaryExpression:
+		if expression.Operation != ast.OperationNilCoalesce {
+			return nil
+		}
+
+		return checker.firstLocalReferencedVariable(expression.Left, expression.Right)
+
+	case *ast.ArrayExpression:
+		return checker.firstLocalReferencedVariable(expression.Values...)
+
+	case *ast.DictionaryExpression:
+		for _, entry := range expression.Entries {
+			referencedVariable := checker.firstLocalReferencedVariable(entry.Key, entry.Value)
+			if referencedVariable != nil {
+				return referencedVariable
+			}
+		}
+
+		return nil
+
+	case *ast.InvocationExpression:
+		returnType := checker.Elaboration.InvocationExpressionReturnTypes[expression]
+		if !mayHoldReference(returnType) {
+			return nil
+		}
+
+		for _, argument := range expression.Arguments {
+			referencedVariable := checker.localReferencedVariable(argument.Expression)
+			if referencedVariable != nil {
+				return referencedVariable
+			}
+		}
+
+		return nil
+	}
+
+	return nil
+}
+
+// firstLocalReferencedVariable returns the local variable of the current function
+// which is referenced by the first of the given expressions which references one, if any.
+//
+func (checker *Checker) firstLocalReferencedVariable(expressions ...ast.Expression) *Variable {
+	for _, expression := range expressions {
+		referencedVariable := checker.localReferencedVariable(expression)
+		if referencedVariable != nil {
+			return referencedVariable
+		}
+	}
+
+	return nil
+}
+
+// functionLocalVariable returns the given variable if it is local to the current function,
+// and nil otherwise, e.g. if it is a local variable of an enclosing function.
+//
+func (checker *Checker) functionLocalVariable(variable *Variable) *Variable {
+	if !checker.isFunctionLocalVariable(variable) {
+		return nil
+	}
+
+	return variable
+}
+
+// isHeapAllocatedType returns true if values of the given type are heap-allocated,
+// i.e. if references to them may outlive the variable they are stored in.
+//
+func isHeapAllocatedType(ty Type) bool {
+	switch ty.(type) {
+	case *CompositeType,
+		*RestrictedType,
+		*VariableSizedType,
+		*ConstantSizedType,
+		*DictionaryType:
+
+		return true
+
+	default:
+		return false
+	}
+}
+
+// mayHoldReference returns true if values of the given type may hold a reference,
+// e.g. if the type is a reference type, a composite type, or a container type.
+//
+func mayHoldReference(ty Type) bool {
+	switch ty := ty.(type) {
+	case nil,
+		*NumericType,
+		*FixedPointNumericType,
+		*AddressType:
+
+		return false
+
+	case *OptionalType:
+		return mayHoldReference(ty.Type)
+
+	case *SimpleType:
+		switch ty {
+		case AnyType, AnyStructType, AnyResourceType:
+			return true
+
+		default:
+			return false
+		}
+
+	default:
+		return true
+	}
+}
+
+// recordLocalReferenceVariable records that the given variable holds a reference to a local variable,
+// if the given value expression is a reference to a local variable.
+//
+func (checker *Checker) recordLocalReferenceVariable(variable *Variable, value ast.Expression) {
+	if !checker.isFunctionLocalVariable(variable) {
+		return
+	}
+
+	referencedVariable := checker.localReferencedVariable(value)
+	if referencedVariable == nil {
+		return
+	}
+
+	checker.localReferenceVariables[variable] = referencedVariable
+}
+
+// enterFunctionExpression records that the given function expression is checked,
+// so that the references it captures can be recorded, see recordCapturedReferenceVariable.
+//
+// The returned function must be called when the function expression was checked.
+//
+func (checker *Checker) enterFunctionExpression(expression *ast.FunctionExpression) func() {
+	checker.enclosingFunctionExpressions = append(
+		checker.enclosingFunctionExpressions,
+		enclosingFunctionExpression{
+			expression:           expression,
+			valueActivationDepth: checker.valueActivations.Depth(),
+		},
+	)
+
+	return func() {
+		lastIndex := len(checker.enclosingFunctionExpressions) - 1
+		checker.enclosingFunctionExpressions = checker.enclosingFunctionExpressions[:lastIndex]
+	}
+}
+
+// recordCapturedReferenceVariable records that the enclosing function expressions
+// which capture the given variable hold a reference to a local variable,
+// if the given variable holds such a reference.
+//
+func (checker *Checker) recordCapturedReferenceVariable(variable *Variable) {
+	referencedVariable := checker.localReferenceVariables[variable]
+	if referencedVariable == nil {
+		return
+	}
+
+	for i := len(checker.enclosingFunctionExpressions) - 1; i >= 0; i-- {
+		enclosingFunction := checker.enclosingFunctionExpressions[i]
+
+		// The variable is declared in the function expression, so it is not captured
+		// by it or any of the function expressions enclosing it
+
+		if variable.ActivationDepth > enclosingFunction.valueActivationDepth {
+			return
+		}
+
+		checker.referenceCapturingFunctions[enclosingFunction.expression] = referencedVariable
+	}
+}
+
+// checkReturnedReferenceEscape reports an error if the returned expression
+// is a reference to a local variable.
+//
+func (checker *Checker) checkReturnedReferenceEscape(returnedExpression ast.Expression) {
+	referencedVariable := checker.localReferencedVariable(returnedExpression)
+	if referencedVariable == nil {
+		return
+	}
+
+	checker.report(
+		&ReferenceEscapesScopeError{
+			Name:  referencedVariable.Identifier,
+			Range: ast.NewRangeFromPositioned(returnedExpression),
+		},
+	)
+}
+
+// checkAssignedReferenceEscape reports an error if the assigned value
+// is a reference to a local variable, and the target is not a local variable,
+// or a member or element of a local variable.
+//
+// If the target is a local variable, it is recorded if it holds such a reference:
+// A previously recorded reference is cleared when the variable is assigned another value.
+//
+func (checker *Checker) checkAssignedReferenceEscape(target, value ast.Expression) {
+	referencedVariable := checker.localReferencedVariable(value)
+
+	if identifierExpression, ok := target.(*ast.IdentifierExpression); ok {
+		variable := checker.valueActivations.Find(identifierExpression.Identifier.Identifier)
+		if checker.isFunctionLocalVariable(variable) {
+			if referencedVariable == nil {
+				delete(checker.localReferenceVariables, variable)
+			} else {
+				checker.localReferenceVariables[variable] = referencedVariable
+			}
+			return
+		}
+	}
+
+	if referencedVariable == nil {
+		return
+	}
+
+	checker.checkStoredReferenceEscape(target, value, referencedVariable)
+}
+
+// checkInsertedReferenceEscape reports an error if a reference to a local variable
+// is passed to a function which inserts it into an array or dictionary, e.g. `append`,
+// and the array or dictionary is not a local variable, or a member or element of a local variable.
+//
+func (checker *Checker) checkInsertedReferenceEscape(invocationExpression *ast.InvocationExpression) {
+	memberExpression, ok := invocationExpression.InvokedExpression.(*ast.MemberExpression)
+	if !ok {
+		return
+	}
+
+	memberInfo, ok := checker.Elaboration.MemberExpressionMemberInfos[memberExpression]
+	if !ok || !isContainerInsertionFunction(memberInfo.Member) {
+		return
+	}
+
+	for _, argument := range invocationExpression.Arguments {
+		referencedVariable := checker.localReferencedVariable(argument.Expression)
+		if referencedVariable == nil {
+			continue
+		}
+
+		checker.checkStoredReferenceEscape(
+			memberExpression.Expression,
+			argument.Expression,
+			referencedVariable,
+		)
+	}
+}
+
+// isContainerInsertionFunction returns true if the given member is
+// a function of an array or dictionary which inserts the given arguments into it.
+//
+func isContainerInsertionFunction(member *Member) bool {
+	if member == nil {
+		return false
+	}
+
+	switch member.ContainerType.(type) {
+	case *VariableSizedType:
+		switch member.Identifier.Identifier {
+		case "append", "appendAll", "insert":
+			return true
+		}
+
+	case *DictionaryType:
+		return member.Identifier.Identifier == "insert"
+	}
+
+	return false
+}
+
+// checkStoredReferenceEscape reports an error if the given value, which holds a reference
+// to the given local variable, is stored in the given target,
+// and the target is not a member or element of a local variable.
+//
+// If the target is a member or element of a local variable,
+// it is recorded that the local variable holds the reference.
+//
+func (checker *Checker) checkStoredReferenceEscape(
+	target ast.Expression,
+	value ast.Expression,
+	referencedVariable *Variable,
+) {
+	rootVariable := checker.assignmentTargetRootVariable(target)
+	if checker.isFunctionLocalVariable(rootVariable) {
+		checker.localReferenceVariables[rootVariable] = referencedVariable
+		return
+	}
+
+	checker.report(
+		&ReferenceEscapesScopeError{
+			Name:  referencedVariable.Identifier,
+			Range: ast.NewRangeFromPositioned(value),
+		},
+	)
+}
+
+// assignmentTargetRootVariable returns the variable which contains the given assignment target,
+// e.g. `a` for `a.b[0]`, if any.
+//
+func (checker *Checker) assignmentTargetRootVariable(target ast.Expression) *Variable {
+	for {
+		switch expression := target.(type) {
+		case *ast.MemberExpression:
+			target = expression.Expression
+
+		case *ast.IndexExpression:
+			target = expression.TargetExpression
+
+		case *ast.IdentifierExpression:
+			return checker.valueActivations.Find(expression.Identifier.Identifier)
+
+		default:
+			return nil
+		}
+	}
+}
@@ -19,6 +19,10 @@
 package sema
 
 import (
+	"fmt"
+	"math/big"
+	"strings"
+
 	"github.com/onflow/cadence/runtime/ast"
 )
 
@@ -32,6 +36,25 @@ func (checker *Checker) VisitReferenceExpression(referenceExpression *ast.Refere
 	resultType := checker.ConvertType(referenceExpression.Type)
 	checker.checkInvalidInterfaceAsType(resultType, referenceExpression.Type)
 
+	referencedExpression := referenceExpression.Expression
+
+	// If the referenced expression is optional-chained, e.g. `&s?.field as &T?`,
+	// the result type may be an optional reference type:
+	// The result is nil if the optional chain short-circuits
+
+	isOptionalReference := false
+
+	if memberExpression, ok := referencedExpression.(*ast.MemberExpression); ok &&
+		memberExpression.Optional {
+
+		if optionalResultType, ok := resultType.(*OptionalType); ok {
+			if _, ok := optionalResultType.Type.(*ReferenceType); ok {
+				isOptionalReference = true
+				resultType = optionalResultType.Type
+			}
+		}
+	}
+
 	var referenceType *ReferenceType
 	var targetType, referencedType Type
 
@@ -47,15 +70,25 @@ func (checker *Checker) VisitReferenceExpression(referenceExpression *ast.Refere
 			)
 		} else {
 			targetType = referenceType.Type
+
+			// The reference is created with the reference type at run-time
+
+			checker.checkRuntimeType(referenceType, referenceExpression.Type)
 		}
 	}
 
-	// Type-check the referenced expression
-
-	referencedExpression := referenceExpression.Expression
+	// Type-check the referenced expression.
+	//
+	// The referenced expression is checked even if the result type is invalid,
+	// so that errors in the referenced expression are also reported
 
 	// If the referenced expression is an index expression, it might be into storage
 
+	// indexedType is the type of the referenced index expression, if any,
+	// before it is unwrapped
+
+	var indexedType Type
+
 	indexExpression, isIndexExpression := referencedExpression.(*ast.IndexExpression)
 	if isIndexExpression {
 		// The referenced expression will evaluate to an optional type if it is indexing:
@@ -65,7 +98,13 @@ func (checker *Checker) VisitReferenceExpression(referenceExpression *ast.Refere
 
 		expectedType := wrapWithOptionalIfNotNil(targetType)
 
-		_, referencedType = checker.visitExpression(indexExpression, expectedType)
+		previousReferencedIndexExpression := checker.referencedIndexExpression
+		checker.referencedIndexExpression = indexExpression
+
+		_, indexedType = checker.visitExpression(indexExpression, expectedType)
+		referencedType = indexedType
+
+		checker.referencedIndexExpression = previousReferencedIndexExpression
 
 		// Unwrap the optional one level, but not infinitely
 
@@ -73,14 +112,68 @@ func (checker *Checker) VisitReferenceExpression(referenceExpression *ast.Refere
 			referencedType = optionalReferencedType.Type
 		}
 
+	} else if isOptionalReference {
+		// The optional-chained expression evaluates to an optional.
+		//
+		// Hence expect an optional, and unwrap it one level
+
+		expectedType := wrapWithOptionalIfNotNil(targetType)
+
+		_, referencedType = checker.visitExpression(referencedExpression, expectedType)
+
+		if optionalReferencedType, ok := referencedType.(*OptionalType); ok {
+			referencedType = optionalReferencedType.Type
+		}
+
 	} else {
 		// If the referenced expression is not an index expression, check it normally
 		_, referencedType = checker.visitExpression(referencedExpression, targetType)
 	}
 
 	if _, ok := referencedType.(*OptionalType); ok {
+
+		// If the referenced expression is an index expression,
+		// e.g. a dictionary read, the fix is usually to force-unwrap the result.
+		// For other expressions force-unwrapping is not obviously correct.
+		//
+		// A force-unwrapped index expression is not unwrapped implicitly,
+		// so all levels of optionals must be force-unwrapped,
+		// e.g. twice for a dictionary with an optional value type.
+		// Only suggest it if the unwrapped value can actually be referenced as the target type
+
+		var suggestion string
+		if isIndexExpression {
+			unwrappedType := UnwrapOptionalType(indexedType)
+			if targetType == nil || IsSubType(unwrappedType, targetType) {
+				suggestion = fmt.Sprintf(
+					"&%s%s as %s",
+					indexExpression,
+					strings.Repeat("!", optionalNestingDepth(indexedType)),
+					referenceExpression.Type,
+				)
+			}
+		}
+
 		checker.report(
 			&OptionalTypeReferenceError{
+				ActualType: referencedType,
+				Suggestion: suggestion,
+				Range:      expressionRange(referencedExpression),
+			},
+		)
+	}
+
+	// Taking a reference to a value which is produced by the referenced expression,
+	// e.g. a value returned from a function call, is allowed for non-resource values:
+	// The reference refers to the ephemeral value.
+	//
+	// However, an ephemeral resource would be lost, as it is not stored anywhere
+
+	if referencedType.IsResourceType() &&
+		IsEphemeralValueExpression(referencedExpression) {
+
+		checker.report(
+			&EphemeralResourceReferenceError{
 				ActualType: referencedType,
 				Range:      expressionRange(referencedExpression),
 			},
@@ -91,7 +184,156 @@ func (checker *Checker) VisitReferenceExpression(referenceExpression *ast.Refere
 		return InvalidType
 	}
 
+	// An authorized reference must not be created to a value
+	// which is only accessible through an unauthorized reference,
+	// as that would allow downcasting, which the unauthorized reference does not permit
+
+	if referenceType.Authorized {
+		accessedReferenceType := checker.accessingReferenceType(referencedExpression)
+		if accessedReferenceType != nil && !accessedReferenceType.Authorized {
+			checker.report(
+				&UnauthorizedReferenceEscalationError{
+					AccessedType: accessedReferenceType,
+					Range:        expressionRange(referencedExpression),
+				},
+			)
+		}
+	}
+
 	checker.Elaboration.ReferenceExpressionBorrowTypes[referenceExpression] = referenceType
 
+	if isOptionalReference {
+		checker.Elaboration.OptionalReferenceExpressions[referenceExpression] = struct{}{}
+	}
+
+	// Creating a reference has no side effects,
+	// so a reference which is immediately discarded is likely a mistake
+
+	if checker.unusedReferenceHintsEnabled &&
+		checker.discardedExpression == referenceExpression {
+
+		checker.hint(
+			&UnusedReferenceHint{
+				Range: ast.NewRangeFromPositioned(referenceExpression),
+			},
+		)
+	}
+
+	if isOptionalReference {
+		return &OptionalType{
+			Type: referenceType,
+		}
+	}
+
 	return referenceType
 }
+
+// accessingReferenceType returns the type of the nearest reference
+// through which the value of the given expression is accessed,
+// e.g. the type of `ref` in `ref.field` or `ref.array[0]`,
+// or nil if the value is not accessed through a reference.
+//
+func (checker *Checker) accessingReferenceType(expression ast.Expression) *ReferenceType {
+	for {
+		var accessedType Type
+
+		switch typedExpression := expression.(type) {
+		case *ast.MemberExpression:
+			accessedType = checker.Elaboration.MemberExpressionMemberInfos[typedExpression].AccessedType
+			expression = typedExpression.Expression
+
+		case *ast.IndexExpression:
+			accessedType = checker.Elaboration.IndexExpressionIndexedTypes[typedExpression]
+			expression = typedExpression.TargetExpression
+
+		default:
+			return nil
+		}
+
+		// The access might be optional chaining
+
+		if optionalType, ok := accessedType.(*OptionalType); ok {
+			accessedType = optionalType.Type
+		}
+
+		if referenceType, ok := accessedType.(*ReferenceType); ok {
+			return referenceType
+		}
+	}
+}
+
+// checkConstantSizedArrayIndexBounds reports an error if the indexed type is a constant-sized array,
+// or a reference to one, and the indexing expression is an integer literal which is out of bounds.
+//
+// Other indices are checked at run-time.
+//
+func (checker *Checker) checkConstantSizedArrayIndexBounds(
+	indexedType ValueIndexableType,
+	indexingExpression ast.Expression,
+) {
+	if referenceType, ok := indexedType.(*ReferenceType); ok {
+		indexedType, ok = referenceType.Type.(ValueIndexableType)
+		if !ok {
+			return
+		}
+	}
+
+	constantSizedType, ok := indexedType.(*ConstantSizedType)
+	if !ok {
+		return
+	}
+
+	integerExpression, ok := indexingExpression.(*ast.IntegerExpression)
+	if !ok {
+		return
+	}
+
+	index := integerExpression.Value
+	if index.Sign() >= 0 && index.Cmp(big.NewInt(constantSizedType.Size)) < 0 {
+		return
+	}
+
+	checker.report(
+		&ArrayIndexOutOfBoundsError{
+			Index: index,
+			Size:  constantSizedType.Size,
+			Range: ast.NewRangeFromPositioned(indexingExpression),
+		},
+	)
+}
+
+// IsEphemeralValueExpression returns true if the given expression produces a new value,
+// which is not stored anywhere, e.g. a function call or a resource creation.
+//
+func IsEphemeralValueExpression(expression ast.Expression) bool {
+	switch expression := expression.(type) {
+	case *ast.InvocationExpression,
+		*ast.CreateExpression:
+
+		return true
+
+	case *ast.UnaryExpression:
+		return expression.Operation == ast.OperationMove
+
+	case *ast.CastingExpression:
+		return IsEphemeralValueExpression(expression.Expression)
+
+	default:
+		return false
+	}
+}
+
+// optionalNestingDepth returns the number of levels of optionals of the given type,
+// e.g. 2 for `Int??`, and 0 for `Int`.
+//
+func optionalNestingDepth(ty Type) int {
+	depth := 0
+	for {
+		optionalType, ok := ty.(*OptionalType)
+		if !ok {
+			return depth
+		}
+		ty = optionalType.Type
+		depth++
+	}
+}
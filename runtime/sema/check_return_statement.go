@@ -65,6 +65,10 @@ func (checker *Checker) VisitReturnStatement(statement *ast.ReturnStatement) ast
 	checker.checkVariableMove(statement.Expression)
 	checker.checkResourceMoveOperation(statement.Expression, valueType)
 
+	if checker.referenceEscapeChecksEnabled {
+		checker.checkReturnedReferenceEscape(statement.Expression)
+	}
+
 	return nil
 }
 
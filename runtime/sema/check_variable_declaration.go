@@ -188,6 +188,12 @@ func (checker *Checker) visitVariableDeclaration(declaration *ast.VariableDeclar
 	})
 	checker.report(err)
 
+	if checker.referenceEscapeChecksEnabled {
+		checker.recordLocalReferenceVariable(variable, declaration.Value)
+	}
+
+	checker.recordCreatedReferenceConstant(variable, declaration.Value, valueType)
+
 	if checker.positionInfoEnabled {
 		checker.recordVariableDeclarationOccurrence(identifier, variable)
 		checker.recordVariableDeclarationRange(declaration, identifier, declarationType)
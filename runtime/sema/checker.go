@@ -115,7 +115,30 @@ type Checker struct {
 	checkHandler                       CheckHandlerFunc
 	expectedType                       Type
 	memberAccountAccessHandler         MemberAccountAccessHandlerFunc
-}
+	unusedReferenceHintsEnabled        bool
+	rejectStoredReferences             bool
+	referenceEscapeChecksEnabled       bool
+	localReferenceVariables            map[*Variable]*Variable
+	enclosingFunctionExpressions       []enclosingFunctionExpression
+	referenceCapturingFunctions        map[*ast.FunctionExpression]*Variable
+	createdReferenceConstants          map[*Variable]struct{}
+	discardedExpression                ast.Expression
+	referencedIndexExpression          *ast.IndexExpression
+	memberDeclarations                 map[*Member]ast.Declaration
+	declaredMembers                    []*Member
+	referencedMembers                  map[*Member]struct{}
+	unborrowedLinkHintsEnabled         bool
+	unborrowedPublicLinkHintsEnabled   bool
+	capabilityLinks                    []capabilityLink
+	obtainedCapabilityPaths            map[string]struct{}
+	obtainsDynamicCapabilityPaths      bool
+	maxTypeDepth                       int
+	typeDepth                          int
+}
+
+// DefaultMaxTypeDepth is the default maximum nesting depth of type expressions.
+//
+const DefaultMaxTypeDepth = 1000
 
 type Option func(*Checker) error
 
@@ -235,6 +258,81 @@ func WithPositionInfoEnabled(enabled bool) Option {
 	}
 }
 
+// WithUnusedReferenceHintsEnabled returns a checker option which enables/disables
+// if hints are reported for reference expressions whose result is discarded.
+//
+func WithUnusedReferenceHintsEnabled(enabled bool) Option {
+	return func(checker *Checker) error {
+		checker.unusedReferenceHintsEnabled = enabled
+		return nil
+	}
+}
+
+// WithRejectStoredReferences returns a checker option which enables/disables
+// if an error is reported when a value which statically contains a reference
+// is saved to storage.
+//
+// By default, such values are only rejected at run-time.
+//
+func WithRejectStoredReferences(enabled bool) Option {
+	return func(checker *Checker) error {
+		checker.rejectStoredReferences = enabled
+		return nil
+	}
+}
+
+// WithReferenceEscapeChecksEnabled returns a checker option which enables/disables
+// if an error is reported when a reference to a local variable escapes the function,
+// e.g. when it is returned, or assigned to a field.
+//
+// By default, the checks are enabled.
+//
+func WithReferenceEscapeChecksEnabled(enabled bool) Option {
+	return func(checker *Checker) error {
+		checker.referenceEscapeChecksEnabled = enabled
+		return nil
+	}
+}
+
+// WithUnborrowedLinkHintsEnabled returns a checker option which enables/disables
+// if hints are reported for private capability links which are never borrowed in the program.
+//
+// The capabilities might still be borrowed by other programs,
+// so the hints are only low-confidence.
+//
+func WithUnborrowedLinkHintsEnabled(enabled bool) Option {
+	return func(checker *Checker) error {
+		checker.unborrowedLinkHintsEnabled = enabled
+		return nil
+	}
+}
+
+// WithUnborrowedPublicLinkHintsEnabled returns a checker option which enables/disables
+// if unborrowed link hints are also reported for public capability links,
+// which are usually intended to be borrowed by other programs.
+//
+// The option only has an effect if unborrowed link hints are enabled.
+//
+func WithUnborrowedPublicLinkHintsEnabled(enabled bool) Option {
+	return func(checker *Checker) error {
+		checker.unborrowedPublicLinkHintsEnabled = enabled
+		return nil
+	}
+}
+
+// WithMaxTypeDepth returns a checker option which sets
+// the maximum nesting depth of type expressions, e.g. `[[[Int]]]` has a depth of 4.
+// A limit of zero means the nesting depth is unlimited.
+//
+// By default, the limit is DefaultMaxTypeDepth.
+//
+func WithMaxTypeDepth(limit int) Option {
+	return func(checker *Checker) error {
+		checker.maxTypeDepth = limit
+		return nil
+	}
+}
+
 func NewChecker(program *ast.Program, location common.Location, options ...Option) (*Checker, error) {
 
 	if location == nil {
@@ -250,14 +348,19 @@ func NewChecker(program *ast.Program, location common.Location, options ...Optio
 	)
 
 	checker := &Checker{
-		Program:             program,
-		Location:            location,
-		valueActivations:    valueActivations,
-		resources:           NewResources(),
-		typeActivations:     typeActivations,
-		functionActivations: functionActivations,
-		containerTypes:      map[Type]bool{},
-		Elaboration:         NewElaboration(),
+		Program:                      program,
+		Location:                     location,
+		valueActivations:             valueActivations,
+		resources:                    NewResources(),
+		typeActivations:              typeActivations,
+		functionActivations:          functionActivations,
+		containerTypes:               map[Type]bool{},
+		maxTypeDepth:                 DefaultMaxTypeDepth,
+		Elaboration:                  NewElaboration(),
+		referenceEscapeChecksEnabled: true,
+		localReferenceVariables:      map[*Variable]*Variable{},
+		referenceCapturingFunctions:  map[*ast.FunctionExpression]*Variable{},
+		createdReferenceConstants:    map[*Variable]struct{}{},
 	}
 
 	checker.beforeExtractor = NewBeforeExtractor(checker.report)
@@ -288,6 +391,7 @@ func (checker *Checker) SubChecker(program *ast.Program, location common.Locatio
 		WithCheckHandler(checker.checkHandler),
 		WithImportHandler(checker.importHandler),
 		WithLocationHandler(checker.locationHandler),
+		WithReferenceEscapeChecksEnabled(checker.referenceEscapeChecksEnabled),
 	)
 }
 
@@ -369,6 +473,10 @@ func (checker *Checker) Check() error {
 
 		checker.declareGlobalRanges()
 
+		if checker.unborrowedLinkHintsEnabled {
+			checker.reportUnborrowedLinks()
+		}
+
 		checker.Elaboration.setIsChecking(false)
 		checker.isChecked = true
 	}
@@ -550,7 +658,7 @@ func (checker *Checker) checkTopLevelDeclarationValidity(declarations []ast.Decl
 }
 
 func (checker *Checker) declareGlobalFunctionDeclaration(declaration *ast.FunctionDeclaration) {
-	functionType := checker.functionType(declaration.ParameterList, declaration.ReturnTypeAnnotation)
+	functionType := checker.functionDeclarationType(declaration)
 	checker.Elaboration.FunctionDeclarationFunctionTypes[declaration] = functionType
 	checker.declareFunctionDeclaration(declaration, functionType)
 }
@@ -887,6 +995,31 @@ func (checker *Checker) findAndCheckValueVariable(identifierExpression *ast.Iden
 
 // ConvertType converts an AST type representation to a sema type
 func (checker *Checker) ConvertType(t ast.Type) Type {
+
+	// The AST might contain "holes" if parsing failed
+
+	if t == nil {
+		return InvalidType
+	}
+
+	// Limit the nesting depth of the type,
+	// to prevent deeply nested types from exhausting the stack
+
+	checker.typeDepth++
+	defer func() {
+		checker.typeDepth--
+	}()
+
+	if checker.maxTypeDepth > 0 && checker.typeDepth > checker.maxTypeDepth {
+		checker.report(
+			&TypeDepthLimitError{
+				Limit: checker.maxTypeDepth,
+				Range: ast.NewRangeFromPositioned(t),
+			},
+		)
+		return InvalidType
+	}
+
 	switch t := t.(type) {
 	case *ast.NominalType:
 		return checker.convertNominalType(t)
@@ -914,10 +1047,6 @@ func (checker *Checker) ConvertType(t ast.Type) Type {
 
 	case *ast.InstantiationType:
 		return checker.convertInstantiationType(t)
-
-	case nil:
-		// The AST might contain "holes" if parsing failed
-		return InvalidType
 	}
 
 	panic(&astTypeConversionError{invalidASTType: t})
@@ -2197,6 +2326,22 @@ func (checker *Checker) checkTypeAnnotation(typeAnnotation *TypeAnnotation, pos
 	checker.checkInvalidInterfaceAsType(typeAnnotation.Type, pos)
 }
 
+// checkRuntimeType reports an error if the given type is needed at run-time,
+// but it contains a generic type, as type arguments are not available at run-time.
+//
+func (checker *Checker) checkRuntimeType(ty Type, pos ast.HasPosition) {
+	if !containsGenericType(ty) {
+		return
+	}
+
+	checker.report(
+		&GenericTypeRuntimeTypeError{
+			Type:  ty,
+			Range: ast.NewRangeFromPositioned(pos),
+		},
+	)
+}
+
 func (checker *Checker) checkInvalidInterfaceAsType(ty Type, pos ast.HasPosition) {
 	rewrittenType, rewritten := ty.RewriteWithRestrictedTypes()
 	if rewritten {
@@ -83,6 +83,10 @@ type Elaboration struct {
 	EffectivePredeclaredTypes           map[string]TypeDeclaration
 	isChecking                          bool
 	ReferenceExpressionBorrowTypes      map[*ast.ReferenceExpression]*ReferenceType
+	IndexExpressionIndexedTypes         map[*ast.IndexExpression]Type
+	// OptionalReferenceExpressions are the reference expressions of optional-chained expressions,
+	// e.g. `&s?.field as &T?`, which result in an optional reference
+	OptionalReferenceExpressions map[*ast.ReferenceExpression]struct{}
 }
 
 func NewElaboration() *Elaboration {
@@ -135,6 +139,8 @@ func NewElaboration() *Elaboration {
 		EffectivePredeclaredValues:          map[string]ValueDeclaration{},
 		EffectivePredeclaredTypes:           map[string]TypeDeclaration{},
 		ReferenceExpressionBorrowTypes:      map[*ast.ReferenceExpression]*ReferenceType{},
+		IndexExpressionIndexedTypes:         map[*ast.IndexExpression]Type{},
+		OptionalReferenceExpressions:        map[*ast.ReferenceExpression]struct{}{},
 	}
 }
 
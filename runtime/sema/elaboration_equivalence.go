@@ -0,0 +1,325 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2020 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sema
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/onflow/cadence/runtime/ast"
+)
+
+// ElaborationsEquivalent returns true if the given elaborations are equivalent,
+// i.e. if they record the same types and members for the same program elements.
+//
+// Program elements are matched by their order in the program,
+// so position-only differences, e.g. different whitespace or comments, are ignored.
+//
+// The compared information includes the types of declarations and type annotations
+// (e.g. of variable and function declarations, and casting expressions),
+// the types of expressions (e.g. invocations, arrays, dictionaries),
+// the borrow types of reference expressions, and the resolved members of member expressions.
+//
+func ElaborationsEquivalent(a, b *Elaboration) bool {
+	summaryA := summarizeElaboration(a)
+	summaryB := summarizeElaboration(b)
+
+	if len(summaryA) != len(summaryB) {
+		return false
+	}
+
+	// NOTE: map range is safe, as the result does not depend on the order
+
+	for category, entriesA := range summaryA { //nolint:maprangecheck
+		entriesB, ok := summaryB[category]
+		if !ok || len(entriesA) != len(entriesB) {
+			return false
+		}
+
+		for i, entryA := range entriesA {
+			if entryA.description != entriesB[i].description {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// elaborationSummary is a position-independent representation of an elaboration.
+// Each category has the descriptions of the elaborated program elements,
+// ordered by the position of the program elements.
+//
+type elaborationSummary map[string][]elaborationSummaryEntry
+
+type elaborationSummaryEntry struct {
+	startOffset int
+	endOffset   int
+	description string
+}
+
+func (s elaborationSummary) add(category string, element ast.HasPosition, description string) {
+	s[category] = append(
+		s[category],
+		elaborationSummaryEntry{
+			startOffset: element.StartPosition().Offset,
+			endOffset:   element.EndPosition().Offset,
+			description: description,
+		},
+	)
+}
+
+func (s elaborationSummary) addType(category string, element ast.HasPosition, ty Type) {
+	s.add(category, element, elaborationTypeDescription(ty))
+}
+
+func (s elaborationSummary) addTypes(category string, element ast.HasPosition, types []Type) {
+	s.add(category, element, elaborationTypesDescription(types))
+}
+
+func (s elaborationSummary) sort() {
+	// NOTE: map range is safe, as each category is sorted independently
+	for _, entries := range s { //nolint:maprangecheck
+		sort.SliceStable(entries, func(i, j int) bool {
+			a := entries[i]
+			b := entries[j]
+			if a.startOffset != b.startOffset {
+				return a.startOffset < b.startOffset
+			}
+			if a.endOffset != b.endOffset {
+				return a.endOffset < b.endOffset
+			}
+			return a.description < b.description
+		})
+	}
+}
+
+func elaborationTypeDescription(ty Type) string {
+	if ty == nil {
+		return ""
+	}
+	return string(ty.ID())
+}
+
+func elaborationTypesDescription(types []Type) string {
+	descriptions := make([]string, len(types))
+	for i, ty := range types {
+		descriptions[i] = elaborationTypeDescription(ty)
+	}
+	return fmt.Sprint(descriptions)
+}
+
+func elaborationMemberInfoDescription(info MemberInfo) string {
+	var member string
+	if info.Member != nil {
+		member = fmt.Sprintf(
+			"%s.%s: %s",
+			elaborationTypeDescription(info.Member.ContainerType),
+			info.Member.Identifier.Identifier,
+			elaborationTypeDescription(info.Member.TypeAnnotation.Type),
+		)
+	}
+
+	return fmt.Sprintf(
+		"%s (accessed type: %s, optional: %t)",
+		member,
+		elaborationTypeDescription(info.AccessedType),
+		info.IsOptional,
+	)
+}
+
+func summarizeElaboration(elaboration *Elaboration) elaborationSummary {
+	summary := elaborationSummary{}
+
+	// NOTE: map ranges are safe, as the entries of each category are sorted afterwards
+
+	// Declarations and type annotations
+
+	for declaration, functionType := range elaboration.FunctionDeclarationFunctionTypes { //nolint:maprangecheck
+		summary.addType("FunctionDeclarationFunctionTypes", declaration, functionType)
+	}
+
+	for declaration, ty := range elaboration.VariableDeclarationValueTypes { //nolint:maprangecheck
+		summary.addType("VariableDeclarationValueTypes", declaration, ty)
+	}
+
+	for declaration, ty := range elaboration.VariableDeclarationSecondValueTypes { //nolint:maprangecheck
+		summary.addType("VariableDeclarationSecondValueTypes", declaration, ty)
+	}
+
+	for declaration, ty := range elaboration.VariableDeclarationTargetTypes { //nolint:maprangecheck
+		summary.addType("VariableDeclarationTargetTypes", declaration, ty)
+	}
+
+	for declaration, compositeType := range elaboration.CompositeDeclarationTypes { //nolint:maprangecheck
+		summary.addType("CompositeDeclarationTypes", declaration, compositeType)
+	}
+
+	for declaration, interfaceType := range elaboration.InterfaceDeclarationTypes { //nolint:maprangecheck
+		summary.addType("InterfaceDeclarationTypes", declaration, interfaceType)
+	}
+
+	for declaration, functionType := range elaboration.ConstructorFunctionTypes { //nolint:maprangecheck
+		summary.addType("ConstructorFunctionTypes", declaration, functionType)
+	}
+
+	for expression, functionType := range elaboration.FunctionExpressionFunctionType { //nolint:maprangecheck
+		summary.addType("FunctionExpressionFunctionType", expression, functionType)
+	}
+
+	for expression, ty := range elaboration.CastingStaticValueTypes { //nolint:maprangecheck
+		summary.addType("CastingStaticValueTypes", expression, ty)
+	}
+
+	for expression, ty := range elaboration.CastingTargetTypes { //nolint:maprangecheck
+		summary.addType("CastingTargetTypes", expression, ty)
+	}
+
+	// Statements
+
+	for statement, ty := range elaboration.AssignmentStatementValueTypes { //nolint:maprangecheck
+		summary.addType("AssignmentStatementValueTypes", statement, ty)
+	}
+
+	for statement, ty := range elaboration.AssignmentStatementTargetTypes { //nolint:maprangecheck
+		summary.addType("AssignmentStatementTargetTypes", statement, ty)
+	}
+
+	for statement, ty := range elaboration.ReturnStatementValueTypes { //nolint:maprangecheck
+		summary.addType("ReturnStatementValueTypes", statement, ty)
+	}
+
+	for statement, ty := range elaboration.ReturnStatementReturnTypes { //nolint:maprangecheck
+		summary.addType("ReturnStatementReturnTypes", statement, ty)
+	}
+
+	for statement, ty := range elaboration.SwapStatementLeftTypes { //nolint:maprangecheck
+		summary.addType("SwapStatementLeftTypes", statement, ty)
+	}
+
+	for statement, ty := range elaboration.SwapStatementRightTypes { //nolint:maprangecheck
+		summary.addType("SwapStatementRightTypes", statement, ty)
+	}
+
+	for statement, eventType := range elaboration.EmitStatementEventTypes { //nolint:maprangecheck
+		summary.addType("EmitStatementEventTypes", statement, eventType)
+	}
+
+	// Expressions
+
+	for expression, types := range elaboration.InvocationExpressionArgumentTypes { //nolint:maprangecheck
+		summary.addTypes("InvocationExpressionArgumentTypes", expression, types)
+	}
+
+	for expression, types := range elaboration.InvocationExpressionParameterTypes { //nolint:maprangecheck
+		summary.addTypes("InvocationExpressionParameterTypes", expression, types)
+	}
+
+	for expression, ty := range elaboration.InvocationExpressionReturnTypes { //nolint:maprangecheck
+		summary.addType("InvocationExpressionReturnTypes", expression, ty)
+	}
+
+	for expression, typeArguments := range elaboration.InvocationExpressionTypeArguments { //nolint:maprangecheck
+		var types []Type
+		typeArguments.Foreach(func(_ *TypeParameter, ty Type) {
+			types = append(types, ty)
+		})
+		summary.addTypes("InvocationExpressionTypeArguments", expression, types)
+	}
+
+	for expression, ty := range elaboration.BinaryExpressionResultTypes { //nolint:maprangecheck
+		summary.addType("BinaryExpressionResultTypes", expression, ty)
+	}
+
+	for expression, ty := range elaboration.BinaryExpressionRightTypes { //nolint:maprangecheck
+		summary.addType("BinaryExpressionRightTypes", expression, ty)
+	}
+
+	for expression, types := range elaboration.ArrayExpressionArgumentTypes { //nolint:maprangecheck
+		summary.addTypes("ArrayExpressionArgumentTypes", expression, types)
+	}
+
+	for expression, arrayType := range elaboration.ArrayExpressionArrayType { //nolint:maprangecheck
+		summary.addType("ArrayExpressionArrayType", expression, arrayType)
+	}
+
+	for expression, dictionaryType := range elaboration.DictionaryExpressionType { //nolint:maprangecheck
+		summary.addType("DictionaryExpressionType", expression, dictionaryType)
+	}
+
+	for expression, ty := range elaboration.IntegerExpressionType { //nolint:maprangecheck
+		summary.addType("IntegerExpressionType", expression, ty)
+	}
+
+	for expression, ty := range elaboration.FixedPointExpression { //nolint:maprangecheck
+		summary.addType("FixedPointExpression", expression, ty)
+	}
+
+	for expression, ty := range elaboration.IndexExpressionIndexedTypes { //nolint:maprangecheck
+		summary.addType("IndexExpressionIndexedTypes", expression, ty)
+	}
+
+	for expression, ty := range elaboration.IdentifierInInvocationTypes { //nolint:maprangecheck
+		summary.addType("IdentifierInInvocationTypes", expression, ty)
+	}
+
+	// Reference borrow types
+
+	for expression, borrowType := range elaboration.ReferenceExpressionBorrowTypes { //nolint:maprangecheck
+		summary.addType("ReferenceExpressionBorrowTypes", expression, borrowType)
+	}
+
+	for expression := range elaboration.OptionalReferenceExpressions { //nolint:maprangecheck
+		summary.add("OptionalReferenceExpressions", expression, "optional")
+	}
+
+	// Resolved members
+
+	for expression, info := range elaboration.MemberExpressionMemberInfos { //nolint:maprangecheck
+		summary.add("MemberExpressionMemberInfos", expression, elaborationMemberInfoDescription(info))
+	}
+
+	for expression, ty := range elaboration.MemberExpressionExpectedTypes { //nolint:maprangecheck
+		summary.addType("MemberExpressionExpectedTypes", expression, ty)
+	}
+
+	// Globals
+
+	elaboration.GlobalValues.Foreach(func(name string, variable *Variable) {
+		summary["GlobalValues"] = append(
+			summary["GlobalValues"],
+			elaborationSummaryEntry{
+				description: fmt.Sprintf("%s: %s", name, elaborationTypeDescription(variable.Type)),
+			},
+		)
+	})
+
+	elaboration.GlobalTypes.Foreach(func(name string, variable *Variable) {
+		summary["GlobalTypes"] = append(
+			summary["GlobalTypes"],
+			elaborationSummaryEntry{
+				description: fmt.Sprintf("%s: %s", name, elaborationTypeDescription(variable.Type)),
+			},
+		)
+	})
+
+	summary.sort()
+
+	return summary
+}
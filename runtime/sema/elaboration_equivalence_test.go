@@ -0,0 +1,206 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2020 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/cadence/runtime/parser2"
+)
+
+func TestElaborationsEquivalent(t *testing.T) {
+
+	t.Parallel()
+
+	check := func(t *testing.T, code string) *Elaboration {
+		program, err := parser2.ParseProgram(code)
+		require.NoError(t, err)
+
+		checker, err := NewChecker(
+			program,
+			common.StringLocation("test"),
+			WithAccessCheckMode(AccessCheckModeNotSpecifiedUnrestricted),
+		)
+		require.NoError(t, err)
+
+		err = checker.Check()
+		require.NoError(t, err)
+
+		return checker.Elaboration
+	}
+
+	const code = `
+      pub contract C {
+
+          pub resource interface Receiver {
+              pub fun deposit(amount: UInt64)
+          }
+
+          pub resource Vault: Receiver {
+              pub var balance: UInt64
+
+              init(balance: UInt64) {
+                  self.balance = balance
+              }
+
+              pub fun deposit(amount: UInt64) {
+                  self.balance = self.balance + amount
+              }
+          }
+
+          pub fun test(): [UInt64] {
+              let vault <- create Vault(balance: 1)
+              let ref = &vault as &Vault{Receiver}
+              ref.deposit(amount: 2)
+              let balances = [vault.balance, UInt64(3)]
+              let names = {"vault": vault.balance}
+              destroy vault
+              return balances
+          }
+      }
+    `
+
+	// Same program, with different whitespace and comments
+
+	const reformattedCode = `
+      // A contract
+      pub contract C {
+          pub resource interface Receiver { pub fun deposit(amount: UInt64) }
+
+          pub resource Vault: Receiver {
+              pub var balance: UInt64
+              init(balance: UInt64) { self.balance = balance }
+
+              pub fun deposit(amount: UInt64) {
+                  self.balance = self.balance
+                      + amount
+              }
+          }
+
+          pub fun test(): [UInt64] {
+              let vault <- create Vault(balance: 1)
+              let ref = &vault as &Vault{Receiver}
+              ref.deposit(amount: 2)
+              let balances = [
+                  vault.balance,
+                  UInt64(3)
+              ]
+              let names = {
+                  "vault": vault.balance
+              }
+              destroy vault
+              return balances
+          }
+      }
+    `
+
+	t.Run("same program", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.True(t,
+			ElaborationsEquivalent(
+				check(t, code),
+				check(t, code),
+			),
+		)
+	})
+
+	t.Run("reformatted program", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.True(t,
+			ElaborationsEquivalent(
+				check(t, code),
+				check(t, reformattedCode),
+			),
+		)
+	})
+
+	t.Run("different types", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.False(t,
+			ElaborationsEquivalent(
+				check(t, `let x: Int = 1`),
+				check(t, `let x: UInt8 = 1`),
+			),
+		)
+	})
+
+	t.Run("different members", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.False(t,
+			ElaborationsEquivalent(
+				check(t, `
+                  struct S {
+                      let a: Int
+                      let b: Int
+
+                      init() {
+                          self.a = 1
+                          self.b = 2
+                      }
+                  }
+
+                  let x = S().a
+                `),
+				check(t, `
+                  struct S {
+                      let a: Int
+                      let b: Int
+
+                      init() {
+                          self.a = 1
+                          self.b = 2
+                      }
+                  }
+
+                  let x = S().b
+                `),
+			),
+		)
+	})
+
+	t.Run("different reference borrow types", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.False(t,
+			ElaborationsEquivalent(
+				check(t, `
+                  let x = 1
+                  let y = &x as &Int
+                `),
+				check(t, `
+                  let x = 1
+                  let y = &x as auth &Int
+                `),
+			),
+		)
+	})
+}
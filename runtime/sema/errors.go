@@ -784,6 +784,26 @@ func (e *FieldTypeNotStorableError) EndPosition() ast.Position {
 	return e.Pos.Shifted(length - 1)
 }
 
+// StoredReferenceError is an error that is reported when a value
+// which statically contains a reference is saved to storage,
+// if stored references are rejected (see WithRejectStoredReferences).
+//
+// References are not storable, so saving such a value always fails at run-time.
+//
+type StoredReferenceError struct {
+	Type Type
+	ast.Range
+}
+
+func (e *StoredReferenceError) Error() string {
+	return fmt.Sprintf(
+		"cannot store value containing reference: `%s`",
+		e.Type.QualifiedString(),
+	)
+}
+
+func (*StoredReferenceError) isSemanticError() {}
+
 // FunctionExpressionInConditionError
 
 type FunctionExpressionInConditionError struct {
@@ -1382,6 +1402,74 @@ func (e *InvalidInterfaceDeclarationError) Error() string {
 
 func (*InvalidInterfaceDeclarationError) isSemanticError() {}
 
+// InvalidRestrictedReferenceWideningError
+
+type InvalidRestrictedReferenceWideningError struct {
+	ValueType  Type
+	TargetType Type
+	ast.Range
+}
+
+func (e *InvalidRestrictedReferenceWideningError) Error() string {
+	return "cannot cast restricted reference to a wider type"
+}
+
+func (*InvalidRestrictedReferenceWideningError) isSemanticError() {}
+
+func (e *InvalidRestrictedReferenceWideningError) SecondaryError() string {
+	return fmt.Sprintf(
+		"restricted references cannot be widened: `%s` can never be cast to `%s`; "+
+			"consider borrowing the reference as `%s`, or using an authorized reference",
+		e.ValueType.QualifiedString(),
+		e.TargetType.QualifiedString(),
+		e.TargetType.QualifiedString(),
+	)
+}
+
+// InvalidInterfaceFunctionTypeParametersError
+
+type InvalidInterfaceFunctionTypeParametersError struct {
+	ast.Range
+}
+
+func (e *InvalidInterfaceFunctionTypeParametersError) Error() string {
+	return "type parameters are not supported for interface functions"
+}
+
+func (*InvalidInterfaceFunctionTypeParametersError) isSemanticError() {}
+
+// GenericTypeRuntimeTypeError
+
+type GenericTypeRuntimeTypeError struct {
+	Type Type
+	ast.Range
+}
+
+func (e *GenericTypeRuntimeTypeError) Error() string {
+	return fmt.Sprintf(
+		"cannot use type `%s` at run-time: type arguments of generic functions are not available at run-time",
+		e.Type.QualifiedString(),
+	)
+}
+
+func (*GenericTypeRuntimeTypeError) isSemanticError() {}
+
+// InvalidIndexedEventFieldError
+
+type InvalidIndexedEventFieldError struct {
+	Name string
+	ast.Range
+}
+
+func (e *InvalidIndexedEventFieldError) Error() string {
+	return fmt.Sprintf(
+		"invalid indexed event field: event has no parameter `%s`",
+		e.Name,
+	)
+}
+
+func (*InvalidIndexedEventFieldError) isSemanticError() {}
+
 // IncorrectTransferOperationError
 
 type IncorrectTransferOperationError struct {
@@ -2014,6 +2102,8 @@ func (*NonReferenceTypeReferenceError) isSemanticError() {}
 
 type OptionalTypeReferenceError struct {
 	ActualType Type
+	// Suggestion is a suggested fix for the reference expression, if any
+	Suggestion string
 	ast.Range
 }
 
@@ -2024,8 +2114,100 @@ func (e *OptionalTypeReferenceError) Error() string {
 	)
 }
 
+func (e *OptionalTypeReferenceError) SecondaryError() string {
+	if e.Suggestion == "" {
+		return ""
+	}
+	return fmt.Sprintf("did you mean `%s`?", e.Suggestion)
+}
+
 func (*OptionalTypeReferenceError) isSemanticError() {}
 
+// EphemeralResourceReferenceError is reported when a reference is taken to a resource
+// which is produced by the referenced expression, e.g. returned from a function call.
+// The resource is not stored anywhere and would be lost.
+//
+type EphemeralResourceReferenceError struct {
+	ActualType Type
+	ast.Range
+}
+
+func (e *EphemeralResourceReferenceError) Error() string {
+	return fmt.Sprintf(
+		"cannot create reference to ephemeral resource, got `%s`",
+		e.ActualType.QualifiedString(),
+	)
+}
+
+func (e *EphemeralResourceReferenceError) SecondaryError() string {
+	return "the resource is not stored and would be lost. consider moving the resource into a variable first"
+}
+
+func (*EphemeralResourceReferenceError) isSemanticError() {}
+
+// UnauthorizedReferenceEscalationError is reported when an authorized reference is taken
+// to a value which is accessed through an unauthorized reference.
+//
+type UnauthorizedReferenceEscalationError struct {
+	AccessedType *ReferenceType
+	ast.Range
+}
+
+func (e *UnauthorizedReferenceEscalationError) Error() string {
+	return fmt.Sprintf(
+		"cannot create authorized reference to value accessed through unauthorized reference of type `%s`",
+		e.AccessedType.QualifiedString(),
+	)
+}
+
+func (e *UnauthorizedReferenceEscalationError) SecondaryError() string {
+	return "an authorized reference allows downcasting, which the unauthorized reference does not permit. " +
+		"consider creating an unauthorized reference"
+}
+
+func (*UnauthorizedReferenceEscalationError) isSemanticError() {}
+
+// ReferenceEscapesScopeError is reported when a reference to a local variable
+// escapes the function in which the variable is declared,
+// e.g. when it is returned, or assigned to a field.
+//
+type ReferenceEscapesScopeError struct {
+	Name string
+	ast.Range
+}
+
+func (e *ReferenceEscapesScopeError) Error() string {
+	return fmt.Sprintf(
+		"reference to local variable `%s` escapes its scope",
+		e.Name,
+	)
+}
+
+func (e *ReferenceEscapesScopeError) SecondaryError() string {
+	return "the reference would outlive the referenced value. consider returning or storing the value instead"
+}
+
+func (*ReferenceEscapesScopeError) isSemanticError() {}
+
+// ArrayIndexOutOfBoundsError is reported when a reference is taken
+// to an element of a constant-sized array, and the literal index is out of bounds.
+//
+type ArrayIndexOutOfBoundsError struct {
+	Index *big.Int
+	Size  int64
+	ast.Range
+}
+
+func (e *ArrayIndexOutOfBoundsError) Error() string {
+	return fmt.Sprintf(
+		"array index out of bounds: %s, but size is %d",
+		e.Index,
+		e.Size,
+	)
+}
+
+func (*ArrayIndexOutOfBoundsError) isSemanticError() {}
+
 // InvalidResourceCreationError
 
 type InvalidResourceCreationError struct {
@@ -2944,6 +3126,19 @@ func (e *MissingSwitchCaseStatementsError) EndPosition() ast.Position {
 	return e.Pos
 }
 
+// TypeDepthLimitError
+
+type TypeDepthLimitError struct {
+	Limit int
+	ast.Range
+}
+
+func (e *TypeDepthLimitError) Error() string {
+	return fmt.Sprintf("type is nested too deeply: exceeds limit of %d", e.Limit)
+}
+
+func (*TypeDepthLimitError) isSemanticError() {}
+
 // MissingEntryPointError
 
 type MissingEntryPointError struct {
@@ -116,3 +116,31 @@ func (h *UnnecessaryCastHint) Hint() string {
 }
 
 func (*UnnecessaryCastHint) isHint() {}
+
+// UnusedReferenceHint
+
+type UnusedReferenceHint struct {
+	ast.Range
+}
+
+func (h *UnusedReferenceHint) Hint() string {
+	return "reference is never used: creating a reference has no side effects"
+}
+
+func (*UnusedReferenceHint) isHint() {}
+
+// UnborrowedLinkHint
+
+type UnborrowedLinkHint struct {
+	Path *ast.PathExpression
+	ast.Range
+}
+
+func (h *UnborrowedLinkHint) Hint() string {
+	return fmt.Sprintf(
+		"capability linked at `%s` is never borrowed in this program",
+		h.Path,
+	)
+}
+
+func (*UnborrowedLinkHint) isHint() {}
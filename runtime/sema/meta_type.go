@@ -31,6 +31,10 @@ const metaTypeSubtypeDocString = `
 Returns true if this type is a subtype of the given type at run-time
 `
 
+const metaTypeIsStorableDocString = `
+True if values of this type can be stored, e.g. saved to account storage
+`
+
 // MetaType represents the type of a type.
 //
 var MetaType = &SimpleType{
@@ -84,6 +88,17 @@ func init() {
 					)
 				},
 			},
+			"isStorable": {
+				Kind: common.DeclarationKindField,
+				Resolve: func(identifier string, _ ast.Range, _ func(error)) *Member {
+					return NewPublicConstantFieldMember(
+						t,
+						identifier,
+						BoolType,
+						metaTypeIsStorableDocString,
+					)
+				},
+			},
 		}
 	}
 }
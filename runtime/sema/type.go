@@ -557,6 +557,13 @@ with the value of this optional when it is not nil.
 Returns nil if this optional is nil
 `
 
+const optionalTypeOrElseFunctionDocString = `
+Returns the value of this optional when it is not nil.
+
+Otherwise, returns the result of calling the given function.
+The function is only called if this optional is nil
+`
+
 func (t *OptionalType) GetMembers() map[string]MemberResolver {
 
 	members := map[string]MemberResolver{
@@ -584,11 +591,52 @@ func (t *OptionalType) GetMembers() map[string]MemberResolver {
 				)
 			},
 		},
+		"orElse": {
+			Kind: common.DeclarationKindFunction,
+			Resolve: func(identifier string, targetRange ast.Range, report func(error)) *Member {
+
+				// It invalid for an optional of a resource to have an `orElse` function
+
+				if t.Type.IsResourceType() {
+					report(
+						&InvalidResourceOptionalMemberError{
+							Name:            identifier,
+							DeclarationKind: common.DeclarationKindFunction,
+							Range:           targetRange,
+						},
+					)
+				}
+
+				return NewPublicFunctionMember(
+					t,
+					identifier,
+					OptionalTypeOrElseFunctionType(t.Type),
+					optionalTypeOrElseFunctionDocString,
+				)
+			},
+		},
 	}
 
 	return withBuiltinMembers(t, members)
 }
 
+func OptionalTypeOrElseFunctionType(typ Type) *FunctionType {
+	return &FunctionType{
+		Parameters: []*Parameter{
+			{
+				Label:      ArgumentLabelNotRequired,
+				Identifier: "compute",
+				TypeAnnotation: NewTypeAnnotation(
+					&FunctionType{
+						ReturnTypeAnnotation: NewTypeAnnotation(typ),
+					},
+				),
+			},
+		},
+		ReturnTypeAnnotation: NewTypeAnnotation(typ),
+	}
+}
+
 func OptionalTypeMapFunctionType(typ Type) *FunctionType {
 	typeParameter := &TypeParameter{
 		Name: "T",
@@ -662,8 +710,9 @@ func (t *GenericType) Equal(other Type) bool {
 	return t.TypeParameter == otherType.TypeParameter
 }
 
-func (*GenericType) IsResourceType() bool {
-	return false
+func (t *GenericType) IsResourceType() bool {
+	typeBound := t.TypeParameter.TypeBound
+	return typeBound != nil && typeBound.IsResourceType()
 }
 
 func (*GenericType) IsInvalidType() bool {
@@ -747,6 +796,48 @@ func (t *GenericType) GetMembers() map[string]MemberResolver {
 	return withBuiltinMembers(t, nil)
 }
 
+// containsGenericType returns true if the given type is a generic type, or contains one.
+//
+// The type arguments of declared generic functions are not available at run-time,
+// so such types cannot be used where a run-time type is needed, e.g. in a dynamic cast.
+//
+func containsGenericType(ty Type) bool {
+	switch ty := ty.(type) {
+	case *GenericType:
+		return true
+
+	case *OptionalType:
+		return containsGenericType(ty.Type)
+
+	case ArrayType:
+		return containsGenericType(ty.ElementType(false))
+
+	case *DictionaryType:
+		return containsGenericType(ty.KeyType) ||
+			containsGenericType(ty.ValueType)
+
+	case *ReferenceType:
+		return containsGenericType(ty.Type)
+
+	case *RestrictedType:
+		return containsGenericType(ty.Type)
+
+	case *CapabilityType:
+		return ty.BorrowType != nil &&
+			containsGenericType(ty.BorrowType)
+
+	case *FunctionType:
+		for _, parameter := range ty.Parameters {
+			if containsGenericType(parameter.TypeAnnotation.Type) {
+				return true
+			}
+		}
+		return containsGenericType(ty.ReturnTypeAnnotation.Type)
+	}
+
+	return false
+}
+
 // IntegerRangedType
 
 type IntegerRangedType interface {
@@ -1561,6 +1652,26 @@ Removes the last element from the array and returns it.
 The array must not be empty. If the array is empty, the program aborts
 `
 
+const arrayTypeToHexStringFunctionDocString = `
+Returns a hexadecimal string for the bytes in the array.
+
+The function is only available for arrays of bytes, i.e. arrays with element type ` + "`UInt8`" + `
+`
+
+const arrayTypeSortedByUUIDFunctionDocString = `
+Moves all resources out of the array and returns them in a new array, ordered by their UUIDs in ascending order.
+
+The array is empty afterwards.
+
+The function is only available for arrays of resources
+`
+
+var ArrayToHexStringFunctionType = &FunctionType{
+	ReturnTypeAnnotation: NewTypeAnnotation(
+		StringType,
+	),
+}
+
 func getArrayMembers(arrayType ArrayType) map[string]MemberResolver {
 
 	members := map[string]MemberResolver{
@@ -1615,6 +1726,23 @@ func getArrayMembers(arrayType ArrayType) map[string]MemberResolver {
 		},
 	}
 
+	// Arrays of bytes can be hex-encoded
+
+	if arrayType.ElementType(false).Equal(UInt8Type) {
+
+		members["toHexString"] = MemberResolver{
+			Kind: common.DeclarationKindFunction,
+			Resolve: func(identifier string, _ ast.Range, _ func(error)) *Member {
+				return NewPublicFunctionMember(
+					arrayType,
+					identifier,
+					ArrayToHexStringFunctionType,
+					arrayTypeToHexStringFunctionDocString,
+				)
+			},
+		}
+	}
+
 	// TODO: maybe still return members but report a helpful error?
 
 	if _, ok := arrayType.(*VariableSizedType); ok {
@@ -1744,11 +1872,36 @@ func getArrayMembers(arrayType ArrayType) map[string]MemberResolver {
 				)
 			},
 		}
+
+		// Arrays of resources, which all have a UUID, can be sorted by UUID
+
+		if hasResourceUUID(arrayType.ElementType(false)) {
+
+			members["sortedByUUID"] = MemberResolver{
+				Kind: common.DeclarationKindFunction,
+				Resolve: func(identifier string, _ ast.Range, _ func(error)) *Member {
+					return NewPublicFunctionMember(
+						arrayType,
+						identifier,
+						ArraySortedByUUIDFunctionType(arrayType),
+						arrayTypeSortedByUUIDFunctionDocString,
+					)
+				},
+			}
+		}
 	}
 
 	return withBuiltinMembers(arrayType, members)
 }
 
+// hasResourceUUID returns true if values of the given type are resources
+// which have the predeclared `uuid` field, i.e. composites and interfaces of resource kind.
+//
+func hasResourceUUID(ty Type) bool {
+	compositeKindedType, ok := ty.(CompositeKindedType)
+	return ok && compositeKindedType.GetCompositeKind() == common.CompositeKindResource
+}
+
 func ArrayRemoveLastFunctionType(elementType Type) *FunctionType {
 	return &FunctionType{
 		ReturnTypeAnnotation: NewTypeAnnotation(
@@ -1757,6 +1910,14 @@ func ArrayRemoveLastFunctionType(elementType Type) *FunctionType {
 	}
 }
 
+func ArraySortedByUUIDFunctionType(arrayType ArrayType) *FunctionType {
+	return &FunctionType{
+		ReturnTypeAnnotation: NewTypeAnnotation(
+			arrayType,
+		),
+	}
+}
+
 func ArrayRemoveFirstFunctionType(elementType Type) *FunctionType {
 	return &FunctionType{
 		ReturnTypeAnnotation: NewTypeAnnotation(
@@ -2305,6 +2466,9 @@ type FunctionType struct {
 	RequiredArgumentCount    *int
 	ArgumentExpressionsCheck ArgumentExpressionsCheck
 	Members                  *StringMemberOrderedMap
+	// isDeclaredGeneric is true if the function type is the type of a declared generic function.
+	// The type arguments of invocations of such functions are not needed at run-time
+	isDeclaredGeneric bool
 }
 
 func RequiredArgumentCount(count int) *int {
@@ -2612,6 +2776,7 @@ func (t *FunctionType) RewriteWithRestrictedTypes() (Type, bool) {
 			Parameters:            rewrittenParameters,
 			ReturnTypeAnnotation:  NewTypeAnnotation(rewrittenReturnType),
 			RequiredArgumentCount: t.RequiredArgumentCount,
+			isDeclaredGeneric:     t.isDeclaredGeneric,
 		}, true
 	} else {
 		return t, false
@@ -2785,6 +2950,7 @@ func init() {
 		PublicKeyType,
 		SignatureAlgorithmType,
 		HashAlgorithmType,
+		CapabilityCheckResultType,
 	)
 
 	for _, ty := range types {
@@ -3370,10 +3536,13 @@ type CompositeType struct {
 	Fields                              []string
 	// TODO: add support for overloaded initializers
 	ConstructorParameters []*Parameter
-	nestedTypes           *StringTypeOrderedMap
-	containerType         Type
-	EnumRawType           Type
-	hasComputedMembers    bool
+	// IndexedFields are the names of the fields of an event type which are marked as indexed,
+	// using `pragma indexed` declarations in the docstring of the event declaration
+	IndexedFields      []string
+	nestedTypes        *StringTypeOrderedMap
+	containerType      Type
+	EnumRawType        Type
+	hasComputedMembers bool
 
 	// Only applicable for native composite types.
 	importable bool
@@ -4205,6 +4374,13 @@ Removes the value for the given key from the dictionary.
 Returns the value as an optional if the dictionary contained the key, or nil if the dictionary did not contain the key
 `
 
+const dictionaryTypeMergingFunctionDocString = `
+Returns a new dictionary containing the entries of this dictionary and the entries of the given dictionary.
+
+If both dictionaries contain the same key, the given resolve function is called with the existing value and the value of the given dictionary,
+and the result is used as the value of the key in the new dictionary
+`
+
 func (t *DictionaryType) GetMembers() map[string]MemberResolver {
 	t.initializeMemberResolvers()
 	return t.memberResolvers
@@ -4303,6 +4479,28 @@ func (t *DictionaryType) initializeMemberResolvers() {
 					)
 				},
 			},
+			"merging": {
+				Kind: common.DeclarationKindFunction,
+				Resolve: func(identifier string, targetRange ast.Range, report func(error)) *Member {
+					// TODO: maybe allow for resource key and value types
+
+					if t.KeyType.IsResourceType() || t.ValueType.IsResourceType() {
+						report(
+							&InvalidResourceDictionaryMemberError{
+								Name:            identifier,
+								DeclarationKind: common.DeclarationKindFunction,
+								Range:           targetRange,
+							},
+						)
+					}
+
+					return NewPublicFunctionMember(t,
+						identifier,
+						DictionaryMergingFunctionType(t),
+						dictionaryTypeMergingFunctionDocString,
+					)
+				},
+			},
 		})
 	})
 }
@@ -4359,6 +4557,39 @@ func DictionaryRemoveFunctionType(t *DictionaryType) *FunctionType {
 	}
 }
 
+func DictionaryMergingFunctionType(t *DictionaryType) *FunctionType {
+	return &FunctionType{
+		Parameters: []*Parameter{
+			{
+				Label:          ArgumentLabelNotRequired,
+				Identifier:     "other",
+				TypeAnnotation: NewTypeAnnotation(t),
+			},
+			{
+				Identifier: "resolve",
+				TypeAnnotation: NewTypeAnnotation(
+					&FunctionType{
+						Parameters: []*Parameter{
+							{
+								Label:          ArgumentLabelNotRequired,
+								Identifier:     "existing",
+								TypeAnnotation: NewTypeAnnotation(t.ValueType),
+							},
+							{
+								Label:          ArgumentLabelNotRequired,
+								Identifier:     "new",
+								TypeAnnotation: NewTypeAnnotation(t.ValueType),
+							},
+						},
+						ReturnTypeAnnotation: NewTypeAnnotation(t.ValueType),
+					},
+				),
+			},
+		},
+		ReturnTypeAnnotation: NewTypeAnnotation(t),
+	}
+}
+
 func (*DictionaryType) isValueIndexableType() bool {
 	return true
 }
@@ -4736,6 +4967,22 @@ func checkSubTypeWithoutEquality(subType Type, superType Type) bool {
 		return true
 	}
 
+	// A generic type is a subtype of the supertypes of its type bound
+
+	if typedSubType, ok := subType.(*GenericType); ok {
+		if optionalSuperType, ok := superType.(*OptionalType); ok {
+			return IsSubType(subType, optionalSuperType.Type)
+		}
+
+		if superType == AnyType {
+			return true
+		}
+
+		typeBound := typedSubType.TypeParameter.TypeBound
+		return typeBound != nil &&
+			IsSubType(typeBound, superType)
+	}
+
 	switch superType {
 	case AnyType:
 		return true
@@ -5907,6 +6154,39 @@ func CapabilityTypeBorrowFunctionType(borrowType Type) *FunctionType {
 	}
 }
 
+func CapabilityTypeBorrowOrFunctionType(borrowType Type) *FunctionType {
+
+	var typeParameters []*TypeParameter
+
+	if borrowType == nil {
+		typeParameter := capabilityTypeParameter
+
+		typeParameters = []*TypeParameter{
+			typeParameter,
+		}
+
+		borrowType = &GenericType{
+			TypeParameter: typeParameter,
+		}
+	}
+
+	return &FunctionType{
+		TypeParameters: typeParameters,
+		Parameters: []*Parameter{
+			{
+				Label:          ArgumentLabelNotRequired,
+				Identifier:     "fallback",
+				TypeAnnotation: NewTypeAnnotation(&CapabilityType{}),
+			},
+		},
+		ReturnTypeAnnotation: NewTypeAnnotation(
+			&OptionalType{
+				Type: borrowType,
+			},
+		),
+	}
+}
+
 func CapabilityTypeCheckFunctionType(borrowType Type) *FunctionType {
 
 	var typeParameters []*TypeParameter
@@ -5923,14 +6203,44 @@ func CapabilityTypeCheckFunctionType(borrowType Type) *FunctionType {
 	}
 }
 
+func CapabilityTypeCheckStatusFunctionType(borrowType Type) *FunctionType {
+
+	var typeParameters []*TypeParameter
+
+	if borrowType == nil {
+		typeParameters = []*TypeParameter{
+			capabilityTypeParameter,
+		}
+	}
+
+	return &FunctionType{
+		TypeParameters:       typeParameters,
+		ReturnTypeAnnotation: NewTypeAnnotation(CapabilityCheckResultType),
+	}
+}
+
 const capabilityTypeBorrowFunctionDocString = `
 Returns a reference to the object targeted by the capability, provided it can be borrowed using the given type
 `
 
+const capabilityTypeBorrowOrFunctionDocString = `
+Returns a reference to the object targeted by the capability, provided it can be borrowed using the given type.
+
+If the capability cannot be borrowed, returns a reference to the object targeted by the given fallback capability,
+provided it can be borrowed using the given type
+`
+
 const capabilityTypeCheckFunctionDocString = `
 Returns true if the capability currently targets an object that satisfies the given type, i.e. could be borrowed using the given type
 `
 
+const capabilityTypeCheckStatusFunctionDocString = `
+Returns the result of checking the capability using the given type.
+
+Unlike ` + "`check`" + `, the result distinguishes between a capability which is not linked,
+and a capability which is linked, but cannot be borrowed using the given type
+`
+
 const addressTypeCheckFunctionDocString = `
 The address of the capability
 `
@@ -5954,6 +6264,17 @@ func (t *CapabilityType) initializeMemberResolvers() {
 					)
 				},
 			},
+			"borrowOr": {
+				Kind: common.DeclarationKindFunction,
+				Resolve: func(identifier string, _ ast.Range, _ func(error)) *Member {
+					return NewPublicFunctionMember(
+						t,
+						identifier,
+						CapabilityTypeBorrowOrFunctionType(t.BorrowType),
+						capabilityTypeBorrowOrFunctionDocString,
+					)
+				},
+			},
 			"check": {
 				Kind: common.DeclarationKindFunction,
 				Resolve: func(identifier string, _ ast.Range, _ func(error)) *Member {
@@ -5965,6 +6286,17 @@ func (t *CapabilityType) initializeMemberResolvers() {
 					)
 				},
 			},
+			"checkStatus": {
+				Kind: common.DeclarationKindFunction,
+				Resolve: func(identifier string, _ ast.Range, _ func(error)) *Member {
+					return NewPublicFunctionMember(
+						t,
+						identifier,
+						CapabilityTypeCheckStatusFunctionType(t.BorrowType),
+						capabilityTypeCheckStatusFunctionDocString,
+					)
+				},
+			},
 			"address": {
 				Kind: common.DeclarationKindField,
 				Resolve: func(identifier string, _ ast.Range, _ func(error)) *Member {
@@ -5994,6 +6326,7 @@ func init() {
 		PublicAccountType,
 		PublicAccountKeysType,
 		PublicAccountContractsType,
+		CapabilityCheckResultType,
 	}
 
 	for _, semaType := range types {
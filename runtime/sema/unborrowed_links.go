@@ -0,0 +1,140 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2020 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sema
+
+import (
+	"github.com/onflow/cadence/runtime/ast"
+	"github.com/onflow/cadence/runtime/common"
+)
+
+type capabilityLink struct {
+	path       *ast.PathExpression
+	invocation *ast.InvocationExpression
+}
+
+// recordCapabilityLinkOrRetrieval records the invocation if it links a capability
+// or gets a capability, so unborrowed links can be reported by reportUnborrowedLinks.
+//
+func (checker *Checker) recordCapabilityLinkOrRetrieval(invocationExpression *ast.InvocationExpression) {
+	memberExpression, ok := invocationExpression.InvokedExpression.(*ast.MemberExpression)
+	if !ok {
+		return
+	}
+
+	memberInfo, ok := checker.Elaboration.MemberExpressionMemberInfos[memberExpression]
+	if !ok || memberInfo.Member == nil {
+		return
+	}
+
+	member := memberInfo.Member
+
+	if len(invocationExpression.Arguments) < 1 {
+		return
+	}
+
+	pathExpression, isPathLiteral := invocationExpression.Arguments[0].Expression.(*ast.PathExpression)
+
+	switch {
+	case member.ContainerType == AuthAccountType &&
+		member.Identifier.Identifier == AuthAccountLinkField:
+
+		// The target of the link might be another link,
+		// which is then used through this link, like an obtained capability
+
+		if len(invocationExpression.Arguments) > 1 {
+			checker.recordObtainedCapabilityPath(invocationExpression.Arguments[1].Expression)
+		}
+
+		// Only links whose capability is immediately discarded are considered:
+		// The returned capability might be borrowed
+
+		if !isPathLiteral ||
+			checker.discardedExpression != invocationExpression {
+
+			return
+		}
+
+		checker.capabilityLinks = append(
+			checker.capabilityLinks,
+			capabilityLink{
+				path:       pathExpression,
+				invocation: invocationExpression,
+			},
+		)
+
+	case member.ContainerType == AuthAccountType &&
+		member.Identifier.Identifier == AuthAccountGetCapabilityField,
+		member.ContainerType == PublicAccountType &&
+			member.Identifier.Identifier == PublicAccountGetCapabilityField:
+
+		checker.recordObtainedCapabilityPath(invocationExpression.Arguments[0].Expression)
+	}
+}
+
+// recordObtainedCapabilityPath records that the capability at the given path might be borrowed,
+// i.e. that a link at the path is used.
+//
+func (checker *Checker) recordObtainedCapabilityPath(expression ast.Expression) {
+
+	// If the path is not known statically,
+	// any capability might be obtained and borrowed
+
+	pathExpression, ok := expression.(*ast.PathExpression)
+	if !ok {
+		checker.obtainsDynamicCapabilityPaths = true
+		return
+	}
+
+	if checker.obtainedCapabilityPaths == nil {
+		checker.obtainedCapabilityPaths = map[string]struct{}{}
+	}
+	checker.obtainedCapabilityPaths[pathExpression.String()] = struct{}{}
+}
+
+// reportUnborrowedLinks reports a hint for each capability link
+// whose capability is never obtained in the program, and can therefore not be borrowed.
+//
+// Public links are usually intended to be borrowed by other programs,
+// so they are only reported if unborrowed public link hints are enabled.
+//
+func (checker *Checker) reportUnborrowedLinks() {
+	if checker.obtainsDynamicCapabilityPaths {
+		return
+	}
+
+	for _, link := range checker.capabilityLinks {
+
+		if link.path.Domain.Identifier == common.PathDomainPublic.Identifier() &&
+			!checker.unborrowedPublicLinkHintsEnabled {
+
+			continue
+		}
+
+		if _, ok := checker.obtainedCapabilityPaths[link.path.String()]; ok {
+			continue
+		}
+
+		checker.hint(
+			&UnborrowedLinkHint{
+				Path:  link.path,
+				Range: ast.NewRangeFromPositioned(link.invocation),
+			},
+		)
+	}
+}
@@ -0,0 +1,93 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2020 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sema
+
+import (
+	"sort"
+
+	"github.com/onflow/cadence/runtime/ast"
+)
+
+// recordMemberDeclaration records the declaration of the given member,
+// if the member is declared with private or contract access,
+// so it can be reported by UnusedDeclarations if it is never referenced.
+//
+func (checker *Checker) recordMemberDeclaration(member *Member, declaration ast.Declaration) {
+	if member.Access != ast.AccessPrivate &&
+		member.Access != ast.AccessContract {
+
+		return
+	}
+
+	if checker.memberDeclarations == nil {
+		checker.memberDeclarations = map[*Member]ast.Declaration{}
+	}
+	checker.memberDeclarations[member] = declaration
+	checker.declaredMembers = append(checker.declaredMembers, member)
+}
+
+// recordMemberReference records that the given member is referenced,
+// e.g. accessed in a member expression.
+//
+func (checker *Checker) recordMemberReference(member *Member) {
+	if member == nil {
+		return
+	}
+
+	if _, ok := checker.memberDeclarations[member]; !ok {
+		return
+	}
+
+	if checker.referencedMembers == nil {
+		checker.referencedMembers = map[*Member]struct{}{}
+	}
+	checker.referencedMembers[member] = struct{}{}
+}
+
+// UnusedDeclarations returns the declarations of the checked program
+// which are never referenced within the program, i.e. dead code.
+//
+// Only fields and functions of composites which are declared
+// with private (`priv` / `access(self)`) or contract (`access(contract)`) access are reported,
+// as these can only be accessed from within the program.
+// Declarations with any other access are conservatively considered used,
+// as they might be accessed by other programs.
+//
+// A member which is only referenced by itself (e.g. a recursive function)
+// is considered used.
+//
+// The declarations are returned in the order they appear in the program.
+//
+func (checker *Checker) UnusedDeclarations() []ast.Declaration {
+	var declarations []ast.Declaration
+
+	for _, member := range checker.declaredMembers {
+		if _, ok := checker.referencedMembers[member]; ok {
+			continue
+		}
+
+		declarations = append(declarations, checker.memberDeclarations[member])
+	}
+
+	sort.SliceStable(declarations, func(i, j int) bool {
+		return declarations[i].StartPosition().Compare(declarations[j].StartPosition()) < 0
+	})
+
+	return declarations
+}
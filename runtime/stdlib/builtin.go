@@ -22,6 +22,7 @@ import (
 	"fmt"
 
 	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/cadence/runtime/errors"
 	"github.com/onflow/cadence/runtime/interpreter"
 	"github.com/onflow/cadence/runtime/sema"
 )
@@ -113,6 +114,120 @@ var PanicFunction = NewStandardLibraryFunction(
 	},
 )
 
+// WeakReferenceFunction
+
+const weakReferenceFunctionDocString = `
+Returns a function which returns the given reference, if the referenced resource still exists, or nil otherwise.
+
+The referenced resource no longer exists if it was destroyed.
+For a reference to a stored resource, the referenced resource no longer exists if it was moved out of storage
+`
+
+var weakReferenceFunctionTypeParameter = &sema.TypeParameter{
+	Name: "T",
+	TypeBound: &sema.ReferenceType{
+		Type: sema.AnyResourceType,
+	},
+}
+
+// WeakReferenceFunctionType is the type of the weak reference function:
+// `fun weakReference<T: &AnyResource>(_ reference: T): ((): T?)`
+//
+var WeakReferenceFunctionType = &sema.FunctionType{
+	TypeParameters: []*sema.TypeParameter{
+		weakReferenceFunctionTypeParameter,
+	},
+	Parameters: []*sema.Parameter{
+		{
+			Label:      sema.ArgumentLabelNotRequired,
+			Identifier: "reference",
+			TypeAnnotation: sema.NewTypeAnnotation(
+				&sema.GenericType{
+					TypeParameter: weakReferenceFunctionTypeParameter,
+				},
+			),
+		},
+	},
+	ReturnTypeAnnotation: sema.NewTypeAnnotation(
+		weakReferenceGetFunctionType(
+			&sema.GenericType{
+				TypeParameter: weakReferenceFunctionTypeParameter,
+			},
+		),
+	),
+}
+
+func weakReferenceGetFunctionType(referenceType sema.Type) *sema.FunctionType {
+	return &sema.FunctionType{
+		ReturnTypeAnnotation: sema.NewTypeAnnotation(
+			&sema.OptionalType{
+				Type: referenceType,
+			},
+		),
+	}
+}
+
+// WeakReferenceFunction is a function which creates a weak reference from a reference to a resource,
+// i.e. a function which returns the reference, or nil if the referenced resource no longer exists.
+//
+// Existing references are not affected, they keep referring to the resource, even after it was destroyed.
+//
+// The function is not part of BuiltinFunctions, so the global name is not reserved by default.
+// Environments may opt in by declaring the function as a predeclared value.
+//
+var WeakReferenceFunction = NewStandardLibraryFunction(
+	"weakReference",
+	WeakReferenceFunctionType,
+	weakReferenceFunctionDocString,
+	func(invocation interpreter.Invocation) interpreter.Value {
+		reference := invocation.Arguments[0]
+
+		referenceType, ok := invocation.TypeParameterTypes.Get(weakReferenceFunctionTypeParameter)
+		if !ok {
+			panic(errors.NewUnreachableError())
+		}
+
+		return interpreter.NewHostFunctionValue(
+			func(invocation interpreter.Invocation) interpreter.Value {
+				if !isLiveReference(invocation.Interpreter, reference) {
+					return interpreter.NilValue{}
+				}
+
+				return interpreter.NewSomeValueNonCopying(reference)
+			},
+			weakReferenceGetFunctionType(referenceType),
+		)
+	},
+)
+
+// isLiveReference returns true if the value referenced by the given reference still exists,
+// i.e. it was not destroyed, and, for references to storage, it is still stored.
+//
+func isLiveReference(inter *interpreter.Interpreter, reference interpreter.Value) bool {
+	var referencedValue *interpreter.Value
+
+	switch reference := reference.(type) {
+	case *interpreter.EphemeralReferenceValue:
+		referencedValue = reference.ReferencedValue()
+
+	case *interpreter.StorageReferenceValue:
+		referencedValue = reference.ReferencedValue(inter)
+
+	default:
+		panic(errors.NewUnreachableError())
+	}
+
+	if referencedValue == nil {
+		return false
+	}
+
+	if resourceKindedValue, ok := (*referencedValue).(interpreter.ResourceKindedValue); ok {
+		return !resourceKindedValue.IsDestroyed()
+	}
+
+	return true
+}
+
 // BuiltinFunctions
 
 var BuiltinFunctions = StandardLibraryFunctions{
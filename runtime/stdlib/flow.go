@@ -128,15 +128,27 @@ var unsafeRandomFunctionType = &sema.FunctionType{
 	),
 }
 
+const getPendingStorageDeltaFunctionDocString = `
+Returns an estimate of the change in storage usage, in bytes,
+caused by the storage writes of the current execution so far
+`
+
+var getPendingStorageDeltaFunctionType = &sema.FunctionType{
+	ReturnTypeAnnotation: sema.NewTypeAnnotation(
+		sema.Int64Type,
+	),
+}
+
 // FlowBuiltinImpls defines the set of functions needed to implement the Flow
 // built-in functions.
 type FlowBuiltinImpls struct {
-	CreateAccount   interpreter.HostFunction
-	GetAccount      interpreter.HostFunction
-	Log             interpreter.HostFunction
-	GetCurrentBlock interpreter.HostFunction
-	GetBlock        interpreter.HostFunction
-	UnsafeRandom    interpreter.HostFunction
+	CreateAccount          interpreter.HostFunction
+	GetAccount             interpreter.HostFunction
+	Log                    interpreter.HostFunction
+	GetCurrentBlock        interpreter.HostFunction
+	GetBlock               interpreter.HostFunction
+	UnsafeRandom           interpreter.HostFunction
+	GetPendingStorageDelta interpreter.HostFunction
 }
 
 // FlowBuiltInFunctions returns a list of standard library functions, bound to
@@ -179,6 +191,12 @@ func FlowBuiltInFunctions(impls FlowBuiltinImpls) StandardLibraryFunctions {
 			unsafeRandomFunctionDocString,
 			impls.UnsafeRandom,
 		),
+		NewStandardLibraryFunction(
+			"getPendingStorageDelta",
+			getPendingStorageDeltaFunctionType,
+			getPendingStorageDeltaFunctionDocString,
+			impls.GetPendingStorageDelta,
+		),
 	}
 }
 
@@ -200,6 +218,9 @@ func DefaultFlowBuiltinImpls() FlowBuiltinImpls {
 		UnsafeRandom: func(invocation interpreter.Invocation) interpreter.Value {
 			return interpreter.UInt64Value(rand.Uint64())
 		},
+		GetPendingStorageDelta: func(invocation interpreter.Invocation) interpreter.Value {
+			return interpreter.Int64Value(0)
+		},
 	}
 }
 
@@ -36,11 +36,35 @@ import (
 type Storage struct {
 	*atree.PersistentSlabStorage
 	// NOTE: temporary, will be refactored to dictionary
-	writes          map[interpreter.StorageKey]atree.Storable
-	readCache       map[interpreter.StorageKey]atree.Storable
-	contractUpdates map[interpreter.StorageKey]atree.Storable
-	Ledger          atree.Ledger
-	reportMetric    func(f func(), report func(metrics Metrics, duration time.Duration))
+	writes           map[interpreter.StorageKey]atree.Storable
+	readCache        map[interpreter.StorageKey]atree.Storable
+	contractUpdates  map[interpreter.StorageKey]atree.Storable
+	Ledger           atree.Ledger
+	reportMetric     func(f func(), report func(metrics Metrics, duration time.Duration))
+	meter            ComputationMeter
+	slabOpRecorder   *SlabOpRecorder
+	readOnly         bool
+	writeAttempted   bool
+	accessedAccounts map[common.Address]struct{}
+	reads            *storageReadCounter
+	// storageUsedCache is the cache of the amounts of storage used by accounts, if any.
+	// The cached amount of an account is invalidated when the account's storage is written
+	storageUsedCache *interpreter.StorageUsedCache
+	// commitParallelism is the number of workers
+	// which encode the modified slabs concurrently when the storage is committed
+	commitParallelism int
+	// pendingStorageDelta is the change in storage usage, in bytes,
+	// caused by the writes and removals staged since the last commit, see PendingStorageDelta
+	pendingStorageDelta int64
+	// storedCapabilities are the capabilities which were transferred into the storage of accounts,
+	// and are validated when the storage is committed, see recordStoredCapability
+	storedCapabilities []*interpreter.CapabilityValue
+	// slabSizes are the sizes of the account slabs, as they were last retrieved or stored,
+	// so the pending storage delta can be updated when the slabs are stored or removed
+	slabSizes map[atree.StorageID]uint32
+	// slabStorageKeys are the keys of the account storage entries which reference retrieved slabs,
+	// see Retrieve
+	slabStorageKeys map[atree.StorageID]interpreter.StorageKey
 }
 
 var _ atree.SlabStorage = &Storage{}
@@ -50,7 +74,56 @@ func NewStorage(
 	ledger atree.Ledger,
 	reportMetric func(f func(), report func(metrics Metrics, duration time.Duration)),
 ) *Storage {
-	ledgerStorage := atree.NewLedgerBaseStorage(ledger)
+	const readOnly = false
+	const keyPrefix = ""
+	return newStorage(ledger, reportMetric, readOnly, keyPrefix)
+}
+
+func newStorage(
+	ledger atree.Ledger,
+	reportMetric func(f func(), report func(metrics Metrics, duration time.Duration)),
+	readOnly bool,
+	keyPrefix string,
+) *Storage {
+	// If the ledger meters computation,
+	// also meter the reads and writes of slabs
+
+	meter, _ := unwrapLedger(ledger).(ComputationMeter)
+
+	// If the storage is namespaced,
+	// all registers, both of values and of slabs, are namespaced
+
+	if keyPrefix != "" {
+		ledger = newNamespacedLedger(ledger, keyPrefix)
+	}
+
+	var slabLedger atree.Ledger = ledger
+	if meter != nil {
+		slabLedger = meteredLedger{
+			Ledger: ledger,
+			meter:  meter,
+		}
+	}
+
+	// Count the reads of slabs, in addition to the reads of values
+
+	reads := &storageReadCounter{}
+
+	slabLedger = readCountingLedger{
+		Ledger:  slabLedger,
+		counter: reads,
+	}
+
+	// If the storage is read-only,
+	// the slab storage must never write to the ledger
+
+	if readOnly {
+		slabLedger = readOnlyLedger{
+			Ledger: slabLedger,
+		}
+	}
+
+	ledgerStorage := atree.NewLedgerBaseStorage(slabLedger)
 	persistentSlabStorage := atree.NewPersistentSlabStorage(
 		ledgerStorage,
 		interpreter.CBOREncMode,
@@ -64,8 +137,206 @@ func NewStorage(
 		writes:                map[interpreter.StorageKey]atree.Storable{},
 		readCache:             map[interpreter.StorageKey]atree.Storable{},
 		contractUpdates:       map[interpreter.StorageKey]atree.Storable{},
+		slabSizes:             map[atree.StorageID]uint32{},
 		reportMetric:          reportMetric,
+		meter:                 meter,
+		readOnly:              readOnly,
+		reads:                 reads,
+		commitParallelism:     runtime.NumCPU(),
+	}
+}
+
+// storageReadCounter counts the registers read from the ledger,
+// and enforces the limit of reads, if any.
+//
+type storageReadCounter struct {
+	count int
+	limit int
+}
+
+func (c *storageReadCounter) recordRead() error {
+	c.count++
+	if c.limit > 0 && c.count > c.limit {
+		return StorageReadLimitExceededError{
+			Count: c.count,
+			Limit: c.limit,
+		}
 	}
+	return nil
+}
+
+// readCountingLedger is a ledger which counts the register reads.
+//
+type readCountingLedger struct {
+	atree.Ledger
+	counter *storageReadCounter
+}
+
+func (l readCountingLedger) GetValue(owner, key []byte) ([]byte, error) {
+	err := l.counter.recordRead()
+	if err != nil {
+		// The exceeded limit is not a failure of the ledger,
+		// so the interpreter recovers it (see interpreter.ExternalAbortError)
+		return nil, err
+	}
+
+	return l.Ledger.GetValue(owner, key)
+}
+
+// SetMaxReads sets the maximum number of registers the storage may read from the ledger.
+// When the limit is exceeded, reads fail with a StorageReadLimitExceededError.
+// Zero means the number of reads is unlimited.
+//
+func (s *Storage) SetMaxReads(limit int) {
+	s.reads.limit = limit
+}
+
+// SetCommitParallelism sets the number of workers
+// which encode the modified slabs concurrently when the storage is committed.
+//
+// Regardless of the parallelism, the encoded slabs are written to the ledger
+// in the same deterministic order.
+// One means the slabs are encoded serially.
+// By default, the number of workers is the number of CPUs.
+//
+func (s *Storage) SetCommitParallelism(parallelism int) {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	s.commitParallelism = parallelism
+}
+
+// Reads returns the number of registers the storage read from the ledger.
+//
+func (s *Storage) Reads() int {
+	return s.reads.count
+}
+
+// meteredLedger is a ledger which meters the computation of register reads and writes.
+//
+type meteredLedger struct {
+	atree.Ledger
+	meter ComputationMeter
+}
+
+func (l meteredLedger) GetValue(owner, key []byte) ([]byte, error) {
+	value, err := l.Ledger.GetValue(owner, key)
+	if err != nil {
+		return nil, err
+	}
+
+	err = l.meter.MeterComputation(ComputationKindStorageRead, uint(len(value)))
+	if err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+func (l meteredLedger) SetValue(owner, key, value []byte) error {
+	err := l.meter.MeterComputation(ComputationKindStorageWrite, uint(len(value)))
+	if err != nil {
+		return err
+	}
+
+	return l.Ledger.SetValue(owner, key, value)
+}
+
+// meterComputation meters the given computation, if the ledger meters computation.
+//
+func (s *Storage) meterComputation(kind ComputationKind, intensity uint) error {
+	if s.meter == nil {
+		return nil
+	}
+
+	var err error
+	wrapPanic(func() {
+		err = s.meter.MeterComputation(kind, intensity)
+	})
+	return err
+}
+
+// TrackAccessedAccounts starts tracking the accounts whose storage is read or written,
+// see AccessedAccounts.
+//
+func (s *Storage) TrackAccessedAccounts() {
+	if s.accessedAccounts == nil {
+		s.accessedAccounts = map[common.Address]struct{}{}
+	}
+}
+
+func (s *Storage) recordAccountAccess(address common.Address) {
+	if s.accessedAccounts == nil {
+		return
+	}
+
+	s.accessedAccounts[address] = struct{}{}
+}
+
+// CacheStorageUsed starts caching the amounts of storage used by accounts,
+// until the storage of the account is written.
+//
+func (s *Storage) CacheStorageUsed() {
+	s.storageUsedCache = interpreter.NewStorageUsedCache()
+}
+
+// StorageUsedCache returns the cache of the amounts of storage used by accounts,
+// or nil if the amounts are not cached.
+//
+func (s *Storage) StorageUsedCache() *interpreter.StorageUsedCache {
+	return s.storageUsedCache
+}
+
+func (s *Storage) invalidateStorageUsed(address common.Address) {
+	if s.storageUsedCache == nil {
+		return
+	}
+
+	s.storageUsedCache.Invalidate(address)
+}
+
+// invalidateSlabOwnerStorageUsed invalidates the cached storage used of the owner of a written slab.
+// Temporary slabs are not stored in any account, so they do not invalidate any cached amount.
+//
+func (s *Storage) invalidateSlabOwnerStorageUsed(id atree.StorageID) {
+	if id.Address == atree.AddressUndefined {
+		return
+	}
+
+	s.invalidateStorageUsed(common.Address(id.Address))
+}
+
+// GenerateStorageID allocates a new storage index in the given account.
+// The host environment stores the next storage index of an account in the account's storage,
+// so the allocation invalidates the cached storage used of the account.
+//
+func (s *Storage) GenerateStorageID(address atree.Address) (atree.StorageID, error) {
+	if address != atree.AddressUndefined {
+		s.invalidateStorageUsed(common.Address(address))
+	}
+
+	return s.PersistentSlabStorage.GenerateStorageID(address)
+}
+
+// AccessedAccounts returns the distinct addresses of the accounts
+// whose storage was read or written since tracking was started (see TrackAccessedAccounts),
+// sorted in ascending order.
+//
+func (s *Storage) AccessedAccounts() []common.Address {
+	addresses := make([]common.Address, 0, len(s.accessedAccounts))
+
+	// NOTE: ranging over maps is safe (deterministic),
+	// if it is side-effect free and the keys are sorted afterwards
+
+	for address := range s.accessedAccounts { //nolint:maprangecheck
+		addresses = append(addresses, address)
+	}
+
+	sort.Slice(addresses, func(i, j int) bool {
+		return bytes.Compare(addresses[i][:], addresses[j][:]) < 0
+	})
+
+	return addresses
 }
 
 // ValueExists returns true if a value exists in account storage.
@@ -76,6 +347,8 @@ func (s *Storage) ValueExists(
 	key string,
 ) bool {
 
+	s.recordAccountAccess(address)
+
 	storageKey := interpreter.StorageKey{
 		Address: address,
 		Key:     key,
@@ -94,8 +367,12 @@ func (s *Storage) ValueExists(
 
 	// Ask interface
 
+	err := s.reads.recordRead()
+	if err != nil {
+		panic(err)
+	}
+
 	var exists bool
-	var err error
 	wrapPanic(func() {
 		exists, err = s.Ledger.ValueExists(address[:], []byte(key))
 	})
@@ -118,6 +395,8 @@ func (s *Storage) ReadValue(
 	key string,
 ) interpreter.OptionalValue {
 
+	s.recordAccountAccess(address)
+
 	storageKey := interpreter.StorageKey{
 		Address: address,
 		Key:     key,
@@ -127,7 +406,7 @@ func (s *Storage) ReadValue(
 	if storable == nil {
 		return interpreter.NilValue{}
 	} else {
-		storedValue := interpreter.StoredValue(storable, s)
+		storedValue := s.storedValue(storageKey, storable)
 		return interpreter.NewSomeValueNonCopying(storedValue)
 	}
 }
@@ -147,8 +426,35 @@ func (s *Storage) readStorable(storageKey interpreter.StorageKey) atree.Storable
 
 	// Load data through the runtime interface
 
+	storedData := s.readStoredData(storageKey)
+
+	// No data, keep fact in cache
+
+	if len(storedData) == 0 {
+		s.readCache[storageKey] = nil
+		return nil
+	}
+
+	// Existing data, decode and keep in cache
+
+	readStorable := s.decodeStoredData(storedData)
+
+	s.readCache[storageKey] = readStorable
+
+	return readStorable
+}
+
+// readStoredData loads the encoded data of the given account storage key
+// through the runtime interface.
+//
+func (s *Storage) readStoredData(storageKey interpreter.StorageKey) []byte {
+
+	err := s.reads.recordRead()
+	if err != nil {
+		panic(err)
+	}
+
 	var storedData []byte
-	var err error
 	wrapPanic(func() {
 		storedData, err = s.Ledger.GetValue(storageKey.Address[:], []byte(storageKey.Key))
 	})
@@ -156,22 +462,24 @@ func (s *Storage) readStorable(storageKey interpreter.StorageKey) atree.Storable
 		panic(err)
 	}
 
-	// No data, keep fact in cache
-
-	if len(storedData) == 0 {
-		s.readCache[storageKey] = nil
-		return nil
+	err = s.meterComputation(ComputationKindStorageRead, uint(len(storedData)))
+	if err != nil {
+		panic(err)
 	}
 
-	// Existing data, decode and keep in cache
+	return storedData
+}
+
+func (s *Storage) decodeStoredData(storedData []byte) atree.Storable {
 
-	var readStorable atree.Storable
+	var storable atree.Storable
+	var err error
 
 	decoder := interpreter.CBORDecMode.NewByteStreamDecoder(storedData)
 
 	s.reportMetric(
 		func() {
-			readStorable, err = interpreter.DecodeStorable(decoder, atree.StorageIDUndefined)
+			storable, err = interpreter.DecodeStorable(decoder, atree.StorageIDUndefined)
 		},
 		func(metrics Metrics, duration time.Duration) {
 			metrics.ValueDecoded(duration)
@@ -181,9 +489,220 @@ func (s *Storage) readStorable(storageKey interpreter.StorageKey) atree.Storable
 		panic(err)
 	}
 
-	s.readCache[storageKey] = readStorable
+	return storable
+}
 
-	return readStorable
+// Reencode decodes the value stored under the given account storage key,
+// and encodes it again in the current encoding format.
+//
+// The account storage register and all slabs reachable from it are re-encoded.
+// If the encoding stored in the ledger differs from the current encoding,
+// the storable or slab is written back, which takes effect when the storage is committed,
+// and true is returned.
+// If no value is stored, or the stored encodings are already current, nothing is written.
+//
+func (s *Storage) Reencode(storageKey interpreter.StorageKey) (changed bool, err error) {
+
+	s.recordAccountAccess(storageKey.Address)
+
+	if s.readOnly {
+		return false, ReadOnlyStorageError{}
+	}
+
+	storedData := s.readStoredData(storageKey)
+	if len(storedData) == 0 {
+		return false, nil
+	}
+
+	storable := s.decodeStoredData(storedData)
+
+	s.readCache[storageKey] = storable
+
+	var buf bytes.Buffer
+	encoder := atree.NewEncoder(&buf, interpreter.CBOREncMode)
+
+	encoded, err := encodeStorable(storable, encoder, &buf)
+	if err != nil {
+		return false, err
+	}
+
+	if !bytes.Equal(encoded, storedData) {
+
+		// Write the decoded storable back,
+		// so it gets encoded in the current format when the storage is committed
+
+		s.invalidateStorageUsed(storageKey.Address)
+
+		s.writes[storageKey] = storable
+
+		changed = true
+	}
+
+	slabsChanged, err := s.reencodeSlabs(storageKey, []atree.Storable{storable})
+	if err != nil {
+		return false, err
+	}
+
+	return changed || slabsChanged, nil
+}
+
+// reencodeSlabs re-encodes the slabs which are reachable from the given storables
+// of the value stored under the given account storage key,
+// and stores the slabs which have a stored encoding that differs from the current encoding,
+// so they get written back when the storage is committed.
+//
+func (s *Storage) reencodeSlabs(
+	storageKey interpreter.StorageKey,
+	storables []atree.Storable,
+) (
+	changed bool,
+	err error,
+) {
+
+	for len(storables) > 0 {
+
+		var nextStorables []atree.Storable
+
+		for _, storable := range storables {
+
+			// Inlined storables might contain references to slabs
+
+			storageIDStorable, ok := storable.(atree.StorageIDStorable)
+			if !ok {
+				nextStorables = append(nextStorables, storable.ChildStorables()...)
+				continue
+			}
+
+			id := atree.StorageID(storageIDStorable)
+
+			slab, found, err := s.PersistentSlabStorage.Retrieve(id)
+			if err != nil {
+				return false, err
+			}
+			if !found {
+				return false, MissingSlabError{
+					StorageID:  id,
+					StorageKey: storageKey,
+				}
+			}
+
+			nextStorables = append(nextStorables, slab.ChildStorables()...)
+
+			encoded, err := atree.Encode(slab, interpreter.CBOREncMode)
+			if err != nil {
+				return false, err
+			}
+
+			if bytes.Equal(encoded, s.readSlabData(id)) {
+				continue
+			}
+
+			err = s.Store(id, slab)
+			if err != nil {
+				return false, err
+			}
+
+			changed = true
+		}
+
+		storables = nextStorables
+	}
+
+	return changed, nil
+}
+
+// readSlabData returns the data stored in the ledger for the slab with the given ID,
+// i.e. the data of the slab before it was modified in this storage, if at all.
+//
+func (s *Storage) readSlabData(id atree.StorageID) []byte {
+	return s.readStoredData(
+		interpreter.StorageKey{
+			Address: common.Address(id.Address),
+			Key:     string(atree.SlabIndexToLedgerKey(id.Index)),
+		},
+	)
+}
+
+// Retrieve returns the slab with the given ID.
+//
+// Slabs are only retrieved when they are referenced,
+// so if the slab does not exist, e.g. because the stored data is corrupted,
+// it returns a MissingSlabError, like for the slabs of account storage values (see storedValue).
+// The error aborts the execution (see interpreter.ExternalAbortError).
+//
+// The slabs of account storage values are associated with the key of their account storage entry,
+// and the association is carried down to the child slabs of retrieved slabs,
+// so the error includes the storage key for missing nested slabs as well.
+//
+func (s *Storage) Retrieve(id atree.StorageID) (atree.Slab, bool, error) {
+	slab, found, err := s.PersistentSlabStorage.Retrieve(id)
+	if err != nil {
+		return nil, false, err
+	}
+
+	storageKey, ok := s.slabStorageKeys[id]
+
+	if !found {
+		return nil, false, MissingSlabError{
+			StorageID:  id,
+			StorageKey: storageKey,
+		}
+	}
+
+	s.recordRetrievedSlabSize(id, slab)
+
+	if ok {
+		for _, childStorable := range slab.ChildStorables() {
+			childStorageIDStorable, ok := childStorable.(atree.StorageIDStorable)
+			if !ok {
+				continue
+			}
+
+			s.recordSlabStorageKey(atree.StorageID(childStorageIDStorable), storageKey)
+		}
+	}
+
+	return slab, true, nil
+}
+
+// recordSlabStorageKey associates the slab with the given ID
+// with the key of the account storage entry which (transitively) references it.
+//
+func (s *Storage) recordSlabStorageKey(id atree.StorageID, storageKey interpreter.StorageKey) {
+	if s.slabStorageKeys == nil {
+		s.slabStorageKeys = map[atree.StorageID]interpreter.StorageKey{}
+	}
+	s.slabStorageKeys[id] = storageKey
+}
+
+// storedValue returns the value for the given storable,
+// which is stored under the given account storage key.
+//
+// If the storable references a slab which does not exist,
+// e.g. because the stored data is corrupted,
+// it panics with a MissingSlabError.
+//
+func (s *Storage) storedValue(storageKey interpreter.StorageKey, storable atree.Storable) interpreter.Value {
+
+	if storageIDStorable, ok := storable.(atree.StorageIDStorable); ok {
+		storageID := atree.StorageID(storageIDStorable)
+
+		_, found, err := s.PersistentSlabStorage.Retrieve(storageID)
+		if err != nil {
+			panic(err)
+		}
+
+		if !found {
+			panic(MissingSlabError{
+				StorageID:  storageID,
+				StorageKey: storageKey,
+			})
+		}
+
+		s.recordSlabStorageKey(storageID, storageKey)
+	}
+
+	return interpreter.StoredValue(storable, s)
 }
 
 func (s *Storage) WriteValue(
@@ -192,6 +711,15 @@ func (s *Storage) WriteValue(
 	key string,
 	value interpreter.OptionalValue,
 ) {
+	s.recordAccountAccess(address)
+
+	if s.readOnly {
+		s.writeAttempted = true
+		return
+	}
+
+	s.invalidateStorageUsed(address)
+
 	storageKey := interpreter.StorageKey{
 		Address: address,
 		Key:     key,
@@ -201,7 +729,7 @@ func (s *Storage) WriteValue(
 
 	existingStorable := s.readStorable(storageKey)
 	if existingStorable != nil {
-		interpreter.StoredValue(existingStorable, s).
+		s.storedValue(storageKey, existingStorable).
 			DeepRemove(inter)
 		inter.RemoveReferencedSlab(existingStorable)
 	}
@@ -236,6 +764,16 @@ func (s *Storage) WriteValue(
 		panic(errors.NewUnreachableError())
 	}
 
+	// The new value replaces the existing value, if any,
+	// which is either the stored value, or a previously staged value
+
+	if newStorable != nil {
+		s.pendingStorageDelta += int64(newStorable.ByteSize())
+	}
+	if existingStorable != nil {
+		s.pendingStorageDelta -= int64(existingStorable.ByteSize())
+	}
+
 	// Only write locally.
 	// The value is eventually written back through the runtime interface in `Commit`.
 
@@ -248,6 +786,13 @@ func (s *Storage) recordContractUpdate(
 	key string,
 	contract interpreter.Value,
 ) {
+	s.recordAccountAccess(address)
+
+	if s.readOnly {
+		s.writeAttempted = true
+		return
+	}
+
 	storageKey := interpreter.StorageKey{
 		Address: address,
 		Key:     key,
@@ -257,7 +802,7 @@ func (s *Storage) recordContractUpdate(
 
 	existingStorable, ok := s.contractUpdates[storageKey]
 	if ok {
-		interpreter.StoredValue(existingStorable, s).
+		s.storedValue(storageKey, existingStorable).
 			DeepRemove(inter)
 		inter.RemoveReferencedSlab(existingStorable)
 	}
@@ -287,18 +832,75 @@ func (s *Storage) recordContractUpdate(
 	}
 }
 
+// PendingStorageDelta returns an estimate of the change in storage usage, in bytes,
+// caused by the writes staged in this storage,
+// i.e. relative to the values which were stored before the execution.
+//
+// The estimate is based on the sizes of the storables of the written values,
+// and the sizes of the slabs which were stored or removed,
+// compared to the sizes of the previously stored storables and slabs.
+//
+// The delta is updated when writes and removals are staged,
+// so getting it does not read from the ledger or encode slabs.
+//
+func (s *Storage) PendingStorageDelta() int64 {
+	return s.pendingStorageDelta
+}
+
 type AccountStorageEntry struct {
 	StorageKey       interpreter.StorageKey
 	Storable         atree.Storable
 	IsContractUpdate bool
 }
 
-// TODO: bring back concurrent encoding
-// Commit serializes/saves all values in the readCache in storage (through the runtime interface).
+// Commit writes the written values, and if requested the contract updates, to the ledger
+// (through the runtime interface), and commits the modified slabs.
+//
+// The values are encoded serially, with one encoder (see encodeAccountStorageEntries),
+// and the slabs are encoded concurrently (see SetCommitParallelism).
+//
+// The commit is not atomic: The values are written in order of their storage keys,
+// followed by the slabs. If a write fails, e.g. because the ledger of an account is unavailable,
+// the writes before it remain in the ledger. The host must discard all writes
+// of a failed execution, like for any other error.
+//
+// If contract updates are committed, i.e. the storage of the execution is finally committed,
+// the capabilities which were stored during the execution are validated first (see recordStoredCapability).
+// The storage is also committed during the execution without contract updates, e.g. to get the storage used,
+// but stored capabilities might only be linked later in the execution.
 //
 func (s *Storage) Commit(inter *interpreter.Interpreter, commitContractUpdates bool) error {
 
-	var accountStorageEntries []AccountStorageEntry
+	// A read-only storage never writes to the ledger
+
+	if s.readOnly {
+		if s.writeAttempted {
+			return ReadOnlyStorageError{}
+		}
+		return nil
+	}
+
+	if commitContractUpdates && len(s.storedCapabilities) > 0 {
+		err := s.validateStoredCapabilities(inter)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Contract updates are only committed if requested.
+	// Plain writes need no further removals, as their existing values were already removed
+	// when the values were written.
+	// All entries are committed the same way, the only optimization is that they are encoded
+	// using one encoder, whose buffer is reused (see encodeAccountStorageEntries).
+
+	hasContractUpdates := commitContractUpdates && len(s.contractUpdates) > 0
+
+	entryCount := len(s.writes)
+	if hasContractUpdates {
+		entryCount += len(s.contractUpdates)
+	}
+
+	accountStorageEntries := make([]AccountStorageEntry, 0, entryCount)
 
 	// NOTE: ranging over maps is safe (deterministic),
 	// if it is side-effect free and the keys are sorted afterwards
@@ -318,7 +920,7 @@ func (s *Storage) Commit(inter *interpreter.Interpreter, commitContractUpdates b
 	// Second, if enabled,
 	// write all contract updates (which were delayed and not observable)
 
-	if commitContractUpdates {
+	if hasContractUpdates {
 		for storageKey, storable := range s.contractUpdates { //nolint:maprangecheck
 			accountStorageEntries = append(
 				accountStorageEntries,
@@ -335,60 +937,43 @@ func (s *Storage) Commit(inter *interpreter.Interpreter, commitContractUpdates b
 
 	SortAccountStorageEntries(accountStorageEntries)
 
-	// Write account storage entries in order
+	// If an account storage change is a contract update,
+	// and it is overwriting an existing contract value,
+	// it must be properly removed first:
+	// The removal did not occur during execution,
+	// because contract updates are deferred to the commit
 
-	// TODO: bring back concurrent encoding
 	for _, entry := range accountStorageEntries {
-
-		storageKey := entry.StorageKey
-		storable := entry.Storable
-
-		address := storageKey.Address
-
-		// If the account storage change is a contract update,
-		// and it is overwriting an existing contract value,
-		// it must be properly removed first:
-		// The removal did not occur during execution,
-		// because contract updates are deferred to the commit
-
-		if entry.IsContractUpdate {
-			existingStorable := s.readStorable(storageKey)
-			if existingStorable != nil {
-				interpreter.StoredValue(existingStorable, s).
-					DeepRemove(inter)
-				inter.RemoveReferencedSlab(existingStorable)
-			}
+		if !entry.IsContractUpdate {
+			continue
 		}
 
-		var encoded []byte
+		existingStorable := s.readStorable(entry.StorageKey)
+		if existingStorable != nil {
+			s.storedValue(entry.StorageKey, existingStorable).
+				DeepRemove(inter)
+			inter.RemoveReferencedSlab(existingStorable)
+		}
+	}
 
-		if storable != nil {
-			var err error
+	encodedEntries, err := s.encodeAccountStorageEntries(accountStorageEntries)
+	if err != nil {
+		return err
+	}
 
-			var buf bytes.Buffer
-			encoder := atree.NewEncoder(&buf, interpreter.CBOREncMode)
+	// Write account storage entries in order
 
-			s.reportMetric(
-				func() {
-					err = storable.Encode(encoder)
-				},
-				func(metrics Metrics, duration time.Duration) {
-					metrics.ValueEncoded(duration)
-				},
-			)
-			if err != nil {
-				return err
-			}
+	for i, entry := range accountStorageEntries {
 
-			err = encoder.CBOR.Flush()
-			if err != nil {
-				return err
-			}
+		storageKey := entry.StorageKey
+		address := storageKey.Address
+		encoded := encodedEntries[i]
 
-			encoded = buf.Bytes()
+		err := s.meterComputation(ComputationKindStorageWrite, uint(len(encoded)))
+		if err != nil {
+			return err
 		}
 
-		var err error
 		wrapPanic(func() {
 			err = s.Ledger.SetValue(
 				address[:],
@@ -404,7 +989,101 @@ func (s *Storage) Commit(inter *interpreter.Interpreter, commitContractUpdates b
 	// Commit the underlying slab storage's writes
 
 	// TODO: report encoding metric for all encoded slabs
-	return s.PersistentSlabStorage.FastCommit(runtime.NumCPU())
+	err = s.PersistentSlabStorage.FastCommit(s.commitParallelism)
+	if err != nil {
+		return err
+	}
+
+	// The sizes of the slabs are kept, as they are now the sizes of the stored slabs
+
+	s.pendingStorageDelta = 0
+
+	return nil
+}
+
+// encodeAccountStorageEntries encodes the storables of the given account storage entries,
+// and returns the encoded data in the order of the entries.
+// The data is nil for entries without a storable, i.e. for removals.
+//
+func (s *Storage) encodeAccountStorageEntries(entries []AccountStorageEntry) ([][]byte, error) {
+
+	encodedEntries := make([][]byte, len(entries))
+
+	// The encoder and its buffer are shared by all entries,
+	// the encoded data of each entry is copied out of the buffer
+
+	var buf bytes.Buffer
+	encoder := atree.NewEncoder(&buf, interpreter.CBOREncMode)
+
+	for i, entry := range entries {
+		if entry.Storable == nil {
+			continue
+		}
+
+		var err error
+		s.reportMetric(
+			func() {
+				encodedEntries[i], err = encodeStorable(entry.Storable, encoder, &buf)
+			},
+			func(metrics Metrics, duration time.Duration) {
+				metrics.ValueEncoded(duration)
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return encodedEntries, nil
+}
+
+// encodeStorable encodes the given storable using the given encoder, which writes to the given buffer,
+// and returns a copy of the encoded data. The buffer is reset.
+// The data is nil if the storable is nil.
+//
+func encodeStorable(storable atree.Storable, encoder *atree.Encoder, buf *bytes.Buffer) ([]byte, error) {
+	if storable == nil {
+		return nil, nil
+	}
+
+	err := storable.Encode(encoder)
+	if err != nil {
+		return nil, err
+	}
+
+	err = encoder.CBOR.Flush()
+	if err != nil {
+		return nil, err
+	}
+
+	encoded := make([]byte, buf.Len())
+	copy(encoded, buf.Bytes())
+	buf.Reset()
+
+	return encoded, nil
+}
+
+// Rollback discards all changes since the last commit,
+// i.e. the pending writes, the pending contract updates, and the modified and temporary slabs,
+// and returns the storage to its last-committed state.
+//
+// A subsequent commit produces no writes, as if the storage was never modified.
+// Storage indices which were already allocated in the ledger are not reclaimed.
+//
+func (s *Storage) Rollback() {
+	s.writes = map[interpreter.StorageKey]atree.Storable{}
+	s.contractUpdates = map[interpreter.StorageKey]atree.Storable{}
+	s.writeAttempted = false
+
+	// Cached storables and slabs might have been modified in-place,
+	// so they must be read from the ledger again
+
+	s.readCache = map[interpreter.StorageKey]atree.Storable{}
+	s.pendingStorageDelta = 0
+	s.slabSizes = map[atree.StorageID]uint32{}
+	s.storedCapabilities = nil
+	s.PersistentSlabStorage.DropDeltas()
+	s.PersistentSlabStorage.DropCache()
 }
 
 func SortAccountStorageEntries(entries []AccountStorageEntry) {
@@ -416,8 +1095,10 @@ func SortAccountStorageEntries(entries []AccountStorageEntry) {
 }
 
 func (s *Storage) CheckHealth() error {
-	// Check slab storage health
-	rootSlabIDs, err := atree.CheckStorageHealth(s, -1)
+	// Check slab storage health.
+	// NOTE: check the underlying slab storage directly,
+	// so missing slabs are returned as errors, instead of panicking (see Retrieve)
+	rootSlabIDs, err := atree.CheckStorageHealth(s.PersistentSlabStorage, -1)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,222 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2020 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runtime
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/onflow/atree"
+
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/cadence/runtime/interpreter"
+)
+
+// StorageCommitShape describes the shape of the values which are written to storage
+// before a storage commit is benchmarked using BenchmarkStorageCommitShapes.
+//
+// Each account storage value is an array of integers,
+// which is nested in dictionaries of the given size, to the given depth.
+// For example, a shape with a dictionary size of 2 and a nesting depth of 2
+// results in values like `{"0": {"0": [0, 1], "1": [0, 1]}, "1": {"0": [0, 1], "1": [0, 1]}}`.
+//
+// Note that the number of arrays grows exponentially with the nesting depth.
+//
+type StorageCommitShape struct {
+	Name string
+	// StorageItemCount is the number of account storage values
+	StorageItemCount int
+	// ArrayElementCount is the number of integers in each (innermost) array
+	ArrayElementCount int
+	// DictionarySize is the number of entries of each dictionary
+	DictionarySize int
+	// NestingDepth is the number of dictionaries each array is nested in
+	NestingDepth int
+}
+
+// StorageCommitShapes are the default shapes benchmarked by BenchmarkStorageCommitShapes
+//
+var StorageCommitShapes = []StorageCommitShape{
+	{
+		Name:              "flat",
+		StorageItemCount:  100,
+		ArrayElementCount: 100,
+	},
+	{
+		Name:              "many small",
+		StorageItemCount:  1000,
+		ArrayElementCount: 1,
+	},
+	{
+		Name:              "shallow wide",
+		StorageItemCount:  10,
+		ArrayElementCount: 100,
+		DictionarySize:    100,
+		NestingDepth:      1,
+	},
+	{
+		Name:              "deep narrow",
+		StorageItemCount:  10,
+		ArrayElementCount: 1,
+		DictionarySize:    1,
+		NestingDepth:      50,
+	},
+}
+
+// BenchmarkStorageCommitShapes benchmarks the commit of a storage
+// for each of the given value shapes, in a sub-benchmark named after the shape.
+//
+// For each iteration, a new storage with a new ledger is created,
+// and the values are written to it. Only the commit is measured.
+//
+func BenchmarkStorageCommitShapes(
+	b *testing.B,
+	shapes []StorageCommitShape,
+	newLedger func() atree.Ledger,
+) {
+	for _, shape := range shapes {
+		shape := shape
+
+		b.Run(shape.Name, func(b *testing.B) {
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+
+				storage, inter := newStorageWithShape(b, shape, newLedger())
+
+				b.StartTimer()
+
+				const commitContractUpdates = false
+				err := storage.Commit(inter, commitContractUpdates)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func newStorageWithShape(
+	b *testing.B,
+	shape StorageCommitShape,
+	ledger atree.Ledger,
+) (
+	*Storage,
+	*interpreter.Interpreter,
+) {
+	storage := NewStorage(
+		ledger,
+		func(f func(), _ func(metrics Metrics, duration time.Duration)) {
+			f()
+		},
+	)
+
+	inter, err := interpreter.NewInterpreter(
+		nil,
+		common.StringLocation("benchmark"),
+		interpreter.WithStorage(storage),
+	)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	address := common.BytesToAddress([]byte{0x1})
+
+	for i := 0; i < shape.StorageItemCount; i++ {
+		value := newValueWithShape(inter, shape, address)
+
+		key := interpreter.PathToStorageKey(interpreter.PathValue{
+			Domain:     common.PathDomainStorage,
+			Identifier: strconv.Itoa(i),
+		})
+
+		storage.WriteValue(
+			inter,
+			address,
+			key,
+			interpreter.NewSomeValueNonCopying(value),
+		)
+	}
+
+	return storage, inter
+}
+
+func newValueWithShape(
+	inter *interpreter.Interpreter,
+	shape StorageCommitShape,
+	address common.Address,
+) interpreter.Value {
+
+	var newValue func(depth int) interpreter.Value
+	newValue = func(depth int) interpreter.Value {
+
+		if depth == 0 {
+			elements := make([]interpreter.Value, shape.ArrayElementCount)
+			for i := range elements {
+				elements[i] = interpreter.NewIntValueFromInt64(int64(i))
+			}
+
+			return interpreter.NewArrayValue(
+				inter,
+				storageCommitShapeStaticType(0).(interpreter.ArrayStaticType),
+				address,
+				elements...,
+			)
+		}
+
+		keysAndValues := make([]interpreter.Value, 0, shape.DictionarySize*2)
+		for i := 0; i < shape.DictionarySize; i++ {
+			keysAndValues = append(
+				keysAndValues,
+				interpreter.NewStringValue(strconv.Itoa(i)),
+				newValue(depth-1),
+			)
+		}
+
+		return interpreter.NewDictionaryValueWithAddress(
+			inter,
+			storageCommitShapeStaticType(depth).(interpreter.DictionaryStaticType),
+			address,
+			keysAndValues...,
+		)
+	}
+
+	return newValue(shape.NestingDepth)
+}
+
+// storageCommitShapeStaticType returns the static type of the values
+// of a storage commit shape with the given nesting depth:
+// An array of integers, nested in the given number of dictionaries.
+//
+func storageCommitShapeStaticType(depth int) interpreter.StaticType {
+	var staticType interpreter.StaticType = interpreter.VariableSizedStaticType{
+		Type: interpreter.PrimitiveStaticTypeInt,
+	}
+
+	for i := 0; i < depth; i++ {
+		staticType = interpreter.DictionaryStaticType{
+			KeyType:   interpreter.PrimitiveStaticTypeString,
+			ValueType: staticType,
+		}
+	}
+
+	return staticType
+}
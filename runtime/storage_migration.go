@@ -0,0 +1,172 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2020 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runtime
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/cadence/runtime/interpreter"
+)
+
+// ValueMigration is a function which migrates a stored value.
+//
+// It is called with the path and the stored value, and returns the migrated value,
+// and true if the stored value should be replaced with the migrated value.
+// A nil migrated value removes the stored value.
+//
+type ValueMigration func(path cadence.Path, value interpreter.Value) (interpreter.Value, bool)
+
+// MigrateValues migrates all values stored in the given account, e.g. after a contract update.
+//
+// The given function is called for each stored value, in order of the storage keys of the paths,
+// and the stored value is replaced by the returned value, if the function returns true.
+//
+// The migrated value may be of a different type than the stored value.
+// It may be the stored value itself, modified in-place, a new value, or a new value containing the stored value:
+// The migrated value is deep-copied into the account, and then the stored value and its slabs are removed.
+// New values should be created without an owner, so their temporary slabs are not committed.
+//
+// Both the values which are committed to the ledger and the values written, but not yet committed, are migrated.
+// The migrated values are written to the ledger when the storage is committed.
+//
+// The ledger of the storage must implement AccountRegisterIterator.
+//
+func (s *Storage) MigrateValues(owner common.Address, migrate ValueMigration) (err error) {
+
+	s.recordAccountAccess(owner)
+
+	if s.readOnly {
+		return ReadOnlyStorageError{}
+	}
+
+	iterator, ok := unwrapLedger(s.Ledger).(AccountRegisterIterator)
+	if !ok {
+		return fmt.Errorf(
+			"cannot migrate values: ledger does not support iterating over account registers",
+		)
+	}
+
+	// Gather the storage keys of all stored values,
+	// i.e. of the committed values and of the written values
+
+	keys := map[string]struct{}{}
+
+	wrapPanic(func() {
+		err = iterator.ForEachAccountRegister(
+			owner[:],
+			func(key []byte, value []byte) error {
+				// Empty registers do not exist
+				if len(value) == 0 {
+					return nil
+				}
+
+				keys[string(key)] = struct{}{}
+				return nil
+			},
+		)
+	})
+	if err != nil {
+		return err
+	}
+
+	// NOTE: ranging over maps is safe (deterministic),
+	// if it is side-effect free and the keys are sorted afterwards
+
+	for storageKey := range s.writes { //nolint:maprangecheck
+		if storageKey.Address == owner {
+			keys[storageKey.Key] = struct{}{}
+		}
+	}
+
+	sortedKeys := make([]string, 0, len(keys))
+
+	for key := range keys { //nolint:maprangecheck
+		sortedKeys = append(sortedKeys, key)
+	}
+
+	sort.Strings(sortedKeys)
+
+	inter, err := interpreter.NewInterpreter(
+		nil,
+		common.StringLocation("migration"),
+		interpreter.WithStorage(s),
+	)
+	if err != nil {
+		return err
+	}
+
+	// Recover internal panics and return them as an error.
+	// For example, a stored value might not be decodable
+
+	defer inter.RecoverErrors(func(internalErr error) {
+		err = internalErr
+	})
+
+	for _, key := range sortedKeys {
+
+		// Only values stored at paths are migrated, e.g. contracts are not
+
+		domain, identifier, ok := interpreter.PathForStorageKey(key)
+		if !ok {
+			continue
+		}
+
+		// The value might have been removed, but the removal was not committed yet
+
+		storedValue, ok := s.ReadValue(inter, owner, key).(*interpreter.SomeValue)
+		if !ok {
+			continue
+		}
+
+		path := exportPathValue(interpreter.PathValue{
+			Domain:     domain,
+			Identifier: identifier,
+		})
+
+		migratedValue, ok := migrate(path, storedValue.Value)
+		if !ok {
+			continue
+		}
+
+		if migratedValue == nil {
+			s.WriteValue(inter, owner, key, interpreter.NilValue{})
+			continue
+		}
+
+		// Copy the migrated value before the stored value is removed,
+		// as the migrated value might be or contain the stored value
+
+		migratedValue, err = interpreter.DeepCopy(inter, migratedValue, owner)
+		if err != nil {
+			return err
+		}
+
+		s.WriteValue(
+			inter,
+			owner,
+			key,
+			interpreter.NewSomeValueNonCopying(migratedValue),
+		)
+	}
+
+	return nil
+}
@@ -0,0 +1,210 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2020 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runtime
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/onflow/atree"
+
+	"github.com/onflow/cadence/runtime/interpreter"
+)
+
+// NewNamespacedStorage returns a new storage, which namespaces all registers
+// it reads from and writes to the given ledger with the given key prefix,
+// e.g. to let multiple independent runtimes share one ledger without collisions.
+//
+// The storage indices of new slabs are also allocated per namespace,
+// so the registers written in one namespace do not depend on the use of other namespaces.
+//
+// The key prefix must not contain interpreter.StorageKeySeparator,
+// which separates the prefix from the keys,
+// otherwise an InvalidStorageKeyPrefixError is returned.
+//
+func NewNamespacedStorage(
+	ledger atree.Ledger,
+	reportMetric func(f func(), report func(metrics Metrics, duration time.Duration)),
+	keyPrefix string,
+) (*Storage, error) {
+	err := validateStorageKeyPrefix(keyPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	const readOnly = false
+	return newStorage(ledger, reportMetric, readOnly, keyPrefix), nil
+}
+
+// validateStorageKeyPrefix returns an InvalidStorageKeyPrefixError
+// if the given key prefix contains interpreter.StorageKeySeparator.
+// Such a prefix would be ambiguous, as keys of one namespace could collide with keys of another.
+//
+func validateStorageKeyPrefix(keyPrefix string) error {
+	if strings.Contains(keyPrefix, interpreter.StorageKeySeparator) {
+		return InvalidStorageKeyPrefixError{
+			KeyPrefix: keyPrefix,
+		}
+	}
+
+	return nil
+}
+
+// namespacedStorageIndexKey is the key of the register which stores
+// the last allocated storage index of an account in a namespace.
+//
+const namespacedStorageIndexKey = "storage_index"
+
+// namespacedLedger is a ledger which prefixes all register keys with a namespace.
+//
+type namespacedLedger struct {
+	atree.Ledger
+	// prefix is the key prefix of the namespace, including the separator
+	prefix string
+}
+
+func newNamespacedLedger(ledger atree.Ledger, keyPrefix string) namespacedLedger {
+	return namespacedLedger{
+		Ledger: ledger,
+		prefix: keyPrefix + interpreter.StorageKeySeparator,
+	}
+}
+
+var _ atree.Ledger = namespacedLedger{}
+var _ AccountRegisterIterator = namespacedLedger{}
+var _ StorageIndexAdvancer = namespacedLedger{}
+
+func (l namespacedLedger) key(key []byte) []byte {
+	return []byte(l.prefix + string(key))
+}
+
+func (l namespacedLedger) GetValue(owner, key []byte) ([]byte, error) {
+	return l.Ledger.GetValue(owner, l.key(key))
+}
+
+func (l namespacedLedger) SetValue(owner, key, value []byte) error {
+	return l.Ledger.SetValue(owner, l.key(key), value)
+}
+
+func (l namespacedLedger) ValueExists(owner, key []byte) (bool, error) {
+	return l.Ledger.ValueExists(owner, l.key(key))
+}
+
+// AllocateStorageIndex allocates the storage index sequentially in the namespace,
+// instead of using the allocation of the underlying ledger, which is shared by all namespaces.
+//
+func (l namespacedLedger) AllocateStorageIndex(owner []byte) (atree.StorageIndex, error) {
+	key := []byte(namespacedStorageIndexKey)
+
+	var result atree.StorageIndex
+
+	value, err := l.GetValue(owner, key)
+	if err != nil {
+		return result, err
+	}
+
+	var index uint64
+	if len(value) > 0 {
+		index = binary.BigEndian.Uint64(value)
+	}
+	index++
+
+	binary.BigEndian.PutUint64(result[:], index)
+
+	err = l.SetValue(owner, key, result[:])
+	if err != nil {
+		return atree.StorageIndex{}, err
+	}
+
+	return result, nil
+}
+
+// AdvanceStorageIndex advances the sequential allocation of storage indices in the namespace.
+//
+func (l namespacedLedger) AdvanceStorageIndex(owner []byte, index atree.StorageIndex) error {
+	key := []byte(namespacedStorageIndexKey)
+
+	value, err := l.GetValue(owner, key)
+	if err != nil {
+		return err
+	}
+
+	if len(value) > 0 && bytes.Compare(value, index[:]) >= 0 {
+		return nil
+	}
+
+	return l.SetValue(owner, key, index[:])
+}
+
+// accountRegisterIterator returns the ledger of the runtime interface of the given context
+// as an AccountRegisterIterator, if it implements it.
+// If the storage is namespaced, the returned iterator only iterates over the registers in the namespace.
+// An error is returned if the key prefix of the namespace is invalid.
+//
+func accountRegisterIterator(context Context) (AccountRegisterIterator, bool, error) {
+	runtimeInterface := unwrapInterface(context.Interface)
+
+	iterator, ok := runtimeInterface.(AccountRegisterIterator)
+	if !ok {
+		return nil, false, nil
+	}
+
+	if context.StorageKeyPrefix != "" {
+		err := validateStorageKeyPrefix(context.StorageKeyPrefix)
+		if err != nil {
+			return nil, false, err
+		}
+
+		iterator = newNamespacedLedger(runtimeInterface, context.StorageKeyPrefix)
+	}
+
+	return iterator, true, nil
+}
+
+// ForEachAccountRegister iterates over the registers of the account in the namespace,
+// with the keys stripped of the namespace prefix.
+// The register storing the allocated storage index of the namespace is not included.
+//
+func (l namespacedLedger) ForEachAccountRegister(owner []byte, f func(key, value []byte) error) error {
+	iterator, ok := unwrapLedger(l.Ledger).(AccountRegisterIterator)
+	if !ok {
+		return fmt.Errorf(
+			"cannot iterate over account registers: ledger does not support iterating over account registers",
+		)
+	}
+
+	return iterator.ForEachAccountRegister(
+		owner,
+		func(key, value []byte) error {
+			if !strings.HasPrefix(string(key), l.prefix) {
+				return nil
+			}
+
+			key = key[len(l.prefix):]
+			if string(key) == namespacedStorageIndexKey {
+				return nil
+			}
+
+			return f(key, value)
+		},
+	)
+}
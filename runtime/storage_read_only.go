@@ -0,0 +1,72 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2020 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runtime
+
+import (
+	"time"
+
+	"github.com/onflow/atree"
+)
+
+// NewReadOnlyStorage returns a new storage which never writes to the given ledger,
+// e.g. for executing scripts, which must not mutate state.
+//
+// Writes to the storage are not performed, but recorded:
+// Commit returns a ReadOnlyStorageError if any write was attempted.
+//
+// Writes which cannot be deferred to the commit,
+// i.e. the allocation of storage indices for new values in accounts,
+// are rejected immediately with a ReadOnlyStorageError.
+//
+func NewReadOnlyStorage(
+	ledger atree.Ledger,
+	reportMetric func(f func(), report func(metrics Metrics, duration time.Duration)),
+) *Storage {
+	const readOnly = true
+	const keyPrefix = ""
+	return newStorage(ledger, reportMetric, readOnly, keyPrefix)
+}
+
+// recordSlabWrite records the write of the slab with the given ID,
+// if the storage is read-only.
+//
+// Temporary slabs, i.e. slabs which are not owned by an account,
+// are never written to the ledger, so writing them is allowed.
+//
+func (s *Storage) recordSlabWrite(id atree.StorageID) {
+	if !s.readOnly || id.Address == atree.AddressUndefined {
+		return
+	}
+
+	s.writeAttempted = true
+}
+
+// readOnlyLedger is a ledger which rejects all writes.
+//
+type readOnlyLedger struct {
+	atree.Ledger
+}
+
+func (readOnlyLedger) SetValue(_, _, _ []byte) error {
+	return ReadOnlyStorageError{}
+}
+
+func (readOnlyLedger) AllocateStorageIndex(_ []byte) (atree.StorageIndex, error) {
+	return atree.StorageIndex{}, ReadOnlyStorageError{}
+}
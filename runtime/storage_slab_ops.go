@@ -0,0 +1,274 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2020 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runtime
+
+import (
+	"github.com/onflow/atree"
+)
+
+// SlabOpKind is the kind of a slab operation recorded by a SlabOpRecorder.
+//
+type SlabOpKind uint8
+
+const (
+	SlabOpUnknown SlabOpKind = iota
+	// SlabOpCreate is the storing of a new slab,
+	// e.g. when a container is created, or when a slab is split
+	SlabOpCreate
+	// SlabOpUpdate is the storing of an existing slab
+	SlabOpUpdate
+	// SlabOpRemove is the removal of a slab,
+	// e.g. when a container is removed, or when slabs are merged
+	SlabOpRemove
+	// SlabOpSplit is the split of a slab of a container into two slabs.
+	// It is recorded in addition to the creation and updates of the involved slabs,
+	// with the ID of the parent slab of the split slabs
+	SlabOpSplit
+	// SlabOpMerge is the merge of a slab of a container into its sibling.
+	// It is recorded in addition to the removal of the merged slab,
+	// with the ID of the merged slab
+	SlabOpMerge
+)
+
+// SlabOp is a slab operation recorded by a SlabOpRecorder.
+//
+type SlabOp struct {
+	Kind      SlabOpKind
+	StorageID atree.StorageID
+}
+
+// SlabOpRecorder records the slab operations performed on a storage.
+// It is intended to be used in tests, to assert the layout behaviour of containers.
+//
+type SlabOpRecorder struct {
+	Ops []SlabOp
+	// createdSlabs are the IDs of the slabs which were created,
+	// but are not yet a child of a stored slab
+	createdSlabs map[atree.StorageID]struct{}
+}
+
+// Count returns the number of recorded operations of the given kind.
+//
+func (r *SlabOpRecorder) Count(kind SlabOpKind) int {
+	var count int
+	for _, op := range r.Ops {
+		if op.Kind == kind {
+			count++
+		}
+	}
+	return count
+}
+
+// Reset removes all recorded operations.
+//
+func (r *SlabOpRecorder) Reset() {
+	r.Ops = nil
+	r.createdSlabs = nil
+}
+
+func (r *SlabOpRecorder) record(kind SlabOpKind, id atree.StorageID) {
+	r.Ops = append(
+		r.Ops,
+		SlabOp{
+			Kind:      kind,
+			StorageID: id,
+		},
+	)
+}
+
+// recordStore records the storing of the given slab.
+//
+// Splitting a slab creates a new slab, and stores the parent slab, which now also has the new slab as a child.
+// So a split is recorded when an existing meta data slab is stored with a newly created child.
+// New meta data slabs, e.g. of containers constructed from a batch of elements, are not the result of a split.
+//
+func (r *SlabOpRecorder) recordStore(id atree.StorageID, slab atree.Slab, exists bool) {
+	if exists {
+		r.record(SlabOpUpdate, id)
+	} else {
+		r.record(SlabOpCreate, id)
+
+		if r.createdSlabs == nil {
+			r.createdSlabs = map[atree.StorageID]struct{}{}
+		}
+		r.createdSlabs[id] = struct{}{}
+	}
+
+	if !isMetaDataSlab(slab) {
+		return
+	}
+
+	var split bool
+
+	for _, childStorable := range slab.ChildStorables() {
+		childID := atree.StorageID(childStorable.(atree.StorageIDStorable))
+
+		if _, ok := r.createdSlabs[childID]; !ok {
+			continue
+		}
+
+		delete(r.createdSlabs, childID)
+
+		if exists {
+			split = true
+		}
+	}
+
+	if split {
+		r.record(SlabOpSplit, id)
+	}
+}
+
+func isMetaDataSlab(slab atree.Slab) bool {
+	switch slab.(type) {
+	case *atree.ArrayMetaDataSlab, *atree.MapMetaDataSlab:
+		return true
+	default:
+		return false
+	}
+}
+
+// recordRemove records the removal of the given slab.
+//
+// Merging a slab into its sibling moves the elements of the slab into the sibling,
+// and removes the slab, which still has its elements.
+// So a merge is recorded when a slab of a container is removed which is not empty.
+// Slabs of removed containers are emptied before they are removed.
+//
+// A slab which is promoted to the root slab is stored with the ID of the root slab,
+// before the slab with its own ID is removed. This is not a merge.
+//
+func (r *SlabOpRecorder) recordRemove(id atree.StorageID, slab atree.Slab, exists bool) {
+	r.record(SlabOpRemove, id)
+
+	if !exists || slab.ID() != id {
+		return
+	}
+
+	switch slab.(type) {
+	case atree.ArraySlab, atree.MapSlab:
+		if len(slab.ChildStorables()) > 0 {
+			r.record(SlabOpMerge, id)
+		}
+	}
+}
+
+// WithSlabOpRecorder starts recording the slab operations performed on the storage,
+// and returns the recorder.
+//
+// Recording slab operations is intended to be used in tests,
+// as it requires additional reads to distinguish the creation of slabs from updates.
+//
+func (s *Storage) WithSlabOpRecorder() *SlabOpRecorder {
+	s.slabOpRecorder = &SlabOpRecorder{}
+	return s.slabOpRecorder
+}
+
+func (s *Storage) Store(id atree.StorageID, slab atree.Slab) error {
+	s.recordSlabWrite(id)
+	s.recordStoredSlabSize(id, slab)
+	s.invalidateSlabOwnerStorageUsed(id)
+
+	if s.slabOpRecorder != nil {
+		_, exists, err := s.PersistentSlabStorage.Retrieve(id)
+		if err != nil {
+			return err
+		}
+
+		s.slabOpRecorder.recordStore(id, slab, exists)
+	}
+
+	return s.PersistentSlabStorage.Store(id, slab)
+}
+
+func (s *Storage) Remove(id atree.StorageID) error {
+	s.recordSlabWrite(id)
+	err := s.recordRemovedSlabSize(id)
+	if err != nil {
+		return err
+	}
+	s.invalidateSlabOwnerStorageUsed(id)
+
+	if s.slabOpRecorder != nil {
+		slab, exists, err := s.PersistentSlabStorage.Retrieve(id)
+		if err != nil {
+			return err
+		}
+
+		s.slabOpRecorder.recordRemove(id, slab, exists)
+	}
+
+	return s.PersistentSlabStorage.Remove(id)
+}
+
+// recordRetrievedSlabSize records the size of the given slab, when it is retrieved for the first time,
+// i.e. before it is modified in-place, so it is the size of the stored slab.
+// Temporary slabs are not stored in any account, so their sizes are not recorded.
+//
+func (s *Storage) recordRetrievedSlabSize(id atree.StorageID, slab atree.Slab) {
+	if id.Address == atree.AddressUndefined {
+		return
+	}
+
+	if _, ok := s.slabSizes[id]; ok {
+		return
+	}
+
+	s.slabSizes[id] = slab.ByteSize()
+}
+
+// recordStoredSlabSize updates the pending storage delta for the storing of the given slab,
+// i.e. the change from the size of the previously retrieved or stored slab, if any.
+//
+func (s *Storage) recordStoredSlabSize(id atree.StorageID, slab atree.Slab) {
+	if id.Address == atree.AddressUndefined {
+		return
+	}
+
+	size := slab.ByteSize()
+
+	s.pendingStorageDelta += int64(size) - int64(s.slabSizes[id])
+	s.slabSizes[id] = size
+}
+
+// recordRemovedSlabSize updates the pending storage delta for the removal of the slab with the given ID.
+// Slabs are usually retrieved before they are removed,
+// so the slab is only retrieved if its size is not known yet.
+//
+func (s *Storage) recordRemovedSlabSize(id atree.StorageID) error {
+	if id.Address == atree.AddressUndefined {
+		return nil
+	}
+
+	size, ok := s.slabSizes[id]
+	if !ok {
+		slab, found, err := s.PersistentSlabStorage.Retrieve(id)
+		if err != nil {
+			return err
+		}
+		if found {
+			size = slab.ByteSize()
+		}
+	}
+
+	s.pendingStorageDelta -= int64(size)
+	delete(s.slabSizes, id)
+
+	return nil
+}
@@ -0,0 +1,227 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2020 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runtime
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/onflow/atree"
+
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/cadence/runtime/interpreter"
+)
+
+// AccountRegisterIterator is an optional interface of a ledger,
+// which allows iterating over all registers of an account.
+//
+// The ledger of a storage must implement this interface
+// for the storage to be able to export snapshots.
+//
+type AccountRegisterIterator interface {
+	ForEachAccountRegister(owner []byte, f func(key []byte, value []byte) error) error
+}
+
+// StorageIndexAdvancer is an optional interface of a ledger,
+// which allows advancing the allocation of storage indices of an account.
+//
+// AdvanceStorageIndex ensures that storage indices allocated subsequently for the account
+// are greater than the given index. If the allocation already advanced past the given index, it is kept.
+//
+// The ledger of a storage must implement this interface
+// for the storage to be able to import snapshots.
+//
+type StorageIndexAdvancer interface {
+	AdvanceStorageIndex(owner []byte, index atree.StorageIndex) error
+}
+
+const storageSnapshotVersion = 1
+
+// storageSnapshot is the portable representation of the storage of an account.
+// It contains all registers of the account, i.e. the account storage values and the slabs.
+//
+type storageSnapshot struct {
+	_         struct{} `cbor:",toarray"`
+	Version   uint64
+	Owner     []byte
+	Registers []storageSnapshotRegister
+}
+
+type storageSnapshotRegister struct {
+	_     struct{} `cbor:",toarray"`
+	Key   []byte
+	Value []byte
+}
+
+// ExportSnapshot exports all registers of the given account to a self-contained snapshot,
+// which can be imported into another storage using ImportSnapshot.
+//
+// Only data which is committed to the ledger is exported,
+// so the storage should be committed before exporting.
+//
+func (s *Storage) ExportSnapshot(owner common.Address) ([]byte, error) {
+
+	iterator, ok := unwrapLedger(s.Ledger).(AccountRegisterIterator)
+	if !ok {
+		return nil, fmt.Errorf(
+			"cannot export storage snapshot: ledger does not support iterating over account registers",
+		)
+	}
+
+	var registers []storageSnapshotRegister
+
+	var err error
+	wrapPanic(func() {
+		err = iterator.ForEachAccountRegister(
+			owner[:],
+			func(key []byte, value []byte) error {
+				// Empty registers do not exist
+				if len(value) == 0 {
+					return nil
+				}
+
+				registers = append(
+					registers,
+					storageSnapshotRegister{
+						Key:   key,
+						Value: value,
+					},
+				)
+				return nil
+			},
+		)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Sort the registers by key, so the snapshot is deterministic
+
+	sort.Slice(registers, func(i, j int) bool {
+		return bytes.Compare(registers[i].Key, registers[j].Key) < 0
+	})
+
+	return interpreter.CBOREncMode.Marshal(storageSnapshot{
+		Version:   storageSnapshotVersion,
+		Owner:     owner[:],
+		Registers: registers,
+	})
+}
+
+// ImportSnapshot imports the registers of the given account from a snapshot,
+// which was exported using ExportSnapshot.
+//
+// The snapshot should be imported into a fresh ledger:
+// Existing registers are overwritten, but registers which are not in the snapshot are kept.
+//
+// The ledger must implement StorageIndexAdvancer.
+//
+func (s *Storage) ImportSnapshot(owner common.Address, data []byte) error {
+
+	var snapshot storageSnapshot
+	err := interpreter.CBORDecMode.Unmarshal(data, &snapshot)
+	if err != nil {
+		return fmt.Errorf("cannot import storage snapshot: %w", err)
+	}
+
+	if snapshot.Version != storageSnapshotVersion {
+		return fmt.Errorf(
+			"cannot import storage snapshot: unsupported version: expected %d, got %d",
+			storageSnapshotVersion,
+			snapshot.Version,
+		)
+	}
+
+	snapshotOwner := common.BytesToAddress(snapshot.Owner)
+	if snapshotOwner != owner {
+		return fmt.Errorf(
+			"cannot import storage snapshot: account mismatch: expected %s, got %s",
+			owner.ShortHexWithPrefix(),
+			snapshotOwner.ShortHexWithPrefix(),
+		)
+	}
+
+	advancer, ok := unwrapLedger(s.Ledger).(StorageIndexAdvancer)
+	if !ok {
+		return fmt.Errorf(
+			"cannot import storage snapshot: ledger does not support advancing the storage index",
+		)
+	}
+
+	var maxStorageIndex atree.StorageIndex
+
+	for _, register := range snapshot.Registers {
+
+		wrapPanic(func() {
+			err = s.Ledger.SetValue(owner[:], register.Key, register.Value)
+		})
+		if err != nil {
+			return err
+		}
+
+		// Keep track of the greatest storage index of the slabs
+
+		key := string(register.Key)
+		if !atree.LedgerKeyIsSlabKey(key) {
+			continue
+		}
+
+		var storageIndex atree.StorageIndex
+		copy(storageIndex[:], key[len(atree.LedgerBaseStorageSlabPrefix):])
+
+		if bytes.Compare(storageIndex[:], maxStorageIndex[:]) > 0 {
+			maxStorageIndex = storageIndex
+		}
+	}
+
+	// Ensure that newly allocated storage indices do not overwrite the imported slabs,
+	// i.e. advance the allocation to the greatest storage index of the imported slabs.
+
+	if maxStorageIndex != (atree.StorageIndex{}) {
+		wrapPanic(func() {
+			err = advancer.AdvanceStorageIndex(owner[:], maxStorageIndex)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// Drop cached data of the account, which might be outdated
+
+	// NOTE: map range is safe, as it only deletes entries
+	for storageKey := range s.readCache { //nolint:maprangecheck
+		if storageKey.Address == owner {
+			delete(s.readCache, storageKey)
+		}
+	}
+
+	s.DropCache()
+
+	// The sizes of cached slabs of the account might be outdated
+
+	// NOTE: map range is safe, as it only deletes entries
+	for id := range s.slabSizes { //nolint:maprangecheck
+		if common.Address(id.Address) == owner {
+			delete(s.slabSizes, id)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,298 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2020 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runtime
+
+import (
+	"testing"
+	"time"
+
+	"github.com/onflow/atree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/cadence/runtime/interpreter"
+	"github.com/onflow/cadence/runtime/tests/utils"
+)
+
+func TestRuntimeStorageSnapshot(t *testing.T) {
+
+	t.Parallel()
+
+	runtime := newTestInterpreterRuntime()
+
+	signer := common.BytesToAddress([]byte{0x42})
+
+	reportMetric := func(f func(), _ func(metrics Metrics, duration time.Duration)) {
+		f()
+	}
+
+	newRuntimeInterface := func(ledger testLedger) *testRuntimeInterface {
+		return &testRuntimeInterface{
+			storage: ledger,
+			getSigningAccounts: func() ([]Address, error) {
+				return []Address{signer}, nil
+			},
+		}
+	}
+
+	nextTransactionLocation := newTransactionLocationGenerator()
+
+	executeTransaction := func(runtimeInterface *testRuntimeInterface, code string) {
+		err := runtime.ExecuteTransaction(
+			Script{
+				Source: []byte(code),
+			},
+			Context{
+				Interface: runtimeInterface,
+				Location:  nextTransactionLocation(),
+			},
+		)
+		require.NoError(t, err)
+	}
+
+	// Set up the original storage
+
+	var originalWrites []testWrite
+
+	originalLedger := newTestLedger(nil, func(owner, key, value []byte) {
+		originalWrites = append(originalWrites, testWrite{
+			owner: owner,
+			key:   key,
+			value: value,
+		})
+	})
+	originalRuntimeInterface := newRuntimeInterface(originalLedger)
+
+	executeTransaction(
+		originalRuntimeInterface,
+		`
+          transaction {
+             prepare(signer: AuthAccount) {
+                 signer.save({"a": [1, 2, 3], "b": [4, 5, 6]}, to: /storage/numbers)
+                 signer.link<&{String: [Int]}>(/public/numbers, target: /storage/numbers)
+             }
+          }
+        `,
+	)
+
+	// Export the storage of the signer and import it into a fresh ledger
+
+	snapshot, err := NewStorage(originalLedger, reportMetric).ExportSnapshot(signer)
+	require.NoError(t, err)
+
+	var importedWrites []testWrite
+
+	importedLedger := newTestLedger(nil, func(owner, key, value []byte) {
+		importedWrites = append(importedWrites, testWrite{
+			owner: owner,
+			key:   key,
+			value: value,
+		})
+	})
+
+	// Importing the snapshot does not allocate storage indices
+
+	allocateStorageIndex := importedLedger.allocateStorageIndex
+	importedLedger.allocateStorageIndex = func(owner []byte) (atree.StorageIndex, error) {
+		require.FailNow(t, "unexpected storage index allocation")
+		return atree.StorageIndex{}, nil
+	}
+
+	err = NewStorage(importedLedger, reportMetric).ImportSnapshot(signer, snapshot)
+	require.NoError(t, err)
+
+	importedLedger.allocateStorageIndex = allocateStorageIndex
+
+	importedRuntimeInterface := newRuntimeInterface(importedLedger)
+
+	// The capability link is preserved
+
+	value, err := runtime.ReadLinked(
+		signer,
+		cadence.Path{
+			Domain:     "public",
+			Identifier: "numbers",
+		},
+		Context{
+			Location:  utils.TestLocation,
+			Interface: importedRuntimeInterface,
+		},
+	)
+	require.NoError(t, err)
+
+	expectedValue, err := runtime.ReadLinked(
+		signer,
+		cadence.Path{
+			Domain:     "public",
+			Identifier: "numbers",
+		},
+		Context{
+			Location:  utils.TestLocation,
+			Interface: originalRuntimeInterface,
+		},
+	)
+	require.NoError(t, err)
+
+	require.Equal(t, expectedValue, value)
+
+	// Executing the same transaction against both ledgers results in the same writes
+
+	const updateTx = `
+      transaction {
+         prepare(signer: AuthAccount) {
+             let numbers = signer.borrow<&{String: [Int]}>(from: /storage/numbers)!
+             numbers["a"]!.append(4)
+             signer.save(["x", "y", "z"], to: /storage/letters)
+         }
+      }
+    `
+
+	originalWrites = nil
+	executeTransaction(originalRuntimeInterface, updateTx)
+
+	importedWrites = nil
+	executeTransaction(importedRuntimeInterface, updateTx)
+
+	require.NotEmpty(t, originalWrites)
+	require.Equal(t, originalWrites, importedWrites)
+}
+
+func TestRuntimeStorageSnapshotErrors(t *testing.T) {
+
+	t.Parallel()
+
+	address := common.BytesToAddress([]byte{0x1})
+
+	reportMetric := func(f func(), _ func(metrics Metrics, duration time.Duration)) {
+		f()
+	}
+
+	t.Run("account mismatch", func(t *testing.T) {
+
+		t.Parallel()
+
+		snapshot, err := NewStorage(newTestLedger(nil, nil), reportMetric).ExportSnapshot(address)
+		require.NoError(t, err)
+
+		otherAddress := common.BytesToAddress([]byte{0x2})
+
+		err = NewStorage(newTestLedger(nil, nil), reportMetric).ImportSnapshot(otherAddress, snapshot)
+		require.Error(t, err)
+	})
+
+	t.Run("invalid data", func(t *testing.T) {
+
+		t.Parallel()
+
+		err := NewStorage(newTestLedger(nil, nil), reportMetric).ImportSnapshot(address, []byte{0x1})
+		require.Error(t, err)
+	})
+
+	t.Run("ledger does not support advancing the storage index", func(t *testing.T) {
+
+		t.Parallel()
+
+		snapshot, err := NewStorage(newTestLedger(nil, nil), reportMetric).ExportSnapshot(address)
+		require.NoError(t, err)
+
+		// Only expose the methods of atree.Ledger
+
+		ledger := struct{ atree.Ledger }{newTestLedger(nil, nil)}
+
+		err = NewStorage(ledger, reportMetric).ImportSnapshot(address, snapshot)
+		require.Error(t, err)
+	})
+}
+
+func TestRuntimeStorageSnapshotNamespaced(t *testing.T) {
+
+	t.Parallel()
+
+	address := common.BytesToAddress([]byte{0x1})
+
+	reportMetric := func(f func(), _ func(metrics Metrics, duration time.Duration)) {
+		f()
+	}
+
+	newNamespacedStorage := func(ledger atree.Ledger) *Storage {
+		storage, err := NewNamespacedStorage(ledger, reportMetric, "a")
+		require.NoError(t, err)
+
+		return storage
+	}
+
+	// Write a slab in the namespace of a ledger
+
+	originalLedger := newTestLedger(nil, nil)
+	originalStorage := newNamespacedStorage(originalLedger)
+
+	inter, err := interpreter.NewInterpreter(
+		nil,
+		utils.TestLocation,
+		interpreter.WithStorage(originalStorage),
+	)
+	require.NoError(t, err)
+
+	array := interpreter.NewArrayValue(
+		inter,
+		interpreter.VariableSizedStaticType{
+			Type: interpreter.PrimitiveStaticTypeInt,
+		},
+		address,
+		interpreter.NewIntValueFromInt64(1),
+	)
+
+	originalStorage.WriteValue(inter, address, "test", interpreter.NewSomeValueNonCopying(array))
+
+	err = originalStorage.Commit(inter, false)
+	require.NoError(t, err)
+
+	snapshot, err := originalStorage.ExportSnapshot(address)
+	require.NoError(t, err)
+
+	// Import the snapshot into the namespace of a fresh ledger
+
+	importedLedger := newTestLedger(nil, nil)
+
+	err = newNamespacedStorage(importedLedger).ImportSnapshot(address, snapshot)
+	require.NoError(t, err)
+
+	// The storage index allocation of the namespace is advanced past the imported slab,
+	// just like in the original ledger
+
+	expectedIndex, err := newNamespacedLedger(originalLedger, "a").AllocateStorageIndex(address[:])
+	require.NoError(t, err)
+
+	index, err := newNamespacedLedger(importedLedger, "a").AllocateStorageIndex(address[:])
+	require.NoError(t, err)
+
+	assert.Equal(t, expectedIndex, index)
+
+	// Advancing the allocation to a lower index keeps the allocation
+
+	err = newNamespacedLedger(importedLedger, "a").AdvanceStorageIndex(address[:], atree.StorageIndex{0, 0, 0, 0, 0, 0, 0, 1})
+	require.NoError(t, err)
+
+	nextIndex, err := newNamespacedLedger(importedLedger, "a").AllocateStorageIndex(address[:])
+	require.NoError(t, err)
+
+	assert.Equal(t, atree.StorageIndex{0, 0, 0, 0, 0, 0, 0, 3}, nextIndex)
+}
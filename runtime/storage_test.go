@@ -21,9 +21,11 @@ package runtime
 import (
 	"bytes"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"math"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -35,6 +37,8 @@ import (
 	"github.com/onflow/cadence/encoding/json"
 	"github.com/onflow/cadence/runtime/common"
 	"github.com/onflow/cadence/runtime/interpreter"
+	"github.com/onflow/cadence/runtime/sema"
+	"github.com/onflow/cadence/runtime/tests/checker"
 	"github.com/onflow/cadence/runtime/tests/utils"
 )
 
@@ -169,6 +173,115 @@ func TestRuntimeStorageWriteCachedIsDeterministic(t *testing.T) {
 	)
 }
 
+func TestRuntimeStorageCommitParallelismIsDeterministic(t *testing.T) {
+
+	t.Parallel()
+
+	commit := func(parallelism int) []testWrite {
+		var writes []testWrite
+
+		onWrite := func(owner, key, value []byte) {
+			writes = append(writes, testWrite{
+				owner: owner,
+				key:   key,
+				value: value,
+			})
+		}
+
+		const arrayElementCount = 100
+		const storageItemCount = 100
+		withWritesToStorage(
+			t,
+			arrayElementCount,
+			storageItemCount,
+			onWrite,
+			func(storage *Storage, inter *interpreter.Interpreter) {
+				storage.SetCommitParallelism(parallelism)
+
+				const commitContractUpdates = true
+				err := storage.Commit(inter, commitContractUpdates)
+				require.NoError(t, err)
+			},
+		)
+
+		return writes
+	}
+
+	serialWrites := commit(1)
+	require.NotEmpty(t, serialWrites)
+
+	for _, parallelism := range []int{2, 4, 16, 1000} {
+
+		// verify for 10 times and check the writes are always the same as the serial writes
+		for i := 0; i < 10; i++ {
+			require.Equal(t, serialWrites, commit(parallelism))
+		}
+	}
+}
+
+func TestRuntimeStorageWriteCachedEncoding(t *testing.T) {
+
+	t.Parallel()
+
+	var writes []testWrite
+
+	onWrite := func(owner, key, value []byte) {
+		writes = append(writes, testWrite{
+			owner: owner,
+			key:   key,
+			value: value,
+		})
+	}
+
+	const arrayElementCount = 10
+	const storageItemCount = 10
+	withWritesToStorage(
+		t,
+		arrayElementCount,
+		storageItemCount,
+		onWrite,
+		func(storage *Storage, inter *interpreter.Interpreter) {
+			const commitContractUpdates = true
+			err := storage.Commit(inter, commitContractUpdates)
+			require.NoError(t, err)
+
+			require.Len(t, writes, storageItemCount)
+
+			for i, write := range writes {
+
+				storageKey := interpreter.StorageKey{
+					Address: common.BytesToAddress(write.owner),
+					Key:     string(write.key),
+				}
+
+				// Writes are in storage key order
+
+				if i > 0 {
+					previousWrite := writes[i-1]
+					previousStorageKey := interpreter.StorageKey{
+						Address: common.BytesToAddress(previousWrite.owner),
+						Key:     string(previousWrite.key),
+					}
+					require.True(t, previousStorageKey.IsLess(storageKey))
+				}
+
+				// Written data is the encoding of the storable
+
+				var buf bytes.Buffer
+				encoder := atree.NewEncoder(&buf, interpreter.CBOREncMode)
+
+				err := storage.writes[storageKey].Encode(encoder)
+				require.NoError(t, err)
+
+				err = encoder.CBOR.Flush()
+				require.NoError(t, err)
+
+				require.Equal(t, buf.Bytes(), write.value)
+			}
+		},
+	)
+}
+
 func BenchmarkRuntimeStorageWriteCached(b *testing.B) {
 	var writes []testWrite
 
@@ -203,6 +316,36 @@ func BenchmarkRuntimeStorageWriteCached(b *testing.B) {
 	)
 }
 
+func BenchmarkRuntimeStorageCommitParallelism(b *testing.B) {
+
+	for _, parallelism := range []int{1, 2, 4, 8} {
+
+		b.Run(strconv.Itoa(parallelism), func(b *testing.B) {
+
+			const arrayElementCount = 100
+			const storageItemCount = 1000
+			withWritesToStorage(
+				b,
+				arrayElementCount,
+				storageItemCount,
+				nil,
+				func(storage *Storage, inter *interpreter.Interpreter) {
+					storage.SetCommitParallelism(parallelism)
+
+					b.ReportAllocs()
+					b.ResetTimer()
+
+					for i := 0; i < b.N; i++ {
+						const commitContractUpdates = true
+						err := storage.Commit(inter, commitContractUpdates)
+						require.NoError(b, err)
+					}
+				},
+			)
+		})
+	}
+}
+
 func TestRuntimeStorageWrite(t *testing.T) {
 
 	t.Parallel()
@@ -253,7 +396,7 @@ func TestRuntimeStorageWrite(t *testing.T) {
 		[]testWrite{
 			{
 				[]byte{0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x1},
-				[]byte("storage\x1fone"),
+				[]byte(interpreter.StorageKeyForPath(common.PathDomainStorage, "one")),
 				[]byte{
 					// CBOR
 					// - tag
@@ -342,210 +485,7 @@ func TestRuntimePublicCapabilityBorrowTypeConfusion(t *testing.T) {
 
 	deployFTContractTx := utils.DeploymentTransaction("FungibleToken", []byte(realFungibleTokenContractInterface))
 
-	const ducContract = `
-      import FungibleToken from 0xaad3e26e406987c2
-
-      pub contract DapperUtilityCoin: FungibleToken {
-
-    // Total supply of DapperUtilityCoins in existence
-    pub var totalSupply: UFix64
-
-    // Event that is emitted when the contract is created
-    pub event TokensInitialized(initialSupply: UFix64)
-
-    // Event that is emitted when tokens are withdrawn from a Vault
-    pub event TokensWithdrawn(amount: UFix64, from: Address?)
-
-    // Event that is emitted when tokens are deposited to a Vault
-    pub event TokensDeposited(amount: UFix64, to: Address?)
-
-    // Event that is emitted when new tokens are minted
-    pub event TokensMinted(amount: UFix64)
-
-    // Event that is emitted when tokens are destroyed
-    pub event TokensBurned(amount: UFix64)
-
-    // Event that is emitted when a new minter resource is created
-    pub event MinterCreated(allowedAmount: UFix64)
-
-    // Event that is emitted when a new burner resource is created
-    pub event BurnerCreated()
-
-    // Vault
-    //
-    // Each user stores an instance of only the Vault in their storage
-    // The functions in the Vault and governed by the pre and post conditions
-    // in FungibleToken when they are called.
-    // The checks happen at runtime whenever a function is called.
-    //
-    // Resources can only be created in the context of the contract that they
-    // are defined in, so there is no way for a malicious user to create Vaults
-    // out of thin air. A special Minter resource needs to be defined to mint
-    // new tokens.
-    //
-    pub resource Vault: FungibleToken.Provider, FungibleToken.Receiver, FungibleToken.Balance {
-
-        // holds the balance of a users tokens
-        pub var balance: UFix64
-
-        // initialize the balance at resource creation time
-        init(balance: UFix64) {
-            self.balance = balance
-        }
-
-        // withdraw
-        //
-        // Function that takes an integer amount as an argument
-        // and withdraws that amount from the Vault.
-        // It creates a new temporary Vault that is used to hold
-        // the money that is being transferred. It returns the newly
-        // created Vault to the context that called so it can be deposited
-        // elsewhere.
-        //
-        pub fun withdraw(amount: UFix64): @FungibleToken.Vault {
-            self.balance = self.balance - amount
-            emit TokensWithdrawn(amount: amount, from: self.owner?.address)
-            return <-create Vault(balance: amount)
-        }
-
-        // deposit
-        //
-        // Function that takes a Vault object as an argument and adds
-        // its balance to the balance of the owners Vault.
-        // It is allowed to destroy the sent Vault because the Vault
-        // was a temporary holder of the tokens. The Vault's balance has
-        // been consumed and therefore can be destroyed.
-        pub fun deposit(from: @FungibleToken.Vault) {
-            let vault <- from as! @DapperUtilityCoin.Vault
-            self.balance = self.balance + vault.balance
-            emit TokensDeposited(amount: vault.balance, to: self.owner?.address)
-            vault.balance = 0.0
-            destroy vault
-        }
-
-        destroy() {
-            DapperUtilityCoin.totalSupply = DapperUtilityCoin.totalSupply - self.balance
-        }
-    }
-
-    // createEmptyVault
-    //
-    // Function that creates a new Vault with a balance of zero
-    // and returns it to the calling context. A user must call this function
-    // and store the returned Vault in their storage in order to allow their
-    // account to be able to receive deposits of this token type.
-    //
-    pub fun createEmptyVault(): @FungibleToken.Vault {
-        return <-create Vault(balance: 0.0)
-    }
-
-    pub resource Administrator {
-        // createNewMinter
-        //
-        // Function that creates and returns a new minter resource
-        //
-        pub fun createNewMinter(allowedAmount: UFix64): @Minter {
-            emit MinterCreated(allowedAmount: allowedAmount)
-            return <-create Minter(allowedAmount: allowedAmount)
-        }
-
-        // createNewBurner
-        //
-        // Function that creates and returns a new burner resource
-        //
-        pub fun createNewBurner(): @Burner {
-            emit BurnerCreated()
-            return <-create Burner()
-        }
-    }
-
-    // Minter
-    //
-    // Resource object that token admin accounts can hold to mint new tokens.
-    //
-    pub resource Minter {
-
-        // the amount of tokens that the minter is allowed to mint
-        pub var allowedAmount: UFix64
-
-        // mintTokens
-        //
-        // Function that mints new tokens, adds them to the total supply,
-        // and returns them to the calling context.
-        //
-        pub fun mintTokens(amount: UFix64): @DapperUtilityCoin.Vault {
-            pre {
-                amount > UFix64(0): "Amount minted must be greater than zero"
-                amount <= self.allowedAmount: "Amount minted must be less than the allowed amount"
-            }
-            DapperUtilityCoin.totalSupply = DapperUtilityCoin.totalSupply + amount
-            self.allowedAmount = self.allowedAmount - amount
-            emit TokensMinted(amount: amount)
-            return <-create Vault(balance: amount)
-        }
-
-        init(allowedAmount: UFix64) {
-            self.allowedAmount = allowedAmount
-        }
-    }
-
-    // Burner
-    //
-    // Resource object that token admin accounts can hold to burn tokens.
-    //
-    pub resource Burner {
-
-        // burnTokens
-        //
-        // Function that destroys a Vault instance, effectively burning the tokens.
-        //
-        // Note: the burned tokens are automatically subtracted from the
-        // total supply in the Vault destructor.
-        //
-        pub fun burnTokens(from: @FungibleToken.Vault) {
-            let vault <- from as! @DapperUtilityCoin.Vault
-            let amount = vault.balance
-            destroy vault
-            emit TokensBurned(amount: amount)
-        }
-    }
-
-    init() {
-        // we're using a high value as the balance here to make it look like we've got a ton of money,
-        // just in case some contract manually checks that our balance is sufficient to pay for stuff
-        self.totalSupply = 999999999.0
-
-        let admin <- create Administrator()
-        let minter <- admin.createNewMinter(allowedAmount: self.totalSupply)
-        self.account.save(<-admin, to: /storage/dapperUtilityCoinAdmin)
-
-        // mint tokens
-        let tokenVault <- minter.mintTokens(amount: self.totalSupply)
-        self.account.save(<-tokenVault, to: /storage/dapperUtilityCoinVault)
-        destroy minter
-
-        // Create a public capability to the stored Vault that only exposes
-        // the balance field through the Balance interface
-        self.account.link<&DapperUtilityCoin.Vault{FungibleToken.Balance}>(
-            /public/dapperUtilityCoinBalance,
-            target: /storage/dapperUtilityCoinVault
-        )
-
-        // Create a public capability to the stored Vault that only exposes
-        // the deposit method through the Receiver interface
-        self.account.link<&{FungibleToken.Receiver}>(
-            /public/dapperUtilityCoinReceiver,
-            target: /storage/dapperUtilityCoinVault
-        )
-
-        // Emit an event that shows that the contract was initialized
-        emit TokensInitialized(initialSupply: self.totalSupply)
-    }
-}
-
-    `
-
-	deployDucContractTx := utils.DeploymentTransaction("DapperUtilityCoin", []byte(ducContract))
+	deployDucContractTx := utils.DeploymentTransaction("DapperUtilityCoin", []byte(realDapperUtilityCoinContract))
 
 	const testContract = `
       access(all) contract TestContract{
@@ -685,6 +625,132 @@ transaction {
 	require.Contains(t, err.Error(), "failed to borrow resource converter")
 }
 
+func TestRuntimeReferenceToFunctionCallResult(t *testing.T) {
+
+	t.Parallel()
+
+	runtime := newTestInterpreterRuntime()
+
+	address := common.BytesToAddress([]byte{0x1})
+
+	const testContract = `
+      access(all) contract TestContract {
+        pub struct fake {
+          pub(set) var balance: UFix64
+
+          init() {
+            self.balance = 0.0
+          }
+        }
+
+        pub resource resourceConverter {}
+
+        access(all) fun createConverter(): @resourceConverter {
+            return <- create resourceConverter()
+        }
+      }
+    `
+
+	accountCodes := map[common.LocationID][]byte{}
+	var loggedMessages []string
+
+	runtimeInterface := &testRuntimeInterface{
+		storage: newTestLedger(nil, nil),
+		getSigningAccounts: func() ([]Address, error) {
+			return []Address{address}, nil
+		},
+		resolveLocation: singleIdentifierLocationResolver(t),
+		updateAccountContractCode: func(address Address, name string, code []byte) error {
+			location := common.AddressLocation{
+				Address: address,
+				Name:    name,
+			}
+			accountCodes[location.ID()] = code
+			return nil
+		},
+		getAccountContractCode: func(address Address, name string) (code []byte, err error) {
+			location := common.AddressLocation{
+				Address: address,
+				Name:    name,
+			}
+			return accountCodes[location.ID()], nil
+		},
+		emitEvent: func(event cadence.Event) error {
+			return nil
+		},
+		log: func(message string) {
+			loggedMessages = append(loggedMessages, message)
+		},
+	}
+
+	nextTransactionLocation := newTransactionLocationGenerator()
+
+	err := runtime.ExecuteTransaction(
+		Script{
+			Source: utils.DeploymentTransaction("TestContract", []byte(testContract)),
+		},
+		Context{
+			Interface: runtimeInterface,
+			Location:  nextTransactionLocation(),
+		},
+	)
+	require.NoError(t, err)
+
+	t.Run("struct", func(t *testing.T) {
+
+		loggedMessages = nil
+
+		err := runtime.ExecuteTransaction(
+			Script{
+				Source: []byte(`
+                  import TestContract from 0x1
+
+                  transaction {
+                    prepare(acct: AuthAccount) {
+                      let ref = &TestContract.fake() as &TestContract.fake
+                      ref.balance = 100.0
+                      log(ref.balance)
+                    }
+                  }
+                `),
+			},
+			Context{
+				Interface: runtimeInterface,
+				Location:  nextTransactionLocation(),
+			},
+		)
+		require.NoError(t, err)
+
+		require.Equal(t, []string{"100.00000000"}, loggedMessages)
+	})
+
+	t.Run("resource", func(t *testing.T) {
+
+		err := runtime.ExecuteTransaction(
+			Script{
+				Source: []byte(`
+                  import TestContract from 0x1
+
+                  transaction {
+                    prepare(acct: AuthAccount) {
+                      let ref = &TestContract.createConverter() as &TestContract.resourceConverter
+                    }
+                  }
+                `),
+			},
+			Context{
+				Interface: runtimeInterface,
+				Location:  nextTransactionLocation(),
+			},
+		)
+		require.Error(t, err)
+
+		errs := checker.ExpectCheckerErrors(t, err, 1)
+
+		require.IsType(t, &sema.EphemeralResourceReferenceError{}, errs[0])
+	})
+}
+
 func TestRuntimeStorageReadAndBorrow(t *testing.T) {
 
 	t.Parallel()
@@ -796,53 +862,191 @@ func TestRuntimeStorageReadAndBorrow(t *testing.T) {
 	})
 }
 
-func TestRuntimeTopShotContractDeployment(t *testing.T) {
+func TestRuntimeStoredValueReferencedTypes(t *testing.T) {
 
 	t.Parallel()
 
 	runtime := newTestInterpreterRuntime()
 
-	testAddress, err := common.HexToAddress("0x0b2a3299cc857e29")
-	require.NoError(t, err)
-
-	nextTransactionLocation := newTransactionLocationGenerator()
+	address := common.BytesToAddress([]byte{0x1})
 
-	accountCodes := map[common.LocationID]string{
-		"A.1d7e57aa55817448.NonFungibleToken": realNonFungibleTokenInterface,
-	}
+	const contract = `
+      pub contract Test {
 
-	events := make([]cadence.Event, 0)
+          pub resource interface Receiver {
+              pub fun deposit(token: @NFT)
+          }
 
-	runtimeInterface := &testRuntimeInterface{
-		storage: newTestLedger(nil, nil),
-		getSigningAccounts: func() ([]Address, error) {
-			return []Address{testAddress}, nil
-		},
-		resolveLocation: singleIdentifierLocationResolver(t),
-		updateAccountContractCode: func(address Address, name string, code []byte) error {
-			location := common.AddressLocation{
-				Address: address,
-				Name:    name,
-			}
-			accountCodes[location.ID()] = string(code)
-			return nil
-		},
-		getAccountContractCode: func(address Address, name string) (code []byte, err error) {
-			location := common.AddressLocation{
-				Address: address,
-				Name:    name,
-			}
-			code = []byte(accountCodes[location.ID()])
-			return code, nil
-		},
-		decodeArgument: func(b []byte, t cadence.Type) (cadence.Value, error) {
-			return json.Decode(b)
-		},
-		emitEvent: func(event cadence.Event) error {
-			events = append(events, event)
-			return nil
-		},
-	}
+          pub resource NFT {
+              pub let id: UInt64
+
+              init(id: UInt64) {
+                  self.id = id
+              }
+          }
+
+          pub resource Collection: Receiver {
+              pub var ownedNFTs: @{UInt64: NFT}
+              pub var receivers: [Capability<&{Receiver}>]
+
+              init() {
+                  self.ownedNFTs <- {}
+                  self.receivers = []
+              }
+
+              pub fun deposit(token: @NFT) {
+                  let oldToken <- self.ownedNFTs[token.id] <- token
+                  destroy oldToken
+              }
+
+              destroy() {
+                  destroy self.ownedNFTs
+              }
+          }
+
+          pub fun createCollection(): @Collection {
+              return <- create Collection()
+          }
+
+          pub fun mint(id: UInt64): @NFT {
+              return <- create NFT(id: id)
+          }
+      }
+    `
+
+	const transaction = `
+      import Test from 0x1
+
+      transaction {
+          prepare(signer: AuthAccount) {
+              let collection <- Test.createCollection()
+              collection.deposit(token: <- Test.mint(id: 1))
+              collection.deposit(token: <- Test.mint(id: 2))
+              signer.save(<-collection, to: /storage/collection)
+          }
+      }
+    `
+
+	accountCodes := map[common.LocationID][]byte{}
+
+	runtimeInterface := &testRuntimeInterface{
+		storage: newTestLedger(nil, nil),
+		getSigningAccounts: func() ([]Address, error) {
+			return []Address{address}, nil
+		},
+		resolveLocation: singleIdentifierLocationResolver(t),
+		getAccountContractCode: func(address Address, name string) (code []byte, err error) {
+			location := common.AddressLocation{
+				Address: address,
+				Name:    name,
+			}
+			return accountCodes[location.ID()], nil
+		},
+		updateAccountContractCode: func(address Address, name string, code []byte) error {
+			location := common.AddressLocation{
+				Address: address,
+				Name:    name,
+			}
+			accountCodes[location.ID()] = code
+			return nil
+		},
+		emitEvent: func(_ cadence.Event) error {
+			return nil
+		},
+	}
+
+	nextTransactionLocation := newTransactionLocationGenerator()
+
+	for _, source := range [][]byte{
+		utils.DeploymentTransaction("Test", []byte(contract)),
+		[]byte(transaction),
+	} {
+		err := runtime.ExecuteTransaction(
+			Script{
+				Source: source,
+			},
+			Context{
+				Interface: runtimeInterface,
+				Location:  nextTransactionLocation(),
+			},
+		)
+		require.NoError(t, err)
+	}
+
+	value, err := runtime.ReadStored(
+		address,
+		cadence.Path{
+			Domain:     "storage",
+			Identifier: "collection",
+		},
+		Context{
+			Interface: runtimeInterface,
+		},
+	)
+	require.NoError(t, err)
+
+	var typeIDs []string
+	for _, referencedType := range cadence.ReferencedTypes(value) {
+		typeIDs = append(typeIDs, referencedType.ID())
+	}
+
+	assert.Equal(t,
+		[]string{
+			"A.0000000000000001.Test.Collection",
+			"A.0000000000000001.Test.NFT",
+			"A.0000000000000001.Test.Receiver",
+		},
+		typeIDs,
+	)
+}
+
+func TestRuntimeTopShotContractDeployment(t *testing.T) {
+
+	t.Parallel()
+
+	runtime := newTestInterpreterRuntime()
+
+	testAddress, err := common.HexToAddress("0x0b2a3299cc857e29")
+	require.NoError(t, err)
+
+	nextTransactionLocation := newTransactionLocationGenerator()
+
+	accountCodes := map[common.LocationID]string{
+		"A.1d7e57aa55817448.NonFungibleToken": realNonFungibleTokenInterface,
+	}
+
+	events := make([]cadence.Event, 0)
+
+	runtimeInterface := &testRuntimeInterface{
+		storage: newTestLedger(nil, nil),
+		getSigningAccounts: func() ([]Address, error) {
+			return []Address{testAddress}, nil
+		},
+		resolveLocation: singleIdentifierLocationResolver(t),
+		updateAccountContractCode: func(address Address, name string, code []byte) error {
+			location := common.AddressLocation{
+				Address: address,
+				Name:    name,
+			}
+			accountCodes[location.ID()] = string(code)
+			return nil
+		},
+		getAccountContractCode: func(address Address, name string) (code []byte, err error) {
+			location := common.AddressLocation{
+				Address: address,
+				Name:    name,
+			}
+			code = []byte(accountCodes[location.ID()])
+			return code, nil
+		},
+		decodeArgument: func(b []byte, t cadence.Type) (cadence.Value, error) {
+			return json.Decode(b)
+		},
+		emitEvent: func(event cadence.Event) error {
+			events = append(events, event)
+			return nil
+		},
+	}
 
 	err = runtime.ExecuteTransaction(
 		Script{
@@ -1405,6 +1609,7 @@ func TestRuntimeStorageUnlink(t *testing.T) {
                       )
 
                       assert(signer.getCapability<&Int>(/public/test).borrow() != nil)
+                      assert(signer.getLinkTarget(/public/test) != nil)
                   }
               }
             `),
@@ -1426,6 +1631,7 @@ func TestRuntimeStorageUnlink(t *testing.T) {
                     signer.unlink(/public/test)
 
                     assert(signer.getCapability<&Int>(/public/test).borrow() == nil)
+                    assert(signer.getLinkTarget(/public/test) == nil)
                 }
             }
             `),
@@ -1550,69 +1756,45 @@ func TestRuntimeStorageSaveCapability(t *testing.T) {
 	}
 }
 
-func TestRuntimeStorageReferenceCast(t *testing.T) {
+func TestRuntimeReadCapabilityChains(t *testing.T) {
 
 	t.Parallel()
 
 	runtime := newTestInterpreterRuntime()
 
-	signerAddress := common.BytesToAddress([]byte{0x42})
-
-	deployTx := utils.DeploymentTransaction("Test", []byte(`
-      pub contract Test {
-
-          pub resource interface RI {}
-
-          pub resource R: RI {}
-
-          pub fun createR(): @R {
-              return <-create R()
-          }
-      }
-    `))
-
-	accountCodes := map[common.LocationID][]byte{}
-	var events []cadence.Event
-	var loggedMessages []string
+	signer := common.BytesToAddress([]byte{0x42})
 
 	runtimeInterface := &testRuntimeInterface{
 		storage: newTestLedger(nil, nil),
 		getSigningAccounts: func() ([]Address, error) {
-			return []Address{signerAddress}, nil
-		},
-		resolveLocation: singleIdentifierLocationResolver(t),
-		updateAccountContractCode: func(address Address, name string, code []byte) error {
-			location := common.AddressLocation{
-				Address: address,
-				Name:    name,
-			}
-			accountCodes[location.ID()] = code
-			return nil
-		},
-		getAccountContractCode: func(address Address, name string) (code []byte, err error) {
-			location := common.AddressLocation{
-				Address: address,
-				Name:    name,
-			}
-			code = accountCodes[location.ID()]
-			return code, nil
-		},
-		emitEvent: func(event cadence.Event) error {
-			events = append(events, event)
-			return nil
-		},
-		log: func(message string) {
-			loggedMessages = append(loggedMessages, message)
+			return []Address{signer}, nil
 		},
 	}
 
 	nextTransactionLocation := newTransactionLocationGenerator()
 
-	// Deploy contract
-
 	err := runtime.ExecuteTransaction(
 		Script{
-			Source: deployTx,
+			Source: []byte(`
+              transaction {
+                  prepare(signer: AuthAccount) {
+                      signer.save(42, to: /storage/number)
+                      signer.link<&Int>(/private/number, target: /storage/number)
+                      signer.link<&Int>(/public/number, target: /private/number)
+
+                      signer.save(
+                          signer.getCapability<&Int>(/public/number),
+                          to: /storage/capability
+                      )
+                      signer.link<&Capability>(/public/capability, target: /storage/capability)
+
+                      signer.save(
+                          signer.getCapability<&Int>(/public/missing),
+                          to: /storage/danglingCapability
+                      )
+                  }
+              }
+            `),
 		},
 		Context{
 			Interface: runtimeInterface,
@@ -1621,182 +1803,228 @@ func TestRuntimeStorageReferenceCast(t *testing.T) {
 	)
 	require.NoError(t, err)
 
-	// Run test transaction
-
-	const testTx = `
-      import Test from 0x42
-
-      transaction {
-          prepare(signer: AuthAccount) {
-              signer.save(<-Test.createR(), to: /storage/r)
-
-              signer.link<&Test.R{Test.RI}>(
-                 /public/r,
-                 target: /storage/r
-              )
-
-              let ref = signer.getCapability<&Test.R{Test.RI}>(/public/r).borrow()!
-
-              let casted = (ref as AnyStruct) as! &Test.R
-          }
-      }
-    `
+	newCapability := func(chain cadence.CapabilityChain) cadence.Optional {
+		return cadence.NewOptional(
+			cadence.Capability{
+				Path: cadence.Path{
+					Domain:     "public",
+					Identifier: "number",
+				},
+				Address: cadence.Address(signer),
+				BorrowType: cadence.ReferenceType{
+					Type: cadence.IntType{},
+				},
+				Chain: chain,
+			},
+		)
+	}
 
-	err = runtime.ExecuteTransaction(
-		Script{
-			Source: []byte(testTx),
+	chain := cadence.NewCapabilityChain([]cadence.Path{
+		{
+			Domain:     "public",
+			Identifier: "number",
 		},
-		Context{
-			Interface: runtimeInterface,
-			Location:  nextTransactionLocation(),
+		{
+			Domain:     "private",
+			Identifier: "number",
 		},
-	)
-
-	require.Error(t, err)
-
-	require.Contains(t, err.Error(), "unexpectedly found non-`&Test.R` while force-casting value")
-}
-
-func TestRuntimeStorageNonStorable(t *testing.T) {
+		{
+			Domain:     "storage",
+			Identifier: "number",
+		},
+	})
 
-	t.Parallel()
+	t.Run("ReadStored, resolved", func(t *testing.T) {
 
-	runtime := newTestInterpreterRuntime()
+		value, err := runtime.ReadStored(
+			signer,
+			cadence.Path{
+				Domain:     "storage",
+				Identifier: "capability",
+			},
+			Context{
+				Interface:               runtimeInterface,
+				Location:                nextTransactionLocation(),
+				ResolveCapabilityChains: true,
+			},
+		)
+		require.NoError(t, err)
+		require.Equal(t, newCapability(chain), value)
+	})
 
-	address := common.BytesToAddress([]byte{0x1})
+	t.Run("ReadStored, not resolved", func(t *testing.T) {
 
-	for name, code := range map[string]string{
-		"ephemeral reference": `
-            let value = &1 as &Int
-        `,
-		"storage reference": `
-            signer.save("test", to: /storage/string)
-            let value = signer.borrow<&String>(from: /storage/string)!
-        `,
-		"function": `
-            let value = fun () {}
-        `,
-	} {
-
-		t.Run(name, func(t *testing.T) {
+		value, err := runtime.ReadStored(
+			signer,
+			cadence.Path{
+				Domain:     "storage",
+				Identifier: "capability",
+			},
+			Context{
+				Interface: runtimeInterface,
+				Location:  nextTransactionLocation(),
+			},
+		)
+		require.NoError(t, err)
+		require.Equal(t, newCapability(cadence.CapabilityChain{}), value)
+	})
 
-			tx := []byte(
-				fmt.Sprintf(
-					`
-                      transaction {
-                          prepare(signer: AuthAccount) {
-                              %s
-                              signer.save((value as AnyStruct), to: /storage/value)
-                          }
-                       }
-                    `,
-					code,
-				),
-			)
+	t.Run("ReadLinked, resolved", func(t *testing.T) {
 
-			runtimeInterface := &testRuntimeInterface{
-				storage: newTestLedger(nil, nil),
-				getSigningAccounts: func() ([]Address, error) {
-					return []Address{address}, nil
-				},
-			}
+		value, err := runtime.ReadLinked(
+			signer,
+			cadence.Path{
+				Domain:     "public",
+				Identifier: "capability",
+			},
+			Context{
+				Interface:               runtimeInterface,
+				Location:                nextTransactionLocation(),
+				ResolveCapabilityChains: true,
+			},
+		)
+		require.NoError(t, err)
+		require.Equal(t, newCapability(chain), value)
+	})
 
-			nextTransactionLocation := newTransactionLocationGenerator()
+	t.Run("ReadStored, dangling", func(t *testing.T) {
 
-			err := runtime.ExecuteTransaction(
-				Script{
-					Source: tx,
-				},
-				Context{
-					Interface: runtimeInterface,
-					Location:  nextTransactionLocation(),
+		value, err := runtime.ReadStored(
+			signer,
+			cadence.Path{
+				Domain:     "storage",
+				Identifier: "danglingCapability",
+			},
+			Context{
+				Interface:               runtimeInterface,
+				Location:                nextTransactionLocation(),
+				ResolveCapabilityChains: true,
+			},
+		)
+		require.NoError(t, err)
+		require.Equal(t,
+			cadence.NewOptional(
+				cadence.Capability{
+					Path: cadence.Path{
+						Domain:     "public",
+						Identifier: "missing",
+					},
+					Address: cadence.Address(signer),
+					BorrowType: cadence.ReferenceType{
+						Type: cadence.IntType{},
+					},
 				},
-			)
-			require.Error(t, err)
-
-			require.Contains(t, err.Error(), "cannot store non-storable value")
-		})
-	}
+			),
+			value,
+		)
+	})
 }
 
-func TestRuntimeStorageRecursiveReference(t *testing.T) {
+func TestRuntimeStoredCapabilityTypes(t *testing.T) {
 
 	t.Parallel()
 
 	runtime := newTestInterpreterRuntime()
 
-	address := common.BytesToAddress([]byte{0x1})
-
-	const code = `
-      transaction {
-          prepare(signer: AuthAccount) {
-              let refs: [AnyStruct] = []
-              refs.insert(at: 0, &refs as &AnyStruct)
-              signer.save(refs, to: /storage/refs)
-          }
-      }
-    `
+	signer := common.BytesToAddress([]byte{0x42})
 
 	runtimeInterface := &testRuntimeInterface{
 		storage: newTestLedger(nil, nil),
 		getSigningAccounts: func() ([]Address, error) {
-			return []Address{address}, nil
+			return []Address{signer}, nil
 		},
 	}
 
 	nextTransactionLocation := newTransactionLocationGenerator()
 
+	context := Context{
+		Interface: runtimeInterface,
+		Location:  nextTransactionLocation(),
+	}
+
 	err := runtime.ExecuteTransaction(
 		Script{
-			Source: []byte(code),
-		},
-		Context{
-			Interface: runtimeInterface,
-			Location:  nextTransactionLocation(),
+			Source: []byte(`
+              transaction {
+                  prepare(signer: AuthAccount) {
+                      signer.save(1, to: /storage/number)
+                      signer.link<&Int>(/public/number, target: /storage/number)
+
+                      signer.save(signer.getCapability<&Int>(/public/number), to: /storage/typed)
+                      signer.save(signer.getCapability(/public/number), to: /storage/untyped)
+                  }
+              }
+            `),
 		},
+		context,
 	)
-	require.Error(t, err)
+	require.NoError(t, err)
 
-	require.Contains(t, err.Error(), "cannot store non-storable value")
+	capabilityTypes, unresolved, err := runtime.StoredCapabilityTypes(signer, context)
+	require.NoError(t, err)
+
+	require.Equal(t,
+		map[cadence.Path]string{
+			{Domain: "storage", Identifier: "typed"}:   "&Int",
+			{Domain: "storage", Identifier: "untyped"}: "",
+		},
+		capabilityTypes,
+	)
+	require.Empty(t, unresolved)
 }
 
-func TestRuntimeStorageTransfer(t *testing.T) {
+func TestRuntimeStorageIterationUnloadableTypes(t *testing.T) {
 
 	t.Parallel()
 
 	runtime := newTestInterpreterRuntime()
 
-	address1 := common.BytesToAddress([]byte{0x1})
-	address2 := common.BytesToAddress([]byte{0x2})
-
-	ledger := newTestLedger(nil, nil)
+	signer := common.BytesToAddress([]byte{0x42})
 
-	var signers []Address
+	accountCodes := map[common.LocationID][]byte{}
 
 	runtimeInterface := &testRuntimeInterface{
-		storage: ledger,
+		storage: newTestLedger(nil, nil),
 		getSigningAccounts: func() ([]Address, error) {
-			return signers, nil
+			return []Address{signer}, nil
+		},
+		resolveLocation: singleIdentifierLocationResolver(t),
+		getAccountContractCode: func(address Address, name string) ([]byte, error) {
+			location := common.AddressLocation{
+				Address: address,
+				Name:    name,
+			}
+			return accountCodes[location.ID()], nil
+		},
+		updateAccountContractCode: func(address Address, name string, code []byte) error {
+			location := common.AddressLocation{
+				Address: address,
+				Name:    name,
+			}
+			accountCodes[location.ID()] = code
+			return nil
+		},
+		emitEvent: func(_ cadence.Event) error {
+			return nil
 		},
 	}
 
 	nextTransactionLocation := newTransactionLocationGenerator()
 
-	// Store
-
-	signers = []Address{address1}
-
-	storeTx := []byte(`
-      transaction {
-          prepare(signer: AuthAccount) {
-              signer.save([1], to: /storage/test)
-          }
-       }
-    `)
-
 	err := runtime.ExecuteTransaction(
 		Script{
-			Source: storeTx,
+			Source: utils.DeploymentTransaction(
+				"Test",
+				[]byte(`
+                  pub contract Test {
+                      pub resource R {}
+
+                      pub fun createR(): @R {
+                          return <-create R()
+                      }
+                  }
+                `),
+			),
 		},
 		Context{
 			Interface: runtimeInterface,
@@ -1805,22 +2033,23 @@ func TestRuntimeStorageTransfer(t *testing.T) {
 	)
 	require.NoError(t, err)
 
-	// Transfer
-
-	signers = []Address{address1, address2}
-
-	transferTx := []byte(`
-      transaction {
-          prepare(signer1: AuthAccount, signer2: AuthAccount) {
-              let value = signer1.load<[Int]>(from: /storage/test)!
-              signer2.save(value, to: /storage/test)
-          }
-       }
-    `)
-
 	err = runtime.ExecuteTransaction(
 		Script{
-			Source: transferTx,
+			Source: []byte(`
+              import Test from 0x42
+
+              transaction {
+                  prepare(signer: AuthAccount) {
+                      signer.save(<-Test.createR(), to: /storage/r)
+                      signer.link<&Test.R>(/public/r, target: /storage/r)
+                      signer.save(signer.getCapability<&Test.R>(/public/r), to: /storage/rCap)
+
+                      signer.save(1, to: /storage/number)
+                      signer.link<&Int>(/public/number, target: /storage/number)
+                      signer.save(signer.getCapability<&Int>(/public/number), to: /storage/numberCap)
+                  }
+              }
+            `),
 		},
 		Context{
 			Interface: runtimeInterface,
@@ -1829,298 +2058,616 @@ func TestRuntimeStorageTransfer(t *testing.T) {
 	)
 	require.NoError(t, err)
 
-	var nonEmptyKeys int
-	for _, data := range ledger.storedValues {
-		if len(data) > 0 {
-			nonEmptyKeys++
-		}
+	// Remove the contract, so the type of the resource,
+	// and the borrow type of the capability cannot be loaded anymore
+
+	for locationID := range accountCodes { //nolint:maprangecheck
+		delete(accountCodes, locationID)
 	}
-	assert.Equal(t, 2, nonEmptyKeys)
+	runtimeInterface.programs = nil
+
+	context := Context{
+		Interface: runtimeInterface,
+		Location:  nextTransactionLocation(),
+	}
+
+	t.Run("StoredCapabilityTypes", func(t *testing.T) {
+
+		capabilityTypes, unresolved, err := runtime.StoredCapabilityTypes(signer, context)
+		require.NoError(t, err)
+
+		require.Equal(t,
+			map[cadence.Path]string{
+				{Domain: "storage", Identifier: "numberCap"}: "&Int",
+			},
+			capabilityTypes,
+		)
+		require.Equal(t,
+			[]cadence.Path{
+				{Domain: "storage", Identifier: "rCap"},
+			},
+			unresolved,
+		)
+	})
+
+	t.Run("IterateStorageDomain", func(t *testing.T) {
+
+		var paths []cadence.Path
+
+		err := runtime.IterateStorageDomain(
+			signer,
+			common.PathDomainStorage,
+			func(path cadence.Path, _ cadence.Type) bool {
+				paths = append(paths, path)
+				return true
+			},
+			context,
+		)
+		require.NoError(t, err)
+
+		// The resource and the capability with the unloadable types are skipped
+
+		assert.Equal(t,
+			[]cadence.Path{
+				{Domain: "storage", Identifier: "number"},
+				{Domain: "storage", Identifier: "numberCap"},
+			},
+			paths,
+		)
+	})
 }
 
-func TestRuntimeStorageUsed(t *testing.T) {
+func TestRuntimeIterateStorageDomain(t *testing.T) {
 
 	t.Parallel()
 
 	runtime := newTestInterpreterRuntime()
 
-	ledger := newTestLedger(nil, nil)
+	signer := common.BytesToAddress([]byte{0x42})
 
 	runtimeInterface := &testRuntimeInterface{
-		storage: ledger,
-		getStorageUsed: func(_ Address) (uint64, error) {
-			return 1, nil
+		storage: newTestLedger(nil, nil),
+		getSigningAccounts: func() ([]Address, error) {
+			return []Address{signer}, nil
 		},
 	}
 
-	// NOTE: do NOT change the contents of this script,
-	// it matters how the array is constructed,
-	// ESPECIALLY the value of the addresses and the number of elements!
-	//
-	// Querying storageUsed commits storage, and this test asserts
-	// that this should not clear temporary slabs
+	nextTransactionLocation := newTransactionLocationGenerator()
 
-	script := []byte(`
-       pub fun main() {
-            var addresses: [Address]= [
-                0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731,
-                0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731,
-                0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731,
-                0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731,
-                0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731,
-                0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731,
-                0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731,
-                0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731,
-                0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731,
-                0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731,
-                0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731,
-                0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731,
-                0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731,
-                0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731,
-                0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731,
-                0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731,
-                0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731,
-                0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731,
-                0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731,
-                0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731
-            ]
-            var count = 0
-            for address in addresses {
-                let account = getAccount(address)
-                var x = account.storageUsed
-            }
-        }
-    `)
+	context := Context{
+		Interface: runtimeInterface,
+		Location:  nextTransactionLocation(),
+	}
 
-	_, err := runtime.ExecuteScript(
+	err := runtime.ExecuteTransaction(
 		Script{
-			Source: script,
-		},
-		Context{
-			Interface: runtimeInterface,
-			Location:  common.ScriptLocation{},
+			Source: []byte(`
+              transaction {
+                  prepare(signer: AuthAccount) {
+                      signer.save(1, to: /storage/number)
+                      signer.save("hello", to: /storage/string)
+                      signer.save([1, 2], to: /storage/array)
+
+                      signer.link<&Int>(/public/number, target: /storage/number)
+                      signer.link<&String>(/private/string, target: /storage/string)
+                  }
+              }
+            `),
 		},
+		context,
 	)
 	require.NoError(t, err)
 
-}
-
-func TestSortAccountStorageEntries(t *testing.T) {
+	type storedItem struct {
+		path cadence.Path
+		typ  cadence.Type
+	}
 
-	t.Parallel()
+	iterate := func(domain common.PathDomain, limit int) []storedItem {
+		var items []storedItem
 
-	entries := []AccountStorageEntry{
-		{
-			StorageKey: interpreter.StorageKey{
-				Address: common.Address{2},
-				Key:     "a",
-			},
-		},
-		{
-			StorageKey: interpreter.StorageKey{
-				Address: common.Address{1},
-				Key:     "b",
-			},
-		},
-		{
-			StorageKey: interpreter.StorageKey{
-				Address: common.Address{1},
-				Key:     "a",
-			},
-		},
-		{
-			StorageKey: interpreter.StorageKey{
-				Address: common.Address{0},
-				Key:     "x",
+		err := runtime.IterateStorageDomain(
+			signer,
+			domain,
+			func(path cadence.Path, typ cadence.Type) bool {
+				items = append(items, storedItem{path: path, typ: typ})
+				return len(items) < limit
 			},
-		},
+			context,
+		)
+		require.NoError(t, err)
+
+		return items
 	}
 
-	SortAccountStorageEntries(entries)
+	t.Run("storage", func(t *testing.T) {
 
-	require.Equal(t,
-		[]AccountStorageEntry{
-			{
-				StorageKey: interpreter.StorageKey{
-					Address: common.Address{0},
-					Key:     "x",
+		require.Equal(t,
+			[]storedItem{
+				{
+					path: cadence.Path{Domain: "storage", Identifier: "array"},
+					typ:  cadence.VariableSizedArrayType{ElementType: cadence.IntType{}},
+				},
+				{
+					path: cadence.Path{Domain: "storage", Identifier: "number"},
+					typ:  cadence.IntType{},
+				},
+				{
+					path: cadence.Path{Domain: "storage", Identifier: "string"},
+					typ:  cadence.StringType{},
 				},
 			},
-			{
-				StorageKey: interpreter.StorageKey{
-					Address: common.Address{1},
-					Key:     "a",
+			iterate(common.PathDomainStorage, 10),
+		)
+	})
+
+	t.Run("public", func(t *testing.T) {
+
+		require.Equal(t,
+			[]storedItem{
+				{
+					path: cadence.Path{Domain: "public", Identifier: "number"},
+					typ:  cadence.ReferenceType{Type: cadence.IntType{}},
 				},
 			},
-			{
-				StorageKey: interpreter.StorageKey{
-					Address: common.Address{1},
-					Key:     "b",
+			iterate(common.PathDomainPublic, 10),
+		)
+	})
+
+	t.Run("private", func(t *testing.T) {
+
+		require.Equal(t,
+			[]storedItem{
+				{
+					path: cadence.Path{Domain: "private", Identifier: "string"},
+					typ:  cadence.ReferenceType{Type: cadence.StringType{}},
 				},
 			},
-			{
-				StorageKey: interpreter.StorageKey{
-					Address: common.Address{2},
-					Key:     "a",
+			iterate(common.PathDomainPrivate, 10),
+		)
+	})
+
+	t.Run("stop early", func(t *testing.T) {
+
+		require.Equal(t,
+			[]storedItem{
+				{
+					path: cadence.Path{Domain: "storage", Identifier: "array"},
+					typ:  cadence.VariableSizedArrayType{ElementType: cadence.IntType{}},
 				},
 			},
-		},
-		entries,
-	)
+			iterate(common.PathDomainStorage, 1),
+		)
+	})
+
+	t.Run("invalid domain", func(t *testing.T) {
+
+		err := runtime.IterateStorageDomain(
+			signer,
+			common.PathDomainUnknown,
+			func(path cadence.Path, typ cadence.Type) bool {
+				return true
+			},
+			context,
+		)
+		require.Error(t, err)
+
+		var pathDomainErr interpreter.InvalidPathDomainError
+		require.ErrorAs(t, err, &pathDomainErr)
+	})
 }
 
-func TestRuntimeMissingSlab1173(t *testing.T) {
+func TestRuntimeCheckCapability(t *testing.T) {
 
 	t.Parallel()
 
-	const contract = `
-pub contract Test {
-    pub enum Role: UInt8 {
-        pub case aaa
-        pub case bbb
-    }
+	runtime := newTestInterpreterRuntime()
 
-    pub resource AAA {
-        pub fun callA(): String {
-            return "AAA"
-        }
-    }
+	signer := common.BytesToAddress([]byte{0x42})
 
-    pub resource BBB {
-        pub fun callB(): String {
-            return "BBB"
-        }
-    }
+	runtimeInterface := &testRuntimeInterface{
+		storage: newTestLedger(nil, nil),
+		getSigningAccounts: func() ([]Address, error) {
+			return []Address{signer}, nil
+		},
+	}
 
-    pub resource interface Receiver {
-        pub fun receive(as: Role, capability: Capability)
-    }
+	nextTransactionLocation := newTransactionLocationGenerator()
 
-    pub resource Holder: Receiver {
-        access(self) let roles: { Role: Capability }
-        pub fun receive(as: Role, capability: Capability) {
-            self.roles[as] = capability
-        }
+	context := Context{
+		Interface: runtimeInterface,
+		Location:  nextTransactionLocation(),
+	}
 
-        pub fun borrowA(): &AAA {
-            let role = self.roles[Role.aaa]!
-            return role.borrow<&AAA>()!
-        }
+	err := runtime.ExecuteTransaction(
+		Script{
+			Source: []byte(`
+              transaction {
+                  prepare(signer: AuthAccount) {
+                      signer.save(1, to: /storage/number)
+                      signer.link<&Int>(/public/number, target: /storage/number)
+                      signer.link<&Int>(/private/number, target: /public/number)
 
-        pub fun borrowB(): &BBB {
-            let role = self.roles[Role.bbb]!
-            return role.borrow<&BBB>()!
-        }
+                      signer.save("1", to: /storage/string)
+                      signer.link<&Int>(/public/string, target: /storage/string)
 
-        access(contract) init() {
-            self.roles = {}
-        }
-    }
+                      signer.save(2, to: /storage/removed)
+                      signer.link<&Int>(/public/removed, target: /storage/removed)
+                      signer.load<Int>(from: /storage/removed)
+                  }
+              }
+            `),
+		},
+		context,
+	)
+	require.NoError(t, err)
 
-    access(self) let capabilities: { Role: Capability }
+	test := func(domain string, identifier string, expected CapabilityStatus) {
 
-    pub fun createHolder(): @Holder {
-        return <- create Holder()
-    }
+		t.Run(fmt.Sprintf("/%s/%s", domain, identifier), func(t *testing.T) {
 
-    pub fun attach(as: Role, receiver: &AnyResource{Receiver}) {
-        // TODO: Now verify that the owner is valid.
+			status, err := runtime.CheckCapability(
+				signer,
+				cadence.Path{
+					Domain:     domain,
+					Identifier: identifier,
+				},
+				context,
+			)
+			require.NoError(t, err)
 
-        let capability = self.capabilities[as]!
-        receiver.receive(as: as, capability: capability)
-    }
+			require.Equal(t, expected, status)
+		})
+	}
 
-    init() {
-        self.account.save<@AAA>(<- create AAA(), to: /storage/TestAAA)
-        self.account.save<@BBB>(<- create BBB(), to: /storage/TestBBB)
+	test("public", "number", CapabilityStatus{
+		IsLinked:     true,
+		TargetExists: true,
+		IsBorrowable: true,
+	})
 
-        self.capabilities = {}
-        self.capabilities[Role.aaa] = self.account.link<&AAA>(/private/TestAAA, target: /storage/TestAAA)!
-        self.capabilities[Role.bbb] = self.account.link<&BBB>(/private/TestBBB, target: /storage/TestBBB)!
-    }
-}
+	test("private", "number", CapabilityStatus{
+		IsLinked:     true,
+		TargetExists: true,
+		IsBorrowable: true,
+	})
 
-`
+	test("public", "string", CapabilityStatus{
+		IsLinked:     true,
+		TargetExists: true,
+		IsBorrowable: false,
+	})
 
-	const tx = `
-import Test from 0x1
+	test("public", "removed", CapabilityStatus{
+		IsLinked:     true,
+		TargetExists: false,
+		IsBorrowable: false,
+	})
 
-transaction {
-    prepare(acct: AuthAccount) {}
-    execute {
-        let holder <- Test.createHolder()
-        Test.attach(as: Test.Role.aaa, receiver: &holder as &AnyResource{Test.Receiver})
-        destroy holder
-    }
-}
-`
+	test("public", "missing", CapabilityStatus{})
 
-	runtime := newTestInterpreterRuntime()
+	t.Run("storage path", func(t *testing.T) {
 
-	testAddress := common.BytesToAddress([]byte{0x1})
+		_, err := runtime.CheckCapability(
+			signer,
+			cadence.Path{
+				Domain:     "storage",
+				Identifier: "number",
+			},
+			context,
+		)
+		require.Error(t, err)
 
-	accountCodes := map[common.LocationID][]byte{}
+		var pathDomainErr interpreter.InvalidPathDomainError
+		require.ErrorAs(t, err, &pathDomainErr)
+	})
+}
 
-	var events []cadence.Event
+func TestRuntimeGetLinkTarget(t *testing.T) {
 
-	signerAccount := testAddress
+	t.Parallel()
+
+	runtime := newTestInterpreterRuntime()
+
+	signer := common.BytesToAddress([]byte{0x42})
 
 	runtimeInterface := &testRuntimeInterface{
-		getCode: func(location Location) (bytes []byte, err error) {
-			return accountCodes[location.ID()], nil
-		},
 		storage: newTestLedger(nil, nil),
 		getSigningAccounts: func() ([]Address, error) {
-			return []Address{signerAccount}, nil
-		},
-		resolveLocation: singleIdentifierLocationResolver(t),
-		getAccountContractCode: func(address Address, name string) (code []byte, err error) {
-			location := common.AddressLocation{
-				Address: address,
-				Name:    name,
-			}
-			return accountCodes[location.ID()], nil
-		},
-		updateAccountContractCode: func(address Address, name string, code []byte) error {
-			location := common.AddressLocation{
-				Address: address,
-				Name:    name,
-			}
-			accountCodes[location.ID()] = code
-			return nil
-		},
-		emitEvent: func(event cadence.Event) error {
-			events = append(events, event)
-			return nil
-		},
-		decodeArgument: func(b []byte, t cadence.Type) (value cadence.Value, err error) {
-			return json.Decode(b)
+			return []Address{signer}, nil
 		},
 	}
 
 	nextTransactionLocation := newTransactionLocationGenerator()
 
-	// Deploy contract
+	context := Context{
+		Interface: runtimeInterface,
+		Location:  nextTransactionLocation(),
+	}
 
 	err := runtime.ExecuteTransaction(
 		Script{
-			Source: utils.DeploymentTransaction(
-				"Test",
-				[]byte(contract),
-			),
-		},
-		Context{
-			Interface: runtimeInterface,
-			Location:  nextTransactionLocation(),
+			Source: []byte(`
+              transaction {
+                  prepare(signer: AuthAccount) {
+                      signer.save(1, to: /storage/number)
+                      signer.link<&Int>(/public/number, target: /storage/number)
+                      signer.link<&Int>(/private/number, target: /public/number)
+
+                      signer.link<&Int>(/public/dangling, target: /storage/missing)
+                  }
+              }
+            `),
 		},
+		context,
 	)
 	require.NoError(t, err)
 
-	// Run transaction
+	test := func(path cadence.Path, expectedTarget cadence.Path, expectedLinked bool) {
+
+		t.Run(fmt.Sprintf("/%s/%s", path.Domain, path.Identifier), func(t *testing.T) {
+
+			target, linked, err := runtime.GetLinkTarget(signer, path, context)
+			require.NoError(t, err)
+
+			require.Equal(t, expectedLinked, linked)
+			require.Equal(t, expectedTarget, target)
+		})
+	}
+
+	test(
+		cadence.Path{Domain: "public", Identifier: "number"},
+		cadence.Path{Domain: "storage", Identifier: "number"},
+		true,
+	)
+
+	test(
+		cadence.Path{Domain: "private", Identifier: "number"},
+		cadence.Path{Domain: "public", Identifier: "number"},
+		true,
+	)
+
+	test(
+		cadence.Path{Domain: "public", Identifier: "dangling"},
+		cadence.Path{Domain: "storage", Identifier: "missing"},
+		true,
+	)
+
+	test(
+		cadence.Path{Domain: "public", Identifier: "missing"},
+		cadence.Path{},
+		false,
+	)
+
+	t.Run("storage path", func(t *testing.T) {
+
+		_, _, err := runtime.GetLinkTarget(
+			signer,
+			cadence.Path{
+				Domain:     "storage",
+				Identifier: "number",
+			},
+			context,
+		)
+		require.Error(t, err)
+
+		var pathDomainErr interpreter.InvalidPathDomainError
+		require.ErrorAs(t, err, &pathDomainErr)
+	})
+}
+
+func TestRuntimeReadStoredResolvingCapabilities(t *testing.T) {
+
+	t.Parallel()
+
+	runtime := newTestInterpreterRuntime()
+
+	signer := common.BytesToAddress([]byte{0x42})
+
+	runtimeInterface := &testRuntimeInterface{
+		storage: newTestLedger(nil, nil),
+		getSigningAccounts: func() ([]Address, error) {
+			return []Address{signer}, nil
+		},
+	}
+
+	nextTransactionLocation := newTransactionLocationGenerator()
+
+	context := Context{
+		Interface: runtimeInterface,
+		Location:  nextTransactionLocation(),
+	}
+
+	err := runtime.ExecuteTransaction(
+		Script{
+			Source: []byte(`
+              transaction {
+                  prepare(signer: AuthAccount) {
+                      signer.save(42, to: /storage/number)
+                      signer.link<&Int>(/public/number, target: /storage/number)
+
+                      signer.save(
+                          signer.getCapability<&Int>(/public/number),
+                          to: /storage/capability
+                      )
+                      signer.save(
+                          signer.getCapability(/public/number),
+                          to: /storage/untypedCapability
+                      )
+                      signer.save(
+                          signer.getCapability<&Int>(/public/missing),
+                          to: /storage/danglingCapability
+                      )
+                      signer.save(
+                          signer.getCapability<&String>(/public/number),
+                          to: /storage/mistypedCapability
+                      )
+                  }
+              }
+            `),
+		},
+		context,
+	)
+	require.NoError(t, err)
+
+	read := func(identifier string) (cadence.Value, error) {
+		return runtime.ReadStoredResolvingCapabilities(
+			signer,
+			cadence.Path{
+				Domain:     "storage",
+				Identifier: identifier,
+			},
+			context,
+		)
+	}
+
+	t.Run("non-capability", func(t *testing.T) {
+
+		value, err := read("number")
+		require.NoError(t, err)
+		require.Equal(t, cadence.NewOptional(cadence.NewInt(42)), value)
+	})
+
+	t.Run("missing", func(t *testing.T) {
+
+		value, err := read("missing")
+		require.NoError(t, err)
+		require.Equal(t, cadence.NewOptional(nil), value)
+	})
+
+	for _, identifier := range []string{"capability", "untypedCapability"} {
+
+		t.Run(identifier, func(t *testing.T) {
+
+			value, err := read(identifier)
+			require.NoError(t, err)
+			require.Equal(t, cadence.NewOptional(cadence.NewInt(42)), value)
+		})
+	}
+
+	for _, identifier := range []string{"danglingCapability", "mistypedCapability"} {
+
+		t.Run(identifier, func(t *testing.T) {
+
+			_, err := read(identifier)
+			require.Error(t, err)
+
+			var brokenCapabilityErr BrokenCapabilityError
+			require.ErrorAs(t, err, &brokenCapabilityErr)
+		})
+	}
+}
+
+func TestRuntimeResolveViews(t *testing.T) {
+
+	t.Parallel()
+
+	runtime := newTestInterpreterRuntime()
+
+	signer := common.BytesToAddress([]byte{0x42})
+
+	deployTx := utils.DeploymentTransaction("Test", []byte(`
+      pub contract Test {
+
+          pub struct Display {
+              pub let name: String
+
+              init(name: String) {
+                  self.name = name
+              }
+          }
+
+          pub struct Royalties {}
+
+          pub resource NFT {
+              pub let id: UInt64
+
+              init(id: UInt64) {
+                  self.id = id
+              }
+
+              pub fun resolveView(_ view: Type): AnyStruct? {
+                  if view == Type<Display>() {
+                      return Display(name: "NFT #".concat(self.id.toString()))
+                  }
+                  return nil
+              }
+          }
+
+          pub fun createNFT(id: UInt64): @NFT {
+              return <-create NFT(id: id)
+          }
+
+          pub struct InvalidParameterResolver {
+              pub fun resolveView(_ view: Int): AnyStruct? {
+                  return nil
+              }
+          }
+
+          pub struct NonOptionalResolver {
+              pub fun resolveView(_ view: Type): AnyStruct {
+                  return 1
+              }
+          }
+      }
+    `))
+
+	accountCodes := map[common.LocationID][]byte{}
+
+	runtimeInterface := &testRuntimeInterface{
+		storage: newTestLedger(nil, nil),
+		getSigningAccounts: func() ([]Address, error) {
+			return []Address{signer}, nil
+		},
+		resolveLocation: singleIdentifierLocationResolver(t),
+		updateAccountContractCode: func(address Address, name string, code []byte) error {
+			location := common.AddressLocation{
+				Address: address,
+				Name:    name,
+			}
+			accountCodes[location.ID()] = code
+			return nil
+		},
+		getAccountContractCode: func(address Address, name string) (code []byte, err error) {
+			location := common.AddressLocation{
+				Address: address,
+				Name:    name,
+			}
+			code = accountCodes[location.ID()]
+			return code, nil
+		},
+		emitEvent: func(event cadence.Event) error {
+			return nil
+		},
+	}
+
+	nextTransactionLocation := newTransactionLocationGenerator()
+
+	err := runtime.ExecuteTransaction(
+		Script{
+			Source: deployTx,
+		},
+		Context{
+			Interface: runtimeInterface,
+			Location:  nextTransactionLocation(),
+		},
+	)
+	require.NoError(t, err)
 
 	err = runtime.ExecuteTransaction(
 		Script{
-			Source: []byte(tx),
+			Source: []byte(`
+              import Test from 0x42
+
+              transaction {
+                  prepare(signer: AuthAccount) {
+                      signer.save(<-Test.createNFT(id: 1), to: /storage/nft)
+                      signer.link<&Test.NFT>(/public/nft, target: /storage/nft)
+                      signer.save(Test.InvalidParameterResolver(), to: /storage/invalidParameter)
+                      signer.save(Test.NonOptionalResolver(), to: /storage/nonOptional)
+                  }
+              }
+            `),
 		},
 		Context{
 			Interface: runtimeInterface,
@@ -2128,4 +2675,3275 @@ transaction {
 		},
 	)
 	require.NoError(t, err)
+
+	location := common.AddressLocation{
+		Address: signer,
+		Name:    "Test",
+	}
+
+	displayType := &cadence.StructType{
+		Location:            location,
+		QualifiedIdentifier: "Test.Display",
+	}
+
+	royaltiesType := &cadence.StructType{
+		Location:            location,
+		QualifiedIdentifier: "Test.Royalties",
+	}
+
+	for _, domain := range []string{"storage", "public"} {
+
+		t.Run(domain, func(t *testing.T) {
+
+			views, err := runtime.ResolveViews(
+				signer,
+				cadence.Path{
+					Domain:     domain,
+					Identifier: "nft",
+				},
+				[]cadence.Type{
+					displayType,
+					royaltiesType,
+				},
+				Context{
+					Interface: runtimeInterface,
+					Location:  nextTransactionLocation(),
+				},
+			)
+			require.NoError(t, err)
+
+			// The royalties view is not resolved by the NFT
+
+			require.Len(t, views, 1)
+
+			display, ok := views["A.0000000000000042.Test.Display"].(cadence.Struct)
+			require.True(t, ok)
+
+			assert.Equal(t,
+				[]cadence.Value{
+					cadence.String("NFT #1"),
+				},
+				display.Fields,
+			)
+		})
+	}
+
+	t.Run("missing", func(t *testing.T) {
+
+		_, err := runtime.ResolveViews(
+			signer,
+			cadence.Path{
+				Domain:     "storage",
+				Identifier: "missing",
+			},
+			[]cadence.Type{
+				displayType,
+			},
+			Context{
+				Interface: runtimeInterface,
+				Location:  nextTransactionLocation(),
+			},
+		)
+		require.Error(t, err)
+
+		var viewResolverErr ViewResolverNotFoundError
+		require.ErrorAs(t, err, &viewResolverErr)
+	})
+
+	for _, identifier := range []string{"invalidParameter", "nonOptional"} {
+
+		identifier := identifier
+
+		t.Run(identifier, func(t *testing.T) {
+
+			_, err := runtime.ResolveViews(
+				signer,
+				cadence.Path{
+					Domain:     "storage",
+					Identifier: identifier,
+				},
+				[]cadence.Type{
+					displayType,
+				},
+				Context{
+					Interface: runtimeInterface,
+					Location:  nextTransactionLocation(),
+				},
+			)
+			require.Error(t, err)
+
+			var typeMismatchErr ViewResolverTypeMismatchError
+			require.ErrorAs(t, err, &typeMismatchErr)
+		})
+	}
+}
+
+func TestRuntimeStorageReadLimit(t *testing.T) {
+
+	t.Parallel()
+
+	runtime := newTestInterpreterRuntime()
+
+	signer := common.BytesToAddress([]byte{0x42})
+
+	runtimeInterface := &testRuntimeInterface{
+		storage: newTestLedger(nil, nil),
+		getSigningAccounts: func() ([]Address, error) {
+			return []Address{signer}, nil
+		},
+	}
+
+	nextTransactionLocation := newTransactionLocationGenerator()
+
+	// Store an array which is large enough to be split into many slabs
+
+	err := runtime.ExecuteTransaction(
+		Script{
+			Source: []byte(`
+              transaction {
+                  prepare(signer: AuthAccount) {
+                      let numbers: [Int] = []
+                      var i = 0
+                      while i < 1000 {
+                          numbers.append(i)
+                          i = i + 1
+                      }
+                      signer.save(numbers, to: /storage/numbers)
+                  }
+              }
+            `),
+		},
+		Context{
+			Interface: runtimeInterface,
+			Location:  nextTransactionLocation(),
+		},
+	)
+	require.NoError(t, err)
+
+	const maxStorageReads = 5
+
+	err = runtime.ExecuteTransaction(
+		Script{
+			Source: []byte(`
+              transaction {
+                  prepare(signer: AuthAccount) {
+                      let numbers = signer.borrow<&[Int]>(from: /storage/numbers)!
+                      var sum = 0
+                      var i = 0
+                      while i < numbers.length {
+                          sum = sum + numbers[i]
+                          i = i + 1
+                      }
+                  }
+              }
+            `),
+		},
+		Context{
+			Interface:       runtimeInterface,
+			Location:        nextTransactionLocation(),
+			MaxStorageReads: maxStorageReads,
+		},
+	)
+	require.Error(t, err)
+
+	var storageReadErr StorageReadLimitExceededError
+	require.ErrorAs(t, err, &storageReadErr)
+
+	assert.Equal(t,
+		StorageReadLimitExceededError{
+			Count: maxStorageReads + 1,
+			Limit: maxStorageReads,
+		},
+		storageReadErr,
+	)
+
+	var runtimeErr Error
+	require.ErrorAs(t, err, &runtimeErr)
+
+	assert.Equal(t,
+		&LimitExceeded{
+			Kind:  LimitKindStorageReads,
+			Limit: maxStorageReads,
+		},
+		runtimeErr.LimitExceeded,
+	)
+}
+
+func TestRuntimeStorageCapabilityBorrowTypeNotFound(t *testing.T) {
+
+	t.Parallel()
+
+	runtime := newTestInterpreterRuntime()
+
+	signer := common.BytesToAddress([]byte{0x1})
+
+	contract := []byte(`
+      pub contract Test {
+          pub struct S {}
+      }
+    `)
+
+	accountCodes := map[common.LocationID][]byte{}
+
+	runtimeInterface := &testRuntimeInterface{
+		storage: newTestLedger(nil, nil),
+		getSigningAccounts: func() ([]Address, error) {
+			return []Address{signer}, nil
+		},
+		// NOTE: do not keep programs in the host environment,
+		// so the removal of the contract is effective
+		getProgram: func(_ Location) (*interpreter.Program, error) {
+			return nil, nil
+		},
+		setProgram: func(_ Location, _ *interpreter.Program) error {
+			return nil
+		},
+		resolveLocation: singleIdentifierLocationResolver(t),
+		getAccountContractCode: func(address Address, name string) (code []byte, err error) {
+			location := common.AddressLocation{
+				Address: address,
+				Name:    name,
+			}
+			return accountCodes[location.ID()], nil
+		},
+		updateAccountContractCode: func(address Address, name string, code []byte) error {
+			location := common.AddressLocation{
+				Address: address,
+				Name:    name,
+			}
+			accountCodes[location.ID()] = code
+			return nil
+		},
+		removeAccountContractCode: func(address Address, name string) error {
+			location := common.AddressLocation{
+				Address: address,
+				Name:    name,
+			}
+			delete(accountCodes, location.ID())
+			return nil
+		},
+		emitEvent: func(event cadence.Event) error {
+			return nil
+		},
+	}
+
+	nextTransactionLocation := newTransactionLocationGenerator()
+
+	executeTransaction := func(source []byte) error {
+		return runtime.ExecuteTransaction(
+			Script{
+				Source: source,
+			},
+			Context{
+				Interface: runtimeInterface,
+				Location:  nextTransactionLocation(),
+			},
+		)
+	}
+
+	err := executeTransaction(utils.DeploymentTransaction("Test", contract))
+	require.NoError(t, err)
+
+	// Store a capability with a borrow type declared by the contract
+
+	err = executeTransaction([]byte(`
+      import Test from 0x1
+
+      transaction {
+          prepare(signer: AuthAccount) {
+              let cap = signer.getCapability<&Test.S>(/public/s)
+              signer.save(cap, to: /storage/cap)
+          }
+      }
+    `))
+	require.NoError(t, err)
+
+	// Remove the contract which declares the borrow type
+
+	err = executeTransaction(utils.RemovalTransaction("Test"))
+	require.NoError(t, err)
+
+	// Load and borrow the orphaned capability
+
+	err = executeTransaction([]byte(`
+      transaction {
+          prepare(signer: AuthAccount) {
+              let cap = signer.copy<Capability>(from: /storage/cap)!
+              cap.borrow<&AnyStruct>()
+          }
+      }
+    `))
+	require.Error(t, err)
+
+	var borrowTypeNotFoundErr interpreter.CapabilityBorrowTypeNotFoundError
+	require.ErrorAs(t, err, &borrowTypeNotFoundErr)
+
+	assert.Equal(t,
+		common.AddressLocation{
+			Address: signer,
+			Name:    "Test",
+		},
+		borrowTypeNotFoundErr.Location,
+	)
+	assert.Equal(t, "Test.S", borrowTypeNotFoundErr.QualifiedIdentifier)
+}
+
+func TestRuntimeStorageReadUnknownType(t *testing.T) {
+
+	t.Parallel()
+
+	runtime := newTestInterpreterRuntime()
+
+	signer := common.BytesToAddress([]byte{0x1})
+
+	contract := []byte(`
+      pub contract Test {
+
+          pub resource R {
+              pub let id: Int
+
+              init(id: Int) {
+                  self.id = id
+              }
+          }
+
+          pub fun createR(id: Int): @R {
+              return <-create R(id: id)
+          }
+      }
+    `)
+
+	accountCodes := map[common.LocationID][]byte{}
+
+	runtimeInterface := &testRuntimeInterface{
+		storage: newTestLedger(nil, nil),
+		getSigningAccounts: func() ([]Address, error) {
+			return []Address{signer}, nil
+		},
+		// NOTE: do not keep programs in the host environment,
+		// so the removal of the contract is effective
+		getProgram: func(_ Location) (*interpreter.Program, error) {
+			return nil, nil
+		},
+		setProgram: func(_ Location, _ *interpreter.Program) error {
+			return nil
+		},
+		resolveLocation: singleIdentifierLocationResolver(t),
+		getAccountContractCode: func(address Address, name string) (code []byte, err error) {
+			location := common.AddressLocation{
+				Address: address,
+				Name:    name,
+			}
+			return accountCodes[location.ID()], nil
+		},
+		updateAccountContractCode: func(address Address, name string, code []byte) error {
+			location := common.AddressLocation{
+				Address: address,
+				Name:    name,
+			}
+			accountCodes[location.ID()] = code
+			return nil
+		},
+		removeAccountContractCode: func(address Address, name string) error {
+			location := common.AddressLocation{
+				Address: address,
+				Name:    name,
+			}
+			delete(accountCodes, location.ID())
+			return nil
+		},
+		emitEvent: func(event cadence.Event) error {
+			return nil
+		},
+	}
+
+	nextTransactionLocation := newTransactionLocationGenerator()
+
+	executeTransaction := func(source []byte) {
+		err := runtime.ExecuteTransaction(
+			Script{
+				Source: source,
+			},
+			Context{
+				Interface: runtimeInterface,
+				Location:  nextTransactionLocation(),
+			},
+		)
+		require.NoError(t, err)
+	}
+
+	executeTransaction(utils.DeploymentTransaction("Test", contract))
+
+	executeTransaction([]byte(`
+      import Test from 0x1
+
+      transaction {
+          prepare(signer: AuthAccount) {
+              signer.save(<-Test.createR(id: 42), to: /storage/r)
+          }
+      }
+    `))
+
+	// Remove the contract which declares the type of the stored value
+
+	executeTransaction(utils.RemovalTransaction("Test"))
+
+	path := cadence.Path{
+		Domain:     "storage",
+		Identifier: "r",
+	}
+
+	t.Run("substitute", func(t *testing.T) {
+
+		tombstoneType := &cadence.ResourceType{
+			QualifiedIdentifier: "Tombstone",
+			Fields: []cadence.Field{
+				{
+					Identifier: "id",
+					Type:       cadence.IntType{},
+				},
+			},
+		}
+
+		var unknownTypeIDs []string
+
+		value, err := runtime.ReadStored(
+			signer,
+			path,
+			Context{
+				Interface: runtimeInterface,
+				Location:  common.ScriptLocation{},
+				UnknownTypeHandler: func(typeID string) (cadence.Type, error) {
+					unknownTypeIDs = append(unknownTypeIDs, typeID)
+					return tombstoneType, nil
+				},
+			},
+		)
+		require.NoError(t, err)
+
+		require.Equal(t, []string{"A.0000000000000001.Test.R"}, unknownTypeIDs)
+
+		require.Equal(t,
+			cadence.NewOptional(
+				cadence.NewResource([]cadence.Value{
+					cadence.NewInt(42),
+				}).WithType(tombstoneType),
+			),
+			value,
+		)
+	})
+
+	t.Run("error", func(t *testing.T) {
+
+		handlerErr := errors.New("unknown type")
+
+		_, err := runtime.ReadStored(
+			signer,
+			path,
+			Context{
+				Interface: runtimeInterface,
+				Location:  common.ScriptLocation{},
+				UnknownTypeHandler: func(typeID string) (cadence.Type, error) {
+					return nil, handlerErr
+				},
+			},
+		)
+		require.ErrorIs(t, err, handlerErr)
+	})
+}
+
+func TestRuntimeStorageReferenceCast(t *testing.T) {
+
+	t.Parallel()
+
+	runtime := newTestInterpreterRuntime()
+
+	signerAddress := common.BytesToAddress([]byte{0x42})
+
+	deployTx := utils.DeploymentTransaction("Test", []byte(`
+      pub contract Test {
+
+          pub resource interface RI {}
+
+          pub resource R: RI {}
+
+          pub fun createR(): @R {
+              return <-create R()
+          }
+      }
+    `))
+
+	accountCodes := map[common.LocationID][]byte{}
+	var events []cadence.Event
+	var loggedMessages []string
+
+	runtimeInterface := &testRuntimeInterface{
+		storage: newTestLedger(nil, nil),
+		getSigningAccounts: func() ([]Address, error) {
+			return []Address{signerAddress}, nil
+		},
+		resolveLocation: singleIdentifierLocationResolver(t),
+		updateAccountContractCode: func(address Address, name string, code []byte) error {
+			location := common.AddressLocation{
+				Address: address,
+				Name:    name,
+			}
+			accountCodes[location.ID()] = code
+			return nil
+		},
+		getAccountContractCode: func(address Address, name string) (code []byte, err error) {
+			location := common.AddressLocation{
+				Address: address,
+				Name:    name,
+			}
+			code = accountCodes[location.ID()]
+			return code, nil
+		},
+		emitEvent: func(event cadence.Event) error {
+			events = append(events, event)
+			return nil
+		},
+		log: func(message string) {
+			loggedMessages = append(loggedMessages, message)
+		},
+	}
+
+	nextTransactionLocation := newTransactionLocationGenerator()
+
+	// Deploy contract
+
+	err := runtime.ExecuteTransaction(
+		Script{
+			Source: deployTx,
+		},
+		Context{
+			Interface: runtimeInterface,
+			Location:  nextTransactionLocation(),
+		},
+	)
+	require.NoError(t, err)
+
+	// Run test transaction
+
+	const testTx = `
+      import Test from 0x42
+
+      transaction {
+          prepare(signer: AuthAccount) {
+              signer.save(<-Test.createR(), to: /storage/r)
+
+              signer.link<&Test.R{Test.RI}>(
+                 /public/r,
+                 target: /storage/r
+              )
+
+              let ref = signer.getCapability<&Test.R{Test.RI}>(/public/r).borrow()!
+
+              let casted = (ref as AnyStruct) as! &Test.R
+          }
+      }
+    `
+
+	err = runtime.ExecuteTransaction(
+		Script{
+			Source: []byte(testTx),
+		},
+		Context{
+			Interface: runtimeInterface,
+			Location:  nextTransactionLocation(),
+		},
+	)
+
+	require.Error(t, err)
+
+	// The cast always fails, as restricted references cannot be widened,
+	// so it is rejected statically
+
+	var checkerErr *sema.CheckerError
+	require.ErrorAs(t, err, &checkerErr)
+
+	errs := checkerErr.Errors
+	require.Len(t, errs, 1)
+
+	assert.IsType(t, &sema.InvalidRestrictedReferenceWideningError{}, errs[0])
+}
+
+func TestRuntimeStorageReferenceFailableCast(t *testing.T) {
+
+	t.Parallel()
+
+	runtime := newTestInterpreterRuntime()
+
+	signerAddress := common.BytesToAddress([]byte{0x42})
+
+	deployTx := utils.DeploymentTransaction("Test", []byte(`
+      pub contract Test {
+
+          pub resource interface RI {}
+
+          pub resource R: RI {}
+
+          pub fun createR(): @R {
+              return <-create R()
+          }
+      }
+    `))
+
+	accountCodes := map[common.LocationID][]byte{}
+	var loggedMessages []string
+
+	runtimeInterface := &testRuntimeInterface{
+		storage: newTestLedger(nil, nil),
+		getSigningAccounts: func() ([]Address, error) {
+			return []Address{signerAddress}, nil
+		},
+		resolveLocation: singleIdentifierLocationResolver(t),
+		updateAccountContractCode: func(address Address, name string, code []byte) error {
+			location := common.AddressLocation{
+				Address: address,
+				Name:    name,
+			}
+			accountCodes[location.ID()] = code
+			return nil
+		},
+		getAccountContractCode: func(address Address, name string) (code []byte, err error) {
+			location := common.AddressLocation{
+				Address: address,
+				Name:    name,
+			}
+			code = accountCodes[location.ID()]
+			return code, nil
+		},
+		emitEvent: func(event cadence.Event) error {
+			return nil
+		},
+		log: func(message string) {
+			loggedMessages = append(loggedMessages, message)
+		},
+	}
+
+	nextTransactionLocation := newTransactionLocationGenerator()
+
+	// Deploy contract
+
+	err := runtime.ExecuteTransaction(
+		Script{
+			Source: deployTx,
+		},
+		Context{
+			Interface: runtimeInterface,
+			Location:  nextTransactionLocation(),
+		},
+	)
+	require.NoError(t, err)
+
+	// Run test transaction.
+	// The failable casts result in nil exactly where the force casts fail
+
+	const testTx = `
+      import Test from 0x42
+
+      transaction {
+          prepare(signer: AuthAccount) {
+              signer.save(<-Test.createR(), to: /storage/r)
+
+              signer.link<&Test.R{Test.RI}>(
+                 /public/r,
+                 target: /storage/r
+              )
+
+              signer.link<auth &Test.R{Test.RI}>(
+                 /private/r,
+                 target: /storage/r
+              )
+
+              let ref = signer.getCapability<&Test.R{Test.RI}>(/public/r).borrow()!
+              log(ref as? &Test.R)
+              log((ref as AnyStruct) as? &Test.R)
+
+              let authRef = signer.getCapability<auth &Test.R{Test.RI}>(/private/r).borrow()!
+              log((authRef as? &Test.R) != nil)
+          }
+      }
+    `
+
+	err = runtime.ExecuteTransaction(
+		Script{
+			Source: []byte(testTx),
+		},
+		Context{
+			Interface: runtimeInterface,
+			Location:  nextTransactionLocation(),
+		},
+	)
+	require.NoError(t, err)
+
+	require.Equal(t,
+		[]string{
+			"nil",
+			"nil",
+			"true",
+		},
+		loggedMessages,
+	)
+}
+
+func TestRuntimeStorageNonStorable(t *testing.T) {
+
+	t.Parallel()
+
+	runtime := newTestInterpreterRuntime()
+
+	address := common.BytesToAddress([]byte{0x1})
+
+	for name, code := range map[string]string{
+		"ephemeral reference": `
+            let value = &1 as &Int
+        `,
+		"storage reference": `
+            signer.save("test", to: /storage/string)
+            let value = signer.borrow<&String>(from: /storage/string)!
+        `,
+		"function": `
+            let value = fun () {}
+        `,
+		"reference nested in container": `
+            let value = [{"ref": &1 as &Int}]
+        `,
+		"function nested in optional": `
+            let value: [AnyStruct?] = [fun () {}]
+        `,
+	} {
+
+		t.Run(name, func(t *testing.T) {
+
+			tx := []byte(
+				fmt.Sprintf(
+					`
+                      transaction {
+                          prepare(signer: AuthAccount) {
+                              %s
+                              signer.save((value as AnyStruct), to: /storage/value)
+                          }
+                       }
+                    `,
+					code,
+				),
+			)
+
+			runtimeInterface := &testRuntimeInterface{
+				storage: newTestLedger(nil, nil),
+				getSigningAccounts: func() ([]Address, error) {
+					return []Address{address}, nil
+				},
+			}
+
+			nextTransactionLocation := newTransactionLocationGenerator()
+
+			err := runtime.ExecuteTransaction(
+				Script{
+					Source: tx,
+				},
+				Context{
+					Interface: runtimeInterface,
+					Location:  nextTransactionLocation(),
+				},
+			)
+			require.Error(t, err)
+
+			require.Contains(t, err.Error(), "cannot store non-storable value")
+
+			// The error is reported at the location of the save call
+
+			var nonStorableErr interpreter.NonStorableValueError
+			require.ErrorAs(t, err, &nonStorableErr)
+
+			startOffset := nonStorableErr.StartPosition().Offset
+			endOffset := nonStorableErr.EndPosition().Offset
+			require.Equal(t,
+				"signer.save((value as AnyStruct), to: /storage/value)",
+				string(tx[startOffset:endOffset+1]),
+			)
+		})
+	}
+}
+
+func TestRuntimeStorageRejectStoredReferences(t *testing.T) {
+
+	t.Parallel()
+
+	runtime := newTestInterpreterRuntime()
+
+	address := common.BytesToAddress([]byte{0x1})
+
+	tx := []byte(`
+      transaction {
+          prepare(signer: AuthAccount) {
+              let value = &1 as &Int
+              signer.save((value as AnyStruct), to: /storage/value)
+          }
+      }
+    `)
+
+	runtimeInterface := &testRuntimeInterface{
+		storage: newTestLedger(nil, nil),
+		getSigningAccounts: func() ([]Address, error) {
+			return []Address{address}, nil
+		},
+	}
+
+	nextTransactionLocation := newTransactionLocationGenerator()
+
+	err := runtime.ExecuteTransaction(
+		Script{
+			Source: tx,
+		},
+		Context{
+			Interface:              runtimeInterface,
+			Location:               nextTransactionLocation(),
+			RejectStoredReferences: true,
+		},
+	)
+	require.Error(t, err)
+
+	var checkerErr *sema.CheckerError
+	require.ErrorAs(t, err, &checkerErr)
+
+	errs := checker.ExpectCheckerErrors(t, checkerErr, 1)
+
+	require.IsType(t, &sema.StoredReferenceError{}, errs[0])
+}
+
+func TestRuntimeStorageRecursiveReference(t *testing.T) {
+
+	t.Parallel()
+
+	runtime := newTestInterpreterRuntime()
+
+	address := common.BytesToAddress([]byte{0x1})
+
+	const code = `
+      transaction {
+          prepare(signer: AuthAccount) {
+              let refs: [AnyStruct] = []
+              refs.insert(at: 0, &refs as &AnyStruct)
+              signer.save(refs, to: /storage/refs)
+          }
+      }
+    `
+
+	runtimeInterface := &testRuntimeInterface{
+		storage: newTestLedger(nil, nil),
+		getSigningAccounts: func() ([]Address, error) {
+			return []Address{address}, nil
+		},
+	}
+
+	nextTransactionLocation := newTransactionLocationGenerator()
+
+	err := runtime.ExecuteTransaction(
+		Script{
+			Source: []byte(code),
+		},
+		Context{
+			Interface: runtimeInterface,
+			Location:  nextTransactionLocation(),
+		},
+	)
+	require.Error(t, err)
+
+	require.Contains(t, err.Error(), "cannot store non-storable value")
+}
+
+func TestRuntimeStorageValueDepthLimit(t *testing.T) {
+
+	t.Parallel()
+
+	runtime := newTestInterpreterRuntime()
+
+	address := common.BytesToAddress([]byte{0x1})
+
+	// The value is nested 10 arrays deep
+
+	const saveCode = `
+      transaction {
+          prepare(signer: AuthAccount) {
+              var value: AnyStruct = 1
+              var i = 0
+              while i < 10 {
+                  value = [value]
+                  i = i + 1
+              }
+              signer.save(value, to: /storage/value)
+          }
+      }
+    `
+
+	// The appended value is nested 10 arrays deep,
+	// and it is stored in a container which is already stored,
+	// so the depth is counted from the root of the stored value, i.e. it is 11
+
+	const appendCode = `
+      transaction {
+          prepare(signer: AuthAccount) {
+              var value: AnyStruct = 1
+              var i = 0
+              while i < 10 {
+                  value = [value]
+                  i = i + 1
+              }
+              let values = signer.borrow<&[AnyStruct]>(from: /storage/values)
+                  ?? panic("missing values")
+              values.append(value)
+          }
+      }
+    `
+
+	runtimeInterface := &testRuntimeInterface{
+		storage: newTestLedger(nil, nil),
+		getSigningAccounts: func() ([]Address, error) {
+			return []Address{address}, nil
+		},
+	}
+
+	nextTransactionLocation := newTransactionLocationGenerator()
+
+	execute := func(code string, maxValueDepth int) error {
+		return runtime.ExecuteTransaction(
+			Script{
+				Source: []byte(code),
+			},
+			Context{
+				Interface:     runtimeInterface,
+				Location:      nextTransactionLocation(),
+				MaxValueDepth: maxValueDepth,
+			},
+		)
+	}
+
+	err := execute(saveCode, 9)
+	require.Error(t, err)
+
+	var depthErr interpreter.ValueDepthLimitExceededError
+	require.ErrorAs(t, err, &depthErr)
+
+	assert.Equal(t, 9, depthErr.Limit)
+
+	err = execute(saveCode, 10)
+	require.NoError(t, err)
+
+	err = execute(
+		`
+          transaction {
+              prepare(signer: AuthAccount) {
+                  let values: [AnyStruct] = []
+                  signer.save(values, to: /storage/values)
+              }
+          }
+        `,
+		0,
+	)
+	require.NoError(t, err)
+
+	err = execute(appendCode, 10)
+	require.Error(t, err)
+
+	require.ErrorAs(t, err, &depthErr)
+
+	assert.Equal(t, 10, depthErr.Limit)
+
+	err = execute(appendCode, 11)
+	require.NoError(t, err)
+
+	// The inserted value is nested 10 arrays deep,
+	// and it is stored in an array which is nested in an optional, a dictionary, and an array,
+	// so the depth is counted from the root of the stored value, i.e. it is 14
+
+	const insertNestedCode = `
+      transaction {
+          prepare(signer: AuthAccount) {
+              var value: AnyStruct = 1
+              var i = 0
+              while i < 10 {
+                  value = [value]
+                  i = i + 1
+              }
+              let values = signer.borrow<&[{String: [AnyStruct]}?]>(from: /storage/nested)
+                  ?? panic("missing values")
+              values[0]!["a"]!.append(value)
+          }
+      }
+    `
+
+	err = execute(
+		`
+          transaction {
+              prepare(signer: AuthAccount) {
+                  let values: [{String: [AnyStruct]}?] = [{"a": []}]
+                  signer.save(values, to: /storage/nested)
+              }
+          }
+        `,
+		0,
+	)
+	require.NoError(t, err)
+
+	err = execute(insertNestedCode, 13)
+	require.Error(t, err)
+
+	require.ErrorAs(t, err, &depthErr)
+
+	assert.Equal(t, 13, depthErr.Limit)
+
+	err = execute(insertNestedCode, 14)
+	require.NoError(t, err)
+}
+
+func TestRuntimeStorageTransfer(t *testing.T) {
+
+	t.Parallel()
+
+	runtime := newTestInterpreterRuntime()
+
+	address1 := common.BytesToAddress([]byte{0x1})
+	address2 := common.BytesToAddress([]byte{0x2})
+
+	ledger := newTestLedger(nil, nil)
+
+	var signers []Address
+
+	runtimeInterface := &testRuntimeInterface{
+		storage: ledger,
+		getSigningAccounts: func() ([]Address, error) {
+			return signers, nil
+		},
+	}
+
+	nextTransactionLocation := newTransactionLocationGenerator()
+
+	// Store
+
+	signers = []Address{address1}
+
+	storeTx := []byte(`
+      transaction {
+          prepare(signer: AuthAccount) {
+              signer.save([1], to: /storage/test)
+          }
+       }
+    `)
+
+	err := runtime.ExecuteTransaction(
+		Script{
+			Source: storeTx,
+		},
+		Context{
+			Interface: runtimeInterface,
+			Location:  nextTransactionLocation(),
+		},
+	)
+	require.NoError(t, err)
+
+	// Transfer
+
+	signers = []Address{address1, address2}
+
+	transferTx := []byte(`
+      transaction {
+          prepare(signer1: AuthAccount, signer2: AuthAccount) {
+              let value = signer1.load<[Int]>(from: /storage/test)!
+              signer2.save(value, to: /storage/test)
+          }
+       }
+    `)
+
+	err = runtime.ExecuteTransaction(
+		Script{
+			Source: transferTx,
+		},
+		Context{
+			Interface: runtimeInterface,
+			Location:  nextTransactionLocation(),
+		},
+	)
+	require.NoError(t, err)
+
+	var nonEmptyKeys int
+	for _, data := range ledger.storedValues {
+		if len(data) > 0 {
+			nonEmptyKeys++
+		}
+	}
+	assert.Equal(t, 2, nonEmptyKeys)
+}
+
+func TestRuntimePendingStorageDelta(t *testing.T) {
+
+	t.Parallel()
+
+	runtime := newTestInterpreterRuntime()
+
+	script := []byte(`
+      transaction {
+        prepare(signer: AuthAccount) {
+           log(getPendingStorageDelta())
+           signer.save("answer", to: /storage/answer)
+           log(getPendingStorageDelta() > 0)
+           signer.load<String>(from: /storage/answer)
+           log(getPendingStorageDelta())
+        }
+      }
+    `)
+
+	var loggedMessages []string
+
+	runtimeInterface := &testRuntimeInterface{
+		storage: newTestLedger(nil, nil),
+		getSigningAccounts: func() ([]Address, error) {
+			return []Address{{42}}, nil
+		},
+		log: func(message string) {
+			loggedMessages = append(loggedMessages, message)
+		},
+	}
+
+	nextTransactionLocation := newTransactionLocationGenerator()
+
+	err := runtime.ExecuteTransaction(
+		Script{
+			Source: script,
+		},
+		Context{
+			Interface: runtimeInterface,
+			Location:  nextTransactionLocation(),
+		},
+	)
+	require.NoError(t, err)
+
+	require.Equal(t,
+		[]string{"0", "true", "0"},
+		loggedMessages,
+	)
+}
+
+func TestRuntimePendingStorageDeltaContainers(t *testing.T) {
+
+	t.Parallel()
+
+	runtime := newTestInterpreterRuntime()
+
+	saveTransaction := []byte(`
+      transaction {
+        prepare(signer: AuthAccount) {
+           var numbers: [Int] = []
+           var i = 0
+           while i < 100 {
+               numbers.append(i)
+               i = i + 1
+           }
+           signer.save(numbers, to: /storage/numbers)
+           log(getPendingStorageDelta() > 500)
+        }
+      }
+    `)
+
+	appendTransaction := []byte(`
+      transaction {
+        prepare(signer: AuthAccount) {
+           log(getPendingStorageDelta())
+           let numbers = signer.borrow<&[Int]>(from: /storage/numbers)!
+           numbers.append(100)
+           log(getPendingStorageDelta() > 0)
+        }
+      }
+    `)
+
+	loadTransaction := []byte(`
+      transaction {
+        prepare(signer: AuthAccount) {
+           signer.load<[Int]>(from: /storage/numbers)
+           log(getPendingStorageDelta() < -500)
+        }
+      }
+    `)
+
+	var loggedMessages []string
+
+	runtimeInterface := &testRuntimeInterface{
+		storage: newTestLedger(nil, nil),
+		getSigningAccounts: func() ([]Address, error) {
+			return []Address{{42}}, nil
+		},
+		log: func(message string) {
+			loggedMessages = append(loggedMessages, message)
+		},
+	}
+
+	nextTransactionLocation := newTransactionLocationGenerator()
+
+	for _, transaction := range [][]byte{
+		saveTransaction,
+		appendTransaction,
+		loadTransaction,
+	} {
+		err := runtime.ExecuteTransaction(
+			Script{
+				Source: transaction,
+			},
+			Context{
+				Interface: runtimeInterface,
+				Location:  nextTransactionLocation(),
+			},
+		)
+		require.NoError(t, err)
+	}
+
+	require.Equal(t,
+		[]string{"true", "0", "true", "true"},
+		loggedMessages,
+	)
+}
+
+func TestRuntimePendingStorageDeltaNoLedgerReads(t *testing.T) {
+
+	t.Parallel()
+
+	address := common.BytesToAddress([]byte{0x1})
+
+	const key = "numbers"
+
+	reportMetric := func(f func(), _ func(metrics Metrics, duration time.Duration)) {
+		f()
+	}
+
+	var reads int
+
+	ledger := newTestLedger(
+		func(_, _, _ []byte) {
+			reads++
+		},
+		nil,
+	)
+
+	newStorageAndInterpreter := func() (*Storage, *interpreter.Interpreter) {
+		storage := NewStorage(ledger, reportMetric)
+
+		inter, err := interpreter.NewInterpreter(
+			nil,
+			utils.TestLocation,
+			interpreter.WithStorage(storage),
+		)
+		require.NoError(t, err)
+
+		return storage, inter
+	}
+
+	// Store an array which spans multiple slabs
+
+	storage, inter := newStorageAndInterpreter()
+
+	elements := make([]interpreter.Value, 1000)
+	for i := range elements {
+		elements[i] = interpreter.NewIntValueFromInt64(int64(i))
+	}
+
+	array := interpreter.NewArrayValue(
+		inter,
+		interpreter.VariableSizedStaticType{
+			Type: interpreter.PrimitiveStaticTypeInt,
+		},
+		address,
+		elements...,
+	)
+
+	storage.WriteValue(inter, address, key, interpreter.NewSomeValueNonCopying(array))
+
+	assert.Greater(t, storage.PendingStorageDelta(), int64(1000))
+
+	err := storage.Commit(inter, false)
+	require.NoError(t, err)
+
+	assert.Zero(t, storage.PendingStorageDelta())
+
+	// Append to the stored array
+
+	storage, inter = newStorageAndInterpreter()
+
+	storedValue := storage.ReadValue(inter, address, key)
+	require.IsType(t, &interpreter.SomeValue{}, storedValue)
+
+	storedArray := storedValue.(*interpreter.SomeValue).Value.(*interpreter.ArrayValue)
+	storedArray.Append(inter, interpreter.ReturnEmptyLocationRange, interpreter.NewIntValueFromInt64(1000))
+
+	// Getting the pending storage delta does not read from the ledger
+
+	reads = 0
+
+	assert.Greater(t, storage.PendingStorageDelta(), int64(0))
+
+	assert.Zero(t, reads)
+
+	// Removing the array removes all of its slabs
+
+	storage.WriteValue(inter, address, key, interpreter.NilValue{})
+
+	assert.Less(t, storage.PendingStorageDelta(), int64(-1000))
+}
+
+func TestRuntimeStorageUsed(t *testing.T) {
+
+	t.Parallel()
+
+	runtime := newTestInterpreterRuntime()
+
+	ledger := newTestLedger(nil, nil)
+
+	runtimeInterface := &testRuntimeInterface{
+		storage: ledger,
+		getStorageUsed: func(_ Address) (uint64, error) {
+			return 1, nil
+		},
+	}
+
+	// NOTE: do NOT change the contents of this script,
+	// it matters how the array is constructed,
+	// ESPECIALLY the value of the addresses and the number of elements!
+	//
+	// Querying storageUsed commits storage, and this test asserts
+	// that this should not clear temporary slabs
+
+	script := []byte(`
+       pub fun main() {
+            var addresses: [Address]= [
+                0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731,
+                0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731,
+                0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731,
+                0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731,
+                0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731,
+                0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731,
+                0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731,
+                0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731,
+                0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731,
+                0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731,
+                0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731,
+                0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731,
+                0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731,
+                0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731,
+                0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731,
+                0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731,
+                0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731,
+                0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731,
+                0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731,
+                0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731, 0x2a3c4c2581cef731
+            ]
+            var count = 0
+            for address in addresses {
+                let account = getAccount(address)
+                var x = account.storageUsed
+            }
+        }
+    `)
+
+	_, err := runtime.ExecuteScript(
+		Script{
+			Source: script,
+		},
+		Context{
+			Interface: runtimeInterface,
+			Location:  common.ScriptLocation{},
+		},
+	)
+	require.NoError(t, err)
+
+}
+
+func TestRuntimeStorageUsedCache(t *testing.T) {
+
+	t.Parallel()
+
+	address := common.BytesToAddress([]byte{0x1})
+
+	newRuntimeInterface := func(getStorageUsedCalls *int) *testRuntimeInterface {
+		return &testRuntimeInterface{
+			storage: newTestLedger(nil, nil),
+			getSigningAccounts: func() ([]Address, error) {
+				return []Address{address}, nil
+			},
+			getStorageUsed: func(_ Address) (uint64, error) {
+				*getStorageUsedCalls++
+				return 1, nil
+			},
+		}
+	}
+
+	const script = `
+      pub fun main() {
+          let account = getAccount(0x1)
+          account.storageUsed
+          account.storageUsed
+          account.storageUsed
+      }
+    `
+
+	const transaction = `
+      transaction {
+          prepare(signer: AuthAccount) {
+              signer.storageUsed
+              signer.storageUsed
+              signer.save(1, to: /storage/one)
+              signer.storageUsed
+              signer.storageUsed
+              getAccount(0x2).storageUsed
+          }
+      }
+    `
+
+	t.Run("script, cached", func(t *testing.T) {
+
+		t.Parallel()
+
+		var getStorageUsedCalls int
+
+		_, err := newTestInterpreterRuntime().ExecuteScript(
+			Script{
+				Source: []byte(script),
+			},
+			Context{
+				Interface:        newRuntimeInterface(&getStorageUsedCalls),
+				Location:         common.ScriptLocation{},
+				CacheStorageUsed: true,
+			},
+		)
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, getStorageUsedCalls)
+	})
+
+	t.Run("script, not cached", func(t *testing.T) {
+
+		t.Parallel()
+
+		var getStorageUsedCalls int
+
+		_, err := newTestInterpreterRuntime().ExecuteScript(
+			Script{
+				Source: []byte(script),
+			},
+			Context{
+				Interface: newRuntimeInterface(&getStorageUsedCalls),
+				Location:  common.ScriptLocation{},
+			},
+		)
+		require.NoError(t, err)
+
+		assert.Equal(t, 3, getStorageUsedCalls)
+	})
+
+	t.Run("transaction, write invalidates", func(t *testing.T) {
+
+		t.Parallel()
+
+		var getStorageUsedCalls int
+
+		err := newTestInterpreterRuntime().ExecuteTransaction(
+			Script{
+				Source: []byte(transaction),
+			},
+			Context{
+				Interface:        newRuntimeInterface(&getStorageUsedCalls),
+				Location:         common.TransactionLocation{},
+				CacheStorageUsed: true,
+			},
+		)
+		require.NoError(t, err)
+
+		// One call before the write, one after the write,
+		// and one for the other account
+
+		assert.Equal(t, 3, getStorageUsedCalls)
+	})
+
+	t.Run("transaction, host environment writes invalidate", func(t *testing.T) {
+
+		t.Parallel()
+
+		var getStorageUsedCalls int
+
+		runtimeInterface := newRuntimeInterface(&getStorageUsedCalls)
+		runtimeInterface.addEncodedAccountKey = func(_ Address, _ []byte) error {
+			return nil
+		}
+		runtimeInterface.removeEncodedAccountKey = func(_ Address, _ int) ([]byte, error) {
+			return []byte{1, 2, 3}, nil
+		}
+		runtimeInterface.getAccountContractCode = func(_ Address, _ string) ([]byte, error) {
+			return []byte(`pub contract Test {}`), nil
+		}
+		runtimeInterface.removeAccountContractCode = func(_ Address, _ string) error {
+			return nil
+		}
+		runtimeInterface.emitEvent = func(_ cadence.Event) error {
+			return nil
+		}
+
+		err := newTestInterpreterRuntime().ExecuteTransaction(
+			Script{
+				Source: []byte(`
+                  transaction {
+                      prepare(signer: AuthAccount) {
+                          signer.storageUsed
+                          signer.addPublicKey([1, 2, 3])
+                          signer.storageUsed
+                          signer.removePublicKey(0)
+                          signer.storageUsed
+                          signer.contracts.remove(name: "Test")
+                          signer.storageUsed
+                      }
+                  }
+                `),
+			},
+			Context{
+				Interface:        runtimeInterface,
+				Location:         common.TransactionLocation{},
+				CacheStorageUsed: true,
+			},
+		)
+		require.NoError(t, err)
+
+		// One call before the changes, and one after each change
+
+		assert.Equal(t, 4, getStorageUsedCalls)
+	})
+}
+
+func TestSortAccountStorageEntries(t *testing.T) {
+
+	t.Parallel()
+
+	entries := []AccountStorageEntry{
+		{
+			StorageKey: interpreter.StorageKey{
+				Address: common.Address{2},
+				Key:     "a",
+			},
+		},
+		{
+			StorageKey: interpreter.StorageKey{
+				Address: common.Address{1},
+				Key:     "b",
+			},
+		},
+		{
+			StorageKey: interpreter.StorageKey{
+				Address: common.Address{1},
+				Key:     "a",
+			},
+		},
+		{
+			StorageKey: interpreter.StorageKey{
+				Address: common.Address{0},
+				Key:     "x",
+			},
+		},
+	}
+
+	SortAccountStorageEntries(entries)
+
+	require.Equal(t,
+		[]AccountStorageEntry{
+			{
+				StorageKey: interpreter.StorageKey{
+					Address: common.Address{0},
+					Key:     "x",
+				},
+			},
+			{
+				StorageKey: interpreter.StorageKey{
+					Address: common.Address{1},
+					Key:     "a",
+				},
+			},
+			{
+				StorageKey: interpreter.StorageKey{
+					Address: common.Address{1},
+					Key:     "b",
+				},
+			},
+			{
+				StorageKey: interpreter.StorageKey{
+					Address: common.Address{2},
+					Key:     "a",
+				},
+			},
+		},
+		entries,
+	)
+}
+
+func TestRuntimeMissingSlab1173(t *testing.T) {
+
+	t.Parallel()
+
+	const contract = `
+pub contract Test {
+    pub enum Role: UInt8 {
+        pub case aaa
+        pub case bbb
+    }
+
+    pub resource AAA {
+        pub fun callA(): String {
+            return "AAA"
+        }
+    }
+
+    pub resource BBB {
+        pub fun callB(): String {
+            return "BBB"
+        }
+    }
+
+    pub resource interface Receiver {
+        pub fun receive(as: Role, capability: Capability)
+    }
+
+    pub resource Holder: Receiver {
+        access(self) let roles: { Role: Capability }
+        pub fun receive(as: Role, capability: Capability) {
+            self.roles[as] = capability
+        }
+
+        pub fun borrowA(): &AAA {
+            let role = self.roles[Role.aaa]!
+            return role.borrow<&AAA>()!
+        }
+
+        pub fun borrowB(): &BBB {
+            let role = self.roles[Role.bbb]!
+            return role.borrow<&BBB>()!
+        }
+
+        access(contract) init() {
+            self.roles = {}
+        }
+    }
+
+    access(self) let capabilities: { Role: Capability }
+
+    pub fun createHolder(): @Holder {
+        return <- create Holder()
+    }
+
+    pub fun attach(as: Role, receiver: &AnyResource{Receiver}) {
+        // TODO: Now verify that the owner is valid.
+
+        let capability = self.capabilities[as]!
+        receiver.receive(as: as, capability: capability)
+    }
+
+    init() {
+        self.account.save<@AAA>(<- create AAA(), to: /storage/TestAAA)
+        self.account.save<@BBB>(<- create BBB(), to: /storage/TestBBB)
+
+        self.capabilities = {}
+        self.capabilities[Role.aaa] = self.account.link<&AAA>(/private/TestAAA, target: /storage/TestAAA)!
+        self.capabilities[Role.bbb] = self.account.link<&BBB>(/private/TestBBB, target: /storage/TestBBB)!
+    }
+}
+
+`
+
+	const tx = `
+import Test from 0x1
+
+transaction {
+    prepare(acct: AuthAccount) {}
+    execute {
+        let holder <- Test.createHolder()
+        Test.attach(as: Test.Role.aaa, receiver: &holder as &AnyResource{Test.Receiver})
+        destroy holder
+    }
+}
+`
+
+	runtime := newTestInterpreterRuntime()
+
+	testAddress := common.BytesToAddress([]byte{0x1})
+
+	accountCodes := map[common.LocationID][]byte{}
+
+	var events []cadence.Event
+
+	signerAccount := testAddress
+
+	runtimeInterface := &testRuntimeInterface{
+		getCode: func(location Location) (bytes []byte, err error) {
+			return accountCodes[location.ID()], nil
+		},
+		storage: newTestLedger(nil, nil),
+		getSigningAccounts: func() ([]Address, error) {
+			return []Address{signerAccount}, nil
+		},
+		resolveLocation: singleIdentifierLocationResolver(t),
+		getAccountContractCode: func(address Address, name string) (code []byte, err error) {
+			location := common.AddressLocation{
+				Address: address,
+				Name:    name,
+			}
+			return accountCodes[location.ID()], nil
+		},
+		updateAccountContractCode: func(address Address, name string, code []byte) error {
+			location := common.AddressLocation{
+				Address: address,
+				Name:    name,
+			}
+			accountCodes[location.ID()] = code
+			return nil
+		},
+		emitEvent: func(event cadence.Event) error {
+			events = append(events, event)
+			return nil
+		},
+		decodeArgument: func(b []byte, t cadence.Type) (value cadence.Value, err error) {
+			return json.Decode(b)
+		},
+	}
+
+	nextTransactionLocation := newTransactionLocationGenerator()
+
+	// Deploy contract
+
+	err := runtime.ExecuteTransaction(
+		Script{
+			Source: utils.DeploymentTransaction(
+				"Test",
+				[]byte(contract),
+			),
+		},
+		Context{
+			Interface: runtimeInterface,
+			Location:  nextTransactionLocation(),
+		},
+	)
+	require.NoError(t, err)
+
+	// Run transaction
+
+	err = runtime.ExecuteTransaction(
+		Script{
+			Source: []byte(tx),
+		},
+		Context{
+			Interface: runtimeInterface,
+			Location:  nextTransactionLocation(),
+		},
+	)
+	require.NoError(t, err)
+}
+
+func TestRuntimeMissingSlab(t *testing.T) {
+
+	t.Parallel()
+
+	runtime := newTestInterpreterRuntime()
+
+	address := common.BytesToAddress([]byte{0x1})
+
+	ledger := newTestLedger(nil, nil)
+
+	runtimeInterface := &testRuntimeInterface{
+		storage: ledger,
+		getSigningAccounts: func() ([]Address, error) {
+			return []Address{address}, nil
+		},
+	}
+
+	nextTransactionLocation := newTransactionLocationGenerator()
+
+	err := runtime.ExecuteTransaction(
+		Script{
+			Source: []byte(`
+              transaction {
+                  prepare(signer: AuthAccount) {
+                      signer.save([1, 2, 3], to: /storage/numbers)
+                  }
+              }
+            `),
+		},
+		Context{
+			Interface: runtimeInterface,
+			Location:  nextTransactionLocation(),
+		},
+	)
+	require.NoError(t, err)
+
+	// Corrupt the storage by removing the slab of the array
+
+	var slabKey string
+	for storageKey, data := range ledger.storedValues { //nolint:maprangecheck
+		key := strings.SplitN(storageKey, "|", 2)[1]
+		if len(data) > 0 && atree.LedgerKeyIsSlabKey(key) {
+			require.Empty(t, slabKey)
+			slabKey = key
+			ledger.storedValues[storageKey] = nil
+		}
+	}
+	require.NotEmpty(t, slabKey)
+
+	err = runtime.ExecuteTransaction(
+		Script{
+			Source: []byte(`
+              transaction {
+                  prepare(signer: AuthAccount) {
+                      signer.borrow<&[Int]>(from: /storage/numbers)
+                  }
+              }
+            `),
+		},
+		Context{
+			Interface: runtimeInterface,
+			Location:  nextTransactionLocation(),
+		},
+	)
+	require.Error(t, err)
+
+	var missingSlabErr MissingSlabError
+	require.ErrorAs(t, err, &missingSlabErr)
+
+	var expectedStorageID atree.StorageID
+	copy(expectedStorageID.Address[:], address[:])
+	copy(expectedStorageID.Index[:], slabKey[len(atree.LedgerBaseStorageSlabPrefix):])
+
+	require.Equal(t,
+		MissingSlabError{
+			StorageID: expectedStorageID,
+			StorageKey: interpreter.StorageKey{
+				Address: address,
+				Key:     interpreter.StorageKeyForPath(common.PathDomainStorage, "numbers"),
+			},
+		},
+		missingSlabErr,
+	)
+	require.Contains(t, missingSlabErr.Error(), "of value stored at /storage/numbers in account 0x1")
+}
+
+func TestRuntimeMissingNestedSlab(t *testing.T) {
+
+	t.Parallel()
+
+	runtime := newTestInterpreterRuntime()
+
+	address := common.BytesToAddress([]byte{0x1})
+
+	ledger := newTestLedger(nil, nil)
+
+	runtimeInterface := &testRuntimeInterface{
+		storage: ledger,
+		getSigningAccounts: func() ([]Address, error) {
+			return []Address{address}, nil
+		},
+	}
+
+	nextTransactionLocation := newTransactionLocationGenerator()
+
+	err := runtime.ExecuteTransaction(
+		Script{
+			Source: []byte(`
+              transaction {
+                  prepare(signer: AuthAccount) {
+                      signer.save([[1, 2, 3]], to: /storage/numbers)
+                  }
+              }
+            `),
+		},
+		Context{
+			Interface: runtimeInterface,
+			Location:  nextTransactionLocation(),
+		},
+	)
+	require.NoError(t, err)
+
+	// Corrupt the storage by removing the slab of the inner array.
+	// The account storage register references the slab of the outer array,
+	// i.e. the encoded storage ID ends with the index of the slab
+
+	storageKey := interpreter.StorageKeyForPath(common.PathDomainStorage, "numbers")
+	rootData, err := ledger.GetValue(address[:], []byte(storageKey))
+	require.NoError(t, err)
+
+	var outerSlabIndex atree.StorageIndex
+	copy(outerSlabIndex[:], rootData[len(rootData)-len(outerSlabIndex):])
+	outerSlabKey := string(atree.SlabIndexToLedgerKey(outerSlabIndex))
+
+	var innerSlabKey string
+	for key, data := range ledger.storedValues { //nolint:maprangecheck
+		key := strings.SplitN(key, "|", 2)[1]
+		if len(data) > 0 && atree.LedgerKeyIsSlabKey(key) && key != outerSlabKey {
+			require.Empty(t, innerSlabKey)
+			innerSlabKey = key
+		}
+	}
+	require.NotEmpty(t, innerSlabKey)
+
+	err = ledger.SetValue(address[:], []byte(innerSlabKey), nil)
+	require.NoError(t, err)
+
+	err = runtime.ExecuteTransaction(
+		Script{
+			Source: []byte(`
+              transaction {
+                  prepare(signer: AuthAccount) {
+                      let numbers = signer.borrow<&[[Int]]>(from: /storage/numbers)!
+                      numbers[0][0]
+                  }
+              }
+            `),
+		},
+		Context{
+			Interface: runtimeInterface,
+			Location:  nextTransactionLocation(),
+		},
+	)
+	require.Error(t, err)
+
+	var missingSlabErr MissingSlabError
+	require.ErrorAs(t, err, &missingSlabErr)
+
+	var expectedStorageID atree.StorageID
+	copy(expectedStorageID.Address[:], address[:])
+	copy(expectedStorageID.Index[:], innerSlabKey[len(atree.LedgerBaseStorageSlabPrefix):])
+
+	// The missing slab is reported for the account storage entry which references it
+
+	require.Equal(t,
+		MissingSlabError{
+			StorageID: expectedStorageID,
+			StorageKey: interpreter.StorageKey{
+				Address: address,
+				Key:     storageKey,
+			},
+		},
+		missingSlabErr,
+	)
+	require.Contains(t, missingSlabErr.Error(), "of value stored at /storage/numbers in account 0x1")
+}
+
+type testComputationMeterRuntimeInterface struct {
+	*testRuntimeInterface
+	meterComputation func(kind ComputationKind, intensity uint) error
+}
+
+var _ ComputationMeter = testComputationMeterRuntimeInterface{}
+
+func (i testComputationMeterRuntimeInterface) MeterComputation(kind ComputationKind, intensity uint) error {
+	return i.meterComputation(kind, intensity)
+}
+
+func TestRuntimeStorageComputationMetering(t *testing.T) {
+
+	t.Parallel()
+
+	runtime := newTestInterpreterRuntime()
+
+	address := common.BytesToAddress([]byte{0x1})
+
+	meteredIntensities := map[ComputationKind]uint{}
+
+	runtimeInterface := testComputationMeterRuntimeInterface{
+		testRuntimeInterface: &testRuntimeInterface{
+			storage: newTestLedger(nil, nil),
+			getSigningAccounts: func() ([]Address, error) {
+				return []Address{address}, nil
+			},
+		},
+		meterComputation: func(kind ComputationKind, intensity uint) error {
+			meteredIntensities[kind] += intensity
+			return nil
+		},
+	}
+
+	nextTransactionLocation := newTransactionLocationGenerator()
+
+	// Save a value
+
+	err := runtime.ExecuteTransaction(
+		Script{
+			Source: []byte(`
+              transaction {
+                  prepare(signer: AuthAccount) {
+                      signer.save([1, 2, 3], to: /storage/numbers)
+                  }
+              }
+            `),
+		},
+		Context{
+			Interface: runtimeInterface,
+			Location:  nextTransactionLocation(),
+		},
+	)
+	require.NoError(t, err)
+
+	require.NotZero(t, meteredIntensities[ComputationKindStorageWrite])
+
+	// Load the value
+
+	meteredIntensities = map[ComputationKind]uint{}
+
+	err = runtime.ExecuteTransaction(
+		Script{
+			Source: []byte(`
+              transaction {
+                  prepare(signer: AuthAccount) {
+                      let numbers = signer.copy<[Int]>(from: /storage/numbers)!
+                      assert(numbers.length == 3)
+                  }
+              }
+            `),
+		},
+		Context{
+			Interface: runtimeInterface,
+			Location:  nextTransactionLocation(),
+		},
+	)
+	require.NoError(t, err)
+
+	require.NotZero(t, meteredIntensities[ComputationKindStorageRead])
+	require.Zero(t, meteredIntensities[ComputationKindStorageWrite])
+
+	// Errors abort the execution
+
+	meteringErr := errors.New("computation limit exceeded")
+
+	runtimeInterface.meterComputation = func(kind ComputationKind, _ uint) error {
+		if kind == ComputationKindStorageRead {
+			return meteringErr
+		}
+		return nil
+	}
+
+	err = runtime.ExecuteTransaction(
+		Script{
+			Source: []byte(`
+              transaction {
+                  prepare(signer: AuthAccount) {
+                      signer.copy<[Int]>(from: /storage/numbers)
+                  }
+              }
+            `),
+		},
+		Context{
+			Interface: runtimeInterface,
+			Location:  nextTransactionLocation(),
+		},
+	)
+	require.ErrorIs(t, err, meteringErr)
+}
+
+func BenchmarkRuntimeStorageCommitShapes(b *testing.B) {
+	BenchmarkStorageCommitShapes(
+		b,
+		StorageCommitShapes,
+		func() atree.Ledger {
+			return newTestLedger(nil, nil)
+		},
+	)
+}
+
+func TestRuntimeStorageSlabOpRecorder(t *testing.T) {
+
+	t.Parallel()
+
+	const elementCount = 1000
+
+	address := common.BytesToAddress([]byte{0x1})
+
+	arrayType := interpreter.VariableSizedStaticType{
+		Type: interpreter.PrimitiveStaticTypeInt,
+	}
+
+	newElements := func() []interpreter.Value {
+		elements := make([]interpreter.Value, elementCount)
+		for i := range elements {
+			elements[i] = interpreter.NewIntValueFromInt64(int64(i))
+		}
+		return elements
+	}
+
+	newStorageAndInterpreter := func(t *testing.T) (*Storage, *interpreter.Interpreter) {
+		storage := NewStorage(
+			newTestLedger(nil, nil),
+			func(f func(), _ func(metrics Metrics, duration time.Duration)) {
+				f()
+			},
+		)
+
+		inter, err := interpreter.NewInterpreter(
+			nil,
+			utils.TestLocation,
+			interpreter.WithStorage(storage),
+		)
+		require.NoError(t, err)
+
+		return storage, inter
+	}
+
+	// Repeated append
+
+	appendStorage, appendInter := newStorageAndInterpreter(t)
+
+	appendArray := interpreter.NewArrayValue(appendInter, arrayType, address)
+
+	appendRecorder := appendStorage.WithSlabOpRecorder()
+
+	for _, element := range newElements() {
+		appendArray.Append(appendInter, interpreter.ReturnEmptyLocationRange, element)
+	}
+
+	// Append all
+
+	storage, inter := newStorageAndInterpreter(t)
+
+	array := interpreter.NewArrayValue(inter, arrayType, address)
+	other := interpreter.NewArrayValue(inter, arrayType, address, newElements()...)
+
+	appendAllRecorder := storage.WithSlabOpRecorder()
+
+	array.AppendAll(inter, interpreter.ReturnEmptyLocationRange, other)
+
+	// Batch construction
+
+	storage, inter = newStorageAndInterpreter(t)
+
+	batchRecorder := storage.WithSlabOpRecorder()
+
+	_ = interpreter.NewArrayValue(inter, arrayType, address, newElements()...)
+
+	// Appending splits slabs, and each split creates slabs
+
+	appendSplits := appendRecorder.Count(SlabOpSplit)
+	require.Greater(t, appendSplits, 0)
+
+	appendCreates := appendRecorder.Count(SlabOpCreate)
+	require.GreaterOrEqual(t, appendCreates, appendSplits)
+
+	// Appending all elements of an array appends the elements one by one,
+	// so it splits, creates, and updates as many slabs as repeated appends
+
+	require.Equal(t, appendSplits, appendAllRecorder.Count(SlabOpSplit))
+	require.Equal(t, appendCreates, appendAllRecorder.Count(SlabOpCreate))
+	require.Equal(t, appendRecorder.Count(SlabOpUpdate), appendAllRecorder.Count(SlabOpUpdate))
+
+	// Constructing an array from all elements creates each slab directly, when it is full,
+	// so it does not split or update slabs, unlike repeated appends
+
+	require.Zero(t, batchRecorder.Count(SlabOpSplit))
+	require.Equal(t, appendCreates, batchRecorder.Count(SlabOpCreate))
+	require.Zero(t, batchRecorder.Count(SlabOpUpdate))
+	require.Greater(t, appendRecorder.Count(SlabOpUpdate), 0)
+
+	// Removing elements merges slabs, and each merge removes a slab
+
+	appendAllRecorder.Reset()
+
+	for i := 0; i < elementCount/2; i++ {
+		array.RemoveLast(inter, interpreter.ReturnEmptyLocationRange)
+	}
+
+	merges := appendAllRecorder.Count(SlabOpMerge)
+	require.Greater(t, merges, 0)
+	require.GreaterOrEqual(t, appendAllRecorder.Count(SlabOpRemove), merges)
+	require.Zero(t, appendAllRecorder.Count(SlabOpSplit))
+
+	// Removing an array removes its slabs,
+	// i.e. the root slab, which was created before recording, and the created slabs,
+	// but does not merge slabs
+
+	appendRecorder.Reset()
+
+	appendArray.DeepRemove(appendInter)
+	appendInter.RemoveReferencedSlab(atree.StorageIDStorable(appendArray.StorageID()))
+
+	require.Equal(t, appendCreates+1, appendRecorder.Count(SlabOpRemove))
+	require.Zero(t, appendRecorder.Count(SlabOpMerge))
+}
+
+func TestRuntimeReadOnlyStorage(t *testing.T) {
+
+	t.Parallel()
+
+	address := common.BytesToAddress([]byte{0x1})
+
+	const key = "test"
+
+	var ledgerWrites int
+
+	ledger := newTestLedger(
+		nil,
+		func(_, _, _ []byte) {
+			ledgerWrites++
+		},
+	)
+
+	reportMetric := func(f func(), _ func(metrics Metrics, duration time.Duration)) {
+		f()
+	}
+
+	newInterpreter := func(t *testing.T, storage *Storage) *interpreter.Interpreter {
+		inter, err := interpreter.NewInterpreter(
+			nil,
+			utils.TestLocation,
+			interpreter.WithStorage(storage),
+		)
+		require.NoError(t, err)
+
+		return inter
+	}
+
+	// Store an array in the account storage
+
+	storage := NewStorage(ledger, reportMetric)
+	inter := newInterpreter(t, storage)
+
+	array := interpreter.NewArrayValue(
+		inter,
+		interpreter.VariableSizedStaticType{
+			Type: interpreter.PrimitiveStaticTypeInt,
+		},
+		address,
+		interpreter.NewIntValueFromInt64(1),
+		interpreter.NewIntValueFromInt64(2),
+	)
+
+	storage.WriteValue(inter, address, key, interpreter.NewSomeValueNonCopying(array))
+
+	err := storage.Commit(inter, false)
+	require.NoError(t, err)
+
+	committedLedgerWrites := ledgerWrites
+	require.Greater(t, committedLedgerWrites, 0)
+
+	readArray := func(storage *Storage, inter *interpreter.Interpreter) *interpreter.ArrayValue {
+		value := storage.ReadValue(inter, address, key)
+		require.IsType(t, &interpreter.SomeValue{}, value)
+
+		storedValue := value.(*interpreter.SomeValue).Value
+		require.IsType(t, &interpreter.ArrayValue{}, storedValue)
+
+		return storedValue.(*interpreter.ArrayValue)
+	}
+
+	t.Run("read", func(t *testing.T) {
+
+		storage := NewReadOnlyStorage(ledger, reportMetric)
+		inter := newInterpreter(t, storage)
+
+		array := readArray(storage, inter)
+		require.Equal(t, 2, array.Count())
+
+		err := storage.Commit(inter, false)
+		require.NoError(t, err)
+
+		require.Equal(t, committedLedgerWrites, ledgerWrites)
+	})
+
+	t.Run("write value", func(t *testing.T) {
+
+		storage := NewReadOnlyStorage(ledger, reportMetric)
+		inter := newInterpreter(t, storage)
+
+		storage.WriteValue(inter, address, key, interpreter.NilValue{})
+
+		require.Empty(t, storage.writes)
+
+		err := storage.Commit(inter, false)
+		require.ErrorAs(t, err, &ReadOnlyStorageError{})
+
+		require.Equal(t, committedLedgerWrites, ledgerWrites)
+	})
+
+	t.Run("mutate stored value", func(t *testing.T) {
+
+		storage := NewReadOnlyStorage(ledger, reportMetric)
+		inter := newInterpreter(t, storage)
+
+		array := readArray(storage, inter)
+		array.Append(
+			inter,
+			interpreter.ReturnEmptyLocationRange,
+			interpreter.NewIntValueFromInt64(3),
+		)
+
+		err := storage.Commit(inter, false)
+		require.ErrorAs(t, err, &ReadOnlyStorageError{})
+
+		require.Equal(t, committedLedgerWrites, ledgerWrites)
+	})
+
+	t.Run("temporary values", func(t *testing.T) {
+
+		storage := NewReadOnlyStorage(ledger, reportMetric)
+		inter := newInterpreter(t, storage)
+
+		_ = interpreter.NewArrayValue(
+			inter,
+			interpreter.VariableSizedStaticType{
+				Type: interpreter.PrimitiveStaticTypeInt,
+			},
+			common.Address{},
+			interpreter.NewIntValueFromInt64(1),
+		)
+
+		err := storage.Commit(inter, false)
+		require.NoError(t, err)
+
+		require.Equal(t, committedLedgerWrites, ledgerWrites)
+	})
+}
+
+type testAccessedAccountsRuntimeInterface struct {
+	*testRuntimeInterface
+	onAccountsAccessed func(addresses []Address)
+}
+
+var _ AccessedAccountsListener = testAccessedAccountsRuntimeInterface{}
+
+func (i testAccessedAccountsRuntimeInterface) OnAccountsAccessed(addresses []Address) {
+	i.onAccountsAccessed(addresses)
+}
+
+func TestRuntimeAccessedAccounts(t *testing.T) {
+
+	t.Parallel()
+
+	runtime := newTestInterpreterRuntime()
+
+	address1 := common.BytesToAddress([]byte{0x1})
+	address2 := common.BytesToAddress([]byte{0x2})
+
+	var signers []Address
+	var accessedAccounts []Address
+
+	runtimeInterface := testAccessedAccountsRuntimeInterface{
+		testRuntimeInterface: &testRuntimeInterface{
+			storage: newTestLedger(nil, nil),
+			getSigningAccounts: func() ([]Address, error) {
+				return signers, nil
+			},
+		},
+		onAccountsAccessed: func(addresses []Address) {
+			accessedAccounts = addresses
+		},
+	}
+
+	nextTransactionLocation := newTransactionLocationGenerator()
+
+	executeTransaction := func(source string, transactionSigners ...Address) {
+		signers = transactionSigners
+		accessedAccounts = nil
+
+		err := runtime.ExecuteTransaction(
+			Script{
+				Source: []byte(source),
+			},
+			Context{
+				Interface: runtimeInterface,
+				Location:  nextTransactionLocation(),
+			},
+		)
+		require.NoError(t, err)
+	}
+
+	// Only the storage of the first account is accessed
+
+	executeTransaction(
+		`
+          transaction {
+              prepare(signer: AuthAccount) {
+                  signer.save([1, 2, 3], to: /storage/values)
+              }
+          }
+        `,
+		address1,
+	)
+
+	require.Equal(t, []Address{address1}, accessedAccounts)
+
+	// Transfer from the first account to the second account.
+	// The second account is the first signer,
+	// but the addresses are reported sorted
+
+	executeTransaction(
+		`
+          transaction {
+              prepare(receiver: AuthAccount, sender: AuthAccount) {
+                  let values = sender.load<[Int]>(from: /storage/values)!
+                  receiver.save(values, to: /storage/values)
+              }
+          }
+        `,
+		address2,
+		address1,
+	)
+
+	require.Equal(t, []Address{address1, address2}, accessedAccounts)
+
+	// Signing accounts whose storage is not accessed are not reported
+
+	executeTransaction(
+		`
+          transaction {
+              prepare(signer: AuthAccount) {}
+          }
+        `,
+		address1,
+	)
+
+	require.Empty(t, accessedAccounts)
+}
+
+func TestRuntimeStorageRollback(t *testing.T) {
+
+	t.Parallel()
+
+	address := common.BytesToAddress([]byte{0x1})
+
+	const key = "test"
+
+	var ledgerWrites int
+
+	ledger := newTestLedger(
+		nil,
+		func(_, _, _ []byte) {
+			ledgerWrites++
+		},
+	)
+
+	reportMetric := func(f func(), _ func(metrics Metrics, duration time.Duration)) {
+		f()
+	}
+
+	storage := NewStorage(ledger, reportMetric)
+
+	inter, err := interpreter.NewInterpreter(
+		nil,
+		utils.TestLocation,
+		interpreter.WithStorage(storage),
+	)
+	require.NoError(t, err)
+
+	readArray := func() *interpreter.ArrayValue {
+		value := storage.ReadValue(inter, address, key)
+		require.IsType(t, &interpreter.SomeValue{}, value)
+
+		storedValue := value.(*interpreter.SomeValue).Value
+		require.IsType(t, &interpreter.ArrayValue{}, storedValue)
+
+		return storedValue.(*interpreter.ArrayValue)
+	}
+
+	// Store an array in the account storage and commit it
+
+	array := interpreter.NewArrayValue(
+		inter,
+		interpreter.VariableSizedStaticType{
+			Type: interpreter.PrimitiveStaticTypeInt,
+		},
+		address,
+		interpreter.NewIntValueFromInt64(1),
+		interpreter.NewIntValueFromInt64(2),
+	)
+
+	storage.WriteValue(inter, address, key, interpreter.NewSomeValueNonCopying(array))
+
+	err = storage.Commit(inter, false)
+	require.NoError(t, err)
+
+	committedLedgerWrites := ledgerWrites
+	require.Greater(t, committedLedgerWrites, 0)
+
+	// Mutate the stored array, write a new value, and create a temporary value
+
+	readArray().Append(
+		inter,
+		interpreter.ReturnEmptyLocationRange,
+		interpreter.NewIntValueFromInt64(3),
+	)
+
+	storage.WriteValue(
+		inter,
+		address,
+		"other",
+		interpreter.NewSomeValueNonCopying(interpreter.NewIntValueFromInt64(42)),
+	)
+
+	_ = interpreter.NewArrayValue(
+		inter,
+		interpreter.VariableSizedStaticType{
+			Type: interpreter.PrimitiveStaticTypeInt,
+		},
+		common.Address{},
+		interpreter.NewIntValueFromInt64(1),
+	)
+
+	// Roll back, and ensure the commit produces no writes
+
+	storage.Rollback()
+
+	err = storage.Commit(inter, false)
+	require.NoError(t, err)
+
+	require.Equal(t, committedLedgerWrites, ledgerWrites)
+
+	// The committed state is unchanged
+
+	require.Equal(t, 2, readArray().Count())
+	require.False(t, storage.ValueExists(inter, address, "other"))
+}
+
+func TestRuntimeReencodeStored(t *testing.T) {
+
+	t.Parallel()
+
+	runtime := newTestInterpreterRuntime()
+
+	address := common.BytesToAddress([]byte{0x42})
+
+	var writes int
+
+	ledger := newTestLedger(
+		nil,
+		func(_, _, _ []byte) {
+			writes++
+		},
+	)
+
+	runtimeInterface := &testRuntimeInterface{
+		storage: ledger,
+	}
+
+	context := Context{
+		Interface: runtimeInterface,
+		Location:  utils.TestLocation,
+	}
+
+	path := cadence.Path{
+		Domain:     "storage",
+		Identifier: "number",
+	}
+
+	key := []byte(interpreter.StorageKeyForPath(common.PathDomainStorage, "number"))
+
+	// Store the UInt8 value 42 with a non-canonical encoding of the integer,
+	// i.e. with a two-byte argument instead of a one-byte argument
+
+	nonCanonical := []byte{
+		// tag
+		0xd8, interpreter.CBORTagUInt8Value,
+		// unsigned integer, two-byte argument
+		0x19, 0x0, 0x2a,
+	}
+
+	err := ledger.SetValue(address[:], key, nonCanonical)
+	require.NoError(t, err)
+
+	writes = 0
+
+	changed, err := runtime.ReencodeStored(address, path, context)
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.Equal(t, 1, writes)
+
+	reencoded, err := ledger.GetValue(address[:], key)
+	require.NoError(t, err)
+	require.Equal(t,
+		[]byte{
+			// tag
+			0xd8, interpreter.CBORTagUInt8Value,
+			// unsigned integer, one-byte argument
+			0x18, 0x2a,
+		},
+		reencoded,
+	)
+
+	value, err := runtime.ReadStored(address, path, context)
+	require.NoError(t, err)
+	require.Equal(t, cadence.NewOptional(cadence.NewUInt8(42)), value)
+
+	// Re-encoding the value again does not change it
+
+	writes = 0
+
+	changed, err = runtime.ReencodeStored(address, path, context)
+	require.NoError(t, err)
+	require.False(t, changed)
+	require.Equal(t, 0, writes)
+
+	// Re-encoding a non-existing value does not change anything
+
+	changed, err = runtime.ReencodeStored(
+		address,
+		cadence.Path{
+			Domain:     "storage",
+			Identifier: "missing",
+		},
+		context,
+	)
+	require.NoError(t, err)
+	require.False(t, changed)
+	require.Equal(t, 0, writes)
+}
+
+func TestRuntimeReencodeStoredSlabs(t *testing.T) {
+
+	t.Parallel()
+
+	runtime := newTestInterpreterRuntime()
+
+	address := common.BytesToAddress([]byte{0x42})
+
+	ledger := newTestLedger(nil, nil)
+
+	runtimeInterface := &testRuntimeInterface{
+		storage: ledger,
+		getSigningAccounts: func() ([]Address, error) {
+			return []Address{address}, nil
+		},
+	}
+
+	nextTransactionLocation := newTransactionLocationGenerator()
+
+	err := runtime.ExecuteTransaction(
+		Script{
+			Source: []byte(`
+              transaction {
+                  prepare(signer: AuthAccount) {
+                      let numbers: [UInt8] = [42]
+                      signer.save(numbers, to: /storage/numbers)
+                  }
+              }
+            `),
+		},
+		Context{
+			Interface: runtimeInterface,
+			Location:  nextTransactionLocation(),
+		},
+	)
+	require.NoError(t, err)
+
+	// Encode the UInt8 value 42 in the slab of the array non-canonically,
+	// i.e. with a two-byte argument instead of a one-byte argument
+
+	canonical := []byte{
+		// tag
+		0xd8, interpreter.CBORTagUInt8Value,
+		// unsigned integer, one-byte argument
+		0x18, 0x2a,
+	}
+
+	nonCanonical := []byte{
+		// tag
+		0xd8, interpreter.CBORTagUInt8Value,
+		// unsigned integer, two-byte argument
+		0x19, 0x0, 0x2a,
+	}
+
+	var slabKey string
+	for key, value := range ledger.storedValues { //nolint:maprangecheck
+		if !bytes.Contains(value, canonical) {
+			continue
+		}
+		require.Empty(t, slabKey)
+		slabKey = key
+		ledger.storedValues[key] = bytes.Replace(value, canonical, nonCanonical, 1)
+	}
+	require.NotEmpty(t, slabKey)
+	require.Contains(t, slabKey, atree.LedgerBaseStorageSlabPrefix)
+
+	context := Context{
+		Interface: runtimeInterface,
+		Location:  utils.TestLocation,
+	}
+
+	path := cadence.Path{
+		Domain:     "storage",
+		Identifier: "numbers",
+	}
+
+	changed, err := runtime.ReencodeStored(address, path, context)
+	require.NoError(t, err)
+	require.True(t, changed)
+
+	require.True(t, bytes.Contains(ledger.storedValues[slabKey], canonical))
+
+	value, err := runtime.ReadStored(address, path, context)
+	require.NoError(t, err)
+	require.Equal(t,
+		cadence.NewOptional(
+			cadence.NewArray([]cadence.Value{
+				cadence.NewUInt8(42),
+			}),
+		),
+		value,
+	)
+
+	// Re-encoding the value again does not change it
+
+	changed, err = runtime.ReencodeStored(address, path, context)
+	require.NoError(t, err)
+	require.False(t, changed)
+}
+
+func TestRuntimeNamespacedStorage(t *testing.T) {
+
+	t.Parallel()
+
+	address := common.BytesToAddress([]byte{0x1})
+
+	const key = "test"
+
+	reportMetric := func(f func(), _ func(metrics Metrics, duration time.Duration)) {
+		f()
+	}
+
+	newInterpreter := func(t *testing.T, storage *Storage) *interpreter.Interpreter {
+		inter, err := interpreter.NewInterpreter(
+			nil,
+			utils.TestLocation,
+			interpreter.WithStorage(storage),
+		)
+		require.NoError(t, err)
+
+		return inter
+	}
+
+	writeArray := func(t *testing.T, storage *Storage, values ...int64) {
+		inter := newInterpreter(t, storage)
+
+		elements := make([]interpreter.Value, len(values))
+		for i, value := range values {
+			elements[i] = interpreter.NewIntValueFromInt64(value)
+		}
+
+		array := interpreter.NewArrayValue(
+			inter,
+			interpreter.VariableSizedStaticType{
+				Type: interpreter.PrimitiveStaticTypeInt,
+			},
+			address,
+			elements...,
+		)
+
+		storage.WriteValue(inter, address, key, interpreter.NewSomeValueNonCopying(array))
+
+		err := storage.Commit(inter, false)
+		require.NoError(t, err)
+	}
+
+	readArray := func(t *testing.T, storage *Storage) interpreter.Value {
+		inter := newInterpreter(t, storage)
+		return storage.ReadValue(inter, address, key)
+	}
+
+	newNamespacedStorage := func(t *testing.T, ledger atree.Ledger, keyPrefix string) *Storage {
+		storage, err := NewNamespacedStorage(ledger, reportMetric, keyPrefix)
+		require.NoError(t, err)
+
+		return storage
+	}
+
+	ledger := newTestLedger(nil, nil)
+
+	// Write different arrays at the same key, in different namespaces
+
+	writeArray(t, newNamespacedStorage(t, ledger, "a"), 1, 2)
+	writeArray(t, newNamespacedStorage(t, ledger, "b"), 3)
+
+	t.Run("namespaces are independent", func(t *testing.T) {
+
+		for prefix, expectedCount := range map[string]int{
+			"a": 2,
+			"b": 1,
+		} {
+			value := readArray(t, newNamespacedStorage(t, ledger, prefix))
+			require.IsType(t, &interpreter.SomeValue{}, value)
+
+			storedValue := value.(*interpreter.SomeValue).Value
+			require.IsType(t, &interpreter.ArrayValue{}, storedValue)
+
+			assert.Equal(t, expectedCount, storedValue.(*interpreter.ArrayValue).Count())
+		}
+
+		// Neither value is visible without a namespace
+
+		assert.Equal(t,
+			interpreter.NilValue{},
+			readArray(t, NewStorage(ledger, reportMetric)),
+		)
+	})
+
+	t.Run("registers are deterministic per namespace", func(t *testing.T) {
+
+		// Writing the same array in a namespace of a fresh ledger
+		// produces the same registers as in the shared ledger
+
+		freshLedger := newTestLedger(nil, nil)
+
+		writeArray(t, newNamespacedStorage(t, freshLedger, "a"), 1, 2)
+
+		namespacedValues := func(ledger testLedger) map[string][]byte {
+			values := map[string][]byte{}
+			for key, value := range ledger.storedValues {
+				if strings.Contains(key, "|a\x1f") {
+					values[key] = value
+				}
+			}
+			return values
+		}
+
+		freshValues := namespacedValues(freshLedger)
+		require.Len(t, freshValues, len(freshLedger.storedValues))
+
+		assert.Equal(t, freshValues, namespacedValues(ledger))
+	})
+
+	t.Run("invalid key prefix", func(t *testing.T) {
+
+		// The key prefix must not contain the separator,
+		// otherwise the keys of namespaces could collide,
+		// e.g. key "b\x1Fc" in namespace "a" and key "c" in namespace "a\x1Fb"
+
+		_, err := NewNamespacedStorage(ledger, reportMetric, "a"+interpreter.StorageKeySeparator+"b")
+		require.Error(t, err)
+
+		var prefixErr InvalidStorageKeyPrefixError
+		require.ErrorAs(t, err, &prefixErr)
+		assert.Equal(t, "a\x1Fb", prefixErr.KeyPrefix)
+	})
+}
+
+func TestRuntimeNamespacedStorageIteration(t *testing.T) {
+
+	t.Parallel()
+
+	runtime := newTestInterpreterRuntime()
+
+	signer := common.BytesToAddress([]byte{0x42})
+
+	runtimeInterface := &testRuntimeInterface{
+		storage: newTestLedger(nil, nil),
+		getSigningAccounts: func() ([]Address, error) {
+			return []Address{signer}, nil
+		},
+	}
+
+	nextTransactionLocation := newTransactionLocationGenerator()
+
+	// Store different values and capabilities in different namespaces
+
+	for _, prefix := range []string{"a", "b"} {
+		err := runtime.ExecuteTransaction(
+			Script{
+				Source: []byte(fmt.Sprintf(
+					`
+                      transaction {
+                          prepare(signer: AuthAccount) {
+                              signer.save(1, to: /storage/%[1]s)
+                              signer.link<&Int>(/public/%[1]s, target: /storage/%[1]s)
+                              signer.save(
+                                  signer.getCapability<&Int>(/public/%[1]s),
+                                  to: /storage/%[1]sCap
+                              )
+                          }
+                      }
+                    `,
+					prefix,
+				)),
+			},
+			Context{
+				Interface:        runtimeInterface,
+				Location:         nextTransactionLocation(),
+				StorageKeyPrefix: prefix,
+			},
+		)
+		require.NoError(t, err)
+	}
+
+	context := Context{
+		Interface:        runtimeInterface,
+		StorageKeyPrefix: "a",
+	}
+
+	t.Run("IterateStorageDomain", func(t *testing.T) {
+
+		var paths []cadence.Path
+
+		err := runtime.IterateStorageDomain(
+			signer,
+			common.PathDomainStorage,
+			func(path cadence.Path, _ cadence.Type) bool {
+				paths = append(paths, path)
+				return true
+			},
+			context,
+		)
+		require.NoError(t, err)
+
+		assert.Equal(t,
+			[]cadence.Path{
+				{Domain: "storage", Identifier: "a"},
+				{Domain: "storage", Identifier: "aCap"},
+			},
+			paths,
+		)
+	})
+
+	t.Run("StoredCapabilityTypes", func(t *testing.T) {
+
+		types, unresolved, err := runtime.StoredCapabilityTypes(signer, context)
+		require.NoError(t, err)
+		require.Empty(t, unresolved)
+
+		assert.Equal(t,
+			map[cadence.Path]string{
+				{Domain: "storage", Identifier: "aCap"}: "&Int",
+			},
+			types,
+		)
+	})
+
+	t.Run("invalid key prefix", func(t *testing.T) {
+
+		invalidContext := Context{
+			Interface:        runtimeInterface,
+			Location:         nextTransactionLocation(),
+			StorageKeyPrefix: "a\x1Fb",
+		}
+
+		var prefixErr InvalidStorageKeyPrefixError
+
+		err := runtime.ExecuteTransaction(
+			Script{
+				Source: []byte(`
+                  transaction {
+                      prepare(signer: AuthAccount) {
+                          signer.save(1, to: /storage/c)
+                      }
+                  }
+                `),
+			},
+			invalidContext,
+		)
+		require.ErrorAs(t, err, &prefixErr)
+
+		err = runtime.IterateStorageDomain(
+			signer,
+			common.PathDomainStorage,
+			func(_ cadence.Path, _ cadence.Type) bool {
+				return true
+			},
+			invalidContext,
+		)
+		require.ErrorAs(t, err, &prefixErr)
+
+		_, _, err = runtime.StoredCapabilityTypes(signer, invalidContext)
+		require.ErrorAs(t, err, &prefixErr)
+	})
+}
+
+func TestRuntimeStorageMigrateValues(t *testing.T) {
+
+	t.Parallel()
+
+	runtime := newTestInterpreterRuntime()
+
+	signer := common.BytesToAddress([]byte{0x42})
+
+	reportMetric := func(f func(), _ func(metrics Metrics, duration time.Duration)) {
+		f()
+	}
+
+	ledger := newTestLedger(nil, nil)
+
+	var loggedMessages []string
+
+	runtimeInterface := &testRuntimeInterface{
+		storage: ledger,
+		getSigningAccounts: func() ([]Address, error) {
+			return []Address{signer}, nil
+		},
+		log: func(message string) {
+			loggedMessages = append(loggedMessages, message)
+		},
+	}
+
+	nextTransactionLocation := newTransactionLocationGenerator()
+
+	executeTransaction := func(code string) {
+		err := runtime.ExecuteTransaction(
+			Script{
+				Source: []byte(code),
+			},
+			Context{
+				Interface: runtimeInterface,
+				Location:  nextTransactionLocation(),
+			},
+		)
+		require.NoError(t, err)
+	}
+
+	executeTransaction(`
+      transaction {
+          prepare(signer: AuthAccount) {
+              signer.save(1, to: /storage/a)
+              // Large enough to be stored in separate slabs
+              let numbers: [Int] = []
+              var i = 0
+              while i < 1000 {
+                  numbers.append(i)
+                  i = i + 1
+              }
+              signer.save(numbers, to: /storage/b)
+              signer.save("c", to: /storage/c)
+              signer.save(4, to: /storage/d)
+          }
+      }
+    `)
+
+	storage := NewStorage(ledger, reportMetric)
+
+	inter, err := interpreter.NewInterpreter(
+		nil,
+		utils.TestLocation,
+		interpreter.WithStorage(storage),
+	)
+	require.NoError(t, err)
+
+	var migratedPaths []cadence.Path
+
+	err = storage.MigrateValues(
+		signer,
+		func(path cadence.Path, value interpreter.Value) (interpreter.Value, bool) {
+			migratedPaths = append(migratedPaths, path)
+
+			switch path.Identifier {
+			case "a":
+				// Change the type of the value
+				return interpreter.NewStringValue(value.String()), true
+
+			case "b":
+				// Nest the stored value in a new value
+				return interpreter.NewArrayValue(
+					inter,
+					interpreter.VariableSizedStaticType{
+						Type: value.StaticType(),
+					},
+					common.Address{},
+					value,
+				), true
+
+			case "d":
+				// Remove the value
+				return nil, true
+
+			default:
+				return nil, false
+			}
+		},
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t,
+		[]cadence.Path{
+			{Domain: "storage", Identifier: "a"},
+			{Domain: "storage", Identifier: "b"},
+			{Domain: "storage", Identifier: "c"},
+			{Domain: "storage", Identifier: "d"},
+		},
+		migratedPaths,
+	)
+
+	const commitContractUpdates = false
+	err = storage.Commit(inter, commitContractUpdates)
+	require.NoError(t, err)
+
+	require.NoError(t, storage.CheckHealth())
+
+	executeTransaction(`
+      transaction {
+          prepare(signer: AuthAccount) {
+              log(signer.load<String>(from: /storage/a))
+              let numbers = signer.load<[[Int]]>(from: /storage/b)!
+              log(numbers.length)
+              log(numbers[0].length)
+              log(numbers[0][999])
+              log(signer.load<String>(from: /storage/c))
+              log(signer.load<Int>(from: /storage/d))
+          }
+      }
+    `)
+
+	assert.Equal(t,
+		[]string{
+			`"1"`,
+			`1`,
+			`1000`,
+			`999`,
+			`"c"`,
+			`nil`,
+		},
+		loggedMessages,
+	)
+
+	// All values were loaded, so no slabs of the old or the migrated values are left
+
+	for key, value := range ledger.storedValues {
+		assert.Empty(t, value, key)
+	}
+
+	t.Run("read-only", func(t *testing.T) {
+
+		t.Parallel()
+
+		const readOnly = true
+		const keyPrefix = ""
+		storage := newStorage(newTestLedger(nil, nil), reportMetric, readOnly, keyPrefix)
+
+		err := storage.MigrateValues(
+			signer,
+			func(_ cadence.Path, value interpreter.Value) (interpreter.Value, bool) {
+				return value, true
+			},
+		)
+		require.ErrorAs(t, err, &ReadOnlyStorageError{})
+	})
+}
+
+func TestRuntimeValidateAccountStorage(t *testing.T) {
+
+	t.Parallel()
+
+	runtime := newTestInterpreterRuntime()
+
+	signer := common.BytesToAddress([]byte{0x42})
+
+	ledger := newTestLedger(nil, nil)
+
+	runtimeInterface := &testRuntimeInterface{
+		storage: ledger,
+		getSigningAccounts: func() ([]Address, error) {
+			return []Address{signer}, nil
+		},
+	}
+
+	nextTransactionLocation := newTransactionLocationGenerator()
+
+	err := runtime.ExecuteTransaction(
+		Script{
+			Source: []byte(`
+              transaction {
+                  prepare(signer: AuthAccount) {
+                      // Large enough to be stored in separate slabs
+                      let numbers: [Int] = []
+                      var i = 0
+                      while i < 1000 {
+                          numbers.append(i)
+                          i = i + 1
+                      }
+                      signer.save(numbers, to: /storage/a)
+                      signer.save(numbers, to: /storage/b)
+                      signer.save(1, to: /storage/c)
+                  }
+              }
+            `),
+		},
+		Context{
+			Interface: runtimeInterface,
+			Location:  nextTransactionLocation(),
+		},
+	)
+	require.NoError(t, err)
+
+	validate := func() *StorageValidationReport {
+		report, err := runtime.ValidateAccountStorage(
+			signer,
+			Context{
+				Interface: runtimeInterface,
+			},
+		)
+		require.NoError(t, err)
+		return report
+	}
+
+	report := validate()
+	require.True(t, report.IsValid(), "%v", report.Problems)
+
+	rootSlabKey := func(identifier string) []byte {
+		storageKey := interpreter.StorageKeyForPath(common.PathDomainStorage, identifier)
+		data, err := ledger.GetValue(signer[:], []byte(storageKey))
+		require.NoError(t, err)
+
+		decoder := interpreter.CBORDecMode.NewByteStreamDecoder(data)
+		storable, err := interpreter.DecodeStorable(decoder, atree.StorageIDUndefined)
+		require.NoError(t, err)
+
+		require.IsType(t, atree.StorageIDStorable{}, storable)
+		return atree.SlabIndexToLedgerKey(atree.StorageID(storable.(atree.StorageIDStorable)).Index)
+	}
+
+	// Remove the root slab of the first array,
+	// corrupt the root slab of the second array,
+	// and add an unreferenced slab
+
+	err = ledger.SetValue(signer[:], rootSlabKey("a"), nil)
+	require.NoError(t, err)
+
+	err = ledger.SetValue(signer[:], rootSlabKey("b"), []byte{0x1, 0x2, 0x3})
+	require.NoError(t, err)
+
+	orphanedIndex := atree.StorageIndex{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+	err = ledger.SetValue(signer[:], atree.SlabIndexToLedgerKey(orphanedIndex), []byte{0x1, 0x2, 0x3})
+	require.NoError(t, err)
+
+	storageBefore := map[string][]byte{}
+	for key, value := range ledger.storedValues {
+		storageBefore[key] = value
+	}
+
+	report = validate()
+	require.False(t, report.IsValid())
+
+	// The validation is read-only
+
+	assert.Equal(t, storageBefore, ledger.storedValues)
+
+	// All problems are reported
+
+	problemsByKind := map[StorageProblemKind][]StorageProblem{}
+	for _, problem := range report.Problems {
+		problemsByKind[problem.Kind] = append(problemsByKind[problem.Kind], problem)
+	}
+
+	require.Len(t, problemsByKind[StorageProblemKindDanglingReference], 1)
+	assert.Equal(t,
+		"storage\x1Fa",
+		problemsByKind[StorageProblemKindDanglingReference][0].StorageKey,
+	)
+
+	require.Len(t, problemsByKind[StorageProblemKindUndecodableSlab], 1)
+	assert.Equal(t,
+		"storage\x1Fb",
+		problemsByKind[StorageProblemKindUndecodableSlab][0].StorageKey,
+	)
+	assert.Error(t, problemsByKind[StorageProblemKindUndecodableSlab][0].Err)
+
+	// The child slabs of both arrays are no longer reachable
+
+	orphanedProblems := problemsByKind[StorageProblemKindOrphanedSlab]
+	require.Greater(t, len(orphanedProblems), 2)
+	assert.Equal(t,
+		atree.NewStorageID(atree.Address(signer), orphanedIndex),
+		orphanedProblems[len(orphanedProblems)-1].StorageID,
+	)
+
+	assert.Empty(t, problemsByKind[StorageProblemKindUndecodableValue])
 }
@@ -0,0 +1,285 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2020 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runtime
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/onflow/atree"
+
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/cadence/runtime/interpreter"
+)
+
+//go:generate go run golang.org/x/tools/cmd/stringer -type=StorageProblemKind
+
+// StorageProblemKind is the kind of a problem found by the validation of account storage.
+//
+type StorageProblemKind uint
+
+const (
+	StorageProblemKindUnknown StorageProblemKind = iota
+	// StorageProblemKindUndecodableValue is a root register,
+	// e.g. a storage path or a contract, which cannot be decoded
+	StorageProblemKindUndecodableValue
+	// StorageProblemKindDanglingReference is a reference to a slab which does not exist
+	StorageProblemKindDanglingReference
+	// StorageProblemKindUndecodableSlab is a slab which cannot be decoded
+	StorageProblemKindUndecodableSlab
+	// StorageProblemKindOrphanedSlab is a slab which is not reachable from any root register
+	StorageProblemKindOrphanedSlab
+)
+
+// StorageProblem is a problem found by the validation of account storage.
+//
+type StorageProblem struct {
+	Kind StorageProblemKind
+	// StorageKey is the key of the root register the problem was found in,
+	// or the key of the root register from which the problematic slab is referenced, if any
+	StorageKey string
+	// StorageID is the ID of the problematic slab, if any
+	StorageID atree.StorageID
+	// Err is the decoding error, if any
+	Err error
+}
+
+func (p StorageProblem) String() string {
+	switch p.Kind {
+	case StorageProblemKindUndecodableValue:
+		return fmt.Sprintf("cannot decode value stored at %q: %s", p.StorageKey, p.Err)
+	case StorageProblemKindDanglingReference:
+		return fmt.Sprintf("value stored at %q refers to non-existing slab %s", p.StorageKey, p.StorageID)
+	case StorageProblemKindUndecodableSlab:
+		return fmt.Sprintf("cannot decode slab %s: %s", p.StorageID, p.Err)
+	case StorageProblemKindOrphanedSlab:
+		return fmt.Sprintf("slab %s is not reachable from any stored value", p.StorageID)
+	default:
+		return fmt.Sprintf("%s: %q %s", p.Kind, p.StorageKey, p.StorageID)
+	}
+}
+
+// StorageValidationReport reports the problems found by the validation of account storage,
+// see ValidateAccountStorage.
+//
+type StorageValidationReport struct {
+	Problems []StorageProblem
+}
+
+// IsValid returns true if no problems were found.
+//
+func (r *StorageValidationReport) IsValid() bool {
+	return len(r.Problems) == 0
+}
+
+// isStorageRootKey returns true if the given register key is the key of a root register,
+// i.e. the key of a value stored at a path, or the key of a contract.
+//
+func isStorageRootKey(key string) bool {
+	if _, _, ok := interpreter.PathForStorageKey(key); ok {
+		return true
+	}
+
+	return strings.HasPrefix(key, formatContractKey(""))
+}
+
+// ValidateAccountStorage validates the storage of the given account:
+// It decodes all root registers, i.e. the values stored at paths and the contracts,
+// and all slabs reachable from them, and reports references to non-existing slabs,
+// values and slabs which cannot be decoded, and slabs which are not reachable from any root.
+//
+// The validation is read-only, and collects all problems instead of stopping at the first.
+// The returned error is only non-nil if the registers of the account cannot be iterated.
+//
+func ValidateAccountStorage(
+	ledger AccountRegisterIterator,
+	address common.Address,
+) (
+	*StorageValidationReport,
+	error,
+) {
+	type rootRegister struct {
+		key  string
+		data []byte
+	}
+
+	var roots []rootRegister
+	slabs := map[atree.StorageID][]byte{}
+
+	err := ledger.ForEachAccountRegister(
+		address[:],
+		func(key []byte, value []byte) error {
+			// Empty registers do not exist
+			if len(value) == 0 {
+				return nil
+			}
+
+			keyString := string(key)
+
+			switch {
+			case atree.LedgerKeyIsSlabKey(keyString):
+				var index atree.StorageIndex
+				// Keys with an invalid length are not slab keys
+				if len(key)-len(atree.LedgerBaseStorageSlabPrefix) != len(index) {
+					return nil
+				}
+				copy(index[:], key[len(atree.LedgerBaseStorageSlabPrefix):])
+
+				storageID := atree.NewStorageID(atree.Address(address), index)
+				slabs[storageID] = value
+
+			case isStorageRootKey(keyString):
+				roots = append(roots, rootRegister{
+					key:  keyString,
+					data: value,
+				})
+			}
+
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(roots, func(i, j int) bool {
+		return roots[i].key < roots[j].key
+	})
+
+	report := &StorageValidationReport{}
+
+	type reference struct {
+		storageKey string
+		storageID  atree.StorageID
+	}
+
+	// collectReferences appends the references to slabs
+	// contained in the given storable, directly or nested
+
+	collectReferences := func(storageKey string, storable atree.Storable, references []reference) []reference {
+		storables := []atree.Storable{storable}
+		for len(storables) > 0 {
+			storable := storables[0]
+			storables = storables[1:]
+
+			if storageIDStorable, ok := storable.(atree.StorageIDStorable); ok {
+				references = append(references, reference{
+					storageKey: storageKey,
+					storageID:  atree.StorageID(storageIDStorable),
+				})
+				continue
+			}
+
+			storables = append(storables, storable.ChildStorables()...)
+		}
+		return references
+	}
+
+	// Decode the root registers
+
+	var references []reference
+
+	for _, root := range roots {
+		decoder := interpreter.CBORDecMode.NewByteStreamDecoder(root.data)
+		storable, err := interpreter.DecodeStorable(decoder, atree.StorageIDUndefined)
+		if err != nil {
+			report.Problems = append(report.Problems, StorageProblem{
+				Kind:       StorageProblemKindUndecodableValue,
+				StorageKey: root.key,
+				Err:        err,
+			})
+			continue
+		}
+
+		references = collectReferences(root.key, storable, references)
+	}
+
+	// Visit all slabs reachable from the roots
+
+	visited := map[atree.StorageID]struct{}{}
+
+	for len(references) > 0 {
+		reference := references[0]
+		references = references[1:]
+
+		storageID := reference.storageID
+
+		if _, ok := visited[storageID]; ok {
+			continue
+		}
+		visited[storageID] = struct{}{}
+
+		data, ok := slabs[storageID]
+		if !ok {
+			report.Problems = append(report.Problems, StorageProblem{
+				Kind:       StorageProblemKindDanglingReference,
+				StorageKey: reference.storageKey,
+				StorageID:  storageID,
+			})
+			continue
+		}
+
+		slab, err := atree.DecodeSlab(
+			storageID,
+			data,
+			interpreter.CBORDecMode,
+			interpreter.DecodeStorable,
+			interpreter.DecodeTypeInfo,
+		)
+		if err != nil {
+			report.Problems = append(report.Problems, StorageProblem{
+				Kind:       StorageProblemKindUndecodableSlab,
+				StorageKey: reference.storageKey,
+				StorageID:  storageID,
+				Err:        err,
+			})
+			continue
+		}
+
+		for _, childStorable := range slab.ChildStorables() {
+			references = collectReferences(reference.storageKey, childStorable, references)
+		}
+	}
+
+	// Report the slabs which are not reachable from any root
+
+	var orphanedStorageIDs []atree.StorageID
+
+	// NOTE: map range is safe, as the storage IDs get sorted
+	for storageID := range slabs { //nolint:maprangecheck
+		if _, ok := visited[storageID]; ok {
+			continue
+		}
+		orphanedStorageIDs = append(orphanedStorageIDs, storageID)
+	}
+
+	sort.Slice(orphanedStorageIDs, func(i, j int) bool {
+		return orphanedStorageIDs[i].Compare(orphanedStorageIDs[j]) < 0
+	})
+
+	for _, storageID := range orphanedStorageIDs {
+		report.Problems = append(report.Problems, StorageProblem{
+			Kind:      StorageProblemKindOrphanedSlab,
+			StorageID: storageID,
+		})
+	}
+
+	return report, nil
+}
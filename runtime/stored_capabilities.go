@@ -0,0 +1,135 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2020 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runtime
+
+import (
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/cadence/runtime/interpreter"
+	"github.com/onflow/cadence/runtime/sema"
+)
+
+// recordStoredCapability is called when a capability is transferred into the storage of an account
+// (see Context.ValidateStoredCapabilities).
+// It records the capability, so it is validated when the storage is committed (see validateStoredCapabilities).
+//
+// The capability is not validated immediately,
+// as its link might only be created after the capability is stored.
+//
+func (s *Storage) recordStoredCapability(
+	_ *interpreter.Interpreter,
+	_ func() interpreter.LocationRange,
+	capability *interpreter.CapabilityValue,
+) {
+	s.storedCapabilities = append(s.storedCapabilities, capability)
+}
+
+// validateStoredCapabilities checks that the capabilities which were stored since the last validation
+// are live, i.e. their links resolve to a stored value.
+//
+func (s *Storage) validateStoredCapabilities(inter *interpreter.Interpreter) (err error) {
+
+	// recover internal panics and return them as an error
+	defer inter.RecoverErrors(func(internalErr error) {
+		err = internalErr
+	})
+
+	capabilities := s.storedCapabilities
+	s.storedCapabilities = nil
+
+	validated := map[interpreter.StorageKey]struct{}{}
+
+	for _, capability := range capabilities {
+		address := capability.Address.ToAddress()
+
+		key := interpreter.StorageKey{
+			Address: address,
+			Key:     interpreter.PathToStorageKey(capability.Path),
+		}
+		if _, ok := validated[key]; ok {
+			continue
+		}
+		validated[key] = struct{}{}
+
+		if !checkLink(inter, address, capability.Path).TargetExists {
+			return DanglingStoredCapabilityError{
+				Address: address,
+				Path:    capability.Path,
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkLink returns the status of the link stored at the given path.
+//
+// The links are followed regardless of their types.
+// If the path is not a link, but stores a value, the path itself is the target.
+//
+func checkLink(
+	inter *interpreter.Interpreter,
+	address common.Address,
+	path interpreter.PathValue,
+) (
+	status CapabilityStatus,
+) {
+	someValue, ok := inter.ReadStored(address, interpreter.PathToStorageKey(path)).(*interpreter.SomeValue)
+	if !ok {
+		return
+	}
+
+	link, ok := someValue.Value.(interpreter.LinkValue)
+	if !ok {
+		status.TargetExists = true
+		return
+	}
+
+	status.IsLinked = true
+
+	targetKey, _, err := inter.GetCapabilityFinalTargetStorageKey(
+		address,
+		path,
+		&sema.ReferenceType{
+			Type: sema.AnyType,
+		},
+		interpreter.ReturnEmptyLocationRange,
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	status.TargetExists = targetKey != ""
+	if !status.TargetExists {
+		return
+	}
+
+	borrowType, ok := inter.MustConvertStaticToSemaType(link.Type).(*sema.ReferenceType)
+	if !ok {
+		return
+	}
+
+	status.IsBorrowable = inter.IsCapabilityBorrowable(
+		interpreter.AddressValue(address),
+		path,
+		borrowType,
+		interpreter.ReturnEmptyLocationRange,
+	)
+
+	return
+}
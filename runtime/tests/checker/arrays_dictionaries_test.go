@@ -316,6 +316,73 @@ func TestCheckDictionaryValues(t *testing.T) {
 	)
 }
 
+func TestCheckDictionaryMerging(t *testing.T) {
+
+	t.Parallel()
+
+	checker, err := ParseAndCheck(t, `
+        let merged = {"abc": 1}.merging(
+            {"def": 2},
+            resolve: fun (existing: Int, new: Int): Int {
+                return existing + new
+            }
+        )
+    `)
+
+	require.NoError(t, err)
+
+	mergedType := RequireGlobalValue(t, checker.Elaboration, "merged")
+
+	assert.Equal(t,
+		&sema.DictionaryType{
+			KeyType:   sema.StringType,
+			ValueType: sema.IntType,
+		},
+		mergedType,
+	)
+}
+
+func TestCheckInvalidDictionaryMerging(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("invalid other dictionary", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t, `
+            let merged = {"abc": 1}.merging(
+                {"def": "2"},
+                resolve: fun (existing: Int, new: Int): Int {
+                    return existing + new
+                }
+            )
+        `)
+
+		errs := ExpectCheckerErrors(t, err, 1)
+
+		assert.IsType(t, &sema.TypeMismatchError{}, errs[0])
+	})
+
+	t.Run("invalid resolve function", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t, `
+            let merged = {"abc": 1}.merging(
+                {"def": 2},
+                resolve: fun (existing: String, new: String): String {
+                    return existing.concat(new)
+                }
+            )
+        `)
+
+		errs := ExpectCheckerErrors(t, err, 1)
+
+		assert.IsType(t, &sema.TypeMismatchError{}, errs[0])
+	})
+}
+
 func TestCheckLength(t *testing.T) {
 
 	t.Parallel()
@@ -265,6 +265,100 @@ func TestCheckCapability_borrow(t *testing.T) {
 	})
 }
 
+func TestCheckCapability_borrowOr(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("untyped", func(t *testing.T) {
+
+		t.Parallel()
+
+		checker, err := ParseAndCheckWithPanic(t, `
+          resource R {}
+
+          let capability: Capability = panic("")
+          let fallback: Capability = panic("")
+
+          let r = capability.borrowOr<&R>(fallback)
+        `)
+
+		require.NoError(t, err)
+
+		rType := RequireGlobalType(t, checker.Elaboration, "R")
+		rValueType := RequireGlobalValue(t, checker.Elaboration, "r")
+
+		require.Equal(t,
+			&sema.OptionalType{
+				Type: &sema.ReferenceType{
+					Type: rType,
+				},
+			},
+			rValueType,
+		)
+	})
+
+	t.Run("typed", func(t *testing.T) {
+
+		t.Parallel()
+
+		checker, err := ParseAndCheckWithPanic(t, `
+          resource R {}
+
+          let capability: Capability<&R> = panic("")
+          let fallback: Capability<&R> = panic("")
+
+          let r = capability.borrowOr(fallback)
+        `)
+
+		require.NoError(t, err)
+
+		rType := RequireGlobalType(t, checker.Elaboration, "R")
+		rValueType := RequireGlobalValue(t, checker.Elaboration, "r")
+
+		require.Equal(t,
+			&sema.OptionalType{
+				Type: &sema.ReferenceType{
+					Type: rType,
+				},
+			},
+			rValueType,
+		)
+	})
+
+	t.Run("missing type argument", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheckWithPanic(t, `
+          let capability: Capability = panic("")
+          let fallback: Capability = panic("")
+
+          let r = capability.borrowOr(fallback)
+        `)
+
+		errs := ExpectCheckerErrors(t, err, 1)
+
+		require.IsType(t, &sema.TypeParameterTypeInferenceError{}, errs[0])
+	})
+
+	t.Run("invalid fallback", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheckWithPanic(t, `
+          resource R {}
+
+          let capability: Capability = panic("")
+
+          let r = capability.borrowOr<&R>(1)
+        `)
+
+		errs := ExpectCheckerErrors(t, err, 1)
+
+		require.IsType(t, &sema.TypeMismatchError{}, errs[0])
+	})
+}
+
 func TestCheckCapability_check(t *testing.T) {
 
 	t.Parallel()
@@ -451,3 +545,165 @@ func TestCheckCapability_address(t *testing.T) {
 		require.Equal(t, &sema.AddressType{}, addrType)
 	})
 }
+
+func TestCheckUnborrowedLinkHint(t *testing.T) {
+
+	t.Parallel()
+
+	parseAndCheck := func(t *testing.T, code string, publicLinks bool) *sema.Checker {
+		checker, err := ParseAndCheckWithOptions(t,
+			code,
+			ParseAndCheckOptions{
+				Options: []sema.Option{
+					sema.WithUnborrowedLinkHintsEnabled(true),
+					sema.WithUnborrowedPublicLinkHintsEnabled(publicLinks),
+				},
+			},
+		)
+		require.NoError(t, err)
+		return checker
+	}
+
+	t.Run("private link, never borrowed", func(t *testing.T) {
+
+		t.Parallel()
+
+		checker := parseAndCheck(t,
+			`
+              resource R {}
+
+              fun test(account: AuthAccount) {
+                  account.link<&R>(/private/r, target: /storage/r)
+              }
+            `,
+			false,
+		)
+
+		hints := checker.Hints()
+		require.Len(t, hints, 1)
+		require.IsType(t, &sema.UnborrowedLinkHint{}, hints[0])
+		assert.Equal(t, "/private/r", hints[0].(*sema.UnborrowedLinkHint).Path.String())
+	})
+
+	t.Run("private link, borrowed", func(t *testing.T) {
+
+		t.Parallel()
+
+		checker := parseAndCheck(t,
+			`
+              resource R {}
+
+              fun test(account: AuthAccount) {
+                  account.link<&R>(/private/r, target: /storage/r)
+                  account.getCapability<&R>(/private/r).borrow()
+              }
+            `,
+			false,
+		)
+
+		require.Empty(t, checker.Hints())
+	})
+
+	t.Run("private link, dynamic path borrowed", func(t *testing.T) {
+
+		t.Parallel()
+
+		checker := parseAndCheck(t,
+			`
+              resource R {}
+
+              fun test(account: AuthAccount, path: CapabilityPath) {
+                  account.link<&R>(/private/r, target: /storage/r)
+                  account.getCapability<&R>(path).borrow()
+              }
+            `,
+			false,
+		)
+
+		require.Empty(t, checker.Hints())
+	})
+
+	t.Run("private link, capability used", func(t *testing.T) {
+
+		t.Parallel()
+
+		checker := parseAndCheck(t,
+			`
+              resource R {}
+
+              fun test(account: AuthAccount) {
+                  let cap = account.link<&R>(/private/r, target: /storage/r)!
+                  cap.borrow()
+              }
+            `,
+			false,
+		)
+
+		require.Empty(t, checker.Hints())
+	})
+
+	t.Run("private link, target of other link", func(t *testing.T) {
+
+		t.Parallel()
+
+		checker := parseAndCheck(t,
+			`
+              resource R {}
+
+              fun test(account: AuthAccount) {
+                  account.link<&R>(/private/r, target: /storage/r)
+                  account.link<&R>(/public/r, target: /private/r)
+              }
+            `,
+			true,
+		)
+
+		// Only the public link is never borrowed,
+		// the private link is used through it
+
+		hints := checker.Hints()
+		require.Len(t, hints, 1)
+		require.IsType(t, &sema.UnborrowedLinkHint{}, hints[0])
+		assert.Equal(t, "/public/r", hints[0].(*sema.UnborrowedLinkHint).Path.String())
+	})
+
+	t.Run("public link, never borrowed", func(t *testing.T) {
+
+		t.Parallel()
+
+		const code = `
+          resource R {}
+
+          fun test(account: AuthAccount) {
+              account.link<&R>(/public/r, target: /storage/r)
+          }
+        `
+
+		checker := parseAndCheck(t, code, false)
+		require.Empty(t, checker.Hints())
+
+		checker = parseAndCheck(t, code, true)
+
+		hints := checker.Hints()
+		require.Len(t, hints, 1)
+		require.IsType(t, &sema.UnborrowedLinkHint{}, hints[0])
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+
+		t.Parallel()
+
+		checker, err := ParseAndCheck(t,
+			`
+              resource R {}
+
+              fun test(account: AuthAccount) {
+                  account.link<&R>(/private/r, target: /storage/r)
+              }
+            `,
+		)
+		require.NoError(t, err)
+
+		require.Empty(t, checker.Hints())
+	})
+}
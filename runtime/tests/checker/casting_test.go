@@ -4824,9 +4824,21 @@ func TestCheckCastUnauthorizedResourceReferenceType(t *testing.T) {
 					),
 				)
 
-				errs := ExpectCheckerErrors(t, err, 1)
+				switch op {
+				case "as":
+					errs := ExpectCheckerErrors(t, err, 1)
 
-				assert.IsType(t, &sema.TypeMismatchError{}, errs[0])
+					assert.IsType(t, &sema.TypeMismatchError{}, errs[0])
+
+				case "as?":
+					// The failable cast is allowed, and results in nil
+					require.NoError(t, err)
+
+				default:
+					errs := ExpectCheckerErrors(t, err, 1)
+
+					assert.IsType(t, &sema.InvalidRestrictedReferenceWideningError{}, errs[0])
+				}
 			})
 
 			t.Run("restricted type -> unrestricted type: different resource", func(t *testing.T) {
@@ -5471,9 +5483,21 @@ func TestCheckCastUnauthorizedStructReferenceType(t *testing.T) {
 					),
 				)
 
-				errs := ExpectCheckerErrors(t, err, 1)
+				switch op {
+				case "as":
+					errs := ExpectCheckerErrors(t, err, 1)
 
-				assert.IsType(t, &sema.TypeMismatchError{}, errs[0])
+					assert.IsType(t, &sema.TypeMismatchError{}, errs[0])
+
+				case "as?":
+					// The failable cast is allowed, and results in nil
+					require.NoError(t, err)
+
+				default:
+					errs := ExpectCheckerErrors(t, err, 1)
+
+					assert.IsType(t, &sema.InvalidRestrictedReferenceWideningError{}, errs[0])
+				}
 			})
 
 			t.Run("restricted type -> unrestricted type: different resource", func(t *testing.T) {
@@ -6044,3 +6068,255 @@ func TestCheckUnnecessaryCasts(t *testing.T) {
 		require.Len(t, checker.Hints(), 0)
 	})
 }
+
+func TestCheckCastRestrictedReferenceWidening(t *testing.T) {
+
+	t.Parallel()
+
+	for _, op := range []string{"as?", "as!"} {
+
+		t.Run(op, func(t *testing.T) {
+
+			t.Run("created restricted reference", func(t *testing.T) {
+
+				_, err := ParseAndCheck(t,
+					fmt.Sprintf(
+						`
+                          resource interface RI {}
+
+                          resource R: RI {}
+
+                          fun test() {
+                              let r <- create R()
+                              let ref = &r as &R{RI}
+                              let r2 = ref %s &R
+                              destroy r
+                          }
+                        `,
+						op,
+					),
+				)
+
+				// The failable cast is allowed, and results in nil
+
+				if op == "as?" {
+					require.NoError(t, err)
+					return
+				}
+
+				errs := ExpectCheckerErrors(t, err, 1)
+
+				assert.IsType(t, &sema.InvalidRestrictedReferenceWideningError{}, errs[0])
+			})
+
+			t.Run("created restricted reference, statically widened", func(t *testing.T) {
+
+				_, err := ParseAndCheck(t,
+					fmt.Sprintf(
+						`
+                          resource interface RI {}
+
+                          resource R: RI {}
+
+                          fun test() {
+                              let r <- create R()
+                              let ref = &r as &R{RI}
+                              let r2 = ((ref as AnyStruct) as AnyStruct) %[1]s &R
+                              let r3 = ((&r as &R{RI}) as AnyStruct) %[1]s &R
+                              destroy r
+                          }
+                        `,
+						op,
+					),
+				)
+
+				// The failable casts are allowed, and result in nil
+
+				if op == "as?" {
+					require.NoError(t, err)
+					return
+				}
+
+				errs := ExpectCheckerErrors(t, err, 2)
+
+				assert.IsType(t, &sema.InvalidRestrictedReferenceWideningError{}, errs[0])
+				assert.IsType(t, &sema.InvalidRestrictedReferenceWideningError{}, errs[1])
+			})
+
+			t.Run("restricted reference parameter", func(t *testing.T) {
+
+				_, err := ParseAndCheck(t,
+					fmt.Sprintf(
+						`
+                          resource interface RI {}
+
+                          resource R: RI {}
+
+                          fun test(r: &R{RI}) {
+                              let r2 = r %s &R
+                          }
+                        `,
+						op,
+					),
+				)
+
+				// The failable cast is allowed
+
+				if op == "as?" {
+					require.NoError(t, err)
+					return
+				}
+
+				errs := ExpectCheckerErrors(t, err, 1)
+
+				assert.IsType(t, &sema.TypeMismatchError{}, errs[0])
+			})
+
+			t.Run("restricted reference parameter, statically widened", func(t *testing.T) {
+
+				// The parameter might be a reference of the unrestricted type,
+				// so the cast might succeed
+
+				_, err := ParseAndCheck(t,
+					fmt.Sprintf(
+						`
+                          resource interface RI {}
+
+                          resource R: RI {}
+
+                          fun test(r: &R{RI}) {
+                              let r2 = ((r as AnyStruct) as AnyStruct) %s &R
+                          }
+                        `,
+						op,
+					),
+				)
+
+				require.NoError(t, err)
+			})
+
+			t.Run("upcast unrestricted reference, statically widened", func(t *testing.T) {
+
+				_, err := ParseAndCheck(t,
+					fmt.Sprintf(
+						`
+                          resource interface RI {}
+
+                          resource R: RI {}
+
+                          fun test() {
+                              let r <- create R()
+                              let ref: &R{RI} = &r as &R
+                              let r2 = (ref as AnyStruct) %s &R
+                              destroy r
+                          }
+                        `,
+						op,
+					),
+				)
+
+				require.NoError(t, err)
+			})
+
+			t.Run("borrowed restricted reference, statically widened", func(t *testing.T) {
+
+				_, err := ParseAndCheck(t,
+					fmt.Sprintf(
+						`
+                          resource interface RI {}
+
+                          resource R: RI {}
+
+                          fun test(cap: Capability) {
+                              let ref = cap.borrow<&R{RI}>()!
+                              let r2 = (ref as AnyStruct) %s &R
+                          }
+                        `,
+						op,
+					),
+				)
+
+				// The failable cast is allowed, and results in nil
+
+				if op == "as?" {
+					require.NoError(t, err)
+					return
+				}
+
+				errs := ExpectCheckerErrors(t, err, 1)
+
+				assert.IsType(t, &sema.InvalidRestrictedReferenceWideningError{}, errs[0])
+			})
+
+			t.Run("restricted reference of user-defined borrow function, statically widened", func(t *testing.T) {
+
+				// The function might return a reference of the unrestricted type,
+				// so the cast might succeed
+
+				_, err := ParseAndCheck(t,
+					fmt.Sprintf(
+						`
+                          resource interface RI {}
+
+                          resource R: RI {}
+
+                          struct Holder {
+                              fun borrow(): &R{RI}? {
+                                  return nil
+                              }
+                          }
+
+                          fun test(holder: Holder) {
+                              let ref = holder.borrow()!
+                              let r2 = (ref as AnyStruct) %s &R
+                          }
+                        `,
+						op,
+					),
+				)
+
+				require.NoError(t, err)
+			})
+
+			t.Run("authorized restricted reference, statically widened", func(t *testing.T) {
+
+				_, err := ParseAndCheck(t,
+					fmt.Sprintf(
+						`
+                          resource interface RI {}
+
+                          resource R: RI {}
+
+                          fun test(r: auth &R{RI}) {
+                              let r2 = (r as AnyStruct) %s &R
+                          }
+                        `,
+						op,
+					),
+				)
+
+				require.NoError(t, err)
+			})
+
+			t.Run("unrestricted reference, statically widened", func(t *testing.T) {
+
+				_, err := ParseAndCheck(t,
+					fmt.Sprintf(
+						`
+                          resource interface RI {}
+
+                          resource R: RI {}
+
+                          fun test(r: &R) {
+                              let r2 = (r as AnyStruct) %s &R
+                          }
+                        `,
+						op,
+					),
+				)
+
+				require.NoError(t, err)
+			})
+		})
+	}
+}
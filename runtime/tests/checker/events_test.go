@@ -189,6 +189,48 @@ func TestCheckEventDeclaration(t *testing.T) {
 
 }
 
+func TestCheckEventIndexedFields(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("valid", func(t *testing.T) {
+
+		t.Parallel()
+
+		checker, err := ParseAndCheck(t, `
+          /// Emitted when tokens are transferred.
+          /// pragma indexed from, to
+          /// pragma indexed to
+          event Transfer(from: Address, to: Address, amount: UFix64)
+
+          event Other(value: Int)
+        `)
+		require.NoError(t, err)
+
+		transferType := RequireGlobalType(t, checker.Elaboration, "Transfer").(*sema.CompositeType)
+		assert.Equal(t, []string{"from", "to"}, transferType.IndexedFields)
+
+		otherType := RequireGlobalType(t, checker.Elaboration, "Other").(*sema.CompositeType)
+		assert.Empty(t, otherType.IndexedFields)
+	})
+
+	t.Run("invalid: unknown field", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t, `
+          /// pragma indexed from, amount
+          event Transfer(from: Address, to: Address)
+        `)
+
+		errs := ExpectCheckerErrors(t, err, 1)
+
+		var indexedFieldErr *sema.InvalidIndexedEventFieldError
+		require.ErrorAs(t, errs[0], &indexedFieldErr)
+		assert.Equal(t, "amount", indexedFieldErr.Name)
+	})
+}
+
 func TestCheckEmitEvent(t *testing.T) {
 
 	t.Parallel()
@@ -922,3 +922,474 @@ func TestCheckBorrowOfCapabilityWithoutTypeArgument(t *testing.T) {
 
 	require.NoError(t, err)
 }
+
+func TestCheckGenericFunctionDeclaration(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("inferred type argument", func(t *testing.T) {
+
+		t.Parallel()
+
+		checker, err := ParseAndCheck(t, `
+          fun identity<T: AnyStruct>(_ x: T): T {
+              return x
+          }
+
+          let x = identity(1)
+          let y = identity("hello")
+        `)
+
+		require.NoError(t, err)
+
+		assert.Equal(t,
+			sema.IntType,
+			RequireGlobalValue(t, checker.Elaboration, "x"),
+		)
+
+		assert.Equal(t,
+			sema.StringType,
+			RequireGlobalValue(t, checker.Elaboration, "y"),
+		)
+	})
+
+	t.Run("explicit type argument", func(t *testing.T) {
+
+		t.Parallel()
+
+		checker, err := ParseAndCheck(t, `
+          fun identity<T>(_ x: T): T {
+              return x
+          }
+
+          let x = identity<Int>(1)
+        `)
+
+		require.NoError(t, err)
+
+		assert.Equal(t,
+			sema.IntType,
+			RequireGlobalValue(t, checker.Elaboration, "x"),
+		)
+	})
+
+	t.Run("explicit type argument, mismatch", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t, `
+          fun identity<T>(_ x: T): T {
+              return x
+          }
+
+          let x = identity<String>(1)
+        `)
+
+		errs := ExpectCheckerErrors(t, err, 2)
+
+		require.IsType(t, &sema.TypeParameterTypeMismatchError{}, errs[0])
+		require.IsType(t, &sema.TypeMismatchError{}, errs[1])
+	})
+
+	t.Run("multiple type parameters", func(t *testing.T) {
+
+		t.Parallel()
+
+		checker, err := ParseAndCheck(t, `
+          fun second<T, U>(_ x: T, _ y: U): U {
+              return y
+          }
+
+          let x = second(1, "two")
+        `)
+
+		require.NoError(t, err)
+
+		assert.Equal(t,
+			sema.StringType,
+			RequireGlobalValue(t, checker.Elaboration, "x"),
+		)
+	})
+
+	t.Run("type parameter used in composite type", func(t *testing.T) {
+
+		t.Parallel()
+
+		checker, err := ParseAndCheck(t, `
+          fun first<T>(_ xs: [T]): T? {
+              if xs.length == 0 {
+                  return nil
+              }
+              return xs[0]
+          }
+
+          let x = first([1, 2, 3])
+        `)
+
+		require.NoError(t, err)
+
+		assert.Equal(t,
+			&sema.OptionalType{Type: sema.IntType},
+			RequireGlobalValue(t, checker.Elaboration, "x"),
+		)
+	})
+
+	t.Run("inconsistent type arguments", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t, `
+          fun pair<T>(_ x: T, _ y: T) {}
+
+          let x = pair(1, "two")
+        `)
+
+		errs := ExpectCheckerErrors(t, err, 2)
+
+		require.IsType(t, &sema.TypeParameterTypeMismatchError{}, errs[0])
+		require.IsType(t, &sema.TypeMismatchError{}, errs[1])
+	})
+
+	t.Run("type bound satisfied", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t, `
+          fun double<T: Integer>(_ x: T): T {
+              return x
+          }
+
+          let x = double(UInt8(1))
+        `)
+
+		require.NoError(t, err)
+	})
+
+	t.Run("type bound violated", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t, `
+          fun double<T: Integer>(_ x: T): T {
+              return x
+          }
+
+          let x = double("one")
+        `)
+
+		errs := ExpectCheckerErrors(t, err, 1)
+
+		require.IsType(t, &sema.TypeMismatchError{}, errs[0])
+	})
+
+	t.Run("type bound violated, explicit type argument", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t, `
+          fun double<T: Integer>(_ x: T): T {
+              return x
+          }
+
+          let x = double<String>("one")
+        `)
+
+		errs := ExpectCheckerErrors(t, err, 1)
+
+		require.IsType(t, &sema.TypeMismatchError{}, errs[0])
+	})
+
+	t.Run("uninferrable type parameter", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t, `
+          fun make<T>(): T? {
+              return nil
+          }
+
+          let x = make()
+        `)
+
+		errs := ExpectCheckerErrors(t, err, 1)
+
+		require.IsType(t, &sema.TypeParameterTypeInferenceError{}, errs[0])
+	})
+
+	t.Run("type parameter is subtype of type bound", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t, `
+          fun toAnyStruct<T: Integer>(_ x: T): Integer {
+              let y: AnyStruct = x
+              return x
+          }
+        `)
+
+		require.NoError(t, err)
+	})
+
+	t.Run("type parameter is not subtype of other type", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t, `
+          fun toInt<T: Integer>(_ x: T): Int {
+              return x
+          }
+        `)
+
+		errs := ExpectCheckerErrors(t, err, 1)
+
+		require.IsType(t, &sema.TypeMismatchError{}, errs[0])
+	})
+
+	t.Run("resource, type bound", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t, `
+          resource R {}
+
+          fun identity<T: AnyResource>(_ r: @T): @T {
+              return <-r
+          }
+
+          let r <- identity(<-create R())
+        `)
+
+		require.NoError(t, err)
+	})
+
+	t.Run("resource, missing resource annotation", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t, `
+          fun identity<T: AnyResource>(_ r: T): @T {
+              return <-r
+          }
+        `)
+
+		errs := ExpectCheckerErrors(t, err, 1)
+
+		require.IsType(t, &sema.MissingResourceAnnotationError{}, errs[0])
+	})
+
+	t.Run("resource, loss", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t, `
+          fun drop<T: AnyResource>(_ r: @T) {}
+        `)
+
+		errs := ExpectCheckerErrors(t, err, 1)
+
+		require.IsType(t, &sema.ResourceLossError{}, errs[0])
+	})
+
+	t.Run("resource, without type bound", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t, `
+          resource R {}
+
+          fun identity<T>(_ x: T): T {
+              return x
+          }
+
+          let r <- identity(<-create R())
+        `)
+
+		errs := ExpectCheckerErrors(t, err, 1)
+
+		require.IsType(t, &sema.TypeMismatchError{}, errs[0])
+	})
+
+	t.Run("undeclared type bound", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t, `
+          fun test<T: X>(_ x: T) {}
+        `)
+
+		errs := ExpectCheckerErrors(t, err, 1)
+
+		require.IsType(t, &sema.NotDeclaredError{}, errs[0])
+	})
+
+	t.Run("redeclared type parameter", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t, `
+          fun test<T, T>() {}
+        `)
+
+		errs := ExpectCheckerErrors(t, err, 1)
+
+		require.IsType(t, &sema.RedeclarationError{}, errs[0])
+	})
+
+	t.Run("type parameter not visible outside of function", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t, `
+          fun test<T>(_ x: T) {}
+
+          let x: T = 1
+        `)
+
+		errs := ExpectCheckerErrors(t, err, 1)
+
+		require.IsType(t, &sema.NotDeclaredError{}, errs[0])
+	})
+
+	t.Run("composite function", func(t *testing.T) {
+
+		t.Parallel()
+
+		checker, err := ParseAndCheck(t, `
+          struct S {
+              fun identity<T>(_ x: T): T {
+                  return x
+              }
+          }
+
+          let x = S().identity(true)
+        `)
+
+		require.NoError(t, err)
+
+		assert.Equal(t,
+			sema.BoolType,
+			RequireGlobalValue(t, checker.Elaboration, "x"),
+		)
+	})
+
+	t.Run("local function", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t, `
+          fun test(): Int {
+              fun identity<T>(_ x: T): T {
+                  return x
+              }
+              return identity(1)
+          }
+        `)
+
+		require.NoError(t, err)
+	})
+
+	t.Run("interface function", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t, `
+          struct interface SI {
+              fun identity<T>(_ x: T): T
+          }
+        `)
+
+		errs := ExpectCheckerErrors(t, err, 1)
+
+		require.IsType(t, &sema.InvalidInterfaceFunctionTypeParametersError{}, errs[0])
+	})
+}
+
+func TestCheckGenericFunctionDeclarationRuntimeTypes(t *testing.T) {
+
+	t.Parallel()
+
+	test := func(name string, code string) {
+		t.Run(name, func(t *testing.T) {
+
+			t.Parallel()
+
+			_, err := ParseAndCheck(t, code)
+
+			errs := ExpectCheckerErrors(t, err, 1)
+
+			require.IsType(t, &sema.GenericTypeRuntimeTypeError{}, errs[0])
+		})
+	}
+
+	test("failable cast", `
+      fun cast<T: AnyStruct>(_ x: AnyStruct): T? {
+          return x as? T
+      }
+    `)
+
+	test("force cast", `
+      fun cast<T: AnyStruct>(_ x: AnyStruct): T {
+          return x as! T
+      }
+    `)
+
+	test("force cast, nested", `
+      fun cast<T: AnyStruct>(_ x: AnyStruct): [T] {
+          return x as! [T]
+      }
+    `)
+
+	test("run-time type", `
+      fun isInstance<T: AnyStruct>(_ x: AnyStruct): Bool {
+          return x.isInstance(Type<T>())
+      }
+    `)
+
+	test("array literal", `
+      fun pair<T: AnyStruct>(_ x: T, _ y: T): [T] {
+          return [x, y]
+      }
+    `)
+
+	test("dictionary literal", `
+      fun single<T: AnyStruct>(_ x: T): {String: T} {
+          return {"x": x}
+      }
+    `)
+
+	test("reference", `
+      fun ref<T: AnyStruct>(_ x: T) {
+          let ref = &x as &T
+      }
+    `)
+
+	t.Run("static cast", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t, `
+          fun identity<T: AnyStruct>(_ x: T): T {
+              return x as T
+          }
+        `)
+
+		require.NoError(t, err)
+	})
+
+	t.Run("declared generic function", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t, `
+          fun identity<T: AnyStruct>(_ x: T): T {
+              return x
+          }
+
+          fun twice<T: AnyStruct>(_ x: T): T {
+              return identity<T>(identity(x))
+          }
+        `)
+
+		require.NoError(t, err)
+	})
+}
@@ -63,6 +63,23 @@ func TestCheckMetaType(t *testing.T) {
 			RequireGlobalValue(t, checker.Elaboration, "type"),
 		)
 	})
+
+	t.Run("isStorable", func(t *testing.T) {
+
+		t.Parallel()
+
+		checker, err := ParseAndCheck(t, `
+          let type = Type<[Int]>()
+          let isStorable = type.isStorable
+        `)
+
+		require.NoError(t, err)
+
+		assert.Equal(t,
+			sema.BoolType,
+			RequireGlobalValue(t, checker.Elaboration, "isStorable"),
+		)
+	})
 }
 
 func TestCheckIsInstance(t *testing.T) {
@@ -451,3 +451,75 @@ func TestCheckOptionalMap(t *testing.T) {
 		assert.IsType(t, &sema.TypeMismatchError{}, errs[0])
 	})
 }
+
+func TestCheckOptionalOrElse(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("valid", func(t *testing.T) {
+
+		_, err := ParseAndCheckWithPanic(t, `
+          fun test(): Int {
+              let x: Int? = 1
+              return x.orElse(fun (): Int {
+                  return 2
+              })
+          }
+        `)
+
+		require.NoError(t, err)
+	})
+
+	t.Run("invalid parameter", func(t *testing.T) {
+
+		_, err := ParseAndCheckWithPanic(t, `
+          fun test(): Int {
+              let x: Int? = 1
+              return x.orElse(fun (_ value: Int): Int {
+                  return value
+              })
+          }
+        `)
+
+		errs := ExpectCheckerErrors(t, err, 1)
+
+		assert.IsType(t, &sema.TypeMismatchError{}, errs[0])
+	})
+
+	t.Run("invalid return type", func(t *testing.T) {
+
+		_, err := ParseAndCheckWithPanic(t, `
+          fun test(): Int {
+              let x: Int? = 1
+              return x.orElse(fun (): String {
+                  return "2"
+              })
+          }
+        `)
+
+		errs := ExpectCheckerErrors(t, err, 1)
+
+		assert.IsType(t, &sema.TypeMismatchError{}, errs[0])
+	})
+
+	t.Run("invalid resource", func(t *testing.T) {
+
+		_, err := ParseAndCheckWithPanic(t, `
+          resource R {}
+
+          fun test(): @R {
+              let x: @R? <- create R()
+              let r <- x.orElse(fun (): @R {
+                  return <-create R()
+              })
+              destroy x
+              return <-r
+          }
+        `)
+
+		errs := ExpectCheckerErrors(t, err, 2)
+
+		assert.IsType(t, &sema.InvalidResourceOptionalMemberError{}, errs[0])
+		assert.IsType(t, &sema.ResourceLossError{}, errs[1])
+	})
+}
@@ -20,6 +20,7 @@ package checker
 
 import (
 	"fmt"
+	"math/big"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -27,6 +28,7 @@ import (
 
 	"github.com/onflow/cadence/runtime/common"
 	"github.com/onflow/cadence/runtime/sema"
+	"github.com/onflow/cadence/runtime/stdlib"
 )
 
 func TestCheckReferenceTypeOuter(t *testing.T) {
@@ -1017,6 +1019,69 @@ func TestCheckReferenceExpressionOfOptional(t *testing.T) {
 		assert.IsType(t, &sema.NonReferenceTypeReferenceError{}, errs[0])
 		assert.IsType(t, &sema.OptionalTypeReferenceError{}, errs[1])
 	})
+
+	t.Run("non-index, no suggestion", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t, `
+          let i: Int? = 1
+          let ref = &i as &Int
+        `)
+
+		errs := ExpectCheckerErrors(t, err, 2)
+
+		require.IsType(t, &sema.OptionalTypeReferenceError{}, errs[1])
+
+		optionalErr := errs[1].(*sema.OptionalTypeReferenceError)
+		assert.Empty(t, optionalErr.Suggestion)
+		assert.Empty(t, optionalErr.SecondaryError())
+	})
+
+	t.Run("index, suggestion", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t, `
+          let dict: {String: Int?} = {}
+          let ref = &dict["one"] as &Int
+        `)
+
+		errs := ExpectCheckerErrors(t, err, 2)
+
+		assert.IsType(t, &sema.TypeMismatchError{}, errs[0])
+		require.IsType(t, &sema.OptionalTypeReferenceError{}, errs[1])
+
+		optionalErr := errs[1].(*sema.OptionalTypeReferenceError)
+		assert.Equal(t, `&dict["one"]!! as &Int`, optionalErr.Suggestion)
+		assert.Equal(t, "did you mean `&dict[\"one\"]!! as &Int`?", optionalErr.SecondaryError())
+
+		// The suggestion is valid
+
+		_, err = ParseAndCheck(t, `
+          let dict: {String: Int?} = {}
+          let ref = &dict["one"]!! as &Int
+        `)
+		require.NoError(t, err)
+	})
+
+	t.Run("index, type mismatch, no suggestion", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t, `
+          let dict: {String: String?} = {}
+          let ref = &dict["one"] as &Int
+        `)
+
+		errs := ExpectCheckerErrors(t, err, 2)
+
+		assert.IsType(t, &sema.TypeMismatchError{}, errs[0])
+		require.IsType(t, &sema.OptionalTypeReferenceError{}, errs[1])
+
+		optionalErr := errs[1].(*sema.OptionalTypeReferenceError)
+		assert.Empty(t, optionalErr.Suggestion)
+	})
 }
 
 func TestCheckInvalidReferenceExpressionNonReferenceAmbiguous(t *testing.T) {
@@ -1079,6 +1144,96 @@ func TestCheckInvalidDictionaryAccessReference(t *testing.T) {
 	assert.Equal(t, 21, typeMismatchError.EndPos.Column)
 }
 
+func TestCheckReferenceToFunctionCallResult(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("struct", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t, `
+          struct S {}
+
+          fun makeS(): S {
+              return S()
+          }
+
+          let ref = &makeS() as &S
+        `)
+
+		require.NoError(t, err)
+	})
+
+	t.Run("struct constructor", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t, `
+          struct S {}
+
+          let ref = &S() as &S
+        `)
+
+		require.NoError(t, err)
+	})
+
+	t.Run("resource", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t, `
+          resource R {}
+
+          fun makeR(): @R {
+              return <-create R()
+          }
+
+          fun test() {
+              let ref = &makeR() as &R
+          }
+        `)
+
+		errs := ExpectCheckerErrors(t, err, 1)
+
+		assert.IsType(t, &sema.EphemeralResourceReferenceError{}, errs[0])
+	})
+
+	t.Run("resource creation", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t, `
+          resource R {}
+
+          fun test() {
+              let ref = &(<-create R()) as &R
+          }
+        `)
+
+		errs := ExpectCheckerErrors(t, err, 1)
+
+		assert.IsType(t, &sema.EphemeralResourceReferenceError{}, errs[0])
+	})
+
+	t.Run("resource variable", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t, `
+          resource R {}
+
+          fun test() {
+              let r <- create R()
+              let ref = &r as &R
+              destroy r
+          }
+        `)
+
+		require.NoError(t, err)
+	})
+}
+
 func TestCheckReferenceTypeImplicitConformance(t *testing.T) {
 
 	t.Parallel()
@@ -1129,3 +1284,1142 @@ func TestCheckReferenceTypeImplicitConformance(t *testing.T) {
 		require.IsType(t, &sema.TypeMismatchError{}, errs[0])
 	})
 }
+
+func TestCheckUnusedReferenceHint(t *testing.T) {
+
+	t.Parallel()
+
+	parseAndCheck := func(t *testing.T, code string, enabled bool) *sema.Checker {
+		checker, err := ParseAndCheckWithOptions(t,
+			code,
+			ParseAndCheckOptions{
+				Options: []sema.Option{
+					sema.WithUnusedReferenceHintsEnabled(enabled),
+				},
+			},
+		)
+		require.NoError(t, err)
+		return checker
+	}
+
+	t.Run("discarded", func(t *testing.T) {
+
+		t.Parallel()
+
+		checker := parseAndCheck(t,
+			`
+              fun test(x: Int) {
+                  &x as &Int
+              }
+            `,
+			true,
+		)
+
+		hints := checker.Hints()
+		require.Len(t, hints, 1)
+		require.IsType(t, &sema.UnusedReferenceHint{}, hints[0])
+	})
+
+	t.Run("discarded, disabled", func(t *testing.T) {
+
+		t.Parallel()
+
+		checker := parseAndCheck(t,
+			`
+              fun test(x: Int) {
+                  &x as &Int
+              }
+            `,
+			false,
+		)
+
+		require.Empty(t, checker.Hints())
+	})
+
+	t.Run("function argument", func(t *testing.T) {
+
+		t.Parallel()
+
+		checker := parseAndCheck(t,
+			`
+              fun use(_ ref: &Int) {}
+
+              fun test() {
+                  let x = 1
+                  use(&x as &Int)
+              }
+            `,
+			true,
+		)
+
+		require.Empty(t, checker.Hints())
+	})
+
+	t.Run("saved", func(t *testing.T) {
+
+		t.Parallel()
+
+		checker := parseAndCheck(t,
+			`
+              fun test() {
+                  let x = 1
+                  let ref = &x as &Int
+              }
+            `,
+			true,
+		)
+
+		require.Empty(t, checker.Hints())
+	})
+}
+
+func TestCheckRejectStoredReferences(t *testing.T) {
+
+	t.Parallel()
+
+	parseAndCheck := func(t *testing.T, code string, enabled bool) error {
+		_, err := ParseAndCheckWithOptions(t,
+			code,
+			ParseAndCheckOptions{
+				Options: []sema.Option{
+					sema.WithRejectStoredReferences(enabled),
+				},
+			},
+		)
+		return err
+	}
+
+	t.Run("reference", func(t *testing.T) {
+
+		t.Parallel()
+
+		err := parseAndCheck(t,
+			`
+              fun test(account: AuthAccount) {
+                  let x = 1
+                  account.save((&x as &Int) as AnyStruct, to: /storage/x)
+              }
+            `,
+			true,
+		)
+
+		errs := ExpectCheckerErrors(t, err, 1)
+
+		require.IsType(t, &sema.StoredReferenceError{}, errs[0])
+	})
+
+	t.Run("reference, disabled", func(t *testing.T) {
+
+		t.Parallel()
+
+		err := parseAndCheck(t,
+			`
+              fun test(account: AuthAccount) {
+                  let x = 1
+                  account.save((&x as &Int) as AnyStruct, to: /storage/x)
+              }
+            `,
+			false,
+		)
+
+		require.NoError(t, err)
+	})
+
+	t.Run("array of references", func(t *testing.T) {
+
+		t.Parallel()
+
+		err := parseAndCheck(t,
+			`
+              fun test(account: AuthAccount) {
+                  let x = 1
+                  let refs = [&x as &Int]
+                  account.ensureSaved(refs as AnyStruct, to: /storage/refs)
+              }
+            `,
+			true,
+		)
+
+		errs := ExpectCheckerErrors(t, err, 1)
+
+		require.IsType(t, &sema.StoredReferenceError{}, errs[0])
+	})
+
+	t.Run("composite with reference field", func(t *testing.T) {
+
+		t.Parallel()
+
+		err := parseAndCheck(t,
+			`
+              struct S {
+                  let ref: &Int
+
+                  init(ref: &Int) {
+                      self.ref = ref
+                  }
+              }
+
+              fun test(account: AuthAccount) {
+                  let x = 1
+                  account.save(S(ref: &x as &Int) as AnyStruct, to: /storage/s)
+              }
+            `,
+			true,
+		)
+
+		errs := ExpectCheckerErrors(t, err, 1)
+
+		require.IsType(t, &sema.StoredReferenceError{}, errs[0])
+	})
+
+	t.Run("no reference", func(t *testing.T) {
+
+		t.Parallel()
+
+		err := parseAndCheck(t,
+			`
+              fun test(account: AuthAccount) {
+                  account.save([1, 2] as AnyStruct, to: /storage/numbers)
+              }
+            `,
+			true,
+		)
+
+		require.NoError(t, err)
+	})
+}
+
+func TestCheckWeakReference(t *testing.T) {
+
+	t.Parallel()
+
+	parseAndCheck := func(t *testing.T, code string) (*sema.Checker, error) {
+		return ParseAndCheckWithOptions(t,
+			code,
+			ParseAndCheckOptions{
+				Options: []sema.Option{
+					sema.WithPredeclaredValues(
+						stdlib.StandardLibraryFunctions{
+							stdlib.WeakReferenceFunction,
+						}.ToSemaValueDeclarations(),
+					),
+				},
+			},
+		)
+	}
+
+	t.Run("resource reference", func(t *testing.T) {
+
+		t.Parallel()
+
+		checker, err := parseAndCheck(t, `
+          resource R {}
+
+          let r <- create R()
+          let weak = weakReference(&r as &R)
+          let ref = weak()
+        `)
+		require.NoError(t, err)
+
+		rType := RequireGlobalType(t, checker.Elaboration, "R")
+		referenceType := &sema.ReferenceType{
+			Type: rType,
+		}
+
+		assert.Equal(t,
+			&sema.FunctionType{
+				ReturnTypeAnnotation: sema.NewTypeAnnotation(
+					&sema.OptionalType{
+						Type: referenceType,
+					},
+				),
+			},
+			RequireGlobalValue(t, checker.Elaboration, "weak"),
+		)
+
+		assert.Equal(t,
+			&sema.OptionalType{
+				Type: referenceType,
+			},
+			RequireGlobalValue(t, checker.Elaboration, "ref"),
+		)
+	})
+
+	t.Run("struct reference", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := parseAndCheck(t, `
+          struct S {}
+
+          let s = S()
+          let weak = weakReference(&s as &S)
+        `)
+
+		errs := ExpectCheckerErrors(t, err, 1)
+
+		require.IsType(t, &sema.TypeMismatchError{}, errs[0])
+	})
+
+	t.Run("not declared by default", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t, `
+          resource R {}
+
+          let r <- create R()
+          let weak = weakReference(&r as &R)
+        `)
+
+		errs := ExpectCheckerErrors(t, err, 1)
+
+		require.IsType(t, &sema.NotDeclaredError{}, errs[0])
+	})
+}
+
+func TestCheckInvalidReferenceExpressionNonReferenceReportsReferencedExpressionErrors(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("invocation", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t, `
+          fun f(_ x: Int): Int {
+              return x
+          }
+
+          let y = &f(true) as Int
+        `)
+
+		errs := ExpectCheckerErrors(t, err, 2)
+
+		assert.IsType(t, &sema.NonReferenceTypeReferenceError{}, errs[0])
+		assert.IsType(t, &sema.TypeMismatchError{}, errs[1])
+	})
+
+	t.Run("index", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t, `
+          let xs: {Int: Int} = {}
+          let y = &xs[z] as Int
+        `)
+
+		errs := ExpectCheckerErrors(t, err, 2)
+
+		assert.IsType(t, &sema.NonReferenceTypeReferenceError{}, errs[0])
+		assert.IsType(t, &sema.NotDeclaredError{}, errs[1])
+	})
+}
+
+func TestCheckReferenceExpressionConstantSizedArrayIndexBounds(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("in bounds", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t, `
+          let xs: [Int; 3] = [1, 2, 3]
+          let ref = &xs[2] as &Int
+        `)
+
+		require.NoError(t, err)
+	})
+
+	t.Run("out of bounds", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t, `
+          let xs: [Int; 3] = [1, 2, 3]
+          let ref = &xs[5] as &Int
+        `)
+
+		errs := ExpectCheckerErrors(t, err, 1)
+
+		var indexErr *sema.ArrayIndexOutOfBoundsError
+		require.ErrorAs(t, errs[0], &indexErr)
+
+		assert.Equal(t, big.NewInt(5), indexErr.Index)
+		assert.Equal(t, int64(3), indexErr.Size)
+	})
+
+	t.Run("out of bounds, reference to array", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t, `
+          let xs: [Int; 3] = [1, 2, 3]
+          let xsRef = &xs as &[Int; 3]
+          let ref = &xsRef[3] as &Int
+        `)
+
+		errs := ExpectCheckerErrors(t, err, 1)
+
+		assert.IsType(t, &sema.ArrayIndexOutOfBoundsError{}, errs[0])
+	})
+
+	t.Run("variable-sized array", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t, `
+          let xs: [Int] = [1, 2, 3]
+          let ref = &xs[5] as &Int
+        `)
+
+		require.NoError(t, err)
+	})
+
+	t.Run("non-literal index", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t, `
+          let xs: [Int; 3] = [1, 2, 3]
+          let index = 5
+          let ref = &xs[index] as &Int
+        `)
+
+		require.NoError(t, err)
+	})
+
+	t.Run("not referenced", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t, `
+          let xs: [Int; 3] = [1, 2, 3]
+          let x = xs[5]
+        `)
+
+		require.NoError(t, err)
+	})
+}
+
+func TestCheckAuthorizedReferenceThroughUnauthorizedReference(t *testing.T) {
+
+	t.Parallel()
+
+	const types = `
+      resource R {
+          let s: @S
+          let ss: @[S]
+
+          init() {
+              self.s <- create S()
+              self.ss <- [<-create S()]
+          }
+
+          destroy() {
+              destroy self.s
+              destroy self.ss
+          }
+      }
+
+      resource S {}
+    `
+
+	t.Run("field, unauthorized reference", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t,
+			types+`
+              fun test(ref: &R): auth &S {
+                  return &ref.s as auth &S
+              }
+            `,
+		)
+
+		errs := ExpectCheckerErrors(t, err, 1)
+
+		var escalationErr *sema.UnauthorizedReferenceEscalationError
+		require.ErrorAs(t, errs[0], &escalationErr)
+
+		assert.Equal(t, "&R", escalationErr.AccessedType.QualifiedString())
+	})
+
+	t.Run("element, unauthorized reference", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t,
+			types+`
+              fun test(ref: &R): auth &S {
+                  return &ref.ss[0] as auth &S
+              }
+            `,
+		)
+
+		errs := ExpectCheckerErrors(t, err, 1)
+
+		assert.IsType(t, &sema.UnauthorizedReferenceEscalationError{}, errs[0])
+	})
+
+	t.Run("field, unauthorized reference, unauthorized result", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t,
+			types+`
+              fun test(ref: &R): &S {
+                  return &ref.s as &S
+              }
+            `,
+		)
+
+		require.NoError(t, err)
+	})
+
+	t.Run("field, authorized reference", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t,
+			types+`
+              fun test(ref: auth &R): auth &S {
+                  return &ref.s as auth &S
+              }
+            `,
+		)
+
+		require.NoError(t, err)
+	})
+
+	t.Run("owned value", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t,
+			types+`
+              fun test(r: @R) {
+                  let ref = &r.s as auth &S
+                  destroy r
+              }
+            `,
+		)
+
+		require.NoError(t, err)
+	})
+}
+
+func TestCheckReferenceExpressionOfOptionalChaining(t *testing.T) {
+
+	t.Parallel()
+
+	const types = `
+      struct Inner {
+          let value: Int
+
+          init(value: Int) {
+              self.value = value
+          }
+      }
+
+      struct Outer {
+          let inner: Inner?
+
+          init(inner: Inner?) {
+              self.inner = inner
+          }
+      }
+
+      let outer: Outer? = Outer(inner: Inner(value: 1))
+    `
+
+	t.Run("optional reference", func(t *testing.T) {
+
+		t.Parallel()
+
+		checker, err := ParseAndCheck(t, types+`
+          let ref = &outer?.inner as &Inner?
+        `)
+
+		require.NoError(t, err)
+
+		refValueType := RequireGlobalValue(t, checker.Elaboration, "ref")
+
+		require.IsType(t, &sema.OptionalType{}, refValueType)
+		require.IsType(t,
+			&sema.ReferenceType{},
+			refValueType.(*sema.OptionalType).Type,
+		)
+	})
+
+	t.Run("nested optional chaining", func(t *testing.T) {
+
+		t.Parallel()
+
+		checker, err := ParseAndCheck(t, types+`
+          let ref = &outer?.inner?.value as &Int?
+        `)
+
+		require.NoError(t, err)
+
+		assert.Equal(t,
+			&sema.OptionalType{
+				Type: &sema.ReferenceType{
+					Type: sema.IntType,
+				},
+			},
+			RequireGlobalValue(t, checker.Elaboration, "ref"),
+		)
+	})
+
+	t.Run("non-optional reference", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t, types+`
+          let ref = &outer?.inner as &Inner
+        `)
+
+		errs := ExpectCheckerErrors(t, err, 2)
+
+		assert.IsType(t, &sema.TypeMismatchError{}, errs[0])
+		assert.IsType(t, &sema.OptionalTypeReferenceError{}, errs[1])
+	})
+
+	t.Run("type mismatch", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t, types+`
+          let ref = &outer?.inner?.value as &String?
+        `)
+
+		errs := ExpectCheckerErrors(t, err, 1)
+
+		assert.IsType(t, &sema.TypeMismatchError{}, errs[0])
+	})
+}
+
+func TestCheckReferenceEscape(t *testing.T) {
+
+	t.Parallel()
+
+	parseAndCheck := func(t *testing.T, code string) error {
+		_, err := ParseAndCheck(t, code)
+		return err
+	}
+
+	expectEscape := func(t *testing.T, code string, name string) {
+		err := parseAndCheck(t, code)
+
+		errs := ExpectCheckerErrors(t, err, 1)
+
+		var escapeErr *sema.ReferenceEscapesScopeError
+		require.ErrorAs(t, errs[0], &escapeErr)
+
+		assert.Equal(t, name, escapeErr.Name)
+	}
+
+	t.Run("return, local variable", func(t *testing.T) {
+
+		t.Parallel()
+
+		expectEscape(t,
+			`
+              fun test(): &Int {
+                  let x = 1
+                  return &x as &Int
+              }
+            `,
+			"x",
+		)
+	})
+
+	t.Run("return, parameter", func(t *testing.T) {
+
+		t.Parallel()
+
+		expectEscape(t,
+			`
+              fun test(x: Int): &Int {
+                  return &x as &Int
+              }
+            `,
+			"x",
+		)
+	})
+
+	t.Run("return, reference variable", func(t *testing.T) {
+
+		t.Parallel()
+
+		expectEscape(t,
+			`
+              fun test(): &Int {
+                  let x = 1
+                  let ref = &x as &Int
+                  return ref
+              }
+            `,
+			"x",
+		)
+	})
+
+	t.Run("return, reassigned reference variable", func(t *testing.T) {
+
+		t.Parallel()
+
+		expectEscape(t,
+			`
+              let y = 2
+
+              fun test(): &Int {
+                  let x = 1
+                  var ref = &y as &Int
+                  ref = &x as &Int
+                  return ref
+              }
+            `,
+			"x",
+		)
+	})
+
+	t.Run("assignment, field", func(t *testing.T) {
+
+		t.Parallel()
+
+		expectEscape(t,
+			`
+              struct S {
+                  var ref: &Int?
+
+                  init() {
+                      self.ref = nil
+                  }
+
+                  fun update() {
+                      let x = 1
+                      self.ref = &x as &Int
+                  }
+              }
+            `,
+			"x",
+		)
+	})
+
+	t.Run("assignment, global variable", func(t *testing.T) {
+
+		t.Parallel()
+
+		expectEscape(t,
+			`
+              let y = 2
+              var ref = &y as &Int
+
+              fun test() {
+                  let x = 1
+                  ref = &x as &Int
+              }
+            `,
+			"x",
+		)
+	})
+
+	t.Run("assignment, local variable", func(t *testing.T) {
+
+		t.Parallel()
+
+		err := parseAndCheck(t, `
+          fun test() {
+              let x = 1
+              let refs: [&Int] = [&x as &Int]
+              refs[0] = &x as &Int
+              var ref = &x as &Int
+              ref = &x as &Int
+          }
+        `)
+
+		require.NoError(t, err)
+	})
+
+	t.Run("return, field", func(t *testing.T) {
+
+		t.Parallel()
+
+		err := parseAndCheck(t, `
+          resource R {
+              let values: @[R]
+
+              init() {
+                  self.values <- []
+              }
+
+              fun borrowValue(): &R {
+                  return &self.values[0] as &R
+              }
+
+              fun borrowSelf(): &R {
+                  return &self as &R
+              }
+
+              destroy() {
+                  destroy self.values
+              }
+          }
+        `)
+
+		require.NoError(t, err)
+	})
+
+	t.Run("return, outer local variable", func(t *testing.T) {
+
+		t.Parallel()
+
+		err := parseAndCheck(t, `
+          fun test(): ((): &Int) {
+              let x = 1
+              return fun (): &Int {
+                  return &x as &Int
+              }
+          }
+        `)
+
+		require.NoError(t, err)
+	})
+
+	t.Run("return, cleared reference variable", func(t *testing.T) {
+
+		t.Parallel()
+
+		err := parseAndCheck(t, `
+          let y = 2
+
+          fun test(): &Int {
+              let x = 1
+              var ref = &x as &Int
+              ref = &y as &Int
+              return ref
+          }
+        `)
+
+		require.NoError(t, err)
+	})
+
+	t.Run("return, heap-allocated local variable", func(t *testing.T) {
+
+		t.Parallel()
+
+		err := parseAndCheck(t, `
+          struct S {}
+
+          resource R {}
+
+          fun testStruct(): &S {
+              let s = S()
+              return &s as &S
+          }
+
+          fun testResource(): &R {
+              let r <- create R()
+              let ref = &r as &R
+              destroy r
+              return ref
+          }
+
+          fun testArray(): &[Int] {
+              let values = [1]
+              return &values as &[Int]
+          }
+
+          fun testDictionary(): &{String: Int} {
+              let values = {"a": 1}
+              return &values as &{String: Int}
+          }
+        `)
+
+		require.NoError(t, err)
+	})
+
+	t.Run("return, array literal", func(t *testing.T) {
+
+		t.Parallel()
+
+		expectEscape(t,
+			`
+              fun test(): [&Int] {
+                  let x = 1
+                  return [&x as &Int]
+              }
+            `,
+			"x",
+		)
+	})
+
+	t.Run("return, dictionary literal", func(t *testing.T) {
+
+		t.Parallel()
+
+		expectEscape(t,
+			`
+              fun test(): {String: &Int} {
+                  let x = 1
+                  return {"x": &x as &Int}
+              }
+            `,
+			"x",
+		)
+	})
+
+	t.Run("return, optional", func(t *testing.T) {
+
+		t.Parallel()
+
+		expectEscape(t,
+			`
+              fun test(): &Int? {
+                  let x = 1
+                  let ref: &Int? = &x as &Int
+                  return ref
+              }
+            `,
+			"x",
+		)
+	})
+
+	t.Run("return, nil-coalescing", func(t *testing.T) {
+
+		t.Parallel()
+
+		expectEscape(t,
+			`
+              fun test(other: &Int?): &Int {
+                  let x = 1
+                  return other ?? &x as &Int
+              }
+            `,
+			"x",
+		)
+	})
+
+	t.Run("return, composite", func(t *testing.T) {
+
+		t.Parallel()
+
+		expectEscape(t,
+			`
+              struct S {
+                  let ref: &Int
+
+                  init(ref: &Int) {
+                      self.ref = ref
+                  }
+              }
+
+              fun test(): S {
+                  let x = 1
+                  return S(ref: &x as &Int)
+              }
+            `,
+			"x",
+		)
+	})
+
+	t.Run("return, composite variable", func(t *testing.T) {
+
+		t.Parallel()
+
+		expectEscape(t,
+			`
+              struct S {
+                  let ref: &Int
+
+                  init(ref: &Int) {
+                      self.ref = ref
+                  }
+              }
+
+              fun test(): S {
+                  let x = 1
+                  let s = S(ref: &x as &Int)
+                  return s
+              }
+            `,
+			"x",
+		)
+	})
+
+	t.Run("return, function result", func(t *testing.T) {
+
+		t.Parallel()
+
+		expectEscape(t,
+			`
+              fun identity(_ ref: &Int): &Int {
+                  return ref
+              }
+
+              fun test(): &Int {
+                  let x = 1
+                  return identity(&x as &Int)
+              }
+            `,
+			"x",
+		)
+	})
+
+	t.Run("return, function result without reference", func(t *testing.T) {
+
+		t.Parallel()
+
+		err := parseAndCheck(t, `
+          fun isSame(_ ref: &Int, _ other: &Int): Bool {
+              return ref == other
+          }
+
+          fun test(): Bool {
+              let x = 1
+              let ref = &x as &Int
+              return isSame(ref, ref)
+          }
+        `)
+
+		require.NoError(t, err)
+	})
+
+	t.Run("return, local array with appended reference", func(t *testing.T) {
+
+		t.Parallel()
+
+		expectEscape(t,
+			`
+              fun test(): [&Int] {
+                  let x = 1
+                  let refs: [&Int] = []
+                  refs.append(&x as &Int)
+                  return refs
+              }
+            `,
+			"x",
+		)
+	})
+
+	t.Run("append, field", func(t *testing.T) {
+
+		t.Parallel()
+
+		expectEscape(t,
+			`
+              struct S {
+                  let refs: [&Int]
+
+                  init() {
+                      self.refs = []
+                  }
+
+                  fun add() {
+                      let x = 1
+                      self.refs.append(&x as &Int)
+                  }
+              }
+            `,
+			"x",
+		)
+	})
+
+	t.Run("insert, global dictionary", func(t *testing.T) {
+
+		t.Parallel()
+
+		expectEscape(t,
+			`
+              let refs: {String: &Int} = {}
+
+              fun test() {
+                  let x = 1
+                  let ref = &x as &Int
+                  refs.insert(key: "x", ref)
+              }
+            `,
+			"x",
+		)
+	})
+
+	t.Run("return, capturing closure", func(t *testing.T) {
+
+		t.Parallel()
+
+		expectEscape(t,
+			`
+              fun test(): ((): &Int) {
+                  let x = 1
+                  let ref = &x as &Int
+                  return fun (): &Int {
+                      return ref
+                  }
+              }
+            `,
+			"x",
+		)
+	})
+
+	t.Run("assignment, capturing closure variable", func(t *testing.T) {
+
+		t.Parallel()
+
+		expectEscape(t,
+			`
+              var f: ((): Bool)? = nil
+
+              fun test() {
+                  let x = 1
+                  let ref = &x as &Int
+                  let g = fun (): Bool {
+                      return ref == ref
+                  }
+                  f = g
+              }
+            `,
+			"x",
+		)
+	})
+
+	t.Run("local capturing closure", func(t *testing.T) {
+
+		t.Parallel()
+
+		err := parseAndCheck(t, `
+          fun test(): Bool {
+              let x = 1
+              let ref = &x as &Int
+              let f = fun (): &Int {
+                  return ref
+              }
+              return f() == ref
+          }
+        `)
+
+		require.NoError(t, err)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheckWithOptions(t,
+			`
+              fun test(): &Int {
+                  let x = 1
+                  return &x as &Int
+              }
+            `,
+			ParseAndCheckOptions{
+				Options: []sema.Option{
+					sema.WithReferenceEscapeChecksEnabled(false),
+				},
+			},
+		)
+
+		require.NoError(t, err)
+	})
+}
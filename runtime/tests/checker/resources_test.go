@@ -3673,6 +3673,34 @@ func TestCheckInvalidResourceDictionaryValues(t *testing.T) {
 	assert.IsType(t, &sema.InvalidNestedResourceMoveError{}, errs[1])
 }
 
+func TestCheckInvalidResourceDictionaryMerging(t *testing.T) {
+
+	t.Parallel()
+
+	_, err := ParseAndCheck(t, `
+      resource X {}
+
+      fun test() {
+          let xs <- {"x1": <-create X()}
+          let ys <- {"x2": <-create X()}
+          let merged <- xs.merging(
+              <-ys,
+              resolve: fun (existing: @X, new: @X): @X {
+                  destroy new
+                  return <-existing
+              }
+          )
+          destroy merged
+          destroy xs
+      }
+    `)
+
+	errs := ExpectCheckerErrors(t, err, 2)
+
+	assert.IsType(t, &sema.InvalidResourceDictionaryMemberError{}, errs[0])
+	assert.IsType(t, &sema.ResourceLossError{}, errs[1])
+}
+
 func TestCheckInvalidResourceLossAfterMoveThroughDictionaryIndexing(t *testing.T) {
 
 	t.Parallel()
@@ -5049,3 +5077,68 @@ func TestCheckEmptyResourceCollectionMove(t *testing.T) {
 		require.NoError(t, err)
 	})
 }
+
+func TestCheckArraySortedByUUID(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("resource array", func(t *testing.T) {
+
+		t.Parallel()
+
+		checker, err := ParseAndCheck(t, `
+          resource R {}
+
+          fun test(rs: @[R]): @[R] {
+              let sorted <- rs.sortedByUUID()
+              destroy rs
+              return <-sorted
+          }
+        `)
+		require.NoError(t, err)
+
+		functionType := RequireGlobalValue(t, checker.Elaboration, "test").(*sema.FunctionType)
+		assert.Equal(t,
+			&sema.VariableSizedType{
+				Type: RequireGlobalType(t, checker.Elaboration, "R"),
+			},
+			functionType.ReturnTypeAnnotation.Type,
+		)
+	})
+
+	t.Run("struct array", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t, `
+          struct S {}
+
+          fun test(ss: [S]): [S] {
+              return ss.sortedByUUID()
+          }
+        `)
+
+		errs := ExpectCheckerErrors(t, err, 1)
+
+		require.IsType(t, &sema.NotDeclaredMemberError{}, errs[0])
+	})
+
+	t.Run("constant-sized resource array", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t, `
+          resource R {}
+
+          fun test(rs: @[R; 2]): @[R; 2] {
+              let sorted <- rs.sortedByUUID()
+              destroy rs
+              return <-sorted
+          }
+        `)
+
+		errs := ExpectCheckerErrors(t, err, 1)
+
+		require.IsType(t, &sema.NotDeclaredMemberError{}, errs[0])
+	})
+}
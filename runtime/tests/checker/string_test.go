@@ -292,6 +292,64 @@ func TestCheckStringEncodeHex(t *testing.T) {
 	)
 }
 
+func TestCheckArrayToHexString(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("variable-sized byte array", func(t *testing.T) {
+
+		t.Parallel()
+
+		checker, err := ParseAndCheck(t, `
+            let bytes: [UInt8] = [1, 2, 3, 0xCA, 0xDE]
+            let x = bytes.toHexString()
+            let y = x.decodeHex().toHexString()
+	    `)
+
+		require.NoError(t, err)
+
+		assert.Equal(t,
+			sema.StringType,
+			RequireGlobalValue(t, checker.Elaboration, "x"),
+		)
+		assert.Equal(t,
+			sema.StringType,
+			RequireGlobalValue(t, checker.Elaboration, "y"),
+		)
+	})
+
+	t.Run("constant-sized byte array", func(t *testing.T) {
+
+		t.Parallel()
+
+		checker, err := ParseAndCheck(t, `
+            let bytes: [UInt8; 2] = [0xCA, 0xDE]
+            let x = bytes.toHexString()
+	    `)
+
+		require.NoError(t, err)
+
+		assert.Equal(t,
+			sema.StringType,
+			RequireGlobalValue(t, checker.Elaboration, "x"),
+		)
+	})
+
+	t.Run("non-byte array", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t, `
+            let numbers: [Int] = [1, 2, 3]
+            let x = numbers.toHexString()
+	    `)
+
+		errs := ExpectCheckerErrors(t, err, 1)
+
+		require.IsType(t, &sema.NotDeclaredMemberError{}, errs[0])
+	})
+}
+
 func TestCheckStringUtf8Field(t *testing.T) {
 
 	t.Parallel()
@@ -0,0 +1,123 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2020 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package checker
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/cadence/runtime/ast"
+	"github.com/onflow/cadence/runtime/sema"
+)
+
+func TestCheckTypeDepthLimit(t *testing.T) {
+
+	t.Parallel()
+
+	nestedArrayType := func(depth int) string {
+		return strings.Repeat("[", depth) + "Int" + strings.Repeat("]", depth)
+	}
+
+	check := func(code string, limit int) error {
+		_, err := ParseAndCheckWithOptions(t,
+			code,
+			ParseAndCheckOptions{
+				Options: []sema.Option{
+					sema.WithMaxTypeDepth(limit),
+				},
+			},
+		)
+		return err
+	}
+
+	t.Run("within limit", func(t *testing.T) {
+
+		t.Parallel()
+
+		// Two arrays and the element type
+
+		err := check(
+			"let xs: "+nestedArrayType(2)+" = []",
+			3,
+		)
+		require.NoError(t, err)
+	})
+
+	t.Run("exceeding limit", func(t *testing.T) {
+
+		t.Parallel()
+
+		err := check(
+			"let xs: "+nestedArrayType(3)+" = []",
+			3,
+		)
+
+		errs := ExpectCheckerErrors(t, err, 1)
+
+		var depthErr *sema.TypeDepthLimitError
+		require.ErrorAs(t, errs[0], &depthErr)
+
+		assert.Equal(t, 3, depthErr.Limit)
+		assert.Equal(t,
+			ast.Position{Offset: 11, Line: 1, Column: 11},
+			depthErr.StartPos,
+		)
+	})
+
+	t.Run("exceeding limit in function type", func(t *testing.T) {
+
+		t.Parallel()
+
+		err := check(
+			"fun test(xs: "+nestedArrayType(3)+") {}",
+			3,
+		)
+
+		errs := ExpectCheckerErrors(t, err, 1)
+
+		require.IsType(t, &sema.TypeDepthLimitError{}, errs[0])
+	})
+
+	t.Run("unlimited", func(t *testing.T) {
+
+		t.Parallel()
+
+		err := check(
+			"let xs: "+nestedArrayType(sema.DefaultMaxTypeDepth)+" = []",
+			0,
+		)
+		require.NoError(t, err)
+	})
+
+	t.Run("default limit", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := ParseAndCheck(t,
+			"let xs: "+nestedArrayType(sema.DefaultMaxTypeDepth)+" = []",
+		)
+
+		errs := ExpectCheckerErrors(t, err, 1)
+
+		require.IsType(t, &sema.TypeDepthLimitError{}, errs[0])
+	})
+}
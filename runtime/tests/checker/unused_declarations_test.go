@@ -0,0 +1,149 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2020 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package checker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckUnusedDeclarations(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("unused private function", func(t *testing.T) {
+
+		t.Parallel()
+
+		checker, err := ParseAndCheck(t, `
+          pub contract C {
+
+              access(self) fun helper(): Int {
+                  return 1
+              }
+
+              pub fun test(): Int {
+                  return 2
+              }
+          }
+        `)
+		require.NoError(t, err)
+
+		declarations := checker.UnusedDeclarations()
+		require.Len(t, declarations, 1)
+		assert.Equal(t,
+			"helper",
+			declarations[0].DeclarationIdentifier().Identifier,
+		)
+	})
+
+	t.Run("used private function", func(t *testing.T) {
+
+		t.Parallel()
+
+		checker, err := ParseAndCheck(t, `
+          pub contract C {
+
+              priv fun helper(): Int {
+                  return 1
+              }
+
+              pub fun test(): Int {
+                  return self.helper()
+              }
+          }
+        `)
+		require.NoError(t, err)
+
+		assert.Empty(t, checker.UnusedDeclarations())
+	})
+
+	t.Run("fields and contract access", func(t *testing.T) {
+
+		t.Parallel()
+
+		checker, err := ParseAndCheck(t, `
+          pub contract C {
+
+              access(contract) var used: Int
+              access(contract) var unused: Int
+              priv let unusedField: Int
+
+              access(contract) fun unusedFunction() {}
+
+              init() {
+                  self.used = 1
+                  self.unused = 2
+                  self.unusedField = 3
+              }
+
+              pub resource R {
+
+                  access(contract) fun test(): Int {
+                      return C.used
+                  }
+              }
+          }
+        `)
+		require.NoError(t, err)
+
+		declarations := checker.UnusedDeclarations()
+
+		var identifiers []string
+		for _, declaration := range declarations {
+			identifiers = append(
+				identifiers,
+				declaration.DeclarationIdentifier().Identifier,
+			)
+		}
+
+		// NOTE: assignments in the initializer are member accesses,
+		// so only the functions are unused
+
+		assert.Equal(t,
+			[]string{"unusedFunction", "test"},
+			identifiers,
+		)
+	})
+
+	t.Run("public declarations", func(t *testing.T) {
+
+		t.Parallel()
+
+		checker, err := ParseAndCheck(t, `
+          pub contract C {
+
+              pub var x: Int
+
+              pub fun test() {}
+
+              access(account) fun test2() {}
+
+              init() {
+                  self.x = 1
+              }
+          }
+        `)
+		require.NoError(t, err)
+
+		assert.Empty(t, checker.UnusedDeclarations())
+	})
+}
@@ -28,6 +28,7 @@ import (
 
 	. "github.com/onflow/cadence/runtime/tests/utils"
 
+	"github.com/onflow/cadence/runtime/ast"
 	"github.com/onflow/cadence/runtime/interpreter"
 	"github.com/onflow/cadence/runtime/sema"
 )
@@ -836,3 +837,188 @@ func TestInterpretSaturatedArithmeticFunctions(t *testing.T) {
 		test(ty, "Divide", testCase.divide)
 	}
 }
+
+func TestInterpretOverflowAndUnderflowErrors(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("overflow", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := parseCheckAndInterpret(t, `
+          fun test(): UInt8 {
+              let a: UInt8 = 255
+              let b: UInt8 = 1
+              return a + b
+          }
+        `)
+
+		_, err := inter.Invoke("test")
+		require.Error(t, err)
+
+		var overflowError interpreter.OverflowError
+		require.ErrorAs(t, err, &overflowError)
+
+		require.Equal(t,
+			interpreter.OverflowError{
+				Operation:  ast.OperationPlus,
+				LeftValue:  interpreter.UInt8Value(255),
+				RightValue: interpreter.UInt8Value(1),
+				Type:       interpreter.PrimitiveStaticTypeUInt8,
+			},
+			overflowError,
+		)
+		require.Equal(t, "overflow: 255 + 1 overflows UInt8", overflowError.Error())
+	})
+
+	t.Run("underflow", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := parseCheckAndInterpret(t, `
+          fun test(): Int8 {
+              let a: Int8 = -128
+              let b: Int8 = -1
+              return a + b
+          }
+        `)
+
+		_, err := inter.Invoke("test")
+		require.Error(t, err)
+
+		var underflowError interpreter.UnderflowError
+		require.ErrorAs(t, err, &underflowError)
+
+		require.Equal(t,
+			interpreter.UnderflowError{
+				Operation:  ast.OperationPlus,
+				LeftValue:  interpreter.Int8Value(-128),
+				RightValue: interpreter.Int8Value(-1),
+				Type:       interpreter.PrimitiveStaticTypeInt8,
+			},
+			underflowError,
+		)
+		require.Equal(t, "underflow: -128 + -1 underflows Int8", underflowError.Error())
+	})
+
+	t.Run("negate", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := parseCheckAndInterpret(t, `
+          fun test(): Int8 {
+              let a: Int8 = -128
+              return -a
+          }
+        `)
+
+		_, err := inter.Invoke("test")
+		require.Error(t, err)
+
+		var overflowError interpreter.OverflowError
+		require.ErrorAs(t, err, &overflowError)
+
+		require.Equal(t, "overflow: -(-128) overflows Int8", overflowError.Error())
+	})
+
+	t.Run("conversion", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := parseCheckAndInterpret(t, `
+          fun test(): UInt8 {
+              let a = 256
+              return UInt8(a)
+          }
+        `)
+
+		_, err := inter.Invoke("test")
+		require.Error(t, err)
+
+		var overflowError interpreter.OverflowError
+		require.ErrorAs(t, err, &overflowError)
+
+		require.Equal(t, "overflow: 256 overflows UInt8", overflowError.Error())
+	})
+
+	t.Run("subtract, underflow", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := parseCheckAndInterpret(t, `
+          fun test(): Int8 {
+              let a: Int8 = -128
+              let b: Int8 = 1
+              return a - b
+          }
+        `)
+
+		_, err := inter.Invoke("test")
+		require.Error(t, err)
+
+		var underflowError interpreter.UnderflowError
+		require.ErrorAs(t, err, &underflowError)
+
+		require.Equal(t, "underflow: -128 - 1 underflows Int8", underflowError.Error())
+	})
+
+	t.Run("subtract, overflow", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := parseCheckAndInterpret(t, `
+          fun test(): Int64 {
+              let a: Int64 = 9223372036854775807
+              let b: Int64 = -1
+              return a - b
+          }
+        `)
+
+		_, err := inter.Invoke("test")
+		require.Error(t, err)
+
+		var overflowError interpreter.OverflowError
+		require.ErrorAs(t, err, &overflowError)
+
+		require.Equal(t, "overflow: 9223372036854775807 - -1 overflows Int64", overflowError.Error())
+	})
+
+	t.Run("index", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := parseCheckAndInterpret(t, `
+          fun test(): Int {
+              let a = [1]
+              return a[-100000000000000000000]
+          }
+        `)
+
+		_, err := inter.Invoke("test")
+		require.Error(t, err)
+
+		var underflowError interpreter.UnderflowError
+		require.ErrorAs(t, err, &underflowError)
+
+		require.Equal(t, "underflow: -100000000000000000000 underflows Int64", underflowError.Error())
+	})
+
+	t.Run("index, UInt", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := parseCheckAndInterpret(t, `
+          fun test(): Int {
+              let a = [1]
+              let index: UInt = 0
+              return a[index]
+          }
+        `)
+
+		result, err := inter.Invoke("test")
+		require.NoError(t, err)
+
+		require.Equal(t, interpreter.NewIntValueFromInt64(1), result)
+	})
+}
@@ -922,6 +922,201 @@ func TestInterpretCapability_check(t *testing.T) {
 	})
 }
 
+func TestInterpretCapability_checkStatus(t *testing.T) {
+
+	t.Parallel()
+
+	address := interpreter.NewAddressValueFromBytes([]byte{42})
+
+	inter, _ := testAccount(
+		t,
+		address,
+		true,
+		`
+          resource R {}
+
+          resource R2 {}
+
+          fun saveAndLink() {
+              let r <- create R()
+              account.save(<-r, to: /storage/r)
+
+              account.link<&R>(/public/single, target: /storage/r)
+
+              account.link<&R>(/public/nonExistent, target: /storage/nonExistent)
+          }
+
+          fun checkStatus(_ capability: Capability): [Bool] {
+              let status = capability.checkStatus<&R>()
+              return [status.isLinked, status.isBorrowable]
+          }
+
+          fun single(): [Bool] {
+              return checkStatus(account.getCapability(/public/single))
+          }
+
+          fun singleR2(): [Bool] {
+              let status: CapabilityCheckResult = account.getCapability(/public/single).checkStatus<&R2>()
+              return [status.isLinked, status.isBorrowable]
+          }
+
+          fun nonExistent(): [Bool] {
+              return checkStatus(account.getCapability(/public/nonExistent))
+          }
+
+          fun unlinked(): [Bool] {
+              return checkStatus(account.getCapability(/public/unlinked))
+          }
+
+          fun singleTyped(): [Bool] {
+              let status = account.getCapability<&R>(/public/single).checkStatus()
+              return [status.isLinked, status.isBorrowable]
+          }
+        `,
+	)
+
+	_, err := inter.Invoke("saveAndLink")
+	require.NoError(t, err)
+
+	for name, expected := range map[string][]interpreter.Value{
+		"single":      {interpreter.BoolValue(true), interpreter.BoolValue(true)},
+		"singleR2":    {interpreter.BoolValue(true), interpreter.BoolValue(false)},
+		"nonExistent": {interpreter.BoolValue(true), interpreter.BoolValue(false)},
+		"unlinked":    {interpreter.BoolValue(false), interpreter.BoolValue(false)},
+		"singleTyped": {interpreter.BoolValue(true), interpreter.BoolValue(true)},
+	} {
+
+		t.Run(name, func(t *testing.T) {
+
+			value, err := inter.Invoke(name)
+			require.NoError(t, err)
+
+			require.IsType(t, &interpreter.ArrayValue{}, value)
+
+			AssertValueSlicesEqual(
+				t,
+				inter,
+				expected,
+				arrayElements(inter, value.(*interpreter.ArrayValue)),
+			)
+		})
+	}
+}
+
+func TestInterpretCapability_borrowOr(t *testing.T) {
+
+	t.Parallel()
+
+	address := interpreter.NewAddressValueFromBytes([]byte{42})
+
+	inter, _ := testAccount(
+		t,
+		address,
+		true,
+		`
+          resource interface I {
+              let value: Int
+          }
+
+          resource R: I {
+              let value: Int
+
+              init(value: Int) {
+                  self.value = value
+              }
+          }
+
+          fun saveAndLink() {
+              account.save(<-create R(value: 1), to: /storage/primary)
+              account.link<&R>(/public/primary, target: /storage/primary)
+
+              account.save(<-create R(value: 2), to: /storage/fallback)
+              account.link<&R>(/public/fallback, target: /storage/fallback)
+
+              account.link<&R>(/public/dangling, target: /storage/nonExistent)
+          }
+
+          fun primaryLive(): Int? {
+              let primary = account.getCapability(/public/primary)
+              let fallback = account.getCapability(/public/fallback)
+              return primary.borrowOr<&R>(fallback)?.value
+          }
+
+          fun primaryDangling(): Int? {
+              let primary = account.getCapability(/public/dangling)
+              let fallback = account.getCapability(/public/fallback)
+              return primary.borrowOr<&R>(fallback)?.value
+          }
+
+          fun primaryUnlinked(): Int? {
+              let primary = account.getCapability(/public/unlinked)
+              let fallback = account.getCapability(/public/fallback)
+              return primary.borrowOr<&R>(fallback)?.value
+          }
+
+          fun primaryDanglingTyped(): Int? {
+              let primary = account.getCapability<&R>(/public/dangling)
+              let fallback = account.getCapability<&R>(/public/fallback)
+              return primary.borrowOr(fallback)?.value
+          }
+
+          fun restrictedFallbackWider(): Int? {
+              let primary = account.getCapability(/public/dangling)
+              let fallback = account.getCapability<&R{I}>(/public/fallback)
+              return primary.borrowOr<&R>(fallback)?.value
+          }
+
+          fun restrictedFallback(): Int? {
+              let primary = account.getCapability(/public/dangling)
+              let fallback = account.getCapability<&R{I}>(/public/fallback)
+              return primary.borrowOr<&R{I}>(fallback)?.value
+          }
+
+          fun bothDangling(): Int? {
+              let primary = account.getCapability(/public/dangling)
+              let fallback = account.getCapability(/public/unlinked)
+              return primary.borrowOr<&R>(fallback)?.value
+          }
+        `,
+	)
+
+	_, err := inter.Invoke("saveAndLink")
+	require.NoError(t, err)
+
+	for name, expected := range map[string]interpreter.Value{
+		"primaryLive": interpreter.NewSomeValueNonCopying(
+			interpreter.NewIntValueFromInt64(1),
+		),
+		"primaryDangling": interpreter.NewSomeValueNonCopying(
+			interpreter.NewIntValueFromInt64(2),
+		),
+		"primaryUnlinked": interpreter.NewSomeValueNonCopying(
+			interpreter.NewIntValueFromInt64(2),
+		),
+		"primaryDanglingTyped": interpreter.NewSomeValueNonCopying(
+			interpreter.NewIntValueFromInt64(2),
+		),
+		// The fallback capability cannot be borrowed as a wider type than it was issued for
+		"restrictedFallbackWider": interpreter.NilValue{},
+		"restrictedFallback": interpreter.NewSomeValueNonCopying(
+			interpreter.NewIntValueFromInt64(2),
+		),
+		"bothDangling": interpreter.NilValue{},
+	} {
+
+		name := name
+		expected := expected
+
+		t.Run(name, func(t *testing.T) {
+
+			value, err := inter.Invoke(name)
+			require.NoError(t, err)
+
+			AssertValuesEqual(t, inter, expected, value)
+		})
+	}
+}
+
 func TestInterpretCapability_address(t *testing.T) {
 
 	t.Parallel()
@@ -3608,6 +3608,38 @@ func TestInterpretResourceConstructorCast(t *testing.T) {
 	}
 }
 
+func TestInterpretDynamicCastingUpcastRestrictedReference(t *testing.T) {
+
+	t.Parallel()
+
+	// A reference of the unrestricted type which is statically upcast to a restricted type
+	// can be cast back to the unrestricted type
+
+	for operation := range dynamicCastingOperations {
+		inter := parseCheckAndInterpret(t,
+			fmt.Sprintf(`
+                  resource interface RI {}
+
+                  resource R: RI {}
+
+                  fun test(): Bool {
+                      let r <- create R()
+                      let ref: &R{RI} = &r as &R
+                      let ref2 = (ref as AnyStruct) %s &R
+                      destroy r
+                      return ref2 != nil
+                  }
+                `,
+				operation.Symbol(),
+			),
+		)
+
+		result, err := inter.Invoke("test")
+		require.NoError(t, err)
+		require.Equal(t, interpreter.BoolValue(true), result)
+	}
+}
+
 func TestInterpretFunctionTypeCasting(t *testing.T) {
 
 	t.Parallel()
@@ -3454,6 +3454,67 @@ func TestInterpretOptionalMap(t *testing.T) {
 	})
 }
 
+func TestInterpretOptionalOrElse(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("some", func(t *testing.T) {
+
+		inter := parseCheckAndInterpret(t, `
+          var calls = 0
+
+          let one: Int? = 42
+          let result = one.orElse(fun (): Int {
+              calls = calls + 1
+              return 0
+          })
+        `)
+
+		AssertValuesEqual(
+			t,
+			inter,
+			interpreter.NewIntValueFromInt64(42),
+			inter.Globals["result"].GetValue(),
+		)
+
+		// The function is not called, as the optional is not nil
+
+		AssertValuesEqual(
+			t,
+			inter,
+			interpreter.NewIntValueFromInt64(0),
+			inter.Globals["calls"].GetValue(),
+		)
+	})
+
+	t.Run("nil", func(t *testing.T) {
+
+		inter := parseCheckAndInterpret(t, `
+          var calls = 0
+
+          let none: Int? = nil
+          let result = none.orElse(fun (): Int {
+              calls = calls + 1
+              return 0
+          })
+        `)
+
+		AssertValuesEqual(
+			t,
+			inter,
+			interpreter.NewIntValueFromInt64(0),
+			inter.Globals["result"].GetValue(),
+		)
+
+		AssertValuesEqual(
+			t,
+			inter,
+			interpreter.NewIntValueFromInt64(1),
+			inter.Globals["calls"].GetValue(),
+		)
+	})
+}
+
 func TestInterpretCompositeNilEquality(t *testing.T) {
 
 	t.Parallel()
@@ -5534,6 +5595,135 @@ func TestInterpretDictionaryValues(t *testing.T) {
 	)
 }
 
+func TestInterpretDictionaryMerging(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("disjoint keys", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := parseCheckAndInterpret(t, `
+          fun test(): [Int] {
+              let a = {"a": 1, "b": 2}
+              let b = {"c": 3}
+              let merged = a.merging(
+                  b,
+                  resolve: fun (existing: Int, new: Int): Int {
+                      return -1
+                  }
+              )
+              return [merged.length, merged["a"]!, merged["b"]!, merged["c"]!, a.length, b.length]
+          }
+        `)
+
+		value, err := inter.Invoke("test")
+		require.NoError(t, err)
+
+		arrayValue := value.(*interpreter.ArrayValue)
+
+		AssertValueSlicesEqual(
+			t,
+			inter,
+			[]interpreter.Value{
+				interpreter.NewIntValueFromInt64(3),
+				interpreter.NewIntValueFromInt64(1),
+				interpreter.NewIntValueFromInt64(2),
+				interpreter.NewIntValueFromInt64(3),
+				interpreter.NewIntValueFromInt64(2),
+				interpreter.NewIntValueFromInt64(1),
+			},
+			arrayElements(inter, arrayValue),
+		)
+	})
+
+	t.Run("colliding keys", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := parseCheckAndInterpret(t, `
+          fun test(): [Int] {
+              let a = {"a": 1, "b": 2}
+              let b = {"b": 30, "c": 4}
+              let merged = a.merging(
+                  b,
+                  resolve: fun (existing: Int, new: Int): Int {
+                      return existing * 100 + new
+                  }
+              )
+              return [merged.length, merged["a"]!, merged["b"]!, merged["c"]!, a["b"]!, b["b"]!]
+          }
+        `)
+
+		value, err := inter.Invoke("test")
+		require.NoError(t, err)
+
+		arrayValue := value.(*interpreter.ArrayValue)
+
+		AssertValueSlicesEqual(
+			t,
+			inter,
+			[]interpreter.Value{
+				interpreter.NewIntValueFromInt64(3),
+				interpreter.NewIntValueFromInt64(1),
+				interpreter.NewIntValueFromInt64(230),
+				interpreter.NewIntValueFromInt64(4),
+				interpreter.NewIntValueFromInt64(2),
+				interpreter.NewIntValueFromInt64(30),
+			},
+			arrayElements(inter, arrayValue),
+		)
+	})
+
+	t.Run("nested containers", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := parseCheckAndInterpret(t, `
+          fun test(): [Int] {
+              let a = {"a": [1, 2, 3], "b": [4]}
+              let b = {"b": [5, 6], "c": [7]}
+              let merged = a.merging(
+                  b,
+                  resolve: fun (existing: [Int], new: [Int]): [Int] {
+                      return existing.concat(new)
+                  }
+              )
+              merged["a"]!.append(10)
+              return [
+                  merged["a"]!.length,
+                  merged["b"]!.length,
+                  merged["c"]!.length,
+                  a["a"]!.length,
+                  a["b"]!.length,
+                  b["b"]!.length,
+                  b["c"]!.length
+              ]
+          }
+        `)
+
+		value, err := inter.Invoke("test")
+		require.NoError(t, err)
+
+		arrayValue := value.(*interpreter.ArrayValue)
+
+		AssertValueSlicesEqual(
+			t,
+			inter,
+			[]interpreter.Value{
+				interpreter.NewIntValueFromInt64(4),
+				interpreter.NewIntValueFromInt64(3),
+				interpreter.NewIntValueFromInt64(1),
+				interpreter.NewIntValueFromInt64(3),
+				interpreter.NewIntValueFromInt64(1),
+				interpreter.NewIntValueFromInt64(2),
+				interpreter.NewIntValueFromInt64(1),
+			},
+			arrayElements(inter, arrayValue),
+		)
+	})
+}
+
 func TestInterpretDictionaryKeyTypes(t *testing.T) {
 
 	t.Parallel()
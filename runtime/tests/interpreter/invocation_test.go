@@ -24,6 +24,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/onflow/cadence/runtime/interpreter"
+	. "github.com/onflow/cadence/runtime/tests/utils"
 )
 
 func TestInterpretFunctionInvocationCheckArgumentTypes(t *testing.T) {
@@ -41,3 +42,75 @@ func TestInterpretFunctionInvocationCheckArgumentTypes(t *testing.T) {
 
 	require.ErrorAs(t, err, &interpreter.ValueTransferTypeError{})
 }
+
+func TestInterpretGenericFunctionDeclarationInvocation(t *testing.T) {
+
+	t.Parallel()
+
+	inter := parseCheckAndInterpret(t, `
+       fun identity<T>(_ x: T): T {
+           return x
+       }
+
+       fun first<T>(_ xs: [T]): T? {
+           if xs.length == 0 {
+               return nil
+           }
+           return xs[0]
+       }
+
+       resource R {
+           let id: Int
+
+           init(id: Int) {
+               self.id = id
+           }
+       }
+
+       fun move<T: AnyResource>(_ r: @T): @T {
+           return <-r
+       }
+
+       let a = identity(1)
+       let b = identity<String>("two")
+       let c = first([3, 4])
+
+       fun test(): Int {
+           let r <- move(<-create R(id: 5))
+           let id = r.id
+           destroy r
+           return id
+       }
+   `)
+
+	AssertValuesEqual(
+		t,
+		inter,
+		interpreter.NewIntValueFromInt64(1),
+		inter.Globals["a"].GetValue(),
+	)
+
+	AssertValuesEqual(
+		t,
+		inter,
+		interpreter.NewStringValue("two"),
+		inter.Globals["b"].GetValue(),
+	)
+
+	AssertValuesEqual(
+		t,
+		inter,
+		interpreter.NewSomeValueNonCopying(interpreter.NewIntValueFromInt64(3)),
+		inter.Globals["c"].GetValue(),
+	)
+
+	result, err := inter.Invoke("test")
+	require.NoError(t, err)
+
+	AssertValuesEqual(
+		t,
+		inter,
+		interpreter.NewIntValueFromInt64(5),
+		result,
+	)
+}
@@ -562,6 +562,87 @@ func TestInterpretIsSubtype(t *testing.T) {
 	}
 }
 
+func TestInterpretIsStorable(t *testing.T) {
+
+	t.Parallel()
+
+	cases := []struct {
+		name   string
+		code   string
+		result bool
+	}{
+		{
+			name: "Int is storable",
+			code: `
+              let result = Type<Int>().isStorable
+            `,
+			result: true,
+		},
+		{
+			name: "Int? is storable",
+			code: `
+              let result = Type<Int?>().isStorable
+            `,
+			result: true,
+		},
+		{
+			name: "resource is storable",
+			code: `
+              resource R {}
+              let result = Type<@R>().isStorable
+            `,
+			result: true,
+		},
+		{
+			name: "reference is not storable",
+			code: `
+              let result = Type<&Int>().isStorable
+            `,
+			result: false,
+		},
+		{
+			name: "array of references is not storable",
+			code: `
+              let result = Type<[&Int]>().isStorable
+            `,
+			result: false,
+		},
+		{
+			name: "function is not storable",
+			code: `
+              let result = Type<((): Void)>().isStorable
+            `,
+			result: false,
+		},
+		{
+			name: "struct with reference field is not storable",
+			code: `
+              struct S {
+                  let ref: &Int
+
+                  init(ref: &Int) {
+                      self.ref = ref
+                  }
+              }
+
+              let result = Type<S>().isStorable
+            `,
+			result: false,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			inter := parseCheckAndInterpret(t, testCase.code)
+
+			assert.Equal(t,
+				interpreter.BoolValue(testCase.result),
+				inter.Globals["result"].GetValue(),
+			)
+		})
+	}
+}
+
 func TestInterpretGetType(t *testing.T) {
 
 	t.Parallel()
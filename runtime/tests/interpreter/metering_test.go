@@ -295,6 +295,158 @@ func TestInterpretLoopIterationHandler(t *testing.T) {
 	)
 }
 
+func TestInterpretLoopIterationLimit(t *testing.T) {
+
+	t.Parallel()
+
+	const maxLoopIterations = 10
+
+	test := func(t *testing.T, code string) {
+
+		checker, err := checker.ParseAndCheck(t, code)
+		require.NoError(t, err)
+
+		storage := interpreter.NewInMemoryStorage()
+
+		inter, err := interpreter.NewInterpreter(
+			interpreter.ProgramFromChecker(checker),
+			checker.Location,
+			interpreter.WithStorage(storage),
+			interpreter.WithMaxLoopIterations(maxLoopIterations),
+		)
+		require.NoError(t, err)
+
+		err = inter.Interpret()
+		require.NoError(t, err)
+
+		_, err = inter.Invoke("test")
+		require.Error(t, err)
+
+		var loopIterationLimitErr interpreter.LoopIterationLimitError
+		require.ErrorAs(t, err, &loopIterationLimitErr)
+
+		assert.Equal(t,
+			uint64(maxLoopIterations),
+			loopIterationLimitErr.Limit,
+		)
+	}
+
+	t.Run("while", func(t *testing.T) {
+
+		t.Parallel()
+
+		test(t, `
+          fun test() {
+              while true {}
+          }
+        `)
+	})
+
+	t.Run("for", func(t *testing.T) {
+
+		t.Parallel()
+
+		test(t, `
+          fun test() {
+              for i in [1, 2, 3, 4, 5, 6] {
+                  for j in [1, 2, 3] {}
+              }
+          }
+        `)
+	})
+}
+
+func TestInterpretEventLimit(t *testing.T) {
+
+	t.Parallel()
+
+	const code = `
+      event Minted(id: Int)
+
+      fun test(count: Int) {
+          var i = 0
+          while i < count {
+              emit Minted(id: i)
+              i = i + 1
+          }
+      }
+    `
+
+	test := func(t *testing.T, maxEvents uint64, count int) (*interpreter.Interpreter, error) {
+
+		checker, err := checker.ParseAndCheck(t, code)
+		require.NoError(t, err)
+
+		storage := interpreter.NewInMemoryStorage()
+
+		var emittedCount uint64
+
+		inter, err := interpreter.NewInterpreter(
+			interpreter.ProgramFromChecker(checker),
+			checker.Location,
+			interpreter.WithStorage(storage),
+			interpreter.WithMaxEvents(maxEvents),
+			interpreter.WithOnEventEmittedHandler(
+				func(
+					inter *interpreter.Interpreter,
+					_ func() interpreter.LocationRange,
+					_ *interpreter.CompositeValue,
+					_ *sema.CompositeType,
+				) error {
+					emittedCount++
+
+					// The count includes the event being emitted
+
+					assert.Equal(t, emittedCount, inter.EventCount())
+
+					return nil
+				},
+			),
+		)
+		require.NoError(t, err)
+
+		err = inter.Interpret()
+		require.NoError(t, err)
+
+		_, err = inter.Invoke("test", interpreter.NewIntValueFromInt64(int64(count)))
+
+		return inter, err
+	}
+
+	t.Run("within limit", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter, err := test(t, 10, 10)
+		require.NoError(t, err)
+
+		assert.Equal(t, uint64(10), inter.EventCount())
+	})
+
+	t.Run("exceeding limit", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := test(t, 10, 11)
+		require.Error(t, err)
+
+		var eventLimitErr interpreter.EventLimitExceededError
+		require.ErrorAs(t, err, &eventLimitErr)
+
+		assert.Equal(t, uint64(10), eventLimitErr.Limit)
+	})
+
+	t.Run("unlimited", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter, err := test(t, 0, 1000)
+		require.NoError(t, err)
+
+		assert.Equal(t, uint64(1000), inter.EventCount())
+	})
+}
+
 func TestInterpretFunctionInvocationHandler(t *testing.T) {
 
 	t.Parallel()
@@ -26,6 +26,7 @@ import (
 
 	"github.com/onflow/cadence/runtime/common"
 	"github.com/onflow/cadence/runtime/sema"
+	"github.com/onflow/cadence/runtime/stdlib"
 	"github.com/onflow/cadence/runtime/tests/checker"
 
 	"github.com/onflow/cadence/runtime/interpreter"
@@ -775,3 +776,223 @@ func TestInterpretReferenceUseAfterShiftStatementMove(t *testing.T) {
 		)
 	})
 }
+
+func TestInterpretReferenceToFunctionCallResult(t *testing.T) {
+
+	t.Parallel()
+
+	inter := parseCheckAndInterpret(t, `
+      struct S {
+          pub(set) var balance: UFix64
+
+          init() {
+              self.balance = 0.0
+          }
+      }
+
+      fun makeS(): S {
+          return S()
+      }
+
+      fun test(): UFix64 {
+          let ref = &makeS() as &S
+          ref.balance = 100.0
+          return ref.balance
+      }
+
+      fun testConstructor(): UFix64 {
+          let ref = &S() as &S
+          ref.balance = 42.0
+          return ref.balance
+      }
+    `)
+
+	value, err := inter.Invoke("test")
+	require.NoError(t, err)
+
+	AssertValuesEqual(
+		t,
+		inter,
+		interpreter.NewUFix64ValueWithInteger(100),
+		value,
+	)
+
+	value, err = inter.Invoke("testConstructor")
+	require.NoError(t, err)
+
+	AssertValuesEqual(
+		t,
+		inter,
+		interpreter.NewUFix64ValueWithInteger(42),
+		value,
+	)
+}
+
+func TestInterpretWeakReference(t *testing.T) {
+
+	t.Parallel()
+
+	standardLibraryFunctions := stdlib.StandardLibraryFunctions{
+		stdlib.WeakReferenceFunction,
+	}
+
+	inter, err := parseCheckAndInterpretWithOptions(t,
+		`
+          resource R {
+              let id: Int
+
+              init(id: Int) {
+                  self.id = id
+              }
+          }
+
+          fun test(): [AnyStruct] {
+              let r <- create R(id: 1)
+              let weak = weakReference(&r as &R)
+
+              let idBefore = weak()?.id
+              destroy r
+              let isNilAfter = weak() == nil
+
+              return [idBefore, isNilAfter]
+          }
+        `,
+		ParseCheckAndInterpretOptions{
+			CheckerOptions: []sema.Option{
+				sema.WithPredeclaredValues(standardLibraryFunctions.ToSemaValueDeclarations()),
+			},
+			Options: []interpreter.Option{
+				interpreter.WithPredeclaredValues(standardLibraryFunctions.ToInterpreterValueDeclarations()),
+			},
+		},
+	)
+	require.NoError(t, err)
+
+	value, err := inter.Invoke("test")
+	require.NoError(t, err)
+
+	require.IsType(t, &interpreter.ArrayValue{}, value)
+
+	AssertValueSlicesEqual(
+		t,
+		inter,
+		[]interpreter.Value{
+			interpreter.NewSomeValueNonCopying(interpreter.NewIntValueFromInt64(1)),
+			interpreter.BoolValue(true),
+		},
+		arrayElements(inter, value.(*interpreter.ArrayValue)),
+	)
+}
+
+func TestInterpretReferenceToOptionalChaining(t *testing.T) {
+
+	t.Parallel()
+
+	inter := parseCheckAndInterpret(t, `
+      struct Inner {
+          pub let value: Int
+
+          init(value: Int) {
+              self.value = value
+          }
+      }
+
+      struct Outer {
+          pub let inner: Inner?
+
+          init(inner: Inner?) {
+              self.inner = inner
+          }
+      }
+
+      fun test(_ outer: Outer?): Int? {
+          let ref = &outer?.inner as &Inner?
+          return ref?.value
+      }
+
+      fun testSome(): Int? {
+          return test(Outer(inner: Inner(value: 42)))
+      }
+
+      fun testNil(): Int? {
+          return test(nil)
+      }
+
+      fun testNestedSome(): Bool {
+          let outer: Outer? = Outer(inner: Inner(value: 42))
+          let ref = &outer?.inner?.value as &Int?
+          return ref != nil
+      }
+
+      fun testNestedNil(): Bool {
+          let outer: Outer? = Outer(inner: nil)
+          let ref = &outer?.inner?.value as &Int?
+          return ref == nil
+      }
+    `)
+
+	value, err := inter.Invoke("testSome")
+	require.NoError(t, err)
+
+	AssertValuesEqual(
+		t,
+		inter,
+		interpreter.NewSomeValueNonCopying(interpreter.NewIntValueFromInt64(42)),
+		value,
+	)
+
+	value, err = inter.Invoke("testNil")
+	require.NoError(t, err)
+
+	AssertValuesEqual(
+		t,
+		inter,
+		interpreter.NilValue{},
+		value,
+	)
+
+	for _, name := range []string{"testNestedSome", "testNestedNil"} {
+
+		value, err = inter.Invoke(name)
+		require.NoError(t, err)
+
+		AssertValuesEqual(
+			t,
+			inter,
+			interpreter.BoolValue(true),
+			value,
+		)
+	}
+}
+
+func TestInterpretReferenceToEphemeralResource(t *testing.T) {
+
+	t.Parallel()
+
+	inter, err := parseCheckAndInterpretWithOptions(t,
+		`
+          resource R {}
+
+          fun makeR(): @R {
+              return <-create R()
+          }
+
+          fun test() {
+              let ref = &makeR() as &R
+          }
+        `,
+		ParseCheckAndInterpretOptions{
+			HandleCheckerError: func(err error) {
+				errs := checker.ExpectCheckerErrors(t, err, 1)
+
+				require.IsType(t, &sema.EphemeralResourceReferenceError{}, errs[0])
+			},
+		},
+	)
+	require.NoError(t, err)
+
+	_, err = inter.Invoke("test")
+	require.Error(t, err)
+
+	require.ErrorAs(t, err, &interpreter.EphemeralResourceReferenceError{})
+}
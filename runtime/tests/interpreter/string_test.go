@@ -19,6 +19,7 @@
 package interpreter_test
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -130,6 +131,105 @@ func TestInterpretStringEncodeHex(t *testing.T) {
 	)
 }
 
+func TestInterpretArrayToHexString(t *testing.T) {
+
+	t.Parallel()
+
+	t.Run("round-trip", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := parseCheckAndInterpret(t, `
+          fun test(): [AnyStruct] {
+              let bytes: [UInt8] = [1, 2, 3, 0xCA, 0xDE]
+              let hex = bytes.toHexString()
+              return [hex, hex.decodeHex()]
+          }
+	    `)
+
+		result, err := inter.Invoke("test")
+		require.NoError(t, err)
+
+		require.IsType(t, &interpreter.ArrayValue{}, result)
+		results := arrayElements(inter, result.(*interpreter.ArrayValue))
+		require.Len(t, results, 2)
+
+		RequireValuesEqual(
+			t,
+			inter,
+			interpreter.NewStringValue("010203cade"),
+			results[0],
+		)
+
+		RequireValuesEqual(
+			t,
+			inter,
+			interpreter.NewArrayValue(
+				inter,
+				interpreter.VariableSizedStaticType{
+					Type: interpreter.PrimitiveStaticTypeUInt8,
+				},
+				common.Address{},
+				interpreter.UInt8Value(1),
+				interpreter.UInt8Value(2),
+				interpreter.UInt8Value(3),
+				interpreter.UInt8Value(0xCA),
+				interpreter.UInt8Value(0xDE),
+			),
+			results[1],
+		)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+
+		t.Parallel()
+
+		inter := parseCheckAndInterpret(t, `
+          fun test(): String {
+              let bytes: [UInt8] = []
+              return bytes.toHexString()
+          }
+	    `)
+
+		result, err := inter.Invoke("test")
+		require.NoError(t, err)
+
+		RequireValuesEqual(
+			t,
+			inter,
+			interpreter.NewStringValue(""),
+			result,
+		)
+	})
+
+	for name, code := range map[string]string{
+		"odd length": "ABC",
+		"non-hex":    "XY",
+	} {
+
+		code := code
+
+		t.Run(name, func(t *testing.T) {
+
+			t.Parallel()
+
+			inter := parseCheckAndInterpret(t, fmt.Sprintf(
+				`
+                  fun test(): [UInt8] {
+                      return "%s".decodeHex()
+                  }
+	            `,
+				code,
+			))
+
+			_, err := inter.Invoke("test")
+			require.Error(t, err)
+
+			require.ErrorAs(t, err, &interpreter.InvalidHexStringError{})
+		})
+	}
+}
+
 func TestInterpretStringUtf8Field(t *testing.T) {
 
 	t.Parallel()
@@ -151,3 +151,52 @@ func TestInterpretResourceUUID(t *testing.T) {
 		)
 	}
 }
+
+func TestInterpretArraySortedByUUID(t *testing.T) {
+
+	t.Parallel()
+
+	inter := parseCheckAndInterpret(t, `
+      resource R {}
+
+      fun test(): [UInt64] {
+          let r1 <- create R()
+          let r2 <- create R()
+          let r3 <- create R()
+
+          let rs <- [<-r3, <-r1, <-r2]
+          let sorted <- rs.sortedByUUID()
+
+          let uuids: [UInt64] = [UInt64(rs.length)]
+          var i = 0
+          while i < sorted.length {
+              uuids.append(sorted[i].uuid)
+              i = i + 1
+          }
+
+          destroy rs
+          destroy sorted
+
+          return uuids
+      }
+    `)
+
+	value, err := inter.Invoke("test")
+	require.NoError(t, err)
+
+	require.IsType(t, &interpreter.ArrayValue{}, value)
+
+	AssertValueSlicesEqual(
+		t,
+		inter,
+		[]interpreter.Value{
+			// the original array is empty
+			interpreter.UInt64Value(0),
+			// the resources are ordered by UUID
+			interpreter.UInt64Value(1),
+			interpreter.UInt64Value(2),
+			interpreter.UInt64Value(3),
+		},
+		arrayElements(inter, value.(*interpreter.ArrayValue)),
+	)
+}
@@ -0,0 +1,429 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2020 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cadence
+
+import (
+	"fmt"
+)
+
+// ValidationError is the error returned by ValidateAgainstType.
+// It reports the path to the (first) part of the value which does not conform to the type.
+//
+// The path starts with `$`, which denotes the validated value itself,
+// followed by field accesses (e.g. `.balance`) and index accesses (e.g. `[0]`).
+//
+type ValidationError struct {
+	Path    string
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("invalid value at %s: %s", e.Path, e.Message)
+}
+
+// ValidateAgainstType checks that the given value structurally conforms to the given type,
+// i.e. that the value has the expected kind, that composite values have the expected fields,
+// and that the elements of containers (arrays, dictionaries, optionals) conform to the element types.
+//
+// Abstract types (e.g. AnyStruct) accept any value.
+//
+// Values cannot be validated against interface types, restricted types with restrictions,
+// reference types, and function types:
+// Composite values do not declare the interfaces they conform to,
+// and references and functions cannot be represented as values.
+// For such types, a ValidationError is returned which reports that the type is not supported.
+//
+func ValidateAgainstType(value Value, t Type) error {
+	return validateAgainstType(value, t, "$")
+}
+
+func validateAgainstType(value Value, t Type, path string) error {
+
+	newError := func(format string, args ...interface{}) error {
+		return ValidationError{
+			Path:    path,
+			Message: fmt.Sprintf(format, args...),
+		}
+	}
+
+	newTypeMismatchError := func() error {
+		return newError("expected value of type %s, got %s", t.ID(), describeValue(value))
+	}
+
+	newUnsupportedTypeError := func() error {
+		return newError("cannot validate value against type %s: type is not supported", t.ID())
+	}
+
+	if value == nil {
+		return newError("missing value")
+	}
+
+	if t == nil {
+		return nil
+	}
+
+	switch t := t.(type) {
+	case AnyType, AnyStructType, AnyResourceType:
+		return nil
+
+	case OptionalType:
+		optional, ok := value.(Optional)
+		if !ok {
+			return newTypeMismatchError()
+		}
+		if optional.Value == nil {
+			return nil
+		}
+		return validateAgainstType(optional.Value, t.Type, path)
+
+	case VariableSizedArrayType:
+		array, ok := value.(Array)
+		if !ok {
+			return newTypeMismatchError()
+		}
+		return validateArrayElements(array, t.ElementType, path)
+
+	case ConstantSizedArrayType:
+		array, ok := value.(Array)
+		if !ok {
+			return newTypeMismatchError()
+		}
+		if uint(len(array.Values)) != t.Size {
+			return newError("expected %d elements, got %d", t.Size, len(array.Values))
+		}
+		return validateArrayElements(array, t.ElementType, path)
+
+	case DictionaryType:
+		dictionary, ok := value.(Dictionary)
+		if !ok {
+			return newTypeMismatchError()
+		}
+		for _, pair := range dictionary.Pairs {
+			keyPath := fmt.Sprintf("%s[%s]", path, pair.Key)
+
+			err := validateAgainstType(pair.Key, t.KeyType, keyPath)
+			if err != nil {
+				return err
+			}
+
+			err = validateAgainstType(pair.Value, t.ElementType, keyPath)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case *StructType:
+		structValue, ok := value.(Struct)
+		if !ok {
+			return newTypeMismatchError()
+		}
+		return validateCompositeFields(structValue.StructType, structValue.Fields, t, path)
+
+	case *ResourceType:
+		resource, ok := value.(Resource)
+		if !ok {
+			return newTypeMismatchError()
+		}
+		return validateCompositeFields(resource.ResourceType, resource.Fields, t, path)
+
+	case *EventType:
+		event, ok := value.(Event)
+		if !ok {
+			return newTypeMismatchError()
+		}
+		return validateCompositeFields(event.EventType, event.Fields, t, path)
+
+	case *ContractType:
+		contract, ok := value.(Contract)
+		if !ok {
+			return newTypeMismatchError()
+		}
+		return validateCompositeFields(contract.ContractType, contract.Fields, t, path)
+
+	case *EnumType:
+		enum, ok := value.(Enum)
+		if !ok {
+			return newTypeMismatchError()
+		}
+		return validateCompositeFields(enum.EnumType, enum.Fields, t, path)
+
+	case NumberType:
+		if !isIntegerValue(value) && !isFixedPointValue(value) {
+			return newTypeMismatchError()
+		}
+		return nil
+
+	case SignedNumberType:
+		if !isSignedIntegerValue(value) && !isSignedFixedPointValue(value) {
+			return newTypeMismatchError()
+		}
+		return nil
+
+	case IntegerType:
+		if !isIntegerValue(value) {
+			return newTypeMismatchError()
+		}
+		return nil
+
+	case SignedIntegerType:
+		if !isSignedIntegerValue(value) {
+			return newTypeMismatchError()
+		}
+		return nil
+
+	case FixedPointType:
+		if !isFixedPointValue(value) {
+			return newTypeMismatchError()
+		}
+		return nil
+
+	case SignedFixedPointType:
+		if !isSignedFixedPointValue(value) {
+			return newTypeMismatchError()
+		}
+		return nil
+
+	case PathType, StoragePathType, PublicPathType, PrivatePathType, CapabilityPathType:
+		pathValue, ok := value.(Path)
+		if !ok || !isPathInDomain(pathValue, t) {
+			return newTypeMismatchError()
+		}
+		return nil
+
+	case CapabilityType:
+		capability, ok := value.(Capability)
+		if !ok {
+			return newTypeMismatchError()
+		}
+		if t.BorrowType != nil &&
+			capability.BorrowType != nil &&
+			capability.BorrowType.ID() != t.BorrowType.ID() {
+
+			return newError(
+				"expected capability with borrow type %s, got %s",
+				t.BorrowType.ID(),
+				capability.BorrowType.ID(),
+			)
+		}
+		return nil
+
+	case MetaType:
+		if _, ok := value.(TypeValue); !ok {
+			return newTypeMismatchError()
+		}
+		return nil
+
+	case RestrictedType:
+		if len(t.Restrictions) > 0 {
+			return newUnsupportedTypeError()
+		}
+		return validateAgainstType(value, t.Type, path)
+
+	case InterfaceType, ReferenceType, FunctionType:
+		return newUnsupportedTypeError()
+	}
+
+	// All other types are validated by comparing them with the type of the value
+
+	valueType := value.Type()
+	if compositeType, ok := valueType.(CompositeType); ok {
+		valueType = nonNilCompositeType(compositeType)
+	}
+	if valueType == nil || valueType.ID() != t.ID() {
+		return newTypeMismatchError()
+	}
+
+	return nil
+}
+
+func validateArrayElements(array Array, elementType Type, path string) error {
+	for i, element := range array.Values {
+		err := validateAgainstType(element, elementType, fmt.Sprintf("%s[%d]", path, i))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateCompositeFields(
+	valueType CompositeType,
+	fields []Value,
+	expectedType CompositeType,
+	path string,
+) error {
+
+	expectedFields := expectedType.CompositeFields()
+
+	valueType = nonNilCompositeType(valueType)
+
+	// If the value has a type, it must be the expected type,
+	// and the fields must be in the same order
+
+	if valueType != nil {
+		if valueType.ID() != expectedType.ID() {
+			return ValidationError{
+				Path: path,
+				Message: fmt.Sprintf(
+					"expected value of type %s, got %s",
+					expectedType.ID(),
+					valueType.ID(),
+				),
+			}
+		}
+
+		valueFields := valueType.CompositeFields()
+		for i, field := range valueFields {
+			if i >= len(expectedFields) {
+				break
+			}
+
+			expectedIdentifier := expectedFields[i].Identifier
+			if field.Identifier != expectedIdentifier {
+				return ValidationError{
+					Path: path,
+					Message: fmt.Sprintf(
+						"expected field `%s`, got `%s`",
+						expectedIdentifier,
+						field.Identifier,
+					),
+				}
+			}
+		}
+	}
+
+	for i, expectedField := range expectedFields {
+		fieldPath := fmt.Sprintf("%s.%s", path, expectedField.Identifier)
+
+		if i >= len(fields) {
+			return ValidationError{
+				Path:    fieldPath,
+				Message: "missing field",
+			}
+		}
+
+		err := validateAgainstType(fields[i], expectedField.Type, fieldPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(fields) > len(expectedFields) {
+		return ValidationError{
+			Path: path,
+			Message: fmt.Sprintf(
+				"expected %d fields, got %d",
+				len(expectedFields),
+				len(fields),
+			),
+		}
+	}
+
+	return nil
+}
+
+func describeValue(value Value) string {
+	valueType := value.Type()
+	if compositeType, ok := valueType.(CompositeType); ok {
+		valueType = nonNilCompositeType(compositeType)
+	}
+	if valueType == nil {
+		return fmt.Sprintf("%T", value)
+	}
+	return valueType.ID()
+}
+
+// nonNilCompositeType returns the given composite type,
+// or nil if the composite type is a nil pointer.
+//
+func nonNilCompositeType(t CompositeType) CompositeType {
+	switch typedType := t.(type) {
+	case *StructType:
+		if typedType == nil {
+			return nil
+		}
+	case *ResourceType:
+		if typedType == nil {
+			return nil
+		}
+	case *EventType:
+		if typedType == nil {
+			return nil
+		}
+	case *ContractType:
+		if typedType == nil {
+			return nil
+		}
+	case *EnumType:
+		if typedType == nil {
+			return nil
+		}
+	}
+	return t
+}
+
+func isSignedIntegerValue(value Value) bool {
+	switch value.(type) {
+	case Int, Int8, Int16, Int32, Int64, Int128, Int256:
+		return true
+	default:
+		return false
+	}
+}
+
+func isIntegerValue(value Value) bool {
+	switch value.(type) {
+	case UInt, UInt8, UInt16, UInt32, UInt64, UInt128, UInt256,
+		Word8, Word16, Word32, Word64:
+		return true
+	default:
+		return isSignedIntegerValue(value)
+	}
+}
+
+func isSignedFixedPointValue(value Value) bool {
+	_, ok := value.(Fix64)
+	return ok
+}
+
+func isFixedPointValue(value Value) bool {
+	switch value.(type) {
+	case Fix64, UFix64:
+		return true
+	default:
+		return false
+	}
+}
+
+func isPathInDomain(path Path, t Type) bool {
+	switch t.(type) {
+	case StoragePathType:
+		return path.Domain == "storage"
+	case PublicPathType:
+		return path.Domain == "public"
+	case PrivatePathType:
+		return path.Domain == "private"
+	case CapabilityPathType:
+		return path.Domain == "public" ||
+			path.Domain == "private"
+	default:
+		return true
+	}
+}
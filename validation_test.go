@@ -0,0 +1,243 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2020 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cadence
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/cadence/runtime/tests/utils"
+)
+
+func TestValidateAgainstType(t *testing.T) {
+
+	t.Parallel()
+
+	fooType := &StructType{
+		Location:            utils.TestLocation,
+		QualifiedIdentifier: "Foo",
+		Fields: []Field{
+			{
+				Identifier: "name",
+				Type:       StringType{},
+			},
+			{
+				Identifier: "tags",
+				Type: VariableSizedArrayType{
+					ElementType: StringType{},
+				},
+			},
+			{
+				Identifier: "parent",
+				Type: OptionalType{
+					Type: AddressType{},
+				},
+			},
+		},
+	}
+
+	t.Run("valid struct", func(t *testing.T) {
+
+		t.Parallel()
+
+		value := NewStruct([]Value{
+			String("foo"),
+			NewArray([]Value{String("a"), String("b")}),
+			NewOptional(nil),
+		}).WithType(fooType)
+
+		err := ValidateAgainstType(value, fooType)
+		require.NoError(t, err)
+	})
+
+	t.Run("struct missing field", func(t *testing.T) {
+
+		t.Parallel()
+
+		value := NewStruct([]Value{
+			String("foo"),
+			NewArray([]Value{String("a"), String("b")}),
+		})
+
+		err := ValidateAgainstType(value, fooType)
+		require.Error(t, err)
+
+		var validationErr ValidationError
+		require.ErrorAs(t, err, &validationErr)
+
+		assert.Equal(t, "$.parent", validationErr.Path)
+	})
+
+	t.Run("invalid element", func(t *testing.T) {
+
+		t.Parallel()
+
+		value := NewStruct([]Value{
+			String("foo"),
+			NewArray([]Value{String("a"), NewInt(2)}),
+			NewOptional(Address{0x1}),
+		})
+
+		err := ValidateAgainstType(value, fooType)
+		require.Error(t, err)
+
+		var validationErr ValidationError
+		require.ErrorAs(t, err, &validationErr)
+
+		assert.Equal(t, "$.tags[1]", validationErr.Path)
+	})
+
+	t.Run("invalid optional", func(t *testing.T) {
+
+		t.Parallel()
+
+		value := NewStruct([]Value{
+			String("foo"),
+			NewArray([]Value{}),
+			NewOptional(String("bar")),
+		})
+
+		err := ValidateAgainstType(value, fooType)
+		require.Error(t, err)
+
+		var validationErr ValidationError
+		require.ErrorAs(t, err, &validationErr)
+
+		assert.Equal(t, "$.parent", validationErr.Path)
+	})
+
+	t.Run("dictionary", func(t *testing.T) {
+
+		t.Parallel()
+
+		dictionaryType := DictionaryType{
+			KeyType:     StringType{},
+			ElementType: IntegerType{},
+		}
+
+		err := ValidateAgainstType(
+			NewDictionary([]KeyValuePair{
+				{Key: String("a"), Value: NewInt(1)},
+				{Key: String("b"), Value: NewUInt8(2)},
+			}),
+			dictionaryType,
+		)
+		require.NoError(t, err)
+
+		err = ValidateAgainstType(
+			NewDictionary([]KeyValuePair{
+				{Key: String("a"), Value: NewInt(1)},
+				{Key: String("b"), Value: UFix64(100000000)},
+			}),
+			dictionaryType,
+		)
+		require.Error(t, err)
+
+		var validationErr ValidationError
+		require.ErrorAs(t, err, &validationErr)
+
+		assert.Equal(t, `$["b"]`, validationErr.Path)
+	})
+
+	t.Run("constant sized array", func(t *testing.T) {
+
+		t.Parallel()
+
+		err := ValidateAgainstType(
+			NewArray([]Value{NewInt(1)}),
+			ConstantSizedArrayType{
+				Size:        2,
+				ElementType: IntType{},
+			},
+		)
+		require.Error(t, err)
+	})
+
+	t.Run("path domain", func(t *testing.T) {
+
+		t.Parallel()
+
+		path := Path{
+			Domain:     "storage",
+			Identifier: "foo",
+		}
+
+		require.NoError(t, ValidateAgainstType(path, StoragePathType{}))
+		require.Error(t, ValidateAgainstType(path, PublicPathType{}))
+	})
+
+	t.Run("any struct", func(t *testing.T) {
+
+		t.Parallel()
+
+		err := ValidateAgainstType(NewInt(1), AnyStructType{})
+		require.NoError(t, err)
+	})
+
+	t.Run("unsupported types", func(t *testing.T) {
+
+		t.Parallel()
+
+		interfaceType := &StructInterfaceType{
+			Location:            utils.TestLocation,
+			QualifiedIdentifier: "I",
+		}
+
+		value := NewStruct([]Value{
+			String("foo"),
+			NewArray([]Value{}),
+			NewOptional(nil),
+		}).WithType(fooType)
+
+		for _, unsupportedType := range []Type{
+			interfaceType,
+			RestrictedType{
+				Type:         AnyStructType{},
+				Restrictions: []Type{interfaceType},
+			}.WithID("AnyStruct{S.test.I}"),
+			ReferenceType{
+				Type: fooType,
+			},
+		} {
+			err := ValidateAgainstType(value, unsupportedType)
+			require.Error(t, err)
+
+			var validationErr ValidationError
+			require.ErrorAs(t, err, &validationErr)
+
+			assert.Equal(t, "$", validationErr.Path)
+			assert.Contains(t, validationErr.Message, "not supported")
+		}
+	})
+
+	t.Run("unrestricted restricted type", func(t *testing.T) {
+
+		t.Parallel()
+
+		err := ValidateAgainstType(
+			NewInt(1),
+			RestrictedType{
+				Type: AnyStructType{},
+			},
+		)
+		require.NoError(t, err)
+	})
+}
@@ -22,6 +22,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"math/big"
+	"strings"
 	"unicode/utf8"
 
 	"github.com/onflow/cadence/fixedpoint"
@@ -905,6 +906,79 @@ func (v UFix64) String() string {
 	return format.UFix64(uint64(v))
 }
 
+// Add returns the sum of the two values.
+//
+// It returns an interpreter.OverflowError if the sum overflows,
+// matching the semantics of the `+` operator in Cadence programs.
+//
+func (v UFix64) Add(other UFix64) (UFix64, error) {
+	return ufix64Operation(v, other, interpreter.UFix64Value.Plus)
+}
+
+// Sub returns the difference of the two values.
+//
+// It returns an interpreter.UnderflowError if the difference underflows,
+// matching the semantics of the `-` operator in Cadence programs.
+//
+func (v UFix64) Sub(other UFix64) (UFix64, error) {
+	return ufix64Operation(v, other, interpreter.UFix64Value.Minus)
+}
+
+// Mul returns the product of the two values.
+//
+// It returns an interpreter.OverflowError if the product overflows,
+// matching the semantics of the `*` operator in Cadence programs.
+//
+func (v UFix64) Mul(other UFix64) (UFix64, error) {
+	return ufix64Operation(v, other, interpreter.UFix64Value.Mul)
+}
+
+// Div returns the quotient of the two values.
+//
+// It returns an interpreter.DivisionByZeroError if the divisor is zero,
+// and otherwise matches the semantics of the `/` operator in Cadence programs.
+//
+func (v UFix64) Div(other UFix64) (UFix64, error) {
+	if other == 0 {
+		return 0, interpreter.DivisionByZeroError{}
+	}
+	return ufix64Operation(v, other, interpreter.UFix64Value.Div)
+}
+
+// ufix64Operation performs the given operation of the interpreter,
+// so the arithmetic is exactly the same as the one of Cadence programs,
+// and returns the arithmetic errors instead of panicking.
+//
+func ufix64Operation(
+	left, right UFix64,
+	operation func(interpreter.UFix64Value, interpreter.NumberValue) interpreter.NumberValue,
+) (
+	result UFix64,
+	err error,
+) {
+	defer func() {
+		if r := recover(); r != nil {
+			switch r := r.(type) {
+			case interpreter.OverflowError,
+				interpreter.UnderflowError,
+				interpreter.DivisionByZeroError:
+
+				err = r.(error)
+
+			default:
+				panic(r)
+			}
+		}
+	}()
+
+	resultValue := operation(
+		interpreter.UFix64Value(left),
+		interpreter.UFix64Value(right),
+	)
+
+	return UFix64(resultValue.(interpreter.UFix64Value)), nil
+}
+
 // Array
 
 type Array struct {
@@ -1118,6 +1192,30 @@ func (v Event) WithType(typ *EventType) Event {
 	return v
 }
 
+// DeclaringLocation returns the location of the program which declared the event,
+// e.g. the address location of the contract which declared the event.
+//
+func (v Event) DeclaringLocation() (common.Location, error) {
+	if v.EventType == nil {
+		return nil, fmt.Errorf("event has no type")
+	}
+
+	location := v.EventType.Location
+	if location == nil {
+		return nil, fmt.Errorf("event type %s has no location", v.EventType.ID())
+	}
+
+	// The address location might not include the name of the contract.
+	// The first part of the qualified identifier is the name of the contract.
+
+	if addressLocation, ok := location.(common.AddressLocation); ok && addressLocation.Name == "" {
+		addressLocation.Name = strings.SplitN(v.EventType.QualifiedIdentifier, ".", 2)[0]
+		return addressLocation, nil
+	}
+
+	return location, nil
+}
+
 func (v Event) ToGoValue() interface{} {
 	ret := make([]interface{}, len(v.Fields))
 
@@ -1201,11 +1299,64 @@ func (v Link) String() string {
 
 // Path
 
+// Path is a path value.
+//
+// Prefer constructing paths with NewPath, which validates the domain and identifier.
+// A struct literal is not validated.
+//
 type Path struct {
 	Domain     string
 	Identifier string
 }
 
+// NewPath returns a path with the given domain and identifier.
+//
+// The domain must be one of `storage`, `private`, or `public`,
+// and the identifier must be a valid identifier,
+// i.e. a letter or underscore, followed by letters, digits, or underscores.
+//
+func NewPath(domain, identifier string) (Path, error) {
+	if common.PathDomainFromIdentifier(domain) == common.PathDomainUnknown {
+		return Path{}, fmt.Errorf("invalid domain in path: %s", domain)
+	}
+
+	if !isValidIdentifier(identifier) {
+		return Path{}, fmt.Errorf("invalid identifier in path: %s", identifier)
+	}
+
+	return Path{
+		Domain:     domain,
+		Identifier: identifier,
+	}, nil
+}
+
+func isValidIdentifier(identifier string) bool {
+	if identifier == "" {
+		return false
+	}
+
+	for i, r := range identifier {
+		switch {
+		case r >= 'a' && r <= 'z',
+			r >= 'A' && r <= 'Z',
+			r == '_':
+
+			continue
+
+		case r >= '0' && r <= '9':
+			// Identifiers must not start with a digit
+			if i == 0 {
+				return false
+			}
+
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
 func (Path) isValue() {}
 
 func (Path) Type() Type {
@@ -1255,6 +1406,10 @@ type Capability struct {
 	Path       Path
 	Address    Address
 	BorrowType Type
+	// Chain is the chain of paths through which the capability is linked.
+	// It is empty unless the capability was resolved when it was read,
+	// see runtime.Context.ResolveCapabilityChains
+	Chain CapabilityChain
 }
 
 func (Capability) isValue() {}
@@ -1275,6 +1430,61 @@ func (v Capability) String() string {
 	)
 }
 
+// CapabilityChain is the chain of paths through which a capability is linked,
+// starting with the path of the capability, and ending with the path of the final target.
+//
+// The paths are encoded in a string, so chains, and capabilities which include them,
+// are comparable by value, e.g. using == or as map keys.
+// The zero value is the empty chain.
+//
+type CapabilityChain struct {
+	// paths are the encoded paths, separated by capabilityChainSeparator.
+	// Neither path domains nor identifiers may contain the separators
+	paths string
+}
+
+const capabilityChainSeparator = " "
+
+const capabilityChainPathSeparator = "/"
+
+func NewCapabilityChain(paths []Path) CapabilityChain {
+	encodedPaths := make([]string, len(paths))
+	for i, path := range paths {
+		encodedPaths[i] = path.Domain + capabilityChainPathSeparator + path.Identifier
+	}
+
+	return CapabilityChain{
+		paths: strings.Join(encodedPaths, capabilityChainSeparator),
+	}
+}
+
+// IsEmpty returns true if the chain has no paths.
+//
+func (c CapabilityChain) IsEmpty() bool {
+	return c.paths == ""
+}
+
+// Paths returns the paths of the chain, or nil if the chain is empty.
+//
+func (c CapabilityChain) Paths() []Path {
+	if c.IsEmpty() {
+		return nil
+	}
+
+	encodedPaths := strings.Split(c.paths, capabilityChainSeparator)
+
+	paths := make([]Path, len(encodedPaths))
+	for i, encodedPath := range encodedPaths {
+		parts := strings.SplitN(encodedPath, capabilityChainPathSeparator, 2)
+		paths[i] = Path{
+			Domain:     parts[0],
+			Identifier: parts[1],
+		}
+	}
+
+	return paths
+}
+
 // Enum
 type Enum struct {
 	EnumType *EnumType
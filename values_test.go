@@ -20,6 +20,7 @@ package cadence
 
 import (
 	"fmt"
+	"math"
 	"math/big"
 	"testing"
 	"unicode/utf8"
@@ -27,6 +28,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/cadence/runtime/interpreter"
 	"github.com/onflow/cadence/runtime/sema"
 	"github.com/onflow/cadence/runtime/tests/utils"
 )
@@ -509,6 +512,50 @@ func TestNonUTF8String(t *testing.T) {
 	assert.Contains(t, err.Error(), "invalid UTF-8 in string")
 }
 
+func TestNewPath(t *testing.T) {
+
+	t.Run("valid", func(t *testing.T) {
+
+		for _, domain := range []string{"storage", "private", "public"} {
+			for _, identifier := range []string{"test", "_test", "Test_2"} {
+
+				path, err := NewPath(domain, identifier)
+				require.NoError(t, err)
+
+				assert.Equal(t,
+					Path{
+						Domain:     domain,
+						Identifier: identifier,
+					},
+					path,
+				)
+			}
+		}
+	})
+
+	t.Run("invalid domain", func(t *testing.T) {
+
+		for _, domain := range []string{"", "foo", "Storage"} {
+
+			_, err := NewPath(domain, "test")
+			require.Error(t, err)
+
+			assert.Contains(t, err.Error(), "invalid domain in path")
+		}
+	})
+
+	t.Run("invalid identifier", func(t *testing.T) {
+
+		for _, identifier := range []string{"", "2test", "te-st", "te/st", "tést"} {
+
+			_, err := NewPath("storage", identifier)
+			require.Error(t, err)
+
+			assert.Contains(t, err.Error(), "invalid identifier in path")
+		}
+	})
+}
+
 func TestNewInt128FromBig(t *testing.T) {
 
 	_, err := NewInt128FromBig(big.NewInt(1))
@@ -599,3 +646,259 @@ func TestNewUInt256FromBig(t *testing.T) {
 	_, err = NewUInt256FromBig(aboveMax)
 	require.Error(t, err)
 }
+
+func TestUFix64Arithmetic(t *testing.T) {
+
+	t.Parallel()
+
+	ufix64 := func(s string) UFix64 {
+		v, err := NewUFix64(s)
+		require.NoError(t, err)
+		return v
+	}
+
+	maxValue := UFix64(math.MaxUint64)
+
+	t.Run("add", func(t *testing.T) {
+		t.Parallel()
+
+		result, err := ufix64("1.5").Add(ufix64("0.25"))
+		require.NoError(t, err)
+		assert.Equal(t, ufix64("1.75"), result)
+
+		_, err = maxValue.Add(ufix64("0.00000001"))
+		require.ErrorAs(t, err, &interpreter.OverflowError{})
+	})
+
+	t.Run("sub", func(t *testing.T) {
+		t.Parallel()
+
+		result, err := ufix64("1.5").Sub(ufix64("0.25"))
+		require.NoError(t, err)
+		assert.Equal(t, ufix64("1.25"), result)
+
+		_, err = ufix64("0.25").Sub(ufix64("1.5"))
+		require.ErrorAs(t, err, &interpreter.UnderflowError{})
+	})
+
+	t.Run("mul", func(t *testing.T) {
+		t.Parallel()
+
+		result, err := ufix64("1.5").Mul(ufix64("0.25"))
+		require.NoError(t, err)
+		assert.Equal(t, ufix64("0.375"), result)
+
+		// The result is truncated to 8 decimal places
+
+		result, err = ufix64("0.00000001").Mul(ufix64("0.5"))
+		require.NoError(t, err)
+		assert.Equal(t, ufix64("0.0"), result)
+
+		_, err = maxValue.Mul(ufix64("2.0"))
+		require.ErrorAs(t, err, &interpreter.OverflowError{})
+	})
+
+	t.Run("div", func(t *testing.T) {
+		t.Parallel()
+
+		result, err := ufix64("1.5").Div(ufix64("0.25"))
+		require.NoError(t, err)
+		assert.Equal(t, ufix64("6.0"), result)
+
+		result, err = ufix64("1.0").Div(ufix64("3.0"))
+		require.NoError(t, err)
+		assert.Equal(t, ufix64("0.33333333"), result)
+
+		_, err = ufix64("1.0").Div(0)
+		require.ErrorAs(t, err, &interpreter.DivisionByZeroError{})
+	})
+}
+
+func TestEvent_DeclaringLocation(t *testing.T) {
+
+	t.Parallel()
+
+	address := common.BytesToAddress([]byte{0x1})
+
+	t.Run("address location", func(t *testing.T) {
+
+		t.Parallel()
+
+		event := NewEvent(nil).WithType(&EventType{
+			Location: common.AddressLocation{
+				Address: address,
+				Name:    "Test",
+			},
+			QualifiedIdentifier: "Test.E",
+		})
+
+		location, err := event.DeclaringLocation()
+		require.NoError(t, err)
+
+		assert.Equal(t,
+			common.AddressLocation{
+				Address: address,
+				Name:    "Test",
+			},
+			location,
+		)
+	})
+
+	t.Run("address location without name", func(t *testing.T) {
+
+		t.Parallel()
+
+		event := NewEvent(nil).WithType(&EventType{
+			Location: common.AddressLocation{
+				Address: address,
+			},
+			QualifiedIdentifier: "Test.E",
+		})
+
+		location, err := event.DeclaringLocation()
+		require.NoError(t, err)
+
+		assert.Equal(t,
+			common.AddressLocation{
+				Address: address,
+				Name:    "Test",
+			},
+			location,
+		)
+	})
+
+	t.Run("transaction location", func(t *testing.T) {
+
+		t.Parallel()
+
+		event := NewEvent(nil).WithType(&EventType{
+			Location:            common.TransactionLocation{0x1},
+			QualifiedIdentifier: "E",
+		})
+
+		location, err := event.DeclaringLocation()
+		require.NoError(t, err)
+
+		assert.Equal(t, common.TransactionLocation{0x1}, location)
+	})
+
+	t.Run("missing location", func(t *testing.T) {
+
+		t.Parallel()
+
+		event := NewEvent(nil).WithType(&EventType{
+			QualifiedIdentifier: "E",
+		})
+
+		_, err := event.DeclaringLocation()
+		require.Error(t, err)
+	})
+
+	t.Run("missing type", func(t *testing.T) {
+
+		t.Parallel()
+
+		_, err := NewEvent(nil).DeclaringLocation()
+		require.Error(t, err)
+	})
+}
+
+func TestCapabilityChain(t *testing.T) {
+
+	t.Parallel()
+
+	paths := []Path{
+		{
+			Domain:     "public",
+			Identifier: "foo",
+		},
+		{
+			Domain:     "storage",
+			Identifier: "foo",
+		},
+	}
+
+	chain := NewCapabilityChain(paths)
+
+	t.Run("immutable", func(t *testing.T) {
+
+		t.Parallel()
+
+		paths[0].Identifier = "bar"
+		chain.Paths()[1].Identifier = "bar"
+
+		assert.Equal(t,
+			[]Path{
+				{
+					Domain:     "public",
+					Identifier: "foo",
+				},
+				{
+					Domain:     "storage",
+					Identifier: "foo",
+				},
+			},
+			chain.Paths(),
+		)
+	})
+
+	t.Run("comparable", func(t *testing.T) {
+
+		t.Parallel()
+
+		newCapability := func() Capability {
+			return Capability{
+				Path: Path{
+					Domain:     "public",
+					Identifier: "foo",
+				},
+				Address:    Address{0x1},
+				BorrowType: ReferenceType{Type: IntType{}},
+				Chain: NewCapabilityChain([]Path{
+					{
+						Domain:     "public",
+						Identifier: "foo",
+					},
+					{
+						Domain:     "storage",
+						Identifier: "foo",
+					},
+				}),
+			}
+		}
+
+		capability := newCapability()
+
+		// Capabilities with separately created, but equal chains are equal
+
+		assert.True(t, capability == newCapability())
+
+		values := map[Value]struct{}{
+			capability: {},
+		}
+
+		_, ok := values[newCapability()]
+		assert.True(t, ok)
+
+		// Capabilities with different chains are not equal
+
+		otherCapability := newCapability()
+		otherCapability.Chain = NewCapabilityChain([]Path{
+			{
+				Domain:     "public",
+				Identifier: "foo",
+			},
+		})
+
+		assert.False(t, capability == otherCapability)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+
+		t.Parallel()
+
+		assert.True(t, CapabilityChain{}.IsEmpty())
+		assert.Nil(t, CapabilityChain{}.Paths())
+		assert.False(t, chain.IsEmpty())
+	})
+}
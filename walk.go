@@ -0,0 +1,97 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2020 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cadence
+
+// Walk traverses the given value and all values nested in it in depth-first, pre-order:
+// The function visit is called for the value, and then for the nested values, recursively, in order.
+// If visit returns false, the walk stops, i.e. no further values are visited.
+//
+// The nested values are:
+//   - the value of an optional, if any
+//   - the elements of an array
+//   - the keys and values of a dictionary, alternating, in order of the pairs
+//   - the fields of a composite value (struct, resource, event, contract, or enum)
+//   - the address and the path of a capability
+//   - the target path of a link
+//
+// Exported values are trees, i.e. they never contain cycles, so the walk always terminates.
+//
+func Walk(value Value, visit func(Value) bool) {
+	walk(value, visit)
+}
+
+// walk walks the given value, and returns false if the walk was stopped.
+//
+func walk(value Value, visit func(Value) bool) bool {
+	if !visit(value) {
+		return false
+	}
+
+	switch value := value.(type) {
+	case Optional:
+		if value.Value != nil {
+			return walk(value.Value, visit)
+		}
+
+	case Array:
+		return walkValues(value.Values, visit)
+
+	case Dictionary:
+		for _, pair := range value.Pairs {
+			if !walk(pair.Key, visit) ||
+				!walk(pair.Value, visit) {
+
+				return false
+			}
+		}
+
+	case Struct:
+		return walkValues(value.Fields, visit)
+
+	case Resource:
+		return walkValues(value.Fields, visit)
+
+	case Event:
+		return walkValues(value.Fields, visit)
+
+	case Contract:
+		return walkValues(value.Fields, visit)
+
+	case Enum:
+		return walkValues(value.Fields, visit)
+
+	case Capability:
+		return walk(value.Address, visit) &&
+			walk(value.Path, visit)
+
+	case Link:
+		return walk(value.TargetPath, visit)
+	}
+
+	return true
+}
+
+func walkValues(values []Value, visit func(Value) bool) bool {
+	for _, value := range values {
+		if !walk(value, visit) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,133 @@
+/*
+ * Cadence - The resource-oriented smart contract programming language
+ *
+ * Copyright 2019-2020 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cadence
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWalk(t *testing.T) {
+
+	t.Parallel()
+
+	path := Path{
+		Domain:     "public",
+		Identifier: "foo",
+	}
+
+	capability := Capability{
+		Path:    path,
+		Address: BytesToAddress([]byte{0x1}),
+	}
+
+	structValue := NewStruct([]Value{
+		NewInt(1),
+		NewOptional(capability),
+	})
+
+	dictionary := NewDictionary([]KeyValuePair{
+		{
+			Key:   String("a"),
+			Value: structValue,
+		},
+		{
+			Key:   String("b"),
+			Value: NewOptional(nil),
+		},
+	})
+
+	array := NewArray([]Value{
+		dictionary,
+		NewLink(path, "&Int"),
+	})
+
+	t.Run("all", func(t *testing.T) {
+
+		t.Parallel()
+
+		var visited []Value
+		Walk(array, func(value Value) bool {
+			visited = append(visited, value)
+			return true
+		})
+
+		assert.Equal(t,
+			[]Value{
+				array,
+				dictionary,
+				String("a"),
+				structValue,
+				NewInt(1),
+				NewOptional(capability),
+				capability,
+				BytesToAddress([]byte{0x1}),
+				path,
+				String("b"),
+				NewOptional(nil),
+				NewLink(path, "&Int"),
+				path,
+			},
+			visited,
+		)
+	})
+
+	t.Run("stop", func(t *testing.T) {
+
+		t.Parallel()
+
+		var visited []Value
+		Walk(array, func(value Value) bool {
+			visited = append(visited, value)
+			_, isStruct := value.(Struct)
+			return !isStruct
+		})
+
+		assert.Equal(t,
+			[]Value{
+				array,
+				dictionary,
+				String("a"),
+				structValue,
+			},
+			visited,
+		)
+	})
+
+	t.Run("stop in dictionary key", func(t *testing.T) {
+
+		t.Parallel()
+
+		var visited []Value
+		Walk(array, func(value Value) bool {
+			visited = append(visited, value)
+			return value != String("a")
+		})
+
+		assert.Equal(t,
+			[]Value{
+				array,
+				dictionary,
+				String("a"),
+			},
+			visited,
+		)
+	})
+}